@@ -0,0 +1,144 @@
+package web
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Types of accessibility issues.
+const (
+	A11yMissingAlt   = "missing-alt"   // image without alt text
+	A11yEmptyLink    = "empty-link"    // link without an accessible name
+	A11yEmptyButton  = "empty-button"  // button without an accessible name
+	A11yHeadingOrder = "heading-order" // heading level skipped
+	A11yMissingLang  = "missing-lang"  // document language not declared
+)
+
+// maxIssueElementLength limits the length of AccessibilityIssue.Element.
+const maxIssueElementLength = 120
+
+// AccessibilityIssue is a problem found by Document.AccessibilityIssues.
+type AccessibilityIssue struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+
+	// Element is the start tag of the offending element, truncated, if any.
+	Element string `json:"element,omitempty"`
+}
+
+// AccessibilityIssues audits the document for common accessibility problems
+// detectable from its HTML: images without alt text, links and buttons
+// without an accessible name, skipped heading levels and a missing document
+// language. Elements hidden with the hidden or aria-hidden attributes are
+// ignored. This is not a substitute for a full audit, as problems depending
+// on styles and scripts can't be detected.
+func (d *Document) AccessibilityIssues() []*AccessibilityIssue {
+	return memoize(d, "accessibility", func() []*AccessibilityIssue {
+		issues := []*AccessibilityIssue{}
+		add := func(issueType string, s *goquery.Selection, format string, args ...any) {
+			issue := &AccessibilityIssue{Type: issueType, Message: fmt.Sprintf(format, args...)}
+			if s != nil {
+				issue.Element = startTag(s)
+			}
+			issues = append(issues, issue)
+		}
+
+		html := d.doc.Find("html").First()
+		if strings.TrimSpace(html.AttrOr("lang", html.AttrOr("xml:lang", ""))) == "" {
+			add(A11yMissingLang, nil, "the html element has no lang attribute")
+		}
+
+		d.doc.Find(`img, input[type="image" i], area[href]`).Each(func(_ int, s *goquery.Selection) {
+			if _, ok := s.Attr("alt"); ok || hiddenFromAccessibility(s) || labeled(s) {
+				return
+			}
+			switch strings.ToLower(s.AttrOr("role", "")) {
+			case "presentation", "none":
+				return
+			}
+			add(A11yMissingAlt, s, "%s has no alt attribute", goquery.NodeName(s))
+		})
+
+		d.doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+			if !hiddenFromAccessibility(s) && !hasAccessibleName(s) {
+				add(A11yEmptyLink, s, "link has no text or label")
+			}
+		})
+
+		d.doc.Find(`button, input[type="button" i], [role="button" i]`).Each(func(_ int, s *goquery.Selection) {
+			if goquery.NodeName(s) == "input" && strings.TrimSpace(s.AttrOr("value", "")) != "" {
+				return
+			}
+			if !hiddenFromAccessibility(s) && !hasAccessibleName(s) {
+				add(A11yEmptyButton, s, "button has no text or label")
+			}
+		})
+
+		previous := 0
+		d.doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, s *goquery.Selection) {
+			if hiddenFromAccessibility(s) {
+				return
+			}
+			level := int(goquery.NodeName(s)[1] - '0')
+			if previous > 0 && level > previous+1 {
+				add(A11yHeadingOrder, s, "h%d follows h%d, skipping a level", level, previous)
+			}
+			previous = level
+		})
+		return issues
+	})
+}
+
+// hiddenFromAccessibility returns true if an element or one of its ancestors
+// is hidden from assistive technologies.
+func hiddenFromAccessibility(s *goquery.Selection) bool {
+	return s.Closest(`[hidden], [aria-hidden="true" i]`).Length() > 0
+}
+
+// labeled returns true if an element is labeled by ARIA attributes or a
+// title.
+func labeled(s *goquery.Selection) bool {
+	for _, attr := range []string{"aria-label", "aria-labelledby", "title"} {
+		if strings.TrimSpace(s.AttrOr(attr, "")) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAccessibleName returns true if an element has text, a label, or an
+// image with alt text.
+func hasAccessibleName(s *goquery.Selection) bool {
+	if labeled(s) || strings.TrimSpace(s.Text()) != "" {
+		return true
+	}
+	named := false
+	s.Find(`img[alt], svg title, [aria-label]`).EachWithBreak(func(_ int, child *goquery.Selection) bool {
+		name := child.AttrOr("alt", child.AttrOr("aria-label", child.Text()))
+		named = strings.TrimSpace(name) != ""
+		return !named
+	})
+	return named
+}
+
+// startTag returns the start tag of an element, truncated.
+func startTag(s *goquery.Selection) string {
+	var tag strings.Builder
+	tag.WriteString("<" + goquery.NodeName(s))
+	for _, attr := range s.Nodes[0].Attr {
+		fmt.Fprintf(&tag, " %s=%q", attr.Key, attr.Val)
+	}
+	tag.WriteString(">")
+	result := tag.String()
+	if len(result) <= maxIssueElementLength {
+		return result
+	}
+	end := maxIssueElementLength - 3
+	for end > 0 && !utf8.RuneStart(result[end]) {
+		end--
+	}
+	return result[:end] + "..."
+}
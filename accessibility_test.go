@@ -0,0 +1,45 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_AccessibilityIssues(t *testing.T) {
+	doc, err := NewDocument(`<html><body>
+		<h1>Title</h1>
+		<img src="/logo.png">
+		<img src="/spacer.gif" alt="">
+		<img src="/chart.png" role="presentation">
+		<a href="/home"></a>
+		<a href="/about">About</a>
+		<a href="/profile"><img src="/avatar.png" alt="Profile"></a>
+		<a href="/search" aria-label="Search"><svg></svg></a>
+		<button></button>
+		<button>Save</button>
+		<input type="button" value="Cancel">
+		<input type="submit">
+		<div role="button" title="Close"></div>
+		<h3>Details</h3>
+		<h2>Section</h2>
+		<div hidden><a href="/hidden"></a><img src="/hidden.png"></div>
+	</body></html>`)
+	require.NoError(t, err)
+
+	var types []string
+	for _, issue := range doc.AccessibilityIssues() {
+		types = append(types, issue.Type)
+	}
+	require.Equal(t, []string{A11yMissingLang, A11yMissingAlt, A11yEmptyLink, A11yEmptyButton, A11yHeadingOrder}, types)
+
+	issues := doc.AccessibilityIssues()
+	require.Equal(t, `<img src="/logo.png">`, issues[1].Element)
+	require.Equal(t, `<a href="/home">`, issues[2].Element)
+	require.Equal(t, "h3 follows h1, skipping a level", issues[4].Message)
+
+	// No issues for an accessible document
+	doc, err = NewDocument(`<html lang="en"><body><h1>Title</h1><h2>Section</h2><a href="/">Home</a></body></html>`)
+	require.NoError(t, err)
+	require.Empty(t, doc.AccessibilityIssues())
+}
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"strings"
+	"time"
+)
+
+// ArticleTypes are the schema.org types treated as articles.
+var ArticleTypes = []string{
+	"Article",
+	"NewsArticle",
+	"AnalysisNewsArticle",
+	"OpinionNewsArticle",
+	"ReportageNewsArticle",
+	"BlogPosting",
+	"LiveBlogPosting",
+	"TechArticle",
+	"ScholarlyArticle",
+	"Report",
+}
+
+// Article contains schema.org Article data extracted from a page.
+type Article struct {
+	Type          string    `json:"type,omitempty"` // e.g. "NewsArticle"
+	Headline      string    `json:"headline,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	Authors       []string  `json:"authors,omitempty"`
+	Publisher     string    `json:"publisher,omitempty"`
+	DatePublished time.Time `json:"date_published,omitzero"`
+	DateModified  time.Time `json:"date_modified,omitzero"`
+	Section       string    `json:"section,omitempty"`
+	Keywords      []string  `json:"keywords,omitempty"`
+	Images        []string  `json:"images,omitempty"`
+	URL           string    `json:"url,omitempty"`
+	Language      string    `json:"language,omitempty"`
+	Body          string    `json:"body,omitempty"`
+	WordCount     int       `json:"word_count,omitempty"`
+}
+
+// ExtractArticle returns the article described by the document, or nil if
+// the page does not look like an article. Structured data is preferred, with
+// Open Graph and article meta tags filling any gaps. The body is the text of
+// the main content of the page.
+func ExtractArticle(doc *Document) *Article {
+	items := FindStructuredItems(doc.JSONLD(), ArticleTypes...)
+	items = append(items, FindStructuredItems(doc.Microdata(), ArticleTypes...)...)
+	isArticle := len(items) > 0 ||
+		strings.EqualFold(doc.metaContent("og:type"), "article") ||
+		doc.metaContent("article:published_time") != "" ||
+		len(doc.doc.Find("article").Nodes) > 0
+	if !isArticle {
+		return nil
+	}
+	article := &Article{Type: "Article"}
+	if len(items) > 0 {
+		article = articleFromItem(items[0])
+	}
+	fillString(&article.Headline, doc.metaContent("og:title"))
+	fillString(&article.Headline, doc.Title())
+	fillString(&article.Description, doc.Description())
+	fillString(&article.Publisher, doc.metaContent("og:site_name"))
+	fillString(&article.Section, doc.metaContent("article:section"))
+	fillString(&article.URL, doc.CanonicalURL())
+	fillString(&article.Language, doc.Language())
+	if len(article.Authors) == 0 {
+		article.Authors = nonEmpty(doc.Author(), doc.metaContent("article:author"))
+	}
+	if article.DatePublished.IsZero() {
+		article.DatePublished = ParseTime(doc.metaContent("article:published_time", "og:published_time", "date"))
+	}
+	if article.DateModified.IsZero() {
+		article.DateModified = ParseTime(doc.metaContent("article:modified_time", "og:updated_time"))
+	}
+	if len(article.Keywords) == 0 {
+		article.Keywords = doc.Keywords()
+	}
+	if len(article.Images) == 0 {
+		article.Images = nonEmpty(doc.Image())
+	}
+	if article.Body == "" {
+		article.Body = doc.MainContentText()
+	}
+	article.WordCount = len(strings.Fields(article.Body))
+	return article
+}
+
+func articleFromItem(item map[string]any) *Article {
+	article := &Article{
+		Type:          SchemaTypes(item)[0],
+		Headline:      schemaString(item["headline"]),
+		Description:   schemaString(item["description"]),
+		Authors:       schemaStrings(item["author"]),
+		Publisher:     schemaString(item["publisher"]),
+		DatePublished: ParseTime(schemaString(item["datePublished"])),
+		DateModified:  ParseTime(schemaString(item["dateModified"])),
+		Section:       schemaString(item["articleSection"]),
+		Keywords:      schemaKeywords(item["keywords"]),
+		Images:        schemaURLs(item["image"]),
+		URL:           schemaString(item["url"]),
+		Language:      schemaString(item["inLanguage"]),
+		Body:          schemaString(item["articleBody"]),
+	}
+	if article.Headline == "" {
+		article.Headline = schemaString(item["name"])
+	}
+	if article.URL == "" {
+		article.URL = schemaString(item["mainEntityOfPage"])
+	}
+	article.WordCount = len(strings.Fields(article.Body))
+	return article
+}
+
+// schemaKeywords returns keywords given either as a list or as a single
+// comma separated string.
+func schemaKeywords(value any) []string {
+	if s, ok := value.(string); ok {
+		return parseKeywords(s)
+	}
+	return schemaStrings(value)
+}
+
+// timeLayouts are the date formats commonly found in structured data and
+// meta tags.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// ParseTime parses a date or timestamp in any of the formats commonly used in
+// structured data and meta tags. Returns the zero time if parsing fails.
+func ParseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
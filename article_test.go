@@ -0,0 +1,84 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractArticle_JSONLD(t *testing.T) {
+	doc, err := NewDocument(`
+		<html lang="en"><head>
+			<title>Ignored | News</title>
+			<meta property="og:site_name" content="Daily News">
+			<script type="application/ld+json">
+			{
+				"@context": "https://schema.org",
+				"@type": "NewsArticle",
+				"headline": "City Opens New Park",
+				"author": [{"@type": "Person", "name": "Ana Ruiz"}, {"@type": "Person", "name": "Ben Li"}],
+				"datePublished": "2024-03-01T08:00:00Z",
+				"dateModified": "2024-03-02",
+				"keywords": "parks, city",
+				"image": "https://example.com/park.jpg"
+			}
+			</script>
+		</head>
+		<body>
+			<nav><a href="/">Home</a></nav>
+			<article>
+				<h1>City Opens New Park</h1>
+				<p>The park opened on <b>Friday</b>.</p>
+				<p>It covers ten acres.</p>
+				<form><input name="q"></form>
+			</article>
+			<footer>Copyright</footer>
+		</body></html>
+	`)
+	require.NoError(t, err)
+
+	article := ExtractArticle(doc)
+	require.NotNil(t, article)
+	require.Equal(t, "NewsArticle", article.Type)
+	require.Equal(t, "City Opens New Park", article.Headline)
+	require.Equal(t, []string{"Ana Ruiz", "Ben Li"}, article.Authors)
+	require.Equal(t, "Daily News", article.Publisher)
+	require.Equal(t, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), article.DatePublished)
+	require.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), article.DateModified)
+	require.Equal(t, []string{"city", "parks"}, article.Keywords)
+	require.Equal(t, "en", article.Language)
+	require.Equal(t, "City Opens New Park\n\nThe park opened on Friday.\n\nIt covers ten acres.", article.Body)
+	require.Equal(t, 13, article.WordCount)
+}
+
+func TestExtractArticle_MetaFallback(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<title>Release Notes</title>
+			<meta property="og:type" content="article">
+			<meta name="author" content="Sam">
+			<meta property="article:published_time" content="2023-11-05T10:30:00+02:00">
+		</head>
+		<body><main><p>Version 2 is out.</p></main></body></html>
+	`)
+	require.NoError(t, err)
+
+	article := ExtractArticle(doc)
+	require.NotNil(t, article)
+	require.Equal(t, "Release Notes", article.Headline)
+	require.Equal(t, []string{"Sam"}, article.Authors)
+	require.Equal(t, "2023-11-05T08:30:00Z", article.DatePublished.UTC().Format(time.RFC3339))
+	require.Equal(t, "Version 2 is out.", article.Body)
+
+	doc, err = NewDocument(`<html><body><p>Just a page</p></body></html>`)
+	require.NoError(t, err)
+	require.Nil(t, ExtractArticle(doc))
+}
+
+func TestParseTime(t *testing.T) {
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ParseTime("2024-01-02T03:04:05Z"))
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ParseTime("2024-01-02 03:04:05"))
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), ParseTime(" 2024-01-02 "))
+	require.True(t, ParseTime("yesterday").IsZero())
+}
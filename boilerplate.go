@@ -0,0 +1,170 @@
+package web
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// BoilerplateOptions defines the options of a BoilerplateDetector.
+type BoilerplateOptions struct {
+	// Threshold is the fraction of pages (0-1) a block must appear on to be
+	// boilerplate. Defaults to 0.5.
+	Threshold float64
+
+	// MinPages is the number of pages to learn from before blocks are
+	// considered boilerplate. Defaults to 5.
+	MinPages int
+}
+
+// BoilerplateDetector detects the blocks of pages, such as headers, footers
+// and sidebars, that are repeated across many pages of a site, so they can be
+// removed from the text and markdown of pages. Per-page heuristics like
+// OnlyMainContent miss boilerplate that isn't marked up as such.
+//
+// Pages are added as they are crawled, and blocks are compared by tag and
+// text. Use a detector per site, as boilerplate differs between sites.
+// Detectors are safe for concurrent use.
+//
+//	detector := web.NewBoilerplateDetector(web.BoilerplateOptions{})
+//	for _, doc := range docs {
+//		detector.Add(doc)
+//	}
+//	text, err := detector.Text(doc)
+type BoilerplateDetector struct {
+	threshold float64
+	minPages  int
+	mutex     sync.Mutex
+	pages     int
+	counts    map[uint64]int // Number of pages by block signature
+}
+
+// NewBoilerplateDetector creates a new boilerplate detector.
+func NewBoilerplateDetector(options BoilerplateOptions) *BoilerplateDetector {
+	if options.Threshold <= 0 || options.Threshold > 1 {
+		options.Threshold = 0.5
+	}
+	if options.MinPages <= 0 {
+		options.MinPages = 5
+	}
+	return &BoilerplateDetector{
+		threshold: options.Threshold,
+		minPages:  options.MinPages,
+		counts:    map[uint64]int{},
+	}
+}
+
+// Add learns the blocks of a page. Each page should be added once.
+func (b *BoilerplateDetector) Add(doc *Document) {
+	signatures := map[uint64]bool{}
+	for _, root := range doc.doc.Nodes {
+		visitBlocks(root, func(_ *html.Node, signature uint64) bool {
+			signatures[signature] = true
+			return true
+		})
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pages++
+	for signature := range signatures {
+		b.counts[signature]++
+	}
+}
+
+// Pages returns the number of pages added.
+func (b *BoilerplateDetector) Pages() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.pages
+}
+
+// Remove returns a copy of the document without its boilerplate blocks. The
+// document is returned as is until MinPages pages have been added.
+func (b *BoilerplateDetector) Remove(doc *Document) (*Document, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.pages < b.minPages || len(doc.doc.Nodes) == 0 {
+		return doc, nil
+	}
+	minCount := b.threshold * float64(b.pages)
+	clone := doc.Clone()
+	var boilerplate []*html.Node
+	visitBlocks(clone.doc.Nodes[0], func(n *html.Node, signature uint64) bool {
+		if float64(b.counts[signature]) >= minCount {
+			boilerplate = append(boilerplate, n)
+			return false
+		}
+		return true
+	})
+	if len(boilerplate) == 0 {
+		return doc, nil
+	}
+	for _, n := range boilerplate {
+		n.Parent.RemoveChild(n)
+	}
+	rendered, err := renderHTML(clone.doc.Nodes[0], len(doc.html))
+	if err != nil {
+		return nil, err
+	}
+	clone.html = rendered
+	return clone, nil
+}
+
+// Text returns the main content text of a page without its boilerplate
+// blocks. See Document.MainContentText.
+func (b *BoilerplateDetector) Text(doc *Document) (string, error) {
+	cleaned, err := b.Remove(doc)
+	if err != nil {
+		return "", err
+	}
+	return cleaned.MainContentText(), nil
+}
+
+// visitBlocks calls fn with the block elements of the tree that have text,
+// from the root down, along with the signature of their tag and text. The
+// children of an element are skipped if fn returns false.
+func visitBlocks(n *html.Node, fn func(n *html.Node, signature uint64) bool) {
+	if n.Type == html.ElementNode {
+		switch n.Data {
+		case "head", "script", "style", "template":
+			return
+		}
+		if blockElements[n.Data] {
+			var text strings.Builder
+			nodeText(n, &text)
+			if normalized := strings.Join(strings.Fields(text.String()), " "); normalized != "" {
+				h := fnv.New64a()
+				h.Write([]byte(n.Data))
+				h.Write([]byte{0})
+				h.Write([]byte(normalized))
+				if !fn(n, h.Sum64()) {
+					return
+				}
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		visitBlocks(c, fn)
+	}
+}
+
+// nodeText writes the text of a node and its descendants, separating
+// elements with spaces.
+func nodeText(n *html.Node, text *strings.Builder) {
+	switch n.Type {
+	case html.TextNode:
+		text.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		switch n.Data {
+		case "script", "style", "template":
+			return
+		}
+		text.WriteByte(' ')
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodeText(c, text)
+	}
+}
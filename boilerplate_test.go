@@ -0,0 +1,42 @@
+package web
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoilerplateDetector(t *testing.T) {
+	page := func(i int) *Document {
+		doc, err := NewDocument(fmt.Sprintf(`<html><body>
+			<div class="top"><p>Acme Corp</p><ul><li>Products</li><li>About</li></ul></div>
+			<div class="content"><h1>Page %d</h1><p>Unique text of page %d.</p><p>Sign up for our newsletter</p></div>
+			<div class="bottom">Copyright Acme</div>
+		</body></html>`, i, i))
+		require.NoError(t, err)
+		return doc
+	}
+
+	detector := NewBoilerplateDetector(BoilerplateOptions{MinPages: 3})
+	detector.Add(page(1))
+	detector.Add(page(2))
+
+	// Nothing is removed until enough pages are known
+	doc := page(3)
+	same, err := detector.Remove(doc)
+	require.NoError(t, err)
+	require.Same(t, doc, same)
+
+	detector.Add(page(3))
+	require.Equal(t, 3, detector.Pages())
+	text, err := detector.Text(page(4))
+	require.NoError(t, err)
+	require.Equal(t, "Page 4\n\nUnique text of page 4.", text)
+
+	// The original document is unchanged
+	cleaned, err := detector.Remove(doc)
+	require.NoError(t, err)
+	require.NotContains(t, cleaned.Raw(), "Acme")
+	require.Contains(t, doc.Raw(), "Acme")
+}
@@ -0,0 +1,68 @@
+package web
+
+import "sort"
+
+// Breadcrumb is an entry of a schema.org BreadcrumbList.
+type Breadcrumb struct {
+	Position int    `json:"position,omitempty"`
+	Name     string `json:"name,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// BreadcrumbList is the trail of pages leading to a page, from the root.
+type BreadcrumbList struct {
+	Items []*Breadcrumb `json:"items"`
+}
+
+// ExtractBreadcrumbs returns the breadcrumb trails described by the
+// document's structured data. Entries are sorted by position. Entries given
+// as nested items or as URLs are both supported.
+func ExtractBreadcrumbs(doc *Document) []*BreadcrumbList {
+	var lists []*BreadcrumbList
+	for _, item := range FindStructuredItems(doc.StructuredItems(), "BreadcrumbList") {
+		list := &BreadcrumbList{}
+		for _, element := range schemaList(item["itemListElement"]) {
+			entry, ok := element.(map[string]any)
+			if !ok {
+				continue
+			}
+			crumb := &Breadcrumb{
+				Position: int(schemaNumber(entry["position"])),
+				Name:     schemaString(entry["name"]),
+			}
+			switch target := entry["item"].(type) {
+			case string:
+				crumb.URL = target
+			case map[string]any:
+				fillString(&crumb.Name, schemaString(target["name"]))
+				if urls := schemaURLs(target); len(urls) > 0 {
+					crumb.URL = urls[0]
+				}
+			}
+			fillString(&crumb.URL, schemaString(entry["url"]))
+			if crumb.Name != "" || crumb.URL != "" {
+				list.Items = append(list.Items, crumb)
+			}
+		}
+		if len(list.Items) == 0 {
+			continue
+		}
+		sort.SliceStable(list.Items, func(i, j int) bool {
+			return list.Items[i].Position < list.Items[j].Position
+		})
+		lists = append(lists, list)
+	}
+	return lists
+}
+
+// schemaList returns the entries of a structured data value that may be a
+// single value or a list.
+func schemaList(value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	}
+	return []any{value}
+}
@@ -2,16 +2,21 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/deepnoodle-ai/web"
 	"github.com/deepnoodle-ai/web/crawler"
+	"github.com/deepnoodle-ai/web/crawler/mirror"
 	"github.com/deepnoodle-ai/web/fetch"
 )
 
@@ -27,14 +32,32 @@ func main() {
 	// Parse command line flags
 	var (
 		urls         = flag.String("urls", "", "Comma-separated list of URLs to crawl")
-		inputFile    = flag.String("file", "", "File containing URLs to crawl")
+		inputFile    = flag.String("file", "", "Comma-separated list of files or globs containing URLs to crawl, one per line")
 		maxURLs      = flag.Int("max-urls", 100, "Maximum number of URLs to crawl")
+		maxDepth     = flag.Int("max-depth", 0, "Maximum number of links followed from the seed URLs (0 for no limit)")
 		workers      = flag.Int("workers", 5, "Number of concurrent workers")
 		timeout      = flag.Duration("timeout", 30*time.Second, "Fetch timeout")
 		followMode   = flag.String("follow", "same-domain", "Link following behavior: any, same-domain, related-subdomains, none")
 		verbose      = flag.Bool("verbose", false, "Enable verbose logging")
 		showProgress = flag.Bool("progress", true, "Show progress updates")
 		delay        = flag.Duration("delay", 0, "Delay between requests")
+		bandwidth    = flag.Int64("bandwidth", 0, "Maximum bytes per second transferred from each host (0 for no limit)")
+		failFast     = flag.Bool("fail-fast", false, "Stop the crawl at the first failed fetch")
+		maxErrorRate = flag.Float64("max-error-rate", 0, "Stop the crawl when this fraction of fetches fail (0 disables)")
+		dryRun       = flag.Bool("dry-run", false, "Fetch only the seed URLs and report which URLs would be crawled")
+		mirrorPath   = flag.String("mirror", "", "Save the crawled pages and their assets for offline browsing to this directory, or zip file if ending with .zip")
+		provenance   = flag.String("provenance", "", "Embed the source URL and fetch time in mirrored pages: meta or comment")
+		a11y         = flag.Bool("a11y", false, "Report the accessibility issues of crawled pages")
+		security     = flag.Bool("security", false, "Report the missing security headers and mixed content of crawled pages")
+		templates    = flag.Bool("templates", false, "Report the clusters of pages sharing a template, with suggested URL patterns")
+		sitemapPath  = flag.String("sitemap", "", "Compare the crawled pages with this sitemap file or URL")
+		browser      = flag.Bool("browser", false, "Render pages in a headless Chrome or Chromium, for pages built by JavaScript")
+		reportPath   = flag.String("report", "", "Write a standalone HTML report of the crawl to this file")
+		previousPath = flag.String("previous", "", "Report the new, changed and disappeared pages since the crawl whose fingerprints were saved to this file")
+		fingerprints = flag.String("fingerprints", "", "Save the content fingerprints of the crawled pages to this file, to compare the next crawl with")
+		followFeeds  = flag.Bool("feeds", false, "Follow the items of the RSS, Atom and JSON feeds linked by pages")
+		include      = flag.String("include", "", "Comma-separated glob patterns of the paths of the links to follow, e.g. /blog/*")
+		exclude      = flag.String("exclude", "", "Comma-separated glob patterns of the paths of the links not to follow, e.g. */tag/*")
 	)
 	flag.Parse()
 
@@ -67,13 +90,16 @@ func main() {
 	}
 
 	if *inputFile != "" {
-		items, err := web.ReadFileItems(*inputFile)
-		if err != nil {
+		items, err := web.ReadItems(web.ReadItemsOptions{URLs: true}, strings.Split(*inputFile, ",")...)
+		var itemErrors web.ItemErrors
+		if errors.As(err, &itemErrors) {
+			for _, itemErr := range itemErrors {
+				logger.Warn("Skipping invalid url", slog.String("error", itemErr.Error()))
+			}
+		} else if err != nil {
 			log.Fatalf("Failed to read input file: %v", err)
 		}
-		for _, url := range items {
-			startURLs = append(startURLs, normalize(url))
-		}
+		startURLs = append(startURLs, items...)
 	}
 
 	// Parse follow behavior
@@ -92,44 +118,151 @@ func main() {
 	}
 
 	// Create default fetcher with timeout
-	defaultFetcher := fetch.NewHTTPFetcher(fetch.HTTPFetcherOptions{
-		Timeout: *timeout,
-		Headers: fetch.FakeHeaders,
+	var defaultFetcher fetch.Fetcher = fetch.NewHTTPFetcher(fetch.HTTPFetcherOptions{
+		Timeouts: fetch.Timeouts{Total: *timeout},
+		Headers:  fetch.FakeHeaders,
 	})
+	if *browser {
+		browserFetcher := fetch.NewBrowserFetcher(fetch.BrowserFetcherOptions{
+			Timeout:      *timeout,
+			MaxPages:     *workers,
+			RecycleAfter: 500,
+		})
+		defer browserFetcher.Close()
+		defaultFetcher = browserFetcher
+	}
 
 	// Create crawler
 	c, err := crawler.New(crawler.Options{
-		MaxURLs:        *maxURLs,
-		Workers:        *workers,
-		RequestDelay:   *delay,
-		DefaultFetcher: defaultFetcher,
-		FollowBehavior: followBehavior,
-		Logger:         logger,
-		ShowProgress:   *showProgress,
+		MaxURLs:          *maxURLs,
+		MaxDepth:         *maxDepth,
+		Workers:          *workers,
+		RequestDelay:     *delay,
+		DefaultFetcher:   defaultFetcher,
+		FollowBehavior:   followBehavior,
+		Logger:           logger,
+		ShowProgress:     *showProgress,
+		FailFast:         *failFast,
+		MaxErrorRate:     *maxErrorRate,
+		FollowFeeds:      *followFeeds,
+		MaxHostBandwidth: *bandwidth,
+		IncludePatterns:  globRules(*include),
+		ExcludePatterns:  globRules(*exclude),
 	})
 	if err != nil {
 		log.Fatalf("Failed to create crawler: %v", err)
 	}
 
-	// Start crawling
 	ctx := context.Background()
+
+	// Report the plan of the crawl without crawling
+	if *dryRun {
+		plan, err := c.Plan(ctx, startURLs)
+		if err != nil {
+			log.Fatalf("Planning failed: %v", err)
+		}
+		for _, u := range plan.URLs {
+			if u.Error != "" {
+				fmt.Printf("crawl\t%d\t%s\t(links unknown: %s)\n", u.Depth, u.URL, u.Error)
+			} else {
+				fmt.Printf("crawl\t%d\t%s\n", u.Depth, u.URL)
+			}
+		}
+		for _, u := range plan.Skipped {
+			fmt.Printf("skip\t%d\t%s\t(%s)\n", u.Depth, u.URL, u.Reason)
+		}
+		fmt.Printf("\n%d URLs would be crawled, %d skipped\n", len(plan.URLs), len(plan.Skipped))
+		return
+	}
+
+	// Start crawling
 	startTime := time.Now()
 
-	err = c.Crawl(ctx, startURLs, func(ctx context.Context, result *crawler.Result) {
+	callback := func(ctx context.Context, result *crawler.Result) error {
 		if result.Error != nil {
 			logger.Error("Failed to crawl",
 				slog.String("url", result.URL.String()),
 				slog.String("error", result.Error.Error()))
-			return
+			return nil
 		}
 		logger.Info("Crawled",
 			slog.String("url", result.URL.String()),
 			slog.Int("links", len(result.Links)),
 			slog.Int("status", result.Response.StatusCode))
-	})
+		return nil
+	}
+	var m *mirror.Mirror
+	if *mirrorPath != "" {
+		var writer mirror.Writer
+		if strings.HasSuffix(*mirrorPath, ".zip") {
+			f, err := os.Create(*mirrorPath)
+			if err != nil {
+				log.Fatalf("Failed to create mirror: %v", err)
+			}
+			defer f.Close()
+			writer = mirror.NewZipWriter(f)
+		} else {
+			writer = mirror.NewDirWriter(*mirrorPath)
+		}
+		m, err = mirror.New(mirror.Options{Writer: writer, RewriteLinks: true, Assets: true, Provenance: *provenance})
+		if err != nil {
+			log.Fatalf("Failed to create mirror: %v", err)
+		}
+		callback = m.Callback(callback)
+	}
+	if *a11y {
+		callback = auditCallback(callback)
+	}
+	if *security {
+		callback = securityCallback(callback)
+	}
+	var analyzer *crawler.TemplateAnalyzer
+	if *templates {
+		analyzer = crawler.NewTemplateAnalyzer(crawler.TemplateOptions{})
+		callback = analyzer.Callback(callback)
+	}
+
+	var reportBuilder *crawler.ReportBuilder
+	if *reportPath != "" {
+		reportBuilder = crawler.NewReportBuilder(crawler.ReportOptions{})
+		callback = reportBuilder.Callback(callback)
+	}
+
+	var tracker *crawler.DeltaTracker
+	if *previousPath != "" || *fingerprints != "" {
+		var previous []crawler.PageFingerprint
+		if *previousPath != "" {
+			data, err := os.ReadFile(*previousPath)
+			if err != nil {
+				log.Fatalf("Failed to read previous crawl: %v", err)
+			}
+			if err := json.Unmarshal(data, &previous); err != nil {
+				log.Fatalf("Failed to read previous crawl: %v", err)
+			}
+		}
+		tracker = crawler.NewDeltaTracker(previous, crawler.DeltaOptions{})
+		callback = tracker.Callback(callback)
+	}
+
+	var coverage *crawler.SitemapCoverage
+	if *sitemapPath != "" {
+		sitemap, err := loadSitemap(ctx, *sitemapPath)
+		if err != nil {
+			log.Fatalf("Failed to load sitemap: %v", err)
+		}
+		coverage = crawler.NewSitemapCoverage(sitemap, crawler.SitemapCoverageOptions{})
+		callback = coverage.Callback(callback)
+	}
+
+	err = c.Crawl(ctx, startURLs, callback)
 	if err != nil {
 		log.Fatalf("Crawling failed: %v", err)
 	}
+	if m != nil {
+		if err := m.Close(); err != nil {
+			log.Fatalf("Failed to save mirror: %v", err)
+		}
+	}
 
 	// Print final statistics
 	stats := c.GetStats()
@@ -140,4 +273,174 @@ func main() {
 	fmt.Printf("Successful: %d\n", stats.GetSucceeded())
 	fmt.Printf("Failed: %d\n", stats.GetFailed())
 	fmt.Printf("Average rate: %.2f pages/second\n", float64(crawledCount)/duration.Seconds())
+	bytes := stats.GetBytes()
+	fmt.Printf("Downloaded: %d bytes (%d transferred)\n", bytes.Bytes, bytes.Transferred)
+
+	if coverage != nil {
+		printSitemapReport(coverage.Report())
+	}
+
+	if tracker != nil && *previousPath != "" {
+		printDeltaReport(tracker.Report())
+	}
+	if tracker != nil && *fingerprints != "" {
+		data, err := json.MarshalIndent(tracker.Fingerprints(), "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to save fingerprints: %v", err)
+		}
+		if err := os.WriteFile(*fingerprints, data, 0o644); err != nil {
+			log.Fatalf("Failed to save fingerprints: %v", err)
+		}
+	}
+
+	if reportBuilder != nil {
+		report := reportBuilder.Report()
+		if coverage != nil {
+			report.Coverage = coverage.Report()
+		}
+		if err := writeReport(*reportPath, report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+		fmt.Printf("\nReport written to %s\n", *reportPath)
+	}
+
+	if analyzer != nil {
+		fmt.Printf("\nPage templates:\n")
+		for _, cluster := range analyzer.Clusters() {
+			fmt.Printf("%5d  %s\n", cluster.Pages, cluster.Pattern)
+			for _, sample := range cluster.Samples {
+				fmt.Printf("       %s\n", sample)
+			}
+		}
+	}
+}
+
+// auditCallback returns a crawl callback that prints the accessibility issues
+// of each page before passing the result to the given callback.
+func auditCallback(callback crawler.Callback) crawler.Callback {
+	return func(ctx context.Context, result *crawler.Result) error {
+		if result.Error == nil && result.Response != nil && result.Response.HTML != "" {
+			if doc, err := result.Response.Document(); err == nil {
+				for _, issue := range doc.AccessibilityIssues() {
+					fmt.Printf("a11y\t%s\t%s\t%s\t%s\n", result.URL, issue.Type, issue.Message, issue.Element)
+				}
+			}
+		}
+		return callback(ctx, result)
+	}
+}
+
+// securityCallback returns a crawl callback that prints the missing security
+// headers and mixed content of each page before passing the result to the
+// given callback.
+func securityCallback(callback crawler.Callback) crawler.Callback {
+	return func(ctx context.Context, result *crawler.Result) error {
+		if result.Error == nil && result.Response != nil {
+			audit := result.Response.SecurityAudit()
+			for _, header := range audit.Missing {
+				fmt.Printf("security\t%s\tmissing-header\t%s\n", result.URL, header)
+			}
+			for _, mixed := range audit.MixedContent {
+				kind := "passive"
+				if mixed.Active {
+					kind = "active"
+				}
+				fmt.Printf("security\t%s\tmixed-content\t%s %s %s\n", result.URL, kind, mixed.Element, mixed.URL)
+			}
+		}
+		return callback(ctx, result)
+	}
+}
+
+// writeReport writes the HTML report of the crawl to a file.
+func writeReport(path string, report *crawler.CrawlReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteHTML(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadSitemap reads a sitemap from a file or URL.
+func loadSitemap(ctx context.Context, path string) (*web.Sitemap, error) {
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return web.ParseSitemap(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fetch.DefaultHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, fetch.DefaultMaxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	return web.ParseSitemap(data)
+}
+
+// printDeltaReport prints the changes of the pages since the previous crawl.
+func printDeltaReport(report *crawler.DeltaReport) {
+	fmt.Printf("\nChanges since previous crawl: %d new, %d changed, %d unchanged, %d disappeared\n",
+		len(report.New), len(report.Changed), len(report.Unchanged), len(report.Disappeared))
+	for _, u := range report.New {
+		fmt.Printf("delta\tnew\t%s\n", u)
+	}
+	for _, u := range report.Changed {
+		fmt.Printf("delta\tchanged\t%s\n", u)
+	}
+	for _, u := range report.Disappeared {
+		fmt.Printf("delta\tdisappeared\t%s\n", u)
+	}
+}
+
+// printSitemapReport prints the comparison of the crawl with the sitemap.
+func printSitemapReport(report *crawler.SitemapReport) {
+	fmt.Printf("\nSitemap coverage: %d of %d URLs crawled (%.1f%%)\n",
+		report.Crawled, report.Listed, 100*report.Coverage())
+	for _, u := range report.NotCrawled {
+		fmt.Printf("sitemap\tnot-crawled\t%s\n", u)
+	}
+	for _, u := range report.NotListed {
+		fmt.Printf("sitemap\tnot-listed\t%s\n", u)
+	}
+	for _, failure := range report.Failed {
+		switch {
+		case failure.Error != "":
+			fmt.Printf("sitemap\tfailed\t%s\t%s\n", failure.URL, failure.Error)
+		case failure.RedirectedTo != "":
+			fmt.Printf("sitemap\tredirected\t%s\t%s\n", failure.URL, failure.RedirectedTo)
+		default:
+			fmt.Printf("sitemap\tstatus-%d\t%s\n", failure.StatusCode, failure.URL)
+		}
+	}
+	for _, mismatch := range report.LastModMismatches {
+		fmt.Printf("sitemap\tlastmod-mismatch\t%s\t%s\t%s\n", mismatch.URL,
+			mismatch.SitemapLastMod.Format(time.RFC3339), mismatch.PageLastModified.Format(time.RFC3339))
+	}
+}
+
+// globRules returns the match rules of comma-separated glob patterns.
+func globRules(patterns string) []*crawler.MatchRule {
+	var rules []*crawler.MatchRule
+	for _, pattern := range strings.Split(patterns, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			rules = append(rules, &crawler.MatchRule{Pattern: pattern, Type: crawler.MatchGlob})
+		}
+	}
+	return rules
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// Writes the JSON Schemas of the fetch Request and Response formats, for use
+// by clients written in other languages.
+func main() {
+	dir := flag.String("dir", ".", "Directory to write the schema files to")
+	flag.Parse()
+
+	schemas := map[string]map[string]any{
+		"request.schema.json":  fetch.RequestSchema(),
+		"response.schema.json": fetch.ResponseSchema(),
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	for name, schema := range schemas {
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(*dir, name), append(data, '\n'), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
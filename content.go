@@ -0,0 +1,67 @@
+package web
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// mainContentSelector matches elements that usually wrap the main content of
+// a page.
+const mainContentSelector = `[itemprop="articleBody"], article, main, [role="main"]`
+
+// mainContent returns the element most likely to hold the main content of
+// the page: the candidate container with the most text, or the body.
+func (d *Document) mainContent() *goquery.Selection {
+	return memoize(d, "mainContent", func() *goquery.Selection {
+		var best *goquery.Selection
+		bestLen := 0
+		d.doc.Find(mainContentSelector).Each(func(i int, s *goquery.Selection) {
+			if n := len(strings.TrimSpace(s.Text())); n > bestLen {
+				best, bestLen = s, n
+			}
+		})
+		if best != nil {
+			return best
+		}
+		if body := d.doc.Find("body").First(); len(body.Nodes) > 0 {
+			return body
+		}
+		return d.doc.Selection
+	})
+}
+
+// MainContentText returns the plain text of the main content of the page,
+// with boilerplate such as navigation, forms and dialogs removed. Blocks such
+// as paragraphs and headings are separated by blank lines.
+func (d *Document) MainContentText() string {
+	return memoize(d, "mainContentText", func() string {
+		content := d.mainContent().Clone()
+		for _, tag := range StandardExcludeTags {
+			content.Find(tag).Remove()
+		}
+		var parts []string
+		for _, node := range content.Nodes {
+			parts = append(parts, blockText(node))
+		}
+		return strings.TrimSpace(strings.Join(parts, "\n\n"))
+	})
+}
+
+// blockElements are the elements rendered as separate blocks of text.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "header": true, "li": true,
+	"main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "tr": true, "ul": true,
+}
+
+// blockText returns the text of a node, collapsing whitespace within blocks
+// and separating blocks with blank lines.
+func blockText(root *html.Node) string {
+	r := textRenderer{blockSeparator: "\n\n", lineBreak: " ", collapse: true}
+	return r.render(root)
+}
@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// responseBytes returns the bytes downloaded for a response, as reported by
+// the fetcher, or the size of its HTML for fetchers that don't.
+func responseBytes(response *fetch.Response) ByteCounts {
+	bytes := ByteCounts{Bytes: response.BodySize, Transferred: response.TransferSize}
+	if bytes.Bytes == 0 {
+		bytes.Bytes = int64(len(response.HTML))
+	}
+	if bytes.Transferred == 0 {
+		bytes.Transferred = bytes.Bytes
+	}
+	return bytes
+}
+
+// bandwidthLimiter delays the fetches from each host so that the bytes
+// transferred from it don't exceed a rate on average. A nil
+// *bandwidthLimiter doesn't delay fetches.
+type bandwidthLimiter struct {
+	rate  int64 // bytes per second
+	mutex sync.Mutex
+	next  map[string]time.Time // time of the next fetch from each host
+}
+
+func newBandwidthLimiter(rate int64) *bandwidthLimiter {
+	return &bandwidthLimiter{rate: rate, next: map[string]time.Time{}}
+}
+
+// wait waits until the bytes transferred from a host so far are within the
+// rate.
+func (l *bandwidthLimiter) wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+	l.mutex.Lock()
+	delay := time.Until(l.next[host])
+	l.mutex.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// record records bytes transferred from a host, delaying its next fetch by
+// the time they take at the rate.
+func (l *bandwidthLimiter) record(host string, transferred int64) {
+	if l == nil || transferred <= 0 {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	next := time.Now()
+	if l.next[host].After(next) {
+		next = l.next[host]
+	}
+	l.next[host] = next.Add(time.Duration(transferred) * time.Second / time.Duration(l.rate))
+}
@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_Bandwidth(t *testing.T) {
+	var mutex sync.Mutex
+	starts := map[string][]time.Time{}
+	fetcher := funcFetcher(func(ctx context.Context, req *fetch.Request) (*fetch.Response, error) {
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return nil, err
+		}
+		host := u.Hostname()
+		mutex.Lock()
+		starts[host] = append(starts[host], time.Now())
+		mutex.Unlock()
+		if host == "other.com" {
+			// Sizes not reported by the fetcher are those of the HTML
+			return &fetch.Response{URL: req.URL, HTML: "<p>Hello</p>"}, nil
+		}
+		return &fetch.Response{URL: req.URL, BodySize: 4000, TransferSize: 1000}, nil
+	})
+	crawler, err := New(Options{
+		Workers:          1,
+		DefaultFetcher:   fetcher,
+		FollowBehavior:   FollowNone,
+		MaxHostBandwidth: 10000,
+	})
+	require.NoError(t, err)
+
+	urls := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c", "https://other.com/a"}
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error { return nil })
+	require.NoError(t, err)
+
+	stats := crawler.GetStats()
+	require.Equal(t, ByteCounts{Bytes: 12012, Transferred: 3012}, stats.GetBytes())
+	require.Equal(t, map[string]ByteCounts{
+		"example.com": {Bytes: 12000, Transferred: 3000},
+		"other.com":   {Bytes: 12, Transferred: 12},
+	}, stats.GetHostBytes())
+
+	snapshot := crawler.Snapshot()
+	require.Equal(t, stats.GetBytes(), snapshot.Bytes)
+	require.Equal(t, ByteCounts{Bytes: 12000, Transferred: 3000}, snapshot.Hosts[0].Bytes)
+
+	// 1000 bytes take 100ms at 10 KB/s, so the fetches from example.com are
+	// spaced by as much
+	require.Len(t, starts["example.com"], 3)
+	require.GreaterOrEqual(t, starts["example.com"][2].Sub(starts["example.com"][0]), 200*time.Millisecond)
+}
@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// CacheMode determines how the crawler uses its cache.
+type CacheMode string
+
+const (
+	// CacheReadWrite serves pages from the cache and caches fetched pages.
+	// This is the default.
+	CacheReadWrite CacheMode = "read-write"
+
+	// CacheReadOnly serves pages from the cache without caching fetched
+	// pages, e.g. to experiment from a cache without changing it.
+	CacheReadOnly CacheMode = "read-only"
+
+	// CacheWriteOnly fetches all pages and caches them, e.g. to refresh a
+	// cache without reading stale entries.
+	CacheWriteOnly CacheMode = "write-only"
+
+	// CacheBypass neither reads nor writes the cache.
+	CacheBypass CacheMode = "bypass"
+)
+
+// reads returns true if pages are served from the cache.
+func (m CacheMode) reads() bool {
+	return m == CacheReadWrite || m == CacheReadOnly
+}
+
+// writes returns true if fetched pages are cached.
+func (m CacheMode) writes() bool {
+	return m == CacheReadWrite || m == CacheWriteOnly
+}
+
+func (m CacheMode) validate() error {
+	switch m {
+	case CacheReadWrite, CacheReadOnly, CacheWriteOnly, CacheBypass:
+		return nil
+	}
+	return fmt.Errorf("invalid cache mode: %q", m)
+}
+
+// encodeCachedResponse encodes a response for the cache. The whole response
+// is cached, so that cache hits have the status code, headers, metadata and
+// links of the page as live fetches.
+func encodeCachedResponse(response *fetch.Response) ([]byte, error) {
+	return json.Marshal(response)
+}
+
+// decodeCachedResponse decodes a cached response. Entries holding only the
+// HTML of the page, as cached by previous versions, are also supported.
+func decodeCachedResponse(rawURL string, data []byte) *fetch.Response {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var response fetch.Response
+		if err := json.Unmarshal(data, &response); err == nil {
+			return &response
+		}
+	}
+	return &fetch.Response{URL: rawURL, HTML: string(data)}
+}
+
+// cachedResponse returns the cached response of a URL, or nil if not cached.
+// The links of entries without are extracted, so that they are followed.
+func (c *Crawler) cachedResponse(ctx context.Context, rawURL string) *fetch.Response {
+	cached, err := c.cache.Get(ctx, rawURL)
+	if err != nil {
+		return nil
+	}
+	response := decodeCachedResponse(rawURL, cached)
+	if response.Links == nil && response.HTML != "" {
+		if doc, err := response.Document(); err == nil {
+			response.Links = doc.Links()
+		}
+	}
+	return response
+}
+
+// expired returns true if a cached response is older than the max age of the
+// cache. Responses without a timestamp, e.g. cached by previous versions,
+// are expired.
+func (c *Crawler) expired(response *fetch.Response) bool {
+	if c.cacheMaxAge <= 0 {
+		return false
+	}
+	return response.Timestamp.IsZero() || time.Since(response.Timestamp) > c.cacheMaxAge
+}
+
+// cacheResponse stores a fetched response in the cache, timestamped with the
+// current time if it has no timestamp. Truncated responses are not cached,
+// so that the page is fetched in full next time.
+func (c *Crawler) cacheResponse(ctx context.Context, rawURL string, response *fetch.Response) {
+	if response.Truncated {
+		return
+	}
+	if response.Timestamp.IsZero() {
+		timestamped := *response
+		timestamped.Timestamp = time.Now().UTC()
+		response = &timestamped
+	}
+	data, err := encodeCachedResponse(response)
+	if err == nil {
+		err = c.cache.Set(ctx, rawURL, data)
+	}
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to cache response",
+			slog.String("url", rawURL),
+			slog.String("error", err.Error()))
+	}
+}
+
+// staleResponse returns the cached response of a URL that failed to be
+// fetched, if stale responses are enabled and the URL is cached.
+func (c *Crawler) staleResponse(ctx context.Context, rawURL string, fetchErr error) *fetch.Response {
+	if !c.staleIfError || c.cache == nil || c.cacheMode == CacheBypass {
+		return nil
+	}
+	response := c.cachedResponse(ctx, rawURL)
+	if response != nil {
+		c.logger.WarnContext(ctx, "serving stale cached page",
+			slog.String("url", rawURL),
+			slog.String("error", fetchErr.Error()))
+	}
+	return response
+}
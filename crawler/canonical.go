@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// canonicalHosts maps the hosts of a site to its canonical host, e.g.
+// example.com to www.example.com, as learned from redirects and canonical
+// tags. Only hosts that differ by a "www." prefix are considered variants of
+// each other. The zero value is unused; a nil *canonicalHosts rewrites
+// nothing.
+type canonicalHosts struct {
+	mutex sync.RWMutex
+	hosts map[string]string
+}
+
+func newCanonicalHosts() *canonicalHosts {
+	return &canonicalHosts{hosts: map[string]string{}}
+}
+
+// learn records that the canonical host of the page at pageURL is that of
+// canonicalURL, if the hosts are variants of each other.
+func (h *canonicalHosts) learn(pageURL *url.URL, canonicalURL string) {
+	if h == nil || canonicalURL == "" {
+		return
+	}
+	canonical, err := pageURL.Parse(canonicalURL)
+	if err != nil {
+		return
+	}
+	from, to := strings.ToLower(pageURL.Host), strings.ToLower(canonical.Host)
+	if from == to || strings.TrimPrefix(from, "www.") != strings.TrimPrefix(to, "www.") {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.hosts[from] = to
+	delete(h.hosts, to) // the most recent evidence wins
+}
+
+// rewrite returns the URL with its host replaced by the canonical host.
+func (h *canonicalHosts) rewrite(rawURL string) string {
+	if h == nil {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	h.mutex.RLock()
+	canonical, ok := h.hosts[strings.ToLower(u.Host)]
+	h.mutex.RUnlock()
+	if !ok {
+		return rawURL
+	}
+	u.Host = canonical
+	return u.String()
+}
@@ -2,9 +2,10 @@ package crawler
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"log/slog"
 	"net/url"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/deepnoodle-ai/web"
 	"github.com/deepnoodle-ai/web/cache"
+	"github.com/deepnoodle-ai/web/errors"
 	"github.com/deepnoodle-ai/web/fetch"
 )
 
@@ -33,10 +35,57 @@ type Result struct {
 	Links    []string
 	Response *fetch.Response
 	Error    error
+
+	// CrawlID identifies the crawl and RequestID the fetch of the page,
+	// for correlation with logs.
+	CrawlID   string
+	RequestID string
+
+	// Pattern is the name of the first PatternRule matching the URL, if
+	// any, and PatternParams the parameters of the URL in the pattern.
+	Pattern       string
+	PatternParams map[string]string
+
+	// Stale is true if the page was served from the cache because it failed
+	// to be fetched, with Options.StaleIfError, and StaleError is the error
+	// of the fetch.
+	Stale      bool
+	StaleError error
+
+	// Depth is the number of links followed from a seed to find the URL:
+	// zero for seeds and injected URLs, one for the links found on seeds.
+	Depth int
+
+	// Flagged is true if the status code of the response is flagged by the
+	// StatusPolicy, e.g. for 404 pages by default.
+	Flagged bool
+
+	// PageType is the type of the page, set with Options.ClassifyPages.
+	PageType web.PageType
+
+	// FetchDuration is the time taken to fetch the page, including failed
+	// fetches. It is zero for pages served from the cache.
+	FetchDuration time.Duration
 }
 
-// ProcessCallback is called with the fetch request and parsed result (if any)
-type Callback func(ctx context.Context, result *Result)
+// Callback is called with the result of each processed page, including pages
+// that failed to be fetched. The returned error controls how the crawl
+// proceeds:
+//
+//   - nil continues normally
+//   - ErrSkipLinks continues without following links from the page
+//   - ErrRetry requeues the URL, up to MaxCallbackRetries times
+//   - ErrAbort stops the crawl and Crawl returns an error wrapping it
+//
+// Any other error is logged and the crawl continues.
+type Callback func(ctx context.Context, result *Result) error
+
+// Errors returned by a Callback to control the crawl. They may be wrapped.
+var (
+	ErrSkipLinks = errors.New("skip links")
+	ErrRetry     = errors.New("retry")
+	ErrAbort     = errors.New("crawl aborted")
+)
 
 // Options used to configure a crawler.
 type Options struct {
@@ -54,12 +103,200 @@ type Options struct {
 	ShowProgress         bool
 	ShowProgressInterval time.Duration
 	QueueSize            int
+
+	// MaxDepth limits the number of links followed from the seeds: with 1,
+	// the links found on seeds are crawled, but not the links found on
+	// those pages. Zero means no limit.
+	MaxDepth int
+
+	// FailFast stops the crawl at the first URL that fails to be fetched.
+	FailFast bool
+
+	// MaxErrorRate stops the crawl when the fraction of fetches that failed
+	// exceeds this value (0-1), once ErrorRateWindow fetches have completed.
+	// Zero disables the check.
+	MaxErrorRate float64
+
+	// ErrorRateWindow is the number of completed fetches required before
+	// MaxErrorRate is enforced. Defaults to 100, or MaxURLs if smaller.
+	ErrorRateWindow int
+
+	// ParseWorkers is the number of workers that parse fetched pages and call
+	// the callback. When set, fetch workers hand pages off to the parse
+	// workers so that slow parsers don't hold up fetching. When zero, pages
+	// are parsed by the fetch workers.
+	ParseWorkers int
+
+	// TagLogs adds the crawl ID, seed domain and worker ID to the records
+	// logged by the crawler, so that the logs of concurrent crawls can be
+	// told apart. See LogHandler.
+	TagLogs bool
+
+	// LogThrottle samples the warnings and errors the crawler logs
+	// repeatedly, e.g. for each page of a host that fails consistently.
+	// Nil logs every record.
+	LogThrottle *LogThrottle
+
+	// CrawlIDHeader and RequestIDHeader are the names of the request headers
+	// used to send the crawl and request IDs to fetched sites, e.g.
+	// "X-Request-ID". The IDs are not sent if empty.
+	CrawlIDHeader   string
+	RequestIDHeader string
+
+	// MaxCallbackRetries is the number of times a URL may be requeued by a
+	// callback returning ErrRetry. Defaults to 3.
+	MaxCallbackRetries int
+
+	// Validators check fetched pages before they are parsed, e.g. to detect
+	// soft 404s with NewSoft404Validator. See Validator.
+	Validators []Validator
+
+	// StorageStates shares browser storage state between fetches: the state
+	// returned by a fetch is sent with the next fetches to the same host, so
+	// that cookies and local storage persist as in a browser session.
+	StorageStates *fetch.StorageStates
+
+	// StorageStateFile persists the storage states between crawls. The
+	// states are loaded from the file when the crawler is created, unless
+	// StorageStates is set, and saved to it at the end of each crawl.
+	StorageStateFile string
+
+	// IgnoreRobotsDirectives disables honoring the noindex, nofollow and
+	// none directives of pages, given by their robots meta tag or
+	// X-Robots-Tag header. By default, noindex pages are not parsed or
+	// passed to the callback and the links of nofollow pages are not
+	// followed.
+	IgnoreRobotsDirectives bool
+
+	// CanonicalHosts learns the canonical host of sites, e.g. that
+	// example.com redirects to www.example.com or declares it in its
+	// canonical tags, and rewrites the URLs queued afterwards to the
+	// canonical host, so that both variants are not crawled.
+	CanonicalHosts bool
+
+	// IndexFiles are the names of directory index files whose URLs are
+	// equivalent to that of their directory, so that /path/index.html is
+	// crawled as /path. Set to web.DirectoryIndexFiles for the common names.
+	IndexFiles []string
+
+	// DistinctTrailingSlash treats /path and /path/ as different URLs. By
+	// default, trailing slashes are removed from queued URLs.
+	DistinctTrailingSlash bool
+
+	// PatternRules classify URLs by pattern, in order: the first matching
+	// rule classifies a URL. See PatternRule and Crawler.Classify.
+	PatternRules []*PatternRule
+
+	// ContentTypes restricts the crawl to pages of these media types, e.g.
+	// "text/html". The content type of each URL is checked with a HEAD
+	// request before it is fetched, when the fetcher implements
+	// fetch.Prober. URLs are fetched anyway if the check fails.
+	ContentTypes []string
+
+	// CacheMode determines whether pages are read from and written to the
+	// Cache. Defaults to CacheReadWrite.
+	CacheMode CacheMode
+
+	// CacheMaxAge is the age above which cached pages are fetched again, as
+	// given by their timestamp. When the fetcher supports conditional
+	// requests, expired pages are revalidated with their ETag and
+	// Last-Modified date, and served from the cache if unchanged. It is
+	// also the MaxAge of requests, for fetchers with their own cache.
+	// Cached pages don't expire if zero.
+	CacheMaxAge time.Duration
+
+	// StaleIfError serves the cached version of pages that fail to be
+	// fetched, flagged as stale on the Result, so that crawls degrade
+	// gracefully during site outages. Applies unless the CacheMode is
+	// CacheBypass, e.g. when refreshing a cache with CacheWriteOnly.
+	StaleIfError bool
+
+	// RenderRules customize the rendering of the pages of some domains,
+	// e.g. to drop a cookie banner, without writing a Parser.
+	RenderRules fetch.RenderRules
+
+	// RespectRobots fetches the robots.txt file of each host, skips the URLs
+	// it disallows and waits for its Crawl-delay, up to a minute, between
+	// the fetches from the host. Hosts whose robots.txt fails to be fetched
+	// due to a server or network error are skipped, as required by RFC 9309.
+	RespectRobots bool
+
+	// RobotsUserAgent is the product token matched against the user-agent
+	// lines of robots.txt files, e.g. "MyBot". Only the rules for all user
+	// agents apply if empty.
+	RobotsUserAgent string
+
+	// FollowFeeds fetches the RSS, Atom and JSON feeds linked by pages with
+	// <link rel="alternate"> elements and follows the URLs of their items
+	// as links of the page, e.g. to reach the articles of a news site
+	// beyond those linked by its front page. Each feed is fetched once.
+	FollowFeeds bool
+
+	// MaxHostBandwidth limits the bytes transferred from each host, per
+	// second, e.g. to share the connection of a site with its visitors.
+	// Fetches from a host are delayed by the time the previous ones would
+	// take at this rate. Zero is unlimited. See CrawlerStats.GetHostBytes.
+	MaxHostBandwidth int64
+
+	// IncludePatterns and ExcludePatterns constrain the links followed, by
+	// matching their path, e.g. "/blog/2025/06/post". When include patterns
+	// are set, only the links matching one of them are queued, and links
+	// matching an exclude pattern are never queued. Use MatchGlob rules such
+	// as "/blog/*" or MatchRegex rules such as `^/calendar/\d{4}/`. Links are
+	// matched once normalized, without their query. Seeds are always crawled.
+	IncludePatterns []*MatchRule
+	ExcludePatterns []*MatchRule
+
+	// Frontier records the URLs queued and processed by crawls, so that
+	// they can be resumed with Resume after being stopped or interrupted.
+	// Crawl resets it. See OpenFileFrontier.
+	Frontier Frontier
+
+	// Visited is the set of URLs seen by the crawler, so that each URL is
+	// fetched once. It is kept across crawls. Defaults to a
+	// MemoryVisitedSet.
+	Visited VisitedSet
+
+	// Results retains the results of crawls, before they are passed to the
+	// callback, so that they can be queried after Crawl returns. The
+	// callback of Crawl may be nil when set.
+	Results *ResultStore
+
+	// StatusPolicy defines how responses are handled by status code.
+	// Defaults to DefaultStatusPolicy.
+	StatusPolicy *StatusPolicy
+
+	// PageClassifier determines the type of pages, for the parser rules
+	// matching page types. Defaults to ClassifyResponse.
+	PageClassifier PageClassifier
+
+	// ClassifyPages classifies every page, to set the PageType of results
+	// and count the pages of each type in the crawler stats. Otherwise pages
+	// are only classified when parser rules match page types.
+	ClassifyPages bool
 }
 
+// Errors returned by Crawl when a crawl is aborted due to failures. The
+// returned error also wraps the errors of the failed URLs.
+var (
+	ErrFailFast          = errors.New("crawl stopped after a failure")
+	ErrErrorRateExceeded = errors.New("crawl error rate exceeded")
+)
+
 // Crawler is used to crawl the web.
 type Crawler struct {
-	processedURLs        sync.Map
-	queue                chan string
+	visited              VisitedSet
+	results              *ResultStore
+	statusPolicy         *StatusPolicy
+	pageClassifier       PageClassifier
+	classifyPages        bool
+	redirects            *redirects
+	depths               sync.Map // depth of queued URLs
+	fetchDurations       sync.Map // duration of the last fetch of URLs
+	maxDepth             int
+	queue                *frontier
+	parseQueue           chan *parseJob
+	parseWorkers         int
 	maxURLs              int
 	workers              int
 	requestDelay         time.Duration
@@ -72,11 +309,48 @@ type Crawler struct {
 	followBehavior       FollowBehavior
 	activeWorkers        int64
 	stats                *CrawlerStats
+	failures             *errors.Multi
 	logger               *slog.Logger
+	logThrottle          *throttleHandler
 	running              bool
 	showProgress         bool
 	showProgressInterval time.Duration
 	cancel               context.CancelFunc
+	failFast             bool
+	maxErrorRate         float64
+	errorRateWindow      int
+	failedBefore         int64 // failed count when the crawl started
+	succeededBefore      int64 // succeeded count when the crawl started
+	abortErr             error
+	abortMutex           sync.Mutex
+	maxCallbackRetries   int
+	crawlIDHeader        string
+	requestIDHeader      string
+	retries              sync.Map
+	validators           []Validator
+	storageStates        *fetch.StorageStates
+	storageStateFile     string
+	progress             crawlProgress
+	ignoreRobots         bool
+	canonicalHosts       *canonicalHosts
+	indexFiles           []string
+	keepTrailingSlash    bool
+	patternRules         []*PatternRule
+	patternCounts        map[*PatternRule]int
+	patternMutex         sync.Mutex
+	contentTypes         []string
+	cacheMode            CacheMode
+	cacheMaxAge          time.Duration
+	staleIfError         bool
+	renderRules          fetch.RenderRules
+	robots               *robotsHosts
+	robotsUserAgent      string
+	followFeeds          bool
+	feeds                sync.Map // feeds fetched by the crawler
+	includePatterns      []*MatchRule
+	bandwidth            *bandwidthLimiter
+	excludePatterns      []*MatchRule
+	frontier             Frontier
 }
 
 // New creates a new crawler.
@@ -85,6 +359,14 @@ func New(opts Options) (*Crawler, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
+	if opts.TagLogs {
+		logger = slog.New(NewLogHandler(logger.Handler()))
+	}
+	var logThrottle *throttleHandler
+	if opts.LogThrottle != nil {
+		logThrottle = newThrottleHandler(logger.Handler(), *opts.LogThrottle)
+		logger = slog.New(logThrottle)
+	}
 	if opts.ShowProgress && opts.ShowProgressInterval == 0 {
 		opts.ShowProgressInterval = 30 * time.Second
 	}
@@ -94,9 +376,35 @@ func New(opts Options) (*Crawler, error) {
 	if opts.FollowBehavior == "" {
 		opts.FollowBehavior = FollowSameDomain
 	}
+	if opts.ErrorRateWindow <= 0 {
+		opts.ErrorRateWindow = 100
+		if opts.MaxURLs > 0 && opts.MaxURLs < opts.ErrorRateWindow {
+			opts.ErrorRateWindow = opts.MaxURLs
+		}
+	}
+	if opts.MaxCallbackRetries <= 0 {
+		opts.MaxCallbackRetries = 3
+	}
+	if opts.CacheMode == "" {
+		opts.CacheMode = CacheReadWrite
+	}
+	if err := opts.CacheMode.validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.RenderRules.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.StorageStateFile != "" && opts.StorageStates == nil {
+		states, err := fetch.LoadStorageStates(opts.StorageStateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load storage states: %w", err)
+		}
+		opts.StorageStates = states
+	}
 	c := &Crawler{
 		cache:                opts.Cache,
 		maxURLs:              opts.MaxURLs,
+		maxDepth:             opts.MaxDepth,
 		workers:              opts.Workers,
 		requestDelay:         opts.RequestDelay,
 		defaultFetcher:       opts.DefaultFetcher,
@@ -104,10 +412,68 @@ func New(opts Options) (*Crawler, error) {
 		defaultParser:        opts.DefaultParser,
 		followBehavior:       opts.FollowBehavior,
 		stats:                &CrawlerStats{},
+		failures:             errors.NewMulti(),
 		logger:               logger,
+		logThrottle:          logThrottle,
 		showProgress:         opts.ShowProgress,
 		showProgressInterval: opts.ShowProgressInterval,
-		queue:                make(chan string, opts.QueueSize),
+		queue:                newFrontier(opts.QueueSize),
+		failFast:             opts.FailFast,
+		maxErrorRate:         opts.MaxErrorRate,
+		errorRateWindow:      opts.ErrorRateWindow,
+		maxCallbackRetries:   opts.MaxCallbackRetries,
+		parseWorkers:         opts.ParseWorkers,
+		crawlIDHeader:        opts.CrawlIDHeader,
+		requestIDHeader:      opts.RequestIDHeader,
+		validators:           opts.Validators,
+		storageStates:        opts.StorageStates,
+		storageStateFile:     opts.StorageStateFile,
+		ignoreRobots:         opts.IgnoreRobotsDirectives,
+		indexFiles:           opts.IndexFiles,
+		keepTrailingSlash:    opts.DistinctTrailingSlash,
+		patternRules:         opts.PatternRules,
+		contentTypes:         opts.ContentTypes,
+		cacheMode:            opts.CacheMode,
+		cacheMaxAge:          opts.CacheMaxAge,
+		staleIfError:         opts.StaleIfError,
+		renderRules:          opts.RenderRules,
+		robotsUserAgent:      opts.RobotsUserAgent,
+		followFeeds:          opts.FollowFeeds,
+		includePatterns:      opts.IncludePatterns,
+		excludePatterns:      opts.ExcludePatterns,
+		frontier:             opts.Frontier,
+		visited:              opts.Visited,
+		results:              opts.Results,
+		statusPolicy:         opts.StatusPolicy,
+		pageClassifier:       opts.PageClassifier,
+		classifyPages:        opts.ClassifyPages,
+		patternCounts:        map[*PatternRule]int{},
+	}
+	if c.visited == nil {
+		c.visited = NewMemoryVisitedSet()
+	}
+	if c.pageClassifier == nil {
+		c.pageClassifier = ClassifyResponse
+	}
+	if c.statusPolicy == nil {
+		c.statusPolicy = DefaultStatusPolicy()
+	} else if err := c.statusPolicy.validate(); err != nil {
+		return nil, err
+	}
+	if c.statusPolicy.FollowPermanentRedirects {
+		c.redirects = newRedirects()
+	}
+	if opts.CanonicalHosts {
+		c.canonicalHosts = newCanonicalHosts()
+	}
+	if opts.RespectRobots {
+		c.robots = newRobotsHosts()
+	}
+	if opts.MaxHostBandwidth > 0 {
+		c.bandwidth = newBandwidthLimiter(opts.MaxHostBandwidth)
+	}
+	if opts.ParseWorkers > 0 {
+		c.parseQueue = make(chan *parseJob, opts.ParseWorkers)
 	}
 	if err := c.AddParserRules(opts.ParserRules...); err != nil {
 		return nil, err
@@ -115,6 +481,16 @@ func New(opts Options) (*Crawler, error) {
 	if err := c.AddFetcherRules(opts.FetcherRules...); err != nil {
 		return nil, err
 	}
+	for _, rule := range c.patternRules {
+		if err := rule.Compile(); err != nil {
+			return nil, err
+		}
+	}
+	for _, rule := range slices.Concat(c.includePatterns, c.excludePatterns) {
+		if err := rule.Compile(); err != nil {
+			return nil, fmt.Errorf("invalid url pattern %q: %w", rule.Pattern, err)
+		}
+	}
 	return c, nil
 }
 
@@ -180,28 +556,84 @@ func (c *Crawler) getActiveWorkers() int64 {
 }
 
 // Crawl the provided URLs and call the callback for each processed page.
-// Links may be followed depending on the configured follow behavior.
+// Links may be followed depending on the configured follow behavior. The
+// fetchers are warmed up and checked first, see fetch.PrepareFetcher, and the
+// crawl fails if one of them is unhealthy.
 func (c *Crawler) Crawl(ctx context.Context, urls []string, callback Callback) error {
+	return c.crawl(ctx, urls, nil, false, callback)
+}
+
+// crawl crawls from the seed URLs, or resumes a crawl from its pending URLs.
+func (c *Crawler) crawl(ctx context.Context, urls []string, pending []FrontierURL, resume bool, callback Callback) error {
 	if c.running {
 		return errors.New("crawler is already running")
 	}
 	c.running = true
 
+	// Failures and the error rate only cover the current crawl
+	c.failures = errors.NewMulti()
+	c.failedBefore = c.stats.GetFailed()
+	c.succeededBefore = c.stats.GetSucceeded()
+
+	if err := c.prepareFetchers(ctx); err != nil {
+		c.running = false
+		return err
+	}
+	if c.frontier != nil && !resume {
+		if err := c.frontier.Reset(); err != nil {
+			c.running = false
+			return fmt.Errorf("failed to reset frontier: %w", err)
+		}
+	}
+	if resume {
+		for _, u := range pending {
+			urls = append(urls, u.URL)
+		}
+	}
+	// URLs left queued by a stopped crawl are pending in the frontier, if
+	// any, and are not crawled otherwise
+	c.queue.clear()
+
+	// Identify the crawl in log records
+	ctx = withLogFields(ctx, func(f *logFields) {
+		f.crawlID = newID()
+		f.seedDomain = seedDomain(urls)
+	})
+
 	// This context will be used to stop workers when the work is done
 	ctx, c.cancel = context.WithCancel(ctx)
+	c.progress.start()
 	defer func() {
+		c.progress.stop()
+		if c.logThrottle != nil {
+			c.logThrottle.summarize(ctx)
+		}
 		c.running = false
 		c.cancel()
 		c.cancel = nil
 	}()
+	if c.storageStateFile != "" {
+		defer func() {
+			if err := c.storageStates.Save(c.storageStateFile); err != nil {
+				c.logger.ErrorContext(ctx, "failed to save storage states",
+					slog.String("path", c.storageStateFile),
+					slog.String("error", err.Error()))
+			}
+		}()
+	}
 
 	// Start workers
 	var wg sync.WaitGroup
 	for i := 0; i < c.workers; i++ {
 		wg.Add(1)
-		go c.worker(ctx, &wg, callback)
+		workerCtx := withLogFields(ctx, func(f *logFields) { f.workerID = i + 1 })
+		go c.worker(workerCtx, &wg, callback)
+	}
+	for i := 0; i < c.parseWorkers; i++ {
+		wg.Add(1)
+		workerCtx := withLogFields(ctx, func(f *logFields) { f.parseWorkerID = i + 1 })
+		go c.parseWorker(workerCtx, &wg, callback)
 	}
-	defer close(c.queue)
 
 	// Optionally start the progress reporter
 	if c.showProgress {
@@ -212,7 +644,19 @@ func (c *Crawler) Crawl(ctx context.Context, urls []string, callback Callback) e
 	go c.idleMonitor(ctx, c.cancel)
 
 	// Queue initial URLs
-	count, err := c.enqueue(ctx, urls)
+	var count int
+	var err error
+	if resume {
+		for _, u := range pending {
+			n, enqueueErr := c.enqueueURLs(ctx, []string{u.URL}, u.Depth, true)
+			if err = enqueueErr; err != nil {
+				break
+			}
+			count += n
+		}
+	} else {
+		count, err = c.enqueue(ctx, urls, 0)
+	}
 	if err != nil {
 		return err
 	}
@@ -222,16 +666,60 @@ func (c *Crawler) Crawl(ctx context.Context, urls []string, callback Callback) e
 
 	// Wait for workers to complete
 	wg.Wait()
+
+	c.abortMutex.Lock()
+	defer c.abortMutex.Unlock()
+	if c.abortErr != nil {
+		err := errors.Join(c.abortErr, c.GetErrors())
+		c.abortErr = nil
+		return err
+	}
 	return nil
 }
 
+// seedDomain returns the host of the first valid seed URL.
+func seedDomain(urls []string) string {
+	for _, rawURL := range urls {
+		if u, err := web.NormalizeURL(rawURL); err == nil {
+			return u.Hostname()
+		}
+	}
+	return ""
+}
+
 func (c *Crawler) Stop() {
 	if c.cancel != nil {
 		c.cancel()
 	}
 }
 
-func (c *Crawler) enqueue(ctx context.Context, urls []string) (int, error) {
+// queueValue returns the form of a URL used to queue and deduplicate it.
+func (c *Crawler) queueValue(rawURL string) (string, error) {
+	u, err := web.NormalizeURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if len(c.indexFiles) > 0 {
+		if path := web.TrimDirectoryIndex(u.Path, c.indexFiles); path != u.Path {
+			u.Path, u.RawPath = path, ""
+		}
+	}
+	value := u.String()
+	if !c.keepTrailingSlash {
+		value = strings.TrimSuffix(value, "/")
+	}
+	return value, nil
+}
+
+// enqueue queues the URLs that were not seen before, found at the given
+// depth, and returns how many were queued.
+func (c *Crawler) enqueue(ctx context.Context, urls []string, depth int) (int, error) {
+	return c.enqueueURLs(ctx, urls, depth, false)
+}
+
+// enqueueURLs queues URLs found at the given depth. Seen URLs are only
+// queued again if requeue is true, as when resuming their crawl.
+func (c *Crawler) enqueueURLs(ctx context.Context, urls []string, depth int, requeue bool) (int, error) {
 	// Prevent exceeding the max URLs limit
 	if c.maxURLs > 0 {
 		allowedCount := c.maxURLs - int(c.stats.GetProcessed())
@@ -245,70 +733,122 @@ func (c *Crawler) enqueue(ctx context.Context, urls []string) (int, error) {
 	// Normalize and enqueue the URLs
 	queued := 0
 	for _, rawURL := range urls {
-		url, err := web.NormalizeURL(rawURL)
+		value, err := c.queueValue(rawURL)
 		if err != nil {
-			c.logger.Warn("invalid url",
+			c.logger.WarnContext(ctx, "invalid url",
 				slog.String("url", rawURL),
 				slog.String("error", err.Error()))
 			continue
 		}
-		value := strings.TrimSuffix(url.String(), "/")
-		// Only enqueue if not already processed
-		if _, exists := c.processedURLs.LoadOrStore(value, true); !exists {
-			select {
-			case c.queue <- value:
-				queued++
-			case <-ctx.Done():
-				return queued, ctx.Err()
-			default:
-				// Queue is full, skip this URL
-			}
+		value = c.redirects.rewrite(c.canonicalHosts.rewrite(value))
+		// Only enqueue if not already processed. Requeued URLs were
+		// already counted against their pattern budget.
+		seen := !c.visited.Add(value)
+		if seen && !requeue {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return queued, err
+		}
+		if !seen && !c.takePatternBudget(value) {
+			c.logger.DebugContext(ctx, "pattern budget reached", slog.String("url", value))
+			continue
+		}
+		// URLs are skipped when the queue is full
+		if c.queue.push(value) {
+			c.depths.Store(value, depth)
+			c.checkpointQueued(ctx, value, depth)
+			queued++
 		}
 	}
 	return queued, nil
 }
 
 func (c *Crawler) worker(ctx context.Context, wg *sync.WaitGroup, callback Callback) {
+	defer wg.Done()
+	for {
+		rawURL, ok := c.queue.pop(ctx)
+		if !ok {
+			return
+		}
+		c.incrementActiveWorkers()
+		c.processURL(ctx, rawURL, callback)
+		c.decrementActiveWorkers()
+		if c.requestDelay > 0 {
+			time.Sleep(c.requestDelay)
+		}
+	}
+}
+
+// parseJob is a fetched page waiting to be parsed.
+type parseJob struct {
+	rawURL    string
+	url       *url.URL
+	response  *fetch.Response
+	staleErr  error
+	requestID string
+}
+
+func (c *Crawler) parseWorker(ctx context.Context, wg *sync.WaitGroup, callback Callback) {
 	defer wg.Done()
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case rawURL, ok := <-c.queue:
-			if !ok {
-				return
+		case job := <-c.parseQueue:
+			if ctx.Err() == nil {
+				jobCtx := withLogFields(ctx, func(f *logFields) { f.requestID = job.requestID })
+				retries := c.retryCount(job.rawURL)
+				c.processResponse(jobCtx, job.rawURL, job.url, job.response, job.staleErr, callback)
+				if c.retryCount(job.rawURL) == retries {
+					c.checkpointDone(jobCtx, job.rawURL)
+				}
 			}
-			c.incrementActiveWorkers()
-			c.processURL(ctx, rawURL, callback)
+			// Balances the increment made when the job was queued
 			c.decrementActiveWorkers()
-			if c.requestDelay > 0 {
-				time.Sleep(c.requestDelay)
-			}
 		}
 	}
 }
 
 func (c *Crawler) processURL(ctx context.Context, rawURL string, callback Callback) {
 	c.stats.IncrementProcessed()
+	ctx = withLogFields(ctx, func(f *logFields) { f.requestID = newID() })
+
+	// Pages handed off to the parse workers are recorded as processed once
+	// parsed, and requeued pages remain pending
+	handedOff := false
+	retries := c.retryCount(rawURL)
+	defer func() {
+		if !handedOff && c.retryCount(rawURL) == retries {
+			c.checkpointDone(ctx, rawURL)
+		}
+	}()
 
 	// Parse the url to get its domain
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
-		c.logger.Warn("invalid url",
+		c.logger.WarnContext(ctx, "invalid url",
 			slog.String("url", rawURL),
 			slog.String("error", err.Error()))
 		return
 	}
 	domain := parsedURL.Hostname()
 
-	// Check cache first if one is enabled
-	var response *fetch.Response
-	if c.cache != nil {
-		if cachedHTML, err := c.cache.Get(ctx, rawURL); err == nil {
-			c.logger.Debug("cache hit", slog.String("url", rawURL))
-			response = &fetch.Response{
-				URL:  rawURL,
-				HTML: string(cachedHTML),
+	if !c.robotsAllowed(ctx, parsedURL) {
+		c.logger.DebugContext(ctx, "skipping url disallowed by robots.txt", slog.String("url", rawURL))
+		return
+	}
+
+	// Check cache first if one is enabled. Expired pages are fetched again,
+	// conditionally if possible.
+	var response, expired *fetch.Response
+	if c.cache != nil && c.cacheMode.reads() {
+		if response = c.cachedResponse(ctx, rawURL); response != nil {
+			if c.expired(response) {
+				c.logger.DebugContext(ctx, "cache entry expired", slog.String("url", rawURL))
+				expired, response = response, nil
+			} else {
+				c.logger.DebugContext(ctx, "cache hit", slog.String("url", rawURL))
 			}
 		}
 	}
@@ -316,11 +856,17 @@ func (c *Crawler) processURL(ctx context.Context, rawURL string, callback Callba
 	// Get the appropriate fetcher for this domain
 	fetcher, exists := c.getFetcher(domain)
 	if !exists {
-		c.logger.Error("no fetcher configured",
+		c.logger.ErrorContext(ctx, "no fetcher configured",
 			slog.String("url", rawURL),
 			slog.String("domain", domain))
-		callback(ctx, &Result{URL: parsedURL, Error: errors.New("no fetcher configured for domain")})
-		c.stats.IncrementFailed()
+		err := errors.New("no fetcher configured for domain")
+		if c.handleCallback(ctx, callback, rawURL, &Result{URL: parsedURL, Error: err}) != callbackRetried {
+			c.recordFailure(ctx, rawURL, err)
+		}
+		return
+	}
+
+	if response == nil && !c.allowedContentType(ctx, fetcher, rawURL) {
 		return
 	}
 
@@ -332,67 +878,385 @@ func (c *Crawler) processURL(ctx context.Context, rawURL string, callback Callba
 		// Note: The Fetcher field in Request is for specifying a fetcher name/type
 		// We'll leave it empty and use the actual fetcher instance directly
 	}
+	if c.crawlIDHeader != "" || c.requestIDHeader != "" {
+		req.Headers = map[string]string{}
+		if c.crawlIDHeader != "" {
+			req.Headers[c.crawlIDHeader] = CrawlIDFromContext(ctx)
+		}
+		if c.requestIDHeader != "" {
+			req.Headers[c.requestIDHeader] = RequestIDFromContext(ctx)
+		}
+	}
+	c.renderRules.Apply(req)
+	if c.cacheMaxAge > 0 {
+		req.MaxAge = int(c.cacheMaxAge.Milliseconds())
+	}
+	// Only pass storage states to fetchers that can restore them
+	capabilities := fetch.FetcherCapabilities(fetcher)
+	if c.storageStates != nil && (capabilities == nil || capabilities.StorageState) {
+		req.StorageState = c.storageStates.Get(domain)
+	}
+	if expired != nil && capabilities != nil && capabilities.ConditionalRequests {
+		for name, value := range fetch.ConditionalHeaders(expired) {
+			if req.Headers == nil {
+				req.Headers = map[string]string{}
+			}
+			req.Headers[name] = value
+		}
+	}
 
 	// Fetch if there was not a cache hit
+	var staleErr error
 	if response == nil {
-		c.logger.Debug("fetching", slog.String("url", rawURL))
-		response, err = fetcher.Fetch(ctx, req)
+		c.logger.DebugContext(ctx, "fetching", slog.String("url", rawURL))
+		err = fetch.ValidateRequest(req, capabilities)
+		if err == nil {
+			err = c.waitCrawlDelay(ctx, parsedURL)
+		}
+		if err == nil {
+			err = c.bandwidth.wait(ctx, domain)
+		}
+		if err == nil {
+			done := c.progress.fetching(domain, rawURL)
+			fetchStart := time.Now()
+			response, err = fetcher.Fetch(ctx, req)
+			c.fetchDurations.Store(rawURL, time.Since(fetchStart))
+			done()
+		}
 		if err != nil {
-			callback(ctx, &Result{URL: parsedURL, Error: err})
-			c.stats.IncrementFailed()
-			return
+			if response = c.staleResponse(ctx, rawURL, err); response == nil {
+				if c.handleCallback(ctx, callback, rawURL, &Result{URL: parsedURL, Error: err}) != callbackRetried {
+					c.recordFailure(ctx, rawURL, err)
+				}
+				return
+			}
+			staleErr = err
+		} else {
+			bytes := responseBytes(response)
+			c.stats.AddBytes(domain, bytes.Bytes, bytes.Transferred)
+			c.progress.downloaded(domain, bytes)
+			c.bandwidth.record(domain, bytes.Transferred)
+			if response.NotModified() && expired != nil {
+				c.logger.DebugContext(ctx, "cache entry revalidated", slog.String("url", rawURL))
+				expired.Timestamp = response.Timestamp
+				response = expired
+			}
+			if c.storageStates != nil {
+				c.storageStates.Set(domain, response.StorageState)
+			}
+			if c.canonicalHosts != nil {
+				c.learnCanonicalHost(parsedURL, response)
+			}
+			if c.redirects != nil && response.PermanentRedirect && response.FinalURL != "" {
+				c.learnRedirect(rawURL, response.FinalURL)
+			}
+			if c.cache != nil && c.cacheMode.writes() && response.HTML != "" {
+				c.cacheResponse(ctx, rawURL, response)
+			}
 		}
-		if c.cache != nil && response.HTML != "" {
-			if err := c.cache.Set(ctx, rawURL, []byte(response.HTML)); err != nil {
-				c.logger.Warn("failed to cache html",
-					slog.String("url", rawURL),
-					slog.String("error", err.Error()))
+	}
+
+	if c.parseQueue == nil {
+		c.processResponse(ctx, rawURL, parsedURL, response, staleErr, callback)
+		return
+	}
+	// Count the queued job as active work so the crawl isn't considered idle
+	c.incrementActiveWorkers()
+	select {
+	case c.parseQueue <- &parseJob{rawURL: rawURL, url: parsedURL, response: response, staleErr: staleErr, requestID: RequestIDFromContext(ctx)}:
+		handedOff = true
+	case <-ctx.Done():
+		c.decrementActiveWorkers()
+	}
+}
+
+// processResponse parses a fetched page, calls the callback and enqueues the
+// links to follow. The error of the fetch is given for stale responses.
+func (c *Crawler) processResponse(ctx context.Context, rawURL string, parsedURL *url.URL, response *fetch.Response, staleErr error, callback Callback) {
+	domain := parsedURL.Hostname()
+
+	// Reject pages that fail validation
+	for _, validator := range c.validators {
+		if err := validator.Validate(ctx, response); err != nil {
+			c.logger.WarnContext(ctx, "page failed validation",
+				slog.String("url", rawURL),
+				slog.String("error", err.Error()))
+			result := &Result{URL: parsedURL, Response: response, Error: err}
+			if c.handleCallback(ctx, callback, rawURL, result) != callbackRetried {
+				c.recordFailure(ctx, rawURL, err)
 			}
+			return
 		}
 	}
 
-	// Parse if a parser exists for the domain
+	// Handle the page as defined by the status policy
+	flagged := false
+	switch action := c.statusPolicy.Action(response.StatusCode); action {
+	case StatusFail, StatusGone:
+		err := &StatusError{StatusCode: response.StatusCode, Gone: action == StatusGone}
+		c.logger.DebugContext(ctx, "page failed status policy",
+			slog.String("url", rawURL),
+			slog.Int("status_code", response.StatusCode))
+		result := &Result{URL: parsedURL, Response: response, Error: err}
+		if c.handleCallback(ctx, callback, rawURL, result) != callbackRetried {
+			c.recordFailure(ctx, rawURL, err)
+		}
+		return
+	case StatusFlag:
+		flagged = true
+	}
+
+	// Honor the robots directives of the page
+	var robots web.RobotsDirectives
+	if !c.ignoreRobots {
+		robots = response.RobotsDirectives()
+	}
+
+	// Extract URLs from the page
+	var discoveredLinks []string
+	if response.Links != nil {
+		discoveredLinks = c.extractURLs(response.Links, linkBase(domain, response))
+	}
+
+	// Pages that are not to be indexed are neither parsed nor passed to the
+	// callback, but their links are followed unless they are nofollow
+	action := callbackContinue
+	if robots.NoIndex {
+		c.logger.DebugContext(ctx, "skipping noindex page", slog.String("url", rawURL))
+	} else {
+		action = c.parsePage(ctx, callback, rawURL, parsedURL, response, discoveredLinks, staleErr, flagged)
+		if action == callbackRetried {
+			return
+		}
+	}
+	c.stats.IncrementSucceeded()
+	if action != callbackContinue {
+		return
+	}
+	if robots.NoFollow {
+		c.logger.DebugContext(ctx, "not following links of nofollow page", slog.String("url", rawURL))
+		return
+	}
+
+	depth := c.depth(rawURL)
+	if c.maxDepth > 0 && depth >= c.maxDepth {
+		c.logger.DebugContext(ctx, "not following links beyond max depth",
+			slog.String("url", rawURL),
+			slog.Int("depth", depth))
+		return
+	}
+
+	if c.followFeeds {
+		discoveredLinks = append(discoveredLinks, c.feedLinks(ctx, rawURL, response)...)
+	}
+	filteredURLs := c.filterLinks(parsedURL, discoveredLinks)
+	if _, err := c.enqueue(ctx, filteredURLs, depth+1); err != nil {
+		c.logger.WarnContext(ctx, "failed to enqueue discovered urls",
+			slog.String("url", rawURL),
+			slog.String("error", err.Error()))
+	}
+}
+
+// parsePage parses a page with the parser of its domain, if any, and calls
+// the callback with the result.
+func (c *Crawler) parsePage(ctx context.Context, callback Callback, rawURL string, parsedURL *url.URL, response *fetch.Response, links []string, staleErr error, flagged bool) callbackAction {
 	var parsed any
 	var parseErr error
-	parser, exists := c.getParser(domain)
+	rule, params := c.Classify(rawURL)
+	// The page type is only determined if needed
+	var pageType *web.PageType
+	classify := func() web.PageType {
+		if pageType == nil {
+			value := c.pageClassifier(response)
+			pageType = &value
+		}
+		return *pageType
+	}
+	parser, exists := c.getParser(parsedURL.Hostname(), response, classify)
+	if rule != nil && rule.Parser != nil {
+		parser, exists = rule.Parser, true
+	}
 	if exists {
-		c.logger.Info("parsing with domain parser",
+		c.logger.InfoContext(ctx, "parsing with domain parser",
 			slog.String("url", rawURL),
-			slog.String("domain", domain))
+			slog.String("domain", parsedURL.Hostname()))
 		parsed, parseErr = parser.Parse(ctx, response)
 		if parseErr != nil {
-			c.logger.Error("failed to parse",
+			c.logger.ErrorContext(ctx, "failed to parse",
 				slog.String("url", rawURL),
 				slog.String("error", parseErr.Error()))
 		}
 	}
-
-	// Extract URLs from the page
-	var discoveredLinks []string
-	if response.Links != nil {
-		discoveredLinks = c.extractURLs(response.Links, domain)
-	}
-	callback(ctx, &Result{
+	result := &Result{
 		URL:      parsedURL,
 		Parsed:   parsed,
-		Links:    discoveredLinks,
+		Links:    links,
 		Response: response,
 		Error:    parseErr,
-	})
-	c.stats.IncrementSucceeded()
+		Flagged:  flagged,
+	}
+	if staleErr != nil {
+		result.Stale, result.StaleError = true, staleErr
+	}
+	if rule != nil {
+		result.Pattern, result.PatternParams = rule.Name, params
+	}
+	if c.classifyPages {
+		result.PageType = classify()
+		c.stats.IncrementPageType(result.PageType)
+	}
+	return c.handleCallback(ctx, callback, rawURL, result)
+}
 
-	filteredURLs := c.filterLinks(parsedURL, discoveredLinks)
-	if _, err := c.enqueue(ctx, filteredURLs); err != nil {
-		c.logger.Warn("failed to enqueue discovered urls",
+// takePatternBudget counts a URL against the budget of its PatternRule.
+// Returns false if the budget is exhausted.
+func (c *Crawler) takePatternBudget(rawURL string) bool {
+	rule, _ := c.Classify(rawURL)
+	if rule == nil || rule.MaxURLs <= 0 {
+		return true
+	}
+	c.patternMutex.Lock()
+	defer c.patternMutex.Unlock()
+	if c.patternCounts[rule] >= rule.MaxURLs {
+		return false
+	}
+	c.patternCounts[rule]++
+	return true
+}
+
+// Classify returns the first PatternRule matching the URL and the parameters
+// of the URL in its pattern, or nil if no rule matches.
+func (c *Crawler) Classify(rawURL string) (*PatternRule, map[string]string) {
+	for _, rule := range c.patternRules {
+		if params, ok := rule.Match(rawURL); ok {
+			return rule, params
+		}
+	}
+	return nil, nil
+}
+
+// learnCanonicalHost learns the canonical host of a site from the redirect
+// or canonical tag of a page. The final URL of a redirect is marked as
+// processed, so that it is not fetched again.
+func (c *Crawler) learnCanonicalHost(pageURL *url.URL, response *fetch.Response) {
+	if response.FinalURL != "" {
+		c.canonicalHosts.learn(pageURL, response.FinalURL)
+		if value, err := c.queueValue(response.FinalURL); err == nil {
+			c.visited.Add(value)
+		}
+	}
+	if canonical := response.Metadata.CanonicalURL; canonical != "" {
+		if response.FinalURL != "" {
+			if finalURL, err := url.Parse(response.FinalURL); err == nil {
+				pageURL = finalURL
+			}
+		}
+		c.canonicalHosts.learn(pageURL, canonical)
+	}
+}
+
+// learnRedirect records that a URL is permanently redirected. The target is
+// marked as processed, so that it is not fetched again.
+func (c *Crawler) learnRedirect(rawURL, finalURL string) {
+	value, err := c.queueValue(finalURL)
+	if err != nil {
+		return
+	}
+	c.redirects.add(rawURL, value)
+	c.visited.Add(value)
+}
+
+// callbackAction is how processing of a page proceeds after its callback.
+type callbackAction int
+
+const (
+	callbackContinue callbackAction = iota
+	callbackSkipLinks
+	callbackRetried
+)
+
+// handleCallback calls the callback with the result and applies the returned
+// error as described by Callback.
+func (c *Crawler) handleCallback(ctx context.Context, callback Callback, rawURL string, result *Result) callbackAction {
+	result.CrawlID = CrawlIDFromContext(ctx)
+	result.RequestID = RequestIDFromContext(ctx)
+	result.Depth = c.depth(rawURL)
+	if value, ok := c.fetchDurations.LoadAndDelete(rawURL); ok {
+		result.FetchDuration = value.(time.Duration)
+	}
+	if c.results != nil {
+		c.results.Add(result)
+	}
+	var err error
+	if callback != nil {
+		err = callback(ctx, result)
+	}
+	switch {
+	case err == nil:
+		return callbackContinue
+	case errors.Is(err, ErrAbort):
+		c.abort(ctx, err)
+		return callbackSkipLinks
+	case errors.Is(err, ErrRetry):
+		if errors.Is(result.Error, ErrGone) {
+			c.logger.DebugContext(ctx, "not retrying gone url", slog.String("url", rawURL))
+			return callbackContinue
+		}
+		if c.retry(ctx, rawURL) {
+			return callbackRetried
+		}
+		return callbackContinue
+	case errors.Is(err, ErrSkipLinks):
+		return callbackSkipLinks
+	default:
+		c.logger.WarnContext(ctx, "callback error",
 			slog.String("url", rawURL),
 			slog.String("error", err.Error()))
+		return callbackContinue
+	}
+}
+
+// depth returns the depth at which a queued URL was found.
+func (c *Crawler) depth(rawURL string) int {
+	if value, ok := c.depths.Load(rawURL); ok {
+		return value.(int)
+	}
+	return 0
+}
+
+// retryCount returns the number of times a URL was requeued by callbacks.
+func (c *Crawler) retryCount(rawURL string) int {
+	if value, ok := c.retries.Load(rawURL); ok {
+		return value.(int)
 	}
+	return 0
 }
 
-func (c *Crawler) getParser(domain string) (Parser, bool) {
+// retry requeues a URL that was already processed. Returns false if the URL
+// has exhausted its retries or the queue is full.
+func (c *Crawler) retry(ctx context.Context, rawURL string) bool {
+	attempts := c.retryCount(rawURL)
+	if attempts >= c.maxCallbackRetries {
+		c.logger.WarnContext(ctx, "callback retries exhausted",
+			slog.String("url", rawURL),
+			slog.Int("attempts", attempts))
+		return false
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	if !c.queue.push(rawURL) {
+		c.logger.WarnContext(ctx, "queue is full, not retrying url", slog.String("url", rawURL))
+		return false
+	}
+	c.retries.Store(rawURL, attempts+1)
+	return true
+}
+
+func (c *Crawler) getParser(domain string, response *fetch.Response, classify func() web.PageType) (Parser, bool) {
 	// Check parser rules (already sorted by priority)
 	for _, rule := range c.parserRules {
-		if rule.Matches(domain) {
+		if rule.Matches(domain) && rule.Condition.Matches(response, classify) {
 			return rule.Parser, true
 		}
 	}
@@ -403,6 +1267,30 @@ func (c *Crawler) getParser(domain string) (Parser, bool) {
 	return nil, false
 }
 
+// allowedContentType checks the content type of a URL against the allowed
+// content types with a HEAD request, if the fetcher supports it.
+func (c *Crawler) allowedContentType(ctx context.Context, fetcher fetch.Fetcher, rawURL string) bool {
+	if len(c.contentTypes) == 0 {
+		return true
+	}
+	prober, ok := fetcher.(fetch.Prober)
+	if !ok {
+		return true
+	}
+	info, err := prober.Head(ctx, rawURL)
+	if err != nil || info.StatusCode >= 300 || info.ContentType == "" {
+		return true
+	}
+	mediaType := info.MediaType()
+	if slices.ContainsFunc(c.contentTypes, func(t string) bool { return fetch.MediaType(t) == mediaType }) {
+		return true
+	}
+	c.logger.DebugContext(ctx, "skipping url with content type",
+		slog.String("url", rawURL),
+		slog.String("content_type", info.ContentType))
+	return false
+}
+
 // getFetcher returns the appropriate fetcher for the given domain based on rules
 func (c *Crawler) getFetcher(domain string) (fetch.Fetcher, bool) {
 	// Check fetcher rules (already sorted by priority)
@@ -418,6 +1306,25 @@ func (c *Crawler) getFetcher(domain string) (fetch.Fetcher, bool) {
 	return nil, false
 }
 
+// prepareFetchers warms up the fetchers of the crawler and checks their
+// health, so that a crawl with a misconfigured fetcher fails on startup
+// rather than on every URL.
+func (c *Crawler) prepareFetchers(ctx context.Context) error {
+	fetchers := []fetch.Fetcher{c.defaultFetcher}
+	for _, rule := range c.fetcherRules {
+		fetchers = append(fetchers, rule.Fetcher)
+	}
+	for _, fetcher := range fetchers {
+		if fetcher == nil {
+			continue
+		}
+		if err := fetch.PrepareFetcher(ctx, fetcher); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *Crawler) filterLinks(pageURL *url.URL, links []string) []string {
 	if c.followBehavior == FollowNone {
 		return nil
@@ -428,6 +1335,9 @@ func (c *Crawler) filterLinks(pageURL *url.URL, links []string) []string {
 		if err != nil {
 			continue
 		}
+		if !c.matchesURLPatterns(u) {
+			continue
+		}
 		switch c.followBehavior {
 		case FollowAny:
 			filtered = append(filtered, rawURL)
@@ -444,10 +1354,52 @@ func (c *Crawler) filterLinks(pageURL *url.URL, links []string) []string {
 	return filtered
 }
 
-func (c *Crawler) extractURLs(links []*fetch.Link, domain string) []string {
+// matchesURLPatterns returns true if the path of a URL matches the include
+// patterns, if any, and none of the exclude patterns.
+func (c *Crawler) matchesURLPatterns(u *url.URL) bool {
+	if len(c.includePatterns) == 0 && len(c.excludePatterns) == 0 {
+		return true
+	}
+	value := u.EscapedPath()
+	if value == "" {
+		value = "/"
+	}
+	for _, rule := range c.excludePatterns {
+		if rule.Matches(value) {
+			return false
+		}
+	}
+	if len(c.includePatterns) == 0 {
+		return true
+	}
+	for _, rule := range c.includePatterns {
+		if rule.Matches(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkBase returns what the links of a page are resolved against: the base
+// URL of the page if it has a base element, or its domain.
+func linkBase(domain string, response *fetch.Response) string {
+	if response.Metadata.BaseURL == "" {
+		return domain
+	}
+	pageURL := response.URL
+	if response.FinalURL != "" {
+		pageURL = response.FinalURL
+	}
+	if base := web.ResolveBase(pageURL, response.Metadata.BaseURL); base != "" {
+		return base
+	}
+	return domain
+}
+
+func (c *Crawler) extractURLs(links []*fetch.Link, base string) []string {
 	urlMap := make(map[string]bool)
 	for _, link := range links {
-		if url, ok := web.ResolveLink(domain, link.URL); ok {
+		if url, ok := web.ResolveLink(base, link.URL); ok {
 			urlMap[url] = true
 		}
 	}
@@ -467,10 +1419,13 @@ func (c *Crawler) progressReporter(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			c.logger.Info("crawl progress",
+			bytes := c.stats.GetBytes()
+			c.logger.InfoContext(ctx, "crawl progress",
 				slog.Int64("processed", c.stats.GetProcessed()),
 				slog.Int64("succeeded", c.stats.GetSucceeded()),
-				slog.Int64("failed", c.stats.GetFailed()))
+				slog.Int64("failed", c.stats.GetFailed()),
+				slog.Int64("bytes", bytes.Bytes),
+				slog.Int64("transferred_bytes", bytes.Transferred))
 		}
 	}
 }
@@ -480,6 +1435,45 @@ func (c *Crawler) GetStats() *CrawlerStats {
 	return c.stats
 }
 
+// GetErrors returns the URLs that failed to be fetched during the last crawl
+// as an *errors.Multi of *URLError values, or nil if there were no failures.
+func (c *Crawler) GetErrors() error {
+	return c.failures.ErrorOrNil()
+}
+
+// recordFailure counts a failed URL and keeps its error. Stops the crawl if
+// the failure exceeds the configured error tolerance.
+func (c *Crawler) recordFailure(ctx context.Context, rawURL string, err error) {
+	c.stats.IncrementFailed()
+	c.failures.Append(&URLError{URL: rawURL, Err: err})
+
+	var abortErr error
+	failed := c.stats.GetFailed() - c.failedBefore
+	completed := failed + c.stats.GetSucceeded() - c.succeededBefore
+	if c.failFast {
+		abortErr = ErrFailFast
+	} else if c.maxErrorRate > 0 && completed >= int64(c.errorRateWindow) {
+		if rate := float64(failed) / float64(completed); rate > c.maxErrorRate {
+			abortErr = fmt.Errorf("%w: %d of %d fetches failed", ErrErrorRateExceeded, failed, completed)
+		}
+	}
+	if abortErr != nil {
+		c.abort(ctx, abortErr)
+	}
+}
+
+// abort stops the crawl and causes Crawl to return the given error. Only the
+// first abort error is kept.
+func (c *Crawler) abort(ctx context.Context, abortErr error) {
+	c.abortMutex.Lock()
+	defer c.abortMutex.Unlock()
+	if c.abortErr == nil {
+		c.abortErr = abortErr
+		c.logger.WarnContext(ctx, "aborting crawl", slog.String("reason", abortErr.Error()))
+		c.Stop()
+	}
+}
+
 func (c *Crawler) idleMonitor(ctx context.Context, cancel context.CancelFunc) {
 	// Check every second for idle state
 	ticker := time.NewTicker(1 * time.Second)
@@ -491,8 +1485,8 @@ func (c *Crawler) idleMonitor(ctx context.Context, cancel context.CancelFunc) {
 			return
 		case <-ticker.C:
 			// Check if we're idle: no active workers and queue is empty
-			if c.getActiveWorkers() == 0 && len(c.queue) == 0 {
-				c.logger.Info("no more work available, stopping crawler")
+			if c.getActiveWorkers() == 0 && c.queue.len() == 0 {
+				c.logger.InfoContext(ctx, "no more work available, stopping crawler")
 				cancel() // Cancel context to stop all workers
 				return
 			}
@@ -62,10 +62,11 @@ func TestCrawlerWithFetcherRules(t *testing.T) {
 
 	// Track which URLs were crawled and their responses
 	results := make(map[string]string)
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		if result.Response != nil {
 			results[result.URL.String()] = result.Response.HTML
 		}
+		return nil
 	}
 
 	// Crawl URLs that should use different fetchers
@@ -124,10 +125,11 @@ func TestCrawlerWithFetcherRulePriority(t *testing.T) {
 
 	// Track result
 	var capturedHTML string
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		if result.Response != nil {
 			capturedHTML = result.Response.HTML
 		}
+		return nil
 	}
 
 	err = c.Crawl(context.Background(), []string{testURL}, callback)
@@ -157,10 +159,11 @@ func TestCrawlerWithNoMatchingFetcher(t *testing.T) {
 
 	// Track errors
 	var capturedError error
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		if result.Error != nil {
 			capturedError = result.Error
 		}
+		return nil
 	}
 
 	// Try to crawl a URL that doesn't match any rule
@@ -217,10 +220,11 @@ func TestCrawlerMixedRules(t *testing.T) {
 
 	// Track results
 	var capturedParsed any
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		if result.Parsed != nil {
 			capturedParsed = result.Parsed
 		}
+		return nil
 	}
 
 	err = c.Crawl(context.Background(), []string{"https://example.com"}, callback)
@@ -278,10 +282,11 @@ func TestCrawlerWithRegexFetcherRule(t *testing.T) {
 
 	// Track results
 	results := make(map[string]string)
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		if result.Response != nil {
 			results[result.URL.String()] = result.Response.HTML
 		}
+		return nil
 	}
 
 	err = c.Crawl(context.Background(), urls, callback)
@@ -370,10 +375,11 @@ func TestCrawlerWithGlobFetcherRule(t *testing.T) {
 
 	// Track results
 	results := make(map[string]string)
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		if result.Response != nil {
 			results[result.URL.String()] = result.Response.HTML
 		}
+		return nil
 	}
 
 	// Extract URLs from test cases
@@ -423,10 +429,11 @@ func TestAddFetcherRulesAfterCreation(t *testing.T) {
 
 	// Track results
 	results := make(map[string]string)
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		if result.Response != nil {
 			results[result.URL.String()] = result.Response.HTML
 		}
+		return nil
 	}
 
 	urls := []string{
@@ -460,10 +467,11 @@ func TestCrawlerFetcherError(t *testing.T) {
 
 	// Track errors
 	var capturedError error
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		if result.Error != nil {
 			capturedError = result.Error
 		}
+		return nil
 	}
 
 	err = c.Crawl(context.Background(), []string{"https://error.example.com"}, callback)
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/deepnoodle-ai/web"
 	"github.com/deepnoodle-ai/web/cache"
+	webErrors "github.com/deepnoodle-ai/web/errors"
 	"github.com/deepnoodle-ai/web/fetch"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -154,11 +157,12 @@ func TestCrawler_BasicCrawl(t *testing.T) {
 	var processedData []any
 	mu := sync.Mutex{}
 
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		mu.Lock()
 		defer mu.Unlock()
 		processedURLs = append(processedURLs, result.URL.String())
 		processedData = append(processedData, result.Parsed)
+		return nil
 	}
 
 	ctx := context.Background()
@@ -202,12 +206,13 @@ func TestCrawler_WithParser(t *testing.T) {
 	var parsedResults []any
 	mu := sync.Mutex{}
 
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		mu.Lock()
 		defer mu.Unlock()
 		if result.Parsed != nil {
 			parsedResults = append(parsedResults, result.Parsed)
 		}
+		return nil
 	}
 
 	ctx := context.Background()
@@ -218,6 +223,56 @@ func TestCrawler_WithParser(t *testing.T) {
 	assert.Equal(t, expectedParsedData, parsedResults[0])
 }
 
+func TestCrawler_ParseWorkers(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: []*fetch.Link{{URL: "/0"}, {URL: "/1"}, {URL: "/2"}, {URL: "/3"}, {URL: "/4"}},
+	})
+	for i := 0; i < 5; i++ {
+		url := fmt.Sprintf("https://example.com/%d", i)
+		mockFetcher.AddResponse(url, &fetch.Response{URL: url})
+	}
+
+	// Track how many pages are parsed concurrently
+	var active, maxActive int64
+	mockParser := NewMockParser()
+	mockParser.SetParseFunc(func(ctx context.Context, page *fetch.Response) (any, error) {
+		n := atomic.AddInt64(&active, 1)
+		defer atomic.AddInt64(&active, -1)
+		for {
+			current := atomic.LoadInt64(&maxActive)
+			if n <= current || atomic.CompareAndSwapInt64(&maxActive, current, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		return page.URL, nil
+	})
+
+	crawler, err := New(Options{
+		Workers:        1,
+		ParseWorkers:   5,
+		DefaultFetcher: mockFetcher,
+		DefaultParser:  mockParser,
+	})
+	require.NoError(t, err)
+
+	var parsed []string
+	mu := sync.Mutex{}
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		parsed = append(parsed, result.Parsed.(string))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, parsed, 6)
+	assert.Equal(t, int64(6), crawler.GetStats().GetSucceeded())
+	// A single fetch worker kept several parse workers busy
+	assert.Greater(t, atomic.LoadInt64(&maxActive), int64(1))
+}
+
 func TestCrawler_WithCache(t *testing.T) {
 	htmlCache := cache.NewInMemoryCache()
 
@@ -238,9 +293,10 @@ func TestCrawler_WithCache(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		// The callback won't receive the HTML directly, but we can verify
 		// the cache was used by checking that fetcher was not called
+		return nil
 	}
 
 	ctx := context.Background()
@@ -254,6 +310,248 @@ func TestCrawler_WithCache(t *testing.T) {
 	assert.Equal(t, int64(1), stats.GetProcessed())
 }
 
+func TestCrawler_CachedResponses(t *testing.T) {
+	htmlCache := cache.NewInMemoryCache()
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:        "https://example.com",
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": "text/html"},
+		HTML:       "<html><body><a href=\"/a\">A</a></body></html>",
+		Metadata:   fetch.Metadata{Title: "Home"},
+		Links:      []*fetch.Link{{URL: "/a"}},
+	})
+	fetcher.AddResponse("https://example.com/a", &fetch.Response{URL: "https://example.com/a", StatusCode: 200, HTML: "<html></html>"})
+
+	crawl := func(fetcher fetch.Fetcher) map[string]*fetch.Response {
+		crawler, err := New(Options{Workers: 1, DefaultFetcher: fetcher, Cache: htmlCache})
+		require.NoError(t, err)
+		responses := map[string]*fetch.Response{}
+		err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+			responses[result.URL.String()] = result.Response
+			return result.Error
+		})
+		require.NoError(t, err)
+		return responses
+	}
+	live := crawl(fetcher)
+	require.Len(t, live, 2)
+
+	// Cache hits behave as live fetches, without fetching
+	cached := crawl(fetch.NewMockFetcher())
+	require.Len(t, cached, 2)
+	home := cached["https://example.com"]
+	require.Equal(t, 200, home.StatusCode)
+	require.Equal(t, "text/html", home.Headers["Content-Type"])
+	require.Equal(t, "Home", home.Metadata.Title)
+	require.Equal(t, live["https://example.com"].HTML, home.HTML)
+}
+
+func TestCrawler_BaseURL(t *testing.T) {
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddResponse("https://example.com/blog/post", &fetch.Response{
+		URL:        "https://example.com/blog/post",
+		StatusCode: 200,
+		Metadata:   fetch.Metadata{BaseURL: "/docs/"},
+		Links:      []*fetch.Link{{URL: "intro"}, {URL: "https://other.com/"}},
+	})
+	crawler, err := New(Options{Workers: 1, MaxURLs: 1, DefaultFetcher: fetcher, FollowBehavior: FollowAny})
+	require.NoError(t, err)
+	var links []string
+	err = crawler.Crawl(context.Background(), []string{"https://example.com/blog/post"}, func(ctx context.Context, result *Result) error {
+		links = result.Links
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/docs/intro", "https://other.com"}, links)
+}
+
+func TestCrawler_CacheMode(t *testing.T) {
+	ctx := context.Background()
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddResponse("https://example.com/fresh", &fetch.Response{URL: "https://example.com/fresh", HTML: "<p>fresh</p>"})
+	fetcher.AddResponse("https://example.com/new", &fetch.Response{URL: "https://example.com/new", HTML: "<p>new</p>"})
+	urls := []string{"https://example.com/fresh", "https://example.com/new"}
+
+	tests := []struct {
+		mode   CacheMode
+		html   string // HTML of the first page as crawled
+		cached bool   // whether the second page was cached
+	}{
+		{CacheReadWrite, "<p>stale</p>", true},
+		{CacheReadOnly, "<p>stale</p>", false},
+		{CacheWriteOnly, "<p>fresh</p>", true},
+		{CacheBypass, "<p>fresh</p>", false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			htmlCache := cache.NewInMemoryCache()
+			require.NoError(t, htmlCache.Set(ctx, urls[0], []byte("<p>stale</p>")))
+			crawler, err := New(Options{
+				Workers:        1,
+				DefaultFetcher: fetcher,
+				FollowBehavior: FollowNone,
+				Cache:          htmlCache,
+				CacheMode:      tt.mode,
+			})
+			require.NoError(t, err)
+			var html string
+			err = crawler.Crawl(ctx, urls[:1], func(ctx context.Context, result *Result) error {
+				html = result.Response.HTML
+				return result.Error
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.html, html)
+
+			err = crawler.Crawl(ctx, urls[1:], func(ctx context.Context, result *Result) error { return result.Error })
+			require.NoError(t, err)
+			_, err = htmlCache.Get(ctx, urls[1])
+			require.Equal(t, tt.cached, err == nil)
+		})
+	}
+
+	_, err := New(Options{CacheMode: "offline"})
+	require.EqualError(t, err, `invalid cache mode: "offline"`)
+}
+
+func TestCrawler_StaleIfError(t *testing.T) {
+	ctx := context.Background()
+	htmlCache := cache.NewInMemoryCache()
+	require.NoError(t, htmlCache.Set(ctx, "https://example.com/down", []byte("<p>cached</p>")))
+	fetchErr := fmt.Errorf("connection refused")
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddError("https://example.com/down", fetchErr)
+	fetcher.AddError("https://example.com/uncached", fetchErr)
+	fetcher.AddResponse("https://example.com/up", &fetch.Response{URL: "https://example.com/up", HTML: "<p>live</p>"})
+
+	crawl := func(staleIfError bool) map[string]*Result {
+		crawler, err := New(Options{
+			Workers:        1,
+			DefaultFetcher: fetcher,
+			FollowBehavior: FollowNone,
+			Cache:          htmlCache,
+			CacheMode:      CacheWriteOnly,
+			StaleIfError:   staleIfError,
+		})
+		require.NoError(t, err)
+		results := map[string]*Result{}
+		urls := []string{"https://example.com/down", "https://example.com/uncached", "https://example.com/up"}
+		err = crawler.Crawl(ctx, urls, func(ctx context.Context, result *Result) error {
+			results[result.URL.Path] = result
+			return nil
+		})
+		require.NoError(t, err)
+		return results
+	}
+
+	results := crawl(true)
+	require.NoError(t, results["/down"].Error)
+	require.True(t, results["/down"].Stale)
+	require.Equal(t, fetchErr, results["/down"].StaleError)
+	require.Equal(t, "<p>cached</p>", results["/down"].Response.HTML)
+	require.Equal(t, fetchErr, results["/uncached"].Error)
+	require.False(t, results["/up"].Stale)
+	require.Equal(t, "<p>live</p>", results["/up"].Response.HTML)
+
+	results = crawl(false)
+	require.Equal(t, fetchErr, results["/down"].Error)
+	require.False(t, results["/down"].Stale)
+}
+
+// revalidatingFetcher serves a page with an ETag, answering 304 to
+// conditional requests for the current version.
+type revalidatingFetcher struct {
+	mutex    sync.Mutex
+	etag     string
+	requests []*fetch.Request
+}
+
+func (f *revalidatingFetcher) Capabilities() *fetch.Capabilities {
+	return &fetch.Capabilities{ConditionalRequests: true}
+}
+
+func (f *revalidatingFetcher) Fetch(ctx context.Context, req *fetch.Request) (*fetch.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.requests = append(f.requests, req)
+	if req.Headers["If-None-Match"] == f.etag {
+		return &fetch.Response{URL: req.URL, StatusCode: 304, Timestamp: time.Now().UTC()}, nil
+	}
+	return &fetch.Response{
+		URL:        req.URL,
+		StatusCode: 200,
+		Headers:    map[string]string{"ETag": f.etag},
+		HTML:       "<p>" + f.etag + "</p>",
+	}, nil
+}
+
+func TestCrawler_CacheMaxAge(t *testing.T) {
+	ctx := context.Background()
+	htmlCache := cache.NewInMemoryCache()
+	fetcher := &revalidatingFetcher{etag: `"v1"`}
+	crawl := func(maxAge time.Duration) *Result {
+		crawler, err := New(Options{
+			Workers:        1,
+			DefaultFetcher: fetcher,
+			FollowBehavior: FollowNone,
+			Cache:          htmlCache,
+			CacheMaxAge:    maxAge,
+		})
+		require.NoError(t, err)
+		var result *Result
+		err = crawler.Crawl(ctx, []string{"https://example.com"}, func(ctx context.Context, r *Result) error {
+			result = r
+			return r.Error
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := crawl(time.Hour)
+	require.Equal(t, `<p>"v1"</p>`, result.Response.HTML)
+	require.Len(t, fetcher.requests, 1)
+	require.Equal(t, int(time.Hour.Milliseconds()), fetcher.requests[0].MaxAge)
+
+	// Fresh entries are served from the cache
+	crawl(time.Hour)
+	require.Len(t, fetcher.requests, 1)
+
+	// Expired entries are revalidated, and served from the cache if unchanged
+	time.Sleep(2 * time.Millisecond)
+	result = crawl(time.Millisecond)
+	require.Len(t, fetcher.requests, 2)
+	require.Equal(t, `"v1"`, fetcher.requests[1].Headers["If-None-Match"])
+	require.Equal(t, 200, result.Response.StatusCode)
+	require.Equal(t, `<p>"v1"</p>`, result.Response.HTML)
+
+	// Changed pages are fetched again and cached
+	fetcher.etag = `"v2"`
+	time.Sleep(2 * time.Millisecond)
+	result = crawl(time.Millisecond)
+	require.Len(t, fetcher.requests, 3)
+	require.Equal(t, `<p>"v2"</p>`, result.Response.HTML)
+	result = crawl(0)
+	require.Len(t, fetcher.requests, 3)
+	require.Equal(t, `<p>"v2"</p>`, result.Response.HTML)
+}
+
+func TestCrawler_CachedHTMLLinks(t *testing.T) {
+	htmlCache := cache.NewInMemoryCache()
+	ctx := context.Background()
+	require.NoError(t, htmlCache.Set(ctx, "https://example.com", []byte(`<html><body><a href="/a">A</a></body></html>`)))
+	require.NoError(t, htmlCache.Set(ctx, "https://example.com/a", []byte(`<html><body></body></html>`)))
+
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: fetch.NewMockFetcher(), Cache: htmlCache})
+	require.NoError(t, err)
+	var urls []string
+	err = crawler.Crawl(ctx, []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		urls = append(urls, result.URL.String())
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com", "https://example.com/a"}, urls)
+}
+
 func TestResolveLink(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -421,10 +719,11 @@ func TestCrawler_FollowBehavior(t *testing.T) {
 			var processedURLs []string
 			mu := sync.Mutex{}
 
-			callback := func(ctx context.Context, result *Result) {
+			callback := func(ctx context.Context, result *Result) error {
 				mu.Lock()
 				defer mu.Unlock()
 				processedURLs = append(processedURLs, result.URL.String())
+				return nil
 			}
 
 			ctx := context.Background()
@@ -468,13 +767,14 @@ func TestCrawler_ErrorHandling(t *testing.T) {
 	var errors []error
 	mu := sync.Mutex{}
 
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		mu.Lock()
 		defer mu.Unlock()
 		processedURLs = append(processedURLs, result.URL.String())
 		if result.Error != nil {
 			errors = append(errors, result.Error)
 		}
+		return nil
 	}
 
 	ctx := context.Background()
@@ -489,6 +789,14 @@ func TestCrawler_ErrorHandling(t *testing.T) {
 	assert.Equal(t, int64(2), stats.GetProcessed())
 	assert.Equal(t, int64(1), stats.GetSucceeded())
 	assert.Equal(t, int64(1), stats.GetFailed())
+
+	var multi *webErrors.Multi
+	require.ErrorAs(t, crawler.GetErrors(), &multi)
+	require.Equal(t, 1, multi.Len())
+	var urlErr *URLError
+	require.ErrorAs(t, multi, &urlErr)
+	assert.Equal(t, "https://error.com", urlErr.URL)
+	assert.Equal(t, "https://error.com: fetch failed", multi.Error())
 }
 
 func TestCrawler_MaxURLsLimit(t *testing.T) {
@@ -522,10 +830,11 @@ func TestCrawler_MaxURLsLimit(t *testing.T) {
 	var processedURLs []string
 	mu := sync.Mutex{}
 
-	callback := func(ctx context.Context, result *Result) {
+	callback := func(ctx context.Context, result *Result) error {
 		mu.Lock()
 		defer mu.Unlock()
 		processedURLs = append(processedURLs, result.URL.String())
+		return nil
 	}
 
 	ctx := context.Background()
@@ -537,3 +846,680 @@ func TestCrawler_MaxURLsLimit(t *testing.T) {
 	stats := crawler.GetStats()
 	assert.LessOrEqual(t, stats.GetProcessed(), int64(3))
 }
+
+func TestCrawler_MaxDepth(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	// A chain of pages: / links to /1, which links to /2, and so on
+	for i := 0; i < 4; i++ {
+		pageURL := "https://example.com"
+		if i > 0 {
+			pageURL = fmt.Sprintf("https://example.com/%d", i)
+		}
+		mockFetcher.AddResponse(pageURL, &fetch.Response{
+			URL:   pageURL,
+			Links: []*fetch.Link{{URL: fmt.Sprintf("/%d", i+1)}},
+		})
+	}
+
+	crawler, err := New(Options{
+		Workers:        1,
+		DefaultFetcher: mockFetcher,
+		MaxDepth:       2,
+	})
+	require.NoError(t, err)
+
+	depths := map[string]int{}
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		depths[result.URL.String()] = result.Depth
+		return result.Error
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"https://example.com":   0,
+		"https://example.com/1": 1,
+		"https://example.com/2": 2,
+	}, depths)
+}
+
+func TestCrawler_FailFast(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	var urls []string
+	for i := 0; i < 20; i++ {
+		url := fmt.Sprintf("https://example.com/%d", i)
+		urls = append(urls, url)
+		mockFetcher.AddError(url, fmt.Errorf("blocked"))
+	}
+
+	crawler, err := New(Options{
+		Workers:        1,
+		RequestDelay:   10 * time.Millisecond,
+		DefaultFetcher: mockFetcher,
+		FollowBehavior: FollowNone,
+		FailFast:       true,
+	})
+	require.NoError(t, err)
+
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error { return nil })
+	require.ErrorIs(t, err, ErrFailFast)
+	var urlErr *URLError
+	require.ErrorAs(t, err, &urlErr)
+	assert.Equal(t, "https://example.com/0", urlErr.URL)
+	assert.Less(t, crawler.GetStats().GetProcessed(), int64(5))
+}
+
+// unhealthyFetcher is a fetcher whose health check fails.
+type unhealthyFetcher struct {
+	*fetch.MockFetcher
+}
+
+func (f *unhealthyFetcher) Healthy(ctx context.Context) error {
+	return fmt.Errorf("proxy is unreachable")
+}
+
+func TestCrawler_UnhealthyFetcher(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com"})
+	crawler, err := New(Options{
+		Workers:        1,
+		DefaultFetcher: mockFetcher,
+	})
+	require.NoError(t, err)
+	require.NoError(t, crawler.AddFetcherRules(&FetcherRule{
+		MatchRule: MatchRule{Pattern: "other.com", Type: MatchExact},
+		Fetcher:   &unhealthyFetcher{fetch.NewMockFetcher()},
+	}))
+
+	// The crawl fails before any URL is fetched
+	called := false
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		called = true
+		return nil
+	})
+	require.EqualError(t, err, "fetcher is unhealthy: proxy is unreachable")
+	require.False(t, called)
+	require.Equal(t, int64(0), crawler.GetStats().GetProcessed())
+}
+
+func TestCrawler_MaxErrorRate(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	var urls []string
+	for i := 0; i < 40; i++ {
+		url := fmt.Sprintf("https://example.com/%d", i)
+		urls = append(urls, url)
+		if i%4 == 0 {
+			mockFetcher.AddResponse(url, &fetch.Response{URL: url})
+		} else {
+			mockFetcher.AddError(url, fmt.Errorf("forbidden"))
+		}
+	}
+
+	crawler, err := New(Options{
+		Workers:         1,
+		RequestDelay:    10 * time.Millisecond,
+		DefaultFetcher:  mockFetcher,
+		FollowBehavior:  FollowNone,
+		MaxErrorRate:    0.5,
+		ErrorRateWindow: 10,
+	})
+	require.NoError(t, err)
+
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error { return nil })
+	require.ErrorIs(t, err, ErrErrorRateExceeded)
+	assert.Contains(t, err.Error(), "crawl error rate exceeded: 7 of 10 fetches failed")
+	assert.Less(t, crawler.GetStats().GetProcessed(), int64(15))
+
+	// A tolerable error rate does not stop the crawl
+	crawler, err = New(Options{
+		Workers:         2,
+		DefaultFetcher:  mockFetcher,
+		FollowBehavior:  FollowNone,
+		MaxErrorRate:    0.8,
+		ErrorRateWindow: 10,
+	})
+	require.NoError(t, err)
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), crawler.GetStats().GetProcessed())
+}
+
+func TestCrawler_FailuresPerCrawl(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddError("https://example.com/a", fmt.Errorf("forbidden"))
+	mockFetcher.AddError("https://example.com/b", fmt.Errorf("forbidden"))
+	mockFetcher.AddResponse("https://example.com/c", &fetch.Response{URL: "https://example.com/c"})
+	mockFetcher.AddResponse("https://example.com/d", &fetch.Response{URL: "https://example.com/d"})
+	mockFetcher.AddError("https://example.com/e", fmt.Errorf("forbidden"))
+
+	crawler, err := New(Options{
+		Workers:         1,
+		DefaultFetcher:  mockFetcher,
+		FollowBehavior:  FollowNone,
+		MaxErrorRate:    0.5,
+		ErrorRateWindow: 2,
+	})
+	require.NoError(t, err)
+	callback := func(ctx context.Context, result *Result) error { return nil }
+
+	err = crawler.Crawl(context.Background(), []string{"https://example.com/a", "https://example.com/b"}, callback)
+	require.ErrorIs(t, err, ErrErrorRateExceeded)
+
+	// The failures of the previous crawl don't count against the next one
+	err = crawler.Crawl(context.Background(), []string{"https://example.com/c", "https://example.com/d", "https://example.com/e"}, callback)
+	require.NoError(t, err)
+	var multi *webErrors.Multi
+	require.ErrorAs(t, crawler.GetErrors(), &multi)
+	require.Equal(t, 1, multi.Len())
+	var urlErr *URLError
+	require.ErrorAs(t, multi, &urlErr)
+	assert.Equal(t, "https://example.com/e", urlErr.URL)
+}
+
+func TestCrawler_CallbackSkipLinks(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: []*fetch.Link{{URL: "https://example.com/page1"}},
+	})
+	mockFetcher.AddResponse("https://example.com/page1", &fetch.Response{URL: "https://example.com/page1"})
+
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher})
+	require.NoError(t, err)
+
+	var processedURLs []string
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		processedURLs = append(processedURLs, result.URL.String())
+		return fmt.Errorf("done here: %w", ErrSkipLinks)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://example.com"}, processedURLs)
+	assert.Equal(t, int64(1), crawler.GetStats().GetSucceeded())
+}
+
+func TestCrawler_CallbackRetry(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com"})
+
+	crawler, err := New(Options{
+		Workers:            1,
+		DefaultFetcher:     mockFetcher,
+		MaxCallbackRetries: 2,
+	})
+	require.NoError(t, err)
+
+	var attempts int
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		attempts++
+		return ErrRetry
+	})
+	require.NoError(t, err)
+	// The initial attempt plus two retries
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, int64(1), crawler.GetStats().GetSucceeded())
+	assert.Equal(t, int64(0), crawler.GetStats().GetFailed())
+}
+
+func TestCrawler_CallbackRetryFailure(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddError("https://example.com", fmt.Errorf("unavailable"))
+
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher})
+	require.NoError(t, err)
+
+	var attempts int
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		attempts++
+		if result.Error != nil {
+			return ErrRetry
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, attempts)
+	// Only the final failure is recorded
+	assert.Equal(t, int64(1), crawler.GetStats().GetFailed())
+}
+
+func TestCrawler_CallbackAbort(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	var urls []string
+	for i := 0; i < 20; i++ {
+		url := fmt.Sprintf("https://example.com/%d", i)
+		urls = append(urls, url)
+		mockFetcher.AddResponse(url, &fetch.Response{URL: url})
+	}
+
+	crawler, err := New(Options{
+		Workers:        1,
+		RequestDelay:   10 * time.Millisecond,
+		DefaultFetcher: mockFetcher,
+		FollowBehavior: FollowNone,
+	})
+	require.NoError(t, err)
+
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error {
+		return fmt.Errorf("found it: %w", ErrAbort)
+	})
+	require.ErrorIs(t, err, ErrAbort)
+	assert.Contains(t, err.Error(), "found it")
+	assert.Less(t, crawler.GetStats().GetProcessed(), int64(5))
+}
+
+func TestCrawler_CallbackError(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: []*fetch.Link{{URL: "https://example.com/page1"}},
+	})
+	mockFetcher.AddResponse("https://example.com/page1", &fetch.Response{URL: "https://example.com/page1"})
+
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher})
+	require.NoError(t, err)
+
+	// Other errors are logged and the crawl continues
+	var attempts int
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		attempts++
+		return fmt.Errorf("failed to store result")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+// sessionFetcher returns a storage state counting the fetches of each host.
+type sessionFetcher struct {
+	mutex    sync.Mutex
+	requests []*fetch.Request
+}
+
+func (f *sessionFetcher) Fetch(ctx context.Context, req *fetch.Request) (*fetch.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.requests = append(f.requests, req)
+	visits := 0.0
+	if req.StorageState != nil {
+		visits = req.StorageState["visits"].(float64)
+	}
+	return &fetch.Response{
+		URL:          req.URL,
+		StorageState: map[string]any{"visits": visits + 1},
+	}, nil
+}
+
+func TestCrawler_StorageStates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	fetcher := &sessionFetcher{}
+	crawler, err := New(Options{
+		Workers:          1,
+		DefaultFetcher:   fetcher,
+		FollowBehavior:   FollowNone,
+		StorageStateFile: path,
+	})
+	require.NoError(t, err)
+
+	urls := []string{"https://example.com/a", "https://example.com/b", "https://other.com/a"}
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error { return nil })
+	require.NoError(t, err)
+	require.Len(t, fetcher.requests, 3)
+	require.Nil(t, fetcher.requests[0].StorageState)
+	require.Equal(t, map[string]any{"visits": 1.0}, fetcher.requests[1].StorageState)
+	require.Nil(t, fetcher.requests[2].StorageState)
+
+	// The states are restored by the next crawler
+	states, err := fetch.LoadStorageStates(path)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"visits": 2.0}, states.Get("example.com"))
+
+	crawler, err = New(Options{
+		Workers:          1,
+		DefaultFetcher:   fetcher,
+		FollowBehavior:   FollowNone,
+		StorageStateFile: path,
+	})
+	require.NoError(t, err)
+	err = crawler.Crawl(context.Background(), []string{"https://example.com/c"}, func(ctx context.Context, result *Result) error { return nil })
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"visits": 2.0}, fetcher.requests[3].StorageState)
+}
+
+// statelessFetcher is a sessionFetcher declaring no storage state support.
+type statelessFetcher struct {
+	sessionFetcher
+}
+
+func (f *statelessFetcher) Capabilities() *fetch.Capabilities {
+	return &fetch.Capabilities{}
+}
+
+func TestCrawler_StorageStatesUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	fetcher := &statelessFetcher{}
+	crawler, err := New(Options{
+		Workers:          1,
+		DefaultFetcher:   fetcher,
+		FollowBehavior:   FollowNone,
+		StorageStateFile: path,
+	})
+	require.NoError(t, err)
+
+	urls := []string{"https://example.com/a", "https://example.com/b"}
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error {
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Len(t, fetcher.requests, 2)
+	require.Nil(t, fetcher.requests[1].StorageState)
+}
+
+func TestCrawler_RobotsDirectives(t *testing.T) {
+	links := func(urls ...string) []*fetch.Link {
+		var links []*fetch.Link
+		for _, u := range urls {
+			links = append(links, &fetch.Link{URL: u})
+		}
+		return links
+	}
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: links("/noindex", "/nofollow", "/none"),
+	})
+	fetcher.AddResponse("https://example.com/noindex", &fetch.Response{
+		URL:      "https://example.com/noindex",
+		Metadata: fetch.Metadata{Robots: "noindex"},
+		Links:    links("/a"),
+	})
+	fetcher.AddResponse("https://example.com/nofollow", &fetch.Response{
+		URL:     "https://example.com/nofollow",
+		Headers: map[string]string{"X-Robots-Tag": "nofollow"},
+		Links:   links("/b"),
+	})
+	fetcher.AddResponse("https://example.com/none", &fetch.Response{
+		URL:      "https://example.com/none",
+		Metadata: fetch.Metadata{Robots: "none"},
+		Links:    links("/c"),
+	})
+	for _, path := range []string{"/a", "/b", "/c"} {
+		fetcher.AddResponse("https://example.com"+path, &fetch.Response{URL: "https://example.com" + path})
+	}
+
+	crawl := func(ignore bool) []string {
+		crawler, err := New(Options{
+			Workers:                1,
+			DefaultFetcher:         fetcher,
+			IgnoreRobotsDirectives: ignore,
+		})
+		require.NoError(t, err)
+		var mutex sync.Mutex
+		var urls []string
+		err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			require.NoError(t, result.Error)
+			urls = append(urls, result.URL.Path)
+			return nil
+		})
+		require.NoError(t, err)
+		slices.Sort(urls)
+		return urls
+	}
+	require.Equal(t, []string{"", "/a", "/nofollow"}, crawl(false))
+	require.Equal(t, []string{"", "/a", "/b", "/c", "/nofollow", "/noindex", "/none"}, crawl(true))
+}
+
+func TestCrawler_CanonicalHosts(t *testing.T) {
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:      "https://example.com",
+		FinalURL: "https://www.example.com/",
+		Links:    []*fetch.Link{{URL: "/a"}, {URL: "https://www.example.com/"}},
+	})
+	fetcher.AddResponse("https://other.com", &fetch.Response{
+		URL:      "https://other.com",
+		Metadata: fetch.Metadata{CanonicalURL: "https://www.other.com/"},
+		Links:    []*fetch.Link{{URL: "/b"}},
+	})
+	for _, u := range []string{"https://www.example.com/a", "https://www.other.com/b", "https://example.com/a", "https://other.com/b"} {
+		fetcher.AddResponse(u, &fetch.Response{URL: u})
+	}
+
+	crawl := func(canonical bool) []string {
+		crawler, err := New(Options{
+			Workers:        1,
+			DefaultFetcher: fetcher,
+			CanonicalHosts: canonical,
+		})
+		require.NoError(t, err)
+		var urls []string
+		err = crawler.Crawl(context.Background(), []string{"https://example.com", "https://other.com"}, func(ctx context.Context, result *Result) error {
+			require.NoError(t, result.Error)
+			urls = append(urls, result.URL.String())
+			return nil
+		})
+		require.NoError(t, err)
+		slices.Sort(urls)
+		return urls
+	}
+	require.Equal(t, []string{
+		"https://example.com",
+		"https://other.com",
+		"https://www.example.com/a",
+		"https://www.other.com/b",
+	}, crawl(true))
+	require.Equal(t, []string{
+		"https://example.com",
+		"https://example.com/a",
+		"https://other.com",
+		"https://other.com/b",
+	}, crawl(false))
+}
+
+func TestCrawler_PathEquivalence(t *testing.T) {
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: []*fetch.Link{{URL: "/docs"}, {URL: "/docs/"}, {URL: "/docs/index.html"}, {URL: "/index.html"}},
+	})
+	for _, u := range []string{"https://example.com/docs", "https://example.com/docs/", "https://example.com/docs/index.html", "https://example.com/index.html"} {
+		fetcher.AddResponse(u, &fetch.Response{URL: u})
+	}
+	crawl := func(opts Options) []string {
+		opts.Workers = 1
+		opts.DefaultFetcher = fetcher
+		crawler, err := New(opts)
+		require.NoError(t, err)
+		var urls []string
+		err = crawler.Crawl(context.Background(), []string{"https://example.com/"}, func(ctx context.Context, result *Result) error {
+			require.NoError(t, result.Error)
+			urls = append(urls, result.URL.String())
+			return nil
+		})
+		require.NoError(t, err)
+		slices.Sort(urls)
+		return urls
+	}
+	require.Equal(t, []string{
+		"https://example.com",
+		"https://example.com/docs",
+	}, crawl(Options{IndexFiles: web.DirectoryIndexFiles}))
+	require.Equal(t, []string{
+		"https://example.com",
+		"https://example.com/docs",
+		"https://example.com/docs/index.html",
+		"https://example.com/index.html",
+	}, crawl(Options{}))
+	require.Equal(t, []string{
+		"https://example.com",
+		"https://example.com/docs",
+		"https://example.com/docs/",
+		"https://example.com/docs/index.html",
+		"https://example.com/index.html",
+	}, crawl(Options{DistinctTrailingSlash: true}))
+}
+
+func TestCrawler_PatternRules(t *testing.T) {
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: []*fetch.Link{{URL: "/products/shirt"}, {URL: "/category/men"}},
+	})
+	fetcher.AddResponse("https://example.com/products/shirt", &fetch.Response{URL: "https://example.com/products/shirt"})
+	fetcher.AddResponse("https://example.com/category/men", &fetch.Response{URL: "https://example.com/category/men"})
+
+	productParser := NewMockParser()
+	productParser.SetParseFunc(func(ctx context.Context, page *fetch.Response) (any, error) {
+		return "product", nil
+	})
+	crawler, err := New(Options{
+		Workers:        1,
+		DefaultFetcher: fetcher,
+		DefaultParser:  NewMockParser(),
+		PatternRules: []*PatternRule{
+			{Name: "product", Pattern: "https://example.com/products/{slug}", Parser: productParser},
+			{Name: "category", Pattern: "/category/{name}"},
+		},
+	})
+	require.NoError(t, err)
+
+	rule, params := crawler.Classify("https://example.com/products/hat")
+	require.Equal(t, "product", rule.Name)
+	require.Equal(t, map[string]string{"slug": "hat"}, params)
+	rule, _ = crawler.Classify("https://example.com/about")
+	require.Nil(t, rule)
+
+	results := map[string]*Result{}
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		results[result.URL.Path] = result
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Empty(t, results[""].Pattern)
+	require.Equal(t, "product", results["/products/shirt"].Pattern)
+	require.Equal(t, map[string]string{"slug": "shirt"}, results["/products/shirt"].PatternParams)
+	require.Equal(t, "product", results["/products/shirt"].Parsed)
+	require.Equal(t, "category", results["/category/men"].Pattern)
+	require.Equal(t, map[string]string{"parsed": "data"}, results["/category/men"].Parsed)
+
+	_, err = New(Options{PatternRules: []*PatternRule{{Name: "bad", Pattern: "/{id}/{id}"}}})
+	require.Error(t, err)
+}
+
+func TestCrawler_PatternBudgets(t *testing.T) {
+	fetcher := fetch.NewMockFetcher()
+	var links []*fetch.Link
+	for _, path := range []string{"/products/1", "/products/2", "/products/3", "/blog/a", "/blog/b", "/blog/c"} {
+		links = append(links, &fetch.Link{URL: path})
+		fetcher.AddResponse("https://example.com"+path, &fetch.Response{URL: "https://example.com" + path})
+	}
+	fetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com", Links: links})
+
+	crawler, err := New(Options{
+		Workers:        1,
+		DefaultFetcher: fetcher,
+		PatternRules: []*PatternRule{
+			{Name: "product", Pattern: "/products/{id}", MaxURLs: 2},
+			{Name: "post", Pattern: "/blog/{slug}"},
+		},
+	})
+	require.NoError(t, err)
+
+	plan, err := crawler.Plan(context.Background(), []string{"https://example.com"})
+	require.NoError(t, err)
+	require.Len(t, plan.URLs, 6)
+	require.Len(t, plan.Skipped, 1)
+	require.Equal(t, "https://example.com/products/3", plan.Skipped[0].URL)
+	require.Equal(t, SkipPatternMax, plan.Skipped[0].Reason)
+
+	counts := map[string]int{}
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		counts[result.Pattern]++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"": 1, "product": 2, "post": 3}, counts)
+}
+
+func TestCrawler_URLPatterns(t *testing.T) {
+	fetcher := fetch.NewMockFetcher()
+	var links []*fetch.Link
+	for _, path := range []string{"/blog/a", "/blog/b", "/blog/tag/go", "/about", "/tag/go", "/calendar/2025/06"} {
+		links = append(links, &fetch.Link{URL: path})
+		fetcher.AddResponse("https://example.com"+path, &fetch.Response{URL: "https://example.com" + path})
+	}
+	fetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com", Links: links})
+
+	crawl := func(include, exclude []*MatchRule) []string {
+		crawler, err := New(Options{
+			Workers:         1,
+			DefaultFetcher:  fetcher,
+			IncludePatterns: include,
+			ExcludePatterns: exclude,
+		})
+		require.NoError(t, err)
+		var urls []string
+		err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+			urls = append(urls, result.URL.RequestURI())
+			return nil
+		})
+		require.NoError(t, err)
+		slices.Sort(urls)
+		return urls
+	}
+
+	// The seed is crawled even though it doesn't match the include patterns
+	require.Equal(t, []string{"/", "/blog/a", "/blog/b", "/blog/tag/go"},
+		crawl([]*MatchRule{{Pattern: "/blog/*", Type: MatchGlob}}, nil))
+	require.Equal(t, []string{"/", "/about", "/blog/a"},
+		crawl(nil, []*MatchRule{{Pattern: "/tag/", Type: MatchRegex}, {Pattern: `^/calendar/\d{4}/`, Type: MatchRegex}, {Pattern: "/blog/b", Type: MatchExact}}))
+	require.Equal(t, []string{"/", "/blog/a", "/blog/b"},
+		crawl([]*MatchRule{{Pattern: "/blog/", Type: MatchPrefix}}, []*MatchRule{{Pattern: "*/tag/*", Type: MatchGlob}}))
+
+	_, err := New(Options{ExcludePatterns: []*MatchRule{{Pattern: "(", Type: MatchRegex}}})
+	require.Error(t, err)
+}
+
+// probingFetcher is a mock fetcher that reports content types to HEAD
+// requests.
+type probingFetcher struct {
+	*fetch.MockFetcher
+	contentTypes map[string]string
+}
+
+func (f *probingFetcher) Head(ctx context.Context, rawURL string) (*fetch.ResourceInfo, error) {
+	return &fetch.ResourceInfo{URL: rawURL, StatusCode: 200, ContentType: f.contentTypes[rawURL]}, nil
+}
+
+func TestCrawler_ContentTypes(t *testing.T) {
+	fetcher := &probingFetcher{
+		MockFetcher: fetch.NewMockFetcher(),
+		contentTypes: map[string]string{
+			"https://example.com":          "text/html; charset=utf-8",
+			"https://example.com/page":     "text/html",
+			"https://example.com/file.pdf": "application/pdf",
+		},
+	}
+	fetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: []*fetch.Link{{URL: "/page"}, {URL: "/file.pdf"}, {URL: "/unknown"}},
+	})
+	fetcher.AddResponse("https://example.com/page", &fetch.Response{URL: "https://example.com/page"})
+	fetcher.AddResponse("https://example.com/unknown", &fetch.Response{URL: "https://example.com/unknown"})
+
+	crawler, err := New(Options{
+		Workers:        1,
+		DefaultFetcher: fetcher,
+		ContentTypes:   []string{"text/html"},
+	})
+	require.NoError(t, err)
+
+	var urls []string
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		urls = append(urls, result.URL.String())
+		return result.Error
+	})
+	require.NoError(t, err)
+	slices.Sort(urls)
+	// URLs without a known content type are fetched
+	require.Equal(t, []string{"https://example.com", "https://example.com/page", "https://example.com/unknown"}, urls)
+	require.Zero(t, crawler.GetStats().GetFailed())
+}
@@ -0,0 +1,178 @@
+package crawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// DeltaStatus is the change of a page since a previous crawl.
+type DeltaStatus string
+
+const (
+	DeltaNew         DeltaStatus = "new"         // not in the previous crawl
+	DeltaUnchanged   DeltaStatus = "unchanged"   // same content hash
+	DeltaChanged     DeltaStatus = "changed"     // different content hash
+	DeltaDisappeared DeltaStatus = "disappeared" // not crawled successfully
+)
+
+// PageFingerprint identifies the content of a page of a crawl. The
+// fingerprints of a crawl are the baseline the next crawl is compared with.
+type PageFingerprint struct {
+	URL         string `json:"url"`
+	ContentHash string `json:"content_hash"`
+}
+
+// ContentHash returns the SHA-256 hex digest of the HTML of a response, or an
+// empty string if it has no HTML.
+func ContentHash(response *fetch.Response) string {
+	if response == nil || response.HTML == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(response.HTML))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeltaOptions defines the options of a DeltaTracker.
+type DeltaOptions struct {
+	// Hash returns the content hash of a page. Defaults to ContentHash. Use
+	// a hash of the main content or the Markdown of pages to ignore changes
+	// of their boilerplate, e.g. rotating ads or CSRF tokens.
+	Hash func(response *fetch.Response) string
+}
+
+// DeltaReport classifies the URLs of a crawl and of the previous crawl by
+// their change. URLs are sorted.
+type DeltaReport struct {
+	New         []string `json:"new,omitempty"`
+	Changed     []string `json:"changed,omitempty"`
+	Unchanged   []string `json:"unchanged,omitempty"`
+	Disappeared []string `json:"disappeared,omitempty"`
+}
+
+// DeltaTracker compares the pages of a crawl with those of a previous crawl,
+// e.g. for site change pipelines that only process new and changed pages.
+// Pages that fail or whose status code is 300 or above are not part of the
+// crawl, so the pages of the previous crawl that fail, or are not crawled at
+// all, have disappeared. Pages are compared by URL. It is safe for
+// concurrent use.
+//
+//	tracker := crawler.NewDeltaTracker(previous, crawler.DeltaOptions{})
+//	err := c.Crawl(ctx, seeds, tracker.Callback(nil))
+//	...
+//	report := tracker.Report()
+//	next := tracker.Fingerprints() // baseline of the next crawl
+type DeltaTracker struct {
+	hash     func(response *fetch.Response) string
+	previous map[string]string
+	mutex    sync.Mutex
+	current  map[string]string
+}
+
+// NewDeltaTracker creates a new DeltaTracker comparing a crawl with the
+// fingerprints of a previous crawl.
+func NewDeltaTracker(previous []PageFingerprint, opts DeltaOptions) *DeltaTracker {
+	if opts.Hash == nil {
+		opts.Hash = ContentHash
+	}
+	t := &DeltaTracker{
+		hash:     opts.Hash,
+		previous: make(map[string]string, len(previous)),
+		current:  map[string]string{},
+	}
+	for _, page := range previous {
+		t.previous[page.URL] = page.ContentHash
+	}
+	return t
+}
+
+// Add adds the result of a page and returns its change since the previous
+// crawl. The result of a page replaces any earlier result of the same URL.
+func (t *DeltaTracker) Add(result *Result) DeltaStatus {
+	if result == nil || result.URL == nil {
+		return ""
+	}
+	rawURL := result.URL.String()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	response := result.Response
+	if result.Error != nil || response == nil || response.StatusCode >= 300 {
+		delete(t.current, rawURL)
+		if _, ok := t.previous[rawURL]; ok {
+			return DeltaDisappeared
+		}
+		return ""
+	}
+	hash := t.hash(response)
+	t.current[rawURL] = hash
+	return t.status(rawURL, hash)
+}
+
+// status returns the change of a crawled page.
+func (t *DeltaTracker) status(rawURL, hash string) DeltaStatus {
+	previousHash, ok := t.previous[rawURL]
+	switch {
+	case !ok:
+		return DeltaNew
+	case previousHash == hash:
+		return DeltaUnchanged
+	default:
+		return DeltaChanged
+	}
+}
+
+// Callback returns a crawl callback that adds the results before passing
+// them to the given callback, if any.
+func (t *DeltaTracker) Callback(callback Callback) Callback {
+	return func(ctx context.Context, result *Result) error {
+		t.Add(result)
+		if callback == nil {
+			return nil
+		}
+		return callback(ctx, result)
+	}
+}
+
+// Report returns the changes of the pages added so far.
+func (t *DeltaTracker) Report() *DeltaReport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	report := &DeltaReport{}
+	for rawURL, hash := range t.current {
+		switch t.status(rawURL, hash) {
+		case DeltaNew:
+			report.New = append(report.New, rawURL)
+		case DeltaChanged:
+			report.Changed = append(report.Changed, rawURL)
+		case DeltaUnchanged:
+			report.Unchanged = append(report.Unchanged, rawURL)
+		}
+	}
+	for rawURL := range t.previous {
+		if _, ok := t.current[rawURL]; !ok {
+			report.Disappeared = append(report.Disappeared, rawURL)
+		}
+	}
+	sort.Strings(report.New)
+	sort.Strings(report.Changed)
+	sort.Strings(report.Unchanged)
+	sort.Strings(report.Disappeared)
+	return report
+}
+
+// Fingerprints returns the fingerprints of the pages added so far, sorted by
+// URL, to compare the next crawl with.
+func (t *DeltaTracker) Fingerprints() []PageFingerprint {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	pages := make([]PageFingerprint, 0, len(t.current))
+	for rawURL, hash := range t.current {
+		pages = append(pages, PageFingerprint{URL: rawURL, ContentHash: hash})
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].URL < pages[j].URL })
+	return pages
+}
@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaTracker(t *testing.T) {
+	result := func(rawURL string, response *fetch.Response, err error) *Result {
+		u, _ := url.Parse(rawURL)
+		return &Result{URL: u, Response: response, Error: err}
+	}
+	page := func(html string) *fetch.Response {
+		return &fetch.Response{StatusCode: 200, HTML: html}
+	}
+	previous := []PageFingerprint{
+		{URL: "https://example.com/", ContentHash: ContentHash(page("<p>home</p>"))},
+		{URL: "https://example.com/about", ContentHash: ContentHash(page("<p>about</p>"))},
+		{URL: "https://example.com/gone", ContentHash: ContentHash(page("<p>gone</p>"))},
+		{URL: "https://example.com/broken", ContentHash: ContentHash(page("<p>broken</p>"))},
+		{URL: "https://example.com/skipped", ContentHash: ContentHash(page("<p>skipped</p>"))},
+	}
+
+	tracker := NewDeltaTracker(previous, DeltaOptions{})
+	for _, tt := range []struct {
+		result   *Result
+		expected DeltaStatus
+	}{
+		{result("https://example.com/", page("<p>home</p>"), nil), DeltaUnchanged},
+		{result("https://example.com/about", page("<p>about us</p>"), nil), DeltaChanged},
+		{result("https://example.com/new", page("<p>new</p>"), nil), DeltaNew},
+		{result("https://example.com/gone", &fetch.Response{StatusCode: 404}, nil), DeltaDisappeared},
+		{result("https://example.com/broken", nil, errors.New("timeout")), DeltaDisappeared},
+		{result("https://example.com/failed", nil, errors.New("timeout")), ""},
+	} {
+		require.Equal(t, tt.expected, tracker.Add(tt.result), tt.result.URL.String())
+	}
+
+	require.Equal(t, &DeltaReport{
+		New:         []string{"https://example.com/new"},
+		Changed:     []string{"https://example.com/about"},
+		Unchanged:   []string{"https://example.com/"},
+		Disappeared: []string{"https://example.com/broken", "https://example.com/gone", "https://example.com/skipped"},
+	}, tracker.Report())
+
+	fingerprints := tracker.Fingerprints()
+	require.Len(t, fingerprints, 3)
+	require.Equal(t, PageFingerprint{URL: "https://example.com/", ContentHash: previous[0].ContentHash}, fingerprints[0])
+
+	// The next crawl is compared with the fingerprints of this one
+	next := NewDeltaTracker(fingerprints, DeltaOptions{})
+	require.Equal(t, DeltaUnchanged, next.Add(result("https://example.com/about", page("<p>about us</p>"), nil)))
+}
+
+func TestDeltaTracker_Hash(t *testing.T) {
+	// Only the title is compared
+	tracker := NewDeltaTracker([]PageFingerprint{{URL: "https://example.com", ContentHash: "Home"}}, DeltaOptions{
+		Hash: func(response *fetch.Response) string { return response.Metadata.Title },
+	})
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:        "https://example.com",
+		StatusCode: 200,
+		HTML:       "<p>rotating ad</p>",
+		Metadata:   fetch.Metadata{Title: "Home"},
+	})
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher})
+	require.NoError(t, err)
+	require.NoError(t, crawler.Crawl(context.Background(), []string{"https://example.com"}, tracker.Callback(nil)))
+	require.Equal(t, &DeltaReport{Unchanged: []string{"https://example.com"}}, tracker.Report())
+}
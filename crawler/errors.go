@@ -0,0 +1,15 @@
+package crawler
+
+// URLError records the failure to crawl a URL.
+type URLError struct {
+	URL string
+	Err error
+}
+
+func (e *URLError) Error() string {
+	return e.URL + ": " + e.Err.Error()
+}
+
+func (e *URLError) Unwrap() error {
+	return e.Err
+}
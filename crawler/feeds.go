@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/url"
+
+	"github.com/deepnoodle-ai/web/feed"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// maxFeedSize is the size above which feeds are not read.
+const maxFeedSize = 10 * 1024 * 1024
+
+// feedLinks returns the URLs of the items of the feeds linked by a page.
+// Each feed is fetched once by the crawler, from the first page linking it.
+func (c *Crawler) feedLinks(ctx context.Context, rawURL string, response *fetch.Response) []string {
+	if response.HTML == "" {
+		return nil
+	}
+	doc, err := response.Document()
+	if err != nil {
+		return nil
+	}
+	pageURL := rawURL
+	if response.FinalURL != "" {
+		pageURL = response.FinalURL
+	}
+	var links []string
+	for _, link := range feed.Discover(doc, pageURL) {
+		if _, seen := c.feeds.LoadOrStore(link.URL, true); seen {
+			continue
+		}
+		f, err := c.fetchFeed(ctx, link.URL)
+		if err != nil {
+			c.logger.WarnContext(ctx, "failed to fetch feed",
+				slog.String("url", link.URL),
+				slog.String("page_url", rawURL),
+				slog.String("error", err.Error()))
+			continue
+		}
+		c.logger.DebugContext(ctx, "discovered feed",
+			slog.String("url", link.URL),
+			slog.String("page_url", rawURL),
+			slog.Int("items", len(f.Items)))
+		for _, item := range f.Items {
+			if item.URL != "" {
+				links = append(links, item.URL)
+			}
+		}
+	}
+	return links
+}
+
+// fetchFeed downloads and parses a feed.
+func (c *Crawler) fetchFeed(ctx context.Context, feedURL string) (*feed.Feed, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := c.download(ctx, u.Hostname(), feedURL, &buf, fetch.DownloadOptions{MaxSize: maxFeedSize}); err != nil {
+		return nil, err
+	}
+	return feed.Parse(buf.Bytes(), feedURL)
+}
@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_FollowFeeds(t *testing.T) {
+	fetcher := &robotsFetcher{
+		MockFetcher: fetch.NewMockFetcher(),
+		robots: map[string]string{
+			"https://example.com/feed.xml": `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+	<item><link>https://example.com/posts/1</link></item>
+	<item><link>/posts/2</link></item>
+	<item><link>https://other.com/posts/3</link></item>
+</channel></rss>`,
+		},
+	}
+	head := `<html><head>
+		<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+		<link rel="alternate" type="application/atom+xml" href="/missing.xml">
+	</head><body></body></html>`
+	fetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com", HTML: head})
+	for _, path := range []string{"/posts/1", "/posts/2"} {
+		fetcher.AddResponse("https://example.com"+path, &fetch.Response{URL: "https://example.com" + path, HTML: head})
+	}
+
+	crawl := func(followFeeds bool) []string {
+		crawler, err := New(Options{
+			Workers:        1,
+			DefaultFetcher: fetcher,
+			FollowBehavior: FollowSameDomain,
+			FollowFeeds:    followFeeds,
+		})
+		require.NoError(t, err)
+		var urls []string
+		err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+			urls = append(urls, result.URL.String())
+			return result.Error
+		})
+		require.NoError(t, err)
+		slices.Sort(urls)
+		return urls
+	}
+
+	// The items of the feed are followed as links of the page, and feeds
+	// that fail to be fetched are skipped
+	require.Equal(t, []string{
+		"https://example.com",
+		"https://example.com/posts/1",
+		"https://example.com/posts/2",
+	}, crawl(true))
+	require.Equal(t, []string{"https://example.com"}, crawl(false))
+}
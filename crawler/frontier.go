@@ -0,0 +1,153 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// frontier is the bounded FIFO queue of URLs waiting to be fetched. Unlike a
+// channel, its contents can be listed and edited while a crawl is running.
+type frontier struct {
+	mutex    sync.Mutex
+	urls     []string
+	capacity int
+	ready    chan struct{} // signaled when URLs are pushed
+}
+
+func newFrontier(capacity int) *frontier {
+	return &frontier{capacity: capacity, ready: make(chan struct{}, 1)}
+}
+
+// push adds a URL to the back of the queue. Returns false if it is full.
+func (f *frontier) push(rawURL string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.urls) >= f.capacity {
+		return false
+	}
+	f.urls = append(f.urls, rawURL)
+	f.signal()
+	return true
+}
+
+// pop removes the URL at the front of the queue, waiting for one to be pushed
+// if it is empty. Returns false once the context is done, even if URLs
+// remain.
+func (f *frontier) pop(ctx context.Context) (string, bool) {
+	for {
+		if ctx.Err() != nil {
+			return "", false
+		}
+		f.mutex.Lock()
+		if len(f.urls) > 0 {
+			rawURL := f.urls[0]
+			f.urls[0] = ""
+			f.urls = f.urls[1:]
+			// Wake up the next waiting worker, if any
+			if len(f.urls) > 0 {
+				f.signal()
+			}
+			f.mutex.Unlock()
+			return rawURL, true
+		}
+		f.mutex.Unlock()
+		select {
+		case <-ctx.Done():
+			return "", false
+		case <-f.ready:
+		}
+	}
+}
+
+// signal wakes up a worker waiting in pop. Must be called with the mutex held.
+func (f *frontier) signal() {
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+}
+
+// len returns the number of queued URLs.
+func (f *frontier) len() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.urls)
+}
+
+// list returns up to limit queued URLs starting at offset, in queue order.
+// A limit of zero or less returns all URLs after the offset.
+func (f *frontier) list(offset, limit int) []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(f.urls) {
+		return nil
+	}
+	end := len(f.urls)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return append([]string(nil), f.urls[offset:end]...)
+}
+
+// clear removes all queued URLs.
+func (f *frontier) clear() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	clear(f.urls)
+	f.urls = f.urls[:0]
+}
+
+// remove removes the queued URLs that match and returns how many were
+// removed.
+func (f *frontier) remove(match func(rawURL string) bool) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	kept := f.urls[:0]
+	for _, rawURL := range f.urls {
+		if !match(rawURL) {
+			kept = append(kept, rawURL)
+		}
+	}
+	removed := len(f.urls) - len(kept)
+	clear(f.urls[len(kept):])
+	f.urls = kept
+	return removed
+}
+
+// QueuedURLs returns up to limit URLs waiting to be fetched, starting at
+// offset, in the order they will be fetched. A limit of zero returns all URLs
+// after the offset.
+func (c *Crawler) QueuedURLs(offset, limit int) []string {
+	return c.queue.list(offset, limit)
+}
+
+// RemoveQueued removes the queued URLs that match and returns how many were
+// removed. Removed URLs are still considered seen, so they are not queued
+// again when links to them are found, nor when the crawl is resumed.
+func (c *Crawler) RemoveQueued(match func(rawURL string) bool) int {
+	return c.queue.remove(func(rawURL string) bool {
+		if !match(rawURL) {
+			return false
+		}
+		c.checkpointDone(context.Background(), rawURL)
+		return true
+	})
+}
+
+// Inject adds URLs to the queue of a running crawl, subject to the same
+// deduplication and MaxURLs limit as discovered links. Injected URLs have a
+// depth of zero, like seeds. Returns the number of URLs queued.
+func (c *Crawler) Inject(ctx context.Context, urls ...string) (int, error) {
+	c.progress.mutex.Lock()
+	running := c.progress.running
+	c.progress.mutex.Unlock()
+	if !running {
+		return 0, errors.New("crawler is not running")
+	}
+	return c.enqueue(ctx, urls, 0)
+}
@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_Frontier(t *testing.T) {
+	var crawler *Crawler
+	var mutex sync.Mutex
+	var fetched []string
+	var queued, afterRemove []string
+	fetcher := funcFetcher(func(ctx context.Context, req *fetch.Request) (*fetch.Response, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		fetched = append(fetched, req.URL)
+		switch req.URL {
+		case "https://example.com":
+			return &fetch.Response{URL: req.URL, Links: []*fetch.Link{
+				{URL: "/a"}, {URL: "/b"}, {URL: "/c"}, {URL: "/d"},
+			}}, nil
+		case "https://example.com/a":
+			queued = crawler.QueuedURLs(0, 2)
+			require.Equal(t, 1, crawler.RemoveQueued(func(rawURL string) bool {
+				return strings.HasSuffix(rawURL, "/c")
+			}))
+			count, err := crawler.Inject(ctx, "https://example.com/e", "https://example.com/b")
+			require.NoError(t, err)
+			require.Equal(t, 1, count) // b is already queued
+			afterRemove = crawler.QueuedURLs(1, 0)
+		}
+		return &fetch.Response{URL: req.URL}, nil
+	})
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: fetcher})
+	require.NoError(t, err)
+
+	_, err = crawler.Inject(context.Background(), "https://example.com/x")
+	require.Error(t, err) // not running
+
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error { return nil })
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/b", "https://example.com/c"}, queued)
+	require.Equal(t, []string{"https://example.com/d", "https://example.com/e"}, afterRemove)
+	require.Equal(t, []string{
+		"https://example.com",
+		"https://example.com/a",
+		"https://example.com/b",
+		"https://example.com/d",
+		"https://example.com/e",
+	}, fetched)
+	require.Empty(t, crawler.QueuedURLs(0, 10))
+}
@@ -0,0 +1,267 @@
+package crawler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// logFieldsKey is the context key of the crawl details added to log records.
+type logFieldsKey struct{}
+
+// logFields holds the crawl details added to log records.
+type logFields struct {
+	crawlID       string
+	requestID     string
+	seedDomain    string
+	workerID      int
+	parseWorkerID int
+}
+
+func (f *logFields) attrs() []slog.Attr {
+	attrs := []slog.Attr{slog.String("crawl_id", f.crawlID)}
+	if f.seedDomain != "" {
+		attrs = append(attrs, slog.String("seed_domain", f.seedDomain))
+	}
+	if f.requestID != "" {
+		attrs = append(attrs, slog.String("request_id", f.requestID))
+	}
+	if f.workerID > 0 {
+		attrs = append(attrs, slog.Int("worker_id", f.workerID))
+	}
+	if f.parseWorkerID > 0 {
+		attrs = append(attrs, slog.Int("parse_worker_id", f.parseWorkerID))
+	}
+	return attrs
+}
+
+// withLogFields returns a copy of the context carrying the crawl details,
+// starting from those already in the context, modified by the function.
+func withLogFields(ctx context.Context, modify func(f *logFields)) context.Context {
+	var fields logFields
+	if existing, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		fields = *existing
+	}
+	modify(&fields)
+	return context.WithValue(ctx, logFieldsKey{}, &fields)
+}
+
+// CrawlIDFromContext returns the ID of the crawl a context belongs to, or an
+// empty string. Contexts given to callbacks and parsers carry the ID.
+func CrawlIDFromContext(ctx context.Context) string {
+	if fields, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		return fields.crawlID
+	}
+	return ""
+}
+
+// RequestIDFromContext returns the ID of the fetch a context belongs to, or
+// an empty string. Contexts given to fetchers, callbacks and parsers carry the
+// ID.
+func RequestIDFromContext(ctx context.Context) string {
+	if fields, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		return fields.requestID
+	}
+	return ""
+}
+
+// LogHandler wraps a slog.Handler to add the crawl ID, request ID, seed
+// domain and worker ID to records logged with a context of a crawl. The
+// crawler uses it when Options.TagLogs is set. Wrap your own handler with
+// NewLogHandler to tag the records logged by callbacks and parsers with the
+// context they were given.
+type LogHandler struct {
+	handler slog.Handler
+}
+
+// NewLogHandler returns a LogHandler wrapping the given handler.
+func NewLogHandler(handler slog.Handler) *LogHandler {
+	if existing, ok := handler.(*LogHandler); ok {
+		return existing
+	}
+	return &LogHandler{handler: handler}
+}
+
+// Enabled implements slog.Handler.
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if fields, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		record = record.Clone()
+		record.AddAttrs(fields.attrs()...)
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{handler: h.handler.WithGroup(name)}
+}
+
+// LogThrottle samples the repeated warnings and errors logged by the
+// crawler, e.g. when a host fails consistently. Records are identical when
+// they have the same level, message and host of their url attribute. The
+// first records are logged, then one of every Every records, and the number
+// of records left out is logged when the crawl ends.
+type LogThrottle struct {
+	// First is the number of identical records logged before sampling
+	// starts. Defaults to 10.
+	First int
+
+	// Every is the sampling interval of identical records once First is
+	// reached. Defaults to 100.
+	Every int
+}
+
+// throttleKey identifies identical log records.
+type throttleKey struct {
+	level   slog.Level
+	message string
+	host    string
+}
+
+// throttleCount counts the identical log records seen and left out.
+type throttleCount struct {
+	seen       int
+	suppressed int
+}
+
+// throttleState is shared by a throttleHandler and the handlers derived from
+// it with WithAttrs and WithGroup.
+type throttleState struct {
+	first  int
+	every  int
+	mutex  sync.Mutex
+	counts map[throttleKey]*throttleCount
+}
+
+// throttleHandler wraps a slog.Handler to sample repeated warnings and
+// errors. See LogThrottle.
+type throttleHandler struct {
+	handler slog.Handler
+	state   *throttleState
+}
+
+func newThrottleHandler(handler slog.Handler, opts LogThrottle) *throttleHandler {
+	if opts.First <= 0 {
+		opts.First = 10
+	}
+	if opts.Every <= 0 {
+		opts.Every = 100
+	}
+	return &throttleHandler{
+		handler: handler,
+		state: &throttleState{
+			first:  opts.First,
+			every:  opts.Every,
+			counts: map[throttleKey]*throttleCount{},
+		},
+	}
+}
+
+// Enabled implements slog.Handler.
+func (h *throttleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *throttleHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level < slog.LevelWarn {
+		return h.handler.Handle(ctx, record)
+	}
+	key := throttleKey{level: record.Level, message: record.Message}
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key != "url" {
+			return true
+		}
+		if u, err := url.Parse(attr.Value.String()); err == nil {
+			key.host = u.Hostname()
+		}
+		return false
+	})
+
+	h.state.mutex.Lock()
+	count, ok := h.state.counts[key]
+	if !ok {
+		count = &throttleCount{}
+		h.state.counts[key] = count
+	}
+	count.seen++
+	seen := count.seen
+	sampled := seen > h.state.first
+	if sampled && (seen-h.state.first)%h.state.every != 0 {
+		count.suppressed++
+		h.state.mutex.Unlock()
+		return nil
+	}
+	h.state.mutex.Unlock()
+
+	if sampled {
+		record = record.Clone()
+		record.AddAttrs(slog.Int("occurrences", seen))
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *throttleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &throttleHandler{handler: h.handler.WithAttrs(attrs), state: h.state}
+}
+
+// WithGroup implements slog.Handler.
+func (h *throttleHandler) WithGroup(name string) slog.Handler {
+	return &throttleHandler{handler: h.handler.WithGroup(name), state: h.state}
+}
+
+// summarize logs the number of records left out for each kind of repeated
+// record, then resets the counts.
+func (h *throttleHandler) summarize(ctx context.Context) {
+	h.state.mutex.Lock()
+	counts := h.state.counts
+	h.state.counts = map[throttleKey]*throttleCount{}
+	h.state.mutex.Unlock()
+
+	keys := make([]throttleKey, 0, len(counts))
+	for key, count := range counts {
+		if count.suppressed > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].host != keys[j].host {
+			return keys[i].host < keys[j].host
+		}
+		return keys[i].message < keys[j].message
+	})
+	logger := slog.New(h.handler)
+	for _, key := range keys {
+		count := counts[key]
+		attrs := []slog.Attr{slog.String("message", key.message)}
+		if key.host != "" {
+			attrs = append(attrs, slog.String("host", key.host))
+		}
+		attrs = append(attrs,
+			slog.Int("occurrences", count.seen),
+			slog.Int("suppressed", count.suppressed))
+		logger.LogAttrs(ctx, key.level, "suppressed repeated log records", attrs...)
+	}
+}
+
+// newID returns a random ID used to correlate crawls and requests.
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
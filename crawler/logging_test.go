@@ -0,0 +1,180 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_TagLogs(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com"})
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	crawler, err := New(Options{
+		Workers:        2,
+		DefaultFetcher: mockFetcher,
+		Logger:         logger,
+		TagLogs:        true,
+	})
+	require.NoError(t, err)
+
+	// Callbacks log with their context to get the same attributes
+	callbackLogger := slog.New(NewLogHandler(logger.Handler()))
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		callbackLogger.InfoContext(ctx, "callback")
+		return nil
+	})
+	require.NoError(t, err)
+
+	records := map[string]map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records[record["msg"].(string)] = record
+	}
+	fetching := records["fetching"]
+	require.NotNil(t, fetching)
+	assert.Len(t, fetching["crawl_id"], 16)
+	assert.Equal(t, "example.com", fetching["seed_domain"])
+	assert.Contains(t, []any{1.0, 2.0}, fetching["worker_id"])
+
+	callback := records["callback"]
+	require.NotNil(t, callback)
+	assert.Equal(t, fetching["crawl_id"], callback["crawl_id"])
+	assert.Equal(t, fetching["worker_id"], callback["worker_id"])
+
+	// Records logged outside of workers have the crawl attributes only
+	idle := records["no more work available, stopping crawler"]
+	require.NotNil(t, idle)
+	assert.Equal(t, fetching["crawl_id"], idle["crawl_id"])
+	assert.NotContains(t, idle, "worker_id")
+}
+
+func TestLogHandler_NoCrawl(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil)))
+	logger.With("component", "test").InfoContext(context.Background(), "hello")
+	assert.NotContains(t, buf.String(), "crawl_id")
+	assert.Contains(t, buf.String(), "component=test")
+}
+
+// recordingFetcher records the contexts and requests it is called with.
+type recordingFetcher struct {
+	mutex    sync.Mutex
+	contexts []context.Context
+	requests []*fetch.Request
+}
+
+func (f *recordingFetcher) Fetch(ctx context.Context, req *fetch.Request) (*fetch.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.contexts = append(f.contexts, ctx)
+	f.requests = append(f.requests, req)
+	return &fetch.Response{URL: req.URL}, nil
+}
+
+func TestCrawler_CorrelationIDs(t *testing.T) {
+	fetcher := &recordingFetcher{}
+	crawler, err := New(Options{
+		Workers:         2,
+		DefaultFetcher:  fetcher,
+		ParseWorkers:    1,
+		RequestIDHeader: "X-Request-ID",
+		CrawlIDHeader:   "X-Crawl-ID",
+	})
+	require.NoError(t, err)
+
+	var mutex sync.Mutex
+	results := map[string]*Result{}
+	err = crawler.Crawl(context.Background(), []string{"https://example.com/a", "https://example.com/b"}, func(ctx context.Context, result *Result) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.Equal(t, result.CrawlID, CrawlIDFromContext(ctx))
+		assert.Equal(t, result.RequestID, RequestIDFromContext(ctx))
+		results[result.URL.String()] = result
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, fetcher.requests, 2)
+	require.Len(t, results, 2)
+
+	crawlID := results["https://example.com/a"].CrawlID
+	require.NotEmpty(t, crawlID)
+	require.Equal(t, crawlID, results["https://example.com/b"].CrawlID)
+	require.NotEqual(t, results["https://example.com/a"].RequestID, results["https://example.com/b"].RequestID)
+
+	for i, req := range fetcher.requests {
+		result := results[req.URL]
+		require.NotNil(t, result)
+		require.Equal(t, crawlID, req.Headers["X-Crawl-ID"])
+		require.Equal(t, result.RequestID, req.Headers["X-Request-ID"])
+		require.Equal(t, result.RequestID, RequestIDFromContext(fetcher.contexts[i]))
+	}
+}
+
+func TestCrawler_LogThrottle(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	var urls []string
+	for i := 1; i <= 25; i++ {
+		u := fmt.Sprintf("https://example.com/page%d", i)
+		mockFetcher.AddResponse(u, &fetch.Response{URL: u})
+		urls = append(urls, u)
+	}
+	mockFetcher.AddResponse("https://other.com", &fetch.Response{URL: "https://other.com"})
+	urls = append(urls, "https://other.com")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	crawler, err := New(Options{
+		Workers:        3,
+		DefaultFetcher: mockFetcher,
+		Logger:         logger,
+		LogThrottle:    &LogThrottle{First: 2, Every: 10},
+	})
+	require.NoError(t, err)
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error {
+		return errors.New("boom")
+	})
+	require.NoError(t, err)
+
+	var callbackErrors []map[string]any
+	var summaries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		switch record["msg"] {
+		case "callback error":
+			callbackErrors = append(callbackErrors, record)
+		case "suppressed repeated log records":
+			summaries = append(summaries, record)
+		}
+	}
+
+	// example.com: records 1, 2, 12 and 22 are logged; other.com is counted
+	// separately
+	require.Len(t, callbackErrors, 5)
+	var occurrences []any
+	for _, record := range callbackErrors {
+		occurrences = append(occurrences, record["occurrences"])
+	}
+	assert.ElementsMatch(t, []any{nil, nil, nil, 12.0, 22.0}, occurrences)
+
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "callback error", summaries[0]["message"])
+	assert.Equal(t, "example.com", summaries[0]["host"])
+	assert.Equal(t, "WARN", summaries[0]["level"])
+	assert.Equal(t, 25.0, summaries[0]["occurrences"])
+	assert.Equal(t, 21.0, summaries[0]["suppressed"])
+}
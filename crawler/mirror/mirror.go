@@ -0,0 +1,338 @@
+// Package mirror saves crawled pages as a copy of sites that can be browsed
+// offline, like wget --mirror, to a directory or a zip archive. Pages are
+// selected with the filtering of the crawler, and their links and assets
+// may be rewritten to point to the local copies:
+//
+//	m, err := mirror.New(mirror.Options{
+//		Writer:       mirror.NewDirWriter("mirror"),
+//		RewriteLinks: true,
+//		Assets:       true,
+//	})
+//	...
+//	err = c.Crawl(ctx, seeds, m.Callback(nil))
+//	...
+//	err = m.Close()
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/crawler"
+	"github.com/deepnoodle-ai/web/fetch"
+	"golang.org/x/net/html"
+)
+
+// DefaultMaxAssetSize limits the size of downloaded assets.
+const DefaultMaxAssetSize = 20 * 1024 * 1024 // 20 MB
+
+// Ways of embedding the provenance of pages. See Options.Provenance.
+const (
+	ProvenanceNone    = ""
+	ProvenanceMeta    = "meta"    // meta tags in the head
+	ProvenanceComment = "comment" // a comment at the start of the document
+)
+
+// Names of the provenance meta tags, and of the fields of the comment.
+const (
+	MetaSourceURL = "crawl:source-url"
+	MetaFetchedAt = "crawl:fetched-at" // RFC 3339
+	MetaCrawlID   = "crawl:crawl-id"
+)
+
+// Options defines the options of a Mirror.
+type Options struct {
+	// Writer stores the files of the mirror. Required.
+	Writer Writer
+
+	// RewriteLinks rewrites the links between the pages of a host to the
+	// relative paths of their local copies, so that the mirror can be
+	// browsed offline. Links to pages that are not mirrored are broken.
+	RewriteLinks bool
+
+	// Assets downloads the images, stylesheets, scripts and icons of pages,
+	// from any host, and rewrites the pages to use the local copies.
+	Assets bool
+
+	// Client downloads the assets. Defaults to fetch.DefaultHTTPClient.
+	Client *http.Client
+
+	// MaxAssetSize limits the size of assets in bytes. Larger assets are not
+	// downloaded. Defaults to DefaultMaxAssetSize.
+	MaxAssetSize int64
+
+	// Provenance embeds the source URL, fetch time and crawl ID of pages in
+	// their copies, so that archived pages are self-describing: as meta
+	// tags with ProvenanceMeta, or as a comment with ProvenanceComment.
+	Provenance string
+}
+
+// Mirror saves crawled pages with a Writer. Mirrors are safe for concurrent
+// use.
+type Mirror struct {
+	writer       Writer
+	rewriteLinks bool
+	assets       bool
+	client       *http.Client
+	maxAssetSize int64
+	provenance   string
+	mutex        sync.Mutex
+	files        map[string]bool // Paths written or being downloaded
+}
+
+// New creates a new mirror.
+func New(options Options) (*Mirror, error) {
+	if options.Writer == nil {
+		return nil, fmt.Errorf("mirror writer is required")
+	}
+	switch options.Provenance {
+	case ProvenanceNone, ProvenanceMeta, ProvenanceComment:
+	default:
+		return nil, fmt.Errorf("invalid mirror provenance: %q", options.Provenance)
+	}
+	if options.MaxAssetSize == 0 {
+		options.MaxAssetSize = DefaultMaxAssetSize
+	}
+	return &Mirror{
+		writer:       options.Writer,
+		rewriteLinks: options.RewriteLinks,
+		assets:       options.Assets,
+		client:       options.Client,
+		maxAssetSize: options.MaxAssetSize,
+		provenance:   options.Provenance,
+		files:        map[string]bool{},
+	}, nil
+}
+
+// assetAttributes are the attributes of elements that reference assets.
+var assetAttributes = []struct {
+	selector string
+	attr     string
+}{
+	{"img[src]", "src"},
+	{"script[src]", "src"},
+	{"link[rel~=stylesheet][href]", "href"},
+	{"link[rel~=icon][href]", "href"},
+	{"source[src]", "src"},
+	{"video[poster]", "poster"},
+}
+
+// Save saves the page of a crawl result, if it was fetched successfully.
+func (m *Mirror) Save(ctx context.Context, result *crawler.Result) error {
+	if result.Error != nil || result.Response == nil || result.Response.HTML == "" {
+		return nil
+	}
+	pageURL := result.URL
+	pagePath := LocalPath(pageURL, true)
+	content := result.Response.HTML
+	if m.rewriteLinks || m.assets || m.provenance != ProvenanceNone {
+		rewritten, err := m.rewrite(ctx, result, pagePath, content)
+		if err != nil {
+			return fmt.Errorf("failed to rewrite %s: %w", pageURL, err)
+		}
+		content = rewritten
+	}
+	if !m.reserve(pagePath) {
+		return nil
+	}
+	return m.write(pagePath, []byte(content))
+}
+
+// rewrite rewrites the links and assets of a page to their local copies and
+// embeds its provenance.
+func (m *Mirror) rewrite(ctx context.Context, result *crawler.Result, pagePath, content string) (string, error) {
+	doc, err := web.NewDocument(content)
+	if err != nil {
+		return "", err
+	}
+	pageURL := result.URL
+	root := doc.GoqueryDocument()
+	if m.assets {
+		for _, asset := range assetAttributes {
+			root.Find(asset.selector).Each(func(_ int, s *goquery.Selection) {
+				target, ok := resolve(pageURL, s.AttrOr(asset.attr, ""))
+				if !ok {
+					return
+				}
+				if assetPath, ok := m.saveAsset(ctx, target); ok {
+					s.SetAttr(asset.attr, relativePath(pagePath, assetPath, ""))
+					s.RemoveAttr("srcset")
+				}
+			})
+		}
+	}
+	if m.rewriteLinks {
+		root.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+			target, ok := resolve(pageURL, s.AttrOr("href", ""))
+			if !ok || !strings.EqualFold(target.Host, pageURL.Host) {
+				return
+			}
+			s.SetAttr("href", relativePath(pagePath, LocalPath(target, true), target.Fragment))
+		})
+	}
+	if m.provenance != ProvenanceNone {
+		addProvenance(root, m.provenance, result)
+	}
+	var buf bytes.Buffer
+	for _, node := range root.Nodes {
+		if err := html.Render(&buf, node); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// addProvenance embeds the source URL, fetch time and crawl ID of a page.
+func addProvenance(root *goquery.Document, provenance string, result *crawler.Result) {
+	fetchedAt := result.Response.Timestamp
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	fields := [][2]string{
+		{MetaSourceURL, result.URL.String()},
+		{MetaFetchedAt, fetchedAt.UTC().Format(time.RFC3339)},
+	}
+	if result.CrawlID != "" {
+		fields = append(fields, [2]string{MetaCrawlID, result.CrawlID})
+	}
+
+	if provenance == ProvenanceMeta {
+		head := root.Find("head").First()
+		for _, field := range fields {
+			head.AppendHtml(`<meta name="` + field[0] + `" content="` + html.EscapeString(field[1]) + `"/>`)
+		}
+		return
+	}
+
+	// "--" may not appear in comments
+	var comment strings.Builder
+	for _, field := range fields {
+		value := strings.ReplaceAll(field[1], "--", "-%2D")
+		fmt.Fprintf(&comment, " %s=%q", strings.TrimPrefix(field[0], "crawl:"), value)
+	}
+	comment.WriteByte(' ')
+	document := root.Nodes[0]
+	node := &html.Node{Type: html.CommentNode, Data: comment.String()}
+	next := document.FirstChild
+	for next != nil && next.Type == html.DoctypeNode {
+		next = next.NextSibling
+	}
+	document.InsertBefore(node, next)
+}
+
+// saveAsset downloads an asset unless it was saved already, returning its
+// local path. Assets that fail to download are referenced remotely.
+func (m *Mirror) saveAsset(ctx context.Context, assetURL *url.URL) (string, bool) {
+	assetPath := LocalPath(assetURL, false)
+	if !m.reserve(assetPath) {
+		return assetPath, true
+	}
+	var buf bytes.Buffer
+	_, err := fetch.Download(ctx, assetURL.String(), &buf, fetch.DownloadOptions{
+		Client:  m.client,
+		MaxSize: m.maxAssetSize,
+	})
+	if err == nil {
+		err = m.write(assetPath, buf.Bytes())
+	}
+	if err != nil {
+		m.mutex.Lock()
+		delete(m.files, assetPath)
+		m.mutex.Unlock()
+		return "", false
+	}
+	return assetPath, true
+}
+
+// reserve marks a path as written, returning false if it already was.
+func (m *Mirror) reserve(filePath string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.files[filePath] {
+		return false
+	}
+	m.files[filePath] = true
+	return true
+}
+
+func (m *Mirror) write(filePath string, data []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.writer.WriteFile(filePath, data)
+}
+
+// Callback returns a crawl callback that saves each result before passing it
+// to the given callback, if any.
+func (m *Mirror) Callback(callback crawler.Callback) crawler.Callback {
+	return func(ctx context.Context, result *crawler.Result) error {
+		if err := m.Save(ctx, result); err != nil {
+			return err
+		}
+		if callback == nil {
+			return nil
+		}
+		return callback(ctx, result)
+	}
+}
+
+// Close closes the writer of the mirror.
+func (m *Mirror) Close() error {
+	return m.writer.Close()
+}
+
+// LocalPath returns the slash-separated path of the copy of a URL in a
+// mirror, in the directory of its host. Pages are saved as index.html files
+// in the directory of their path, unless the path ends with .html or .htm.
+// The query of URLs is kept as a hash in the file name.
+func LocalPath(u *url.URL, page bool) string {
+	p := path.Clean("/" + u.Path)
+	switch ext := path.Ext(p); {
+	case page && ext != ".html" && ext != ".htm":
+		p = path.Join(p, "index.html")
+	case !page && (p == "/" || strings.HasSuffix(u.Path, "/")):
+		p = path.Join(p, "index")
+	}
+	if u.RawQuery != "" {
+		sum := sha256.Sum256([]byte(u.RawQuery))
+		ext := path.Ext(p)
+		p = strings.TrimSuffix(p, ext) + "-" + hex.EncodeToString(sum[:4]) + ext
+	}
+	host := strings.ReplaceAll(strings.ToLower(u.Host), ":", "_")
+	return host + p
+}
+
+// relativePath returns the URL of the file at target relative to the file at
+// from, with an optional fragment.
+func relativePath(from, target, fragment string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(target))
+	if err != nil {
+		rel = "/" + target
+	}
+	u := url.URL{Path: filepath.ToSlash(rel), Fragment: fragment}
+	return u.String()
+}
+
+// resolve resolves a reference of a page, accepting only HTTP(S) URLs.
+func resolve(pageURL *url.URL, ref string) (*url.URL, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+	u, err := pageURL.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, false
+	}
+	return u, true
+}
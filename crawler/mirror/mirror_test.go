@@ -0,0 +1,153 @@
+package mirror
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web/crawler"
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		url  string
+		page bool
+		want string
+	}{
+		{"https://example.com", true, "example.com/index.html"},
+		{"https://example.com/", true, "example.com/index.html"},
+		{"https://example.com/docs/intro", true, "example.com/docs/intro/index.html"},
+		{"https://example.com/docs/page.html", true, "example.com/docs/page.html"},
+		{"https://Example.com:8080/../etc/passwd", true, "example.com_8080/etc/passwd/index.html"},
+		{"https://cdn.example.com/img/logo.png", false, "cdn.example.com/img/logo.png"},
+		{"https://cdn.example.com/assets/", false, "cdn.example.com/assets/index"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, LocalPath(u, tt.page), tt.url)
+	}
+
+	// The query is kept as a hash
+	u, _ := url.Parse("https://example.com/search?q=go")
+	require.Regexp(t, `^example\.com/search/index-[0-9a-f]{8}\.html$`, LocalPath(u, true))
+}
+
+func TestMirror(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logo.png":
+			w.Write([]byte("png"))
+		case "/style.css":
+			w.Write([]byte("body {}"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	local := strings.ReplaceAll(strings.TrimPrefix(server.URL, "http://"), ":", "_")
+
+	var buf bytes.Buffer
+	m, err := New(Options{Writer: NewZipWriter(&buf), RewriteLinks: true, Assets: true})
+	require.NoError(t, err)
+
+	result := func(rawURL, html string) *crawler.Result {
+		u, _ := url.Parse(rawURL)
+		return &crawler.Result{URL: u, Response: &fetch.Response{URL: rawURL, HTML: html}}
+	}
+	ctx := context.Background()
+	err = m.Save(ctx, result(server.URL+"/docs/intro", `<html><head><link rel="stylesheet" href="/style.css"></head><body>
+		<img src="../logo.png" srcset="/logo@2x.png 2x">
+		<img src="/missing.png">
+		<a href="/docs/next#part">Next</a>
+		<a href="https://other.com/">Other</a>
+	</body></html>`))
+	require.NoError(t, err)
+	err = m.Save(ctx, result(server.URL+"/docs/next", `<html><body><img src="/logo.png"></body></html>`))
+	require.NoError(t, err)
+	// Failed pages are not saved
+	failed := result(server.URL+"/failed", "")
+	failed.Error = io.ErrUnexpectedEOF
+	require.NoError(t, m.Save(ctx, failed))
+	require.NoError(t, m.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[f.Name] = string(data)
+	}
+	require.Len(t, files, 4)
+	require.Equal(t, "png", files[local+"/logo.png"])
+	require.Equal(t, "body {}", files[local+"/style.css"])
+
+	intro := files[local+"/docs/intro/index.html"]
+	require.Contains(t, intro, `<link rel="stylesheet" href="../../style.css"/>`)
+	require.Contains(t, intro, `<img src="../../logo.png"/>`)
+	require.Contains(t, intro, `<img src="/missing.png"/>`)
+	require.Contains(t, intro, `<a href="../next/index.html#part">Next</a>`)
+	require.Contains(t, intro, `<a href="https://other.com/">Other</a>`)
+	require.Contains(t, files[local+"/docs/next/index.html"], `<img src="../../logo.png"/>`)
+}
+
+func TestDirWriter(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(Options{Writer: NewDirWriter(dir)})
+	require.NoError(t, err)
+	u, _ := url.Parse("https://example.com/about")
+	html := `<html><head></head><body><a href="/">Home</a></body></html>`
+	err = m.Save(context.Background(), &crawler.Result{URL: u, Response: &fetch.Response{HTML: html}})
+	require.NoError(t, err)
+	require.NoError(t, m.Close())
+
+	// Pages are saved as is unless rewritten
+	data, err := os.ReadFile(filepath.Join(dir, "example.com", "about", "index.html"))
+	require.NoError(t, err)
+	require.Equal(t, html, string(data))
+}
+
+func TestMirror_Provenance(t *testing.T) {
+	dir := t.TempDir()
+	u, _ := url.Parse("https://example.com/about?a=1&b=2")
+	fetchedAt := time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC)
+	result := &crawler.Result{
+		URL:      u,
+		CrawlID:  "crawl-1",
+		Response: &fetch.Response{HTML: `<!DOCTYPE html><html><head><title>About</title></head><body>About</body></html>`, Timestamp: fetchedAt},
+	}
+	read := func(provenance string) string {
+		m, err := New(Options{Writer: NewDirWriter(filepath.Join(dir, provenance)), Provenance: provenance})
+		require.NoError(t, err)
+		require.NoError(t, m.Save(context.Background(), result))
+		data, err := os.ReadFile(filepath.Join(dir, provenance, LocalPath(u, true)))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	require.Equal(t, `<!DOCTYPE html><html><head><title>About</title>`+
+		`<meta name="crawl:source-url" content="https://example.com/about?a=1&amp;b=2"/>`+
+		`<meta name="crawl:fetched-at" content="2025-03-01T12:30:00Z"/>`+
+		`<meta name="crawl:crawl-id" content="crawl-1"/>`+
+		`</head><body>About</body></html>`, read(ProvenanceMeta))
+
+	require.Equal(t, `<!DOCTYPE html><!-- source-url="https://example.com/about?a=1&amp;b=2" fetched-at="2025-03-01T12:30:00Z" crawl-id="crawl-1" -->`+
+		`<html><head><title>About</title></head><body>About</body></html>`, read(ProvenanceComment))
+
+	_, err := New(Options{Writer: NewDirWriter(dir), Provenance: "header"})
+	require.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package mirror
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Writer stores the files of a mirror.
+type Writer interface {
+	// WriteFile writes a file at the given slash-separated relative path.
+	WriteFile(path string, data []byte) error
+
+	// Close flushes the files written.
+	Close() error
+}
+
+// DirWriter writes the files of a mirror to a directory.
+type DirWriter struct {
+	dir string
+}
+
+// NewDirWriter creates a writer of files to the given directory.
+func NewDirWriter(dir string) *DirWriter {
+	return &DirWriter{dir: dir}
+}
+
+// WriteFile implements the Writer interface.
+func (w *DirWriter) WriteFile(path string, data []byte) error {
+	fullPath := filepath.Join(w.dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(fullPath, data, 0o644)
+}
+
+// Close implements the Writer interface.
+func (w *DirWriter) Close() error {
+	return nil
+}
+
+// ZipWriter writes the files of a mirror to a zip archive.
+type ZipWriter struct {
+	zip *zip.Writer
+}
+
+// NewZipWriter creates a writer of files to a zip archive written to w.
+func NewZipWriter(w io.Writer) *ZipWriter {
+	return &ZipWriter{zip: zip.NewWriter(w)}
+}
+
+// WriteFile implements the Writer interface.
+func (w *ZipWriter) WriteFile(path string, data []byte) error {
+	f, err := w.zip.CreateHeader(&zip.FileHeader{
+		Name:     path,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// Close writes the central directory of the archive. The underlying writer
+// is not closed.
+func (w *ZipWriter) Close() error {
+	return w.zip.Close()
+}
@@ -0,0 +1,25 @@
+package crawler
+
+import (
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// PageClassifier returns the type of a fetched page. It determines the page
+// types matched by the conditions of parser rules, and the page types of
+// results when Options.ClassifyPages is set.
+type PageClassifier func(response *fetch.Response) web.PageType
+
+// ClassifyResponse is the default PageClassifier. Pages are classified with
+// web.ClassifyPageURL, given the final URL of the response.
+func ClassifyResponse(response *fetch.Response) web.PageType {
+	doc, err := response.Document()
+	if err != nil {
+		return web.PageUnknown
+	}
+	pageURL := response.FinalURL
+	if pageURL == "" {
+		pageURL = response.URL
+	}
+	return web.ClassifyPageURL(doc, pageURL)
+}
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// DocumentParserFunc adapts a function that extracts data from an HTML
+// document to the Parser interface. The function is called with the page
+// document, reusing the tree parsed by the fetcher when available.
+type DocumentParserFunc func(ctx context.Context, page *fetch.Response, doc *web.Document) (any, error)
+
+// Parse implements the Parser interface.
+func (f DocumentParserFunc) Parse(ctx context.Context, page *fetch.Response) (any, error) {
+	doc, err := page.Document()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+	return f(ctx, page, doc)
+}
+
+// NewProductParser returns a Parser that extracts schema.org Product data as
+// a *web.Product. Pages that do not describe a product parse to nil.
+func NewProductParser() Parser {
+	return DocumentParserFunc(func(ctx context.Context, page *fetch.Response, doc *web.Document) (any, error) {
+		if product := web.ExtractProduct(doc); product != nil {
+			return product, nil
+		}
+		return nil, nil
+	})
+}
+
+// NewArticleParser returns a Parser that extracts schema.org Article data as
+// a *web.Article, including the main content text of the page. Pages that do
+// not look like articles parse to nil.
+func NewArticleParser() Parser {
+	return DocumentParserFunc(func(ctx context.Context, page *fetch.Response, doc *web.Document) (any, error) {
+		if article := web.ExtractArticle(doc); article != nil {
+			return article, nil
+		}
+		return nil, nil
+	})
+}
+
+// NewRecipeParser returns a Parser that extracts schema.org Recipe data as a
+// *web.Recipe. Pages that do not describe a recipe parse to nil.
+func NewRecipeParser() Parser {
+	return DocumentParserFunc(func(ctx context.Context, page *fetch.Response, doc *web.Document) (any, error) {
+		if recipe := web.ExtractRecipe(doc); recipe != nil {
+			return recipe, nil
+		}
+		return nil, nil
+	})
+}
+
+// NewEventParser returns a Parser that extracts schema.org Event data as a
+// []*web.Event. Pages that do not describe any events parse to nil.
+func NewEventParser() Parser {
+	return DocumentParserFunc(func(ctx context.Context, page *fetch.Response, doc *web.Document) (any, error) {
+		if events := web.ExtractEvents(doc); len(events) > 0 {
+			return events, nil
+		}
+		return nil, nil
+	})
+}
+
+// NewJobPostingParser returns a Parser that extracts schema.org JobPosting
+// data as a []*web.JobPosting. Pages without job postings parse to nil.
+func NewJobPostingParser() Parser {
+	return DocumentParserFunc(func(ctx context.Context, page *fetch.Response, doc *web.Document) (any, error) {
+		if jobs := web.ExtractJobPostings(doc); len(jobs) > 0 {
+			return jobs, nil
+		}
+		return nil, nil
+	})
+}
+
+// NewFAQParser returns a Parser that extracts the questions and answers of a
+// schema.org FAQPage as a []*web.FAQItem. Pages without an FAQ parse to nil.
+func NewFAQParser() Parser {
+	return DocumentParserFunc(func(ctx context.Context, page *fetch.Response, doc *web.Document) (any, error) {
+		if faq := web.ExtractFAQ(doc); len(faq) > 0 {
+			return faq, nil
+		}
+		return nil, nil
+	})
+}
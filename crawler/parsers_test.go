@@ -0,0 +1,153 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductParser(t *testing.T) {
+	parser := NewProductParser()
+
+	parsed, err := parser.Parse(context.Background(), &fetch.Response{
+		HTML: `<html><head><script type="application/ld+json">
+			{"@type": "Product", "name": "Widget", "offers": {"price": 5, "priceCurrency": "USD"}}
+		</script></head></html>`,
+	})
+	require.NoError(t, err)
+	product, ok := parsed.(*web.Product)
+	require.True(t, ok)
+	require.Equal(t, "Widget", product.Name)
+	require.Equal(t, 5.0, product.Price)
+
+	parsed, err = parser.Parse(context.Background(), &fetch.Response{HTML: `<html><body>Hi</body></html>`})
+	require.NoError(t, err)
+	require.Nil(t, parsed)
+}
+
+func TestArticleParser(t *testing.T) {
+	parsed, err := NewArticleParser().Parse(context.Background(), &fetch.Response{
+		HTML: `<html><head><title>Post</title></head><body><article><p>Hello there.</p></article></body></html>`,
+	})
+	require.NoError(t, err)
+	article, ok := parsed.(*web.Article)
+	require.True(t, ok)
+	require.Equal(t, "Post", article.Headline)
+	require.Equal(t, "Hello there.", article.Body)
+}
+
+func TestEventParser(t *testing.T) {
+	parser := NewEventParser()
+
+	parsed, err := parser.Parse(context.Background(), &fetch.Response{
+		HTML: `<html><head><script type="application/ld+json">
+			{"@type": "Event", "name": "Meetup", "startDate": "2025-01-02"}
+		</script></head></html>`,
+	})
+	require.NoError(t, err)
+	events, ok := parsed.([]*web.Event)
+	require.True(t, ok)
+	require.Len(t, events, 1)
+	require.Equal(t, "Meetup", events[0].Name)
+
+	parsed, err = parser.Parse(context.Background(), &fetch.Response{HTML: `<html><body>Hi</body></html>`})
+	require.NoError(t, err)
+	require.Nil(t, parsed)
+}
+
+func TestClassifyResponse(t *testing.T) {
+	require.Equal(t, web.PageProduct, ClassifyResponse(&fetch.Response{
+		HTML: `<script type="application/ld+json">{"@type": "Product", "name": "Widget"}</script>`,
+	}))
+	require.Equal(t, web.PageSearch, ClassifyResponse(&fetch.Response{
+		URL:      "https://example.com/find",
+		FinalURL: "https://example.com/search",
+		HTML:     `<p>Results</p>`,
+	}))
+	require.Equal(t, web.PageUnknown, ClassifyResponse(&fetch.Response{URL: "https://example.com", HTML: `<p>Hi</p>`}))
+}
+
+func TestCrawler_ParserConditions(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com/widget", &fetch.Response{
+		StatusCode: 200,
+		HTML:       `<script type="application/ld+json">{"@type": "Product", "name": "Widget"}</script>`,
+	})
+	mockFetcher.AddResponse("https://example.com/news", &fetch.Response{
+		StatusCode: 200,
+		HTML:       `<html><head><title>News</title></head><body><article><p>Hello.</p></article></body></html>`,
+	})
+	mockFetcher.AddResponse("https://example.com/missing", &fetch.Response{
+		StatusCode: 404,
+		HTML:       `<script type="application/ld+json">{"@type": "Product", "name": "Gone"}</script><article><p>Sold out.</p></article>`,
+	})
+	mockFetcher.AddResponse("https://example.com/empty", &fetch.Response{StatusCode: 200})
+
+	classified := 0
+	crawler, err := New(Options{
+		Workers:        1,
+		DefaultFetcher: mockFetcher,
+		FollowBehavior: FollowNone,
+		ParserRules: []*ParserRule{
+			NewParserRule("example.com", NewProductParser(), WithParserPriority(2), WithParserCondition(ResponseCondition{
+				StatusCodes: []int{200},
+				PageTypes:   []web.PageType{web.PageProduct},
+			})),
+			NewParserRule("example.com", NewArticleParser(), WithParserPriority(1), WithParserCondition(ResponseCondition{
+				MinContentLength: 1,
+			})),
+		},
+		PageClassifier: func(response *fetch.Response) web.PageType {
+			classified++
+			return ClassifyResponse(response)
+		},
+	})
+	require.NoError(t, err)
+
+	parsed := map[string]any{}
+	urls := []string{"https://example.com/widget", "https://example.com/news", "https://example.com/missing", "https://example.com/empty"}
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error {
+		parsed[result.URL.Path] = result.Parsed
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.IsType(t, &web.Product{}, parsed["/widget"])
+	require.IsType(t, &web.Article{}, parsed["/news"])
+	// 404 pages don't match the status codes of the product rule
+	require.IsType(t, &web.Article{}, parsed["/missing"])
+	require.Nil(t, parsed["/empty"])
+	// Pages are only classified when their status code matches
+	require.Equal(t, 3, classified)
+}
+
+func TestCrawler_ClassifyPages(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		HTML:  `<html><body><article>A</article><article>B</article><article>C</article></body></html>`,
+		Links: []*fetch.Link{{URL: "/2024/05/post"}, {URL: "/about"}},
+	})
+	mockFetcher.AddResponse("https://example.com/2024/05/post", &fetch.Response{URL: "https://example.com/2024/05/post", HTML: `<p>Post</p>`})
+	mockFetcher.AddResponse("https://example.com/about", &fetch.Response{URL: "https://example.com/about", HTML: `<p>About</p>`})
+
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher, ClassifyPages: true})
+	require.NoError(t, err)
+	pageTypes := map[string]web.PageType{}
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		pageTypes[result.URL.Path] = result.PageType
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]web.PageType{
+		"":              web.PageListing,
+		"/2024/05/post": web.PageArticle,
+		"/about":        web.PageUnknown,
+	}, pageTypes)
+	require.Equal(t, map[web.PageType]int64{
+		web.PageListing: 1,
+		web.PageArticle: 1,
+		web.PageUnknown: 1,
+	}, crawler.GetStats().GetPageTypes())
+}
@@ -0,0 +1,142 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// Reasons URLs are skipped in a Plan.
+const (
+	SkipInvalidURL  = "invalid url"
+	SkipDuplicate   = "duplicate"
+	SkipNotFollowed = "not followed" // excluded by the follow behavior or URL patterns
+	SkipMaxURLs     = "max urls reached"
+	SkipPatternMax  = "pattern budget reached" // see PatternRule.MaxURLs
+	SkipDisallowed  = "disallowed by robots.txt"
+)
+
+// Plan reports what a crawl would do, as computed by Crawler.Plan.
+type Plan struct {
+	// URLs would be crawled, in the order they would be queued.
+	URLs []*PlannedURL
+
+	// Skipped URLs would not be crawled. See Reason.
+	Skipped []*PlannedURL
+}
+
+// PlannedURL is a URL considered by a Plan.
+type PlannedURL struct {
+	URL    string
+	Depth  int    // 0 for seeds, 1 for links found on seeds
+	Source string // seed the link was found on
+	Reason string // why the URL would be skipped
+	Error  string // why the links of a seed are unknown, e.g. a fetch error
+}
+
+// Plan performs a dry run of a crawl: the seeds are fetched and the filters
+// and budgets of the crawler are applied to them and to the links found on
+// them, without fetching anything else or calling parsers. Use it to check a
+// configuration before an expensive crawl.
+func (c *Crawler) Plan(ctx context.Context, urls []string) (*Plan, error) {
+	plan := &Plan{}
+	seen := map[string]bool{}
+	budget := c.maxURLs
+	patternCounts := map[*PatternRule]int{}
+	disallowed := func(value string) bool {
+		u, err := url.Parse(value)
+		return err == nil && !c.robotsAllowed(ctx, u)
+	}
+	add := func(planned *PlannedURL) bool {
+		value, err := c.queueValue(planned.URL)
+		switch {
+		case err != nil:
+			planned.Reason = SkipInvalidURL
+		case seen[value]:
+			planned.Reason = SkipDuplicate
+		case disallowed(value):
+			planned.Reason = SkipDisallowed
+		case c.maxURLs > 0 && budget <= 0:
+			planned.Reason = SkipMaxURLs
+		default:
+			if rule, _ := c.Classify(value); rule != nil && rule.MaxURLs > 0 {
+				if patternCounts[rule] >= rule.MaxURLs {
+					planned.Reason = SkipPatternMax
+				}
+				patternCounts[rule]++
+			}
+		}
+		if planned.Reason != "" {
+			plan.Skipped = append(plan.Skipped, planned)
+			return false
+		}
+		seen[value] = true
+		budget--
+		planned.URL = value
+		plan.URLs = append(plan.URLs, planned)
+		return true
+	}
+
+	var seeds []*PlannedURL
+	for _, rawURL := range urls {
+		if seed := (&PlannedURL{URL: rawURL}); add(seed) {
+			seeds = append(seeds, seed)
+		}
+	}
+	for _, seed := range seeds {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		parsedURL, links, err := c.planSeed(ctx, seed.URL)
+		if err != nil {
+			seed.Error = err.Error()
+			continue
+		}
+		followed := map[string]bool{}
+		for _, link := range c.filterLinks(parsedURL, links) {
+			followed[link] = true
+		}
+		for _, link := range links {
+			planned := &PlannedURL{URL: link, Depth: 1, Source: seed.URL}
+			if !followed[link] {
+				planned.Reason = SkipNotFollowed
+				plan.Skipped = append(plan.Skipped, planned)
+				continue
+			}
+			add(planned)
+		}
+	}
+	return plan, nil
+}
+
+// planSeed fetches and validates a seed and returns the links found on it.
+func (c *Crawler) planSeed(ctx context.Context, rawURL string) (*url.URL, []string, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	domain := parsedURL.Hostname()
+	fetcher, exists := c.getFetcher(domain)
+	if !exists {
+		return nil, nil, errors.New("no fetcher configured for domain")
+	}
+	response, err := fetcher.Fetch(ctx, &fetch.Request{URL: rawURL})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, validator := range c.validators {
+		if err := validator.Validate(ctx, response); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !c.ignoreRobots && response.RobotsDirectives().NoFollow {
+		return parsedURL, nil, nil
+	}
+	var links []string
+	if response.Links != nil {
+		links = c.extractURLs(response.Links, linkBase(domain, response))
+	}
+	return parsedURL, links, nil
+}
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_Plan(t *testing.T) {
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddResponse("https://example.com", &fetch.Response{
+		URL: "https://example.com",
+		Links: []*fetch.Link{
+			{URL: "/about"},
+			{URL: "/blog"},
+			{URL: "/pricing"},
+			{URL: "https://other.com/page"},
+		},
+	})
+	fetcher.AddError("https://broken.com", errors.New("connection refused"))
+
+	crawler, err := New(Options{
+		MaxURLs:        4,
+		DefaultFetcher: fetcher,
+	})
+	require.NoError(t, err)
+	plan, err := crawler.Plan(context.Background(), []string{
+		"https://example.com/",
+		"https://example.com",
+		"https://broken.com",
+	})
+	require.NoError(t, err)
+
+	type entry struct {
+		URL, Reason, Error string
+		Depth              int
+	}
+	entries := func(urls []*PlannedURL) []entry {
+		var result []entry
+		for _, u := range urls {
+			result = append(result, entry{URL: u.URL, Reason: u.Reason, Error: u.Error, Depth: u.Depth})
+		}
+		return result
+	}
+	require.Equal(t, []entry{
+		{URL: "https://example.com"},
+		{URL: "https://broken.com", Error: "connection refused"},
+		{URL: "https://example.com/about", Depth: 1},
+		{URL: "https://example.com/blog", Depth: 1},
+	}, entries(plan.URLs))
+	require.Equal(t, []entry{
+		{URL: "https://example.com", Reason: SkipDuplicate},
+		{URL: "https://example.com/pricing", Reason: SkipMaxURLs, Depth: 1},
+		{URL: "https://other.com/page", Reason: SkipNotFollowed, Depth: 1},
+	}, entries(plan.Skipped))
+	require.Equal(t, "https://example.com", plan.URLs[2].Source)
+}
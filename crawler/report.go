@@ -0,0 +1,427 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+)
+
+// Defaults of ReportOptions.
+const (
+	DefaultReportMaxErrors    = 10
+	DefaultReportMaxSlowPages = 10
+)
+
+// ReportOptions defines the options of a ReportBuilder.
+type ReportOptions struct {
+	// Title of the report. Defaults to "Crawl report".
+	Title string
+
+	// MaxErrors is the number of most frequent errors reported. Defaults to
+	// DefaultReportMaxErrors.
+	MaxErrors int
+
+	// MaxSlowPages is the number of slowest pages reported. Defaults to
+	// DefaultReportMaxSlowPages.
+	MaxSlowPages int
+}
+
+// CrawlReport summarizes the outcome of a crawl for people who do not read
+// logs, e.g. to share the results of a crawl. See ReportBuilder.
+type CrawlReport struct {
+	Title    string        `json:"title"`
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	Duration time.Duration `json:"duration"`
+
+	// Processed is the number of pages processed, Succeeded and Failed the
+	// number of those that succeeded or failed, and Flagged the number of
+	// successful pages flagged by the StatusPolicy.
+	Processed int `json:"processed"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Flagged   int `json:"flagged"`
+
+	// StatusCodes is the number of pages of each status code.
+	StatusCodes map[int]int `json:"status_codes,omitempty"`
+
+	// PageTypes is the number of pages of each type, with
+	// Options.ClassifyPages.
+	PageTypes map[web.PageType]int `json:"page_types,omitempty"`
+
+	// Hosts is the number of pages of each host, by descending number of
+	// pages.
+	Hosts []*ReportHost `json:"hosts,omitempty"`
+
+	// Depths is the number of pages found at each depth from the seeds.
+	Depths map[int]int `json:"depths,omitempty"`
+
+	// TopErrors are the most frequent errors, by descending count.
+	TopErrors []*ReportError `json:"top_errors,omitempty"`
+
+	// SlowestPages are the pages that took the longest to fetch, slowest
+	// first.
+	SlowestPages []*ReportPage `json:"slowest_pages,omitempty"`
+
+	// AverageFetchDuration is the average time taken by fetches.
+	AverageFetchDuration time.Duration `json:"average_fetch_duration"`
+
+	// Coverage compares the crawl with a sitemap, if set by the caller.
+	Coverage *SitemapReport `json:"coverage,omitempty"`
+}
+
+// ReportHost is the number of pages crawled on a host.
+type ReportHost struct {
+	Host   string `json:"host"`
+	Pages  int    `json:"pages"`
+	Failed int    `json:"failed"`
+}
+
+// ReportError is an error of a crawl and the number of pages that failed
+// with it. Errors that only differ by the URLs they mention are counted
+// together.
+type ReportError struct {
+	Error   string `json:"error"`
+	Count   int    `json:"count"`
+	Example string `json:"example"` // URL of a page that failed with the error
+}
+
+// ReportPage is a page of a crawl and the time taken to fetch it.
+type ReportPage struct {
+	URL           string        `json:"url"`
+	StatusCode    int           `json:"status_code,omitempty"`
+	FetchDuration time.Duration `json:"fetch_duration"`
+}
+
+// ReportBuilder collects the results of a crawl into a CrawlReport. It only
+// retains counts and the slowest pages, so it can be used for large crawls.
+// It is safe for concurrent use.
+//
+//	builder := crawler.NewReportBuilder(crawler.ReportOptions{})
+//	err := c.Crawl(ctx, seeds, builder.Callback(nil))
+//	...
+//	err = builder.Report().WriteHTML(f)
+type ReportBuilder struct {
+	title        string
+	maxErrors    int
+	maxSlowPages int
+	mutex        sync.Mutex
+	report       *CrawlReport
+	hosts        map[string]*ReportHost
+	errors       map[string]*ReportError
+	fetches      int
+	fetchTime    time.Duration
+}
+
+// NewReportBuilder creates a new ReportBuilder. The report starts when it is
+// created.
+func NewReportBuilder(opts ReportOptions) *ReportBuilder {
+	if opts.Title == "" {
+		opts.Title = "Crawl report"
+	}
+	if opts.MaxErrors <= 0 {
+		opts.MaxErrors = DefaultReportMaxErrors
+	}
+	if opts.MaxSlowPages <= 0 {
+		opts.MaxSlowPages = DefaultReportMaxSlowPages
+	}
+	return &ReportBuilder{
+		title:        opts.Title,
+		maxErrors:    opts.MaxErrors,
+		maxSlowPages: opts.MaxSlowPages,
+		report: &CrawlReport{
+			Started:     time.Now(),
+			StatusCodes: map[int]int{},
+			PageTypes:   map[web.PageType]int{},
+			Depths:      map[int]int{},
+		},
+		hosts:  map[string]*ReportHost{},
+		errors: map[string]*ReportError{},
+	}
+}
+
+// Callback returns a crawl callback that adds the results to the report
+// before passing them to the given callback, if any.
+func (b *ReportBuilder) Callback(callback Callback) Callback {
+	return func(ctx context.Context, result *Result) error {
+		b.Add(result)
+		if callback == nil {
+			return nil
+		}
+		return callback(ctx, result)
+	}
+}
+
+// Add adds the result of a page to the report.
+func (b *ReportBuilder) Add(result *Result) {
+	if result == nil || result.URL == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	r := b.report
+	r.Processed++
+	r.Depths[result.Depth]++
+
+	host := result.URL.Hostname()
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &ReportHost{Host: host}
+		b.hosts[host] = h
+	}
+	h.Pages++
+
+	statusCode := 0
+	if result.Response != nil {
+		statusCode = result.Response.StatusCode
+		if statusCode != 0 {
+			r.StatusCodes[statusCode]++
+		}
+	}
+	if result.Error != nil {
+		r.Failed++
+		h.Failed++
+		message := reportURLPattern.ReplaceAllString(result.Error.Error(), "<url>")
+		e, ok := b.errors[message]
+		if !ok {
+			e = &ReportError{Error: message, Example: result.URL.String()}
+			b.errors[message] = e
+		}
+		e.Count++
+	} else {
+		r.Succeeded++
+		if result.Flagged {
+			r.Flagged++
+		}
+		if result.PageType != web.PageUnknown {
+			r.PageTypes[result.PageType]++
+		}
+	}
+
+	if result.FetchDuration > 0 {
+		b.fetches++
+		b.fetchTime += result.FetchDuration
+		b.addSlowPage(&ReportPage{
+			URL:           result.URL.String(),
+			StatusCode:    statusCode,
+			FetchDuration: result.FetchDuration,
+		})
+	}
+}
+
+// reportURLPattern matches the URLs mentioned by errors.
+var reportURLPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s"']+`)
+
+// addSlowPage keeps the page if it is one of the slowest.
+func (b *ReportBuilder) addSlowPage(page *ReportPage) {
+	pages := b.report.SlowestPages
+	i := sort.Search(len(pages), func(i int) bool {
+		return pages[i].FetchDuration < page.FetchDuration
+	})
+	if i >= b.maxSlowPages {
+		return
+	}
+	if len(pages) < b.maxSlowPages {
+		pages = append(pages, nil)
+	}
+	copy(pages[i+1:], pages[i:])
+	pages[i] = page
+	b.report.SlowestPages = pages
+}
+
+// Report returns the report of the results added so far, finished now.
+func (b *ReportBuilder) Report() *CrawlReport {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	report := *b.report
+	report.Title = b.title
+	report.Finished = time.Now()
+	report.Duration = report.Finished.Sub(report.Started)
+	if b.fetches > 0 {
+		report.AverageFetchDuration = b.fetchTime / time.Duration(b.fetches)
+	}
+	report.StatusCodes = maps.Clone(b.report.StatusCodes)
+	report.PageTypes = maps.Clone(b.report.PageTypes)
+	report.Depths = maps.Clone(b.report.Depths)
+	report.SlowestPages = append([]*ReportPage(nil), b.report.SlowestPages...)
+
+	report.Hosts = nil
+	for _, h := range b.hosts {
+		host := *h
+		report.Hosts = append(report.Hosts, &host)
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		if report.Hosts[i].Pages != report.Hosts[j].Pages {
+			return report.Hosts[i].Pages > report.Hosts[j].Pages
+		}
+		return report.Hosts[i].Host < report.Hosts[j].Host
+	})
+
+	report.TopErrors = nil
+	for _, e := range b.errors {
+		reportErr := *e
+		report.TopErrors = append(report.TopErrors, &reportErr)
+	}
+	sort.Slice(report.TopErrors, func(i, j int) bool {
+		if report.TopErrors[i].Count != report.TopErrors[j].Count {
+			return report.TopErrors[i].Count > report.TopErrors[j].Count
+		}
+		return report.TopErrors[i].Error < report.TopErrors[j].Error
+	})
+	if len(report.TopErrors) > b.maxErrors {
+		report.TopErrors = report.TopErrors[:b.maxErrors]
+	}
+	return &report
+}
+
+// SuccessRate returns the fraction of the processed pages that succeeded,
+// or 1 if no page was processed.
+func (r *CrawlReport) SuccessRate() float64 {
+	if r.Processed == 0 {
+		return 1
+	}
+	return float64(r.Succeeded) / float64(r.Processed)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(value float64) string {
+		return fmt.Sprintf("%.1f%%", 100*value)
+	},
+	"duration": func(d time.Duration) string {
+		if d >= time.Second {
+			return d.Round(10 * time.Millisecond).String()
+		}
+		return d.Round(time.Millisecond).String()
+	},
+	"sortedKeys": func(counts map[int]int) []int {
+		keys := make([]int, 0, len(counts))
+		for key := range counts {
+			keys = append(keys, key)
+		}
+		sort.Ints(keys)
+		return keys
+	},
+	"pageTypes": func(counts map[web.PageType]int) []web.PageType {
+		var types []web.PageType
+		for _, pageType := range web.PageTypes {
+			if counts[pageType] > 0 {
+				types = append(types, pageType)
+			}
+		}
+		return types
+	},
+}).Parse(reportTemplateText))
+
+// WriteHTML writes the report as a standalone HTML page, with no external
+// stylesheets or scripts, so it can be shared as a single file.
+func (r *CrawlReport) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
+
+// reportTemplateText is the template of the HTML report.
+const reportTemplateText = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; }
+h1 { margin-bottom: 0.25rem; }
+h2 { margin-top: 2rem; border-bottom: 1px solid #ddd; padding-bottom: 0.25rem; }
+.subtitle { color: #666; }
+.cards { display: flex; flex-wrap: wrap; gap: 1rem; margin-top: 1.5rem; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 0.75rem 1rem; min-width: 8rem; }
+.card .value { font-size: 1.6rem; font-weight: 600; }
+.card .label { color: #666; font-size: 0.85rem; }
+.failed { color: #b00020; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.35rem 0.5rem; border-bottom: 1px solid #eee; vertical-align: top; }
+th { background: #f6f6f6; }
+td.number, th.number { text-align: right; white-space: nowrap; }
+td.url { word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="subtitle">{{.Started.Format "2006-01-02 15:04:05 MST"}} &middot; {{duration .Duration}}</p>
+
+<div class="cards">
+<div class="card"><div class="value">{{.Processed}}</div><div class="label">Pages processed</div></div>
+<div class="card"><div class="value">{{.Succeeded}}</div><div class="label">Succeeded</div></div>
+<div class="card"><div class="value{{if .Failed}} failed{{end}}">{{.Failed}}</div><div class="label">Failed</div></div>
+<div class="card"><div class="value">{{.Flagged}}</div><div class="label">Flagged</div></div>
+<div class="card"><div class="value">{{percent .SuccessRate}}</div><div class="label">Success rate</div></div>
+<div class="card"><div class="value">{{duration .AverageFetchDuration}}</div><div class="label">Average fetch time</div></div>
+</div>
+
+{{with .Coverage}}
+<h2>Sitemap coverage</h2>
+<p>{{.Crawled}} of {{.Listed}} sitemap URLs crawled ({{percent .Coverage}}).
+{{len .NotCrawled}} not crawled, {{len .NotListed}} crawled but not listed, {{len .Failed}} failed.</p>
+{{if .NotCrawled}}
+<table>
+<tr><th>Not crawled</th></tr>
+{{range .NotCrawled}}<tr><td class="url">{{.}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+
+{{if .TopErrors}}
+<h2>Top errors</h2>
+<table>
+<tr><th>Error</th><th class="number">Pages</th><th>Example</th></tr>
+{{range .TopErrors}}<tr><td>{{.Error}}</td><td class="number">{{.Count}}</td><td class="url"><a href="{{.Example}}">{{.Example}}</a></td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .SlowestPages}}
+<h2>Slowest pages</h2>
+<table>
+<tr><th>Page</th><th class="number">Status</th><th class="number">Fetch time</th></tr>
+{{range .SlowestPages}}<tr><td class="url"><a href="{{.URL}}">{{.URL}}</a></td><td class="number">{{if .StatusCode}}{{.StatusCode}}{{end}}</td><td class="number">{{duration .FetchDuration}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .Hosts}}
+<h2>Hosts</h2>
+<table>
+<tr><th>Host</th><th class="number">Pages</th><th class="number">Failed</th></tr>
+{{range .Hosts}}<tr><td>{{.Host}}</td><td class="number">{{.Pages}}</td><td class="number">{{.Failed}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .StatusCodes}}
+<h2>Status codes</h2>
+<table>
+<tr><th>Status</th><th class="number">Pages</th></tr>
+{{range $code := sortedKeys .StatusCodes}}<tr><td>{{$code}}</td><td class="number">{{index $.StatusCodes $code}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{with pageTypes .PageTypes}}
+<h2>Page types</h2>
+<table>
+<tr><th>Type</th><th class="number">Pages</th></tr>
+{{range .}}<tr><td>{{.}}</td><td class="number">{{index $.PageTypes .}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .Depths}}
+<h2>Depth</h2>
+<table>
+<tr><th>Links from seeds</th><th class="number">Pages</th></tr>
+{{range $depth := sortedKeys .Depths}}<tr><td>{{$depth}}</td><td class="number">{{index $.Depths $depth}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportBuilder(t *testing.T) {
+	result := func(rawURL string, statusCode int, err error, duration time.Duration) *Result {
+		u, _ := url.Parse(rawURL)
+		r := &Result{URL: u, Error: err, FetchDuration: duration}
+		if statusCode != 0 {
+			r.Response = &fetch.Response{URL: rawURL, StatusCode: statusCode}
+		}
+		return r
+	}
+
+	builder := NewReportBuilder(ReportOptions{Title: "Example <crawl>", MaxErrors: 2, MaxSlowPages: 2})
+	callback := builder.Callback(nil)
+	for _, r := range []*Result{
+		result("https://example.com/", 200, nil, 100*time.Millisecond),
+		result("https://example.com/a", 200, nil, 300*time.Millisecond),
+		result("https://example.com/b", 200, nil, 200*time.Millisecond),
+		result("https://example.com/c", 500, &StatusError{StatusCode: 500}, 50*time.Millisecond),
+		result("https://other.com/x", 0, errors.New("Get \"https://other.com/x\": timeout"), 0),
+		result("https://other.com/y", 0, errors.New("Get \"https://other.com/y\": timeout"), 0),
+		result("https://other.com/z", 0, errors.New("dns failure"), 0),
+	} {
+		require.NoError(t, callback(context.Background(), r))
+	}
+	flagged := result("https://example.com/missing", 404, nil, 0)
+	flagged.Flagged = true
+	flagged.PageType = web.PageError
+	flagged.Depth = 1
+	builder.Add(flagged)
+
+	report := builder.Report()
+	assert.Equal(t, "Example <crawl>", report.Title)
+	assert.Equal(t, 8, report.Processed)
+	assert.Equal(t, 4, report.Succeeded)
+	assert.Equal(t, 4, report.Failed)
+	assert.Equal(t, 1, report.Flagged)
+	assert.Equal(t, map[int]int{200: 3, 404: 1, 500: 1}, report.StatusCodes)
+	assert.Equal(t, map[web.PageType]int{web.PageError: 1}, report.PageTypes)
+	assert.Equal(t, map[int]int{0: 7, 1: 1}, report.Depths)
+	assert.Equal(t, []*ReportHost{
+		{Host: "example.com", Pages: 5, Failed: 1},
+		{Host: "other.com", Pages: 3, Failed: 3},
+	}, report.Hosts)
+	assert.Equal(t, []*ReportError{
+		{Error: "Get \"<url>\": timeout", Count: 2, Example: "https://other.com/x"},
+		{Error: "dns failure", Count: 1, Example: "https://other.com/z"},
+	}, report.TopErrors)
+	assert.Equal(t, []*ReportPage{
+		{URL: "https://example.com/a", StatusCode: 200, FetchDuration: 300 * time.Millisecond},
+		{URL: "https://example.com/b", StatusCode: 200, FetchDuration: 200 * time.Millisecond},
+	}, report.SlowestPages)
+	assert.Equal(t, 162500*time.Microsecond, report.AverageFetchDuration)
+	assert.InDelta(t, 0.5, report.SuccessRate(), 0.001)
+
+	report.Coverage = &SitemapReport{Listed: 4, Crawled: 3, NotCrawled: []string{"https://example.com/unlinked"}}
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteHTML(&buf))
+	html := buf.String()
+	assert.Contains(t, html, "<title>Example &lt;crawl&gt;</title>")
+	assert.Contains(t, html, "50.0%")
+	assert.Contains(t, html, "Get &#34;&lt;url&gt;&#34;: timeout")
+	assert.Contains(t, html, `<a href="https://example.com/a">https://example.com/a</a>`)
+	assert.Contains(t, html, "300ms")
+	assert.Contains(t, html, "3 of 4 sitemap URLs crawled (75.0%)")
+	assert.Contains(t, html, "https://example.com/unlinked")
+	assert.Contains(t, html, "<td>error</td>")
+	assert.NotContains(t, html, "<script")
+}
+
+func TestCrawler_FetchDuration(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com", StatusCode: 200})
+
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher})
+	require.NoError(t, err)
+	builder := NewReportBuilder(ReportOptions{})
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, builder.Callback(func(ctx context.Context, result *Result) error {
+		assert.Positive(t, result.FetchDuration)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	report := builder.Report()
+	require.Len(t, report.SlowestPages, 1)
+	assert.Equal(t, "https://example.com", report.SlowestPages[0].URL)
+	assert.Positive(t, report.AverageFetchDuration)
+}
@@ -0,0 +1,222 @@
+package crawler
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// DefaultMaxStoredResults is the default number of results retained by a
+// ResultStore.
+const DefaultMaxStoredResults = 10000
+
+// ResultStoreOptions defines the options of a ResultStore.
+type ResultStoreOptions struct {
+	// MaxResults bounds the number of results retained. The oldest results
+	// are evicted once it is reached. Defaults to DefaultMaxStoredResults.
+	MaxResults int
+
+	// KeepContent retains the content of responses: their HTML, Markdown,
+	// text, screenshots and PDFs. By default only their status, headers,
+	// metadata and links are retained, to bound memory.
+	KeepContent bool
+}
+
+// ResultQuery selects results of a ResultStore. Zero values match all
+// results.
+type ResultQuery struct {
+	// Domain matches the results of a host and its subdomains.
+	Domain string
+
+	// StatusCode matches the results with a response of this status.
+	StatusCode int
+
+	// Failed matches the results with an error.
+	Failed bool
+
+	// Pattern matches the results of URLs classified by the PatternRule of
+	// this name.
+	Pattern string
+
+	// Offset is the number of matching results skipped, and Limit the
+	// maximum number of results returned, or all if zero.
+	Offset int
+	Limit  int
+}
+
+// ResultPage is a page of the results matching a query.
+type ResultPage struct {
+	Results []*Result
+
+	// Total is the number of results matching the query.
+	Total int
+
+	// NextOffset is the offset of the next page, or zero if this is the
+	// last page.
+	NextOffset int
+}
+
+// ResultStore retains the results of crawls in memory, so that their
+// outcomes can be inspected after Crawl returns without writing a callback.
+// Results are listed in the order they were added; a result for a URL
+// already stored replaces it, e.g. when a page is retried. It is safe for
+// concurrent use.
+//
+//	store := crawler.NewResultStore(crawler.ResultStoreOptions{})
+//	c, err := crawler.New(crawler.Options{Results: store, ...})
+//	err = c.Crawl(ctx, seeds, nil)
+//	page := store.Query(crawler.ResultQuery{Failed: true, Limit: 20})
+type ResultStore struct {
+	maxResults  int
+	keepContent bool
+	mutex       sync.Mutex
+	ring        []*Result
+	first       int            // sequence number of the oldest result
+	next        int            // sequence number of the next result
+	seqs        map[string]int // sequence number of each stored URL
+}
+
+// NewResultStore creates a new empty result store.
+func NewResultStore(opts ResultStoreOptions) *ResultStore {
+	if opts.MaxResults <= 0 {
+		opts.MaxResults = DefaultMaxStoredResults
+	}
+	return &ResultStore{
+		maxResults:  opts.MaxResults,
+		keepContent: opts.KeepContent,
+		ring:        make([]*Result, 0, min(opts.MaxResults, 1024)),
+		seqs:        map[string]int{},
+	}
+}
+
+// Add stores a result, evicting the oldest result if the store is full.
+func (s *ResultStore) Add(result *Result) {
+	if result == nil || result.URL == nil {
+		return
+	}
+	stored := *result
+	if !s.keepContent && result.Response != nil {
+		stored.Response = summarizeResponse(result.Response)
+	}
+	key := result.URL.String()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if seq, ok := s.seqs[key]; ok {
+		s.ring[seq%s.maxResults] = &stored
+		return
+	}
+	if s.next-s.first == s.maxResults {
+		oldest := s.ring[s.first%s.maxResults]
+		delete(s.seqs, oldest.URL.String())
+		s.first++
+	}
+	if len(s.ring) < s.maxResults {
+		s.ring = append(s.ring, &stored)
+	} else {
+		s.ring[s.next%s.maxResults] = &stored
+	}
+	s.seqs[key] = s.next
+	s.next++
+}
+
+// Callback returns a crawl callback that stores the results before passing
+// them to the given callback, if any.
+func (s *ResultStore) Callback(callback Callback) Callback {
+	return func(ctx context.Context, result *Result) error {
+		s.Add(result)
+		if callback == nil {
+			return nil
+		}
+		return callback(ctx, result)
+	}
+}
+
+// Len returns the number of results stored.
+func (s *ResultStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.next - s.first
+}
+
+// Get returns the stored result of a URL, if any.
+func (s *ResultStore) Get(rawURL string) (*Result, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	seq, ok := s.seqs[rawURL]
+	if !ok {
+		return nil, false
+	}
+	return s.ring[seq%s.maxResults], true
+}
+
+// Query returns a page of the results matching the query, oldest first.
+func (s *ResultStore) Query(query ResultQuery) *ResultPage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	page := &ResultPage{}
+	for seq := s.first; seq < s.next; seq++ {
+		result := s.ring[seq%s.maxResults]
+		if !query.matches(result) {
+			continue
+		}
+		if page.Total >= query.Offset && (query.Limit <= 0 || len(page.Results) < query.Limit) {
+			page.Results = append(page.Results, result)
+		}
+		page.Total++
+	}
+	if end := query.Offset + len(page.Results); end < page.Total {
+		page.NextOffset = end
+	}
+	return page
+}
+
+// Reset removes all results.
+func (s *ResultStore) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ring = s.ring[:0]
+	s.first, s.next = 0, 0
+	clear(s.seqs)
+}
+
+// matches returns true if a result matches the query.
+func (q *ResultQuery) matches(result *Result) bool {
+	if q.Domain != "" {
+		host := strings.ToLower(result.URL.Hostname())
+		domain := strings.ToLower(q.Domain)
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			return false
+		}
+	}
+	if q.StatusCode != 0 && (result.Response == nil || result.Response.StatusCode != q.StatusCode) {
+		return false
+	}
+	if q.Failed && result.Error == nil {
+		return false
+	}
+	if q.Pattern != "" && result.Pattern != q.Pattern {
+		return false
+	}
+	return true
+}
+
+// summarizeResponse returns a copy of a response without its content.
+func summarizeResponse(response *fetch.Response) *fetch.Response {
+	return &fetch.Response{
+		Version:           response.Version,
+		URL:               response.URL,
+		StatusCode:        response.StatusCode,
+		Headers:           response.Headers,
+		Error:             response.Error,
+		Metadata:          response.Metadata,
+		Links:             response.Links,
+		Timestamp:         response.Timestamp,
+		FinalURL:          response.FinalURL,
+		PermanentRedirect: response.PermanentRedirect,
+		ActionResults:     response.ActionResults,
+		Artifacts:         response.Artifacts,
+		Extensions:        response.Extensions,
+	}
+}
@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultStore(t *testing.T) {
+	store := NewResultStore(ResultStoreOptions{MaxResults: 4})
+	add := func(rawURL string, status int, err error) {
+		u, _ := url.Parse(rawURL)
+		result := &Result{URL: u, Error: err}
+		if status != 0 {
+			result.Response = &fetch.Response{URL: rawURL, StatusCode: status, HTML: "<p>content</p>"}
+		}
+		store.Add(result)
+	}
+	add("https://example.com/1", 200, nil)
+	add("https://example.com/2", 404, nil)
+	add("https://blog.example.com/3", 200, nil)
+	add("https://other.com/4", 0, errors.New("timeout"))
+	add("https://other.com/5", 200, nil) // evicts /1
+	add("https://example.com/2", 200, nil)
+
+	require.Equal(t, 4, store.Len())
+	_, ok := store.Get("https://example.com/1")
+	require.False(t, ok)
+	result, ok := store.Get("https://example.com/2")
+	require.True(t, ok)
+	require.Equal(t, 200, result.Response.StatusCode)
+	require.Empty(t, result.Response.HTML)
+
+	urls := func(page *ResultPage) []string {
+		var urls []string
+		for _, result := range page.Results {
+			urls = append(urls, result.URL.String())
+		}
+		return urls
+	}
+	require.Equal(t, []string{"https://example.com/2", "https://blog.example.com/3"},
+		urls(store.Query(ResultQuery{Domain: "Example.com"})))
+	require.Equal(t, []string{"https://other.com/4"}, urls(store.Query(ResultQuery{Failed: true})))
+
+	page := store.Query(ResultQuery{StatusCode: 200, Limit: 2})
+	require.Equal(t, []string{"https://example.com/2", "https://blog.example.com/3"}, urls(page))
+	require.Equal(t, 3, page.Total)
+	require.Equal(t, 2, page.NextOffset)
+	page = store.Query(ResultQuery{StatusCode: 200, Offset: page.NextOffset, Limit: 2})
+	require.Equal(t, []string{"https://other.com/5"}, urls(page))
+	require.Equal(t, 0, page.NextOffset)
+
+	store.Reset()
+	require.Equal(t, 0, store.Len())
+	require.Empty(t, store.Query(ResultQuery{}).Results)
+}
+
+func TestCrawler_Results(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:        "https://example.com",
+		StatusCode: 200,
+		HTML:       "<p>home</p>",
+		Links:      []*fetch.Link{{URL: "/a"}, {URL: "/b"}},
+	})
+	mockFetcher.AddResponse("https://example.com/a", &fetch.Response{URL: "https://example.com/a", StatusCode: 200})
+	mockFetcher.AddError("https://example.com/b", fmt.Errorf("blocked"))
+
+	store := NewResultStore(ResultStoreOptions{KeepContent: true})
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher, Results: store})
+	require.NoError(t, err)
+	require.NoError(t, crawler.Crawl(context.Background(), []string{"https://example.com"}, nil))
+
+	require.Equal(t, 3, store.Len())
+	home, ok := store.Get("https://example.com")
+	require.True(t, ok)
+	require.Equal(t, "<p>home</p>", home.Response.HTML)
+	failed := store.Query(ResultQuery{Failed: true})
+	require.Equal(t, 1, failed.Total)
+	require.Equal(t, "https://example.com/b", failed.Results[0].URL.String())
+}
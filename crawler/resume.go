@@ -0,0 +1,288 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// Frontier persists the frontier of a crawl, i.e. its queue of URLs waiting
+// to be fetched and its set of seen URLs, so that a stopped or interrupted
+// crawl can be resumed with Crawler.Resume. The crawler records each URL it
+// queues and processes. Implementations must be safe for concurrent use.
+type Frontier interface {
+	// Queued records that a URL was queued, found at the given depth.
+	Queued(rawURL string, depth int) error
+
+	// Done records that a URL was processed, or removed from the queue.
+	Done(rawURL string) error
+
+	// Load returns the recorded state of the crawl.
+	Load() (*FrontierState, error)
+
+	// Reset clears the recorded state, at the start of a new crawl.
+	Reset() error
+}
+
+// FrontierURL is a URL recorded by a Frontier.
+type FrontierURL struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth,omitempty"`
+}
+
+// FrontierState is the state of a crawl recorded by a Frontier.
+type FrontierState struct {
+	// Pending are the URLs queued but not processed, in queue order.
+	Pending []FrontierURL
+
+	// Done are the URLs processed.
+	Done []FrontierURL
+}
+
+// MemoryFrontier is a Frontier kept in memory, to resume crawls stopped
+// within a process.
+type MemoryFrontier struct {
+	mutex sync.Mutex
+	urls  map[string]*frontierEntry
+	seq   int
+}
+
+type frontierEntry struct {
+	depth int
+	done  bool
+	seq   int // order in which the URL was last queued
+}
+
+// NewMemoryFrontier creates a new in-memory frontier.
+func NewMemoryFrontier() *MemoryFrontier {
+	return &MemoryFrontier{urls: map[string]*frontierEntry{}}
+}
+
+// Queued records that a URL was queued.
+func (f *MemoryFrontier) Queued(rawURL string, depth int) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.seq++
+	f.urls[rawURL] = &frontierEntry{depth: depth, seq: f.seq}
+	return nil
+}
+
+// Done records that a URL was processed.
+func (f *MemoryFrontier) Done(rawURL string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if entry, ok := f.urls[rawURL]; ok {
+		entry.done = true
+	} else {
+		f.urls[rawURL] = &frontierEntry{done: true}
+	}
+	return nil
+}
+
+// Load returns the recorded state of the crawl.
+func (f *MemoryFrontier) Load() (*FrontierState, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	state := &FrontierState{}
+	var seqs []int
+	for rawURL, entry := range f.urls {
+		if entry.done {
+			state.Done = append(state.Done, FrontierURL{URL: rawURL, Depth: entry.depth})
+		} else {
+			state.Pending = append(state.Pending, FrontierURL{URL: rawURL, Depth: entry.depth})
+			seqs = append(seqs, entry.seq)
+		}
+	}
+	sort.Sort(bySeq{state.Pending, seqs})
+	sort.Slice(state.Done, func(i, j int) bool { return state.Done[i].URL < state.Done[j].URL })
+	return state, nil
+}
+
+// Reset clears the recorded state.
+func (f *MemoryFrontier) Reset() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	clear(f.urls)
+	f.seq = 0
+	return nil
+}
+
+// bySeq sorts URLs by the order in which they were queued.
+type bySeq struct {
+	urls []FrontierURL
+	seqs []int
+}
+
+func (s bySeq) Len() int           { return len(s.urls) }
+func (s bySeq) Less(i, j int) bool { return s.seqs[i] < s.seqs[j] }
+func (s bySeq) Swap(i, j int) {
+	s.urls[i], s.urls[j] = s.urls[j], s.urls[i]
+	s.seqs[i], s.seqs[j] = s.seqs[j], s.seqs[i]
+}
+
+// FileFrontier is a Frontier persisted to a file, to resume crawls across
+// processes, e.g. after a crash. The file is an append-only log of JSON
+// lines, so that the state survives the process being killed at any point.
+type FileFrontier struct {
+	mutex  sync.Mutex
+	file   *os.File
+	memory *MemoryFrontier
+}
+
+// frontierRecord is a line of the log of a FileFrontier.
+type frontierRecord struct {
+	Op    string `json:"op"` // "queued" or "done"
+	URL   string `json:"url"`
+	Depth int    `json:"depth,omitempty"`
+}
+
+// OpenFileFrontier opens the frontier persisted to a file, creating the file
+// if it doesn't exist. Lines that fail to parse, e.g. a line truncated by a
+// crash, are skipped.
+func OpenFileFrontier(path string) (*FileFrontier, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open frontier: %w", err)
+	}
+	memory := NewMemoryFrontier()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record frontierRecord
+		if json.Unmarshal(scanner.Bytes(), &record) != nil || record.URL == "" {
+			continue
+		}
+		switch record.Op {
+		case "queued":
+			memory.Queued(record.URL, record.Depth)
+		case "done":
+			memory.Done(record.URL)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read frontier: %w", err)
+	}
+	// Terminate a truncated last line, so that the next record starts on a
+	// line of its own
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			if _, err := file.Write([]byte{'\n'}); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("failed to write frontier: %w", err)
+			}
+		}
+	}
+	return &FileFrontier{file: file, memory: memory}, nil
+}
+
+// Queued records that a URL was queued.
+func (f *FileFrontier) Queued(rawURL string, depth int) error {
+	if err := f.append(frontierRecord{Op: "queued", URL: rawURL, Depth: depth}); err != nil {
+		return err
+	}
+	return f.memory.Queued(rawURL, depth)
+}
+
+// Done records that a URL was processed.
+func (f *FileFrontier) Done(rawURL string) error {
+	if err := f.append(frontierRecord{Op: "done", URL: rawURL}); err != nil {
+		return err
+	}
+	return f.memory.Done(rawURL)
+}
+
+// Load returns the recorded state of the crawl.
+func (f *FileFrontier) Load() (*FrontierState, error) {
+	return f.memory.Load()
+}
+
+// Reset truncates the file.
+func (f *FileFrontier) Reset() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to reset frontier: %w", err)
+	}
+	return f.memory.Reset()
+}
+
+// Close closes the file.
+func (f *FileFrontier) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.file.Close()
+}
+
+// append writes a record to the file in a single write.
+func (f *FileFrontier) append(record frontierRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write frontier: %w", err)
+	}
+	return nil
+}
+
+// Resume resumes a crawl from the state recorded by Options.Frontier: the
+// URLs processed are not fetched again, and count against MaxURLs, while the
+// pending URLs are queued again. URLs that were being processed when the
+// crawl stopped are fetched again. The callback is called as with Crawl.
+func (c *Crawler) Resume(ctx context.Context, callback Callback) error {
+	if c.frontier == nil {
+		return errors.New("resuming requires a frontier")
+	}
+	state, err := c.frontier.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load frontier: %w", err)
+	}
+	// URLs processed by this crawler were already counted
+	var processed int64
+	for _, u := range state.Done {
+		if c.visited.Add(u.URL) {
+			processed++
+		}
+		c.depths.Store(u.URL, u.Depth)
+	}
+	atomic.AddInt64(&c.stats.processed, processed)
+	return c.crawl(ctx, nil, state.Pending, true, callback)
+}
+
+// checkpointQueued records a queued URL in the frontier, if any.
+func (c *Crawler) checkpointQueued(ctx context.Context, rawURL string, depth int) {
+	if c.frontier == nil {
+		return
+	}
+	if err := c.frontier.Queued(rawURL, depth); err != nil {
+		c.logger.WarnContext(ctx, "failed to record queued url",
+			slog.String("url", rawURL),
+			slog.String("error", err.Error()))
+	}
+}
+
+// checkpointDone records a processed URL in the frontier, if any. URLs are
+// not recorded once the crawl is stopped, as their processing may have been
+// interrupted.
+func (c *Crawler) checkpointDone(ctx context.Context, rawURL string) {
+	if c.frontier == nil || ctx.Err() != nil {
+		return
+	}
+	if err := c.frontier.Done(rawURL); err != nil {
+		c.logger.WarnContext(ctx, "failed to record processed url",
+			slog.String("url", rawURL),
+			slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,178 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_Resume(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	var links []*fetch.Link
+	for i := 1; i <= 5; i++ {
+		pageURL := fmt.Sprintf("https://example.com/%d", i)
+		links = append(links, &fetch.Link{URL: pageURL})
+		mockFetcher.AddResponse(pageURL, &fetch.Response{URL: pageURL})
+	}
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com", Links: links})
+
+	frontier, err := OpenFileFrontier(filepath.Join(t.TempDir(), "frontier.jsonl"))
+	require.NoError(t, err)
+	defer frontier.Close()
+	newCrawler := func() *Crawler {
+		c, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher, Frontier: frontier})
+		require.NoError(t, err)
+		return c
+	}
+
+	// Stop the crawl while the third page is processed
+	var first []string
+	c := newCrawler()
+	err = c.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		first = append(first, result.URL.String())
+		if len(first) == 3 {
+			c.Stop()
+		}
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com", "https://example.com/1", "https://example.com/2"}, first)
+
+	state, err := frontier.Load()
+	require.NoError(t, err)
+	require.Len(t, state.Done, 2)
+	require.Len(t, state.Pending, 4)
+	require.Equal(t, FrontierURL{URL: "https://example.com/2", Depth: 1}, state.Pending[0])
+
+	// The interrupted page and the pending pages are crawled when resumed
+	var resumed []string
+	depths := map[string]int{}
+	err = newCrawler().Resume(context.Background(), func(ctx context.Context, result *Result) error {
+		resumed = append(resumed, result.URL.String())
+		depths[result.URL.String()] = result.Depth
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/2", "https://example.com/3", "https://example.com/4", "https://example.com/5"}, resumed)
+	require.Equal(t, 1, depths["https://example.com/5"])
+
+	state, err = frontier.Load()
+	require.NoError(t, err)
+	require.Len(t, state.Done, 6)
+	require.Empty(t, state.Pending)
+
+	// A resumed crawl without a frontier fails
+	c, err = New(Options{DefaultFetcher: mockFetcher})
+	require.NoError(t, err)
+	require.Error(t, c.Resume(context.Background(), func(ctx context.Context, result *Result) error { return nil }))
+}
+
+func TestCrawler_ResumeSameCrawler(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	var links []*fetch.Link
+	for i := 1; i <= 5; i++ {
+		pageURL := fmt.Sprintf("https://example.com/%d", i)
+		links = append(links, &fetch.Link{URL: pageURL})
+		mockFetcher.AddResponse(pageURL, &fetch.Response{URL: pageURL})
+	}
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com", Links: links})
+
+	frontier := NewMemoryFrontier()
+	c, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher, Frontier: frontier})
+	require.NoError(t, err)
+
+	// Stop the crawl while the third page is processed
+	var first []string
+	err = c.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		first = append(first, result.URL.String())
+		if len(first) == 3 {
+			c.Stop()
+		}
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Len(t, first, 3)
+	processed := c.GetStats().GetProcessed()
+
+	// The same crawler crawls the pending pages, which it has already seen,
+	// without counting the processed pages again
+	var resumed []string
+	err = c.Resume(context.Background(), func(ctx context.Context, result *Result) error {
+		resumed = append(resumed, result.URL.String())
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/2", "https://example.com/3", "https://example.com/4", "https://example.com/5"}, resumed)
+	require.Equal(t, processed+4, c.GetStats().GetProcessed())
+
+	state, err := frontier.Load()
+	require.NoError(t, err)
+	require.Len(t, state.Done, 6)
+	require.Empty(t, state.Pending)
+}
+
+func TestCrawler_CrawlWithoutSeeds(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{URL: "https://example.com"})
+
+	frontier, err := OpenFileFrontier(filepath.Join(t.TempDir(), "frontier.jsonl"))
+	require.NoError(t, err)
+	defer frontier.Close()
+	require.NoError(t, frontier.Queued("https://example.com", 0))
+
+	// A crawl without seeds starts over instead of resuming the frontier
+	c, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher, Frontier: frontier})
+	require.NoError(t, err)
+	var crawled []string
+	err = c.Crawl(context.Background(), nil, func(ctx context.Context, result *Result) error {
+		crawled = append(crawled, result.URL.String())
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Empty(t, crawled)
+
+	state, err := frontier.Load()
+	require.NoError(t, err)
+	require.Empty(t, state.Pending)
+	require.Empty(t, state.Done)
+}
+
+func TestFileFrontier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frontier.jsonl")
+	frontier, err := OpenFileFrontier(path)
+	require.NoError(t, err)
+	require.NoError(t, frontier.Queued("https://example.com", 0))
+	require.NoError(t, frontier.Queued("https://example.com/a", 1))
+	require.NoError(t, frontier.Queued("https://example.com/b", 1))
+	require.NoError(t, frontier.Done("https://example.com"))
+	require.NoError(t, frontier.Close())
+
+	// A line truncated by a crash is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"op":"done","url":"https://exa`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	frontier, err = OpenFileFrontier(path)
+	require.NoError(t, err)
+	defer frontier.Close()
+	require.NoError(t, frontier.Queued("https://example.com/b", 1))
+	state, err := frontier.Load()
+	require.NoError(t, err)
+	require.Equal(t, &FrontierState{
+		Pending: []FrontierURL{{URL: "https://example.com/a", Depth: 1}, {URL: "https://example.com/b", Depth: 1}},
+		Done:    []FrontierURL{{URL: "https://example.com"}},
+	}, state)
+
+	require.NoError(t, frontier.Reset())
+	state, err = frontier.Load()
+	require.NoError(t, err)
+	require.Empty(t, state.Pending)
+	require.Empty(t, state.Done)
+}
@@ -0,0 +1,157 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// Limits applied to robots.txt files.
+const (
+	maxRobotsSize  = 512 * 1024 // RFC 9309 requires parsing at least 500 KiB
+	maxRobotsDelay = time.Minute
+)
+
+// disallowAll is used for hosts whose robots.txt is unavailable due to a
+// server error, which RFC 9309 requires treating as a complete disallow.
+var disallowAll = web.ParseRobotsTxt([]byte("User-agent: *\nDisallow: /"))
+
+// downloader is implemented by fetchers that download files with their own
+// client and headers, such as fetch.HTTPFetcher.
+type downloader interface {
+	Download(ctx context.Context, rawURL string, w io.Writer, options fetch.DownloadOptions) (*fetch.DownloadResult, error)
+}
+
+// download downloads a file with the fetcher of its domain, if it is a
+// downloader, or with fetch.Download.
+func (c *Crawler) download(ctx context.Context, domain, rawURL string, w io.Writer, options fetch.DownloadOptions) error {
+	fetcher, _ := c.getFetcher(domain)
+	if d, ok := fetcher.(downloader); ok {
+		_, err := d.Download(ctx, rawURL, w, options)
+		return err
+	}
+	_, err := fetch.Download(ctx, rawURL, w, options)
+	return err
+}
+
+// robotsHosts holds the robots.txt files of the hosts of a crawl.
+type robotsHosts struct {
+	mutex sync.Mutex
+	hosts map[string]*robotsHost
+}
+
+// robotsHost is the robots.txt file of a host and the time its next fetch
+// may start, to honor its crawl delay.
+type robotsHost struct {
+	ready  chan struct{}
+	robots *web.RobotsTxt
+	mutex  sync.Mutex
+	next   time.Time
+}
+
+func newRobotsHosts() *robotsHosts {
+	return &robotsHosts{hosts: map[string]*robotsHost{}}
+}
+
+// robotsFor returns the robots.txt file of the host of a URL, fetching it on
+// first use. Concurrent callers wait for the first fetch. A fetch interrupted
+// by its context is not kept, so that waiting callers fetch the file again.
+func (c *Crawler) robotsFor(ctx context.Context, u *url.URL) *robotsHost {
+	origin := u.Scheme + "://" + u.Host
+	for {
+		c.robots.mutex.Lock()
+		host, ok := c.robots.hosts[origin]
+		if !ok {
+			host = &robotsHost{ready: make(chan struct{})}
+			c.robots.hosts[origin] = host
+		}
+		c.robots.mutex.Unlock()
+		if ok {
+			select {
+			case <-host.ready:
+			case <-ctx.Done():
+				return &robotsHost{robots: disallowAll}
+			}
+			if host.robots == nil {
+				continue
+			}
+			return host
+		}
+
+		robots := c.fetchRobots(ctx, u.Hostname(), origin+"/robots.txt")
+		if ctx.Err() != nil {
+			c.robots.mutex.Lock()
+			delete(c.robots.hosts, origin)
+			c.robots.mutex.Unlock()
+			close(host.ready)
+			return &robotsHost{robots: disallowAll}
+		}
+		host.robots = robots
+		close(host.ready)
+		return host
+	}
+}
+
+// fetchRobots fetches and parses a robots.txt file. Missing files allow all
+// URLs, while server errors disallow all of them.
+func (c *Crawler) fetchRobots(ctx context.Context, domain, robotsURL string) *web.RobotsTxt {
+	var buf bytes.Buffer
+	err := c.download(ctx, domain, robotsURL, &buf, fetch.DownloadOptions{MaxSize: maxRobotsSize})
+	if err == nil {
+		return web.ParseRobotsTxt(buf.Bytes())
+	}
+	var requestErr *errors.RequestError
+	if errors.As(err, &requestErr) && requestErr.StatusCode() >= 400 && requestErr.StatusCode() < 500 {
+		return &web.RobotsTxt{}
+	}
+	c.logger.WarnContext(ctx, "failed to fetch robots.txt",
+		slog.String("url", robotsURL),
+		slog.String("error", err.Error()))
+	return disallowAll
+}
+
+// robotsAllowed returns true if the robots.txt file of the host of a URL
+// allows crawling it.
+func (c *Crawler) robotsAllowed(ctx context.Context, u *url.URL) bool {
+	if c.robots == nil {
+		return true
+	}
+	return c.robotsFor(ctx, u).robots.Allowed(c.robotsUserAgent, u.RequestURI())
+}
+
+// waitCrawlDelay waits until the crawl delay of the host of a URL has passed
+// since the previous fetch from the host, if its robots.txt sets one.
+func (c *Crawler) waitCrawlDelay(ctx context.Context, u *url.URL) error {
+	if c.robots == nil {
+		return nil
+	}
+	host := c.robotsFor(ctx, u)
+	delay := min(host.robots.CrawlDelay(c.robotsUserAgent), maxRobotsDelay)
+	if delay <= 0 {
+		return nil
+	}
+	host.mutex.Lock()
+	start := time.Now()
+	if host.next.After(start) {
+		start = host.next
+	}
+	host.next = start.Add(delay)
+	host.mutex.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+// robotsFetcher serves robots.txt files from memory, as well as server
+// errors for the hosts without one.
+type robotsFetcher struct {
+	*fetch.MockFetcher
+	robots map[string]string // robots.txt by URL
+}
+
+func (f *robotsFetcher) Download(ctx context.Context, rawURL string, w io.Writer, options fetch.DownloadOptions) (*fetch.DownloadResult, error) {
+	content, ok := f.robots[rawURL]
+	if !ok {
+		return nil, errors.NewRequestErrorf("unexpected status code: 503").WithStatusCode(503)
+	}
+	if content == "" {
+		return nil, errors.NewRequestErrorf("unexpected status code: 404").WithStatusCode(404)
+	}
+	n, err := io.Copy(w, strings.NewReader(content))
+	return &fetch.DownloadResult{URL: rawURL, Size: n, Written: n}, err
+}
+
+func TestCrawler_RespectRobots(t *testing.T) {
+	fetcher := &robotsFetcher{
+		MockFetcher: fetch.NewMockFetcher(),
+		robots: map[string]string{
+			"https://example.com/robots.txt": "User-agent: *\nDisallow: /\n\nUser-agent: MyBot\nDisallow: /admin\nCrawl-delay: 0.05\n",
+			"https://open.com/robots.txt":    "",
+		},
+	}
+	fetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: []*fetch.Link{{URL: "/page"}, {URL: "/admin/users"}},
+	})
+	fetcher.AddResponse("https://example.com/page", &fetch.Response{URL: "https://example.com/page"})
+	fetcher.AddResponse("https://open.com", &fetch.Response{URL: "https://open.com"})
+	fetcher.AddResponse("https://down.com", &fetch.Response{URL: "https://down.com"})
+
+	crawler, err := New(Options{
+		Workers:         2,
+		DefaultFetcher:  fetcher,
+		FollowBehavior:  FollowSameDomain,
+		RespectRobots:   true,
+		RobotsUserAgent: "MyBot",
+	})
+	require.NoError(t, err)
+
+	var urls []string
+	var times []time.Time
+	err = crawler.Crawl(context.Background(), []string{"https://example.com", "https://open.com", "https://down.com"}, func(ctx context.Context, result *Result) error {
+		urls = append(urls, result.URL.String())
+		if result.URL.Host == "example.com" {
+			times = append(times, time.Now())
+		}
+		return result.Error
+	})
+	require.NoError(t, err)
+	slices.Sort(urls)
+	// Disallowed URLs and hosts whose robots.txt fails are skipped
+	require.Equal(t, []string{"https://example.com", "https://example.com/page", "https://open.com"}, urls)
+	require.Len(t, times, 2)
+	require.GreaterOrEqual(t, times[1].Sub(times[0]), 40*time.Millisecond)
+
+	// Only the rules for all user agents apply by default
+	plan, err := New(Options{DefaultFetcher: fetcher, RespectRobots: true})
+	require.NoError(t, err)
+	planned, err := plan.Plan(context.Background(), []string{"https://example.com"})
+	require.NoError(t, err)
+	require.Empty(t, planned.URLs)
+	require.Equal(t, SkipDisallowed, planned.Skipped[0].Reason)
+}
+
+// blockingRobotsFetcher signals the start of robots.txt downloads and blocks
+// them until their context is done, or until it is released.
+type blockingRobotsFetcher struct {
+	*robotsFetcher
+	started  chan struct{}
+	released chan struct{}
+}
+
+func (f *blockingRobotsFetcher) Download(ctx context.Context, rawURL string, w io.Writer, options fetch.DownloadOptions) (*fetch.DownloadResult, error) {
+	select {
+	case f.started <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-f.released:
+	}
+	return f.robotsFetcher.Download(ctx, rawURL, w, options)
+}
+
+func (f *blockingRobotsFetcher) waitStarted(t *testing.T) {
+	select {
+	case <-f.started:
+	case <-time.After(time.Second):
+		t.Fatal("robots.txt was not fetched")
+	}
+}
+
+func TestCrawler_RobotsCanceled(t *testing.T) {
+	fetcher := &blockingRobotsFetcher{
+		robotsFetcher: &robotsFetcher{
+			MockFetcher: fetch.NewMockFetcher(),
+			robots:      map[string]string{"https://example.com/robots.txt": ""},
+		},
+		started:  make(chan struct{}),
+		released: make(chan struct{}),
+	}
+	crawler, err := New(Options{DefaultFetcher: fetcher, RespectRobots: true})
+	require.NoError(t, err)
+	u, err := url.Parse("https://example.com/page")
+	require.NoError(t, err)
+
+	// A canceled fetch disallows the URL without caching the result
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.False(t, crawler.robotsAllowed(ctx, u))
+
+	// Callers waiting for a canceled fetch fetch the file again
+	ctx, cancel = context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() { done <- crawler.robotsAllowed(ctx, u) }()
+	fetcher.waitStarted(t)
+	waiter := make(chan bool)
+	go func() { waiter <- crawler.robotsAllowed(context.Background(), u) }()
+	cancel()
+	require.False(t, <-done)
+	fetcher.waitStarted(t)
+	close(fetcher.released)
+	require.True(t, <-waiter)
+	require.True(t, crawler.robotsAllowed(context.Background(), u))
+}
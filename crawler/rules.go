@@ -3,8 +3,10 @@ package crawler
 import (
 	"context"
 	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/deepnoodle-ai/web"
 	"github.com/deepnoodle-ai/web/fetch"
 )
 
@@ -31,6 +33,48 @@ type MatchRule struct {
 type ParserRule struct {
 	MatchRule
 	Parser Parser // The parser to use for matching domains
+
+	// Condition restricts the rule to the responses with the given
+	// characteristics, so that the pages of a site can be routed to
+	// different parsers. Rules without a condition match all responses.
+	Condition *ResponseCondition
+}
+
+// ResponseCondition matches responses by their characteristics. Zero fields
+// match all responses.
+type ResponseCondition struct {
+	// StatusCodes are the status codes matched.
+	StatusCodes []int
+
+	// MinContentLength and MaxContentLength bound the length of the HTML of
+	// the response. Zero is unbounded.
+	MinContentLength int
+	MaxContentLength int
+
+	// PageTypes are the page types matched, as determined by the page
+	// classifier of the crawler, e.g. web.PageArticle or web.PageProduct.
+	PageTypes []web.PageType
+}
+
+// Matches checks if a response matches the condition. The page type is
+// determined with the given function, only if needed.
+func (c *ResponseCondition) Matches(response *fetch.Response, pageType func() web.PageType) bool {
+	if c == nil {
+		return true
+	}
+	if len(c.StatusCodes) > 0 && !slices.Contains(c.StatusCodes, response.StatusCode) {
+		return false
+	}
+	if c.MinContentLength > 0 && len(response.HTML) < c.MinContentLength {
+		return false
+	}
+	if c.MaxContentLength > 0 && len(response.HTML) > c.MaxContentLength {
+		return false
+	}
+	if len(c.PageTypes) > 0 && !slices.Contains(c.PageTypes, pageType()) {
+		return false
+	}
+	return true
 }
 
 // FetcherRule defines a rule for matching domains to fetchers
@@ -111,6 +155,13 @@ func WithParserMatchType(matchType MatchType) ParserRuleOption {
 	}
 }
 
+// WithParserCondition restricts a parser rule to the matching responses
+func WithParserCondition(condition ResponseCondition) ParserRuleOption {
+	return func(r *ParserRule) {
+		r.Condition = &condition
+	}
+}
+
 // NewParserRule creates a new parser rule with the given pattern and parser.
 // By default, it uses exact matching with priority 0.
 // Use functional options to customize behavior.
@@ -119,6 +170,7 @@ func WithParserMatchType(matchType MatchType) ParserRuleOption {
 //
 //	rule := NewParserRule("example.com", parser, WithParserPriority(10))
 //	rule := NewParserRule("*.example.com", parser, WithParserMatchType(MatchGlob), WithParserPriority(5))
+//	rule := NewParserRule("example.com", parser, WithParserCondition(ResponseCondition{PageTypes: []web.PageType{web.PageProduct}}))
 func NewParserRule(pattern string, parser Parser, opts ...ParserRuleOption) *ParserRule {
 	rule := &ParserRule{
 		MatchRule: MatchRule{
@@ -179,3 +231,37 @@ func NewFetcherRule(pattern string, fetcher fetch.Fetcher, opts ...FetcherRuleOp
 
 	return rule
 }
+
+// PatternRule classifies the URLs matching a URL pattern, e.g. as the product
+// pages of a site, and optionally parses them with a dedicated parser. See
+// web.URLPattern for the pattern syntax.
+type PatternRule struct {
+	Name    string // name of the class of URLs, e.g. "product"
+	Pattern string // e.g. "https://example.com/products/{slug}"
+	Parser  Parser // parser of the matching pages, instead of the domain parser
+
+	// MaxURLs is the budget of the rule: the maximum number of matching URLs
+	// queued during a crawl, e.g. to crawl at most 500 product pages while
+	// leaving room for other pages. Zero is unlimited.
+	MaxURLs int
+
+	pattern *web.URLPattern
+}
+
+// Compile parses the URL pattern of the rule.
+func (r *PatternRule) Compile() error {
+	pattern, err := web.NewURLPattern(r.Pattern)
+	if err != nil {
+		return err
+	}
+	r.pattern = pattern
+	return nil
+}
+
+// Match returns the parameters of the URL if it matches the rule.
+func (r *PatternRule) Match(rawURL string) (map[string]string, bool) {
+	if r.pattern == nil {
+		return nil, false
+	}
+	return r.pattern.Match(rawURL)
+}
@@ -0,0 +1,225 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+)
+
+// DefaultLastModTolerance is the default difference between the lastmod of a
+// sitemap URL and the Last-Modified header of its page reported as a
+// mismatch. Lastmod values are often dates only.
+const DefaultLastModTolerance = 24 * time.Hour
+
+// SitemapCoverageOptions defines the options of a SitemapCoverage.
+type SitemapCoverageOptions struct {
+	// LastModTolerance is the difference between the lastmod of a URL and
+	// the Last-Modified header of its page above which they mismatch.
+	// Defaults to DefaultLastModTolerance.
+	LastModTolerance time.Duration
+}
+
+// SitemapReport compares the URLs of a sitemap with the pages of a crawl.
+// URLs are compared once normalized as by the crawler.
+type SitemapReport struct {
+	// Listed is the number of URLs of the sitemap, and Crawled the number of
+	// those that were crawled successfully.
+	Listed  int `json:"listed"`
+	Crawled int `json:"crawled"`
+
+	// NotCrawled are the URLs of the sitemap that were not crawled.
+	NotCrawled []string `json:"not_crawled,omitempty"`
+
+	// NotListed are the pages crawled successfully that are not in the
+	// sitemap.
+	NotListed []string `json:"not_listed,omitempty"`
+
+	// Failed are the URLs of the sitemap whose fetch failed, whose status
+	// code is not 2xx, or that redirect to another URL.
+	Failed []*SitemapFailure `json:"failed,omitempty"`
+
+	// LastModMismatches are the URLs whose lastmod differs from the
+	// Last-Modified header of their page.
+	LastModMismatches []*LastModMismatch `json:"lastmod_mismatches,omitempty"`
+}
+
+// Coverage returns the fraction of the URLs of the sitemap crawled
+// successfully, or 1 for an empty sitemap.
+func (r *SitemapReport) Coverage() float64 {
+	if r.Listed == 0 {
+		return 1
+	}
+	return float64(r.Crawled) / float64(r.Listed)
+}
+
+// SitemapFailure is a URL of a sitemap that failed to be crawled.
+type SitemapFailure struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+
+	// RedirectedTo is the URL the sitemap URL redirects to, if any.
+	RedirectedTo string `json:"redirected_to,omitempty"`
+}
+
+// LastModMismatch is a URL of a sitemap whose lastmod differs from the
+// Last-Modified header of its page.
+type LastModMismatch struct {
+	URL              string    `json:"url"`
+	SitemapLastMod   time.Time `json:"sitemap_lastmod"`
+	PageLastModified time.Time `json:"page_last_modified"`
+}
+
+// SitemapCoverage compares the pages of a crawl with a sitemap, to find the
+// pages missing from either, and the stale lastmod values of the sitemap.
+// It is safe for concurrent use.
+//
+//	coverage := crawler.NewSitemapCoverage(sitemap, crawler.SitemapCoverageOptions{})
+//	err := c.Crawl(ctx, seeds, coverage.Callback(nil))
+//	...
+//	report := coverage.Report()
+type SitemapCoverage struct {
+	tolerance time.Duration
+	listed    map[string]web.SitemapURL
+	order     []string
+	mutex     sync.Mutex
+	pages     map[string]*coveredPage
+}
+
+// coveredPage is the outcome of the crawl of a page.
+type coveredPage struct {
+	statusCode   int
+	err          error
+	finalURL     string // set if redirected to another URL
+	lastModified time.Time
+}
+
+// NewSitemapCoverage creates a new SitemapCoverage of the URLs of a sitemap.
+// Invalid and duplicate URLs are ignored.
+func NewSitemapCoverage(sitemap *web.Sitemap, opts SitemapCoverageOptions) *SitemapCoverage {
+	if opts.LastModTolerance <= 0 {
+		opts.LastModTolerance = DefaultLastModTolerance
+	}
+	c := &SitemapCoverage{
+		tolerance: opts.LastModTolerance,
+		listed:    map[string]web.SitemapURL{},
+		pages:     map[string]*coveredPage{},
+	}
+	if sitemap != nil {
+		for _, u := range sitemap.URLs {
+			key, ok := sitemapKey(u.Loc)
+			if !ok {
+				continue
+			}
+			if _, exists := c.listed[key]; !exists {
+				c.listed[key] = u
+				c.order = append(c.order, key)
+			}
+		}
+	}
+	return c
+}
+
+// DiffSitemap compares the URLs of a sitemap with the results of a crawl.
+func DiffSitemap(sitemap *web.Sitemap, results []*Result, opts SitemapCoverageOptions) *SitemapReport {
+	coverage := NewSitemapCoverage(sitemap, opts)
+	for _, result := range results {
+		coverage.Add(result)
+	}
+	return coverage.Report()
+}
+
+// Add records the result of the crawl of a page.
+func (c *SitemapCoverage) Add(result *Result) {
+	if result == nil || result.URL == nil {
+		return
+	}
+	key, ok := sitemapKey(result.URL.String())
+	if !ok {
+		return
+	}
+	page := &coveredPage{err: result.Error}
+	if result.Response != nil {
+		page.statusCode = result.Response.StatusCode
+		if finalKey, ok := sitemapKey(result.Response.FinalURL); ok && finalKey != key {
+			page.finalURL = result.Response.FinalURL
+		}
+		for name, value := range result.Response.Headers {
+			if strings.EqualFold(name, "Last-Modified") {
+				page.lastModified, _ = http.ParseTime(value)
+			}
+		}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.pages[key] = page
+}
+
+// Callback returns a crawl callback that adds the results before passing
+// them to the given callback, if any.
+func (c *SitemapCoverage) Callback(callback Callback) Callback {
+	return func(ctx context.Context, result *Result) error {
+		c.Add(result)
+		if callback == nil {
+			return nil
+		}
+		return callback(ctx, result)
+	}
+}
+
+// Report returns the comparison of the sitemap with the pages added so far.
+// URLs of the sitemap are listed in sitemap order, other pages sorted.
+func (c *SitemapCoverage) Report() *SitemapReport {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	report := &SitemapReport{Listed: len(c.order)}
+	for _, key := range c.order {
+		listed := c.listed[key]
+		page, ok := c.pages[key]
+		switch {
+		case !ok:
+			report.NotCrawled = append(report.NotCrawled, listed.Loc)
+			continue
+		case page.err != nil:
+			report.Failed = append(report.Failed, &SitemapFailure{URL: listed.Loc, Error: page.err.Error()})
+			continue
+		case page.statusCode < 200 || page.statusCode > 299:
+			report.Failed = append(report.Failed, &SitemapFailure{URL: listed.Loc, StatusCode: page.statusCode})
+			continue
+		case page.finalURL != "":
+			report.Failed = append(report.Failed, &SitemapFailure{URL: listed.Loc, StatusCode: page.statusCode, RedirectedTo: page.finalURL})
+			continue
+		}
+		report.Crawled++
+		if !listed.LastMod.IsZero() && !page.lastModified.IsZero() {
+			if diff := listed.LastMod.Sub(page.lastModified); diff > c.tolerance || diff < -c.tolerance {
+				report.LastModMismatches = append(report.LastModMismatches, &LastModMismatch{
+					URL:              listed.Loc,
+					SitemapLastMod:   listed.LastMod,
+					PageLastModified: page.lastModified,
+				})
+			}
+		}
+	}
+	for key, page := range c.pages {
+		if _, ok := c.listed[key]; !ok && page.err == nil && page.statusCode >= 200 && page.statusCode <= 299 {
+			report.NotListed = append(report.NotListed, key)
+		}
+	}
+	sort.Strings(report.NotListed)
+	return report
+}
+
+// sitemapKey returns the URL normalized as by the crawler.
+func sitemapKey(rawURL string) (string, bool) {
+	u, err := web.NormalizeURL(rawURL)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(u.String(), "/"), true
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSitemapCoverage(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	sitemap := &web.Sitemap{URLs: []web.SitemapURL{
+		{Loc: "https://example.com/"},
+		{Loc: "http://example.com/fresh/", LastMod: day(1)},
+		{Loc: "https://example.com/stale", LastMod: day(1)},
+		{Loc: "https://example.com/missing"},
+		{Loc: "https://example.com/gone"},
+		{Loc: "https://example.com/moved"},
+		{Loc: "https://example.com/broken"},
+		{Loc: "https://example.com/"}, // duplicate
+	}}
+	result := func(rawURL string, response *fetch.Response, err error) *Result {
+		u, _ := url.Parse(rawURL)
+		return &Result{URL: u, Response: response, Error: err}
+	}
+	ok := func(headers map[string]string) *fetch.Response {
+		return &fetch.Response{StatusCode: 200, Headers: headers}
+	}
+
+	coverage := NewSitemapCoverage(sitemap, SitemapCoverageOptions{})
+	callback := coverage.Callback(nil)
+	for _, r := range []*Result{
+		result("https://example.com", ok(nil), nil),
+		result("https://example.com/fresh", ok(map[string]string{"Last-Modified": "Wed, 01 May 2024 18:00:00 GMT"}), nil),
+		result("https://example.com/stale", ok(map[string]string{"last-modified": "Fri, 10 May 2024 09:00:00 GMT"}), nil),
+		result("https://example.com/gone", &fetch.Response{StatusCode: 404}, nil),
+		result("https://example.com/moved", &fetch.Response{StatusCode: 200, FinalURL: "https://example.com/new"}, nil),
+		result("https://example.com/broken", nil, errors.New("connection refused")),
+		result("https://example.com/orphan", ok(nil), nil),
+		result("https://example.com/contact", ok(nil), nil),
+	} {
+		require.NoError(t, callback(context.Background(), r))
+	}
+
+	report := coverage.Report()
+	require.Equal(t, &SitemapReport{
+		Listed:     7,
+		Crawled:    3,
+		NotCrawled: []string{"https://example.com/missing"},
+		NotListed:  []string{"https://example.com/contact", "https://example.com/orphan"},
+		Failed: []*SitemapFailure{
+			{URL: "https://example.com/gone", StatusCode: 404},
+			{URL: "https://example.com/moved", StatusCode: 200, RedirectedTo: "https://example.com/new"},
+			{URL: "https://example.com/broken", Error: "connection refused"},
+		},
+		LastModMismatches: []*LastModMismatch{{
+			URL:              "https://example.com/stale",
+			SitemapLastMod:   day(1),
+			PageLastModified: time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC),
+		}},
+	}, report)
+	require.InDelta(t, 3.0/7, report.Coverage(), 1e-9)
+
+	// DiffSitemap compares a set of results at once
+	diff := DiffSitemap(sitemap, nil, SitemapCoverageOptions{})
+	require.Equal(t, 0, diff.Crawled)
+	require.Len(t, diff.NotCrawled, 7)
+	require.Equal(t, 1.0, DiffSitemap(nil, nil, SitemapCoverageOptions{}).Coverage())
+}
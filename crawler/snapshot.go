@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Snapshot is a point-in-time view of the progress of a crawl, e.g. to render
+// a live dashboard.
+type Snapshot struct {
+	Running bool
+
+	// Queued is the number of URLs in the frontier waiting to be fetched.
+	Queued int
+
+	// InFlight are the URLs being fetched, sorted.
+	InFlight []string
+
+	Processed int64
+	Succeeded int64
+	Failed    int64
+
+	// Bytes are the bytes downloaded by the crawl.
+	Bytes ByteCounts
+
+	// Hosts is the state of each host fetched from, sorted by host.
+	Hosts []*HostSnapshot
+
+	// RequestDelay is the delay workers wait after each fetch.
+	RequestDelay time.Duration
+
+	// Elapsed is the time since the crawl started.
+	Elapsed time.Duration
+
+	// ETA estimates the time until the frontier is exhausted or MaxURLs is
+	// reached, at the rate URLs have been processed so far. Zero when
+	// unknown.
+	ETA time.Duration
+}
+
+// HostSnapshot is the state of the fetches to a host.
+type HostSnapshot struct {
+	Host      string
+	InFlight  int
+	Fetched   int64
+	LastFetch time.Time  // start of the last fetch
+	Bytes     ByteCounts // bytes downloaded from the host
+}
+
+// crawlProgress tracks the fetches in flight for snapshots.
+type crawlProgress struct {
+	mutex    sync.Mutex
+	running  bool
+	started  time.Time
+	inFlight map[string]bool
+	hosts    map[string]*HostSnapshot
+}
+
+// start resets the progress at the start of a crawl.
+func (p *crawlProgress) start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.running = true
+	p.started = time.Now()
+	p.inFlight = map[string]bool{}
+	p.hosts = map[string]*HostSnapshot{}
+}
+
+// stop marks the end of a crawl.
+func (p *crawlProgress) stop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.running = false
+}
+
+// fetching records the start of a fetch. The returned function records its
+// end.
+func (p *crawlProgress) fetching(host, rawURL string) func() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.hosts == nil {
+		return func() {}
+	}
+	state, ok := p.hosts[host]
+	if !ok {
+		state = &HostSnapshot{Host: host}
+		p.hosts[host] = state
+	}
+	state.InFlight++
+	state.Fetched++
+	state.LastFetch = time.Now()
+	p.inFlight[rawURL] = true
+	return func() {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		state.InFlight--
+		delete(p.inFlight, rawURL)
+	}
+}
+
+// downloaded records the bytes of a response downloaded from a host.
+func (p *crawlProgress) downloaded(host string, bytes ByteCounts) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if state, ok := p.hosts[host]; ok {
+		state.Bytes.Bytes += bytes.Bytes
+		state.Bytes.Transferred += bytes.Transferred
+	}
+}
+
+// Snapshot returns the current progress of the crawl. It is safe to call
+// concurrently with Crawl. After a crawl, the snapshot reports its final
+// state.
+func (c *Crawler) Snapshot() *Snapshot {
+	snapshot := &Snapshot{
+		Queued:       c.queue.len(),
+		Processed:    c.stats.GetProcessed(),
+		Succeeded:    c.stats.GetSucceeded(),
+		Failed:       c.stats.GetFailed(),
+		RequestDelay: c.requestDelay,
+	}
+	p := &c.progress
+	p.mutex.Lock()
+	snapshot.Running = p.running
+	if !p.started.IsZero() {
+		snapshot.Elapsed = time.Since(p.started)
+	}
+	for rawURL := range p.inFlight {
+		snapshot.InFlight = append(snapshot.InFlight, rawURL)
+	}
+	for _, state := range p.hosts {
+		host := *state
+		snapshot.Hosts = append(snapshot.Hosts, &host)
+		snapshot.Bytes.Bytes += host.Bytes.Bytes
+		snapshot.Bytes.Transferred += host.Bytes.Transferred
+	}
+	p.mutex.Unlock()
+
+	slices.Sort(snapshot.InFlight)
+	slices.SortFunc(snapshot.Hosts, func(a, b *HostSnapshot) int {
+		return strings.Compare(a.Host, b.Host)
+	})
+	if !snapshot.Running {
+		snapshot.Queued = 0
+		return snapshot
+	}
+	remaining := int64(snapshot.Queued)
+	if c.maxURLs > 0 {
+		remaining = min(remaining, int64(c.maxURLs)-snapshot.Processed)
+	}
+	// Processed URLs include those in flight, which are still to complete
+	remaining += int64(len(snapshot.InFlight))
+	completed := snapshot.Succeeded + snapshot.Failed
+	if completed > 0 && remaining > 0 {
+		perURL := snapshot.Elapsed / time.Duration(completed)
+		snapshot.ETA = perURL * time.Duration(remaining)
+	}
+	return snapshot
+}
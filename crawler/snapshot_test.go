@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+// funcFetcher fetches with a function.
+type funcFetcher func(ctx context.Context, req *fetch.Request) (*fetch.Response, error)
+
+func (f funcFetcher) Fetch(ctx context.Context, req *fetch.Request) (*fetch.Response, error) {
+	return f(ctx, req)
+}
+
+func TestCrawler_Snapshot(t *testing.T) {
+	var crawler *Crawler
+	var snapshots []*Snapshot
+	fetcher := funcFetcher(func(ctx context.Context, req *fetch.Request) (*fetch.Response, error) {
+		time.Sleep(10 * time.Millisecond)
+		snapshots = append(snapshots, crawler.Snapshot())
+		return &fetch.Response{URL: req.URL}, nil
+	})
+	crawler, err := New(Options{
+		Workers:        1,
+		DefaultFetcher: fetcher,
+		FollowBehavior: FollowNone,
+		RequestDelay:   time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	urls := []string{"https://example.com/a", "https://example.com/b", "https://other.com/a"}
+	err = crawler.Crawl(context.Background(), urls, func(ctx context.Context, result *Result) error { return nil })
+	require.NoError(t, err)
+	require.Len(t, snapshots, 3)
+
+	first := snapshots[0]
+	require.True(t, first.Running)
+	require.Equal(t, 2, first.Queued)
+	require.Equal(t, []string{"https://example.com/a"}, first.InFlight)
+	require.Equal(t, int64(1), first.Processed)
+	require.Equal(t, time.Millisecond, first.RequestDelay)
+	require.Len(t, first.Hosts, 1)
+	require.Equal(t, "example.com", first.Hosts[0].Host)
+	require.Equal(t, 1, first.Hosts[0].InFlight)
+	require.Zero(t, first.ETA) // nothing completed yet
+
+	second := snapshots[1]
+	require.Equal(t, 1, second.Queued)
+	require.Equal(t, int64(1), second.Succeeded)
+	require.Greater(t, second.ETA, time.Duration(0))
+
+	third := snapshots[2]
+	require.Equal(t, []string{"https://other.com/a"}, third.InFlight)
+	require.Len(t, third.Hosts, 2)
+	require.Equal(t, "example.com", third.Hosts[0].Host)
+	require.Equal(t, 0, third.Hosts[0].InFlight)
+	require.Equal(t, int64(2), third.Hosts[0].Fetched)
+	require.Equal(t, "other.com", third.Hosts[1].Host)
+
+	final := crawler.Snapshot()
+	require.False(t, final.Running)
+	require.Empty(t, final.InFlight)
+	require.Equal(t, int64(3), final.Succeeded)
+	require.Zero(t, final.ETA)
+}
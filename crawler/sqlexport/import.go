@@ -0,0 +1,145 @@
+package sqlexport
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/deepnoodle-ai/web/crawler"
+)
+
+// Run describes a crawl run.
+type Run struct {
+	ID         int64     `json:"id"`
+	CrawlID    string    `json:"crawl_id,omitempty"`
+	Seeds      []string  `json:"seeds,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"` // Zero if the run didn't finish
+}
+
+// Page is a page exported by a crawl run.
+type Page struct {
+	URL         string    `json:"url"`
+	FinalURL    string    `json:"final_url,omitempty"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ContentHash string    `json:"content_hash,omitempty"` // SHA-256 of the HTML
+	Pattern     string    `json:"pattern,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Runs returns the crawl runs of the database, the most recent first.
+func Runs(ctx context.Context, db *sql.DB) ([]*Run, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, crawl_id, seeds, started_at, COALESCE(finished_at, '') FROM crawl_runs ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs []*Run
+	for rows.Next() {
+		var run Run
+		var seeds, startedAt, finishedAt string
+		if err := rows.Scan(&run.ID, &run.CrawlID, &seeds, &startedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(seeds), &run.Seeds); err != nil {
+			return nil, err
+		}
+		run.StartedAt = parseTime(startedAt)
+		run.FinishedAt = parseTime(finishedAt)
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+// Pages returns the pages exported by a crawl run, ordered by URL.
+func Pages(ctx context.Context, db *sql.DB, runID int64) ([]*Page, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT url, final_url, status_code, title, description, content_hash, pattern, fetched_at
+		FROM pages WHERE run_id = ? ORDER BY url`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var pages []*Page
+	for rows.Next() {
+		var page Page
+		var fetchedAt string
+		err := rows.Scan(&page.URL, &page.FinalURL, &page.StatusCode, &page.Title,
+			&page.Description, &page.ContentHash, &page.Pattern, &fetchedAt)
+		if err != nil {
+			return nil, err
+		}
+		page.FetchedAt = parseTime(fetchedAt)
+		pages = append(pages, &page)
+	}
+	return pages, rows.Err()
+}
+
+// Fingerprints returns the fingerprints of exported pages, to compare a crawl
+// with a previous run using crawler.NewDeltaTracker. Pages with a status code
+// of 300 or above are skipped, as they are by the tracker.
+func Fingerprints(pages []*Page) []crawler.PageFingerprint {
+	fingerprints := make([]crawler.PageFingerprint, 0, len(pages))
+	for _, page := range pages {
+		if page.StatusCode >= 300 {
+			continue
+		}
+		fingerprints = append(fingerprints, crawler.PageFingerprint{URL: page.URL, ContentHash: page.ContentHash})
+	}
+	return fingerprints
+}
+
+// Diff lists the URLs whose pages differ between two crawl runs.
+type Diff struct {
+	Added   []string `json:"added,omitempty"`   // Pages only in the new run
+	Removed []string `json:"removed,omitempty"` // Pages only in the old run
+	Changed []string `json:"changed,omitempty"` // Pages whose content changed
+}
+
+// DiffRuns compares the pages of two crawl runs.
+func DiffRuns(ctx context.Context, db *sql.DB, oldRunID, newRunID int64) (*Diff, error) {
+	var diff Diff
+	var err error
+	const onlyIn = `SELECT a.url FROM pages a
+		LEFT JOIN pages b ON b.run_id = ? AND b.url = a.url
+		WHERE a.run_id = ? AND b.url IS NULL ORDER BY a.url`
+	if diff.Added, err = queryURLs(ctx, db, onlyIn, oldRunID, newRunID); err != nil {
+		return nil, err
+	}
+	if diff.Removed, err = queryURLs(ctx, db, onlyIn, newRunID, oldRunID); err != nil {
+		return nil, err
+	}
+	diff.Changed, err = queryURLs(ctx, db, `SELECT a.url FROM pages a
+		JOIN pages b ON b.run_id = ? AND b.url = a.url
+		WHERE a.run_id = ? AND a.content_hash != b.content_hash ORDER BY a.url`, oldRunID, newRunID)
+	if err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+func queryURLs(ctx context.Context, db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var urls []string
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	return urls, rows.Err()
+}
+
+func parseTime(value string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, value)
+	return t
+}
@@ -0,0 +1,219 @@
+// Package sqlexport exports crawls to a SQLite database with a canonical
+// schema of crawl runs, pages, links, metadata and errors, so that crawls can
+// be analyzed with SQL and compared with previous runs.
+//
+// The package uses database/sql, and the database is opened by the caller
+// with the SQLite driver of their choice:
+//
+//	db, err := sql.Open("sqlite", "crawl.db")
+//	...
+//	exporter, err := sqlexport.NewExporter(ctx, db, sqlexport.ExporterOptions{Seeds: seeds})
+//	...
+//	err = c.Crawl(ctx, seeds, exporter.Callback(nil))
+//	...
+//	err = exporter.Finish(ctx)
+package sqlexport
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/web/crawler"
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// Schema is the SQLite schema of exported crawls. Times are stored as
+// RFC 3339 text and metadata as JSON, for use with the SQLite JSON functions.
+const Schema = `
+CREATE TABLE IF NOT EXISTS crawl_runs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	crawl_id    TEXT NOT NULL DEFAULT '',
+	seeds       TEXT NOT NULL DEFAULT '[]',
+	started_at  TEXT NOT NULL,
+	finished_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS pages (
+	run_id       INTEGER NOT NULL REFERENCES crawl_runs(id),
+	url          TEXT NOT NULL,
+	final_url    TEXT NOT NULL DEFAULT '',
+	status_code  INTEGER NOT NULL DEFAULT 0,
+	title        TEXT NOT NULL DEFAULT '',
+	description  TEXT NOT NULL DEFAULT '',
+	content_hash TEXT NOT NULL DEFAULT '',
+	pattern      TEXT NOT NULL DEFAULT '',
+	fetched_at   TEXT NOT NULL,
+	PRIMARY KEY (run_id, url)
+);
+
+CREATE TABLE IF NOT EXISTS links (
+	run_id     INTEGER NOT NULL REFERENCES crawl_runs(id),
+	source_url TEXT NOT NULL,
+	target_url TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS links_source ON links (run_id, source_url);
+CREATE INDEX IF NOT EXISTS links_target ON links (run_id, target_url);
+
+CREATE TABLE IF NOT EXISTS metadata (
+	run_id INTEGER NOT NULL REFERENCES crawl_runs(id),
+	url    TEXT NOT NULL,
+	data   TEXT NOT NULL,
+	PRIMARY KEY (run_id, url)
+);
+
+CREATE TABLE IF NOT EXISTS errors (
+	run_id      INTEGER NOT NULL REFERENCES crawl_runs(id),
+	url         TEXT NOT NULL,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	message     TEXT NOT NULL,
+	occurred_at TEXT NOT NULL
+);
+`
+
+// Init creates the tables of the schema if they don't exist.
+func Init(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, Schema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+	return nil
+}
+
+// ExporterOptions defines the options of an Exporter.
+type ExporterOptions struct {
+	// CrawlID identifies the crawl, e.g. for correlation with logs.
+	CrawlID string
+
+	// Seeds are the URLs the crawl starts from.
+	Seeds []string
+}
+
+// Exporter writes the results of a crawl to a database as a crawl run.
+// Exporters are safe for concurrent use.
+type Exporter struct {
+	db    *sql.DB
+	runID int64
+	mutex sync.Mutex
+}
+
+// NewExporter creates the schema if needed and starts a crawl run.
+func NewExporter(ctx context.Context, db *sql.DB, options ExporterOptions) (*Exporter, error) {
+	if err := Init(ctx, db); err != nil {
+		return nil, err
+	}
+	seeds, err := json.Marshal(options.Seeds)
+	if err != nil {
+		return nil, err
+	}
+	res, err := db.ExecContext(ctx,
+		`INSERT INTO crawl_runs (crawl_id, seeds, started_at) VALUES (?, ?, ?)`,
+		options.CrawlID, string(seeds), formatTime(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start crawl run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start crawl run: %w", err)
+	}
+	return &Exporter{db: db, runID: runID}, nil
+}
+
+// RunID returns the ID of the crawl run.
+func (e *Exporter) RunID() int64 {
+	return e.runID
+}
+
+// Export writes a crawl result: the page with its links and metadata, or the
+// error of a failed page.
+func (e *Exporter) Export(ctx context.Context, result *crawler.Result) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := e.export(ctx, tx, result); err != nil {
+		return fmt.Errorf("failed to export %s: %w", result.URL, err)
+	}
+	return tx.Commit()
+}
+
+func (e *Exporter) export(ctx context.Context, tx *sql.Tx, result *crawler.Result) error {
+	rawURL := result.URL.String()
+	now := formatTime(time.Now())
+	if result.Error != nil {
+		statusCode := 0
+		var requestErr *errors.RequestError
+		if stderrors.As(result.Error, &requestErr) {
+			statusCode = requestErr.StatusCode()
+		}
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO errors (run_id, url, status_code, message, occurred_at) VALUES (?, ?, ?, ?, ?)`,
+			e.runID, rawURL, statusCode, result.Error.Error(), now)
+		return err
+	}
+	response := result.Response
+	if response == nil {
+		return nil
+	}
+	contentHash := crawler.ContentHash(response)
+	_, err := tx.ExecContext(ctx,
+		`INSERT OR REPLACE INTO pages (run_id, url, final_url, status_code, title, description, content_hash, pattern, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.runID, rawURL, response.FinalURL, response.StatusCode, response.Metadata.Title,
+		response.Metadata.Description, contentHash, result.Pattern, now)
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(response.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT OR REPLACE INTO metadata (run_id, url, data) VALUES (?, ?, ?)`,
+		e.runID, rawURL, string(metadata))
+	if err != nil {
+		return err
+	}
+	for _, link := range result.Links {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO links (run_id, source_url, target_url) VALUES (?, ?, ?)`,
+			e.runID, rawURL, link)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Callback returns a crawl callback that exports each result before passing
+// it to the given callback, if any.
+func (e *Exporter) Callback(callback crawler.Callback) crawler.Callback {
+	return func(ctx context.Context, result *crawler.Result) error {
+		if err := e.Export(ctx, result); err != nil {
+			return err
+		}
+		if callback == nil {
+			return nil
+		}
+		return callback(ctx, result)
+	}
+}
+
+// Finish records the end of the crawl run.
+func (e *Exporter) Finish(ctx context.Context) error {
+	_, err := e.db.ExecContext(ctx,
+		`UPDATE crawl_runs SET finished_at = ? WHERE id = ?`,
+		formatTime(time.Now()), e.runID)
+	return err
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,214 @@
+package sqlexport
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web/crawler"
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDriver is a database/sql driver that records the statements
+// executed and answers queries with canned rows, as no SQLite driver is
+// available to the tests.
+type recordingDriver struct {
+	mutex      sync.Mutex
+	statements []statement
+	rows       map[string][][]driver.Value // Rows by query prefix
+}
+
+type statement struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) { return &recordingConn{d}, nil }
+
+// executed returns the arguments of the statements starting with prefix.
+func (d *recordingDriver) executed(prefix string) [][]driver.Value {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var args [][]driver.Value
+	for _, s := range d.statements {
+		if strings.HasPrefix(strings.TrimSpace(s.query), prefix) {
+			args = append(args, s.args)
+		}
+	}
+	return args
+}
+
+type recordingConn struct{ driver *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{c.driver, query}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *recordingConn) Commit() error             { return nil }
+func (c *recordingConn) Rollback() error           { return nil }
+
+type recordingStmt struct {
+	driver *recordingDriver
+	query  string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.mutex.Lock()
+	defer s.driver.mutex.Unlock()
+	s.driver.statements = append(s.driver.statements, statement{s.query, args})
+	return recordingResult{}, nil
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	for prefix, rows := range s.driver.rows {
+		if strings.HasPrefix(strings.TrimSpace(s.query), prefix) {
+			return &recordingRows{rows: rows}, nil
+		}
+	}
+	return &recordingRows{}, nil
+}
+
+type recordingRows struct {
+	rows [][]driver.Value
+}
+
+func (r *recordingRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+func (r *recordingRows) Close() error { return nil }
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// recordingResult is the result of executed statements, with the run ID
+// used by the tests as last insert ID.
+type recordingResult struct{}
+
+func (recordingResult) LastInsertId() (int64, error) { return 7, nil }
+func (recordingResult) RowsAffected() (int64, error) { return 1, nil }
+
+func openDB(t *testing.T, d *recordingDriver) *sql.DB {
+	name := "recording-" + t.Name()
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestExporter(t *testing.T) {
+	d := &recordingDriver{}
+	db := openDB(t, d)
+	ctx := context.Background()
+
+	exporter, err := NewExporter(ctx, db, ExporterOptions{CrawlID: "c1", Seeds: []string{"https://example.com"}})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), exporter.RunID())
+	require.Len(t, d.executed("CREATE TABLE"), 1)
+	runs := d.executed("INSERT INTO crawl_runs")
+	require.Len(t, runs, 1)
+	require.Equal(t, "c1", runs[0][0])
+	require.Equal(t, `["https://example.com"]`, runs[0][1])
+
+	page, _ := url.Parse("https://example.com")
+	var called []string
+	callback := exporter.Callback(func(ctx context.Context, result *crawler.Result) error {
+		called = append(called, result.URL.String())
+		return nil
+	})
+	err = callback(ctx, &crawler.Result{
+		URL:     page,
+		Links:   []string{"https://example.com/a", "https://example.com/b"},
+		Pattern: "home",
+		Response: &fetch.Response{
+			URL:        "https://example.com",
+			StatusCode: 200,
+			HTML:       "<html></html>",
+			Metadata:   fetch.Metadata{Title: "Home", Description: "Welcome"},
+		},
+	})
+	require.NoError(t, err)
+	missing, _ := url.Parse("https://example.com/missing")
+	err = callback(ctx, &crawler.Result{
+		URL:   missing,
+		Error: errors.NewRequestErrorf("not found").WithStatusCode(404),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com", "https://example.com/missing"}, called)
+
+	pages := d.executed("INSERT OR REPLACE INTO pages")
+	require.Len(t, pages, 1)
+	require.Equal(t, []driver.Value{int64(7), "https://example.com", "", int64(200), "Home", "Welcome"}, pages[0][:6])
+	require.Len(t, pages[0][6], 64) // content hash
+	require.Equal(t, "home", pages[0][7])
+	metadata := d.executed("INSERT OR REPLACE INTO metadata")
+	require.Len(t, metadata, 1)
+	require.Contains(t, metadata[0][2], `"title":"Home"`)
+	links := d.executed("INSERT INTO links")
+	require.Len(t, links, 2)
+	require.Equal(t, []driver.Value{int64(7), "https://example.com", "https://example.com/b"}, links[1])
+	errs := d.executed("INSERT INTO errors")
+	require.Len(t, errs, 1)
+	require.Equal(t, []driver.Value{int64(7), "https://example.com/missing", int64(404), "not found"}, errs[0][:4])
+
+	require.NoError(t, exporter.Finish(ctx))
+	finished := d.executed("UPDATE crawl_runs")
+	require.Len(t, finished, 1)
+	require.Equal(t, int64(7), finished[0][1])
+}
+
+func TestImport(t *testing.T) {
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	d := &recordingDriver{rows: map[string][][]driver.Value{
+		"SELECT id": {
+			{int64(2), "c2", `["https://example.com"]`, formatTime(started.Add(time.Hour)), ""},
+			{int64(1), "c1", `[]`, formatTime(started), formatTime(started.Add(time.Minute))},
+		},
+		"SELECT url, final_url": {
+			{"https://example.com", "", int64(200), "Home", "", "abc", "", formatTime(started)},
+		},
+		"SELECT a.url": {
+			{"https://example.com/new"},
+		},
+	}}
+	db := openDB(t, d)
+	ctx := context.Background()
+
+	runs, err := Runs(ctx, db)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, &Run{ID: 2, CrawlID: "c2", Seeds: []string{"https://example.com"}, StartedAt: started.Add(time.Hour)}, runs[0])
+	require.Equal(t, started.Add(time.Minute), runs[1].FinishedAt)
+
+	pages, err := Pages(ctx, db, 1)
+	require.NoError(t, err)
+	require.Equal(t, []*Page{{URL: "https://example.com", StatusCode: 200, Title: "Home", ContentHash: "abc", FetchedAt: started}}, pages)
+
+	pages = append(pages, &Page{URL: "https://example.com/missing", StatusCode: 404})
+	require.Equal(t, []crawler.PageFingerprint{{URL: "https://example.com", ContentHash: "abc"}}, Fingerprints(pages))
+
+	diff, err := DiffRuns(ctx, db, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/new"}, diff.Added)
+}
@@ -1,12 +1,32 @@
 package crawler
 
-import "sync/atomic"
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/deepnoodle-ai/web"
+)
 
 // CrawlerStats tracks crawling statistics. All methods are thread-safe.
 type CrawlerStats struct {
 	processed int64
 	succeeded int64
 	failed    int64
+	pageTypes sync.Map // web.PageType -> *int64
+
+	bytes       int64
+	transferred int64
+	hostBytes   sync.Map // host -> *ByteCounts
+}
+
+// ByteCounts are the numbers of bytes downloaded.
+type ByteCounts struct {
+	// Bytes is the size of the bodies of the responses, once decompressed.
+	Bytes int64 `json:"bytes"`
+
+	// Transferred is the number of bytes transferred to read the bodies,
+	// which is smaller than Bytes for compressed responses.
+	Transferred int64 `json:"transferred"`
 }
 
 // GetProcessed returns the number of URLs processed
@@ -38,3 +58,51 @@ func (s *CrawlerStats) IncrementSucceeded() {
 func (s *CrawlerStats) IncrementFailed() {
 	atomic.AddInt64(&s.failed, 1)
 }
+
+// GetPageTypes returns the number of pages of each type, when pages are
+// classified. Pages of unknown type are counted under web.PageUnknown.
+func (s *CrawlerStats) GetPageTypes() map[web.PageType]int64 {
+	counts := map[web.PageType]int64{}
+	s.pageTypes.Range(func(key, value any) bool {
+		counts[key.(web.PageType)] = atomic.LoadInt64(value.(*int64))
+		return true
+	})
+	return counts
+}
+
+// IncrementPageType atomically increments the counter of a page type
+func (s *CrawlerStats) IncrementPageType(pageType web.PageType) {
+	value, _ := s.pageTypes.LoadOrStore(pageType, new(int64))
+	atomic.AddInt64(value.(*int64), 1)
+}
+
+// GetBytes returns the number of bytes downloaded from all hosts
+func (s *CrawlerStats) GetBytes() ByteCounts {
+	return ByteCounts{
+		Bytes:       atomic.LoadInt64(&s.bytes),
+		Transferred: atomic.LoadInt64(&s.transferred),
+	}
+}
+
+// GetHostBytes returns the number of bytes downloaded from each host
+func (s *CrawlerStats) GetHostBytes() map[string]ByteCounts {
+	counts := map[string]ByteCounts{}
+	s.hostBytes.Range(func(key, value any) bool {
+		host := value.(*ByteCounts)
+		counts[key.(string)] = ByteCounts{
+			Bytes:       atomic.LoadInt64(&host.Bytes),
+			Transferred: atomic.LoadInt64(&host.Transferred),
+		}
+		return true
+	})
+	return counts
+}
+
+// AddBytes atomically adds the bytes of a response downloaded from a host
+func (s *CrawlerStats) AddBytes(host string, bytes, transferred int64) {
+	atomic.AddInt64(&s.bytes, bytes)
+	atomic.AddInt64(&s.transferred, transferred)
+	value, _ := s.hostBytes.LoadOrStore(host, &ByteCounts{})
+	atomic.AddInt64(&value.(*ByteCounts).Bytes, bytes)
+	atomic.AddInt64(&value.(*ByteCounts).Transferred, transferred)
+}
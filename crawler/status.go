@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// StatusAction is how the crawler handles the responses of a status code.
+type StatusAction string
+
+const (
+	// StatusProcess processes responses as pages: they are parsed, passed
+	// to the callback and their links are followed.
+	StatusProcess StatusAction = "process"
+
+	// StatusFlag processes responses as pages, with Result.Flagged set, e.g.
+	// to report broken links without failing them.
+	StatusFlag StatusAction = "flag"
+
+	// StatusFail fails pages with a StatusError. Their links are not
+	// followed.
+	StatusFail StatusAction = "fail"
+
+	// StatusGone fails pages with a StatusError wrapping ErrGone. Callbacks
+	// can't retry them.
+	StatusGone StatusAction = "gone"
+)
+
+func (a StatusAction) validate() error {
+	switch a {
+	case StatusProcess, StatusFlag, StatusFail, StatusGone:
+		return nil
+	}
+	return fmt.Errorf("invalid status action: %q", a)
+}
+
+// ErrGone is wrapped by the errors of pages handled with StatusGone.
+var ErrGone = errors.New("page gone")
+
+// StatusError is the error of pages failed because of the status code of
+// their response.
+type StatusError struct {
+	StatusCode int
+	Gone       bool
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Is returns true for ErrGone if the page is gone.
+func (e *StatusError) Is(target error) bool {
+	return e.Gone && target == ErrGone
+}
+
+// StatusPolicy defines how the crawler handles responses by status code.
+type StatusPolicy struct {
+	// Actions are the actions of specific status codes.
+	Actions map[int]StatusAction
+
+	// ErrorAction is the action of error status codes, i.e. 4xx and 5xx,
+	// not in Actions. Other status codes are processed. Defaults to
+	// StatusFail.
+	ErrorAction StatusAction
+
+	// FollowPermanentRedirects records the URLs permanently redirected, as
+	// reported by Response.PermanentRedirect, so that links to them are
+	// replaced by their target. See Crawler.Redirects.
+	FollowPermanentRedirects bool
+}
+
+// DefaultStatusPolicy returns the status policy of crawlers without one:
+// 404 pages are flagged, 410 pages are gone, other error pages fail, and
+// permanent redirects are followed. The returned policy may be modified.
+func DefaultStatusPolicy() *StatusPolicy {
+	return &StatusPolicy{
+		Actions: map[int]StatusAction{
+			http.StatusNotFound: StatusFlag,
+			http.StatusGone:     StatusGone,
+		},
+		ErrorAction:              StatusFail,
+		FollowPermanentRedirects: true,
+	}
+}
+
+// Action returns the action of a status code. Responses without a status
+// code are processed.
+func (p *StatusPolicy) Action(statusCode int) StatusAction {
+	if action, ok := p.Actions[statusCode]; ok {
+		return action
+	}
+	if statusCode >= 400 {
+		if p.ErrorAction == "" {
+			return StatusFail
+		}
+		return p.ErrorAction
+	}
+	return StatusProcess
+}
+
+func (p *StatusPolicy) validate() error {
+	for code, action := range p.Actions {
+		if err := action.validate(); err != nil {
+			return fmt.Errorf("status code %d: %w", code, err)
+		}
+	}
+	if p.ErrorAction != "" {
+		return p.ErrorAction.validate()
+	}
+	return nil
+}
+
+// redirects maps URLs permanently redirected to their target, both as queue
+// values. A nil *redirects records nothing.
+type redirects struct {
+	mutex   sync.RWMutex
+	targets map[string]string
+}
+
+func newRedirects() *redirects {
+	return &redirects{targets: map[string]string{}}
+}
+
+// add records a permanent redirect.
+func (r *redirects) add(from, to string) {
+	if r == nil || from == to {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.targets[from] = to
+}
+
+// rewrite returns the target of a URL permanently redirected, or the URL.
+func (r *redirects) rewrite(value string) string {
+	if r == nil {
+		return value
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if target, ok := r.targets[value]; ok {
+		return target
+	}
+	return value
+}
+
+// Redirects returns the URLs permanently redirected seen by the crawler,
+// mapped to their target, if the status policy follows permanent redirects.
+func (c *Crawler) Redirects() map[string]string {
+	if c.redirects == nil {
+		return nil
+	}
+	c.redirects.mutex.RLock()
+	defer c.redirects.mutex.RUnlock()
+	return maps.Clone(c.redirects.targets)
+}
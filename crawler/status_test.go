@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusPolicy_Action(t *testing.T) {
+	policy := DefaultStatusPolicy()
+	require.Equal(t, StatusProcess, policy.Action(0))
+	require.Equal(t, StatusProcess, policy.Action(200))
+	require.Equal(t, StatusProcess, policy.Action(304))
+	require.Equal(t, StatusFlag, policy.Action(404))
+	require.Equal(t, StatusGone, policy.Action(410))
+	require.Equal(t, StatusFail, policy.Action(403))
+	require.Equal(t, StatusFail, policy.Action(500))
+
+	policy = &StatusPolicy{Actions: map[int]StatusAction{404: StatusFail}, ErrorAction: StatusFlag}
+	require.Equal(t, StatusFail, policy.Action(404))
+	require.Equal(t, StatusFlag, policy.Action(503))
+
+	_, err := New(Options{StatusPolicy: &StatusPolicy{Actions: map[int]StatusAction{404: "ignore"}}})
+	require.EqualError(t, err, `status code 404: invalid status action: "ignore"`)
+}
+
+func TestCrawler_StatusPolicy(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:        "https://example.com",
+		StatusCode: 200,
+		Links: []*fetch.Link{
+			{URL: "https://example.com/missing"},
+			{URL: "https://example.com/gone"},
+			{URL: "https://example.com/error"},
+		},
+	})
+	mockFetcher.AddResponse("https://example.com/missing", &fetch.Response{
+		URL:        "https://example.com/missing",
+		StatusCode: 404,
+		Links:      []*fetch.Link{{URL: "https://example.com/other"}},
+	})
+	mockFetcher.AddResponse("https://example.com/gone", &fetch.Response{URL: "https://example.com/gone", StatusCode: 410})
+	mockFetcher.AddResponse("https://example.com/error", &fetch.Response{URL: "https://example.com/error", StatusCode: 500})
+	mockFetcher.AddResponse("https://example.com/other", &fetch.Response{URL: "https://example.com/other", StatusCode: 200})
+
+	crawl := func(policy *StatusPolicy) map[string]*Result {
+		crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher, StatusPolicy: policy})
+		require.NoError(t, err)
+		results := map[string]*Result{}
+		err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+			_, retried := results[result.URL.Path]
+			results[result.URL.Path] = result
+			if result.Error != nil && !retried {
+				return ErrRetry
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		return results
+	}
+
+	results := crawl(nil)
+	require.Len(t, results, 5)
+	require.NoError(t, results["/missing"].Error)
+	require.True(t, results["/missing"].Flagged)
+	require.False(t, results[""].Flagged)
+	require.ErrorIs(t, results["/gone"].Error, ErrGone)
+	var statusErr *StatusError
+	require.ErrorAs(t, results["/error"].Error, &statusErr)
+	require.Equal(t, 500, statusErr.StatusCode)
+	require.NotErrorIs(t, statusErr, ErrGone)
+
+	// Failed pages are retried by callbacks, unless they are gone. 404
+	// pages fail and their links are not followed.
+	results = crawl(&StatusPolicy{Actions: map[int]StatusAction{404: StatusFail, 410: StatusGone}})
+	require.Len(t, results, 4)
+	require.Error(t, results["/missing"].Error)
+	require.NotContains(t, results, "/other")
+}
+
+func TestCrawler_PermanentRedirects(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: []*fetch.Link{{URL: "https://example.com/old"}},
+	})
+	mockFetcher.AddResponse("https://example.com/old", &fetch.Response{
+		URL:               "https://example.com/old",
+		FinalURL:          "https://example.com/new",
+		PermanentRedirect: true,
+		Links:             []*fetch.Link{{URL: "https://example.com/old?page=2"}, {URL: "https://example.com/new"}},
+	})
+
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher})
+	require.NoError(t, err)
+	var urls []string
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		urls = append(urls, result.URL.String())
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com", "https://example.com/old"}, urls)
+	require.Equal(t, map[string]string{"https://example.com/old": "https://example.com/new"}, crawler.Redirects())
+
+	// Links to redirected URLs are replaced by their target, which is not
+	// fetched again
+	require.Equal(t, "https://example.com/new", crawler.redirects.rewrite("https://example.com/old"))
+	require.True(t, crawler.Visited().Contains("https://example.com/new"))
+
+	crawler, err = New(Options{Workers: 1, DefaultFetcher: mockFetcher, StatusPolicy: &StatusPolicy{}})
+	require.NoError(t, err)
+	require.Nil(t, crawler.Redirects())
+}
@@ -0,0 +1,243 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/deepnoodle-ai/web"
+	"golang.org/x/net/html"
+)
+
+// TemplateOptions defines the options of a TemplateAnalyzer.
+type TemplateOptions struct {
+	// Similarity is the minimum similarity (0-1) of the structure of a page
+	// to that of a cluster for the page to join it. Defaults to 0.6.
+	Similarity float64
+
+	// MaxSamples limits the number of sample URLs of each cluster. Defaults
+	// to 5.
+	MaxSamples int
+}
+
+// TemplateCluster is a group of crawled pages sharing a template, i.e. a
+// similar DOM structure, such as the product pages of a shop.
+type TemplateCluster struct {
+	// Pages is the number of pages of the cluster.
+	Pages int `json:"pages"`
+
+	// Pattern is a URL pattern matching the pages, suggested for a
+	// PatternRule, e.g. "https://example.com/products/{slug}".
+	Pattern string `json:"pattern"`
+
+	// Samples are the URLs of the first pages of the cluster.
+	Samples []string `json:"samples"`
+}
+
+// TemplateAnalyzer clusters crawled pages by the similarity of their DOM
+// structure, to discover the types of pages of a site and the parsers and
+// pattern rules needed to crawl it. Analyzers are safe for concurrent use.
+//
+//	analyzer := crawler.NewTemplateAnalyzer(crawler.TemplateOptions{})
+//	err := c.Crawl(ctx, seeds, analyzer.Callback(nil))
+//	...
+//	for _, cluster := range analyzer.Clusters() {
+//		fmt.Println(cluster.Pages, cluster.Pattern)
+//	}
+type TemplateAnalyzer struct {
+	similarity float64
+	maxSamples int
+	mutex      sync.Mutex
+	clusters   []*templateCluster
+}
+
+type templateCluster struct {
+	features map[string]bool // Structure of the first page
+	urls     []*url.URL
+}
+
+// NewTemplateAnalyzer creates a new template analyzer.
+func NewTemplateAnalyzer(opts TemplateOptions) *TemplateAnalyzer {
+	if opts.Similarity <= 0 || opts.Similarity > 1 {
+		opts.Similarity = 0.6
+	}
+	if opts.MaxSamples <= 0 {
+		opts.MaxSamples = 5
+	}
+	return &TemplateAnalyzer{similarity: opts.Similarity, maxSamples: opts.MaxSamples}
+}
+
+// Add adds a page to the cluster with the most similar structure, or to a
+// new cluster if none is similar enough.
+func (a *TemplateAnalyzer) Add(pageURL *url.URL, doc *web.Document) {
+	features := templateFeatures(doc)
+	if len(features) == 0 {
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	var best *templateCluster
+	bestSimilarity := a.similarity
+	for _, cluster := range a.clusters {
+		if similarity := jaccard(features, cluster.features); similarity >= bestSimilarity {
+			best, bestSimilarity = cluster, similarity
+		}
+	}
+	if best == nil {
+		best = &templateCluster{features: features}
+		a.clusters = append(a.clusters, best)
+	}
+	best.urls = append(best.urls, pageURL)
+}
+
+// Callback returns a crawl callback that adds the pages fetched successfully
+// before passing the results to the given callback, if any.
+func (a *TemplateAnalyzer) Callback(callback Callback) Callback {
+	return func(ctx context.Context, result *Result) error {
+		if result.Error == nil && result.Response != nil && result.Response.HTML != "" {
+			if doc, err := result.Response.Document(); err == nil {
+				a.Add(result.URL, doc)
+			}
+		}
+		if callback == nil {
+			return nil
+		}
+		return callback(ctx, result)
+	}
+}
+
+// Clusters returns the clusters of the pages added, the largest first.
+func (a *TemplateAnalyzer) Clusters() []*TemplateCluster {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	clusters := make([]*TemplateCluster, 0, len(a.clusters))
+	for _, cluster := range a.clusters {
+		samples := make([]string, 0, a.maxSamples)
+		for _, u := range cluster.urls[:min(len(cluster.urls), a.maxSamples)] {
+			samples = append(samples, u.String())
+		}
+		clusters = append(clusters, &TemplateCluster{
+			Pages:   len(cluster.urls),
+			Pattern: suggestPattern(cluster.urls),
+			Samples: samples,
+		})
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].Pages > clusters[j].Pages
+	})
+	return clusters
+}
+
+// templateFeatures returns the structure of the body of a page as the set of
+// the paths of its elements, limited to their last three elements. Classes
+// are included without digits, which are usually specific to a page.
+func templateFeatures(doc *web.Document) map[string]bool {
+	features := map[string]bool{}
+	var walk func(n *html.Node, path []string)
+	walk = func(n *html.Node, path []string) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "head", "script", "style", "noscript", "template", "svg":
+				return
+			}
+			path = append(path, elementName(n))
+			if len(path) > 3 {
+				path = path[len(path)-3:]
+			}
+			features[strings.Join(path, ">")] = true
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, path)
+		}
+	}
+	for _, node := range doc.GoqueryDocument().Nodes {
+		walk(node, nil)
+	}
+	return features
+}
+
+// elementName returns the tag of an element with its first class, if any.
+func elementName(n *html.Node) string {
+	for _, attr := range n.Attr {
+		if attr.Key != "class" {
+			continue
+		}
+		fields := strings.Fields(attr.Val)
+		if len(fields) == 0 {
+			break
+		}
+		class := strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return -1
+			}
+			return r
+		}, fields[0])
+		return n.Data + "." + class
+	}
+	return n.Data
+}
+
+// suggestPattern returns a URL pattern matching the URLs: segments that
+// differ between URLs become parameters, and paths of different lengths end
+// with a catch-all parameter.
+func suggestPattern(urls []*url.URL) string {
+	if len(urls) == 0 {
+		return ""
+	}
+	host := urls[0].Host
+	var paths [][]string
+	minLen, maxLen := -1, 0
+	for _, u := range urls {
+		if !strings.EqualFold(u.Host, host) {
+			host = ""
+		}
+		segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+		paths = append(paths, segments)
+		if minLen < 0 || len(segments) < minLen {
+			minLen = len(segments)
+		}
+		maxLen = max(maxLen, len(segments))
+	}
+
+	var pattern []string
+	names := map[string]int{}
+	param := func(name string) string {
+		names[name]++
+		if n := names[name]; n > 1 {
+			name = fmt.Sprintf("%s%d", name, n)
+		}
+		return "{" + name + "}"
+	}
+	for i := 0; i < minLen; i++ {
+		segment, numeric := paths[0][i], true
+		for _, segments := range paths {
+			if segments[i] != paths[0][i] {
+				segment = ""
+			}
+			if strings.TrimFunc(segments[i], unicode.IsDigit) != "" {
+				numeric = false
+			}
+		}
+		switch {
+		case segment != "":
+			pattern = append(pattern, segment)
+		case numeric:
+			pattern = append(pattern, param("id"))
+		default:
+			pattern = append(pattern, param("slug"))
+		}
+	}
+	if maxLen > minLen {
+		pattern = append(pattern, "{path...}")
+	}
+
+	path := "/" + strings.Join(pattern, "/")
+	if host == "" {
+		return path
+	}
+	return urls[0].Scheme + "://" + host + path
+}
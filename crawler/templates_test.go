@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateAnalyzer(t *testing.T) {
+	product := `<html><body><header class="site"><nav><a href="/">Home</a></nav></header>
+		<div class="product product-%d"><h1>Product %d</h1><span class="price">$%d</span>
+		<ul class="specs"><li>Size</li><li>Color</li></ul><button class="buy">Buy</button></div></body></html>`
+	article := `<html><body><header class="site"><nav><a href="/">Home</a></nav></header>
+		<article><h1>Post %d</h1><p class="byline">By Ann</p><p>Text</p><p>More %d</p>
+		<section class="comments"><div class="comment">Nice</div></section></article></body></html>`
+
+	analyzer := NewTemplateAnalyzer(TemplateOptions{MaxSamples: 2})
+	add := func(rawURL, html string) {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		doc, err := web.NewDocument(html)
+		require.NoError(t, err)
+		analyzer.Add(u, doc)
+	}
+	for i := 1; i <= 3; i++ {
+		add(fmt.Sprintf("https://shop.example.com/products/item-%d", i), fmt.Sprintf(product, i, i, i*10))
+	}
+	callback := analyzer.Callback(nil)
+	for i := 1; i <= 2; i++ {
+		rawURL := fmt.Sprintf("https://shop.example.com/blog/2024/%d", i)
+		u, _ := url.Parse(rawURL)
+		html := fmt.Sprintf(article, i, i)
+		require.NoError(t, callback(context.Background(), &Result{URL: u, Response: &fetch.Response{URL: rawURL, HTML: html}}))
+	}
+
+	clusters := analyzer.Clusters()
+	require.Len(t, clusters, 2)
+	require.Equal(t, &TemplateCluster{
+		Pages:   3,
+		Pattern: "https://shop.example.com/products/{slug}",
+		Samples: []string{"https://shop.example.com/products/item-1", "https://shop.example.com/products/item-2"},
+	}, clusters[0])
+	require.Equal(t, 2, clusters[1].Pages)
+	require.Equal(t, "https://shop.example.com/blog/2024/{id}", clusters[1].Pattern)
+}
+
+func TestSuggestPattern(t *testing.T) {
+	parse := func(urls ...string) []*url.URL {
+		var parsed []*url.URL
+		for _, rawURL := range urls {
+			u, err := url.Parse(rawURL)
+			require.NoError(t, err)
+			parsed = append(parsed, u)
+		}
+		return parsed
+	}
+	require.Equal(t, "https://example.com/a/{slug}/{id}/{slug2}",
+		suggestPattern(parse("https://example.com/a/x/1/p", "https://example.com/a/y/2/q")))
+	require.Equal(t, "https://example.com/docs/{path...}",
+		suggestPattern(parse("https://example.com/docs", "https://example.com/docs/intro/setup")))
+	require.Equal(t, "/about",
+		suggestPattern(parse("https://a.com/about", "https://b.com/about")))
+}
@@ -0,0 +1,238 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// Validator checks a fetched page before it is parsed. Pages that fail
+// validation are passed to the callback with the error and recorded as
+// failures, and their links are not followed.
+type Validator interface {
+	Validate(ctx context.Context, page *fetch.Response) error
+}
+
+// ValidatorFunc adapts a function to the Validator interface.
+type ValidatorFunc func(ctx context.Context, page *fetch.Response) error
+
+// Validate implements the Validator interface.
+func (f ValidatorFunc) Validate(ctx context.Context, page *fetch.Response) error {
+	return f(ctx, page)
+}
+
+// ErrSoft404 is returned by the soft 404 validator for pages that were served
+// successfully but are error pages. It is wrapped with the reason.
+var ErrSoft404 = errors.New("soft 404")
+
+// DefaultSoft404Phrases are phrases that indicate a missing page.
+var DefaultSoft404Phrases = []string{
+	"page not found",
+	"404 not found",
+	"error 404",
+	"404 error",
+	"not found (404)",
+	"page does not exist",
+	"page doesn't exist",
+	"page cannot be found",
+	"page can't be found",
+	"page could not be found",
+	"page you requested could not be found",
+	"page you were looking for",
+	"page you are looking for",
+	"page you're looking for",
+	"no longer available",
+	"nothing was found",
+}
+
+// Soft404Options configures the soft 404 validator.
+type Soft404Options struct {
+	// Phrases indicating a missing page, matched case-insensitively against
+	// the title and heading, and against the text of short pages. Defaults
+	// to DefaultSoft404Phrases.
+	Phrases []string
+
+	// MaxWords is the number of words of main content below which the text
+	// of a page is checked for the phrases. Defaults to 150.
+	MaxWords int
+
+	// Fetcher is used to fetch a random missing URL once per host, to learn
+	// the page the host serves for missing pages. Pages near-identical to it
+	// are soft 404s. Error pages served with a 404 or 410 status are always
+	// learned while crawling.
+	Fetcher fetch.Fetcher
+
+	// Similarity is the fraction of shared words (0-1) above which a page is
+	// considered identical to an error page of its host. Defaults to 0.9.
+	Similarity float64
+}
+
+// NewSoft404Validator returns a Validator that detects soft 404s: pages
+// served with a success status whose title or content says the page was not
+// found, or that are near-identical to the error pages of their host. Pages
+// served with an error status are not reported.
+func NewSoft404Validator(opts Soft404Options) Validator {
+	if opts.Phrases == nil {
+		opts.Phrases = DefaultSoft404Phrases
+	}
+	if opts.MaxWords <= 0 {
+		opts.MaxWords = 150
+	}
+	if opts.Similarity <= 0 {
+		opts.Similarity = 0.9
+	}
+	phrases := make([]string, len(opts.Phrases))
+	for i, phrase := range opts.Phrases {
+		phrases[i] = strings.ToLower(phrase)
+	}
+	return &soft404Validator{options: opts, phrases: phrases}
+}
+
+type soft404Validator struct {
+	options Soft404Options
+	phrases []string
+	hosts   sync.Map // host -> *errorPages
+}
+
+// errorPages holds the word sets of the error pages of a host.
+type errorPages struct {
+	probe sync.Once
+	mutex sync.Mutex
+	pages []map[string]bool
+}
+
+// maxErrorPages is the number of error pages remembered per host.
+const maxErrorPages = 5
+
+func (p *errorPages) add(words map[string]bool) {
+	if len(words) == 0 {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.pages) < maxErrorPages {
+		p.pages = append(p.pages, words)
+	}
+}
+
+func (p *errorPages) similarity(words map[string]bool) float64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var best float64
+	for _, page := range p.pages {
+		best = max(best, jaccard(page, words))
+	}
+	return best
+}
+
+// Validate implements the Validator interface.
+func (v *soft404Validator) Validate(ctx context.Context, page *fetch.Response) error {
+	pageURL, err := url.Parse(page.URL)
+	if err != nil || page.HTML == "" {
+		return nil
+	}
+	value, _ := v.hosts.LoadOrStore(pageURL.Host, &errorPages{})
+	hostPages := value.(*errorPages)
+
+	doc, err := page.Document()
+	if err != nil {
+		return nil
+	}
+	words := pageWords(doc, pageURL)
+	switch page.StatusCode {
+	case 404, 410:
+		hostPages.add(words)
+		return nil
+	}
+	if page.StatusCode >= 300 {
+		return nil
+	}
+
+	for _, text := range []string{doc.Title(), doc.H1()} {
+		if phrase, ok := v.match(text); ok {
+			return fmt.Errorf("%w: title or heading says %q", ErrSoft404, phrase)
+		}
+	}
+	if len(strings.Fields(doc.MainContentText())) <= v.options.MaxWords {
+		if phrase, ok := v.match(doc.MainContentText()); ok {
+			return fmt.Errorf("%w: content says %q", ErrSoft404, phrase)
+		}
+	}
+
+	// The home page is often served for missing pages, so it is not compared
+	if strings.Trim(pageURL.Path, "/") == "" {
+		return nil
+	}
+	if v.options.Fetcher != nil {
+		hostPages.probe.Do(func() { v.probe(ctx, pageURL, hostPages) })
+	}
+	if similarity := hostPages.similarity(words); similarity >= v.options.Similarity {
+		return fmt.Errorf("%w: matches the error page of the host (%.0f%% similar)", ErrSoft404, similarity*100)
+	}
+	return nil
+}
+
+// probe fetches a URL that should not exist on the host of the page and
+// records the response as an error page of the host.
+func (v *soft404Validator) probe(ctx context.Context, pageURL *url.URL, hostPages *errorPages) {
+	probeURL := &url.URL{Scheme: pageURL.Scheme, Host: pageURL.Host, Path: "/" + newID() + "-not-found"}
+	response, err := v.options.Fetcher.Fetch(ctx, &fetch.Request{URL: probeURL.String()})
+	if err != nil || response.HTML == "" {
+		return
+	}
+	if doc, err := response.Document(); err == nil {
+		hostPages.add(pageWords(doc, probeURL))
+	}
+}
+
+func (v *soft404Validator) match(text string) (string, bool) {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, phrase := range v.phrases {
+		if strings.Contains(text, phrase) {
+			return phrase, true
+		}
+	}
+	return "", false
+}
+
+// pageWords returns the set of words of the title, heading and main content
+// of a page. Words of the URL path are left out, as error pages often
+// include the requested path.
+func pageWords(doc *web.Document, pageURL *url.URL) map[string]bool {
+	words := map[string]bool{}
+	text := doc.Title() + " " + doc.H1() + " " + doc.MainContentText()
+	for _, word := range splitWords(text) {
+		words[word] = true
+	}
+	for _, word := range splitWords(pageURL.Path) {
+		delete(words, word)
+	}
+	return words
+}
+
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// jaccard returns the Jaccard similarity of two word sets.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
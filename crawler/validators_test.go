@@ -0,0 +1,138 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoft404Validator_Phrases(t *testing.T) {
+	validator := NewSoft404Validator(Soft404Options{})
+	ctx := context.Background()
+
+	err := validator.Validate(ctx, &fetch.Response{
+		URL:        "https://example.com/missing",
+		StatusCode: 200,
+		HTML:       `<html><head><title>Page Not Found | Example</title></head><body><p>Sorry.</p></body></html>`,
+	})
+	require.ErrorIs(t, err, ErrSoft404)
+
+	err = validator.Validate(ctx, &fetch.Response{
+		URL:        "https://example.com/gone",
+		StatusCode: 200,
+		HTML:       `<html><body><main><p>Oops! The page you were looking for has moved.</p></main></body></html>`,
+	})
+	require.ErrorIs(t, err, ErrSoft404)
+
+	// Long pages mentioning the phrase are not error pages
+	article := `<html><head><title>Handling errors</title></head><body><article><p>` +
+		strings.Repeat("Servers should answer missing URLs with a proper status. ", 30) +
+		`Never show "page not found" with a 200 status.</p></article></body></html>`
+	err = validator.Validate(ctx, &fetch.Response{URL: "https://example.com/blog", StatusCode: 200, HTML: article})
+	require.NoError(t, err)
+
+	// Hard 404s are not soft 404s
+	err = validator.Validate(ctx, &fetch.Response{
+		URL:        "https://example.com/missing",
+		StatusCode: 404,
+		HTML:       `<html><head><title>Page Not Found</title></head></html>`,
+	})
+	require.NoError(t, err)
+}
+
+func TestSoft404Validator_ErrorTemplates(t *testing.T) {
+	errorPage := func(path string) string {
+		return `<html><head><title>Example Store</title></head><body><main>
+			<h1>Whoops</h1><p>We looked everywhere for ` + path + ` but came up empty.
+			Try searching our catalog or head back to the home page to keep shopping.</p>
+			</main></body></html>`
+	}
+	mockFetcher := &probeFetcher{html: errorPage}
+	validator := NewSoft404Validator(Soft404Options{Fetcher: mockFetcher})
+	ctx := context.Background()
+
+	err := validator.Validate(ctx, &fetch.Response{
+		URL:        "https://example.com/products/retired",
+		StatusCode: 200,
+		HTML:       errorPage("/products/retired"),
+	})
+	require.ErrorIs(t, err, ErrSoft404)
+	require.Equal(t, 1, mockFetcher.probes)
+
+	err = validator.Validate(ctx, &fetch.Response{
+		URL:        "https://example.com/products/widget",
+		StatusCode: 200,
+		HTML: `<html><head><title>Widget | Example Store</title></head><body><main>
+			<h1>Widget</h1><p>A sturdy widget for every home.</p></main></body></html>`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, mockFetcher.probes)
+
+	// Error pages are also learned from hard 404s
+	validator = NewSoft404Validator(Soft404Options{})
+	require.NoError(t, validator.Validate(ctx, &fetch.Response{
+		URL:        "https://example.com/a",
+		StatusCode: 404,
+		HTML:       errorPage("/a"),
+	}))
+	err = validator.Validate(ctx, &fetch.Response{
+		URL:        "https://example.com/b",
+		StatusCode: 200,
+		HTML:       errorPage("/b"),
+	})
+	require.ErrorIs(t, err, ErrSoft404)
+}
+
+func TestCrawler_Validators(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:        "https://example.com",
+		StatusCode: 200,
+		HTML:       `<html><head><title>Home</title></head></html>`,
+		Links:      []*fetch.Link{{URL: "https://example.com/missing"}},
+	})
+	mockFetcher.AddResponse("https://example.com/missing", &fetch.Response{
+		URL:        "https://example.com/missing",
+		StatusCode: 200,
+		HTML:       `<html><head><title>404 Not Found</title></head></html>`,
+		Links:      []*fetch.Link{{URL: "https://example.com/other"}},
+	})
+
+	crawler, err := New(Options{
+		Workers:        1,
+		DefaultFetcher: mockFetcher,
+		Validators:     []Validator{NewSoft404Validator(Soft404Options{})},
+	})
+	require.NoError(t, err)
+
+	errs := map[string]error{}
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		errs[result.URL.String()] = result.Error
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	require.NoError(t, errs["https://example.com"])
+	require.ErrorIs(t, errs["https://example.com/missing"], ErrSoft404)
+	require.Equal(t, int64(1), crawler.GetStats().GetFailed())
+	require.ErrorIs(t, crawler.GetErrors(), ErrSoft404)
+}
+
+// probeFetcher serves an error page for any URL, counting the fetches.
+type probeFetcher struct {
+	html   func(path string) string
+	probes int
+}
+
+func (f *probeFetcher) Fetch(ctx context.Context, req *fetch.Request) (*fetch.Response, error) {
+	f.probes++
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	return &fetch.Response{URL: req.URL, StatusCode: 200, HTML: f.html(u.Path)}, nil
+}
@@ -0,0 +1,84 @@
+package crawler
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// VisitedSet is the set of URLs seen by a crawler, i.e. queued, processed or
+// reached by a redirect, so that each URL is fetched once. URLs are added
+// once normalized. Implementations must be safe for concurrent use, and may
+// be approximate, e.g. bloom filters, or persistent, to share the set across
+// crawls.
+type VisitedSet interface {
+	// Add adds a URL, and returns true if it was not in the set.
+	Add(rawURL string) bool
+
+	// Contains returns true if the URL is in the set.
+	Contains(rawURL string) bool
+
+	// Len returns the number of URLs in the set.
+	Len() int
+
+	// Range calls fn for each URL of the set, in no particular order, until
+	// fn returns false. Approximate sets that can't list their URLs don't
+	// call fn.
+	Range(fn func(rawURL string) bool)
+}
+
+// MemoryVisitedSet is a VisitedSet kept in memory. It is the default set of
+// crawlers.
+type MemoryVisitedSet struct {
+	urls  sync.Map
+	count atomic.Int64
+}
+
+// NewMemoryVisitedSet creates a new empty in-memory visited set.
+func NewMemoryVisitedSet() *MemoryVisitedSet {
+	return &MemoryVisitedSet{}
+}
+
+// Add adds a URL, and returns true if it was not in the set.
+func (s *MemoryVisitedSet) Add(rawURL string) bool {
+	if _, exists := s.urls.LoadOrStore(rawURL, true); exists {
+		return false
+	}
+	s.count.Add(1)
+	return true
+}
+
+// Contains returns true if the URL is in the set.
+func (s *MemoryVisitedSet) Contains(rawURL string) bool {
+	_, ok := s.urls.Load(rawURL)
+	return ok
+}
+
+// Len returns the number of URLs in the set.
+func (s *MemoryVisitedSet) Len() int {
+	return int(s.count.Load())
+}
+
+// Range calls fn for each URL of the set until fn returns false.
+func (s *MemoryVisitedSet) Range(fn func(rawURL string) bool) {
+	s.urls.Range(func(key, _ any) bool {
+		return fn(key.(string))
+	})
+}
+
+// VisitedURLs returns the URLs of a visited set, sorted, e.g. to export them
+// after a crawl.
+func VisitedURLs(set VisitedSet) []string {
+	urls := make([]string, 0, set.Len())
+	set.Range(func(rawURL string) bool {
+		urls = append(urls, rawURL)
+		return true
+	})
+	sort.Strings(urls)
+	return urls
+}
+
+// Visited returns the set of URLs seen by the crawler.
+func (c *Crawler) Visited() VisitedSet {
+	return c.visited
+}
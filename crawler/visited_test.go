@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryVisitedSet(t *testing.T) {
+	set := NewMemoryVisitedSet()
+	var wg sync.WaitGroup
+	var added sync.Map
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, u := range []string{"https://example.com/a", "https://example.com/b"} {
+				if set.Add(u) {
+					_, dup := added.LoadOrStore(u, true)
+					require.False(t, dup)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 2, set.Len())
+	require.True(t, set.Contains("https://example.com/a"))
+	require.False(t, set.Contains("https://example.com/c"))
+	require.Equal(t, []string{"https://example.com/a", "https://example.com/b"}, VisitedURLs(set))
+}
+
+func TestCrawler_Visited(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:   "https://example.com",
+		Links: []*fetch.Link{{URL: "/a"}, {URL: "/b"}},
+	})
+	mockFetcher.AddResponse("https://example.com/a", &fetch.Response{URL: "https://example.com/a"})
+	mockFetcher.AddResponse("https://example.com/b", &fetch.Response{URL: "https://example.com/b"})
+
+	// URLs in a shared set are not fetched again
+	visited := NewMemoryVisitedSet()
+	visited.Add("https://example.com/b")
+	crawler, err := New(Options{Workers: 1, DefaultFetcher: mockFetcher, Visited: visited})
+	require.NoError(t, err)
+	var fetched []string
+	err = crawler.Crawl(context.Background(), []string{"https://example.com"}, func(ctx context.Context, result *Result) error {
+		fetched = append(fetched, result.URL.String())
+		return result.Error
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com", "https://example.com/a"}, fetched)
+	require.Same(t, visited, crawler.Visited())
+	require.Equal(t, []string{
+		"https://example.com",
+		"https://example.com/a",
+		"https://example.com/b",
+	}, VisitedURLs(crawler.Visited()))
+}
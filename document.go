@@ -1,18 +1,45 @@
 package web
 
 import (
+	"bytes"
 	"net/url"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	htmltomarkdown "github.com/JohannesKaufmann/html-to-markdown/v2"
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
 // Link represents a link on a page.
 type Link struct {
-	URL  string `json:"url"`
-	Text string `json:"text,omitempty"`
+	URL   string `json:"url"`
+	Text  string `json:"text,omitempty"`
+	Rel   string `json:"rel,omitempty"`
+	Title string `json:"title,omitempty"`
+
+	// Container is the nearest enclosing landmark of the link: "nav",
+	// "header", "footer", "aside", "main" or "article". Empty if the link is
+	// not inside a landmark.
+	Container string `json:"container,omitempty"`
+
+	// InMainContent is true if the link is part of the main content of the
+	// page rather than navigation or other boilerplate.
+	InMainContent bool `json:"in_main_content,omitempty"`
+}
+
+// HasRel returns true if the link's rel attribute contains the given value,
+// such as "nofollow".
+func (l *Link) HasRel(value string) bool {
+	for _, rel := range strings.Fields(l.Rel) {
+		if strings.EqualFold(rel, value) {
+			return true
+		}
+	}
+	return false
 }
 
 // Host returns the host of the link.
@@ -34,9 +61,17 @@ type Meta struct {
 }
 
 // Document helps parse and extract information from an HTML document.
+//
+// Values extracted from the document are computed once and cached, so they
+// are shared between calls and must not be modified. Modifying the tree via
+// GoqueryDocument after values were extracted leaves them stale; use Clone
+// to work on a separate copy. A Document is safe for concurrent use as long
+// as its tree is not modified.
 type Document struct {
-	doc  *goquery.Document
-	html string
+	doc    *goquery.Document
+	html   string
+	mutex  sync.Mutex
+	values map[string]any
 }
 
 // NewDocument creates a new Document from an HTML string.
@@ -48,6 +83,29 @@ func NewDocument(html string) (*Document, error) {
 	return &Document{doc: doc, html: html}, nil
 }
 
+// memoize returns the value cached under key, computing it on first use.
+// The computation runs without holding the lock, as it may use other
+// memoized values. If computed concurrently, the first value stored wins.
+func memoize[T any](d *Document, key string, compute func() T) T {
+	d.mutex.Lock()
+	value, ok := d.values[key]
+	d.mutex.Unlock()
+	if ok {
+		return value.(T)
+	}
+	result := compute()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if value, ok := d.values[key]; ok {
+		return value.(T)
+	}
+	if d.values == nil {
+		d.values = map[string]any{}
+	}
+	d.values[key] = result
+	return result
+}
+
 // Raw returns the raw HTML text of the document.
 func (d *Document) Raw() string {
 	return d.html
@@ -60,218 +118,342 @@ func (d *Document) GoqueryDocument() *goquery.Document {
 
 // Language of the document.
 func (d *Document) Language() string {
-	if s := d.doc.Find("html").First(); len(s.Nodes) > 0 {
-		return strings.ToLower(strings.TrimSpace(s.AttrOr("lang", "")))
-	}
-	return ""
+	return memoize(d, "language", func() string {
+		if s := d.doc.Find("html").First(); len(s.Nodes) > 0 {
+			return strings.ToLower(strings.TrimSpace(s.AttrOr("lang", "")))
+		}
+		return ""
+	})
 }
 
 // CanonicalURL returns the canonical URL of the document.
 func (d *Document) CanonicalURL() string {
-	if s := d.doc.Find("link[rel='canonical']"); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("href", ""))
-	}
-	return ""
+	return memoize(d, "canonicalURL", func() string {
+		if s := d.doc.Find("link[rel='canonical']"); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("href", ""))
+		}
+		return ""
+	})
+}
+
+// BaseURL returns the href of the base element of the document, which
+// relative URLs of the document resolve against. See ResolveBase.
+func (d *Document) BaseURL() string {
+	return memoize(d, "baseURL", func() string {
+		if s := d.doc.Find("base[href]"); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.First().AttrOr("href", ""))
+		}
+		return ""
+	})
 }
 
 // Title returns the title of the document.
 func (d *Document) Title() string {
-	if s := d.doc.Find("title").First(); len(s.Nodes) > 0 {
-		return NormalizeText(s.Text())
-	}
-	if s := d.doc.Find("meta[property='og:title']").First(); len(s.Nodes) > 0 {
-		return NormalizeText(s.AttrOr("content", ""))
-	}
-	if s := d.doc.Find("meta[name='title']").First(); len(s.Nodes) > 0 {
-		return NormalizeText(s.AttrOr("content", ""))
-	}
-	return ""
+	return memoize(d, "title", func() string {
+		if s := d.doc.Find("title").First(); len(s.Nodes) > 0 {
+			return NormalizeText(s.Text())
+		}
+		if s := d.doc.Find("meta[property='og:title']").First(); len(s.Nodes) > 0 {
+			return NormalizeText(s.AttrOr("content", ""))
+		}
+		if s := d.doc.Find("meta[name='title']").First(); len(s.Nodes) > 0 {
+			return NormalizeText(s.AttrOr("content", ""))
+		}
+		return ""
+	})
 }
 
 // H1 returns the first H1 element of the document.
 func (d *Document) H1() string {
-	var h1 string
-	d.doc.Find("h1").Each(func(i int, s *goquery.Selection) {
-		h1 = NormalizeText(s.Text())
+	return memoize(d, "h1", func() string {
+		var h1 string
+		d.doc.Find("h1").Each(func(i int, s *goquery.Selection) {
+			h1 = NormalizeText(s.Text())
+		})
+		return h1
 	})
-	return h1
 }
 
 // Robots returns the robots meta tag of the document.
 func (d *Document) Robots() string {
-	if s := d.doc.Find("meta[name='robots']").First(); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("content", ""))
-	}
-	return ""
+	return memoize(d, "robots", func() string {
+		if s := d.doc.Find("meta[name='robots']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("content", ""))
+		}
+		return ""
+	})
 }
 
 // Description returns the description meta tag of the document.
 func (d *Document) Description() string {
-	if s := d.doc.Find("meta[name='description']"); len(s.Nodes) > 0 {
-		return NormalizeText(s.AttrOr("content", ""))
-	}
-	if s := d.doc.Find("meta[property='og:description']"); len(s.Nodes) > 0 {
-		return NormalizeText(s.AttrOr("content", ""))
-	}
-	return ""
+	return memoize(d, "description", func() string {
+		if s := d.doc.Find("meta[name='description']"); len(s.Nodes) > 0 {
+			return NormalizeText(s.AttrOr("content", ""))
+		}
+		if s := d.doc.Find("meta[property='og:description']"); len(s.Nodes) > 0 {
+			return NormalizeText(s.AttrOr("content", ""))
+		}
+		return ""
+	})
 }
 
 // Image returns the image meta tag of the document.
 func (d *Document) Image() string {
-	if s := d.doc.Find("meta[property='og:image']").First(); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("content", ""))
-	}
-	if s := d.doc.Find("meta[property='og:image:url']").First(); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("content", ""))
-	}
-	return ""
+	return memoize(d, "image", func() string {
+		if s := d.doc.Find("meta[property='og:image']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("content", ""))
+		}
+		if s := d.doc.Find("meta[property='og:image:url']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("content", ""))
+		}
+		return ""
+	})
 }
 
 // Icon returns the icon link of the document.
 func (d *Document) Icon() string {
-	if s := d.doc.Find("link[rel='icon']").First(); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("href", ""))
-	}
-	if s := d.doc.Find("link[rel='shortcut icon']").First(); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("href", ""))
-	}
-	return ""
+	return memoize(d, "icon", func() string {
+		if s := d.doc.Find("link[rel='icon']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("href", ""))
+		}
+		if s := d.doc.Find("link[rel='shortcut icon']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("href", ""))
+		}
+		return ""
+	})
 }
 
 // Keywords returns the keywords meta tag of the document.
 func (d *Document) Keywords() []string {
-	if s := d.doc.Find("meta[name='keywords']").First(); len(s.Nodes) > 0 {
-		keywords := s.AttrOr("content", "")
-		if len(keywords) > 0 {
+	return memoize(d, "keywords", func() []string {
+		if s := d.doc.Find("meta[name='keywords']").First(); len(s.Nodes) > 0 {
+			keywords := s.AttrOr("content", "")
+			if len(keywords) > 0 {
+				return parseKeywords(keywords)
+			}
+		}
+		if s := d.doc.Find("meta[property='og:keywords']").First(); len(s.Nodes) > 0 {
+			keywords := s.AttrOr("content", "")
 			return parseKeywords(keywords)
 		}
-	}
-	if s := d.doc.Find("meta[property='og:keywords']").First(); len(s.Nodes) > 0 {
-		keywords := s.AttrOr("content", "")
-		return parseKeywords(keywords)
-	}
-	return []string{}
+		return []string{}
+	})
 }
 
 // Author returns the author meta tag of the document.
 func (d *Document) Author() string {
-	if s := d.doc.Find("meta[name='author']").First(); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("content", ""))
-	}
-	if s := d.doc.Find("meta[property='og:author']").First(); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("content", ""))
-	}
-	return ""
+	return memoize(d, "author", func() string {
+		if s := d.doc.Find("meta[name='author']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("content", ""))
+		}
+		if s := d.doc.Find("meta[property='og:author']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("content", ""))
+		}
+		return ""
+	})
 }
 
 // TwitterSite returns the twitter site meta tag of the document.
 func (d *Document) TwitterSite() string {
-	if s := d.doc.Find("meta[name='twitter:site']").First(); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("content", ""))
-	}
-	if s := d.doc.Find("meta[property='twitter:site']").First(); len(s.Nodes) > 0 {
-		return strings.TrimSpace(s.AttrOr("content", ""))
-	}
-	return ""
+	return memoize(d, "twitterSite", func() string {
+		if s := d.doc.Find("meta[name='twitter:site']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("content", ""))
+		}
+		if s := d.doc.Find("meta[property='twitter:site']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("content", ""))
+		}
+		return ""
+	})
 }
 
 // PublishedTime returns the published time meta tag of the document.
 func (d *Document) PublishedTime() time.Time {
-	var timeStr string
-	d.doc.Find("meta[name='article:published_time']").Each(func(i int, s *goquery.Selection) {
-		timeStr = strings.TrimSpace(s.AttrOr("content", ""))
-	})
-	if timeStr != "" {
-		value, _ := time.Parse(time.RFC3339, timeStr)
-		return value
-	}
-	d.doc.Find("meta[property='article:published_time']").Each(func(i int, s *goquery.Selection) {
-		timeStr = strings.TrimSpace(s.AttrOr("content", ""))
-	})
-	if timeStr != "" {
+	return memoize(d, "publishedTime", func() time.Time {
+		var timeStr string
+		d.doc.Find("meta[name='article:published_time']").Each(func(i int, s *goquery.Selection) {
+			timeStr = strings.TrimSpace(s.AttrOr("content", ""))
+		})
+		if timeStr != "" {
+			value, _ := time.Parse(time.RFC3339, timeStr)
+			return value
+		}
+		d.doc.Find("meta[property='article:published_time']").Each(func(i int, s *goquery.Selection) {
+			timeStr = strings.TrimSpace(s.AttrOr("content", ""))
+		})
+		if timeStr != "" {
+			value, _ := time.Parse(time.RFC3339, timeStr)
+			return value
+		}
+		d.doc.Find("meta[property='og:published_time']").Each(func(i int, s *goquery.Selection) {
+			timeStr = strings.TrimSpace(s.AttrOr("content", ""))
+		})
 		value, _ := time.Parse(time.RFC3339, timeStr)
 		return value
-	}
-	d.doc.Find("meta[property='og:published_time']").Each(func(i int, s *goquery.Selection) {
-		timeStr = strings.TrimSpace(s.AttrOr("content", ""))
 	})
-	value, _ := time.Parse(time.RFC3339, timeStr)
-	return value
 }
 
 // Meta returns the meta tags of the document.
 func (d *Document) Meta() []*Meta {
-	metas := []*Meta{}
-	d.doc.Find("meta").Each(func(i int, s *goquery.Selection) {
-		var meta Meta
-		meta.Tag = "meta"
-		meta.Name = s.AttrOr("name", "")
-		meta.Property = s.AttrOr("property", "")
-		meta.Content = s.AttrOr("content", "")
-		meta.Charset = s.AttrOr("charset", "")
-		metas = append(metas, &meta)
+	return memoize(d, "meta", func() []*Meta {
+		metas := []*Meta{}
+		d.doc.Find("meta").Each(func(i int, s *goquery.Selection) {
+			var meta Meta
+			meta.Tag = "meta"
+			meta.Name = s.AttrOr("name", "")
+			meta.Property = s.AttrOr("property", "")
+			meta.Content = s.AttrOr("content", "")
+			meta.Charset = s.AttrOr("charset", "")
+			metas = append(metas, &meta)
+		})
+		return metas
 	})
-	return metas
 }
 
-// Links returns the links on the document.
+// Links returns the links on the document, along with their rel and title
+// attributes and where on the page they appear.
 func (d *Document) Links() []*Link {
-	links := []*Link{}
-	d.doc.Find("a").Each(func(i int, s *goquery.Selection) {
-		href := s.AttrOr("href", "")
-		if href == "" {
-			return
+	return memoize(d, "links", func() []*Link {
+		links := []*Link{}
+		var main *html.Node
+		if content := d.mainContent(); len(content.Nodes) > 0 {
+			main = content.Nodes[0]
 		}
-		links = append(links, &Link{URL: href, Text: s.Text()})
+		d.doc.Find("a").Each(func(i int, s *goquery.Selection) {
+			href := s.AttrOr("href", "")
+			if href == "" {
+				return
+			}
+			link := &Link{
+				URL:   href,
+				Text:  s.Text(),
+				Rel:   strings.ToLower(strings.Join(strings.Fields(s.AttrOr("rel", "")), " ")),
+				Title: strings.TrimSpace(s.AttrOr("title", "")),
+			}
+			link.Container, link.InMainContent = linkContext(s.Nodes[0], main)
+			links = append(links, link)
+		})
+		return links
 	})
-	return links
+}
+
+// ResolveURLs returns the absolute URLs of the links of the document, as
+// found on the given page. Links are resolved against the base element of the
+// document, if any, and normalized without fragments like ResolveLink.
+// Duplicates are removed and the order of the document is kept.
+func (d *Document) ResolveURLs(pageURL string) []string {
+	base := ResolveBase(pageURL, d.BaseURL())
+	seen := map[string]bool{}
+	urls := []string{}
+	for _, link := range d.Links() {
+		if resolved, ok := ResolveLink(base, link.URL); ok && !seen[resolved] {
+			seen[resolved] = true
+			urls = append(urls, resolved)
+		}
+	}
+	return urls
+}
+
+// landmarkRoles maps ARIA landmark roles to their equivalent elements.
+var landmarkRoles = map[string]string{
+	"navigation":    "nav",
+	"banner":        "header",
+	"contentinfo":   "footer",
+	"complementary": "aside",
+	"main":          "main",
+	"article":       "article",
+}
+
+// linkContext returns the nearest landmark container of a node and whether
+// the node is inside the main content element without being inside
+// navigation or other boilerplate landmarks.
+func linkContext(node, main *html.Node) (string, bool) {
+	var container string
+	inMain, boilerplate := false, false
+	for n := node.Parent; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		landmark := ""
+		switch n.Data {
+		case "nav", "header", "footer", "aside", "main", "article":
+			landmark = n.Data
+		}
+		for _, attr := range n.Attr {
+			if attr.Key == "role" {
+				if role, ok := landmarkRoles[strings.ToLower(strings.TrimSpace(attr.Val))]; ok {
+					landmark = role
+				}
+			}
+		}
+		if container == "" {
+			container = landmark
+		}
+		if n == main {
+			inMain = true
+			break
+		}
+		switch landmark {
+		case "nav", "header", "footer", "aside":
+			boilerplate = true
+		}
+	}
+	return container, inMain && !boilerplate
 }
 
 // Images returns the images on the document.
 func (d *Document) Images() []*Link {
-	images := []*Link{}
-	d.doc.Find("img").Each(func(i int, s *goquery.Selection) {
-		src := s.AttrOr("src", "")
-		if src == "" {
-			return
-		}
-		images = append(images, &Link{URL: src, Text: s.AttrOr("alt", "")})
+	return memoize(d, "images", func() []*Link {
+		images := []*Link{}
+		d.doc.Find("img").Each(func(i int, s *goquery.Selection) {
+			src := s.AttrOr("src", "")
+			if src == "" {
+				return
+			}
+			images = append(images, &Link{URL: src, Text: s.AttrOr("alt", "")})
+		})
+		return images
 	})
-	return images
 }
 
 // Paragraphs returns the paragraphs on the document.
 func (d *Document) Paragraphs() []string {
-	paragraphs := []string{}
-	d.doc.Find("p").Each(func(i int, s *goquery.Selection) {
-		nodeText := strings.TrimSpace(s.Text())
-		if nodeText == "" {
-			return
-		}
-		paragraphs = append(paragraphs, nodeText)
+	return memoize(d, "paragraphs", func() []string {
+		paragraphs := []string{}
+		d.doc.Find("p").Each(func(i int, s *goquery.Selection) {
+			nodeText := strings.TrimSpace(s.Text())
+			if nodeText == "" {
+				return
+			}
+			paragraphs = append(paragraphs, nodeText)
+		})
+		return paragraphs
 	})
-	return paragraphs
 }
 
 // Metadata returns the metadata summary for the document.
 func (d *Document) Metadata() Metadata {
-	metadata := Metadata{
-		Title:        d.Title(),
-		Description:  d.Description(),
-		Author:       d.Author(),
-		CanonicalURL: d.CanonicalURL(),
-		Language:     d.Language(),
-		Heading:      d.H1(),
-		Robots:       d.Robots(),
-		Image:        d.Image(),
-		Icon:         d.Icon(),
-		Keywords:     d.Keywords(),
-		Tags:         d.Meta(),
-	}
-	if value := d.PublishedTime(); !value.IsZero() {
-		metadata.PublishedTime = value.Format(time.RFC3339)
-	}
-	return metadata
+	return memoize(d, "metadata", func() Metadata {
+		metadata := Metadata{
+			Title:          d.Title(),
+			Description:    d.Description(),
+			Author:         d.Author(),
+			CanonicalURL:   d.CanonicalURL(),
+			Language:       d.Language(),
+			Heading:        d.H1(),
+			Robots:         d.Robots(),
+			Image:          d.Image(),
+			Icon:           d.Icon(),
+			ManifestURL:    d.ManifestURL(),
+			BaseURL:        d.BaseURL(),
+			Keywords:       d.Keywords(),
+			Tags:           d.Meta(),
+			StructuredData: d.StructuredData(),
+		}
+		if value := d.PublishedTime(); !value.IsZero() {
+			metadata.PublishedTime = value.Format(time.RFC3339)
+		}
+		return metadata
+	})
 }
 
 // RenderOptions contains HTML rendering options.
@@ -279,81 +461,200 @@ type RenderOptions struct {
 	ExcludeTags     []string
 	OnlyMainContent bool
 	Prettify        bool
+
+	// ExcludeProfile names the set of selectors to exclude, in addition to
+	// ExcludeTags. See ExcludeProfiles. OnlyMainContent uses the standard
+	// profile unless another one is set.
+	ExcludeProfile string
+
+	// KeepTags are selectors of the profile or of ExcludeTags that are not
+	// excluded, e.g. "nav" to keep the navigation of the standard profile.
+	KeepTags []string
+
+	// IncludeNoscript renders the content of noscript elements, which often
+	// holds static fallbacks of scripted content, instead of removing it.
+	IncludeNoscript bool
+
+	// Minify strips comments, collapses whitespace and drops empty
+	// attributes, to reduce the size of stored HTML. The whitespace of pre,
+	// textarea, script and style elements is kept. Ignored when prettified.
+	Minify bool
+
+	// Stable renders the HTML deterministically, so that the output only
+	// changes when the document does, e.g. to diff pages between crawls.
+	// The document is minified, attributes and class names are sorted, and
+	// block elements are placed on their own indented lines. Takes
+	// precedence over Prettify and Minify.
+	Stable bool
 }
 
 // IsEmpty returns true if no transformations are requested.
 func (opts RenderOptions) IsEmpty() bool {
-	return len(opts.ExcludeTags) == 0 && !opts.OnlyMainContent && !opts.Prettify
+	return !opts.HasFiltering() && !opts.Prettify && !opts.Minify && !opts.Stable
 }
 
 // HasFiltering returns true if any filtering is requested.
 func (opts RenderOptions) HasFiltering() bool {
-	return len(opts.ExcludeTags) > 0 || opts.OnlyMainContent
+	return len(opts.ExcludeTags) > 0 || opts.OnlyMainContent || opts.ExcludeProfile != "" || opts.IncludeNoscript
 }
 
 // Render the document as HTML, with optional transformations.
 func (d *Document) Render(options RenderOptions) (string, error) {
+	rendered, err := d.RenderDocument(options)
+	if err != nil {
+		return "", err
+	}
+	return rendered.Raw(), nil
+}
+
+// RenderDocument applies the transformations like Render but returns the
+// result as a Document, so it can be used further without re-parsing the
+// HTML. Filtering operates on a copy of the parsed tree, so the original
+// document is not modified. When prettified, Raw returns the formatted HTML
+// while the tree is that of the unformatted HTML.
+func (d *Document) RenderDocument(options RenderOptions) (*Document, error) {
 	if options.IsEmpty() {
-		return d.html, nil
+		return d, nil
 	}
 
-	// HTML before transformations
-	html := d.html
+	// Document before transformations
+	rendered := d
 
 	// Optional tag filtering
 	if options.HasFiltering() {
-		copiedDoc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+		excludeTags, err := options.ExcludeSelectors()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		excludeTags := map[string]bool{}
-		for _, tag := range options.ExcludeTags {
-			excludeTags[tag] = true
+		rendered = d.Clone()
+		if options.IncludeNoscript {
+			unwrapNoscript(rendered.doc.Selection)
 		}
-		if options.OnlyMainContent {
-			for _, tag := range StandardExcludeTags {
-				excludeTags[tag] = true
-			}
+		for _, tag := range excludeTags {
+			rendered.doc.Find(tag).Remove()
+		}
+		html, err := renderHTML(rendered.doc.Nodes[0], len(d.html))
+		if err != nil {
+			return nil, err
 		}
-		for tag := range excludeTags {
-			copiedDoc.Find(tag).Remove()
+		rendered.html = html
+	}
+
+	// Optional formatting
+	if options.Stable && len(rendered.doc.Nodes) > 0 {
+		if rendered == d {
+			rendered = d.Clone()
 		}
-		html, err = copiedDoc.Html()
+		html, err := renderStable(rendered.doc.Nodes[0])
 		if err != nil {
+			return nil, err
+		}
+		rendered.html = html
+	} else if options.Prettify {
+		rendered = &Document{doc: rendered.doc, html: FormatHTML(rendered.html)}
+	} else if options.Minify && len(rendered.doc.Nodes) > 0 {
+		if rendered == d {
+			rendered = d.Clone()
+		}
+		minifyNode(rendered.doc.Nodes[0])
+		html, err := renderHTML(rendered.doc.Nodes[0], len(rendered.html))
+		if err != nil {
+			return nil, err
+		}
+		rendered.html = html
+	}
+
+	return rendered, nil
+}
+
+// Clone returns a deep copy of the document. Changes made to the tree of the
+// copy, e.g. via GoqueryDocument, do not affect the original.
+func (d *Document) Clone() *Document {
+	var root *html.Node
+	if len(d.doc.Nodes) > 0 {
+		root = cloneNode(d.doc.Nodes[0])
+	}
+	return &Document{doc: goquery.NewDocumentFromNode(root), html: d.html}
+}
+
+// Markdown converts the document to Markdown without re-parsing its HTML.
+func (d *Document) Markdown() (string, error) {
+	if len(d.doc.Nodes) == 0 {
+		return "", nil
+	}
+	// The converter modifies the tree it is given
+	markdown, err := htmltomarkdown.ConvertNode(cloneNode(d.doc.Nodes[0]))
+	if err != nil {
+		return "", err
+	}
+	return string(markdown), nil
+}
+
+// bufferPool holds buffers used to render HTML, to reduce allocations when
+// rendering many documents.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxPooledBufferSize is the capacity above which buffers are not returned
+// to the pool, so that rare large pages don't keep memory alive.
+const maxPooledBufferSize = 4 * 1024 * 1024
+
+// renderHTML renders the children of the root node as HTML, like goquery's
+// Html method. The size hint is used to preallocate the buffer.
+func renderHTML(root *html.Node, sizeHint int) (string, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			bufferPool.Put(buf)
+		}
+	}()
+	buf.Grow(sizeHint)
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(buf, child); err != nil {
 			return "", err
 		}
 	}
+	return buf.String(), nil
+}
 
-	// Optional prettify
-	if options.Prettify {
-		html = FormatHTML(html)
+// cloneNode returns a deep copy of the node and its descendants.
+func cloneNode(node *html.Node) *html.Node {
+	clone := &html.Node{
+		Type:      node.Type,
+		DataAtom:  node.DataAtom,
+		Data:      node.Data,
+		Namespace: node.Namespace,
 	}
+	if len(node.Attr) > 0 {
+		clone.Attr = make([]html.Attribute, len(node.Attr))
+		copy(clone.Attr, node.Attr)
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		clone.AppendChild(cloneNode(child))
+	}
+	return clone
+}
 
-	return html, nil
-}
-
-// StandardExcludeTags contains the suggested tags to exclude from HTML.
-var StandardExcludeTags = []string{
-	`[role="dialog"]`,
-	`[aria-modal="true"]`,
-	`[id*="cookie"]`,
-	`[id*="popup"]`,
-	`[id*="modal"]`,
-	`[class*="modal"]`,
-	`[class*="dialog"]`,
-	"img[data-cookieconsent]",
-	"script",
-	"style",
-	"hr",
-	"noscript",
-	"iframe",
-	"select",
-	"input",
-	"button",
-	"svg",
-	"form",
-	"nav",
-	"footer",
+// unwrapNoscript replaces the noscript elements of the tree with their
+// content. The parser keeps that content as text, as scripting is enabled.
+func unwrapNoscript(root *goquery.Selection) {
+	root.Find("noscript").Each(func(_ int, s *goquery.Selection) {
+		node := s.Get(0)
+		context := node.Parent
+		if context == nil || context.Type != html.ElementNode {
+			context = &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+		}
+		nodes, err := html.ParseFragment(strings.NewReader(s.Text()), context)
+		if err != nil {
+			return
+		}
+		for _, child := range nodes {
+			node.Parent.InsertBefore(child, node)
+		}
+		node.Parent.RemoveChild(node)
+	})
 }
 
 // parseKeywords parses the keywords from a string.
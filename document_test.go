@@ -1,6 +1,8 @@
 package web
 
 import (
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -20,3 +22,297 @@ func TestDocument_Markdown(t *testing.T) {
 	header := doc.H1()
 	require.Equal(t, "Hello, world!", header)
 }
+
+func TestDocument_Links(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><body>
+			<header><a href="/">Home</a></header>
+			<div role="navigation"><a href="/docs">Docs</a></div>
+			<main>
+				<p>See the <a href="/guide" title=" The guide " rel="NoFollow  ugc">guide</a> for details.</p>
+				<aside><a href="/related">Related</a></aside>
+			</main>
+			<footer><a href="/privacy">Privacy</a></footer>
+			<a href="/loose">Loose</a>
+		</body></html>
+	`)
+	require.NoError(t, err)
+
+	require.Equal(t, []*Link{
+		{URL: "/", Text: "Home", Container: "header"},
+		{URL: "/docs", Text: "Docs", Container: "nav"},
+		{URL: "/guide", Text: "guide", Rel: "nofollow ugc", Title: "The guide", Container: "main", InMainContent: true},
+		{URL: "/related", Text: "Related", Container: "aside"},
+		{URL: "/privacy", Text: "Privacy", Container: "footer"},
+		{URL: "/loose", Text: "Loose"},
+	}, doc.Links())
+	require.True(t, doc.Links()[2].HasRel("nofollow"))
+}
+
+func TestDocument_ResolveURLs(t *testing.T) {
+	doc, err := NewDocument(`<html><head><base href="https://cdn.example.com/app/"></head><body>
+		<a href="page">Page</a>
+		<a href="/root#top">Root</a>
+		<a href="/root">Root again</a>
+		<a href="mailto:a@example.com">Mail</a>
+	</body></html>`)
+	require.NoError(t, err)
+	require.Equal(t, "https://cdn.example.com/app/", doc.BaseURL())
+	require.Equal(t, []string{"https://cdn.example.com/app/page", "https://cdn.example.com/root"},
+		doc.ResolveURLs("https://example.com/blog/post"))
+
+	// Without a base element, links resolve against the page
+	doc, err = NewDocument(`<html><body><a href="next">Next</a></body></html>`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/blog/next"}, doc.ResolveURLs("https://example.com/blog/post"))
+
+	require.Equal(t, "https://example.com/docs/", ResolveBase("https://example.com/blog/post", "/docs/"))
+	require.Equal(t, "https://example.com/blog/post", ResolveBase("https://example.com/blog/post", "javascript:void(0)"))
+}
+
+func TestDocument_RenderDocument(t *testing.T) {
+	doc, err := NewDocument(`<html><body><nav><a href="/">Home</a></nav><h1>Title</h1><p>Some <b>text</b>.</p></body></html>`)
+	require.NoError(t, err)
+
+	rendered, err := doc.RenderDocument(RenderOptions{ExcludeTags: []string{"nav"}})
+	require.NoError(t, err)
+	require.NotContains(t, rendered.Raw(), "<nav>")
+	require.Empty(t, rendered.Links())
+
+	// The original document is unchanged
+	require.Len(t, doc.Links(), 1)
+
+	html, err := doc.Render(RenderOptions{ExcludeTags: []string{"nav"}})
+	require.NoError(t, err)
+	require.Equal(t, html, rendered.Raw())
+
+	// Converting the tree matches converting the rendered HTML
+	markdown, err := rendered.Markdown()
+	require.NoError(t, err)
+	expected, err := Markdown(rendered.Raw())
+	require.NoError(t, err)
+	require.Equal(t, expected, markdown)
+	require.Equal(t, "# Title\n\nSome **text**.", markdown)
+
+	// Converting does not modify the document
+	again, err := rendered.Markdown()
+	require.NoError(t, err)
+	require.Equal(t, markdown, again)
+
+	// No transformations returns the same document
+	same, err := doc.RenderDocument(RenderOptions{})
+	require.NoError(t, err)
+	require.Same(t, doc, same)
+}
+
+func TestDocument_RenderExcludeProfiles(t *testing.T) {
+	doc, err := NewDocument(`<html><body>
+		<nav>Menu</nav>
+		<article><h1>Title</h1><p>Body</p><div class="comments">Nice</div></article>
+		<form><select><option>Size</option></select></form>
+		<footer>Footer</footer>
+		<script>track()</script>
+	</body></html>`)
+	require.NoError(t, err)
+
+	render := func(options RenderOptions) string {
+		html, err := doc.Render(options)
+		require.NoError(t, err)
+		return html
+	}
+
+	// OnlyMainContent uses the standard profile
+	html := render(RenderOptions{OnlyMainContent: true})
+	require.NotContains(t, html, "Menu")
+	require.NotContains(t, html, "Footer")
+	require.NotContains(t, html, "Size")
+	require.Contains(t, html, "Nice")
+
+	html = render(RenderOptions{OnlyMainContent: true, ExcludeProfile: ExcludeProfileConservative})
+	require.Contains(t, html, "Menu")
+	require.Contains(t, html, "Footer")
+	require.Contains(t, html, "Size")
+	require.NotContains(t, html, "track()")
+
+	html = render(RenderOptions{ExcludeProfile: ExcludeProfileArticle})
+	require.NotContains(t, html, "Nice")
+	require.Contains(t, html, "Body")
+
+	html = render(RenderOptions{ExcludeProfile: ExcludeProfileEcommerce})
+	require.NotContains(t, html, "Menu")
+	require.Contains(t, html, "Size")
+
+	// Selectors can be added and removed
+	html = render(RenderOptions{OnlyMainContent: true, ExcludeTags: []string{"h1"}, KeepTags: []string{"nav", "footer"}})
+	require.Contains(t, html, "Menu")
+	require.Contains(t, html, "Footer")
+	require.NotContains(t, html, "Title")
+
+	_, err = doc.Render(RenderOptions{ExcludeProfile: "minimal"})
+	require.Error(t, err)
+}
+
+func TestDocument_RenderNoscript(t *testing.T) {
+	doc, err := NewDocument(`<html><body><p>Loading</p><noscript><img src="/a.png"/><p>Static <b>fallback</b></p></noscript></body></html>`)
+	require.NoError(t, err)
+
+	html, err := doc.Render(RenderOptions{IncludeNoscript: true, OnlyMainContent: true})
+	require.NoError(t, err)
+	require.Equal(t, `<html><head></head><body><p>Loading</p><img src="/a.png"/><p>Static <b>fallback</b></p></body></html>`, html)
+
+	// Noscript content is removed by default
+	html, err = doc.Render(RenderOptions{OnlyMainContent: true})
+	require.NoError(t, err)
+	require.NotContains(t, html, "fallback")
+}
+
+func TestDocument_RenderMinify(t *testing.T) {
+	doc, err := NewDocument(`<!DOCTYPE html>
+<html>
+  <head>
+    <title>Title</title>
+    <!-- analytics -->
+  </head>
+  <body>
+    <div id="" class="main">
+      <p>Some   <b>bold</b> <i>text</i>
+         on two lines.</p>
+      <pre>  keep
+    this</pre>
+      <input type="checkbox" checked="" value="">
+      <img src="/a.png" alt="">
+    </div>
+  </body>
+</html>`)
+	require.NoError(t, err)
+
+	html, err := doc.Render(RenderOptions{Minify: true})
+	require.NoError(t, err)
+	require.Equal(t, `<!DOCTYPE html><html><head><title>Title</title></head><body><div class="main"><p>Some <b>bold</b> <i>text</i> on two lines.</p><pre>  keep
+    this</pre><input type="checkbox" checked="" value=""/> <img src="/a.png" alt=""/></div></body></html>`, html)
+
+	// The original document is unchanged
+	require.Contains(t, doc.Raw(), "<!-- analytics -->")
+
+	minified, err := MinifyHTML(doc.Raw())
+	require.NoError(t, err)
+	require.Equal(t, html, minified)
+}
+
+func TestDocument_RenderStable(t *testing.T) {
+	doc, err := NewDocument(`<!DOCTYPE html><html><head><title>Title</title>
+		<meta name="description" content="Description"></head>
+		<body class="page  home page"><!-- build 1234 --><nav id="nav" class="top"><ul><li><a href="/" title="">Home</a></li></ul></nav>
+		<main><h1>Title</h1>Loose <em>text</em><p data-b="2" data-a="1">Some   <b>bold</b></p><pre> keep
+  this</pre></main></body></html>`)
+	require.NoError(t, err)
+
+	html, err := doc.Render(RenderOptions{Stable: true})
+	require.NoError(t, err)
+	require.Equal(t, `<!DOCTYPE html>
+<html>
+  <head>
+    <title>Title</title>
+    <meta content="Description" name="description"/>
+  </head>
+  <body class="home page">
+    <nav class="top" id="nav">
+      <ul>
+        <li><a href="/">Home</a></li>
+      </ul>
+    </nav>
+    <main>
+      <h1>Title</h1>
+      Loose <em>text</em>
+      <p data-a="1" data-b="2">Some <b>bold</b></p>
+      <pre> keep
+  this</pre>
+    </main>
+  </body>
+</html>
+`, html)
+
+	// Equivalent documents render identically
+	other, err := StableHTML(`<!DOCTYPE html><html><head><title>Title</title><meta content="Description" name="description"></head>
+		<body class="home page"><nav class="top" id="nav"><ul><li><a href="/">Home</a></li></ul></nav><main><h1>Title</h1>
+		Loose <em>text</em>
+		<p data-a="1" data-b="2">Some <b>bold</b></p><pre> keep
+  this</pre></main></body></html>`)
+	require.NoError(t, err)
+	require.Equal(t, html, other)
+
+	// Rendering is idempotent
+	again, err := StableHTML(html)
+	require.NoError(t, err)
+	require.Equal(t, html, again)
+}
+
+func TestDocument_Memoization(t *testing.T) {
+	doc, err := NewDocument(`<html><head><title>Title</title><meta name="description" content="Description"></head>
+		<body><a href="/a">A</a><a href="/b">B</a></body></html>`)
+	require.NoError(t, err)
+
+	// Concurrent access computes consistent values
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Equal(t, "Title", doc.Metadata().Title)
+			require.Len(t, doc.Links(), 2)
+		}()
+	}
+	wg.Wait()
+
+	// Repeated calls return the cached values
+	links := doc.Links()
+	require.Same(t, links[0], doc.Links()[0])
+	require.Same(t, doc.Meta()[0], doc.Metadata().Tags[0])
+
+	// Values are not shared with clones
+	clone := doc.Clone()
+	clone.GoqueryDocument().Find("title").SetText("Changed")
+	require.Equal(t, "Changed", clone.Title())
+	require.Equal(t, "Title", doc.Title())
+}
+
+func BenchmarkDocument(b *testing.B) {
+	page := strings.Repeat(scanTestHTML, 20)
+	b.Run("NewDocument", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := NewDocument(page); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	doc, err := NewDocument(page)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Render", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := doc.Render(RenderOptions{ExcludeTags: []string{"nav"}}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Markdown", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := doc.Markdown(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Metadata", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			// A fresh document, as values are memoized
+			doc, _ := NewDocument(page)
+			doc.Metadata()
+			doc.Links()
+		}
+	})
+}
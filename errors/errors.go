@@ -28,6 +28,7 @@ func Unwrap(err error) error {
 // BadRequest represents a 400 error.
 type BadRequest struct {
 	Message string `json:"message"`
+	Field   string `json:"field,omitempty"` // path of the invalid field, if any
 }
 
 func (b *BadRequest) Error() string {
@@ -38,6 +39,15 @@ func NewBadRequest(message string, args ...any) *BadRequest {
 	return &BadRequest{Message: fmt.Sprintf(message, args...)}
 }
 
+// NewInvalidField returns a BadRequest for an invalid field of a request,
+// with a message prefixed by the field path, e.g. "actions[0].type: ...".
+func NewInvalidField(field, message string, args ...any) *BadRequest {
+	return &BadRequest{
+		Message: field + ": " + fmt.Sprintf(message, args...),
+		Field:   field,
+	}
+}
+
 // NotFound represents a 404 error.
 type NotFound struct {
 	Message string `json:"message"`
@@ -122,3 +132,8 @@ func IsRequestError(err error) bool {
 	_, ok := err.(*RequestError)
 	return ok
 }
+
+// Join wraps errors.Join
+func Join(errs ...error) error {
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// maxListedErrors limits the number of errors listed in the message of a
+// Multi error.
+const maxListedErrors = 10
+
+// Multi aggregates errors, such as the per-URL failures of a crawl. It
+// supports errors.Is and errors.As against any of the aggregated errors and
+// is safe for concurrent use.
+type Multi struct {
+	mutex sync.Mutex
+	errs  []error
+}
+
+// NewMulti creates a Multi containing the given non-nil errors.
+func NewMulti(errs ...error) *Multi {
+	m := &Multi{}
+	for _, err := range errs {
+		m.Append(err)
+	}
+	return m
+}
+
+// Append adds an error. Nil errors are ignored.
+func (m *Multi) Append(err error) {
+	if err == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.errs = append(m.errs, err)
+}
+
+// Errors returns a copy of the aggregated errors.
+func (m *Multi) Errors() []error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]error(nil), m.errs...)
+}
+
+// Len returns the number of aggregated errors.
+func (m *Multi) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.errs)
+}
+
+// ErrorOrNil returns the Multi if it holds any errors, or nil otherwise.
+func (m *Multi) ErrorOrNil() error {
+	if m == nil || m.Len() == 0 {
+		return nil
+	}
+	return m
+}
+
+func (m *Multi) Error() string {
+	errs := m.Errors()
+	switch len(errs) {
+	case 0:
+		return "no errors"
+	case 1:
+		return errs[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors occurred:", len(errs))
+	for i, err := range errs {
+		if i == maxListedErrors {
+			fmt.Fprintf(&b, "\n\t... and %d more", len(errs)-maxListedErrors)
+			break
+		}
+		fmt.Fprintf(&b, "\n\t* %s", err)
+	}
+	return b.String()
+}
+
+// Unwrap returns the aggregated errors, for use by errors.Is and errors.As.
+func (m *Multi) Unwrap() []error {
+	return m.Errors()
+}
@@ -0,0 +1,31 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMulti(t *testing.T) {
+	m := NewMulti(nil)
+	require.Nil(t, m.ErrorOrNil())
+
+	notFound := NewNotFound("missing")
+	m.Append(fmt.Errorf("first"))
+	m.Append(nil)
+	m.Append(fmt.Errorf("wrapped: %w", notFound))
+	require.Equal(t, 2, m.Len())
+	require.Equal(t, "2 errors occurred:\n\t* first\n\t* wrapped: missing", m.Error())
+
+	var target *NotFound
+	require.True(t, As(m.ErrorOrNil(), &target))
+	require.Same(t, notFound, target)
+	require.True(t, Is(m, notFound))
+
+	for i := 0; i < 20; i++ {
+		m.Append(fmt.Errorf("error %d", i))
+	}
+	require.True(t, strings.HasSuffix(m.Error(), "\n\t... and 12 more"))
+}
@@ -1,11 +1,32 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxBodySnippetSize is the maximum number of bytes of a response body kept
+// on a RequestError.
+const MaxBodySnippetSize = 1024
+
+// Phases of requests in which timeouts occur, as returned by
+// RequestError.TimeoutPhase.
+const (
+	TimeoutConnect = "connect" // establishing the connection
+	TimeoutHeaders = "headers" // awaiting the response headers
+	TimeoutBody    = "body"    // reading the response body
+)
 
 type RequestError struct {
-	err        error
-	statusCode int
-	rawURL     string
+	err          error
+	statusCode   int
+	rawURL       string
+	finalURL     string
+	headers      http.Header
+	bodySnippet  string
+	timeoutPhase string
 }
 
 func (r *RequestError) StatusCode() int {
@@ -16,6 +37,28 @@ func (r *RequestError) RawURL() string {
 	return r.rawURL
 }
 
+// FinalURL returns the URL of the response after any redirects.
+func (r *RequestError) FinalURL() string {
+	return r.finalURL
+}
+
+// Headers returns the response headers, if any.
+func (r *RequestError) Headers() http.Header {
+	return r.headers
+}
+
+// BodySnippet returns the start of the response body, truncated to
+// MaxBodySnippetSize bytes.
+func (r *RequestError) BodySnippet() string {
+	return r.bodySnippet
+}
+
+// TimeoutPhase returns the phase of the request that timed out, e.g.
+// TimeoutBody, or an empty string if the error is not a timeout.
+func (r *RequestError) TimeoutPhase() string {
+	return r.timeoutPhase
+}
+
 func (r *RequestError) Error() string {
 	return r.err.Error()
 }
@@ -41,3 +84,52 @@ func (r *RequestError) WithRawURL(rawURL string) *RequestError {
 	r.rawURL = rawURL
 	return r
 }
+
+func (r *RequestError) WithFinalURL(finalURL string) *RequestError {
+	r.finalURL = finalURL
+	return r
+}
+
+func (r *RequestError) WithTimeoutPhase(phase string) *RequestError {
+	r.timeoutPhase = phase
+	return r
+}
+
+func (r *RequestError) WithHeaders(headers http.Header) *RequestError {
+	r.headers = headers.Clone()
+	return r
+}
+
+// WithBody stores a snippet of the response body, truncated to
+// MaxBodySnippetSize bytes without splitting UTF-8 characters.
+func (r *RequestError) WithBody(body []byte) *RequestError {
+	r.bodySnippet = BodySnippet(body)
+	return r
+}
+
+// WithResponse records the status code, headers and final URL of a response,
+// along with a snippet of its body.
+func (r *RequestError) WithResponse(resp *http.Response, body []byte) *RequestError {
+	r.statusCode = resp.StatusCode
+	r.headers = resp.Header.Clone()
+	if resp.Request != nil && resp.Request.URL != nil {
+		r.finalURL = resp.Request.URL.String()
+	}
+	return r.WithBody(body)
+}
+
+// BodySnippet returns the start of a response body as text, truncated to
+// MaxBodySnippetSize bytes without splitting UTF-8 characters.
+func BodySnippet(body []byte) string {
+	if len(body) > MaxBodySnippetSize {
+		body = body[:MaxBodySnippetSize]
+		// Drop a character cut off by the truncation
+		for i := 0; i < utf8.UTFMax-1 && len(body) > 0; i++ {
+			if r, size := utf8.DecodeLastRune(body); r != utf8.RuneError || size != 1 {
+				break
+			}
+			body = body[:len(body)-1]
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,155 @@
+package web
+
+import (
+	"strings"
+	"time"
+)
+
+// EventTypes are the schema.org types treated as events.
+var EventTypes = []string{
+	"Event",
+	"BusinessEvent",
+	"ChildrensEvent",
+	"ComedyEvent",
+	"CourseInstance",
+	"DanceEvent",
+	"EducationEvent",
+	"ExhibitionEvent",
+	"Festival",
+	"FoodEvent",
+	"Hackathon",
+	"LiteraryEvent",
+	"MusicEvent",
+	"SaleEvent",
+	"ScreeningEvent",
+	"SocialEvent",
+	"SportsEvent",
+	"TheaterEvent",
+	"VisualArtsEvent",
+}
+
+// Offer is a price at which an item, such as an event ticket, is offered.
+type Offer struct {
+	Name         string    `json:"name,omitempty"`
+	Price        float64   `json:"price,omitempty"`
+	Currency     string    `json:"currency,omitempty"`
+	Availability string    `json:"availability,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	ValidFrom    time.Time `json:"valid_from,omitzero"`
+}
+
+// Venue is the location of an event, either a physical place or, for online
+// events, a virtual location with a URL.
+type Venue struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// Event contains schema.org Event data extracted from a page.
+type Event struct {
+	Type           string    `json:"type,omitempty"` // e.g. "MusicEvent"
+	Name           string    `json:"name,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	URL            string    `json:"url,omitempty"`
+	Images         []string  `json:"images,omitempty"`
+	StartDate      time.Time `json:"start_date,omitzero"`
+	EndDate        time.Time `json:"end_date,omitzero"`
+	Status         string    `json:"status,omitempty"`          // e.g. "EventScheduled"
+	AttendanceMode string    `json:"attendance_mode,omitempty"` // e.g. "OfflineEventAttendanceMode"
+	Venues         []*Venue  `json:"venues,omitempty"`
+	Organizer      string    `json:"organizer,omitempty"`
+	Performers     []string  `json:"performers,omitempty"`
+	Offers         []*Offer  `json:"offers,omitempty"`
+}
+
+// ExtractEvents returns the events described by the document's structured
+// data. Listing pages often describe several events.
+func ExtractEvents(doc *Document) []*Event {
+	var events []*Event
+	for _, item := range FindStructuredItems(doc.StructuredItems(), EventTypes...) {
+		event := &Event{
+			Type:           SchemaTypes(item)[0],
+			Name:           schemaString(item["name"]),
+			Description:    schemaString(item["description"]),
+			URL:            schemaString(item["url"]),
+			Images:         schemaURLs(item["image"]),
+			StartDate:      ParseTime(schemaString(item["startDate"])),
+			EndDate:        ParseTime(schemaString(item["endDate"])),
+			Status:         schemaEnum(item["eventStatus"]),
+			AttendanceMode: schemaEnum(item["eventAttendanceMode"]),
+			Venues:         schemaVenues(item["location"]),
+			Organizer:      schemaString(item["organizer"]),
+			Performers:     schemaStrings(item["performer"]),
+			Offers:         schemaOffers(item["offers"]),
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func schemaVenues(value any) []*Venue {
+	var venues []*Venue
+	switch v := value.(type) {
+	case string:
+		if s := NormalizeText(v); s != "" {
+			venues = append(venues, &Venue{Name: s})
+		}
+	case []any:
+		for _, item := range v {
+			venues = append(venues, schemaVenues(item)...)
+		}
+	case map[string]any:
+		venue := &Venue{
+			Name:    schemaString(v["name"]),
+			Address: schemaAddress(v["address"]),
+			URL:     schemaString(v["url"]),
+		}
+		if *venue != (Venue{}) {
+			venues = append(venues, venue)
+		}
+	}
+	return venues
+}
+
+// schemaAddress formats a PostalAddress, or returns a plain text address.
+func schemaAddress(value any) string {
+	address, ok := value.(map[string]any)
+	if !ok {
+		return schemaString(value)
+	}
+	var parts []string
+	for _, key := range []string{"streetAddress", "addressLocality", "addressRegion", "postalCode", "addressCountry"} {
+		if s := schemaString(address[key]); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func schemaOffers(value any) []*Offer {
+	var offers []*Offer
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			offers = append(offers, schemaOffers(item)...)
+		}
+	case map[string]any:
+		if nested, ok := v["offers"]; ok && v["price"] == nil {
+			return schemaOffers(nested)
+		}
+		offer := &Offer{
+			Name:         schemaString(v["name"]),
+			Price:        schemaNumber(v["price"]),
+			Currency:     strings.ToUpper(schemaString(v["priceCurrency"])),
+			Availability: schemaEnum(v["availability"]),
+			URL:          schemaString(v["url"]),
+			ValidFrom:    ParseTime(schemaString(v["validFrom"])),
+		}
+		if offer.Price == 0 {
+			offer.Price = schemaNumber(v["lowPrice"])
+		}
+		offers = append(offers, offer)
+	}
+	return offers
+}
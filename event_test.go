@@ -0,0 +1,63 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractEvents(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<script type="application/ld+json">
+			[{
+				"@context": "https://schema.org",
+				"@type": "MusicEvent",
+				"name": "Jazz Night",
+				"startDate": "2025-06-01T20:00:00-04:00",
+				"endDate": "2025-06-01T23:00",
+				"eventStatus": "https://schema.org/EventScheduled",
+				"eventAttendanceMode": "https://schema.org/OfflineEventAttendanceMode",
+				"location": {
+					"@type": "Place",
+					"name": "Blue Room",
+					"address": {"@type": "PostalAddress", "streetAddress": "1 Main St", "addressLocality": "Springfield", "postalCode": "12345"}
+				},
+				"performer": [{"@type": "MusicGroup", "name": "The Trio"}],
+				"offers": [
+					{"@type": "Offer", "name": "General", "price": "25.00", "priceCurrency": "usd", "availability": "https://schema.org/InStock"},
+					{"@type": "Offer", "name": "VIP", "price": 60, "priceCurrency": "USD", "availability": "https://schema.org/SoldOut"}
+				]
+			}, {
+				"@context": "https://schema.org",
+				"@type": "Event",
+				"name": "Webinar",
+				"startDate": "2025-07-01",
+				"location": {"@type": "VirtualLocation", "url": "https://example.com/live"}
+			}]
+			</script>
+		</head><body></body></html>
+	`)
+	require.NoError(t, err)
+
+	events := ExtractEvents(doc)
+	require.Len(t, events, 2)
+
+	jazz := events[0]
+	require.Equal(t, "MusicEvent", jazz.Type)
+	require.Equal(t, "Jazz Night", jazz.Name)
+	require.Equal(t, time.Date(2025, 6, 1, 20, 0, 0, 0, time.FixedZone("", -4*3600)).Unix(), jazz.StartDate.Unix())
+	require.Equal(t, time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC), jazz.EndDate)
+	require.Equal(t, "EventScheduled", jazz.Status)
+	require.Equal(t, "OfflineEventAttendanceMode", jazz.AttendanceMode)
+	require.Equal(t, []*Venue{{Name: "Blue Room", Address: "1 Main St, Springfield, 12345"}}, jazz.Venues)
+	require.Equal(t, []string{"The Trio"}, jazz.Performers)
+	require.Len(t, jazz.Offers, 2)
+	require.Equal(t, &Offer{Name: "General", Price: 25, Currency: "USD", Availability: "InStock"}, jazz.Offers[0])
+	require.Equal(t, "SoldOut", jazz.Offers[1].Availability)
+
+	webinar := events[1]
+	require.Equal(t, "Event", webinar.Type)
+	require.Equal(t, []*Venue{{URL: "https://example.com/live"}}, webinar.Venues)
+}
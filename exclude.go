@@ -0,0 +1,157 @@
+package web
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Names of the exclude profiles, the sets of selectors removed from HTML when
+// rendering. See RenderOptions.ExcludeProfile.
+const (
+	// ExcludeProfileStandard removes scripts, dialogs, forms, navigation and
+	// footers. It is used by OnlyMainContent unless another profile is set.
+	ExcludeProfileStandard = "standard"
+
+	// ExcludeProfileConservative only removes scripts, styles, embeds and
+	// dialogs, keeping navigation, footers and forms.
+	ExcludeProfileConservative = "conservative"
+
+	// ExcludeProfileAggressive removes headers, sidebars, breadcrumbs, share
+	// buttons and ads in addition to the standard selectors.
+	ExcludeProfileAggressive = "aggressive"
+
+	// ExcludeProfileArticle removes the comments, related links and
+	// newsletter forms that surround articles and blog posts.
+	ExcludeProfileArticle = "article"
+
+	// ExcludeProfileEcommerce removes the chrome of shops while keeping the
+	// variant selectors and forms of product pages.
+	ExcludeProfileEcommerce = "ecommerce"
+)
+
+// StandardExcludeTags contains the suggested tags to exclude from HTML.
+var StandardExcludeTags = []string{
+	`[role="dialog"]`,
+	`[aria-modal="true"]`,
+	`[id*="cookie"]`,
+	`[id*="popup"]`,
+	`[id*="modal"]`,
+	`[class*="modal"]`,
+	`[class*="dialog"]`,
+	"img[data-cookieconsent]",
+	"script",
+	"style",
+	"hr",
+	"noscript",
+	"iframe",
+	"select",
+	"input",
+	"button",
+	"svg",
+	"form",
+	"nav",
+	"footer",
+}
+
+// conservativeExcludeTags are the selectors that never hold page content.
+var conservativeExcludeTags = []string{
+	`[role="dialog"]`,
+	`[aria-modal="true"]`,
+	`[id*="cookie"]`,
+	"img[data-cookieconsent]",
+	"script",
+	"style",
+	"noscript",
+	"iframe",
+}
+
+// ExcludeProfiles maps the names of exclude profiles to their selectors.
+// Callers may register their own profiles before rendering.
+var ExcludeProfiles = map[string][]string{
+	ExcludeProfileStandard:     StandardExcludeTags,
+	ExcludeProfileConservative: conservativeExcludeTags,
+	ExcludeProfileAggressive: concatTags(StandardExcludeTags,
+		"header",
+		"aside",
+		`[role="navigation"]`,
+		`[role="banner"]`,
+		`[role="contentinfo"]`,
+		`[role="complementary"]`,
+		`[class*="sidebar"]`,
+		`[id*="sidebar"]`,
+		`[class*="breadcrumb"]`,
+		`[class*="share"]`,
+		`[class*="social"]`,
+		`[class*="newsletter"]`,
+		`[class*="advert"]`,
+		`[id*="advert"]`,
+	),
+	ExcludeProfileArticle: concatTags(StandardExcludeTags,
+		"aside",
+		`[role="complementary"]`,
+		`[class*="related"]`,
+		`[class*="comment"]`,
+		`[id*="comments"]`,
+		`[class*="share"]`,
+		`[class*="newsletter"]`,
+		`[class*="subscribe"]`,
+		`[class*="advert"]`,
+	),
+	ExcludeProfileEcommerce: concatTags(conservativeExcludeTags,
+		`[id*="popup"]`,
+		`[class*="modal"]`,
+		"nav",
+		"footer",
+		"input[type=hidden]",
+		`[class*="cart-drawer"]`,
+		`[class*="newsletter"]`,
+		`[class*="recently-viewed"]`,
+	),
+}
+
+func concatTags(tags []string, more ...string) []string {
+	return append(slices.Clone(tags), more...)
+}
+
+// ExcludeProfileNames returns the sorted names of the exclude profiles.
+func ExcludeProfileNames() []string {
+	names := make([]string, 0, len(ExcludeProfiles))
+	for name := range ExcludeProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExcludeSelectors returns the selectors removed from HTML with the options:
+// those of the exclude profile, if any or if only the main content is kept,
+// and the ExcludeTags, minus the KeepTags. Returns an error if the profile
+// is unknown.
+func (opts RenderOptions) ExcludeSelectors() ([]string, error) {
+	var selectors []string
+	profile := opts.ExcludeProfile
+	if profile == "" && opts.OnlyMainContent {
+		profile = ExcludeProfileStandard
+	}
+	if profile != "" {
+		tags, ok := ExcludeProfiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown exclude profile %q", profile)
+		}
+		selectors = append(selectors, tags...)
+	}
+	selectors = append(selectors, opts.ExcludeTags...)
+	seen := map[string]bool{}
+	for _, tag := range opts.KeepTags {
+		seen[tag] = true
+	}
+	result := selectors[:0]
+	for _, tag := range selectors {
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package web
+
+// FAQItem is a question and its accepted answer from a schema.org FAQPage.
+type FAQItem struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// ExtractFAQ returns the questions and answers of the FAQPage described by the
+// document's structured data. Answers containing HTML are converted to plain
+// text. Questions without an answer are skipped.
+func ExtractFAQ(doc *Document) []*FAQItem {
+	var faq []*FAQItem
+	for _, page := range FindStructuredItems(doc.StructuredItems(), "FAQPage") {
+		for _, question := range FindStructuredItems([]map[string]any{page}, "Question") {
+			item := &FAQItem{Question: schemaString(question["name"])}
+			answer := question["acceptedAnswer"]
+			if answer == nil {
+				answer = question["suggestedAnswer"]
+			}
+			if answers, ok := answer.([]any); ok && len(answers) > 0 {
+				answer = answers[0]
+			}
+			if a, ok := answer.(map[string]any); ok {
+				item.Answer = schemaHTMLText(a["text"])
+			}
+			if item.Question != "" && item.Answer != "" {
+				faq = append(faq, item)
+			}
+		}
+	}
+	return faq
+}
@@ -0,0 +1,172 @@
+package web
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Icon sources, in order of preference when icons are otherwise equal.
+const (
+	IconSourceLink     = "link"
+	IconSourceManifest = "manifest"
+	IconSourceFallback = "fallback"
+)
+
+// Icon is a candidate icon for a site.
+type Icon struct {
+	URL    string `json:"url"`
+	Rel    string `json:"rel,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Sizes  string `json:"sizes,omitempty"`
+	Width  int    `json:"width,omitempty"`  // Largest declared width, if any
+	Height int    `json:"height,omitempty"` // Largest declared height, if any
+	Any    bool   `json:"any,omitempty"`    // Scalable icon, e.g. SVG
+	Source string `json:"source"`
+}
+
+// iconRels lists the link relations that declare icons.
+var iconRels = map[string]bool{
+	"icon":                         true,
+	"shortcut":                     true,
+	"apple-touch-icon":             true,
+	"apple-touch-icon-precomposed": true,
+	"mask-icon":                    true,
+	"fluid-icon":                   true,
+}
+
+// ResolveFavicon returns the icon candidates for the document, best first.
+// All icon link variants are considered, including apple-touch-icon and
+// Safari mask icons, with the conventional /favicon.ico of the site as a
+// final fallback. Relative URLs are resolved against the base element of
+// the document, if any, or else against the base URL.
+func ResolveFavicon(doc *Document, baseURL string) []*Icon {
+	return ResolveFaviconWithManifest(doc, baseURL, nil)
+}
+
+// ResolveFaviconWithManifest returns the icon candidates for the document
+// as ResolveFavicon does, ranked along with the icons of its web app
+// manifest, if not nil.
+func ResolveFaviconWithManifest(doc *Document, baseURL string, manifest *Manifest) []*Icon {
+	site, _ := url.Parse(baseURL)
+	base, _ := url.Parse(ResolveBase(baseURL, doc.BaseURL()))
+	var icons []*Icon
+	doc.doc.Find("link[rel][href]").Each(func(i int, s *goquery.Selection) {
+		rel := strings.ToLower(strings.Join(strings.Fields(s.AttrOr("rel", "")), " "))
+		isIcon := false
+		for _, token := range strings.Fields(rel) {
+			if iconRels[token] {
+				isIcon = true
+			}
+		}
+		if !isIcon {
+			return
+		}
+		href, ok := resolveIconURL(base, s.AttrOr("href", ""))
+		if !ok {
+			return
+		}
+		icon := &Icon{
+			URL:    href,
+			Rel:    rel,
+			Type:   strings.TrimSpace(s.AttrOr("type", "")),
+			Source: IconSourceLink,
+		}
+		icon.SetSizes(s.AttrOr("sizes", ""))
+		if icon.Type == "image/svg+xml" || strings.HasSuffix(strings.ToLower(href), ".svg") {
+			icon.Any = true
+		}
+		icons = append(icons, icon)
+	})
+	if manifest != nil {
+		icons = append(icons, manifest.ResolveIcons()...)
+	}
+	if site != nil && site.Host != "" {
+		fallback := &url.URL{Scheme: site.Scheme, Host: site.Host, Path: "/favicon.ico"}
+		icons = append(icons, &Icon{
+			URL:    fallback.String(),
+			Rel:    "icon",
+			Type:   "image/x-icon",
+			Source: IconSourceFallback,
+		})
+	}
+	return RankIcons(icons)
+}
+
+// SetSizes parses a sizes attribute such as "16x16 32x32" or "any", keeping
+// the largest declared size.
+func (icon *Icon) SetSizes(sizes string) {
+	icon.Sizes = strings.TrimSpace(sizes)
+	for _, size := range strings.Fields(strings.ToLower(icon.Sizes)) {
+		if size == "any" {
+			icon.Any = true
+			continue
+		}
+		w, h, ok := strings.Cut(size, "x")
+		if !ok {
+			continue
+		}
+		width, err1 := strconv.Atoi(w)
+		height, err2 := strconv.Atoi(h)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if width*height > icon.Width*icon.Height {
+			icon.Width, icon.Height = width, height
+		}
+	}
+}
+
+// RankIcons deduplicates icons by URL and sorts them best first: scalable
+// icons, then larger declared sizes, then by source. Monochrome mask icons
+// are ranked after all others since they are meant to be tinted.
+func RankIcons(icons []*Icon) []*Icon {
+	seen := map[string]bool{}
+	var ranked []*Icon
+	for _, icon := range icons {
+		if seen[icon.URL] {
+			continue
+		}
+		seen[icon.URL] = true
+		ranked = append(ranked, icon)
+	}
+	sourceRank := map[string]int{IconSourceLink: 0, IconSourceManifest: 1, IconSourceFallback: 2}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if aMask, bMask := a.Rel == "mask-icon", b.Rel == "mask-icon"; aMask != bMask {
+			return bMask
+		}
+		if aFallback, bFallback := a.Source == IconSourceFallback, b.Source == IconSourceFallback; aFallback != bFallback {
+			return bFallback
+		}
+		if a.Any != b.Any {
+			return a.Any
+		}
+		if a.Width*a.Height != b.Width*b.Height {
+			return a.Width*a.Height > b.Width*b.Height
+		}
+		return sourceRank[a.Source] < sourceRank[b.Source]
+	})
+	return ranked
+}
+
+func resolveIconURL(base *url.URL, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return "", false
+	}
+	if strings.HasPrefix(href, "data:") {
+		return href, true
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	return u.String(), true
+}
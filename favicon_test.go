@@ -0,0 +1,115 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFavicon(t *testing.T) {
+	doc, err := NewDocument(`
+		<html>
+			<head>
+				<link rel="shortcut icon" href="/favicon-16.png" sizes="16x16">
+				<link rel="icon" href="/favicon-32.png" sizes="16x16 32x32" type="image/png">
+				<link rel="apple-touch-icon" href="https://cdn.example.com/touch.png" sizes="180x180">
+				<link rel="mask-icon" href="/mask.svg" color="#000">
+				<link rel="stylesheet" href="/style.css">
+			</head>
+		</html>
+	`)
+	require.NoError(t, err)
+
+	icons := ResolveFavicon(doc, "https://example.com/blog/post")
+	var urls []string
+	for _, icon := range icons {
+		urls = append(urls, icon.URL)
+	}
+	require.Equal(t, []string{
+		"https://cdn.example.com/touch.png",
+		"https://example.com/favicon-32.png",
+		"https://example.com/favicon-16.png",
+		"https://example.com/favicon.ico",
+		"https://example.com/mask.svg",
+	}, urls)
+	require.Equal(t, 180, icons[0].Width)
+	require.Equal(t, 32, icons[1].Width)
+	require.Equal(t, "16x16 32x32", icons[1].Sizes)
+	require.Equal(t, IconSourceFallback, icons[3].Source)
+}
+
+func TestResolveFavicon_Fallback(t *testing.T) {
+	doc, err := NewDocument(`<html><head><title>No icons</title></head></html>`)
+	require.NoError(t, err)
+
+	icons := ResolveFavicon(doc, "http://example.com/page")
+	require.Len(t, icons, 1)
+	require.Equal(t, "http://example.com/favicon.ico", icons[0].URL)
+}
+
+func TestResolveFavicon_BaseElement(t *testing.T) {
+	doc, err := NewDocument(`
+		<html>
+			<head>
+				<base href="https://cdn.example.com/assets/">
+				<link rel="icon" href="favicon.png" sizes="32x32">
+			</head>
+		</html>
+	`)
+	require.NoError(t, err)
+
+	// Relative icons resolve against the base element, while the fallback
+	// stays on the site of the page
+	icons := ResolveFavicon(doc, "https://example.com/blog/post")
+	require.Len(t, icons, 2)
+	require.Equal(t, "https://cdn.example.com/assets/favicon.png", icons[0].URL)
+	require.Equal(t, "https://example.com/favicon.ico", icons[1].URL)
+}
+
+func TestResolveFavicon_Manifest(t *testing.T) {
+	doc, err := NewDocument(`
+		<html>
+			<head>
+				<link rel="icon" href="/favicon-32.png" sizes="32x32">
+				<link rel="apple-touch-icon" href="/icons/192.png" sizes="180x180">
+				<link rel="manifest" href="/manifest.json">
+			</head>
+		</html>
+	`)
+	require.NoError(t, err)
+	manifest, err := ParseManifest([]byte(`{
+		"icons": [
+			{"src": "/icons/192.png", "sizes": "192x192", "type": "image/png"},
+			{"src": "/icons/512.png", "sizes": "512x512", "type": "image/png"},
+			{"src": "/icons/mono.png", "sizes": "1024x1024", "purpose": "monochrome"}
+		]
+	}`), "https://example.com/manifest.json")
+	require.NoError(t, err)
+
+	// The manifest icons are ranked with the linked icons, which are kept
+	// when both declare the same URL
+	icons := ResolveFaviconWithManifest(doc, "https://example.com/", manifest)
+	var urls []string
+	for _, icon := range icons {
+		urls = append(urls, icon.URL)
+	}
+	require.Equal(t, []string{
+		"https://example.com/icons/512.png",
+		"https://example.com/icons/192.png",
+		"https://example.com/favicon-32.png",
+		"https://example.com/favicon.ico",
+		"https://example.com/icons/mono.png",
+	}, urls)
+	require.Equal(t, IconSourceManifest, icons[0].Source)
+	require.Equal(t, IconSourceLink, icons[1].Source)
+	require.Equal(t, 180, icons[1].Width)
+}
+
+func TestRankIcons_Scalable(t *testing.T) {
+	icon := &Icon{URL: "/icon.svg", Source: IconSourceLink}
+	icon.SetSizes("any")
+	large := &Icon{URL: "/icon-512.png", Source: IconSourceManifest}
+	large.SetSizes("512x512")
+	ranked := RankIcons([]*Icon{large, icon, large})
+	require.Equal(t, []*Icon{icon, large}, ranked)
+}
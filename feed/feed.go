@@ -0,0 +1,238 @@
+// Package feed discovers the RSS, Atom and JSON feeds of pages and parses
+// them into a common Feed structure.
+//
+//	for _, link := range feed.Discover(doc, pageURL) {
+//		...
+//		f, err := feed.Parse(data, link.URL)
+//		for _, item := range f.Items {
+//			fmt.Println(item.Title, item.URL)
+//		}
+//	}
+package feed
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+)
+
+// Formats of feeds.
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+// Feed is a parsed RSS, Atom or JSON feed.
+type Feed struct {
+	Format      string    `json:"format"` // FormatRSS, FormatAtom or FormatJSON
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	URL         string    `json:"url,omitempty"`      // home page of the feed
+	FeedURL     string    `json:"feed_url,omitempty"` // URL of the feed itself
+	Language    string    `json:"language,omitempty"`
+	Image       string    `json:"image,omitempty"`
+	Updated     time.Time `json:"updated,omitzero"`
+	Items       []*Item   `json:"items,omitempty"`
+}
+
+// Item is an entry of a feed, e.g. an article or a podcast episode.
+type Item struct {
+	ID         string       `json:"id,omitempty"`
+	Title      string       `json:"title,omitempty"`
+	URL        string       `json:"url,omitempty"`
+	Summary    string       `json:"summary,omitempty"` // plain text
+	Content    string       `json:"content,omitempty"` // HTML
+	Authors    []string     `json:"authors,omitempty"`
+	Categories []string     `json:"categories,omitempty"`
+	Image      string       `json:"image,omitempty"`
+	Published  time.Time    `json:"published,omitzero"`
+	Updated    time.Time    `json:"updated,omitzero"`
+	Enclosures []*Enclosure `json:"enclosures,omitempty"`
+}
+
+// Enclosure is a file attached to an item, e.g. the audio of a podcast.
+type Enclosure struct {
+	URL    string `json:"url"`
+	Type   string `json:"type,omitempty"`
+	Length int64  `json:"length,omitempty"` // in bytes
+}
+
+// ErrUnknownFormat is returned by Parse for data that is not a feed.
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// Parse parses an RSS 2.0, RSS 1.0, Atom or JSON feed, detected from its
+// content. The feed URL is used to resolve relative URLs and may be empty.
+// Summaries are converted to plain text, while contents are kept as HTML.
+func Parse(data []byte, feedURL string) (*Feed, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("\xef\xbb\xbf"))
+	var feed *Feed
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte("{")):
+		feed, err = parseJSON(data)
+	case bytes.HasPrefix(data, []byte("<")):
+		feed, err = parseXML(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if feed.FeedURL == "" {
+		feed.FeedURL = feedURL
+	}
+	feed.resolve(feedURL)
+	return feed, nil
+}
+
+// resolve makes the URLs of the feed absolute, relative to the feed URL.
+func (f *Feed) resolve(feedURL string) {
+	base, err := url.Parse(feedURL)
+	if err != nil || feedURL == "" {
+		return
+	}
+	resolve := func(value *string) {
+		if *value == "" {
+			return
+		}
+		if u, err := base.Parse(*value); err == nil {
+			*value = u.String()
+		}
+	}
+	resolve(&f.URL)
+	resolve(&f.FeedURL)
+	resolve(&f.Image)
+	for _, item := range f.Items {
+		resolve(&item.URL)
+		resolve(&item.Image)
+		for _, enclosure := range item.Enclosures {
+			resolve(&enclosure.URL)
+		}
+	}
+}
+
+// Link is a feed linked by a page.
+type Link struct {
+	URL    string `json:"url"`
+	Title  string `json:"title,omitempty"`
+	Type   string `json:"type"`   // MIME type, e.g. "application/rss+xml"
+	Format string `json:"format"` // FormatRSS, FormatAtom or FormatJSON
+}
+
+// linkFormats maps the MIME types of feed links to their formats.
+var linkFormats = map[string]string{
+	"application/rss+xml":   FormatRSS,
+	"application/rdf+xml":   FormatRSS,
+	"application/atom+xml":  FormatAtom,
+	"application/feed+json": FormatJSON,
+	"application/json":      FormatJSON,
+}
+
+// Discover returns the feeds linked by a document with
+// <link rel="alternate"> elements, resolved against the page URL. JSON
+// links are only feeds when their title or URL mentions a feed, as the type
+// is also used for other alternates.
+func Discover(doc *web.Document, pageURL string) []*Link {
+	base := web.ResolveBase(pageURL, doc.BaseURL())
+	seen := map[string]bool{}
+	var links []*Link
+	for _, node := range doc.GoqueryDocument().Find("link[href]").Nodes {
+		var rel, typ, href, title string
+		for _, attr := range node.Attr {
+			switch attr.Key {
+			case "rel":
+				rel = strings.ToLower(attr.Val)
+			case "type":
+				typ, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(attr.Val)), ";")
+			case "href":
+				href = strings.TrimSpace(attr.Val)
+			case "title":
+				title = web.NormalizeText(attr.Val)
+			}
+		}
+		format, ok := linkFormats[typ]
+		if !ok || !hasToken(rel, "alternate") {
+			continue
+		}
+		if typ == "application/json" && !strings.Contains(strings.ToLower(title+" "+href), "feed") {
+			continue
+		}
+		resolved, ok := web.ResolveLink(base, href)
+		if !ok || seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+		links = append(links, &Link{URL: resolved, Title: title, Type: typ, Format: format})
+	}
+	return links
+}
+
+// hasToken returns true if a space separated list contains a token.
+func hasToken(list, token string) bool {
+	for _, value := range strings.Fields(list) {
+		if value == token {
+			return true
+		}
+	}
+	return false
+}
+
+// dateLayouts are the layouts of the dates of feeds: RFC 822 dates of RSS,
+// with their common variants, and RFC 3339 dates of Atom and JSON feeds.
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseDate parses a date of a feed, or returns the zero time.
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// plainText converts the HTML of summaries to plain text.
+func plainText(value string) string {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "<") {
+		return web.NormalizeText(value)
+	}
+	doc, err := web.NewDocument(value)
+	if err != nil {
+		return web.NormalizeText(value)
+	}
+	text, _ := doc.Text(web.TextOptions{})
+	return text
+}
+
+// firstNonEmpty returns the first of the values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
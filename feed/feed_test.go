@@ -0,0 +1,232 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_RSS(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:media="http://search.yahoo.com/mrss/"
+	xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+	<title>Example &amp; Co</title>
+	<link>https://example.com/</link>
+	<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+	<description>News of &lt;b&gt;Example&lt;/b&gt;</description>
+	<language>en-us</language>
+	<lastBuildDate>Tue, 10 Jun 2025 04:00:00 GMT</lastBuildDate>
+	<item>
+		<title>First post</title>
+		<link>/posts/first</link>
+		<guid isPermaLink="false">post-1</guid>
+		<description>&lt;p&gt;A &lt;em&gt;short&lt;/em&gt; summary&lt;/p&gt;</description>
+		<content:encoded><![CDATA[<p>The full content</p>]]></content:encoded>
+		<dc:creator>Ada</dc:creator>
+		<category>News</category>
+		<category> Go </category>
+		<pubDate>Mon, 9 Jun 2025 08:30:00 +0200</pubDate>
+		<enclosure url="/audio/1.mp3" type="audio/mpeg" length="1024"/>
+		<media:content url="https://cdn.example.com/1.jpg" medium="image"/>
+	</item>
+	<item>
+		<guid>https://example.com/posts/second</guid>
+		<description>Plain text</description>
+	</item>
+</channel>
+</rss>`
+	feed, err := Parse([]byte(data), "https://example.com/feed")
+	require.NoError(t, err)
+
+	require.Equal(t, FormatRSS, feed.Format)
+	require.Equal(t, "Example & Co", feed.Title)
+	require.Equal(t, "News of Example", feed.Description)
+	require.Equal(t, "https://example.com/", feed.URL)
+	require.Equal(t, "https://example.com/feed.xml", feed.FeedURL)
+	require.Equal(t, "en-us", feed.Language)
+	require.Equal(t, time.Date(2025, 6, 10, 4, 0, 0, 0, time.UTC), feed.Updated.UTC())
+	require.Len(t, feed.Items, 2)
+
+	item := feed.Items[0]
+	require.Equal(t, "post-1", item.ID)
+	require.Equal(t, "First post", item.Title)
+	require.Equal(t, "https://example.com/posts/first", item.URL)
+	require.Equal(t, "A short summary", item.Summary)
+	require.Equal(t, "<p>The full content</p>", item.Content)
+	require.Equal(t, []string{"Ada"}, item.Authors)
+	require.Equal(t, []string{"News", "Go"}, item.Categories)
+	require.Equal(t, "https://cdn.example.com/1.jpg", item.Image)
+	require.Equal(t, time.Date(2025, 6, 9, 6, 30, 0, 0, time.UTC), item.Published.UTC())
+	require.Equal(t, []*Enclosure{
+		{URL: "https://example.com/audio/1.mp3", Type: "audio/mpeg", Length: 1024},
+	}, item.Enclosures)
+
+	item = feed.Items[1]
+	require.Equal(t, "https://example.com/posts/second", item.URL)
+	require.Equal(t, "Plain text", item.Summary)
+	require.Empty(t, item.Content)
+}
+
+func TestParse_RSS1(t *testing.T) {
+	data := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel rdf:about="https://example.com/">
+		<title>Caf` + "\xe9" + `</title>
+		<link>https://example.com/</link>
+	</channel>
+	<item rdf:about="https://example.com/a">
+		<title>A</title>
+		<link>https://example.com/a</link>
+		<dc:date>2025-06-09T08:30:00Z</dc:date>
+	</item>
+</rdf:RDF>`
+	feed, err := Parse([]byte(data), "")
+	require.NoError(t, err)
+	require.Equal(t, FormatRSS, feed.Format)
+	require.Equal(t, "Café", feed.Title)
+	require.Len(t, feed.Items, 1)
+	require.Equal(t, "https://example.com/a", feed.Items[0].ID)
+	require.Equal(t, "https://example.com/a", feed.Items[0].URL)
+	require.Equal(t, time.Date(2025, 6, 9, 8, 30, 0, 0, time.UTC), feed.Items[0].Published)
+}
+
+func TestParse_Atom(t *testing.T) {
+	data := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="fr">
+	<title type="text">Example</title>
+	<subtitle type="html">&lt;i&gt;All&lt;/i&gt; the news</subtitle>
+	<link href="https://example.com/"/>
+	<link rel="self" href="/atom.xml"/>
+	<updated>2025-06-10T04:00:00Z</updated>
+	<entry>
+		<id>tag:example.com,2025:1</id>
+		<title type="html">Fish &amp;amp; chips</title>
+		<link rel="alternate" type="text/html" href="/posts/1"/>
+		<link rel="enclosure" type="video/mp4" href="/video/1.mp4"/>
+		<updated>2025-06-09T08:30:00Z</updated>
+		<author><name>Ada</name></author>
+		<author><name>Alan</name></author>
+		<category term="go" label="Go"/>
+		<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Hello <b>world</b></p></div></content>
+	</entry>
+	<entry>
+		<title>Second &lt;post&gt;</title>
+		<link href="https://example.com/posts/2"/>
+		<published>2025-06-08T08:30:00+02:00</published>
+		<summary>1 &lt; 2</summary>
+		<content type="text">1 &lt; 2 &amp; 3</content>
+	</entry>
+</feed>`
+	feed, err := Parse([]byte(data), "https://example.com/feeds/atom")
+	require.NoError(t, err)
+
+	require.Equal(t, FormatAtom, feed.Format)
+	require.Equal(t, "Example", feed.Title)
+	require.Equal(t, "All the news", feed.Description)
+	require.Equal(t, "https://example.com/", feed.URL)
+	require.Equal(t, "https://example.com/atom.xml", feed.FeedURL)
+	require.Equal(t, "fr", feed.Language)
+	require.Len(t, feed.Items, 2)
+
+	item := feed.Items[0]
+	require.Equal(t, "tag:example.com,2025:1", item.ID)
+	require.Equal(t, "Fish & chips", item.Title)
+	require.Equal(t, "https://example.com/posts/1", item.URL)
+	require.Contains(t, item.Content, "<b>world</b>")
+	require.Equal(t, "Hello world", item.Summary)
+	require.Equal(t, []string{"Ada", "Alan"}, item.Authors)
+	require.Equal(t, []string{"Go"}, item.Categories)
+	require.Equal(t, time.Date(2025, 6, 9, 8, 30, 0, 0, time.UTC), item.Published)
+	require.Equal(t, []*Enclosure{{URL: "https://example.com/video/1.mp4", Type: "video/mp4"}}, item.Enclosures)
+
+	item = feed.Items[1]
+	require.Equal(t, "Second <post>", item.Title)
+	require.Equal(t, "1 < 2", item.Summary)
+	require.Equal(t, "1 &lt; 2 &amp; 3", item.Content)
+	require.Equal(t, time.Date(2025, 6, 8, 6, 30, 0, 0, time.UTC), item.Published.UTC())
+}
+
+func TestParse_JSON(t *testing.T) {
+	data := `{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "Example",
+		"home_page_url": "https://example.com/",
+		"feed_url": "https://example.com/feed.json",
+		"authors": [{"name": "Ada"}],
+		"items": [
+			{
+				"id": 1,
+				"url": "/posts/1",
+				"title": "First",
+				"content_html": "<p>Hello <b>world</b></p>",
+				"date_published": "2025-06-09T08:30:00Z",
+				"tags": ["go"],
+				"attachments": [{"url": "/1.mp3", "mime_type": "audio/mpeg", "size_in_bytes": 42}]
+			},
+			{
+				"id": "2",
+				"external_url": "https://other.com/2",
+				"summary": "Elsewhere",
+				"author": {"name": "Alan"}
+			}
+		]
+	}`
+	feed, err := Parse([]byte(data), "https://example.com/feed")
+	require.NoError(t, err)
+
+	require.Equal(t, FormatJSON, feed.Format)
+	require.Equal(t, "https://example.com/feed.json", feed.FeedURL)
+	require.Len(t, feed.Items, 2)
+
+	require.Equal(t, &Item{
+		ID:         "1",
+		Title:      "First",
+		URL:        "https://example.com/posts/1",
+		Summary:    "Hello world",
+		Content:    "<p>Hello <b>world</b></p>",
+		Authors:    []string{"Ada"},
+		Categories: []string{"go"},
+		Published:  time.Date(2025, 6, 9, 8, 30, 0, 0, time.UTC),
+		Enclosures: []*Enclosure{{URL: "https://example.com/1.mp3", Type: "audio/mpeg", Length: 42}},
+	}, feed.Items[0])
+	require.Equal(t, "https://other.com/2", feed.Items[1].URL)
+	require.Equal(t, "Elsewhere", feed.Items[1].Summary)
+	require.Equal(t, []string{"Alan"}, feed.Items[1].Authors)
+}
+
+func TestParse_UnknownFormat(t *testing.T) {
+	for _, data := range []string{"", "hello", `<html><body>Hi</body></html>`, `{"title": "Not a feed"}`} {
+		_, err := Parse([]byte(data), "")
+		require.ErrorIs(t, err, ErrUnknownFormat, data)
+	}
+	_, err := Parse([]byte("<rss><channel>"), "")
+	require.Error(t, err)
+}
+
+func TestDiscover(t *testing.T) {
+	doc, err := web.NewDocument(`<html><head>
+		<base href="https://example.com/blog/">
+		<link rel="alternate" type="application/rss+xml" title="RSS" href="rss.xml">
+		<link rel="alternate" type="application/atom+xml; charset=utf-8" href="/atom.xml">
+		<link rel="Alternate feed" type="application/feed+json" href="feed.json">
+		<link rel="alternate" type="application/json" href="/wp-json/wp/v2/pages/1">
+		<link rel="alternate" type="application/json" title="JSON Feed" href="/feed/json">
+		<link rel="alternate" type="application/rss+xml" href="rss.xml">
+		<link rel="alternate" hreflang="fr" href="/fr/">
+		<link rel="stylesheet" type="application/rss+xml" href="/nope.xml">
+	</head><body></body></html>`)
+	require.NoError(t, err)
+
+	links := Discover(doc, "https://example.com/blog/post")
+	require.Equal(t, []*Link{
+		{URL: "https://example.com/blog/rss.xml", Title: "RSS", Type: "application/rss+xml", Format: FormatRSS},
+		{URL: "https://example.com/atom.xml", Type: "application/atom+xml", Format: FormatAtom},
+		{URL: "https://example.com/blog/feed.json", Type: "application/feed+json", Format: FormatJSON},
+		{URL: "https://example.com/feed/json", Title: "JSON Feed", Type: "application/json", Format: FormatJSON},
+	}, links)
+}
@@ -0,0 +1,107 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonFeed is a JSON Feed, as specified by jsonfeed.org. Version 1.0 feeds
+// have a single author.
+type jsonFeed struct {
+	Version     string        `json:"version"`
+	Title       string        `json:"title"`
+	HomePageURL string        `json:"home_page_url"`
+	FeedURL     string        `json:"feed_url"`
+	Description string        `json:"description"`
+	Icon        string        `json:"icon"`
+	Language    string        `json:"language"`
+	Items       []*jsonItem   `json:"items"`
+	Author      *jsonAuthor   `json:"author"`
+	Authors     []*jsonAuthor `json:"authors"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonAttachment struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size_in_bytes"`
+}
+
+type jsonItem struct {
+	ID            any               `json:"id"` // a string, or a number in some feeds
+	URL           string            `json:"url"`
+	ExternalURL   string            `json:"external_url"`
+	Title         string            `json:"title"`
+	ContentHTML   string            `json:"content_html"`
+	ContentText   string            `json:"content_text"`
+	Summary       string            `json:"summary"`
+	Image         string            `json:"image"`
+	BannerImage   string            `json:"banner_image"`
+	DatePublished string            `json:"date_published"`
+	DateModified  string            `json:"date_modified"`
+	Author        *jsonAuthor       `json:"author"`
+	Authors       []*jsonAuthor     `json:"authors"`
+	Tags          []string          `json:"tags"`
+	Attachments   []*jsonAttachment `json:"attachments"`
+}
+
+// parseJSON parses a JSON Feed.
+func parseJSON(data []byte) (*Feed, error) {
+	var doc jsonFeed
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid feed: %w", err)
+	}
+	if !strings.Contains(doc.Version, "jsonfeed.org") {
+		return nil, ErrUnknownFormat
+	}
+	feed := &Feed{
+		Format:      FormatJSON,
+		Title:       plainText(doc.Title),
+		Description: plainText(doc.Description),
+		URL:         strings.TrimSpace(doc.HomePageURL),
+		FeedURL:     strings.TrimSpace(doc.FeedURL),
+		Language:    strings.TrimSpace(doc.Language),
+		Image:       strings.TrimSpace(doc.Icon),
+	}
+	for _, i := range doc.Items {
+		item := &Item{
+			Title:      plainText(i.Title),
+			URL:        firstNonEmpty(i.URL, i.ExternalURL),
+			Summary:    plainText(firstNonEmpty(i.Summary, i.ContentText, i.ContentHTML)),
+			Content:    strings.TrimSpace(i.ContentHTML),
+			Categories: trimAll(i.Tags),
+			Image:      firstNonEmpty(i.Image, i.BannerImage),
+			Published:  parseDate(i.DatePublished),
+			Updated:    parseDate(i.DateModified),
+		}
+		if i.ID != nil {
+			item.ID = strings.TrimSpace(fmt.Sprint(i.ID))
+		}
+		authors := i.Authors
+		if len(authors) == 0 && i.Author != nil {
+			authors = []*jsonAuthor{i.Author}
+		}
+		if len(authors) == 0 {
+			authors = doc.Authors
+		}
+		if len(authors) == 0 && doc.Author != nil {
+			authors = []*jsonAuthor{doc.Author}
+		}
+		for _, author := range authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				item.Authors = append(item.Authors, name)
+			}
+		}
+		for _, attachment := range i.Attachments {
+			if url := strings.TrimSpace(attachment.URL); url != "" {
+				item.Enclosures = append(item.Enclosures, &Enclosure{URL: url, Type: attachment.MimeType, Length: attachment.Size})
+			}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed, nil
+}
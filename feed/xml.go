@@ -0,0 +1,400 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// XML namespaces of the elements of feeds that share a local name.
+const (
+	namespaceAtom    = "http://www.w3.org/2005/Atom"
+	namespaceContent = "http://purl.org/rss/1.0/modules/content/"
+	namespaceDC      = "http://purl.org/dc/elements/1.1/"
+	namespaceMedia   = "http://search.yahoo.com/mrss/"
+	namespaceITunes  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+)
+
+// xmlText is an element of which only the text is used.
+type xmlText struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+}
+
+// xmlLink is a link element: an RSS link with the URL as text, or an Atom
+// link with the URL as href attribute.
+type xmlLink struct {
+	XMLName xml.Name
+	Href    string `xml:"href,attr"`
+	Rel     string `xml:"rel,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// xmlMedia is an element referencing a file, e.g. an RSS enclosure or a
+// media:content element.
+type xmlMedia struct {
+	XMLName xml.Name
+	URL     string `xml:"url,attr"`
+	Href    string `xml:"href,attr"` // itunes:image
+	Type    string `xml:"type,attr"`
+	Medium  string `xml:"medium,attr"`
+	Length  string `xml:"length,attr"`
+}
+
+type rssChannel struct {
+	Title       string     `xml:"title"`
+	Links       []xmlLink  `xml:"link"`
+	Description string     `xml:"description"`
+	Language    string     `xml:"language"`
+	PubDate     string     `xml:"pubDate"`
+	BuildDate   string     `xml:"lastBuildDate"`
+	Dates       []xmlText  `xml:"date"`
+	Image       rssImage   `xml:"image"`
+	Items       []*rssItem `xml:"item"`
+}
+
+type rssImage struct {
+	URL string `xml:"url"`
+}
+
+type rssItem struct {
+	Title       string     `xml:"title"`
+	Links       []xmlLink  `xml:"link"`
+	GUID        string     `xml:"guid"`
+	About       string     `xml:"about,attr"` // RSS 1.0
+	Description string     `xml:"description"`
+	Encoded     []xmlText  `xml:"encoded"`
+	Author      string     `xml:"author"`
+	Creators    []xmlText  `xml:"creator"`
+	Categories  []string   `xml:"category"`
+	PubDate     string     `xml:"pubDate"`
+	Dates       []xmlText  `xml:"date"`
+	Enclosures  []xmlMedia `xml:"enclosure"`
+	Media       []xmlMedia `xml:"content"`
+	Thumbnails  []xmlMedia `xml:"thumbnail"`
+	Images      []xmlMedia `xml:"image"`
+	Groups      []struct {
+		Media []xmlMedia `xml:"content"`
+	} `xml:"group"`
+}
+
+// rssDocument is an RSS 2.0 document, or an RSS 1.0 document whose items
+// are siblings of the channel.
+type rssDocument struct {
+	XMLName xml.Name
+	Channel rssChannel `xml:"channel"`
+	Items   []*rssItem `xml:"item"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+// atomText is a text construct: text, escaped HTML or inline XHTML.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// html returns the text construct as HTML.
+func (t atomText) html() string {
+	switch strings.ToLower(t.Type) {
+	case "xhtml":
+		return strings.TrimSpace(t.Inner)
+	case "html", "text/html":
+		return strings.TrimSpace(t.Text)
+	}
+	return strings.TrimSpace(xmlEscape(t.Text))
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      atomText       `xml:"title"`
+	Links      []xmlLink      `xml:"link"`
+	Published  string         `xml:"published"`
+	Issued     string         `xml:"issued"` // Atom 0.3
+	Updated    string         `xml:"updated"`
+	Summary    atomText       `xml:"summary"`
+	Content    atomText       `xml:"content"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Thumbnails []xmlMedia     `xml:"thumbnail"`
+}
+
+type atomFeed struct {
+	Title    atomText     `xml:"title"`
+	Subtitle atomText     `xml:"subtitle"`
+	Links    []xmlLink    `xml:"link"`
+	Updated  string       `xml:"updated"`
+	Lang     string       `xml:"lang,attr"`
+	Logo     string       `xml:"logo"`
+	Icon     string       `xml:"icon"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+// parseXML parses an RSS or Atom feed, detected from its root element.
+func parseXML(data []byte) (*Feed, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(root.Local) {
+	case "rss", "rdf":
+		var doc rssDocument
+		if err := unmarshalXML(data, &doc); err != nil {
+			return nil, err
+		}
+		return doc.feed(), nil
+	case "feed":
+		var doc atomFeed
+		if err := unmarshalXML(data, &doc); err != nil {
+			return nil, err
+		}
+		return doc.feed(), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// newDecoder returns an XML decoder accepting the HTML entities and
+// encodings found in feeds.
+func newDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charset.NewReaderLabel
+	return decoder
+}
+
+func unmarshalXML(data []byte, v any) error {
+	if err := newDecoder(data).Decode(v); err != nil {
+		return fmt.Errorf("invalid feed: %w", err)
+	}
+	return nil
+}
+
+// rootElement returns the name of the root element of an XML document.
+func rootElement(data []byte) (xml.Name, error) {
+	decoder := newDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("invalid feed: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
+
+func (doc *rssDocument) feed() *Feed {
+	channel := doc.Channel
+	feed := &Feed{
+		Format:      FormatRSS,
+		Title:       plainText(channel.Title),
+		Description: plainText(channel.Description),
+		URL:         textLink(channel.Links),
+		FeedURL:     atomLink(channel.Links, "self"),
+		Language:    strings.TrimSpace(channel.Language),
+		Image:       strings.TrimSpace(channel.Image.URL),
+		Updated:     parseDate(firstNonEmpty(channel.BuildDate, channel.PubDate, namespaced(channel.Dates, namespaceDC))),
+	}
+	for _, item := range append(channel.Items, doc.Items...) {
+		feed.Items = append(feed.Items, item.item())
+	}
+	return feed
+}
+
+func (i *rssItem) item() *Item {
+	item := &Item{
+		ID:         firstNonEmpty(i.GUID, i.About),
+		Title:      plainText(i.Title),
+		URL:        firstNonEmpty(textLink(i.Links), atomLink(i.Links, "alternate")),
+		Content:    namespaced(i.Encoded, namespaceContent),
+		Categories: trimAll(i.Categories),
+		Published:  parseDate(firstNonEmpty(i.PubDate, namespaced(i.Dates, namespaceDC))),
+	}
+	// The description holds the summary, or the content if there is no
+	// content:encoded element
+	if item.Content == "" && strings.Contains(i.Description, "<") {
+		item.Content = strings.TrimSpace(i.Description)
+	}
+	item.Summary = plainText(i.Description)
+	if author := firstNonEmpty(i.Author); author != "" {
+		item.Authors = append(item.Authors, author)
+	}
+	for _, creator := range i.Creators {
+		if creator.XMLName.Space == namespaceDC && strings.TrimSpace(creator.Text) != "" {
+			item.Authors = append(item.Authors, strings.TrimSpace(creator.Text))
+		}
+	}
+	if item.URL == "" && strings.HasPrefix(item.ID, "http") {
+		item.URL = item.ID
+	}
+
+	for _, enclosure := range i.Enclosures {
+		item.addEnclosure(enclosure)
+	}
+	media := i.Media
+	for _, group := range i.Groups {
+		media = append(media, group.Media...)
+	}
+	for _, m := range media {
+		if m.XMLName.Space != namespaceMedia {
+			continue
+		}
+		if m.Medium == "image" || strings.HasPrefix(m.Type, "image/") {
+			item.Image = firstNonEmpty(item.Image, m.URL)
+		} else {
+			item.addEnclosure(m)
+		}
+	}
+	for _, thumbnail := range i.Thumbnails {
+		item.Image = firstNonEmpty(item.Image, thumbnail.URL)
+	}
+	for _, image := range i.Images {
+		if image.XMLName.Space == namespaceITunes {
+			item.Image = firstNonEmpty(item.Image, image.Href)
+		}
+	}
+	for _, enclosure := range item.Enclosures {
+		if strings.HasPrefix(enclosure.Type, "image/") {
+			item.Image = firstNonEmpty(item.Image, enclosure.URL)
+		}
+	}
+	return item
+}
+
+// addEnclosure adds a file attached to the item.
+func (item *Item) addEnclosure(media xmlMedia) {
+	if media.URL = strings.TrimSpace(media.URL); media.URL == "" {
+		return
+	}
+	length, _ := strconv.ParseInt(strings.TrimSpace(media.Length), 10, 64)
+	item.Enclosures = append(item.Enclosures, &Enclosure{
+		URL:    media.URL,
+		Type:   strings.TrimSpace(media.Type),
+		Length: length,
+	})
+}
+
+func (doc *atomFeed) feed() *Feed {
+	feed := &Feed{
+		Format:      FormatAtom,
+		Title:       plainText(doc.Title.html()),
+		Description: plainText(doc.Subtitle.html()),
+		URL:         atomLink(doc.Links, "alternate"),
+		FeedURL:     atomLink(doc.Links, "self"),
+		Language:    strings.TrimSpace(doc.Lang),
+		Image:       firstNonEmpty(doc.Logo, doc.Icon),
+		Updated:     parseDate(doc.Updated),
+	}
+	for _, entry := range doc.Entries {
+		feed.Items = append(feed.Items, entry.item())
+	}
+	return feed
+}
+
+func (e *atomEntry) item() *Item {
+	item := &Item{
+		ID:        strings.TrimSpace(e.ID),
+		Title:     plainText(e.Title.html()),
+		URL:       atomLink(e.Links, "alternate"),
+		Summary:   plainText(e.Summary.html()),
+		Content:   e.Content.html(),
+		Published: parseDate(firstNonEmpty(e.Published, e.Issued)),
+		Updated:   parseDate(e.Updated),
+	}
+	if item.Published.IsZero() {
+		item.Published = item.Updated
+	}
+	if item.Summary == "" {
+		item.Summary = plainText(item.Content)
+	}
+	for _, author := range e.Authors {
+		if name := strings.TrimSpace(author.Name); name != "" {
+			item.Authors = append(item.Authors, name)
+		}
+	}
+	for _, category := range e.Categories {
+		if term := firstNonEmpty(category.Label, category.Term); term != "" {
+			item.Categories = append(item.Categories, term)
+		}
+	}
+	for _, link := range e.Links {
+		if strings.EqualFold(link.Rel, "enclosure") {
+			item.addEnclosure(xmlMedia{URL: link.Href, Type: link.Type})
+		}
+	}
+	for _, thumbnail := range e.Thumbnails {
+		item.Image = firstNonEmpty(item.Image, thumbnail.URL)
+	}
+	return item
+}
+
+// textLink returns the first link given as text, as in RSS.
+func textLink(links []xmlLink) string {
+	for _, link := range links {
+		if link.XMLName.Space != namespaceAtom {
+			if text := strings.TrimSpace(link.Text); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// atomLink returns the href of the first link with the given relation. Links
+// without a relation are alternates.
+func atomLink(links []xmlLink, rel string) string {
+	for _, link := range links {
+		linkRel := strings.ToLower(strings.TrimSpace(link.Rel))
+		if linkRel == "" {
+			linkRel = "alternate"
+		}
+		if linkRel == rel && strings.TrimSpace(link.Href) != "" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// namespaced returns the text of the first element of the namespace.
+func namespaced(elements []xmlText, namespace string) string {
+	for _, element := range elements {
+		if element.XMLName.Space == namespace {
+			if text := strings.TrimSpace(element.Text); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// trimAll returns the non-empty values, trimmed.
+func trimAll(values []string) []string {
+	var trimmed []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
+
+// xmlEscape escapes text to be used as HTML.
+func xmlEscape(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
@@ -12,17 +12,46 @@ type TypedAction interface {
 
 // BaseAction contains common fields for all actions
 type BaseAction struct {
-	Type string `json:"type"`
+	Type string           `json:"type"`
+	Name string           `json:"name,omitempty"` // Names the output of the action in Response.Outputs
+	If   *ActionCondition `json:"if,omitempty"`   // Run the action only if the condition holds
 }
 
 func (a BaseAction) GetType() string {
 	return a.Type
 }
 
-// ScreenshotAction triggers a screenshot of the page
+func (a *BaseAction) base() *BaseAction {
+	return a
+}
+
+// ActionCondition is a condition on the page for an action to run. All the
+// given conditions must hold.
+type ActionCondition struct {
+	SelectorExists  string `json:"selector_exists,omitempty"`  // An element matches the selector
+	SelectorMissing string `json:"selector_missing,omitempty"` // No element matches the selector
+}
+
+// CaptureHTMLAction captures the HTML of the page at this point
+type CaptureHTMLAction struct {
+	BaseAction
+}
+
+// ScreenshotAction triggers a screenshot of the page, or of part of it
 type ScreenshotAction struct {
 	BaseAction
-	FullPage bool `json:"full_page,omitempty"`
+	FullPage bool        `json:"full_page,omitempty"`
+	Selector string      `json:"selector,omitempty"` // Capture the first element matching the selector
+	Clip     *ClipRegion `json:"clip,omitempty"`     // Capture a region of the page
+}
+
+// ClipRegion is a region of a page in CSS pixels, relative to the top left
+// corner of the page
+type ClipRegion struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
 }
 
 // PDFAction generates a PDF of the page
@@ -38,6 +67,15 @@ type WaitAction struct {
 	Duration int    `json:"duration,omitempty"` // Wait for specific duration in milliseconds
 }
 
+// ScrollToBottomAction scrolls to the bottom of the page repeatedly so that
+// infinite scroll feeds and listings load their content
+type ScrollToBottomAction struct {
+	BaseAction
+	MaxIterations      int  `json:"max_iterations,omitempty"`         // Maximum number of scrolls, defaults to 10
+	Wait               int  `json:"wait,omitempty"`                   // Wait after each scroll in milliseconds, defaults to 1000
+	StopOnNoNewContent bool `json:"stop_on_no_new_content,omitempty"` // Stop once a scroll doesn't grow the page
+}
+
 // Action is used for JSON marshaling/unmarshaling of polymorphic actions
 type Action struct {
 	Action TypedAction
@@ -74,6 +112,20 @@ func (a *Action) UnmarshalJSON(data []byte) error {
 			return err
 		}
 		a.Action = &action
+	case "scroll_to_bottom":
+		var action ScrollToBottomAction
+		action.Type = typeOnly.Type
+		if err := json.Unmarshal(data, &action); err != nil {
+			return err
+		}
+		a.Action = &action
+	case "capture_html":
+		var action CaptureHTMLAction
+		action.Type = typeOnly.Type
+		if err := json.Unmarshal(data, &action); err != nil {
+			return err
+		}
+		a.Action = &action
 	default:
 		return fmt.Errorf("unknown action type: %s", typeOnly.Type)
 	}
@@ -85,9 +137,28 @@ func (a *Action) MarshalJSON() ([]byte, error) {
 	return json.Marshal(a.Action)
 }
 
+// Named sets the name of the action, under which its output is returned in
+// Response.Outputs, and returns the action
+func (a Action) Named(name string) Action {
+	if b, ok := a.Action.(interface{ base() *BaseAction }); ok {
+		b.base().Name = name
+	}
+	return a
+}
+
+// When sets the condition for the action to run and returns the action
+func (a Action) When(condition ActionCondition) Action {
+	if b, ok := a.Action.(interface{ base() *BaseAction }); ok {
+		b.base().If = &condition
+	}
+	return a
+}
+
 // ScreenshotActionOptions represents the options for a screenshot action
 type ScreenshotActionOptions struct {
-	FullPage bool `json:"full_page,omitempty"`
+	FullPage bool        `json:"full_page,omitempty"`
+	Selector string      `json:"selector,omitempty"` // Capture the first element matching the selector
+	Clip     *ClipRegion `json:"clip,omitempty"`     // Capture a region of the page
 }
 
 // NewScreenshotAction creates a new screenshot action
@@ -96,6 +167,8 @@ func NewScreenshotAction(options ScreenshotActionOptions) Action {
 		Action: &ScreenshotAction{
 			BaseAction: BaseAction{Type: "screenshot"},
 			FullPage:   options.FullPage,
+			Selector:   options.Selector,
+			Clip:       options.Clip,
 		},
 	}
 }
@@ -131,3 +204,31 @@ func NewWaitAction(options WaitActionOptions) Action {
 		},
 	}
 }
+
+// ScrollToBottomActionOptions represents the options for a scroll to bottom action
+type ScrollToBottomActionOptions struct {
+	MaxIterations      int  `json:"max_iterations,omitempty"`         // Maximum number of scrolls, defaults to 10
+	Wait               int  `json:"wait,omitempty"`                   // Wait after each scroll in milliseconds, defaults to 1000
+	StopOnNoNewContent bool `json:"stop_on_no_new_content,omitempty"` // Stop once a scroll doesn't grow the page
+}
+
+// NewScrollToBottomAction creates a new scroll to bottom action
+func NewScrollToBottomAction(options ScrollToBottomActionOptions) Action {
+	return Action{
+		Action: &ScrollToBottomAction{
+			BaseAction:         BaseAction{Type: "scroll_to_bottom"},
+			MaxIterations:      options.MaxIterations,
+			Wait:               options.Wait,
+			StopOnNoNewContent: options.StopOnNoNewContent,
+		},
+	}
+}
+
+// NewCaptureHTMLAction creates a new capture HTML action
+func NewCaptureHTMLAction() Action {
+	return Action{
+		Action: &CaptureHTMLAction{
+			BaseAction: BaseAction{Type: "capture_html"},
+		},
+	}
+}
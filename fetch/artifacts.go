@@ -0,0 +1,183 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArtifactStore stores the artifacts of fetches, such as screenshots and PDFs,
+// which are unwieldy to return inline as base64. Stores for object storage
+// are simple to implement, e.g. for S3 with the AWS SDK:
+//
+//	func (s *S3Store) Put(ctx context.Context, name, contentType string, data []byte) (string, error) {
+//		_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+//			Bucket:      aws.String(s.bucket),
+//			Key:         aws.String(name),
+//			Body:        bytes.NewReader(data),
+//			ContentType: aws.String(contentType),
+//		})
+//		return "s3://" + s.bucket + "/" + name, err
+//	}
+type ArtifactStore interface {
+
+	// Put stores an artifact under the given name and returns its URL or
+	// path.
+	Put(ctx context.Context, name, contentType string, data []byte) (string, error)
+}
+
+// FileArtifactStoreOptions defines the options for a FileArtifactStore.
+type FileArtifactStoreOptions struct {
+	// Dir is the directory the artifacts are written to.
+	Dir string
+
+	// BaseURL is the URL the directory is served at, if any. References to
+	// artifacts are URLs under it instead of file paths.
+	BaseURL string
+}
+
+// FileArtifactStore stores artifacts as files in a directory.
+type FileArtifactStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewFileArtifactStore creates a new FileArtifactStore, creating its directory
+// if needed.
+func NewFileArtifactStore(options FileArtifactStoreOptions) (*FileArtifactStore, error) {
+	if options.Dir == "" {
+		return nil, fmt.Errorf("artifact directory is required")
+	}
+	if err := os.MkdirAll(options.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileArtifactStore{
+		dir:     options.Dir,
+		baseURL: strings.TrimSuffix(options.BaseURL, "/"),
+	}, nil
+}
+
+// Put implements the ArtifactStore interface.
+func (s *FileArtifactStore) Put(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid artifact name: %q", name)
+	}
+	path := filepath.Join(s.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	if s.baseURL != "" {
+		return s.baseURL + "/" + filepath.ToSlash(name), nil
+	}
+	return path, nil
+}
+
+// StoreArtifacts moves the screenshots and PDFs of a response, including
+// those of action outputs, to the store. The inline base64 values are cleared
+// and the references returned by the store are added to Response.Artifacts,
+// keyed by the field they replace, e.g. "screenshot" or
+// "outputs.pricing.screenshot". Artifacts are named after the hash of their
+// content.
+func StoreArtifacts(ctx context.Context, store ArtifactStore, response *Response) error {
+	move := func(key string, value *string) error {
+		if *value == "" {
+			return nil
+		}
+		data, err := base64.StdEncoding.DecodeString(*value)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", key, err)
+		}
+		contentType := http.DetectContentType(data)
+		sum := sha256.Sum256(data)
+		name := hex.EncodeToString(sum[:16]) + artifactExtension(contentType)
+		ref, err := store.Put(ctx, name, contentType, data)
+		if err != nil {
+			return fmt.Errorf("failed to store %s: %w", key, err)
+		}
+		if response.Artifacts == nil {
+			response.Artifacts = map[string]string{}
+		}
+		response.Artifacts[key] = ref
+		*value = ""
+		return nil
+	}
+	if err := move("screenshot", &response.Screenshot); err != nil {
+		return err
+	}
+	if err := move("pdf", &response.PDF); err != nil {
+		return err
+	}
+	for name, output := range response.Outputs {
+		if err := move("outputs."+name+".screenshot", &output.Screenshot); err != nil {
+			return err
+		}
+		if err := move("outputs."+name+".pdf", &output.PDF); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func artifactExtension(contentType string) string {
+	switch MediaType(contentType) {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/webp":
+		return ".webp"
+	case "application/pdf":
+		return ".pdf"
+	}
+	return ""
+}
+
+// ArtifactFetcher wraps a fetcher to move the artifacts of its responses to
+// an ArtifactStore. See StoreArtifacts.
+type ArtifactFetcher struct {
+	fetcher Fetcher
+	store   ArtifactStore
+}
+
+// NewArtifactFetcher creates a new ArtifactFetcher.
+func NewArtifactFetcher(fetcher Fetcher, store ArtifactStore) *ArtifactFetcher {
+	return &ArtifactFetcher{fetcher: fetcher, store: store}
+}
+
+// Capabilities implements the CapabilityReporter interface, reporting the
+// capabilities of the wrapped fetcher.
+func (f *ArtifactFetcher) Capabilities() *Capabilities {
+	return FetcherCapabilities(f.fetcher)
+}
+
+// Warmup implements the Warmer interface, warming up the wrapped fetcher.
+func (f *ArtifactFetcher) Warmup(ctx context.Context) error {
+	return WarmupFetcher(ctx, f.fetcher)
+}
+
+// Healthy implements the HealthChecker interface, checking the health of
+// the wrapped fetcher.
+func (f *ArtifactFetcher) Healthy(ctx context.Context) error {
+	return CheckFetcherHealth(ctx, f.fetcher)
+}
+
+// Fetch implements the Fetcher interface.
+func (f *ArtifactFetcher) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	response, err := f.fetcher.Fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := StoreArtifacts(ctx, f.store, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,56 @@
+package fetch
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifactFetcher(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileArtifactStore(FileArtifactStoreOptions{Dir: dir})
+	require.NoError(t, err)
+
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	pdf := []byte("%PDF-1.7\n0000")
+	mockFetcher := NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com", &Response{
+		URL:        "https://example.com",
+		Screenshot: base64.StdEncoding.EncodeToString(png),
+		PDF:        base64.StdEncoding.EncodeToString(pdf),
+		Outputs: map[string]*ActionOutput{
+			"pricing": {Screenshot: base64.StdEncoding.EncodeToString(png), HTML: "<p>Prices</p>"},
+		},
+	})
+
+	response, err := NewArtifactFetcher(mockFetcher, store).Fetch(context.Background(), &Request{URL: "https://example.com"})
+	require.NoError(t, err)
+	require.Empty(t, response.Screenshot)
+	require.Empty(t, response.PDF)
+	require.Empty(t, response.Outputs["pricing"].Screenshot)
+	require.Equal(t, "<p>Prices</p>", response.Outputs["pricing"].HTML)
+	require.Len(t, response.Artifacts, 3)
+	require.Equal(t, response.Artifacts["screenshot"], response.Artifacts["outputs.pricing.screenshot"])
+	require.Equal(t, ".png", filepath.Ext(response.Artifacts["screenshot"]))
+	require.Equal(t, ".pdf", filepath.Ext(response.Artifacts["pdf"]))
+
+	data, err := os.ReadFile(response.Artifacts["pdf"])
+	require.NoError(t, err)
+	require.Equal(t, pdf, data)
+}
+
+func TestFileArtifactStore(t *testing.T) {
+	store, err := NewFileArtifactStore(FileArtifactStoreOptions{Dir: t.TempDir(), BaseURL: "https://cdn.example.com/artifacts/"})
+	require.NoError(t, err)
+
+	ref, err := store.Put(context.Background(), "a/b.png", "image/png", []byte("png"))
+	require.NoError(t, err)
+	require.Equal(t, "https://cdn.example.com/artifacts/a/b.png", ref)
+
+	_, err = store.Put(context.Background(), "../b.png", "image/png", []byte("png"))
+	require.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceBlocking_Blocks(t *testing.T) {
+	block := &ResourceBlocking{
+		Types:   []string{ResourceImage, ResourceFont, ResourceAnalytics},
+		Domains: []string{"Tracker.example"},
+	}
+	tests := []struct {
+		resourceType string
+		url          string
+		want         bool
+	}{
+		{"image", "https://example.com/logo.png", true},
+		{"Font", "https://fonts.gstatic.com/a.woff2", true},
+		{"script", "https://example.com/app.js", false},
+		{"script", "https://www.googletagmanager.com/gtm.js", true},
+		{"xhr", "https://cdn.tracker.example/collect", true},
+		{"script", "https://notgoogletagmanager.com/gtm.js", false},
+		{"script", "https://securepubads.g.doubleclick.net/tag.js", false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, block.Blocks(tt.resourceType, tt.url), tt.url)
+	}
+
+	var none *ResourceBlocking
+	require.False(t, none.Blocks("image", "https://example.com/logo.png"))
+	require.True(t, (&ResourceBlocking{Types: []string{"ads"}}).Blocks("script", "https://securepubads.g.doubleclick.net/tag.js"))
+}
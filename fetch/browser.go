@@ -0,0 +1,955 @@
+package fetch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// DefaultBrowserPaths are the browsers looked up, in order, when a
+// BrowserFetcher launches a browser without an ExecPath.
+var DefaultBrowserPaths = []string{
+	"chromium",
+	"chromium-browser",
+	"google-chrome",
+	"google-chrome-stable",
+	"chrome",
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+}
+
+// Defaults of mobile emulation.
+var (
+	DefaultMobileUserAgent   = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36"
+	DefaultMobileViewport    = Viewport{Width: 412, Height: 915}
+	DefaultMobileScaleFactor = 2.625
+)
+
+// DefaultViewport is the viewport of pages when none is emulated.
+var DefaultViewport = Viewport{Width: 1280, Height: 800}
+
+// browserActions are the action types run by the BrowserFetcher.
+var browserActions = []string{"screenshot", "pdf", "wait", "scroll_to_bottom", "capture_html"}
+
+// BrowserFetcherOptions defines the options for the browser fetcher.
+type BrowserFetcherOptions struct {
+	// Endpoint is the DevTools endpoint of a running Chrome or Chromium: its
+	// browser WebSocket URL, e.g. "ws://localhost:9222/devtools/browser/<id>",
+	// or the URL of its remote debugging port, e.g. "http://localhost:9222".
+	// The browser must accept connections from other origins, i.e. be started
+	// with --remote-allow-origins=*. A headless browser is launched if empty.
+	Endpoint string
+
+	// ExecPath is the browser launched when Endpoint is empty. Defaults to
+	// the first of DefaultBrowserPaths found.
+	ExecPath string
+
+	// Args are additional command line flags of the launched browser, e.g.
+	// "--no-sandbox" to run as root in a container.
+	Args []string
+
+	// Headers are sent with the requests of pages, along with the headers of
+	// each request.
+	Headers map[string]string
+
+	// UserAgent overrides the user agent of the browser, from which the
+	// "Headless" marker is otherwise removed.
+	UserAgent string
+
+	// MobileUserAgent is the user agent of mobile requests. Defaults to
+	// DefaultMobileUserAgent.
+	MobileUserAgent string
+
+	// Emulation is the default emulation of pages, overridden by that of
+	// each request.
+	Emulation *Emulation
+
+	// Timeout bounds the fetch of each page, including its actions. Defaults
+	// to DefaultTimeout. The timeout of a Request takes precedence.
+	Timeout time.Duration
+
+	// MaxPages is the maximum number of pages open at once. Fetches beyond
+	// it wait for a page to close. Unlimited if zero.
+	MaxPages int
+
+	// RecycleAfter restarts the browser once it has opened this many pages,
+	// as browsers grow in memory over long crawls. Fetches wait for the
+	// open pages to close meanwhile. The connection to an Endpoint is
+	// reopened instead. Never recycled if zero.
+	RecycleAfter int
+
+	// MaxMemory restarts the launched browser, as with RecycleAfter, once
+	// the resident memory of its processes exceeds this many bytes. It is
+	// measured by the health checks, on systems with a /proc file system.
+	MaxMemory int64
+
+	// HealthCheckInterval is the minimum interval between health checks of
+	// the browser, run before fetches, which restart unresponsive browsers.
+	// Defaults to DefaultBrowserHealthCheckInterval.
+	HealthCheckInterval time.Duration
+}
+
+// BrowserFetcher implements the Fetcher interface by rendering pages in a
+// headless Chrome or Chromium, driven over the Chrome DevTools Protocol, so
+// that pages built by JavaScript can be fetched. The HTML of the response is
+// the DOM once the page is loaded, Request.WaitFor has elapsed and the
+// actions of the request have run. Each fetch uses a fresh browser context,
+// so pages don't share cookies or storage: the cookies and local storage of
+// Request.StorageState are restored in the context, and those of the page
+// returned as Response.StorageState, in the format of Playwright storage
+// states. The requests selected by Request.CaptureNetwork are returned as
+// Response.Network. The pages are pooled: their number is bounded by
+// MaxPages, and the browser is restarted when recycled or unresponsive, so
+// that long crawls don't leak browser processes or memory. Close the fetcher
+// to stop the browser it launched.
+type BrowserFetcher struct {
+	endpoint            string
+	execPath            string
+	args                []string
+	headers             map[string]string
+	userAgent           string
+	mobileUserAgent     string
+	emulation           *Emulation
+	timeout             time.Duration
+	recycleAfter        int
+	maxMemory           int64
+	healthCheckInterval time.Duration
+
+	slots     chan struct{} // a value per open page, nil if unlimited
+	recycling sync.RWMutex  // read locked by fetches, locked to recycle
+
+	mutex      sync.Mutex
+	conn       *cdpConn
+	process    *browserProcess
+	closed     bool
+	pages      int // pages opened since the browser was started
+	overMemory bool
+	unhealthy  bool
+	lastCheck  time.Time
+	stats      BrowserPoolStats
+}
+
+// NewBrowserFetcher creates a new browser fetcher. The browser is connected
+// to, or launched, on the first fetch.
+func NewBrowserFetcher(options BrowserFetcherOptions) *BrowserFetcher {
+	if options.MobileUserAgent == "" {
+		options.MobileUserAgent = DefaultMobileUserAgent
+	}
+	if options.Timeout == 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.HealthCheckInterval == 0 {
+		options.HealthCheckInterval = DefaultBrowserHealthCheckInterval
+	}
+	var slots chan struct{}
+	if options.MaxPages > 0 {
+		slots = make(chan struct{}, options.MaxPages)
+	}
+	return &BrowserFetcher{
+		endpoint:            options.Endpoint,
+		execPath:            options.ExecPath,
+		args:                options.Args,
+		headers:             options.Headers,
+		userAgent:           options.UserAgent,
+		mobileUserAgent:     options.MobileUserAgent,
+		emulation:           options.Emulation,
+		timeout:             options.Timeout,
+		recycleAfter:        options.RecycleAfter,
+		maxMemory:           options.MaxMemory,
+		healthCheckInterval: options.HealthCheckInterval,
+		slots:               slots,
+	}
+}
+
+// Capabilities implements the CapabilityReporter interface.
+func (f *BrowserFetcher) Capabilities() *Capabilities {
+	return &Capabilities{
+		Formats:        Formats,
+		Actions:        browserActions,
+		Mobile:         true,
+		StorageState:   true,
+		NetworkCapture: true,
+	}
+}
+
+// Warmup implements the Warmer interface by launching the browser, or
+// connecting to it.
+func (f *BrowserFetcher) Warmup(ctx context.Context) error {
+	_, _, err := f.connect(ctx)
+	return err
+}
+
+// Healthy implements the HealthChecker interface by checking that the
+// browser responds, launching or connecting to it if needed.
+func (f *BrowserFetcher) Healthy(ctx context.Context) error {
+	conn, _, err := f.connect(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.call(ctx, "", "Browser.getVersion", nil, nil)
+}
+
+// Fetch implements the Fetcher interface by rendering the page in the
+// browser.
+func (f *BrowserFetcher) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	timeout := f.timeout
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	release, err := f.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	conn, userAgent, err := f.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page, err := openBrowserPage(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer page.close(ctx)
+
+	if req.Mobile {
+		userAgent = f.mobileUserAgent
+	}
+	headers := make(map[string]string, len(f.headers)+len(req.Headers))
+	for key, value := range f.headers {
+		headers[key] = value
+	}
+	for key, value := range req.Headers {
+		headers[key] = value
+	}
+	if err := page.configure(ctx, f.emulation.Merge(req.Emulation), req.Mobile, userAgent, headers, req.Block); err != nil {
+		return nil, err
+	}
+	var storageState *browserStorageState
+	if len(req.StorageState) > 0 {
+		if storageState, err = parseStorageState(req.StorageState); err != nil {
+			return nil, err
+		}
+		if err := page.restoreStorageState(ctx, storageState); err != nil {
+			return nil, err
+		}
+	}
+	var recorder *networkRecorder
+	if req.CaptureNetwork != nil {
+		recorder = page.captureNetwork(ctx, req.CaptureNetwork)
+	}
+
+	document, err := page.navigate(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if req.WaitFor > 0 {
+		if err := sleepContext(ctx, time.Duration(req.WaitFor)*time.Millisecond); err != nil {
+			return nil, err
+		}
+	}
+
+	// Actions may capture the HTML, otherwise the DOM is captured once they
+	// have run
+	var results Response
+	if err := RunActions(ctx, page, req.Actions, &results); err != nil {
+		return nil, err
+	}
+	content := results.HTML
+	if content == "" {
+		if content, err = page.html(ctx); err != nil {
+			return nil, err
+		}
+	}
+	state, err := page.storageState(ctx, storageState)
+	if err != nil {
+		return nil, err
+	}
+	response, err := ProcessRequest(req, content)
+	if err != nil {
+		return nil, err
+	}
+	response.StorageState = state
+	if recorder != nil {
+		response.Network = recorder.results()
+	}
+	response.URL = req.URL
+	response.StatusCode = document.Status
+	response.Headers = make(map[string]string, len(document.Headers))
+	for name, value := range document.Headers {
+		// Multiple values are separated by newlines
+		value, _, _ = strings.Cut(value, "\n")
+		response.Headers[name] = value
+	}
+	if document.URL != "" && document.URL != req.URL {
+		response.FinalURL = document.URL
+	}
+	response.Screenshot = results.Screenshot
+	response.PDF = results.PDF
+	response.Outputs = results.Outputs
+	response.ActionResults = results.ActionResults
+	return response, nil
+}
+
+// Close closes the connection to the browser, and stops the browser if it
+// was launched by the fetcher.
+func (f *BrowserFetcher) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.closed = true
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+	if f.process != nil {
+		f.process.stop()
+		f.process = nil
+	}
+	return nil
+}
+
+// connect returns the connection to the browser, launching the browser and
+// connecting to it if needed, along with the user agent of pages.
+func (f *BrowserFetcher) connect(ctx context.Context) (*cdpConn, string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.closed {
+		return nil, "", errors.New("browser fetcher is closed")
+	}
+	if f.conn != nil && f.conn.alive() {
+		return f.conn, f.userAgent, nil
+	}
+	endpoint := f.endpoint
+	if endpoint == "" {
+		if f.process == nil || !f.process.running() {
+			if f.process != nil {
+				f.process.stop()
+			}
+			process, err := launchBrowser(ctx, f.execPath, f.args)
+			if err != nil {
+				return nil, "", err
+			}
+			f.process = process
+		}
+		endpoint = f.process.wsURL
+	}
+	wsURL, err := browserWebSocketURL(ctx, endpoint)
+	if err != nil {
+		return nil, "", err
+	}
+	conn, err := dialCDP(ctx, wsURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if f.userAgent == "" {
+		var version struct {
+			UserAgent string `json:"userAgent"`
+		}
+		if err := conn.call(ctx, "", "Browser.getVersion", nil, &version); err != nil {
+			conn.Close()
+			return nil, "", err
+		}
+		f.userAgent = strings.Replace(version.UserAgent, "HeadlessChrome", "Chrome", 1)
+	}
+	f.conn = conn
+	f.lastCheck = time.Now()
+	return conn, f.userAgent, nil
+}
+
+// browserProcess is a browser launched by a BrowserFetcher.
+type browserProcess struct {
+	cmd     *exec.Cmd
+	dataDir string
+	wsURL   string
+	exited  chan struct{}
+}
+
+// launchBrowser starts a headless browser with a temporary profile, and
+// waits for its DevTools endpoint.
+func launchBrowser(ctx context.Context, execPath string, extraArgs []string) (*browserProcess, error) {
+	if execPath == "" {
+		for _, path := range DefaultBrowserPaths {
+			if found, err := exec.LookPath(path); err == nil {
+				execPath = found
+				break
+			}
+		}
+		if execPath == "" {
+			return nil, errors.New("no browser found, set BrowserFetcherOptions.ExecPath or Endpoint")
+		}
+	}
+	dataDir, err := os.MkdirTemp("", "web-browser-*")
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		"--headless=new",
+		"--remote-debugging-port=0",
+		"--remote-allow-origins=*",
+		"--user-data-dir=" + dataDir,
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--disable-gpu",
+		"--hide-scrollbars",
+		"--mute-audio",
+	}
+	args = append(append(args, extraArgs...), "about:blank")
+	cmd := exec.Command(execPath, args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		return nil, fmt.Errorf("failed to launch browser: %w", err)
+	}
+	process := &browserProcess{cmd: cmd, dataDir: dataDir, exited: make(chan struct{})}
+
+	// The browser prints its endpoint on startup. Its output is drained
+	// until it exits, so that it never blocks on writing.
+	found := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		sent := false
+		for scanner.Scan() {
+			if wsURL, ok := strings.CutPrefix(scanner.Text(), "DevTools listening on "); ok && !sent {
+				found <- strings.TrimSpace(wsURL)
+				sent = true
+			}
+		}
+		if !sent {
+			close(found)
+		}
+		cmd.Wait()
+		close(process.exited)
+	}()
+	select {
+	case wsURL, ok := <-found:
+		if !ok {
+			process.stop()
+			return nil, errors.New("failed to launch browser: exited on startup")
+		}
+		process.wsURL = wsURL
+		return process, nil
+	case <-ctx.Done():
+		process.stop()
+		return nil, fmt.Errorf("failed to launch browser: %w", ctx.Err())
+	}
+}
+
+// running returns true if the browser has not exited.
+func (p *browserProcess) running() bool {
+	select {
+	case <-p.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// stop kills the browser and removes its profile.
+func (p *browserProcess) stop() {
+	p.cmd.Process.Kill()
+	select {
+	case <-p.exited:
+	case <-time.After(5 * time.Second):
+	}
+	os.RemoveAll(p.dataDir)
+}
+
+// sleepContext waits for a duration, or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// browserDocument is the response of the browser for the document of a page.
+type browserDocument struct {
+	URL      string            `json:"url"`
+	Status   int               `json:"status"`
+	Headers  map[string]string `json:"headers"`
+	MimeType string            `json:"mimeType"`
+}
+
+// browserPage is a page opened in its own browser context. It implements the
+// ActionPage interface.
+type browserPage struct {
+	conn      *cdpConn
+	contextID string
+	targetID  string
+	sessionID string
+	removers  []func()
+}
+
+// openBrowserPage opens a blank page in a new browser context and attaches
+// to it.
+func openBrowserPage(ctx context.Context, conn *cdpConn) (*browserPage, error) {
+	page := &browserPage{conn: conn}
+	var browserContext struct {
+		BrowserContextID string `json:"browserContextId"`
+	}
+	if err := conn.call(ctx, "", "Target.createBrowserContext", map[string]any{"disposeOnDetach": true}, &browserContext); err != nil {
+		return nil, err
+	}
+	page.contextID = browserContext.BrowserContextID
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	err := conn.call(ctx, "", "Target.createTarget", map[string]any{
+		"url":              "about:blank",
+		"browserContextId": page.contextID,
+	}, &target)
+	if err != nil {
+		page.close(ctx)
+		return nil, err
+	}
+	page.targetID = target.TargetID
+	var session struct {
+		SessionID string `json:"sessionId"`
+	}
+	err = conn.call(ctx, "", "Target.attachToTarget", map[string]any{
+		"targetId": page.targetID,
+		"flatten":  true,
+	}, &session)
+	if err != nil {
+		page.close(ctx)
+		return nil, err
+	}
+	page.sessionID = session.SessionID
+	for _, method := range []string{"Page.enable", "Network.enable"} {
+		if err := page.call(ctx, method, nil, nil); err != nil {
+			page.close(ctx)
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// call sends a command to the session of the page.
+func (p *browserPage) call(ctx context.Context, method string, params, result any) error {
+	return p.conn.call(ctx, p.sessionID, method, params, result)
+}
+
+// on registers a handler of the events of the page, removed when the page
+// is closed.
+func (p *browserPage) on(handle func(method string, params json.RawMessage)) {
+	p.removers = append(p.removers, p.conn.on(p.sessionID, handle))
+}
+
+// close closes the page and disposes its browser context, even if the
+// context of the fetch is done.
+func (p *browserPage) close(ctx context.Context) {
+	for _, remove := range p.removers {
+		remove()
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if p.targetID != "" {
+		p.conn.call(ctx, "", "Target.closeTarget", map[string]any{"targetId": p.targetID}, nil)
+	}
+	if p.contextID != "" {
+		p.conn.call(ctx, "", "Target.disposeBrowserContext", map[string]any{"browserContextId": p.contextID}, nil)
+	}
+}
+
+// configure applies the emulation, user agent, extra headers and resource
+// blocking of a fetch to the page.
+func (p *browserPage) configure(ctx context.Context, emulation *Emulation, mobile bool, userAgent string, headers map[string]string, block *ResourceBlocking) error {
+	if emulation == nil {
+		emulation = &Emulation{}
+	}
+	if emulation.Viewport != nil || emulation.DeviceScaleFactor != 0 || mobile {
+		viewport, scale := DefaultViewport, 1.0
+		if mobile {
+			viewport, scale = DefaultMobileViewport, DefaultMobileScaleFactor
+		}
+		if emulation.Viewport != nil {
+			viewport = *emulation.Viewport
+		}
+		if emulation.DeviceScaleFactor != 0 {
+			scale = emulation.DeviceScaleFactor
+		}
+		err := p.call(ctx, "Emulation.setDeviceMetricsOverride", map[string]any{
+			"width":             viewport.Width,
+			"height":            viewport.Height,
+			"deviceScaleFactor": scale,
+			"mobile":            mobile,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	if mobile {
+		if err := p.call(ctx, "Emulation.setTouchEmulationEnabled", map[string]any{"enabled": true, "maxTouchPoints": 5}, nil); err != nil {
+			return err
+		}
+	}
+	if userAgent != "" {
+		params := map[string]any{"userAgent": userAgent}
+		if emulation.Locale != "" {
+			params["acceptLanguage"] = emulation.Locale
+		}
+		if err := p.call(ctx, "Network.setUserAgentOverride", params, nil); err != nil {
+			return err
+		}
+	}
+	if emulation.Locale != "" {
+		if err := p.call(ctx, "Emulation.setLocaleOverride", map[string]any{"locale": emulation.Locale}, nil); err != nil {
+			return err
+		}
+	}
+	if emulation.Timezone != "" {
+		if err := p.call(ctx, "Emulation.setTimezoneOverride", map[string]any{"timezoneId": emulation.Timezone}, nil); err != nil {
+			return errors.NewInvalidField("emulation.timezone", "%s", err)
+		}
+	}
+	if location := emulation.Geolocation; location != nil {
+		err := p.conn.call(ctx, "", "Browser.grantPermissions", map[string]any{
+			"permissions":      []string{"geolocation"},
+			"browserContextId": p.contextID,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		err = p.call(ctx, "Emulation.setGeolocationOverride", map[string]any{
+			"latitude":  location.Latitude,
+			"longitude": location.Longitude,
+			"accuracy":  max(location.Accuracy, 1),
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	if len(headers) > 0 {
+		if err := p.call(ctx, "Network.setExtraHTTPHeaders", map[string]any{"headers": headers}, nil); err != nil {
+			return err
+		}
+	}
+	if block != nil {
+		return p.block(ctx, block)
+	}
+	return nil
+}
+
+// block intercepts the requests of the page to fail those blocked.
+func (p *browserPage) block(ctx context.Context, block *ResourceBlocking) error {
+	p.on(func(method string, params json.RawMessage) {
+		if method != "Fetch.requestPaused" {
+			return
+		}
+		var paused struct {
+			RequestID    string `json:"requestId"`
+			ResourceType string `json:"resourceType"`
+			Request      struct {
+				URL string `json:"url"`
+			} `json:"request"`
+		}
+		if json.Unmarshal(params, &paused) != nil {
+			return
+		}
+		// Reply from another goroutine, as handlers must not block
+		go func() {
+			if paused.ResourceType != "Document" && block.Blocks(paused.ResourceType, paused.Request.URL) {
+				p.call(ctx, "Fetch.failRequest", map[string]any{
+					"requestId":   paused.RequestID,
+					"errorReason": "BlockedByClient",
+				}, nil)
+				return
+			}
+			p.call(ctx, "Fetch.continueRequest", map[string]any{"requestId": paused.RequestID}, nil)
+		}()
+	})
+	return p.call(ctx, "Fetch.enable", map[string]any{
+		"patterns": []map[string]any{{"urlPattern": "*", "requestStage": "Request"}},
+	}, nil)
+}
+
+// navigate loads a URL in the page, and waits for its load event. Returns
+// the response of the document of the page.
+func (p *browserPage) navigate(ctx context.Context, rawURL string) (*browserDocument, error) {
+	var mutex sync.Mutex
+	documents := map[string]*browserDocument{}
+	loaded := make(chan struct{}, 1)
+	p.on(func(method string, params json.RawMessage) {
+		switch method {
+		case "Network.responseReceived":
+			var event struct {
+				LoaderID string          `json:"loaderId"`
+				Type     string          `json:"type"`
+				Response browserDocument `json:"response"`
+			}
+			if json.Unmarshal(params, &event) == nil && event.Type == "Document" {
+				mutex.Lock()
+				documents[event.LoaderID] = &event.Response
+				mutex.Unlock()
+			}
+		case "Page.loadEventFired":
+			select {
+			case loaded <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	var navigation struct {
+		LoaderID  string `json:"loaderId"`
+		ErrorText string `json:"errorText"`
+	}
+	if err := p.call(ctx, "Page.navigate", map[string]any{"url": rawURL}, &navigation); err != nil {
+		return nil, err
+	}
+	if navigation.ErrorText != "" {
+		return nil, errors.NewRequestErrorf("failed to load page: %s", navigation.ErrorText).WithRawURL(rawURL)
+	}
+	select {
+	case <-loaded:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	mutex.Lock()
+	document := documents[navigation.LoaderID]
+	mutex.Unlock()
+	if document == nil {
+		return nil, errors.NewRequestErrorf("no response received for page").WithRawURL(rawURL)
+	}
+	if mediaType := MediaType(document.MimeType); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, errors.NewRequestErrorf("unexpected content type: %s", document.MimeType).
+			WithStatusCode(document.Status).
+			WithRawURL(rawURL)
+	}
+	return document, nil
+}
+
+// evaluate evaluates a JavaScript expression in the page, awaiting promises,
+// and decodes its value into result, if not nil.
+func (p *browserPage) evaluate(ctx context.Context, expression string, result any) error {
+	var evaluation struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text      string `json:"text"`
+			Exception struct {
+				Description string `json:"description"`
+			} `json:"exception"`
+		} `json:"exceptionDetails"`
+	}
+	err := p.call(ctx, "Runtime.evaluate", map[string]any{
+		"expression":    expression,
+		"returnByValue": true,
+		"awaitPromise":  true,
+	}, &evaluation)
+	if err != nil {
+		return err
+	}
+	if details := evaluation.ExceptionDetails; details != nil {
+		if details.Exception.Description != "" {
+			return fmt.Errorf("script error: %s", details.Exception.Description)
+		}
+		return fmt.Errorf("script error: %s", details.Text)
+	}
+	if result != nil && len(evaluation.Result.Value) > 0 {
+		return json.Unmarshal(evaluation.Result.Value, result)
+	}
+	return nil
+}
+
+// html returns the serialized DOM of the page, with its doctype.
+func (p *browserPage) html(ctx context.Context) (string, error) {
+	var content string
+	err := p.evaluate(ctx, `(document.doctype ? new XMLSerializer().serializeToString(document.doctype) : "") +
+		document.documentElement.outerHTML`, &content)
+	return content, err
+}
+
+// Exists implements the ActionPage interface.
+func (p *browserPage) Exists(ctx context.Context, selector string) (bool, error) {
+	var exists bool
+	err := p.evaluate(ctx, fmt.Sprintf("document.querySelector(%s) !== null", jsString(selector)), &exists)
+	return exists, err
+}
+
+// Run implements the ActionPage interface.
+func (p *browserPage) Run(ctx context.Context, action TypedAction) (*ActionOutput, error) {
+	switch action := action.(type) {
+	case *WaitAction:
+		return nil, p.wait(ctx, action)
+	case *ScrollToBottomAction:
+		return nil, p.scrollToBottom(ctx, action)
+	case *ScreenshotAction:
+		data, err := p.screenshot(ctx, action)
+		if err != nil {
+			return nil, err
+		}
+		return &ActionOutput{Screenshot: data}, nil
+	case *PDFAction:
+		data, err := p.pdf(ctx, action)
+		if err != nil {
+			return nil, err
+		}
+		return &ActionOutput{PDF: data}, nil
+	case *CaptureHTMLAction:
+		content, err := p.html(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &ActionOutput{HTML: content}, nil
+	}
+	return nil, fmt.Errorf("unsupported action type: %s", action.GetType())
+}
+
+// wait waits for an element to appear, then for the duration, if given.
+func (p *browserPage) wait(ctx context.Context, action *WaitAction) error {
+	if action.Selector != "" {
+		for {
+			exists, err := p.Exists(ctx, action.Selector)
+			if err != nil {
+				return err
+			}
+			if exists {
+				break
+			}
+			if err := sleepContext(ctx, 100*time.Millisecond); err != nil {
+				return fmt.Errorf("no element matches %q: %w", action.Selector, err)
+			}
+		}
+	}
+	if action.Duration > 0 {
+		return sleepContext(ctx, time.Duration(action.Duration)*time.Millisecond)
+	}
+	return nil
+}
+
+// scrollToBottom scrolls to the bottom of the page repeatedly.
+func (p *browserPage) scrollToBottom(ctx context.Context, action *ScrollToBottomAction) error {
+	iterations, wait := action.MaxIterations, action.Wait
+	if iterations <= 0 {
+		iterations = 10
+	}
+	if wait <= 0 {
+		wait = 1000
+	}
+	const heightExpression = "document.documentElement.scrollHeight"
+	var height float64
+	if err := p.evaluate(ctx, heightExpression, &height); err != nil {
+		return err
+	}
+	for range iterations {
+		if err := p.evaluate(ctx, "window.scrollTo(0, "+heightExpression+")", nil); err != nil {
+			return err
+		}
+		if err := sleepContext(ctx, time.Duration(wait)*time.Millisecond); err != nil {
+			return err
+		}
+		var newHeight float64
+		if err := p.evaluate(ctx, heightExpression, &newHeight); err != nil {
+			return err
+		}
+		if action.StopOnNoNewContent && newHeight <= height {
+			return nil
+		}
+		height = newHeight
+	}
+	return nil
+}
+
+// screenshot captures the page, or a region or element of it, as a base64
+// encoded PNG.
+func (p *browserPage) screenshot(ctx context.Context, action *ScreenshotAction) (string, error) {
+	params := map[string]any{"format": "png"}
+	clip := action.Clip
+	switch {
+	case action.Selector != "":
+		err := p.evaluate(ctx, fmt.Sprintf(`(() => {
+			const e = document.querySelector(%s);
+			if (!e) return null;
+			const r = e.getBoundingClientRect();
+			return {x: r.left + window.scrollX, y: r.top + window.scrollY, width: r.width, height: r.height};
+		})()`, jsString(action.Selector)), &clip)
+		if err != nil {
+			return "", err
+		}
+		if clip == nil {
+			return "", fmt.Errorf("no element matches %q", action.Selector)
+		}
+	case action.FullPage && clip == nil:
+		var metrics struct {
+			CSSContentSize struct {
+				Width  float64 `json:"width"`
+				Height float64 `json:"height"`
+			} `json:"cssContentSize"`
+		}
+		if err := p.call(ctx, "Page.getLayoutMetrics", nil, &metrics); err != nil {
+			return "", err
+		}
+		clip = &ClipRegion{Width: metrics.CSSContentSize.Width, Height: metrics.CSSContentSize.Height}
+	}
+	if clip != nil {
+		params["clip"] = map[string]any{"x": clip.X, "y": clip.Y, "width": clip.Width, "height": clip.Height, "scale": 1}
+		params["captureBeyondViewport"] = true
+	}
+	var screenshot struct {
+		Data string `json:"data"`
+	}
+	err := p.call(ctx, "Page.captureScreenshot", params, &screenshot)
+	return screenshot.Data, err
+}
+
+// paperSizes are the sizes of the paper formats of PDFs, in inches.
+var paperSizes = map[string][2]float64{
+	"letter":  {8.5, 11},
+	"legal":   {8.5, 14},
+	"tabloid": {11, 17},
+	"ledger":  {17, 11},
+	"a0":      {33.11, 46.81},
+	"a1":      {23.39, 33.11},
+	"a2":      {16.54, 23.39},
+	"a3":      {11.69, 16.54},
+	"a4":      {8.27, 11.69},
+	"a5":      {5.83, 8.27},
+	"a6":      {4.13, 5.83},
+}
+
+// pdf prints the page as a base64 encoded PDF.
+func (p *browserPage) pdf(ctx context.Context, action *PDFAction) (string, error) {
+	format := strings.ToLower(action.Format)
+	if format == "" {
+		format = "letter"
+	}
+	size, ok := paperSizes[format]
+	if !ok {
+		return "", fmt.Errorf("unknown pdf format %q", action.Format)
+	}
+	var pdf struct {
+		Data string `json:"data"`
+	}
+	err := p.call(ctx, "Page.printToPDF", map[string]any{
+		"paperWidth":      size[0],
+		"paperHeight":     size[1],
+		"printBackground": true,
+	}, &pdf)
+	return pdf.Data, err
+}
+
+// jsString returns a JavaScript string literal of a value.
+func jsString(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
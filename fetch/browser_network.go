@@ -0,0 +1,150 @@
+package fetch
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// networkRecorder records the requests of a page selected by a network
+// capture, along with their responses and bodies.
+type networkRecorder struct {
+	page     *browserPage
+	capture  *NetworkCapture
+	mutex    sync.Mutex
+	requests []*CapturedRequest
+	byID     map[string]*CapturedRequest
+	bodies   sync.WaitGroup // reads of response bodies
+	stopped  bool
+}
+
+// captureNetwork starts recording the requests of the page selected by the
+// network capture.
+func (p *browserPage) captureNetwork(ctx context.Context, capture *NetworkCapture) *networkRecorder {
+	r := &networkRecorder{page: p, capture: capture, byID: map[string]*CapturedRequest{}}
+	p.on(func(method string, params json.RawMessage) {
+		r.handle(ctx, method, params)
+	})
+	return r
+}
+
+// handle records the network events of a captured request.
+func (r *networkRecorder) handle(ctx context.Context, method string, params json.RawMessage) {
+	switch method {
+	case "Network.requestWillBeSent":
+		var event struct {
+			RequestID string `json:"requestId"`
+			Type      string `json:"type"`
+			Request   struct {
+				URL      string `json:"url"`
+				Method   string `json:"method"`
+				PostData string `json:"postData"`
+			} `json:"request"`
+		}
+		if json.Unmarshal(params, &event) != nil || !r.capture.Captures(event.Type, event.Request.URL) {
+			return
+		}
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.stopped {
+			return
+		}
+		// Redirects are sent with the ID of the redirected request
+		request := r.byID[event.RequestID]
+		if request == nil {
+			request = &CapturedRequest{}
+			r.byID[event.RequestID] = request
+			r.requests = append(r.requests, request)
+		}
+		request.URL = event.Request.URL
+		request.Method = event.Request.Method
+		request.ResourceType = strings.ToLower(event.Type)
+		request.RequestBody = event.Request.PostData
+	case "Network.responseReceived":
+		var event struct {
+			RequestID string          `json:"requestId"`
+			Response  browserDocument `json:"response"`
+		}
+		if json.Unmarshal(params, &event) != nil {
+			return
+		}
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		request := r.byID[event.RequestID]
+		if request == nil || r.stopped {
+			return
+		}
+		request.StatusCode = event.Response.Status
+		request.ContentType = event.Response.MimeType
+		request.Headers = make(map[string]string, len(event.Response.Headers))
+		for name, value := range event.Response.Headers {
+			value, _, _ = strings.Cut(value, "\n")
+			request.Headers[name] = value
+		}
+	case "Network.loadingFinished":
+		var event struct {
+			RequestID string `json:"requestId"`
+		}
+		if json.Unmarshal(params, &event) != nil {
+			return
+		}
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		request := r.byID[event.RequestID]
+		if request == nil || r.stopped {
+			return
+		}
+		// Read the body from another goroutine, as handlers must not block
+		r.bodies.Add(1)
+		go func() {
+			defer r.bodies.Done()
+			r.readBody(ctx, event.RequestID, request)
+		}()
+	}
+}
+
+// readBody reads the response body of a request, truncated to the size
+// limit of the capture. Bodies the browser no longer holds are left empty.
+func (r *networkRecorder) readBody(ctx context.Context, requestID string, request *CapturedRequest) {
+	var result struct {
+		Body          string `json:"body"`
+		Base64Encoded bool   `json:"base64Encoded"`
+	}
+	if r.page.call(ctx, "Network.getResponseBody", map[string]any{"requestId": requestID}, &result) != nil {
+		return
+	}
+	body := result.Body
+	if result.Base64Encoded {
+		data, err := base64.StdEncoding.DecodeString(result.Body)
+		if err != nil {
+			return
+		}
+		body = string(data)
+	}
+	maxSize := r.capture.MaxBodySize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxCapturedBodySize
+	}
+	truncated := len(body) > maxSize
+	if truncated {
+		body = body[:maxSize]
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	request.Body = body
+	request.Truncated = truncated
+}
+
+// results stops the recording and returns the captured requests, in the
+// order they were sent, once their bodies are read.
+func (r *networkRecorder) results() []*CapturedRequest {
+	r.mutex.Lock()
+	r.stopped = true
+	r.mutex.Unlock()
+	r.bodies.Wait()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.requests
+}
@@ -0,0 +1,201 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// DefaultBrowserHealthCheckInterval is the interval between the health
+// checks of the browser of a BrowserFetcher.
+const DefaultBrowserHealthCheckInterval = 30 * time.Second
+
+// BrowserPoolStats describes the pages of a BrowserFetcher.
+type BrowserPoolStats struct {
+	// OpenPages is the number of pages open, i.e. fetches in progress.
+	OpenPages int `json:"open_pages"`
+
+	// Pages is the number of pages opened since the fetcher was created.
+	Pages int `json:"pages"`
+
+	// Restarts is the number of times the browser was restarted, or
+	// reconnected to, after being recycled or failing a health check.
+	Restarts int `json:"restarts"`
+}
+
+// PoolStats returns the stats of the pages of the fetcher.
+func (f *BrowserFetcher) PoolStats() BrowserPoolStats {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.stats
+}
+
+// acquire waits for a page slot, checks the health of the browser if due,
+// and waits for any recycling of the browser to complete. The returned
+// function releases the slot once the page is closed, and recycles the
+// browser if due.
+func (f *BrowserFetcher) acquire(ctx context.Context) (func(), error) {
+	if f.slots != nil {
+		select {
+		case f.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	f.checkHealth()
+	f.recycling.RLock()
+	f.mutex.Lock()
+	f.stats.OpenPages++
+	f.stats.Pages++
+	f.pages++
+	f.mutex.Unlock()
+	return func() {
+		f.mutex.Lock()
+		f.stats.OpenPages--
+		recycle := f.recycleDue()
+		f.mutex.Unlock()
+		f.recycling.RUnlock()
+		if f.slots != nil {
+			<-f.slots
+		}
+		if recycle {
+			f.recycle()
+		}
+	}, nil
+}
+
+// recycleDue returns true if the browser served RecycleAfter pages, exceeds
+// MaxMemory or failed a health check. The mutex must be held.
+func (f *BrowserFetcher) recycleDue() bool {
+	if f.closed || f.conn == nil {
+		return false
+	}
+	return (f.recycleAfter > 0 && f.pages >= f.recycleAfter) || f.overMemory || f.unhealthy
+}
+
+// recycle stops the browser once its pages are closed, so that the next
+// fetch starts a new one. Fetches wait meanwhile.
+func (f *BrowserFetcher) recycle() {
+	f.recycling.Lock()
+	defer f.recycling.Unlock()
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.recycleDue() {
+		f.restart()
+	}
+}
+
+// restart closes the connection to the browser, and stops the browser if it
+// was launched by the fetcher. The mutex must be held.
+func (f *BrowserFetcher) restart() {
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+	if f.process != nil {
+		f.process.stop()
+		f.process = nil
+	}
+	f.pages = 0
+	f.overMemory = false
+	f.unhealthy = false
+	f.stats.Restarts++
+}
+
+// checkHealth pings the browser at most once per health check interval, and
+// measures the memory of the browser it launched. An unresponsive browser is
+// restarted once its open pages are closed, as when recycled.
+func (f *BrowserFetcher) checkHealth() {
+	f.mutex.Lock()
+	conn := f.conn
+	due := conn != nil && !f.closed && time.Since(f.lastCheck) >= f.healthCheckInterval
+	pid := 0
+	if due {
+		f.lastCheck = time.Now()
+		if f.maxMemory > 0 && f.process != nil {
+			pid = f.process.cmd.Process.Pid
+		}
+	}
+	f.mutex.Unlock()
+	if !due {
+		return
+	}
+
+	// The check doesn't use the context of the fetch, whose deadline may be
+	// about to expire after waiting for a page slot
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	healthy := conn.call(ctx, "", "Browser.getVersion", nil, nil) == nil
+	overMemory := false
+	if healthy && pid > 0 {
+		usage, err := processTreeMemory(pid)
+		overMemory = err == nil && usage > f.maxMemory
+	}
+
+	f.mutex.Lock()
+	if f.conn == conn {
+		f.unhealthy = f.unhealthy || !healthy
+		f.overMemory = f.overMemory || overMemory
+	}
+	f.mutex.Unlock()
+	if !healthy {
+		f.recycle()
+	}
+}
+
+// processTreeMemory returns the resident memory of a process and of its
+// descendants, e.g. the renderer processes of a browser, in bytes. It is
+// only supported on systems with a /proc file system, such as Linux.
+func processTreeMemory(pid int) (int64, error) {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return 0, err
+	}
+	if len(stats) == 0 {
+		return 0, os.ErrNotExist
+	}
+	children := map[int][]int{}
+	for _, path := range stats {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // the process exited
+		}
+		// The command name is in parentheses and may contain spaces, so the
+		// fields are read after its closing parenthesis
+		i := bytes.LastIndexByte(data, ')')
+		if i < 0 {
+			continue
+		}
+		fields := bytes.Fields(data[i+1:])
+		if len(fields) < 2 {
+			continue
+		}
+		child, err1 := strconv.Atoi(filepath.Base(filepath.Dir(path)))
+		parent, err2 := strconv.Atoi(string(fields[1]))
+		if err1 == nil && err2 == nil {
+			children[parent] = append(children[parent], child)
+		}
+	}
+	var total int64
+	pageSize := int64(os.Getpagesize())
+	queue := []int{pid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = append(queue[1:], children[current]...)
+		data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(current), "statm"))
+		if err != nil {
+			continue
+		}
+		fields := bytes.Fields(data)
+		if len(fields) < 2 {
+			continue
+		}
+		if pages, err := strconv.ParseInt(string(fields[1]), 10, 64); err == nil {
+			total += pages * pageSize
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,166 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// browserStorageState is the storage state of a browser context, in the
+// format of Playwright storage states.
+type browserStorageState struct {
+	Cookies []browserCookie        `json:"cookies"`
+	Origins []browserStorageOrigin `json:"origins"`
+}
+
+// browserCookie is a cookie of a storage state. Its fields are named as
+// those of the cookies of the DevTools protocol. Session cookies expire at
+// -1.
+type browserCookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires"`
+	HTTPOnly bool    `json:"httpOnly"`
+	Secure   bool    `json:"secure"`
+	SameSite string  `json:"sameSite,omitempty"`
+}
+
+// browserStorageOrigin is the local storage of an origin.
+type browserStorageOrigin struct {
+	Origin       string               `json:"origin"`
+	LocalStorage []browserStorageItem `json:"localStorage"`
+}
+
+// browserStorageItem is an item of the local storage of an origin.
+type browserStorageItem struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// parseStorageState decodes the storage state of a request.
+func parseStorageState(state map[string]any) (*browserStorageState, error) {
+	var parsed browserStorageState
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = json.Unmarshal(data, &parsed)
+	}
+	if err != nil {
+		return nil, errors.NewInvalidField("storage_state", "%s", err)
+	}
+	return &parsed, nil
+}
+
+// restoreStorageState sets the cookies of a storage state in the browser
+// context of the page, and seeds the local storage of its origins as their
+// documents are created.
+func (p *browserPage) restoreStorageState(ctx context.Context, state *browserStorageState) error {
+	if len(state.Cookies) > 0 {
+		cookies := make([]map[string]any, 0, len(state.Cookies))
+		for _, cookie := range state.Cookies {
+			param := map[string]any{
+				"name":     cookie.Name,
+				"value":    cookie.Value,
+				"domain":   cookie.Domain,
+				"path":     cookie.Path,
+				"httpOnly": cookie.HTTPOnly,
+				"secure":   cookie.Secure,
+			}
+			if cookie.Expires > 0 {
+				param["expires"] = cookie.Expires
+			}
+			if cookie.SameSite != "" {
+				param["sameSite"] = cookie.SameSite
+			}
+			cookies = append(cookies, param)
+		}
+		if err := p.call(ctx, "Network.setCookies", map[string]any{"cookies": cookies}, nil); err != nil {
+			return err
+		}
+	}
+	if len(state.Origins) == 0 {
+		return nil
+	}
+	items := make(map[string][]browserStorageItem, len(state.Origins))
+	for _, origin := range state.Origins {
+		items[origin.Origin] = origin.LocalStorage
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return p.call(ctx, "Page.addScriptToEvaluateOnNewDocument", map[string]any{
+		"source": fmt.Sprintf(`(() => {
+			const items = %s[location.origin];
+			if (!items) return;
+			try {
+				for (const item of items) localStorage.setItem(item.name, item.value);
+			} catch (e) {}
+		})()`, data),
+	}, nil)
+}
+
+// storageState returns the cookies of the browser context of the page and
+// the local storage of the origin of the page, along with the local storage
+// of the other origins of the restored state, if any. Returns nil if the
+// state is empty.
+func (p *browserPage) storageState(ctx context.Context, restored *browserStorageState) (map[string]any, error) {
+	var state browserStorageState
+	var cookies struct {
+		Cookies []browserCookie `json:"cookies"`
+	}
+	err := p.conn.call(ctx, "", "Storage.getCookies", map[string]any{"browserContextId": p.contextID}, &cookies)
+	if err != nil {
+		return nil, err
+	}
+	state.Cookies = cookies.Cookies
+	for i := range state.Cookies {
+		// Chrome omits the default policy of cookies
+		if state.Cookies[i].SameSite == "" {
+			state.Cookies[i].SameSite = "Lax"
+		}
+	}
+
+	var current *browserStorageOrigin
+	err = p.evaluate(ctx, `(() => {
+		try {
+			return {
+				origin: location.origin,
+				localStorage: Object.entries(localStorage).map(([name, value]) => ({name, value})),
+			};
+		} catch (e) {
+			return null;
+		}
+	})()`, &current)
+	if err != nil {
+		return nil, err
+	}
+	if restored != nil {
+		for _, origin := range restored.Origins {
+			if current == nil || origin.Origin != current.Origin {
+				state.Origins = append(state.Origins, origin)
+			}
+		}
+	}
+	if current != nil && len(current.LocalStorage) > 0 {
+		state.Origins = append(state.Origins, *current)
+	}
+	if len(state.Cookies) == 0 && len(state.Origins) == 0 {
+		return nil, nil
+	}
+	if state.Cookies == nil {
+		state.Cookies = []browserCookie{}
+	}
+	if state.Origins == nil {
+		state.Origins = []browserStorageOrigin{}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]any
+	return result, json.Unmarshal(data, &result)
+}
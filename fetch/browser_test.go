@@ -0,0 +1,409 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// fakeBrowser is a DevTools endpoint serving a single page, recording the
+// commands it receives.
+type fakeBrowser struct {
+	html        string
+	errorText   string
+	unhealthy   bool // fail Browser.getVersion
+	cookies     []map[string]any
+	mutex       sync.Mutex
+	calls       map[string][]map[string]any
+	connections int
+}
+
+func newFakeBrowser(t *testing.T, html string) (*fakeBrowser, *httptest.Server) {
+	browser := &fakeBrowser{html: html, calls: map[string][]map[string]any{}}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/devtools/browser/test"
+	mux.HandleFunc("/json/version", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"webSocketDebuggerUrl": wsURL})
+	})
+	mux.Handle("/devtools/browser/test", websocket.Handler(browser.serve))
+	return browser, server
+}
+
+func (b *fakeBrowser) called(method string) []map[string]any {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.calls[method]
+}
+
+func (b *fakeBrowser) serve(ws *websocket.Conn) {
+	send := func(msg map[string]any) { websocket.JSON.Send(ws, msg) }
+	b.mutex.Lock()
+	b.connections++
+	b.mutex.Unlock()
+	for {
+		var msg cdpMessage
+		if websocket.JSON.Receive(ws, &msg) != nil {
+			return
+		}
+		var params map[string]any
+		json.Unmarshal(msg.Params, &params)
+		b.mutex.Lock()
+		b.calls[msg.Method] = append(b.calls[msg.Method], params)
+		unhealthy := b.unhealthy
+		b.mutex.Unlock()
+		if msg.Method == "Browser.getVersion" && unhealthy {
+			send(map[string]any{"id": msg.ID, "error": map[string]any{"code": -32000, "message": "browser unresponsive"}})
+			continue
+		}
+
+		result, loaded := map[string]any{}, false
+		switch msg.Method {
+		case "Browser.getVersion":
+			result["userAgent"] = "Mozilla/5.0 HeadlessChrome/126.0.0.0"
+		case "Target.createBrowserContext":
+			result["browserContextId"] = "context-1"
+		case "Target.createTarget":
+			result["targetId"] = "target-1"
+		case "Target.attachToTarget":
+			result["sessionId"] = "session-1"
+		case "Page.navigate":
+			if b.errorText != "" {
+				result["errorText"] = b.errorText
+				break
+			}
+			b.sendRequests(send, msg.SessionID)
+			send(map[string]any{"sessionId": msg.SessionID, "method": "Network.responseReceived", "params": map[string]any{
+				"loaderId": "loader-1",
+				"type":     "Document",
+				"response": map[string]any{
+					"url":      "https://example.com/home",
+					"status":   200,
+					"headers":  map[string]string{"Content-Type": "text/html", "Set-Cookie": "a=1\nb=2"},
+					"mimeType": "text/html",
+				},
+			}})
+			result["frameId"], result["loaderId"] = "target-1", "loader-1"
+			loaded = true
+		case "Runtime.evaluate":
+			expression := params["expression"].(string)
+			switch {
+			case strings.Contains(expression, "localStorage"):
+				result["result"] = map[string]any{"value": map[string]any{
+					"origin":       "https://example.com",
+					"localStorage": []map[string]any{{"name": "theme", "value": "dark"}},
+				}}
+			case strings.Contains(expression, "outerHTML"):
+				result["result"] = map[string]any{"value": b.html}
+			case strings.Contains(expression, "querySelector"):
+				result["result"] = map[string]any{"value": strings.Contains(expression, "#app")}
+			}
+		case "Page.captureScreenshot":
+			result["data"] = "c2NyZWVuc2hvdA=="
+		case "Storage.getCookies":
+			result["cookies"] = b.cookies
+		case "Network.getResponseBody":
+			if params["requestId"] == "request-1" {
+				result["body"] = `{"items":[1,2,3]}`
+			} else {
+				result["body"], result["base64Encoded"] = "Y29uc29sZS5sb2coMSk=", true
+			}
+		}
+		send(map[string]any{"id": msg.ID, "sessionId": msg.SessionID, "result": result})
+		if loaded {
+			send(map[string]any{"sessionId": msg.SessionID, "method": "Page.loadEventFired", "params": map[string]any{}})
+		}
+	}
+}
+
+// sendRequests sends the network events of an API call and of a script
+// loaded by the page.
+func (b *fakeBrowser) sendRequests(send func(map[string]any), sessionID string) {
+	for _, request := range []struct {
+		id, resourceType, url, method, mimeType string
+	}{
+		{"request-1", "XHR", "https://example.com/api/items", "POST", "application/json"},
+		{"request-2", "Script", "https://example.com/app.js", "GET", "text/javascript"},
+	} {
+		event := func(method string, params map[string]any) {
+			params["requestId"] = request.id
+			send(map[string]any{"sessionId": sessionID, "method": method, "params": params})
+		}
+		requestParams := map[string]any{"url": request.url, "method": request.method}
+		if request.method == "POST" {
+			requestParams["postData"] = `{"page":1}`
+		}
+		event("Network.requestWillBeSent", map[string]any{"type": request.resourceType, "request": requestParams})
+		event("Network.responseReceived", map[string]any{"type": request.resourceType, "response": map[string]any{
+			"url":      request.url,
+			"status":   200,
+			"headers":  map[string]string{"Content-Type": request.mimeType},
+			"mimeType": request.mimeType,
+		}})
+		event("Network.loadingFinished", map[string]any{})
+	}
+}
+
+func TestBrowserFetcher(t *testing.T) {
+	browser, server := newFakeBrowser(t, `<!DOCTYPE html><html><head><title>App</title></head><body><div id="app"><h1>Rendered</h1></div></body></html>`)
+	fetcher := NewBrowserFetcher(BrowserFetcherOptions{Endpoint: server.URL})
+	defer fetcher.Close()
+
+	response, err := fetcher.Fetch(context.Background(), &Request{
+		URL:     "https://example.com",
+		Mobile:  true,
+		Headers: map[string]string{"X-Test": "1"},
+		Formats: []string{"html"},
+		Actions: []Action{
+			NewWaitAction(WaitActionOptions{Selector: "#app"}),
+			NewScreenshotAction(ScreenshotActionOptions{}),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", response.URL)
+	require.Equal(t, "https://example.com/home", response.FinalURL)
+	require.Equal(t, 200, response.StatusCode)
+	require.Equal(t, "a=1", response.Headers["Set-Cookie"])
+	require.Contains(t, response.HTML, "<h1>Rendered</h1>")
+	require.Equal(t, "App", response.Metadata.Title)
+	require.Equal(t, "c2NyZWVuc2hvdA==", response.Screenshot)
+	require.Len(t, response.ActionResults, 2)
+	require.Equal(t, ActionSucceeded, response.ActionResults[1].Status)
+
+	metrics := browser.called("Emulation.setDeviceMetricsOverride")
+	require.Len(t, metrics, 1)
+	require.Equal(t, true, metrics[0]["mobile"])
+	require.Equal(t, DefaultMobileUserAgent, browser.called("Network.setUserAgentOverride")[0]["userAgent"])
+	require.Equal(t, map[string]any{"X-Test": "1"}, browser.called("Network.setExtraHTTPHeaders")[0]["headers"])
+	require.Len(t, browser.called("Target.disposeBrowserContext"), 1)
+
+	// Desktop requests use the user agent of the browser, without the
+	// headless marker
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: "https://example.com"})
+	require.NoError(t, err)
+	require.Equal(t, "Mozilla/5.0 Chrome/126.0.0.0", browser.called("Network.setUserAgentOverride")[1]["userAgent"])
+	require.Len(t, browser.called("Emulation.setDeviceMetricsOverride"), 1)
+}
+
+func TestBrowserFetcher_NavigationError(t *testing.T) {
+	browser, server := newFakeBrowser(t, "")
+	browser.errorText = "net::ERR_NAME_NOT_RESOLVED"
+	fetcher := NewBrowserFetcher(BrowserFetcherOptions{Endpoint: server.URL})
+	defer fetcher.Close()
+
+	_, err := fetcher.Fetch(context.Background(), &Request{URL: "https://missing.example"})
+	var requestErr *errors.RequestError
+	require.True(t, errors.As(err, &requestErr))
+	require.Contains(t, err.Error(), "net::ERR_NAME_NOT_RESOLVED")
+	require.Len(t, browser.called("Target.closeTarget"), 1)
+}
+
+func TestBrowserFetcher_Capabilities(t *testing.T) {
+	fetcher := NewBrowserFetcher(BrowserFetcherOptions{})
+	capabilities := fetcher.Capabilities()
+	require.True(t, capabilities.Mobile)
+	require.True(t, capabilities.Supports("pdf"))
+	require.True(t, capabilities.StorageState)
+	require.True(t, capabilities.NetworkCapture)
+	require.NoError(t, ValidateRequest(&Request{
+		URL:     "https://example.com",
+		Mobile:  true,
+		Actions: []Action{NewScrollToBottomAction(ScrollToBottomActionOptions{})},
+	}, capabilities))
+}
+
+func TestBrowserFetcher_StorageState(t *testing.T) {
+	browser, server := newFakeBrowser(t, "<html><body>Page</body></html>")
+	browser.cookies = []map[string]any{{
+		"name": "session", "value": "2", "domain": "example.com", "path": "/",
+		"expires": -1, "httpOnly": true, "secure": true, "session": true,
+	}}
+	fetcher := NewBrowserFetcher(BrowserFetcherOptions{Endpoint: server.URL})
+	defer fetcher.Close()
+
+	var state map[string]any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"cookies": [{"name": "session", "value": "1", "domain": "example.com", "path": "/", "expires": -1, "httpOnly": true, "secure": true, "sameSite": "Strict"}],
+		"origins": [
+			{"origin": "https://example.com", "localStorage": [{"name": "theme", "value": "light"}]},
+			{"origin": "https://other.com", "localStorage": [{"name": "key", "value": "value"}]}
+		]
+	}`), &state))
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: "https://example.com", StorageState: state})
+	require.NoError(t, err)
+
+	// The cookies and local storage of the request are restored
+	cookies := browser.called("Network.setCookies")
+	require.Len(t, cookies, 1)
+	require.Equal(t, []any{map[string]any{
+		"name": "session", "value": "1", "domain": "example.com", "path": "/",
+		"httpOnly": true, "secure": true, "sameSite": "Strict",
+	}}, cookies[0]["cookies"])
+	scripts := browser.called("Page.addScriptToEvaluateOnNewDocument")
+	require.Len(t, scripts, 1)
+	require.Contains(t, scripts[0]["source"], `"https://other.com":[{"name":"key","value":"value"}]`)
+	require.Equal(t, "context-1", browser.called("Storage.getCookies")[0]["browserContextId"])
+
+	// The state of the page is returned, along with the local storage of
+	// the other origins
+	data, err := json.Marshal(response.StorageState)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"cookies": [{"name": "session", "value": "2", "domain": "example.com", "path": "/", "expires": -1, "httpOnly": true, "secure": true, "sameSite": "Lax"}],
+		"origins": [
+			{"origin": "https://other.com", "localStorage": [{"name": "key", "value": "value"}]},
+			{"origin": "https://example.com", "localStorage": [{"name": "theme", "value": "dark"}]}
+		]
+	}`, string(data))
+
+	// Invalid states are rejected
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: "https://example.com", StorageState: map[string]any{"cookies": "session=1"}})
+	require.True(t, errors.IsBadRequest(err))
+	require.Equal(t, "storage_state", err.(*errors.BadRequest).Field)
+}
+
+func TestBrowserFetcher_CaptureNetwork(t *testing.T) {
+	browser, server := newFakeBrowser(t, "<html><body>Page</body></html>")
+	fetcher := NewBrowserFetcher(BrowserFetcherOptions{Endpoint: server.URL})
+	defer fetcher.Close()
+
+	// Requests are not captured by default
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: "https://example.com"})
+	require.NoError(t, err)
+	require.Nil(t, response.Network)
+	require.Empty(t, browser.called("Network.getResponseBody"))
+
+	// API calls are captured, with their bodies truncated to the limit
+	response, err = fetcher.Fetch(context.Background(), &Request{
+		URL:            "https://example.com",
+		CaptureNetwork: &NetworkCapture{URLContains: []string{"/api/"}, MaxBodySize: 10},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*CapturedRequest{{
+		URL:          "https://example.com/api/items",
+		Method:       "POST",
+		ResourceType: "xhr",
+		RequestBody:  `{"page":1}`,
+		StatusCode:   200,
+		Headers:      map[string]string{"Content-Type": "application/json"},
+		ContentType:  "application/json",
+		Body:         `{"items":[`,
+		Truncated:    true,
+	}}, response.Network)
+
+	// Base64 encoded bodies are decoded
+	response, err = fetcher.Fetch(context.Background(), &Request{
+		URL:            "https://example.com",
+		CaptureNetwork: &NetworkCapture{Types: []string{"script"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Network, 1)
+	require.Equal(t, "https://example.com/app.js", response.Network[0].URL)
+	require.Equal(t, "console.log(1)", response.Network[0].Body)
+	require.False(t, response.Network[0].Truncated)
+}
+
+func TestBrowserFetcher_Pool(t *testing.T) {
+	browser, server := newFakeBrowser(t, "<html><body>Page</body></html>")
+	fetcher := NewBrowserFetcher(BrowserFetcherOptions{
+		Endpoint:            server.URL,
+		MaxPages:            1,
+		RecycleAfter:        2,
+		HealthCheckInterval: time.Nanosecond,
+	})
+	defer fetcher.Close()
+
+	// The browser is recycled every two pages, and checked before the
+	// fetches reusing its connection
+	for range 4 {
+		_, err := fetcher.Fetch(context.Background(), &Request{URL: "https://example.com"})
+		require.NoError(t, err)
+	}
+	require.Equal(t, BrowserPoolStats{Pages: 4, Restarts: 2}, fetcher.PoolStats())
+	browser.mutex.Lock()
+	require.Equal(t, 2, browser.connections)
+	browser.mutex.Unlock()
+	require.Len(t, browser.called("Browser.getVersion"), 3)
+
+	// Fetches wait for a page slot
+	release, err := fetcher.acquire(context.Background())
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = fetcher.Fetch(ctx, &Request{URL: "https://example.com"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	release()
+}
+
+func TestBrowserFetcher_UnhealthyRestart(t *testing.T) {
+	browser, server := newFakeBrowser(t, "<html><body>Page</body></html>")
+	fetcher := NewBrowserFetcher(BrowserFetcherOptions{
+		Endpoint:            server.URL,
+		MaxPages:            2,
+		HealthCheckInterval: time.Nanosecond,
+	})
+	defer fetcher.Close()
+	_, err := fetcher.Fetch(context.Background(), &Request{URL: "https://example.com"})
+	require.NoError(t, err)
+
+	// A page stays open on the browser when it fails a health check
+	release, err := fetcher.acquire(context.Background())
+	require.NoError(t, err)
+	browser.mutex.Lock()
+	browser.unhealthy = true
+	browser.mutex.Unlock()
+	done := make(chan error)
+	go func() {
+		_, err := fetcher.Fetch(context.Background(), &Request{URL: "https://example.com"})
+		done <- err
+	}()
+
+	// The browser is restarted once the open page is closed, and the fetch
+	// that found it unhealthy proceeds with a new connection
+	time.Sleep(50 * time.Millisecond)
+	require.Zero(t, fetcher.PoolStats().Restarts)
+	fetcher.mutex.Lock()
+	require.True(t, fetcher.conn.alive())
+	fetcher.mutex.Unlock()
+	release()
+	require.NoError(t, <-done)
+	require.Equal(t, 1, fetcher.PoolStats().Restarts)
+	browser.mutex.Lock()
+	require.Equal(t, 2, browser.connections)
+	browser.mutex.Unlock()
+}
+
+func TestProcessTreeMemory(t *testing.T) {
+	if _, err := os.Stat("/proc/self/statm"); err != nil {
+		t.Skip("no /proc file system")
+	}
+	usage, err := processTreeMemory(os.Getpid())
+	require.NoError(t, err)
+	require.Greater(t, usage, int64(0))
+}
+
+func TestBrowserFetcher_Warmup(t *testing.T) {
+	browser, server := newFakeBrowser(t, "")
+	fetcher := NewBrowserFetcher(BrowserFetcherOptions{Endpoint: server.URL})
+	defer fetcher.Close()
+
+	require.NoError(t, PrepareFetcher(context.Background(), fetcher))
+	require.Len(t, browser.called("Browser.getVersion"), 2)
+
+	// Browsers that can't be launched fail on warm-up
+	fetcher = NewBrowserFetcher(BrowserFetcherOptions{ExecPath: "/nonexistent/chrome"})
+	defer fetcher.Close()
+	err := PrepareFetcher(context.Background(), fetcher)
+	require.ErrorContains(t, err, "failed to warm up fetcher: failed to launch browser")
+}
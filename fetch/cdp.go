@@ -0,0 +1,224 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// maxCDPMessageSize limits the size of messages received from the browser,
+// which carry screenshots and PDFs.
+const maxCDPMessageSize = 256 << 20 // 256 MB
+
+// cdpMessage is a message of the Chrome DevTools Protocol: a command, its
+// reply, or an event.
+type cdpMessage struct {
+	ID        int64           `json:"id,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *cdpError       `json:"error,omitempty"`
+}
+
+// cdpError is an error replied to a command.
+type cdpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *cdpError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// cdpHandler handles the events of a session. Handlers are called by the
+// reading goroutine, so they must not block.
+type cdpHandler struct {
+	sessionID string
+	handle    func(method string, params json.RawMessage)
+}
+
+// cdpConn is a connection to a browser over the Chrome DevTools Protocol,
+// shared by the sessions of its pages. It is safe for concurrent use.
+type cdpConn struct {
+	ws         *websocket.Conn
+	writeMutex sync.Mutex
+	mutex      sync.Mutex
+	nextID     int64
+	pending    map[int64]chan *cdpMessage
+	handlers   map[int64]*cdpHandler
+	closed     chan struct{}
+	err        error
+}
+
+// dialCDP connects to the browser WebSocket URL of a browser.
+func dialCDP(ctx context.Context, wsURL string) (*cdpConn, error) {
+	config, err := websocket.NewConfig(wsURL, "http://localhost")
+	if err != nil {
+		return nil, fmt.Errorf("invalid browser endpoint: %w", err)
+	}
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to browser: %w", err)
+	}
+	ws.MaxPayloadBytes = maxCDPMessageSize
+	c := &cdpConn{
+		ws:       ws,
+		pending:  map[int64]chan *cdpMessage{},
+		handlers: map[int64]*cdpHandler{},
+		closed:   make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+// read dispatches the replies and events received until the connection is
+// closed.
+func (c *cdpConn) read() {
+	for {
+		var msg cdpMessage
+		if err := websocket.JSON.Receive(c.ws, &msg); err != nil {
+			c.mutex.Lock()
+			c.err = fmt.Errorf("browser connection closed: %w", err)
+			close(c.closed)
+			c.mutex.Unlock()
+			return
+		}
+		c.mutex.Lock()
+		if msg.ID != 0 {
+			if reply, ok := c.pending[msg.ID]; ok {
+				delete(c.pending, msg.ID)
+				reply <- &msg
+			}
+			c.mutex.Unlock()
+			continue
+		}
+		var handlers []*cdpHandler
+		for _, handler := range c.handlers {
+			if handler.sessionID == msg.SessionID {
+				handlers = append(handlers, handler)
+			}
+		}
+		c.mutex.Unlock()
+		for _, handler := range handlers {
+			handler.handle(msg.Method, msg.Params)
+		}
+	}
+}
+
+// call sends a command to the browser, or to the session of a page if a
+// session ID is given, and decodes its result into result, if not nil.
+func (c *cdpConn) call(ctx context.Context, sessionID, method string, params, result any) error {
+	msg := &cdpMessage{SessionID: sessionID, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = data
+	}
+	reply := make(chan *cdpMessage, 1)
+	c.mutex.Lock()
+	if c.err != nil {
+		c.mutex.Unlock()
+		return fmt.Errorf("%s: %w", method, c.err)
+	}
+	c.nextID++
+	msg.ID = c.nextID
+	c.pending[msg.ID] = reply
+	c.mutex.Unlock()
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, msg.ID)
+		c.mutex.Unlock()
+	}()
+
+	c.writeMutex.Lock()
+	err := websocket.JSON.Send(c.ws, msg)
+	c.writeMutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	select {
+	case msg := <-reply:
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %w", method, msg.Error)
+		}
+		if result != nil && len(msg.Result) > 0 {
+			return json.Unmarshal(msg.Result, result)
+		}
+		return nil
+	case <-c.closed:
+		return fmt.Errorf("%s: %w", method, c.err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// on registers a handler of the events of a session, and returns a function
+// that removes it.
+func (c *cdpConn) on(sessionID string, handle func(method string, params json.RawMessage)) func() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.nextID++
+	id := c.nextID
+	c.handlers[id] = &cdpHandler{sessionID: sessionID, handle: handle}
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		delete(c.handlers, id)
+	}
+}
+
+// alive returns true if the connection is open.
+func (c *cdpConn) alive() bool {
+	select {
+	case <-c.closed:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close closes the connection.
+func (c *cdpConn) Close() error {
+	return c.ws.Close()
+}
+
+// browserWebSocketURL returns the browser WebSocket URL of a DevTools
+// endpoint, looking it up from the /json/version page of HTTP endpoints.
+func browserWebSocketURL(ctx context.Context, endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid browser endpoint: %w", err)
+	}
+	switch u.Scheme {
+	case "ws", "wss":
+		return endpoint, nil
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("invalid browser endpoint: unsupported scheme %q", u.Scheme)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/json/version", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to browser: %w", err)
+	}
+	defer resp.Body.Close()
+	var version struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil || version.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("failed to connect to browser: no debugger url at %s", endpoint)
+	}
+	return version.WebSocketDebuggerURL, nil
+}
@@ -0,0 +1,132 @@
+package fetch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deepnoodle-ai/web"
+)
+
+// DefaultChunkSize is the default approximate size of page chunks, in
+// characters.
+const DefaultChunkSize = 1000
+
+// PageChunk is a chunk of the content of a page, ready to be embedded and
+// indexed along with the context needed to cite it.
+type PageChunk struct {
+	URL           string   `json:"url"`
+	Title         string   `json:"title,omitempty"`
+	PublishedTime string   `json:"published_time,omitempty"`
+	HeadingPath   []string `json:"heading_path,omitempty"` // headings the chunk is nested under
+	Index         int      `json:"index"`
+	Text          string   `json:"text"`
+	Start         int      `json:"start"` // byte offset of the chunk in the page markdown
+	End           int      `json:"end"`
+}
+
+// PageChunksOptions defines the options for PageChunks.
+type PageChunksOptions struct {
+	// Size is the approximate size of chunks in characters. Defaults to
+	// DefaultChunkSize.
+	Size int
+}
+
+// PageChunks splits the content of a page into chunks for retrieval
+// augmented generation. The main content of the HTML of the response is
+// converted to markdown, split into sections by heading, and the sections are
+// split into chunks with web.Chunk. Responses without HTML are chunked from
+// their markdown or text. Chunk offsets refer to that markdown or text.
+func PageChunks(response *Response, options PageChunksOptions) ([]*PageChunk, error) {
+	if options.Size <= 0 {
+		options.Size = DefaultChunkSize
+	}
+	content := response.Markdown
+	if response.HTML != "" {
+		doc, err := response.Document()
+		if err != nil {
+			return nil, err
+		}
+		main, err := doc.RenderDocument(web.RenderOptions{OnlyMainContent: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to render main content: %w", err)
+		}
+		if content, err = main.Markdown(); err != nil {
+			return nil, fmt.Errorf("failed to generate markdown: %w", err)
+		}
+	} else if content == "" {
+		content = response.Text
+	}
+
+	var chunks []*PageChunk
+	for _, section := range markdownSections(content) {
+		offset := section.start
+		for _, text := range web.Chunk(content[section.start:section.end], options.Size) {
+			if text == "" {
+				continue
+			}
+			start := offset + strings.Index(content[offset:section.end], text)
+			offset = start + len(text)
+			chunks = append(chunks, &PageChunk{
+				URL:           response.URL,
+				Title:         response.Metadata.Title,
+				PublishedTime: response.Metadata.PublishedTime,
+				HeadingPath:   section.headings,
+				Index:         len(chunks),
+				Text:          text,
+				Start:         start,
+				End:           offset,
+			})
+		}
+	}
+	return chunks, nil
+}
+
+// markdownSection is the text between two headings of a markdown document.
+type markdownSection struct {
+	headings   []string
+	start, end int
+}
+
+// markdownSections splits markdown into sections at ATX headings, outside of
+// code blocks, tracking the path of headings each section is nested under.
+func markdownSections(markdown string) []markdownSection {
+	var sections []markdownSection
+	var path []string
+	levels := []int{}
+	start, offset := 0, 0
+	inCode := false
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		lineStart := offset
+		offset += len(line)
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		level := headingLevel(trimmed)
+		if inCode || level == 0 {
+			continue
+		}
+		sections = append(sections, markdownSection{headings: path, start: start, end: lineStart})
+		for len(levels) > 0 && levels[len(levels)-1] >= level {
+			levels, path = levels[:len(levels)-1], path[:len(path)-1]
+		}
+		levels = append(levels, level)
+		path = append(path[:len(path):len(path)], strings.TrimSpace(strings.Trim(trimmed[level:], "#")))
+		start = offset
+	}
+	return append(sections, markdownSection{headings: path, start: start, end: len(markdown)})
+}
+
+// headingLevel returns the level of an ATX heading line, or 0 if the line
+// isn't a heading.
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
@@ -0,0 +1,61 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageChunks(t *testing.T) {
+	response := &Response{
+		URL: "https://example.com/guide",
+		HTML: `<html><head><title>Guide</title></head><body>
+			<nav><a href="/">Home</a></nav>
+			<main>
+				<p>Welcome to the guide.</p>
+				<h2>Install</h2>
+				<p>Download the binary.</p>
+				<h3>Linux</h3>
+				<p>Use the tarball.</p>
+				<h2>Usage</h2>
+				<p>Run the command.</p>
+			</main>
+			<footer>Copyright</footer>
+		</body></html>`,
+		Metadata: web.Metadata{Title: "Guide", PublishedTime: "2024-05-01T00:00:00Z"},
+	}
+	chunks, err := PageChunks(response, PageChunksOptions{})
+	require.NoError(t, err)
+	require.Len(t, chunks, 4)
+
+	var texts [][]string
+	for i, chunk := range chunks {
+		require.Equal(t, i, chunk.Index)
+		require.Equal(t, "https://example.com/guide", chunk.URL)
+		require.Equal(t, "Guide", chunk.Title)
+		require.Equal(t, "2024-05-01T00:00:00Z", chunk.PublishedTime)
+		require.Less(t, chunk.Start, chunk.End)
+		texts = append(texts, append(chunk.HeadingPath, chunk.Text))
+	}
+	require.Equal(t, [][]string{
+		{"Welcome to the guide."},
+		{"Install", "Download the binary."},
+		{"Install", "Linux", "Use the tarball."},
+		{"Usage", "Run the command."},
+	}, texts)
+}
+
+func TestPageChunksMarkdown(t *testing.T) {
+	markdown := "# Title\n\nFirst sentence. Second sentence.\n\n```\n# x\n```\n"
+	chunks, err := PageChunks(&Response{URL: "https://example.com", Markdown: markdown}, PageChunksOptions{Size: 18})
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+	for _, chunk := range chunks {
+		require.Equal(t, []string{"Title"}, chunk.HeadingPath)
+		require.Equal(t, chunk.Text, markdown[chunk.Start:chunk.End])
+	}
+	require.Equal(t, "First sentence.", chunks[0].Text)
+	require.Equal(t, "Second sentence.", chunks[1].Text)
+	require.Contains(t, chunks[2].Text, "# x")
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-
 	"fmt"
 	"io"
 	"net/http"
@@ -55,7 +54,12 @@ func (c *Client) Fetch(ctx context.Context, request *Request) (*Response, error)
 	if request == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
-	requestBody, err := json.Marshal(request)
+	// Validate a copy, leaving the request of the caller unchanged
+	validated := *request
+	if err := ValidateRequest(&validated, nil); err != nil {
+		return nil, err
+	}
+	requestBody, err := json.Marshal(&validated)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -86,9 +90,9 @@ func (c *Client) Fetch(ctx context.Context, request *Request) (*Response, error)
 
 	if httpResp.StatusCode != 200 {
 		err := fmt.Errorf("request failed with status %d: %s",
-			httpResp.StatusCode, string(responseBody))
+			httpResp.StatusCode, errors.BodySnippet(responseBody))
 		return nil, errors.NewRequestError(err).
-			WithStatusCode(httpResp.StatusCode).
+			WithResponse(httpResp, responseBody).
 			WithRawURL(c.baseURL)
 	}
 
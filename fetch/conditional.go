@@ -0,0 +1,44 @@
+package fetch
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ConditionalHeaders returns the headers of a conditional request for a page,
+// given the validators of a previous response of the page: If-None-Match for
+// its ETag and If-Modified-Since for its Last-Modified date. Fetchers that
+// support conditional requests answer with a 304 Not Modified response
+// without content when the page is unchanged. Returns nil if the response
+// has no validators.
+func ConditionalHeaders(previous *Response) map[string]string {
+	if previous == nil {
+		return nil
+	}
+	var headers map[string]string
+	for name, value := range previous.Headers {
+		var conditional string
+		switch {
+		case strings.EqualFold(name, "ETag"):
+			conditional = "If-None-Match"
+		case strings.EqualFold(name, "Last-Modified"):
+			conditional = "If-Modified-Since"
+		default:
+			continue
+		}
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		headers[conditional] = value
+	}
+	return headers
+}
+
+// NotModified returns true if the response answers a conditional request for
+// an unchanged page, and so has no content.
+func (r *Response) NotModified() bool {
+	return r.StatusCode == http.StatusNotModified
+}
@@ -0,0 +1,33 @@
+package fetch
+
+import (
+	"context"
+	"mime"
+	"strings"
+)
+
+// ContentHandler builds a response from a non-HTML response body. Handlers
+// are registered with the HTTPFetcher by media type, e.g. "application/pdf".
+// The fetcher sets the URL, final URL, status code and headers of the
+// returned response from the HTTP response.
+type ContentHandler interface {
+	HandleContent(ctx context.Context, request *Request, body []byte) (*Response, error)
+}
+
+// ContentHandlerFunc adapts a function to the ContentHandler interface.
+type ContentHandlerFunc func(ctx context.Context, request *Request, body []byte) (*Response, error)
+
+// HandleContent calls f(ctx, request, body).
+func (f ContentHandlerFunc) HandleContent(ctx context.Context, request *Request, body []byte) (*Response, error) {
+	return f(ctx, request, body)
+}
+
+// MediaType returns the lowercased media type of a Content-Type header value,
+// without any parameters such as the charset.
+func MediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
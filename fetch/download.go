@@ -0,0 +1,189 @@
+package fetch
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// DefaultMaxDownloadSize limits the size of downloads.
+const DefaultMaxDownloadSize = 100 * 1024 * 1024 // 100 MB
+
+// DownloadOptions defines the options of Download.
+type DownloadOptions struct {
+	// Client makes the request. Defaults to DefaultHTTPClient, whose timeout
+	// may be too short for large files.
+	Client  *http.Client
+	Headers map[string]string
+
+	// MaxSize limits the size of the file in bytes. Defaults to
+	// DefaultMaxDownloadSize; negative values disable the limit.
+	MaxSize int64
+
+	// Checksum is the expected checksum of the file, as the algorithm and
+	// hex digest, e.g. "sha256:9f86d08...". Supports md5, sha1, sha256 and
+	// sha512. The checksum of the file is computed with sha256 if unset.
+	Checksum string
+
+	// Resume holds the start of the file downloaded by a previous attempt,
+	// e.g. the partial file that w appends to. Only the rest of the file is
+	// requested, with a Range request.
+	Resume io.Reader
+}
+
+// DownloadResult describes a downloaded file.
+type DownloadResult struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`              // Size of the whole file
+	Written     int64  `json:"written"`           // Bytes written to w
+	Checksum    string `json:"checksum"`          // Checksum of the whole file
+	Resumed     bool   `json:"resumed,omitempty"` // Whether a previous download was resumed
+}
+
+// Download writes the file at the given URL to w, e.g. to save the PDFs and
+// images found by a crawl. Returns an error if the file exceeds the maximum
+// size or doesn't match the expected checksum, in which case the content
+// written so far should be discarded.
+func Download(ctx context.Context, rawURL string, w io.Writer, options DownloadOptions) (*DownloadResult, error) {
+	if options.MaxSize == 0 {
+		options.MaxSize = DefaultMaxDownloadSize
+	}
+	algorithm, expected, err := parseChecksum(options.Checksum)
+	if err != nil {
+		return nil, err
+	}
+	h := newChecksumHash(algorithm)
+
+	// Hash the content downloaded previously
+	var offset int64
+	if options.Resume != nil {
+		if offset, err = io.Copy(h, options.Resume); err != nil {
+			return nil, fmt.Errorf("failed to read the partial download: %w", err)
+		}
+		if options.MaxSize > 0 && offset > options.MaxSize {
+			return nil, errors.NewRequestErrorf("download exceeds limit of %d bytes", options.MaxSize).WithRawURL(rawURL)
+		}
+	}
+
+	headers := options.Headers
+	if offset > 0 {
+		headers = make(map[string]string, len(options.Headers)+1)
+		for key, value := range options.Headers {
+			headers[key] = value
+		}
+		headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+	}
+	resp, err := doRequest(ctx, options.Client, http.MethodGet, rawURL, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &DownloadResult{URL: rawURL, ContentType: resp.Header.Get("Content-Type")}
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		result.Resumed = true
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The previous attempt downloaded the whole file
+		result.Resumed = true
+		resp.Body, resp.ContentLength = http.NoBody, 0
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range, so skip the content downloaded before
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			return nil, fmt.Errorf("failed to skip the partial download: %w", err)
+		}
+		resp.ContentLength -= offset
+	default:
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, errors.MaxBodySnippetSize))
+		return nil, errors.NewRequestErrorf("unexpected status code: %d", resp.StatusCode).
+			WithResponse(resp, snippet).
+			WithRawURL(rawURL)
+	}
+
+	// Check the announced size before reading the body
+	if options.MaxSize > 0 && resp.ContentLength > 0 && offset+resp.ContentLength > options.MaxSize {
+		return nil, errors.NewRequestErrorf("download exceeds limit of %d bytes", options.MaxSize).
+			WithResponse(resp, nil).
+			WithRawURL(rawURL)
+	}
+	body := io.Reader(resp.Body)
+	if options.MaxSize > 0 {
+		body = io.LimitReader(resp.Body, options.MaxSize-offset+1)
+	}
+	result.Written, err = io.Copy(io.MultiWriter(w, h), body)
+	if err != nil {
+		return nil, err
+	}
+	result.Size = offset + result.Written
+	if options.MaxSize > 0 && result.Size > options.MaxSize {
+		return nil, errors.NewRequestErrorf("download exceeds limit of %d bytes", options.MaxSize).
+			WithResponse(resp, nil).
+			WithRawURL(rawURL)
+	}
+
+	digest := hex.EncodeToString(h.Sum(nil))
+	result.Checksum = algorithm + ":" + digest
+	if expected != "" && digest != expected {
+		return nil, fmt.Errorf("checksum mismatch: expected %s:%s, got %s", algorithm, expected, result.Checksum)
+	}
+	return result, nil
+}
+
+// Download downloads a file as the Download function, using the client and
+// headers of the fetcher unless given in the options.
+func (f *HTTPFetcher) Download(ctx context.Context, rawURL string, w io.Writer, options DownloadOptions) (*DownloadResult, error) {
+	if options.Client == nil {
+		options.Client = f.client
+	}
+	headers := make(map[string]string, len(f.headers)+len(options.Headers))
+	for key, value := range f.headers {
+		headers[key] = value
+	}
+	for key, value := range options.Headers {
+		headers[key] = value
+	}
+	options.Headers = headers
+	return Download(ctx, rawURL, w, options)
+}
+
+// parseChecksum parses a checksum as its algorithm and lowercase hex digest.
+// The algorithm defaults to sha256 when the checksum is empty.
+func parseChecksum(checksum string) (string, string, error) {
+	if checksum == "" {
+		return "sha256", "", nil
+	}
+	algorithm, digest, ok := strings.Cut(checksum, ":")
+	algorithm = strings.ToLower(algorithm)
+	if !ok || newChecksumHash(algorithm) == nil {
+		return "", "", fmt.Errorf("invalid checksum %q: expected md5, sha1, sha256 or sha512 and a hex digest", checksum)
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", "", fmt.Errorf("invalid checksum %q: %w", checksum, err)
+	}
+	return algorithm, strings.ToLower(digest), nil
+}
+
+func newChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	case "sha512":
+		return sha512.New()
+	}
+	return nil
+}
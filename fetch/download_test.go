@@ -0,0 +1,82 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	sum := sha256.Sum256(content)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+	mux := http.NewServeMux()
+	mux.HandleFunc("/file.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		http.ServeContent(w, r, "file.pdf", time.Time{}, bytes.NewReader(content))
+	})
+	mux.HandleFunc("/no-ranges.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	result, err := Download(ctx, server.URL+"/file.pdf", &buf, DownloadOptions{Checksum: checksum})
+	require.NoError(t, err)
+	require.Equal(t, content, buf.Bytes())
+	require.Equal(t, "application/pdf", result.ContentType)
+	require.Equal(t, int64(len(content)), result.Size)
+	require.Equal(t, checksum, result.Checksum)
+	require.False(t, result.Resumed)
+
+	// Partial downloads are resumed, with or without range support
+	for _, path := range []string{"/file.pdf", "/no-ranges.pdf"} {
+		buf.Reset()
+		partial := content[:400]
+		result, err = Download(ctx, server.URL+path, &buf, DownloadOptions{
+			Checksum: strings.ToUpper(checksum),
+			Resume:   bytes.NewReader(partial),
+		})
+		require.NoError(t, err, path)
+		require.Equal(t, content[400:], buf.Bytes(), path)
+		require.Equal(t, int64(len(content)), result.Size)
+		require.Equal(t, int64(600), result.Written)
+		require.Equal(t, path == "/file.pdf", result.Resumed)
+	}
+
+	// Completed downloads are not downloaded again
+	buf.Reset()
+	result, err = Download(ctx, server.URL+"/file.pdf", &buf, DownloadOptions{Resume: bytes.NewReader(content)})
+	require.NoError(t, err)
+	require.Zero(t, buf.Len())
+	require.Equal(t, checksum, result.Checksum)
+
+	_, err = Download(ctx, server.URL+"/file.pdf", &buf, DownloadOptions{MaxSize: 100})
+	require.ErrorContains(t, err, "download exceeds limit of 100 bytes")
+	_, err = Download(ctx, server.URL+"/no-ranges.pdf", &buf, DownloadOptions{MaxSize: 100})
+	require.ErrorContains(t, err, "download exceeds limit of 100 bytes")
+
+	_, err = Download(ctx, server.URL+"/file.pdf", &buf, DownloadOptions{Checksum: "md5:00"})
+	require.ErrorContains(t, err, "checksum mismatch")
+	_, err = Download(ctx, server.URL+"/file.pdf", &buf, DownloadOptions{Checksum: "crc:00"})
+	require.ErrorContains(t, err, "invalid checksum")
+	_, err = Download(ctx, server.URL+"/missing.pdf", &buf, DownloadOptions{})
+	require.ErrorContains(t, err, "unexpected status code: 404")
+
+	// Fetchers download with their client and headers
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{})
+	buf.Reset()
+	_, err = fetcher.Download(ctx, server.URL+"/file.pdf", &buf, DownloadOptions{})
+	require.NoError(t, err)
+	require.Equal(t, content, buf.Bytes())
+}
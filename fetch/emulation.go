@@ -0,0 +1,54 @@
+package fetch
+
+// Emulation describes the device and environment emulated by a browser while
+// rendering a page, as rendered content often depends on them. Zero values
+// use the defaults of the fetcher.
+type Emulation struct {
+	Viewport          *Viewport    `json:"viewport,omitempty"`
+	DeviceScaleFactor float64      `json:"device_scale_factor,omitempty"`
+	Timezone          string       `json:"timezone,omitempty"` // IANA time zone, e.g. "Europe/Paris"
+	Locale            string       `json:"locale,omitempty"`   // BCP 47 language tag, e.g. "fr-FR"
+	Geolocation       *Geolocation `json:"geolocation,omitempty"`
+}
+
+// Viewport is the size of the browser window in CSS pixels.
+type Viewport struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Geolocation is the position reported by the browser geolocation API.
+type Geolocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Accuracy  float64 `json:"accuracy,omitempty"` // meters
+}
+
+// Merge returns the emulation with the non-zero fields of the override
+// applied, e.g. to apply the emulation of a request to the defaults of a
+// fetcher. Either may be nil.
+func (e *Emulation) Merge(override *Emulation) *Emulation {
+	if e == nil {
+		return override
+	}
+	if override == nil {
+		return e
+	}
+	merged := *e
+	if override.Viewport != nil {
+		merged.Viewport = override.Viewport
+	}
+	if override.DeviceScaleFactor != 0 {
+		merged.DeviceScaleFactor = override.DeviceScaleFactor
+	}
+	if override.Timezone != "" {
+		merged.Timezone = override.Timezone
+	}
+	if override.Locale != "" {
+		merged.Locale = override.Locale
+	}
+	if override.Geolocation != nil {
+		merged.Geolocation = override.Geolocation
+	}
+	return &merged
+}
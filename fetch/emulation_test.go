@@ -0,0 +1,51 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmulation_Merge(t *testing.T) {
+	defaults := &Emulation{
+		Viewport: &Viewport{Width: 1280, Height: 720},
+		Timezone: "America/New_York",
+		Locale:   "en-US",
+	}
+	merged := defaults.Merge(&Emulation{Locale: "fr-FR", Geolocation: &Geolocation{Latitude: 48.85, Longitude: 2.35}})
+	require.Equal(t, &Emulation{
+		Viewport:    &Viewport{Width: 1280, Height: 720},
+		Timezone:    "America/New_York",
+		Locale:      "fr-FR",
+		Geolocation: &Geolocation{Latitude: 48.85, Longitude: 2.35},
+	}, merged)
+	require.Equal(t, "en-US", defaults.Locale)
+	require.Same(t, defaults, defaults.Merge(nil))
+
+	var none *Emulation
+	require.Equal(t, merged, none.Merge(merged))
+}
+
+func TestHTTPFetcher_EmulatedLocale(t *testing.T) {
+	var languages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		languages = append(languages, r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html></html>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{Headers: map[string]string{"Accept-Language": "en-US"}})
+	for _, req := range []*Request{
+		{URL: server.URL},
+		{URL: server.URL, Emulation: &Emulation{Locale: "de-DE"}},
+		{URL: server.URL, Emulation: &Emulation{Locale: "de-DE"}, Headers: map[string]string{"Accept-Language": "it"}},
+	} {
+		_, err := fetcher.Fetch(context.Background(), req)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"en-US", "de-DE", "it"}, languages)
+}
@@ -0,0 +1,138 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxExtractInput is the default size limit of the page content sent
+// to the LLM, in bytes.
+const DefaultMaxExtractInput = 100_000
+
+// ExtractOptions requests structured data extracted from the page by an LLM.
+type ExtractOptions struct {
+	// Schema is the JSON Schema of the data to extract.
+	Schema map[string]any `json:"schema,omitempty"`
+
+	// Prompt describes the data to extract, in addition to or instead of the
+	// schema.
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// LLMClient generates text with a large language model. Implement it with
+// the client of your LLM provider to enable extraction.
+type LLMClient interface {
+
+	// Generate returns the response of the model to the prompt. When a
+	// schema is given, the response must be JSON conforming to it, e.g. by
+	// using the structured output mode of the provider.
+	Generate(ctx context.Context, prompt string, schema map[string]any) (string, error)
+}
+
+// Extract extracts structured data from the content of a response with an
+// LLM. The markdown of the response is used when available, or otherwise the
+// main content text of its HTML.
+func Extract(ctx context.Context, llm LLMClient, response *Response, options *ExtractOptions) (any, error) {
+	if options == nil || (options.Schema == nil && options.Prompt == "") {
+		return nil, fmt.Errorf("extraction requires a schema or a prompt")
+	}
+	content := response.Markdown
+	if content == "" && response.HTML != "" {
+		doc, err := response.Document()
+		if err != nil {
+			return nil, err
+		}
+		content = doc.MainContentText()
+	}
+	if content == "" {
+		content = response.Text
+	}
+	if len(content) > DefaultMaxExtractInput {
+		content = strings.ToValidUTF8(content[:DefaultMaxExtractInput], "")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Extract structured data from the web page below. ")
+	prompt.WriteString("Respond with JSON only, using null for values not found on the page.\n\n")
+	if options.Prompt != "" {
+		prompt.WriteString("Instructions: " + options.Prompt + "\n\n")
+	}
+	if options.Schema != nil {
+		schema, err := json.Marshal(options.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extraction schema: %w", err)
+		}
+		prompt.WriteString("JSON Schema: " + string(schema) + "\n\n")
+	}
+	prompt.WriteString("URL: " + response.URL + "\n")
+	if response.Metadata.Title != "" {
+		prompt.WriteString("Title: " + response.Metadata.Title + "\n")
+	}
+	prompt.WriteString("\n" + content)
+
+	output, err := llm.Generate(ctx, prompt.String(), options.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("llm extraction failed: %w", err)
+	}
+	var extracted any
+	if err := json.Unmarshal([]byte(trimCodeFence(output)), &extracted); err != nil {
+		return nil, fmt.Errorf("llm returned invalid json: %w", err)
+	}
+	return extracted, nil
+}
+
+// trimCodeFence removes a markdown code fence around a model response.
+func trimCodeFence(output string) string {
+	output = strings.TrimSpace(output)
+	if !strings.HasPrefix(output, "```") {
+		return output
+	}
+	output = strings.TrimPrefix(output, "```")
+	output = strings.TrimPrefix(output, "json")
+	return strings.TrimSpace(strings.TrimSuffix(output, "```"))
+}
+
+// ExtractFetcher wraps a fetcher to extract structured data from the pages
+// of requests with Request.Extract set, adding it to Response.Extracted.
+type ExtractFetcher struct {
+	fetcher Fetcher
+	llm     LLMClient
+}
+
+// NewExtractFetcher creates a new ExtractFetcher.
+func NewExtractFetcher(fetcher Fetcher, llm LLMClient) *ExtractFetcher {
+	return &ExtractFetcher{fetcher: fetcher, llm: llm}
+}
+
+// Capabilities implements the CapabilityReporter interface, reporting the
+// capabilities of the wrapped fetcher.
+func (f *ExtractFetcher) Capabilities() *Capabilities {
+	return FetcherCapabilities(f.fetcher)
+}
+
+// Warmup implements the Warmer interface, warming up the wrapped fetcher.
+func (f *ExtractFetcher) Warmup(ctx context.Context) error {
+	return WarmupFetcher(ctx, f.fetcher)
+}
+
+// Healthy implements the HealthChecker interface, checking the health of
+// the wrapped fetcher.
+func (f *ExtractFetcher) Healthy(ctx context.Context) error {
+	return CheckFetcherHealth(ctx, f.fetcher)
+}
+
+// Fetch implements the Fetcher interface.
+func (f *ExtractFetcher) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	response, err := f.fetcher.Fetch(ctx, req)
+	if err != nil || req.Extract == nil {
+		return response, err
+	}
+	extracted, err := Extract(ctx, f.llm, response, req.Extract)
+	if err != nil {
+		return nil, err
+	}
+	response.Extracted = extracted
+	return response, nil
+}
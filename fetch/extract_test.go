@@ -0,0 +1,64 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLLM returns a fixed output and records the prompt.
+type fakeLLM struct {
+	output string
+	err    error
+	prompt string
+	schema map[string]any
+}
+
+func (l *fakeLLM) Generate(ctx context.Context, prompt string, schema map[string]any) (string, error) {
+	l.prompt, l.schema = prompt, schema
+	return l.output, l.err
+}
+
+func TestExtractFetcher(t *testing.T) {
+	mockFetcher := NewMockFetcher()
+	mockFetcher.AddResponse("https://example.com/widget", &Response{
+		URL:  "https://example.com/widget",
+		HTML: `<html><head><title>Widget</title></head><body><main><p>The widget costs $5.</p></main></body></html>`,
+	})
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"price": map[string]any{"type": "number"}},
+	}
+	llm := &fakeLLM{output: "```json\n{\"price\": 5}\n```"}
+	fetcher := NewExtractFetcher(mockFetcher, llm)
+
+	response, err := fetcher.Fetch(context.Background(), &Request{
+		URL:     "https://example.com/widget",
+		Extract: &ExtractOptions{Schema: schema, Prompt: "Get the price in dollars"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"price": 5.0}, response.Extracted)
+	require.Equal(t, schema, llm.schema)
+	require.Contains(t, llm.prompt, "Instructions: Get the price in dollars")
+	require.Contains(t, llm.prompt, `"price":{"type":"number"}`)
+	require.Contains(t, llm.prompt, "The widget costs $5.")
+
+	// Requests without extraction don't call the LLM
+	llm.prompt = ""
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: "https://example.com/widget"})
+	require.NoError(t, err)
+	require.Empty(t, llm.prompt)
+
+	llm.output = "The price is $5"
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: "https://example.com/widget", Extract: &ExtractOptions{Prompt: "price"}})
+	require.ErrorContains(t, err, "llm returned invalid json")
+
+	llm.err = errors.New("rate limited")
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: "https://example.com/widget", Extract: &ExtractOptions{Prompt: "price"}})
+	require.ErrorContains(t, err, "rate limited")
+
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: "https://example.com/widget", Extract: &ExtractOptions{}})
+	require.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package fetch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequest_JSON(t *testing.T) {
+	var request Request
+	err := json.Unmarshal([]byte(`{
+		"url": "https://example.com",
+		"formats": ["markdown"],
+		"actions": [{"type": "wait", "duration": 100}, {"type": "scroll_to_bottom", "max_iterations": 5, "stop_on_no_new_content": true}],
+		"extensions": {"x_priority": 2}
+	}`), &request)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", request.URL)
+	require.Equal(t, []string{"markdown"}, request.Formats)
+	require.Len(t, request.Actions, 2)
+	require.Equal(t, NewScrollToBottomAction(ScrollToBottomActionOptions{MaxIterations: 5, StopOnNoNewContent: true}), request.Actions[1])
+	require.Equal(t, map[string]any{"x_priority": 2.0}, request.Extensions)
+
+	data, err := json.Marshal(&Request{URL: "https://example.com"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"url": "https://example.com"}`, string(data))
+}
+
+func TestResponse_JSON(t *testing.T) {
+	response := &Response{
+		URL:        "https://example.com",
+		StatusCode: 200,
+		Headers:    map[string]string{},
+		Links:      []*web.Link{{URL: "/about", Text: "About"}},
+		Extensions: map[string]any{"cached": true},
+	}
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"url": "https://example.com",
+		"status_code": 200,
+		"headers": {},
+		"metadata": {},
+		"links": [{"url": "/about", "text": "About"}],
+		"extensions": {"cached": true}
+	}`, string(data))
+
+	var decoded Response
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, response.Links, decoded.Links)
+	require.Equal(t, response.Extensions, decoded.Extensions)
+}
+
+func TestResponse_Document(t *testing.T) {
+	response, err := ProcessRequest(&Request{URL: "https://example.com"},
+		`<html><body><h1>Title</h1><a href="/about">About</a></body></html>`)
+	require.NoError(t, err)
+
+	// The document parsed during processing is reused
+	doc, err := response.Document()
+	require.NoError(t, err)
+	again, err := response.Document()
+	require.NoError(t, err)
+	require.Same(t, doc, again)
+	require.Equal(t, "Title", doc.H1())
+
+	// Modified HTML is parsed again
+	response.HTML = "<html><body><h1>Changed</h1></body></html>"
+	doc, err = response.Document()
+	require.NoError(t, err)
+	require.Equal(t, "Changed", doc.H1())
+
+	// Responses without a parsed document are parsed on demand
+	doc, err = (&Response{HTML: "<h1>Other</h1>"}).Document()
+	require.NoError(t, err)
+	require.Equal(t, "Other", doc.H1())
+}
+
+func TestResponse_Indexability(t *testing.T) {
+	response := &Response{
+		URL:      "https://example.com/page",
+		Headers:  map[string]string{"x-robots-tag": "nofollow"},
+		Metadata: web.Metadata{Robots: "noindex"},
+	}
+	require.Equal(t, web.Indexability{
+		Crawlable: true,
+		Reasons:   []string{web.ReasonNoIndexMeta, web.ReasonNoFollowHeader},
+	}, response.Indexability(nil))
+
+	robotsTxt := web.ParseRobotsTxt([]byte("User-agent: *\nDisallow: /page\n"))
+	require.False(t, response.Indexability(robotsTxt).Crawlable)
+}
+
+func TestProcessRequest_Formats(t *testing.T) {
+	html := `<html><head><title>Title</title><link rel="canonical" href="https://example.com/"></head>
+		<body><h1>Heading</h1><a href="/about">About</a></body></html>`
+
+	response, err := ProcessRequest(&Request{URL: "https://example.com", Formats: []string{"links"}}, html)
+	require.NoError(t, err)
+	require.Empty(t, response.HTML)
+	require.Equal(t, "Title", response.Metadata.Title)
+	require.Equal(t, "Heading", response.Metadata.Heading)
+	require.Equal(t, []*web.Link{{URL: "/about", Text: "About"}}, response.Links)
+
+	// Metadata alone stops at the end of the head
+	response, err = ProcessRequest(&Request{URL: "https://example.com", Formats: []string{"metadata"}}, html)
+	require.NoError(t, err)
+	require.Equal(t, "Title", response.Metadata.Title)
+	require.Equal(t, "https://example.com/", response.Metadata.CanonicalURL)
+	require.Empty(t, response.Metadata.Heading)
+	require.Nil(t, response.Links)
+}
+
+func TestProcessRequest_MinHTML(t *testing.T) {
+	html := "<html><head><title>Title</title></head>\n<body>\n  <!-- nav -->\n  <p class=\"\">Some   <b>text</b></p>\n</body></html>"
+
+	response, err := ProcessRequest(&Request{URL: "https://example.com", Formats: []string{"min_html"}}, html)
+	require.NoError(t, err)
+	require.Equal(t, `<html><head><title>Title</title></head><body><p>Some <b>text</b></p></body></html>`, response.HTML)
+	require.Empty(t, response.Markdown)
+}
@@ -0,0 +1,207 @@
+package fetchgrpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// ClientOptions defines the options for the client.
+type ClientOptions struct {
+	// Address of the server, e.g. "http://localhost:8080". Plain http
+	// addresses use unencrypted HTTP/2.
+	Address string
+
+	AuthToken      string            // Optional authorization token
+	Headers        map[string]string // Optional request metadata
+	Timeout        time.Duration     // Optional timeout for Fetch calls
+	MaxMessageSize int               // Defaults to DefaultMaxMessageSize
+
+	// HTTPClient overrides the HTTP client. It must support HTTP/2.
+	HTTPClient *http.Client
+}
+
+// Client calls a FetchService over gRPC. It implements fetch.Fetcher.
+type Client struct {
+	address        string
+	authToken      string
+	headers        map[string]string
+	timeout        time.Duration
+	maxMessageSize int
+	httpClient     *http.Client
+}
+
+// NewClient creates a new client with the given options.
+func NewClient(opts ClientOptions) *Client {
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = DefaultMaxMessageSize
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		protocols := &http.Protocols{}
+		protocols.SetUnencryptedHTTP2(true)
+		protocols.SetHTTP2(true)
+		httpClient = &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	}
+	return &Client{
+		address:        strings.TrimSuffix(opts.Address, "/"),
+		authToken:      opts.AuthToken,
+		headers:        opts.Headers,
+		timeout:        opts.Timeout,
+		maxMessageSize: opts.MaxMessageSize,
+		httpClient:     httpClient,
+	}
+}
+
+// Fetch a page using the remote service.
+func (c *Client) Fetch(ctx context.Context, request *fetch.Request) (*fetch.Response, error) {
+	if request == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	message, err := encodeRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	var response *fetch.Response
+	err = c.call(ctx, fetchMethod, message, func(data []byte) error {
+		if response != nil {
+			return fmt.Errorf("unexpected second response message")
+		}
+		response, err = decodeResponse(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, fmt.Errorf("no response message received")
+	}
+	return response, nil
+}
+
+// Healthy implements the fetch.HealthChecker interface with the standard
+// gRPC health service of the server, which is not serving when its fetcher
+// is unhealthy. Servers without the health service are only checked to be
+// reachable.
+func (c *Client) Healthy(ctx context.Context) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	status := 0
+	err := c.call(ctx, healthMethod, nil, func(data []byte) error {
+		var err error
+		status, err = decodeHealthResponse(data)
+		return err
+	})
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.Code == CodeUnimplemented {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if status != healthServing {
+		return fmt.Errorf("fetch service at %s is not serving", c.address)
+	}
+	return nil
+}
+
+// Crawl starts a crawl on the remote service and calls the callback with
+// each result as it arrives. Returning an error from the callback cancels the
+// crawl and the error is returned.
+func (c *Client) Crawl(ctx context.Context, request *CrawlRequest, callback func(*CrawlResult) error) error {
+	if request == nil {
+		return fmt.Errorf("request cannot be nil")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return c.call(ctx, crawlMethod, encodeCrawlRequest(request), func(data []byte) error {
+		result, err := decodeCrawlResult(data)
+		if err != nil {
+			return err
+		}
+		return callback(result)
+	})
+}
+
+// call makes a gRPC call with a single request message and passes each
+// response message to the handler.
+func (c *Client) call(ctx context.Context, method string, message []byte, handle func([]byte) error) error {
+	var body bytes.Buffer
+	if err := writeFrame(&body, message); err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.address+method, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("TE", "trailers")
+	if deadline, ok := ctx.Deadline(); ok {
+		httpReq.Header.Set(timeoutHeader, encodeTimeout(time.Until(deadline)))
+	}
+	for key, value := range c.headers {
+		httpReq.Header.Set(key, value)
+	}
+	if c.authToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(io.LimitReader(httpResp.Body, 1024))
+		return &StatusError{
+			Code:    CodeUnavailable,
+			Message: fmt.Sprintf("request failed with status %d: %s", httpResp.StatusCode, responseBody),
+		}
+	}
+	// A status in the headers means there are no messages
+	if status := httpResp.Header.Get(statusHeader); status != "" {
+		return parseStatus(status, httpResp.Header.Get(messageHeader))
+	}
+	for {
+		data, err := readFrame(httpResp.Body, c.maxMessageSize)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := handle(data); err != nil {
+			return err
+		}
+	}
+	status := httpResp.Trailer.Get(statusHeader)
+	if status == "" {
+		return &StatusError{Code: CodeInternal, Message: "missing grpc status"}
+	}
+	return parseStatus(status, httpResp.Trailer.Get(messageHeader))
+}
+
+func parseStatus(status, message string) error {
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return &StatusError{Code: CodeUnknown, Message: "invalid grpc status " + status}
+	}
+	return errorFromStatus(code, decodeMessage(message))
+}
@@ -0,0 +1,198 @@
+// Package fetchgrpc implements the fetch service over gRPC, as defined in
+// fetch.proto. It has no dependencies beyond the standard library: messages
+// are encoded directly in the protocol buffer wire format and calls use the
+// gRPC protocol over HTTP/2, so it interoperates with generated clients and
+// servers in other languages.
+package fetchgrpc
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// Method paths of the FetchService.
+const (
+	fetchMethod = "/web.fetch.v1.FetchService/Fetch"
+	crawlMethod = "/web.fetch.v1.FetchService/Crawl"
+
+	// healthMethod is the Check method of the standard gRPC health service.
+	healthMethod = "/grpc.health.v1.Health/Check"
+)
+
+// Serving statuses of the gRPC health service.
+const (
+	healthServing    = 1
+	healthNotServing = 2
+)
+
+// DefaultMaxMessageSize is the default limit on the size of a received
+// message, matching the gRPC default.
+const DefaultMaxMessageSize = 4 * 1024 * 1024
+
+// gRPC status codes.
+const (
+	CodeOK                = 0
+	CodeCanceled          = 1
+	CodeUnknown           = 2
+	CodeInvalidArgument   = 3
+	CodeDeadlineExceeded  = 4
+	CodeNotFound          = 5
+	CodePermissionDenied  = 7
+	CodeResourceExhausted = 8
+	CodeUnimplemented     = 12
+	CodeInternal          = 13
+	CodeUnavailable       = 14
+	CodeUnauthenticated   = 16
+)
+
+const (
+	contentType   = "application/grpc"
+	statusHeader  = "Grpc-Status"
+	messageHeader = "Grpc-Message"
+	timeoutHeader = "Grpc-Timeout"
+)
+
+// StatusError is a gRPC error status returned by a call.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("grpc status %d: %s", e.Code, e.Message)
+}
+
+// statusFromError returns the gRPC status code for an error, mapping the
+// error types of the errors package to their gRPC equivalents.
+func statusFromError(err error) (int, string) {
+	var (
+		statusErr    *StatusError
+		badRequest   *errors.BadRequest
+		notFound     *errors.NotFound
+		unauthorized *errors.Unauthorized
+		forbidden    *errors.Forbidden
+	)
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.Code, statusErr.Message
+	case errors.As(err, &badRequest):
+		return CodeInvalidArgument, err.Error()
+	case errors.As(err, &notFound):
+		return CodeNotFound, err.Error()
+	case errors.As(err, &unauthorized):
+		return CodeUnauthenticated, err.Error()
+	case errors.As(err, &forbidden):
+		return CodePermissionDenied, err.Error()
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled, err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeDeadlineExceeded, err.Error()
+	}
+	return CodeUnknown, err.Error()
+}
+
+// errorFromStatus is the inverse of statusFromError.
+func errorFromStatus(code int, message string) error {
+	switch code {
+	case CodeOK:
+		return nil
+	case CodeInvalidArgument:
+		return errors.NewBadRequest("%s", message)
+	case CodeNotFound:
+		return errors.NewNotFound("%s", message)
+	case CodeUnauthenticated:
+		return errors.NewUnauthorized("%s", message)
+	case CodePermissionDenied:
+		return errors.NewForbidden("%s", message)
+	}
+	return &StatusError{Code: code, Message: message}
+}
+
+// writeFrame writes a length-prefixed, uncompressed message.
+func writeFrame(w io.Writer, message []byte) error {
+	header := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(header[1:], uint32(len(message)))
+	_, err := w.Write(append(header, message...))
+	return err
+}
+
+// readFrame reads a length-prefixed message. Returns io.EOF if the stream
+// ends cleanly before a message.
+func readFrame(r io.Reader, maxSize int) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, &StatusError{Code: CodeInternal, Message: "truncated message header"}
+		}
+		return nil, err
+	}
+	if header[0] != 0 {
+		return nil, &StatusError{Code: CodeUnimplemented, Message: "compressed messages are not supported"}
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if int64(size) > int64(maxSize) {
+		return nil, &StatusError{
+			Code:    CodeResourceExhausted,
+			Message: fmt.Sprintf("message of %d bytes exceeds the limit of %d", size, maxSize),
+		}
+	}
+	message := make([]byte, size)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, &StatusError{Code: CodeInternal, Message: "truncated message"}
+	}
+	return message, nil
+}
+
+// encodeTimeout formats a duration as a grpc-timeout header value.
+func encodeTimeout(d time.Duration) string {
+	if d <= 0 {
+		return "1n"
+	}
+	if ms := d.Milliseconds(); ms < 1e8 {
+		return strconv.FormatInt(max(ms, 1), 10) + "m"
+	}
+	return strconv.FormatInt(int64(d/time.Second), 10) + "S"
+}
+
+// parseTimeout parses a grpc-timeout header value.
+func parseTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// grpc-message values are percent-encoded.
+func encodeMessage(message string) string {
+	return url.PathEscape(message)
+}
+
+func decodeMessage(message string) string {
+	if decoded, err := url.PathUnescape(message); err == nil {
+		return decoded
+	}
+	return message
+}
@@ -0,0 +1,644 @@
+package fetchgrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// CrawlRequest starts a crawl from the given URLs.
+type CrawlRequest struct {
+	URLs           []string `json:"urls"`
+	MaxURLs        int      `json:"max_urls,omitempty"`
+	Workers        int      `json:"workers,omitempty"`
+	FollowBehavior string   `json:"follow_behavior,omitempty"` // any, same-domain, related-subdomains, none
+}
+
+// CrawlResult is the result of crawling one page.
+type CrawlResult struct {
+	URL      string          `json:"url"`
+	Links    []string        `json:"links,omitempty"`
+	Response *fetch.Response `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// optionalJSON marshals a value that is omitted from the message when empty.
+func optionalJSON[T any](value T, empty bool) ([]byte, error) {
+	if empty {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+func encodeRequest(r *fetch.Request) ([]byte, error) {
+	actions, err := optionalJSON(r.Actions, len(r.Actions) == 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode actions: %w", err)
+	}
+	storageState, err := optionalJSON(r.StorageState, len(r.StorageState) == 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode storage state: %w", err)
+	}
+	extensions, err := optionalJSON(r.Extensions, len(r.Extensions) == 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode extensions: %w", err)
+	}
+	emulation, err := optionalJSON(r.Emulation, r.Emulation == nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode emulation: %w", err)
+	}
+	block, err := optionalJSON(r.Block, r.Block == nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode block: %w", err)
+	}
+	captureNetwork, err := optionalJSON(r.CaptureNetwork, r.CaptureNetwork == nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode capture network: %w", err)
+	}
+	extract, err := optionalJSON(r.Extract, r.Extract == nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode extract: %w", err)
+	}
+	var e encoder
+	e.string(1, r.URL)
+	e.bool(2, r.OnlyMainContent)
+	e.strings(3, r.IncludeTags)
+	e.strings(4, r.ExcludeTags)
+	e.int(5, int64(r.MaxAge))
+	e.int(6, int64(r.Timeout))
+	e.int(7, int64(r.WaitFor))
+	e.string(8, r.Fetcher)
+	e.bool(9, r.Mobile)
+	e.bool(10, r.Prettify)
+	e.strings(11, r.Formats)
+	e.stringMap(12, r.Headers)
+	e.string(13, r.Version)
+	e.bytes(14, actions)
+	e.bytes(15, storageState)
+	e.bytes(16, extensions)
+	e.string(17, r.Proxy)
+	e.bytes(18, emulation)
+	e.bytes(19, block)
+	e.bytes(20, captureNetwork)
+	e.bytes(21, extract)
+	e.string(22, r.ExcludeProfile)
+	e.strings(23, r.KeepTags)
+	e.bool(24, r.IncludeNoscript)
+	e.bool(25, r.InlineIframes)
+	return e.buf, nil
+}
+
+func decodeRequest(data []byte) (*fetch.Request, error) {
+	r := &fetch.Request{}
+	d := &decoder{buf: data}
+	for !d.done() {
+		field, wt, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		var raw []byte
+		switch field {
+		case 1:
+			r.URL, err = d.string(wt)
+		case 2:
+			r.OnlyMainContent, err = d.bool(wt)
+		case 3:
+			err = d.appendString(wt, &r.IncludeTags)
+		case 4:
+			err = d.appendString(wt, &r.ExcludeTags)
+		case 5:
+			err = d.int32(wt, &r.MaxAge)
+		case 6:
+			err = d.int32(wt, &r.Timeout)
+		case 7:
+			err = d.int32(wt, &r.WaitFor)
+		case 8:
+			r.Fetcher, err = d.string(wt)
+		case 9:
+			r.Mobile, err = d.bool(wt)
+		case 10:
+			r.Prettify, err = d.bool(wt)
+		case 11:
+			err = d.appendString(wt, &r.Formats)
+		case 12:
+			if r.Headers == nil {
+				r.Headers = map[string]string{}
+			}
+			err = d.mapEntry(wt, r.Headers)
+		case 13:
+			r.Version, err = d.string(wt)
+		case 14:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.Actions)
+			}
+		case 15:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.StorageState)
+			}
+		case 16:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.Extensions)
+			}
+		case 17:
+			r.Proxy, err = d.string(wt)
+		case 18:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.Emulation)
+			}
+		case 19:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.Block)
+			}
+		case 20:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.CaptureNetwork)
+			}
+		case 21:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.Extract)
+			}
+		case 22:
+			r.ExcludeProfile, err = d.string(wt)
+		case 23:
+			err = d.appendString(wt, &r.KeepTags)
+		case 24:
+			r.IncludeNoscript, err = d.bool(wt)
+		case 25:
+			r.InlineIframes, err = d.bool(wt)
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid request field %d: %w", field, err)
+		}
+	}
+	return r, nil
+}
+
+func encodeResponse(r *fetch.Response) ([]byte, error) {
+	storageState, err := optionalJSON(r.StorageState, len(r.StorageState) == 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode storage state: %w", err)
+	}
+	extensions, err := optionalJSON(r.Extensions, len(r.Extensions) == 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode extensions: %w", err)
+	}
+	network, err := optionalJSON(r.Network, len(r.Network) == 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode network: %w", err)
+	}
+	outputs, err := optionalJSON(r.Outputs, len(r.Outputs) == 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode outputs: %w", err)
+	}
+	actionResults, err := optionalJSON(r.ActionResults, len(r.ActionResults) == 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode action results: %w", err)
+	}
+	extracted, err := optionalJSON(r.Extracted, r.Extracted == nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode extracted: %w", err)
+	}
+	metadata, err := encodeMetadata(&r.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	var e encoder
+	e.string(1, r.URL)
+	e.int(2, int64(r.StatusCode))
+	e.stringMap(3, r.Headers)
+	e.string(4, r.HTML)
+	e.string(5, r.Markdown)
+	e.string(6, r.Text)
+	e.string(7, r.Screenshot)
+	e.string(8, r.PDF)
+	e.string(9, r.Error)
+	if len(metadata) > 0 {
+		e.message(10, metadata)
+	}
+	for _, link := range r.Links {
+		e.message(11, encodeLink(link))
+	}
+	if !r.Timestamp.IsZero() {
+		var ts encoder
+		ts.int(1, r.Timestamp.Unix())
+		ts.int(2, int64(r.Timestamp.Nanosecond()))
+		e.message(12, ts.buf)
+	}
+	e.string(13, r.Version)
+	e.bytes(14, storageState)
+	e.bytes(15, extensions)
+	e.bytes(16, network)
+	e.bytes(17, outputs)
+	e.bytes(18, actionResults)
+	e.stringMap(19, r.Artifacts)
+	e.bytes(20, extracted)
+	e.string(21, r.FinalURL)
+	e.bool(22, r.PermanentRedirect)
+	e.bool(23, r.Truncated)
+	e.int(24, r.BodySize)
+	e.int(25, r.TransferSize)
+	return e.buf, nil
+}
+
+func decodeResponse(data []byte) (*fetch.Response, error) {
+	r := &fetch.Response{Headers: map[string]string{}}
+	d := &decoder{buf: data}
+	for !d.done() {
+		field, wt, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		var raw []byte
+		switch field {
+		case 1:
+			r.URL, err = d.string(wt)
+		case 2:
+			err = d.int32(wt, &r.StatusCode)
+		case 3:
+			err = d.mapEntry(wt, r.Headers)
+		case 4:
+			r.HTML, err = d.string(wt)
+		case 5:
+			r.Markdown, err = d.string(wt)
+		case 6:
+			r.Text, err = d.string(wt)
+		case 7:
+			r.Screenshot, err = d.string(wt)
+		case 8:
+			r.PDF, err = d.string(wt)
+		case 9:
+			r.Error, err = d.string(wt)
+		case 10:
+			if raw, err = d.bytes(wt); err == nil {
+				err = decodeMetadata(raw, &r.Metadata)
+			}
+		case 11:
+			if raw, err = d.bytes(wt); err == nil {
+				var link *web.Link
+				if link, err = decodeLink(raw); err == nil {
+					r.Links = append(r.Links, link)
+				}
+			}
+		case 12:
+			if raw, err = d.bytes(wt); err == nil {
+				r.Timestamp, err = decodeTimestamp(raw)
+			}
+		case 13:
+			r.Version, err = d.string(wt)
+		case 14:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.StorageState)
+			}
+		case 15:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.Extensions)
+			}
+		case 16:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.Network)
+			}
+		case 17:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.Outputs)
+			}
+		case 18:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.ActionResults)
+			}
+		case 19:
+			if r.Artifacts == nil {
+				r.Artifacts = map[string]string{}
+			}
+			err = d.mapEntry(wt, r.Artifacts)
+		case 20:
+			if raw, err = d.bytes(wt); err == nil {
+				err = json.Unmarshal(raw, &r.Extracted)
+			}
+		case 21:
+			r.FinalURL, err = d.string(wt)
+		case 22:
+			r.PermanentRedirect, err = d.bool(wt)
+		case 23:
+			r.Truncated, err = d.bool(wt)
+		case 24:
+			r.BodySize, err = d.varint(wt)
+		case 25:
+			r.TransferSize, err = d.varint(wt)
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid response field %d: %w", field, err)
+		}
+	}
+	return r, nil
+}
+
+func decodeTimestamp(data []byte) (time.Time, error) {
+	var seconds, nanos int64
+	d := &decoder{buf: data}
+	for !d.done() {
+		field, wt, err := d.next()
+		if err != nil {
+			return time.Time{}, err
+		}
+		switch field {
+		case 1:
+			seconds, err = d.varint(wt)
+		case 2:
+			nanos, err = d.varint(wt)
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
+
+func encodeMetadata(m *web.Metadata) ([]byte, error) {
+	manifest, err := optionalJSON(m.Manifest, m.Manifest == nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	structuredData, err := optionalJSON(m.StructuredData, m.StructuredData == nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode structured data: %w", err)
+	}
+	var e encoder
+	e.string(1, m.Title)
+	e.string(2, m.Description)
+	e.string(3, m.Language)
+	e.string(4, m.Author)
+	e.string(5, m.CanonicalURL)
+	e.string(6, m.Heading)
+	e.string(7, m.Robots)
+	e.string(8, m.Image)
+	e.string(9, m.Icon)
+	e.string(10, m.ManifestURL)
+	e.string(11, m.PublishedTime)
+	e.strings(12, m.Keywords)
+	for _, tag := range m.Tags {
+		var t encoder
+		t.string(1, tag.Tag)
+		t.string(2, tag.Name)
+		t.string(3, tag.Property)
+		t.string(4, tag.Content)
+		t.string(5, tag.Charset)
+		e.message(13, t.buf)
+	}
+	e.bytes(14, manifest)
+	e.string(15, m.BaseURL)
+	e.bytes(16, structuredData)
+	return e.buf, nil
+}
+
+func decodeMetadata(data []byte, m *web.Metadata) error {
+	d := &decoder{buf: data}
+	for !d.done() {
+		field, wt, err := d.next()
+		if err != nil {
+			return err
+		}
+		var raw []byte
+		switch field {
+		case 1:
+			m.Title, err = d.string(wt)
+		case 2:
+			m.Description, err = d.string(wt)
+		case 3:
+			m.Language, err = d.string(wt)
+		case 4:
+			m.Author, err = d.string(wt)
+		case 5:
+			m.CanonicalURL, err = d.string(wt)
+		case 6:
+			m.Heading, err = d.string(wt)
+		case 7:
+			m.Robots, err = d.string(wt)
+		case 8:
+			m.Image, err = d.string(wt)
+		case 9:
+			m.Icon, err = d.string(wt)
+		case 10:
+			m.ManifestURL, err = d.string(wt)
+		case 11:
+			m.PublishedTime, err = d.string(wt)
+		case 12:
+			err = d.appendString(wt, &m.Keywords)
+		case 13:
+			if raw, err = d.bytes(wt); err == nil {
+				var tag *web.Meta
+				if tag, err = decodeMeta(raw); err == nil {
+					m.Tags = append(m.Tags, tag)
+				}
+			}
+		case 14:
+			if raw, err = d.bytes(wt); err == nil {
+				m.Manifest = &web.Manifest{}
+				err = json.Unmarshal(raw, m.Manifest)
+			}
+		case 15:
+			m.BaseURL, err = d.string(wt)
+		case 16:
+			if raw, err = d.bytes(wt); err == nil {
+				m.StructuredData = &web.StructuredData{}
+				err = json.Unmarshal(raw, m.StructuredData)
+			}
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid metadata field %d: %w", field, err)
+		}
+	}
+	return nil
+}
+
+func decodeMeta(data []byte) (*web.Meta, error) {
+	meta := &web.Meta{}
+	d := &decoder{buf: data}
+	for !d.done() {
+		field, wt, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			meta.Tag, err = d.string(wt)
+		case 2:
+			meta.Name, err = d.string(wt)
+		case 3:
+			meta.Property, err = d.string(wt)
+		case 4:
+			meta.Content, err = d.string(wt)
+		case 5:
+			meta.Charset, err = d.string(wt)
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return meta, nil
+}
+
+func encodeLink(link *web.Link) []byte {
+	var e encoder
+	e.string(1, link.URL)
+	e.string(2, link.Text)
+	e.string(3, link.Rel)
+	e.string(4, link.Title)
+	e.string(5, link.Container)
+	e.bool(6, link.InMainContent)
+	return e.buf
+}
+
+func decodeLink(data []byte) (*web.Link, error) {
+	link := &web.Link{}
+	d := &decoder{buf: data}
+	for !d.done() {
+		field, wt, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			link.URL, err = d.string(wt)
+		case 2:
+			link.Text, err = d.string(wt)
+		case 3:
+			link.Rel, err = d.string(wt)
+		case 4:
+			link.Title, err = d.string(wt)
+		case 5:
+			link.Container, err = d.string(wt)
+		case 6:
+			link.InMainContent, err = d.bool(wt)
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return link, nil
+}
+
+// encodeHealthResponse encodes a grpc.health.v1.HealthCheckResponse.
+func encodeHealthResponse(status int) []byte {
+	var e encoder
+	e.int(1, int64(status))
+	return e.buf
+}
+
+// decodeHealthResponse decodes the status of a
+// grpc.health.v1.HealthCheckResponse.
+func decodeHealthResponse(data []byte) (int, error) {
+	var status int
+	d := &decoder{buf: data}
+	for !d.done() {
+		field, wt, err := d.next()
+		if err != nil {
+			return 0, err
+		}
+		switch field {
+		case 1:
+			err = d.int32(wt, &status)
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid health response field %d: %w", field, err)
+		}
+	}
+	return status, nil
+}
+
+func encodeCrawlRequest(r *CrawlRequest) []byte {
+	var e encoder
+	e.strings(1, r.URLs)
+	e.int(2, int64(r.MaxURLs))
+	e.int(3, int64(r.Workers))
+	e.string(4, r.FollowBehavior)
+	return e.buf
+}
+
+func decodeCrawlRequest(data []byte) (*CrawlRequest, error) {
+	r := &CrawlRequest{}
+	d := &decoder{buf: data}
+	for !d.done() {
+		field, wt, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			err = d.appendString(wt, &r.URLs)
+		case 2:
+			err = d.int32(wt, &r.MaxURLs)
+		case 3:
+			err = d.int32(wt, &r.Workers)
+		case 4:
+			r.FollowBehavior, err = d.string(wt)
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid crawl request field %d: %w", field, err)
+		}
+	}
+	return r, nil
+}
+
+func encodeCrawlResult(r *CrawlResult) ([]byte, error) {
+	var e encoder
+	e.string(1, r.URL)
+	e.strings(2, r.Links)
+	if r.Response != nil {
+		response, err := encodeResponse(r.Response)
+		if err != nil {
+			return nil, err
+		}
+		e.message(3, response)
+	}
+	e.string(4, r.Error)
+	return e.buf, nil
+}
+
+func decodeCrawlResult(data []byte) (*CrawlResult, error) {
+	r := &CrawlResult{}
+	d := &decoder{buf: data}
+	for !d.done() {
+		field, wt, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		var raw []byte
+		switch field {
+		case 1:
+			r.URL, err = d.string(wt)
+		case 2:
+			err = d.appendString(wt, &r.Links)
+		case 3:
+			if raw, err = d.bytes(wt); err == nil {
+				r.Response, err = decodeResponse(raw)
+			}
+		case 4:
+			r.Error, err = d.string(wt)
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid crawl result field %d: %w", field, err)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,299 @@
+package fetchgrpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/deepnoodle-ai/web/crawler"
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// ServerOptions used to configure a Server.
+type ServerOptions struct {
+	// Fetcher used to serve Fetch calls and to fetch pages during crawls.
+	Fetcher fetch.Fetcher
+
+	// MaxCrawlURLs bounds the number of pages a single Crawl call may fetch.
+	// Defaults to 1000.
+	MaxCrawlURLs int
+
+	// MaxCrawlWorkers bounds the concurrency of a single Crawl call.
+	// Defaults to 10.
+	MaxCrawlWorkers int
+
+	// MaxMessageSize limits the size of received messages. Defaults to
+	// DefaultMaxMessageSize.
+	MaxMessageSize int
+
+	// RenderRules customize the rendering of the pages of some domains,
+	// for both Fetch and Crawl calls.
+	RenderRules fetch.RenderRules
+
+	Logger *slog.Logger
+}
+
+// Server serves the FetchService over gRPC. It is an http.Handler and must be
+// served over HTTP/2, either with TLS or, as returned by HTTPServer, over
+// unencrypted HTTP/2.
+type Server struct {
+	fetcher         fetch.Fetcher
+	maxCrawlURLs    int
+	maxCrawlWorkers int
+	maxMessageSize  int
+	renderRules     fetch.RenderRules
+	logger          *slog.Logger
+}
+
+// NewServer creates a new Server with the given options.
+func NewServer(opts ServerOptions) *Server {
+	if opts.MaxCrawlURLs <= 0 {
+		opts.MaxCrawlURLs = 1000
+	}
+	if opts.MaxCrawlWorkers <= 0 {
+		opts.MaxCrawlWorkers = 10
+	}
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Server{
+		fetcher:         opts.Fetcher,
+		maxCrawlURLs:    opts.MaxCrawlURLs,
+		maxCrawlWorkers: opts.MaxCrawlWorkers,
+		maxMessageSize:  opts.MaxMessageSize,
+		renderRules:     opts.RenderRules,
+		logger:          opts.Logger,
+	}
+}
+
+// HTTPServer returns an http.Server that serves the gRPC service on the given
+// address over unencrypted HTTP/2.
+func (s *Server) HTTPServer(addr string) *http.Server {
+	protocols := &http.Protocols{}
+	protocols.SetUnencryptedHTTP2(true)
+	protocols.SetHTTP2(true)
+	return &http.Server{Addr: addr, Handler: s, Protocols: protocols}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		http.Error(w, "grpc requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), contentType) {
+		http.Error(w, "invalid grpc request", http.StatusUnsupportedMediaType)
+		return
+	}
+	ctx := r.Context()
+	if timeout, ok := parseTimeout(r.Header.Get(timeoutHeader)); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	stream := &serverStream{w: w}
+	var err error
+	switch r.URL.Path {
+	case fetchMethod:
+		err = s.handleFetch(ctx, r, stream)
+	case crawlMethod:
+		err = s.handleCrawl(ctx, r, stream)
+	case healthMethod:
+		err = s.handleHealth(ctx, r, stream)
+	default:
+		err = &StatusError{Code: CodeUnimplemented, Message: "unknown method " + r.URL.Path}
+	}
+	code, message := CodeOK, ""
+	if err != nil {
+		code, message = statusFromError(err)
+		s.logger.Debug("grpc call failed",
+			slog.String("method", r.URL.Path),
+			slog.Int("code", code),
+			slog.String("error", message))
+	}
+	stream.finish(code, message)
+}
+
+// Warmup warms up the fetcher of the server and checks its health. Call it
+// before serving, to fail on startup rather than on the first call.
+func (s *Server) Warmup(ctx context.Context) error {
+	if s.fetcher == nil {
+		return nil
+	}
+	return fetch.PrepareFetcher(ctx, s.fetcher)
+}
+
+// handleHealth implements the Check method of the standard gRPC health
+// service: the server is serving while its fetcher is healthy.
+func (s *Server) handleHealth(ctx context.Context, r *http.Request, stream *serverStream) error {
+	if _, err := readFrame(r.Body, s.maxMessageSize); err != nil {
+		return s.readError(err)
+	}
+	status := healthServing
+	if s.fetcher == nil {
+		status = healthNotServing
+	} else if err := fetch.CheckFetcherHealth(ctx, s.fetcher); err != nil {
+		s.logger.Warn("fetcher is unhealthy", slog.String("error", err.Error()))
+		status = healthNotServing
+	}
+	return stream.send(encodeHealthResponse(status))
+}
+
+func (s *Server) handleFetch(ctx context.Context, r *http.Request, stream *serverStream) error {
+	if s.fetcher == nil {
+		return &StatusError{Code: CodeUnimplemented, Message: "no fetcher configured"}
+	}
+	data, err := readFrame(r.Body, s.maxMessageSize)
+	if err != nil {
+		return s.readError(err)
+	}
+	request, err := decodeRequest(data)
+	if err != nil {
+		return errors.NewBadRequest("%s", err)
+	}
+	if err := fetch.ValidateRequest(request, fetch.FetcherCapabilities(s.fetcher)); err != nil {
+		return err
+	}
+	s.renderRules.Apply(request)
+	response, err := s.fetcher.Fetch(ctx, request)
+	if err != nil {
+		return err
+	}
+	message, err := encodeResponse(response)
+	if err != nil {
+		return &StatusError{Code: CodeInternal, Message: err.Error()}
+	}
+	return stream.send(message)
+}
+
+func (s *Server) handleCrawl(ctx context.Context, r *http.Request, stream *serverStream) error {
+	if s.fetcher == nil {
+		return &StatusError{Code: CodeUnimplemented, Message: "no fetcher configured"}
+	}
+	data, err := readFrame(r.Body, s.maxMessageSize)
+	if err != nil {
+		return s.readError(err)
+	}
+	request, err := decodeCrawlRequest(data)
+	if err != nil {
+		return errors.NewBadRequest("%s", err)
+	}
+	if len(request.URLs) == 0 {
+		return errors.NewBadRequest("urls are required")
+	}
+	maxURLs := request.MaxURLs
+	if maxURLs <= 0 || maxURLs > s.maxCrawlURLs {
+		maxURLs = s.maxCrawlURLs
+	}
+	workers := request.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	workers = min(workers, s.maxCrawlWorkers)
+	followBehavior := crawler.FollowBehavior(request.FollowBehavior)
+	switch followBehavior {
+	case "", crawler.FollowAny, crawler.FollowSameDomain, crawler.FollowRelatedSubdomains, crawler.FollowNone:
+	default:
+		return errors.NewBadRequest("invalid follow behavior %q", request.FollowBehavior)
+	}
+
+	c, err := crawler.New(crawler.Options{
+		MaxURLs:        maxURLs,
+		Workers:        workers,
+		DefaultFetcher: s.fetcher,
+		FollowBehavior: followBehavior,
+		RenderRules:    s.renderRules,
+		Logger:         s.logger,
+	})
+	if err != nil {
+		return errors.NewBadRequest("%s", err)
+	}
+
+	// Results arrive concurrently from the crawler workers
+	var mutex sync.Mutex
+	var sendErr error
+	err = c.Crawl(ctx, request.URLs, func(ctx context.Context, result *crawler.Result) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if sendErr != nil {
+			return crawler.ErrAbort
+		}
+		crawlResult := &CrawlResult{
+			URL:      result.URL.String(),
+			Links:    result.Links,
+			Response: result.Response,
+		}
+		if result.Error != nil {
+			crawlResult.Error = result.Error.Error()
+		}
+		message, err := encodeCrawlResult(crawlResult)
+		if err == nil {
+			err = stream.send(message)
+		}
+		if err != nil {
+			sendErr = err
+			return crawler.ErrAbort
+		}
+		return nil
+	})
+	mutex.Lock()
+	defer mutex.Unlock()
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (s *Server) readError(err error) error {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr
+	}
+	return &StatusError{Code: CodeInvalidArgument, Message: fmt.Sprintf("failed to read request: %s", err)}
+}
+
+// serverStream writes response messages followed by the call status. The
+// status is sent in the headers if no message was sent ("trailers only"),
+// otherwise in the trailers.
+type serverStream struct {
+	w    http.ResponseWriter
+	sent bool
+}
+
+func (s *serverStream) send(message []byte) error {
+	if !s.sent {
+		s.w.WriteHeader(http.StatusOK)
+		s.sent = true
+	}
+	if err := writeFrame(s.w, message); err != nil {
+		return err
+	}
+	return http.NewResponseController(s.w).Flush()
+}
+
+func (s *serverStream) finish(code int, message string) {
+	prefix := ""
+	if s.sent {
+		prefix = http.TrailerPrefix
+	}
+	s.w.Header().Set(prefix+statusHeader, strconv.Itoa(code))
+	if message != "" {
+		s.w.Header().Set(prefix+messageHeader, encodeMessage(message))
+	}
+	if !s.sent {
+		s.w.WriteHeader(http.StatusOK)
+	}
+}
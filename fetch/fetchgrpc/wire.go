@@ -0,0 +1,226 @@
+package fetchgrpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Protocol buffer wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("protobuf: truncated message")
+
+// encoder appends protocol buffer fields to a buffer. Zero values of scalar
+// fields are omitted, as in proto3.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) tag(field, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+func (e *encoder) bytes(field int, value []byte) {
+	if len(value) == 0 {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(value)))
+	e.buf = append(e.buf, value...)
+}
+
+func (e *encoder) string(field int, value string) {
+	e.bytes(field, []byte(value))
+}
+
+func (e *encoder) bool(field int, value bool) {
+	if value {
+		e.tag(field, wireVarint)
+		e.buf = append(e.buf, 1)
+	}
+}
+
+func (e *encoder) int(field int, value int64) {
+	if value != 0 {
+		e.tag(field, wireVarint)
+		e.buf = binary.AppendUvarint(e.buf, uint64(value))
+	}
+}
+
+// strings encodes a repeated string field. Unlike singular fields, empty
+// entries are kept.
+func (e *encoder) strings(field int, values []string) {
+	for _, value := range values {
+		e.tag(field, wireBytes)
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(value)))
+		e.buf = append(e.buf, value...)
+	}
+}
+
+// message encodes an embedded message, which is written even when empty.
+func (e *encoder) message(field int, value []byte) {
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(value)))
+	e.buf = append(e.buf, value...)
+}
+
+// stringMap encodes a map<string, string> field as repeated entries, in key
+// order so the output is deterministic. As in the code generated by protoc,
+// the key and value of entries are written even when empty.
+func (e *encoder) stringMap(field int, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var entry encoder
+		entry.message(1, []byte(key))
+		entry.message(2, []byte(values[key]))
+		e.message(field, entry.buf)
+	}
+}
+
+// decoder reads protocol buffer fields from a buffer.
+type decoder struct {
+	buf []byte
+}
+
+func (d *decoder) done() bool {
+	return len(d.buf) == 0
+}
+
+// next reads the tag of the next field.
+func (d *decoder) next() (field, wireType int, err error) {
+	v, err := d.uvarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	field, wireType = int(v>>3), int(v&7)
+	if field <= 0 {
+		return 0, 0, fmt.Errorf("protobuf: invalid field number %d", field)
+	}
+	return field, wireType, nil
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	d.buf = d.buf[n:]
+	return v, nil
+}
+
+func (d *decoder) varint(wireType int) (int64, error) {
+	if wireType != wireVarint {
+		return 0, fmt.Errorf("protobuf: unexpected wire type %d for varint", wireType)
+	}
+	v, err := d.uvarint()
+	return int64(v), err
+}
+
+func (d *decoder) bool(wireType int) (bool, error) {
+	v, err := d.varint(wireType)
+	return v != 0, err
+}
+
+func (d *decoder) bytes(wireType int) ([]byte, error) {
+	if wireType != wireBytes {
+		return nil, fmt.Errorf("protobuf: unexpected wire type %d for bytes", wireType)
+	}
+	n, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(d.buf)) < n {
+		return nil, errTruncated
+	}
+	value := d.buf[:n:n]
+	d.buf = d.buf[n:]
+	return value, nil
+}
+
+func (d *decoder) string(wireType int) (string, error) {
+	b, err := d.bytes(wireType)
+	return string(b), err
+}
+
+// mapEntry reads one entry of a map<string, string> field.
+func (d *decoder) mapEntry(wireType int, values map[string]string) error {
+	b, err := d.bytes(wireType)
+	if err != nil {
+		return err
+	}
+	var key, value string
+	entry := &decoder{buf: b}
+	for !entry.done() {
+		field, wt, err := entry.next()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			key, err = entry.string(wt)
+		case 2:
+			value, err = entry.string(wt)
+		default:
+			err = entry.skip(wt)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	values[key] = value
+	return nil
+}
+
+// skip discards a field of the given wire type, such as a field added in a
+// newer version of the protocol.
+func (d *decoder) skip(wireType int) error {
+	var n int
+	switch wireType {
+	case wireVarint:
+		_, err := d.uvarint()
+		return err
+	case wireBytes:
+		_, err := d.bytes(wireType)
+		return err
+	case wireFixed64:
+		n = 8
+	case wireFixed32:
+		n = 4
+	default:
+		return fmt.Errorf("protobuf: unsupported wire type %d", wireType)
+	}
+	if len(d.buf) < n {
+		return errTruncated
+	}
+	d.buf = d.buf[n:]
+	return nil
+}
+
+// appendString reads one entry of a repeated string field.
+func (d *decoder) appendString(wireType int, values *[]string) error {
+	s, err := d.string(wireType)
+	if err == nil {
+		*values = append(*values, s)
+	}
+	return err
+}
+
+// int32 reads an int32 field into an int.
+func (d *decoder) int32(wireType int, value *int) error {
+	v, err := d.varint(wireType)
+	if err == nil {
+		*value = int(int32(v))
+	}
+	return err
+}
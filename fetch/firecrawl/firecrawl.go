@@ -0,0 +1,211 @@
+// Package firecrawl provides a Firecrawl-compatible API on top of the fetch
+// and crawler packages, so existing Firecrawl (v1) clients can point at a
+// self-hosted instance. Request payloads are translated to fetch.Request and
+// crawler options, and responses back to the Firecrawl document format.
+package firecrawl
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// Action is a Firecrawl page action. Only wait and screenshot actions are
+// supported.
+type Action struct {
+	Type         string `json:"type"`
+	Milliseconds int    `json:"milliseconds,omitempty"`
+	Selector     string `json:"selector,omitempty"`
+	FullPage     bool   `json:"fullPage,omitempty"`
+}
+
+// ScrapeOptions are the Firecrawl scrape options, used directly by /scrape
+// and as scrapeOptions by /crawl.
+type ScrapeOptions struct {
+	Formats         []string          `json:"formats,omitempty"`
+	OnlyMainContent *bool             `json:"onlyMainContent,omitempty"` // defaults to true
+	IncludeTags     []string          `json:"includeTags,omitempty"`
+	ExcludeTags     []string          `json:"excludeTags,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	WaitFor         int               `json:"waitFor,omitempty"` // milliseconds
+	Mobile          bool              `json:"mobile,omitempty"`
+	Timeout         int               `json:"timeout,omitempty"` // milliseconds
+	MaxAge          int               `json:"maxAge,omitempty"`  // milliseconds
+	Actions         []Action          `json:"actions,omitempty"`
+}
+
+// ScrapeRequest is the payload of a Firecrawl /scrape request.
+type ScrapeRequest struct {
+	URL string `json:"url"`
+	ScrapeOptions
+}
+
+// CrawlRequest is the payload of a Firecrawl /crawl request.
+type CrawlRequest struct {
+	URL                string        `json:"url"`
+	Limit              int           `json:"limit,omitempty"`
+	MaxDepth           int           `json:"maxDepth,omitempty"`
+	IncludePaths       []string      `json:"includePaths,omitempty"`
+	ExcludePaths       []string      `json:"excludePaths,omitempty"`
+	AllowExternalLinks bool          `json:"allowExternalLinks,omitempty"`
+	AllowBackwardLinks bool          `json:"allowBackwardLinks,omitempty"`
+	ScrapeOptions      ScrapeOptions `json:"scrapeOptions,omitempty"`
+}
+
+// Metadata is the metadata of a scraped Firecrawl document.
+type Metadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Keywords    string `json:"keywords,omitempty"`
+	Robots      string `json:"robots,omitempty"`
+	OGImage     string `json:"ogImage,omitempty"`
+	SourceURL   string `json:"sourceURL"`
+	URL         string `json:"url,omitempty"`
+	StatusCode  int    `json:"statusCode"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Document is a scraped page in the Firecrawl format.
+type Document struct {
+	Markdown   string   `json:"markdown,omitempty"`
+	HTML       string   `json:"html,omitempty"`
+	RawHTML    string   `json:"rawHtml,omitempty"`
+	Links      []string `json:"links,omitempty"`
+	Screenshot string   `json:"screenshot,omitempty"`
+	Metadata   Metadata `json:"metadata"`
+}
+
+// ScrapeResponse is the response to a /scrape request.
+type ScrapeResponse struct {
+	Success bool      `json:"success"`
+	Data    *Document `json:"data,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// ToRequest translates Firecrawl scrape options for the given URL into a
+// fetch.Request. Returns an *errors.BadRequest for unsupported formats and
+// actions.
+func (o *ScrapeOptions) ToRequest(rawURL string) (*fetch.Request, error) {
+	if strings.TrimSpace(rawURL) == "" {
+		return nil, errors.NewBadRequest("url is required")
+	}
+	request := &fetch.Request{
+		URL:             rawURL,
+		OnlyMainContent: o.OnlyMainContent == nil || *o.OnlyMainContent,
+		IncludeTags:     o.IncludeTags,
+		ExcludeTags:     o.ExcludeTags,
+		Headers:         o.Headers,
+		WaitFor:         o.WaitFor,
+		Mobile:          o.Mobile,
+		Timeout:         o.Timeout,
+		MaxAge:          o.MaxAge,
+	}
+	formats := o.Formats
+	if len(formats) == 0 {
+		formats = []string{"markdown"}
+	}
+	for _, format := range formats {
+		switch format {
+		case "markdown":
+			request.Formats = appendFormat(request.Formats, "markdown")
+		case "html", "rawHtml":
+			request.Formats = appendFormat(request.Formats, "html")
+		case "links":
+			// Links are always included in responses
+		case "screenshot", "screenshot@fullPage":
+			request.Actions = append(request.Actions, fetch.NewScreenshotAction(fetch.ScreenshotActionOptions{
+				FullPage: format == "screenshot@fullPage",
+			}))
+		default:
+			return nil, errors.NewBadRequest("unsupported format: %s", format)
+		}
+	}
+	for i, action := range o.Actions {
+		switch action.Type {
+		case "wait":
+			request.Actions = append(request.Actions, fetch.NewWaitAction(fetch.WaitActionOptions{
+				Selector: action.Selector,
+				Duration: action.Milliseconds,
+			}))
+		case "screenshot":
+			request.Actions = append(request.Actions, fetch.NewScreenshotAction(fetch.ScreenshotActionOptions{
+				FullPage: action.FullPage,
+			}))
+		default:
+			return nil, errors.NewBadRequest("actions[%d]: unsupported action type: %s", i, action.Type)
+		}
+	}
+	if err := fetch.ValidateRequest(request, nil); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func appendFormat(formats []string, format string) []string {
+	for _, f := range formats {
+		if f == format {
+			return formats
+		}
+	}
+	return append(formats, format)
+}
+
+// NewDocument converts a fetch response into a Firecrawl document containing
+// the requested formats. Relative links are resolved against the page URL.
+func NewDocument(response *fetch.Response, opts *ScrapeOptions) *Document {
+	formats := map[string]bool{}
+	for _, format := range opts.Formats {
+		formats[format] = true
+	}
+	if len(formats) == 0 {
+		formats["markdown"] = true
+	}
+	doc := &Document{
+		Screenshot: response.Screenshot,
+		Metadata: Metadata{
+			Title:       response.Metadata.Title,
+			Description: response.Metadata.Description,
+			Language:    response.Metadata.Language,
+			Keywords:    strings.Join(response.Metadata.Keywords, ", "),
+			Robots:      response.Metadata.Robots,
+			OGImage:     response.Metadata.Image,
+			SourceURL:   response.URL,
+			URL:         response.URL,
+			StatusCode:  response.StatusCode,
+			Error:       response.Error,
+		},
+	}
+	if formats["markdown"] {
+		doc.Markdown = response.Markdown
+	}
+	if formats["html"] {
+		doc.HTML = response.HTML
+	}
+	if formats["rawHtml"] {
+		doc.RawHTML = response.HTML
+	}
+	if formats["links"] {
+		base, _ := url.Parse(response.URL)
+		seen := map[string]bool{}
+		for _, link := range response.Links {
+			u, err := url.Parse(link.URL)
+			if err != nil {
+				continue
+			}
+			if base != nil {
+				u = base.ResolveReference(u)
+			}
+			if u.Scheme != "http" && u.Scheme != "https" {
+				continue
+			}
+			if s := u.String(); !seen[s] {
+				seen[s] = true
+				doc.Links = append(doc.Links, s)
+			}
+		}
+	}
+	return doc
+}
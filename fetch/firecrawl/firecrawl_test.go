@@ -0,0 +1,145 @@
+package firecrawl
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapeOptions_ToRequest(t *testing.T) {
+	onlyMain := false
+	opts := &ScrapeOptions{
+		Formats:         []string{"markdown", "html", "rawHtml", "links", "screenshot@fullPage"},
+		OnlyMainContent: &onlyMain,
+		ExcludeTags:     []string{"nav"},
+		WaitFor:         500,
+		Actions:         []Action{{Type: "wait", Milliseconds: 200}},
+	}
+	request, err := opts.ToRequest("https://example.com")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", request.URL)
+	require.False(t, request.OnlyMainContent)
+	require.Equal(t, []string{"markdown", "html"}, request.Formats)
+	require.Equal(t, []string{"nav"}, request.ExcludeTags)
+	require.Equal(t, 500, request.WaitFor)
+	require.Equal(t, []fetch.Action{
+		fetch.NewScreenshotAction(fetch.ScreenshotActionOptions{FullPage: true}),
+		fetch.NewWaitAction(fetch.WaitActionOptions{Duration: 200}),
+	}, request.Actions)
+
+	request, err = (&ScrapeOptions{}).ToRequest("https://example.com")
+	require.NoError(t, err)
+	require.True(t, request.OnlyMainContent)
+	require.Equal(t, []string{"markdown"}, request.Formats)
+
+	_, err = (&ScrapeOptions{Formats: []string{"json"}}).ToRequest("https://example.com")
+	require.EqualError(t, err, "unsupported format: json")
+
+	_, err = (&ScrapeOptions{Actions: []Action{{Type: "click"}}}).ToRequest("https://example.com")
+	require.EqualError(t, err, "actions[0]: unsupported action type: click")
+}
+
+func newTestFetcher() *fetch.MockFetcher {
+	fetcher := fetch.NewMockFetcher()
+	fetcher.AddResponse("https://example.com", &fetch.Response{
+		URL:        "https://example.com",
+		StatusCode: 200,
+		HTML:       "<h1>Home</h1>",
+		Markdown:   "# Home",
+		Metadata:   web.Metadata{Title: "Home"},
+		Links:      []*web.Link{{URL: "/about"}, {URL: "mailto:hi@example.com"}},
+	})
+	fetcher.AddResponse("https://example.com/about", &fetch.Response{
+		URL:        "https://example.com/about",
+		StatusCode: 200,
+		Markdown:   "# About",
+		Links:      []*web.Link{{URL: "/about/team"}},
+	})
+	fetcher.AddResponse("https://example.com/about/team", &fetch.Response{
+		URL:        "https://example.com/about/team",
+		StatusCode: 200,
+		Markdown:   "# Team",
+	})
+	return fetcher
+}
+
+func post(t *testing.T, handler http.Handler, path string, payload any) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	return w
+}
+
+func TestHandler_Scrape(t *testing.T) {
+	handler := NewHandler(HandlerOptions{Fetcher: newTestFetcher()})
+
+	w := post(t, handler, "/v1/scrape", map[string]any{
+		"url":     "https://example.com",
+		"formats": []string{"markdown", "links"},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{
+		"success": true,
+		"data": {
+			"markdown": "# Home",
+			"links": ["https://example.com/about"],
+			"metadata": {"title": "Home", "sourceURL": "https://example.com", "url": "https://example.com", "statusCode": 200}
+		}
+	}`, w.Body.String())
+
+	w = post(t, handler, "/v1/scrape", map[string]any{"formats": []string{"markdown"}})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"success": false, "error": "url is required"}`, w.Body.String())
+}
+
+func TestHandler_Crawl(t *testing.T) {
+	handler := NewHandler(HandlerOptions{Fetcher: newTestFetcher()})
+	crawl := func(payload map[string]any) []string {
+		payload["url"] = "https://example.com"
+		w := post(t, handler, "/v1/crawl", payload)
+		require.Equal(t, http.StatusOK, w.Code)
+		var started CrawlResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+		require.True(t, started.Success)
+		require.Equal(t, "http://example.com/v1/crawl/"+started.ID, started.URL)
+
+		var status CrawlStatus
+		require.Eventually(t, func() bool {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/crawl/"+started.ID, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+			return status.Status == StatusCompleted
+		}, 10*time.Second, 50*time.Millisecond)
+		require.Equal(t, len(status.Data), status.Completed)
+
+		var markdown []string
+		for _, doc := range status.Data {
+			markdown = append(markdown, doc.Markdown)
+		}
+		return markdown
+	}
+
+	require.ElementsMatch(t, []string{"# Home", "# About", "# Team"}, crawl(map[string]any{"limit": 5}))
+	require.ElementsMatch(t, []string{"# Home", "# About"}, crawl(map[string]any{"maxDepth": 1}))
+	require.ElementsMatch(t, []string{"# Home", "# About"}, crawl(map[string]any{"excludePaths": []string{"^/about/"}}))
+	// The seed is crawled, but not the pages only linked from excluded ones
+	require.ElementsMatch(t, []string{"# Home"}, crawl(map[string]any{"includePaths": []string{"^/about/team$"}}))
+
+	w := post(t, handler, "/v1/crawl", map[string]any{"url": "https://example.com", "includePaths": []string{"("}})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"success": false, "error": "invalid includePaths pattern: \"(\""}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/crawl/missing", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,358 @@
+package firecrawl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/web/crawler"
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// Crawl job statuses.
+const (
+	StatusScraping  = "scraping"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// CrawlResponse is the response to a /crawl request.
+type CrawlResponse struct {
+	Success bool   `json:"success"`
+	ID      string `json:"id,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// CrawlStatus is the response to a crawl status request.
+type CrawlStatus struct {
+	Status      string      `json:"status"`
+	Total       int         `json:"total"`
+	Completed   int         `json:"completed"`
+	CreditsUsed int         `json:"creditsUsed"`
+	ExpiresAt   time.Time   `json:"expiresAt"`
+	Data        []*Document `json:"data"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// HandlerOptions used to configure a Handler.
+type HandlerOptions struct {
+	// Fetcher used for scrapes and crawls.
+	Fetcher fetch.Fetcher
+
+	// MaxCrawlURLs bounds the limit of a crawl. Defaults to 1000.
+	MaxCrawlURLs int
+
+	// CrawlWorkers is the concurrency of each crawl. Defaults to 5.
+	CrawlWorkers int
+
+	// JobTTL is how long crawl results are kept. Defaults to 24 hours.
+	JobTTL time.Duration
+
+	Logger *slog.Logger
+}
+
+// Handler serves the Firecrawl v1 API:
+//
+//	POST   /v1/scrape
+//	POST   /v1/crawl
+//	GET    /v1/crawl/{id}
+//	DELETE /v1/crawl/{id}
+//
+// Crawls run in the background and their results are kept in memory.
+type Handler struct {
+	fetcher      fetch.Fetcher
+	maxCrawlURLs int
+	crawlWorkers int
+	jobTTL       time.Duration
+	logger       *slog.Logger
+	mux          *http.ServeMux
+	jobs         map[string]*crawlJob
+	mutex        sync.Mutex
+}
+
+type crawlJob struct {
+	mutex     sync.Mutex
+	status    string
+	total     int
+	data      []*Document
+	err       string
+	expiresAt time.Time
+	cancel    context.CancelFunc
+}
+
+// NewHandler creates a new Handler with the given options.
+func NewHandler(opts HandlerOptions) *Handler {
+	if opts.MaxCrawlURLs <= 0 {
+		opts.MaxCrawlURLs = 1000
+	}
+	if opts.CrawlWorkers <= 0 {
+		opts.CrawlWorkers = 5
+	}
+	if opts.JobTTL <= 0 {
+		opts.JobTTL = 24 * time.Hour
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	h := &Handler{
+		fetcher:      opts.Fetcher,
+		maxCrawlURLs: opts.MaxCrawlURLs,
+		crawlWorkers: opts.CrawlWorkers,
+		jobTTL:       opts.JobTTL,
+		logger:       opts.Logger,
+		mux:          http.NewServeMux(),
+		jobs:         map[string]*crawlJob{},
+	}
+	h.mux.HandleFunc("POST /v1/scrape", h.scrape)
+	h.mux.HandleFunc("POST /v1/crawl", h.crawl)
+	h.mux.HandleFunc("GET /v1/crawl/{id}", h.crawlStatus)
+	h.mux.HandleFunc("DELETE /v1/crawl/{id}", h.cancelCrawl)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) scrape(w http.ResponseWriter, r *http.Request) {
+	var payload ScrapeRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, errors.NewBadRequest("invalid json body"))
+		return
+	}
+	request, err := payload.ToRequest(payload.URL)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := fetch.ValidateRequest(request, fetch.FetcherCapabilities(h.fetcher)); err != nil {
+		writeError(w, err)
+		return
+	}
+	response, err := h.fetcher.Fetch(r.Context(), request)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &ScrapeResponse{
+		Success: true,
+		Data:    NewDocument(response, &payload.ScrapeOptions),
+	})
+}
+
+func (h *Handler) crawl(w http.ResponseWriter, r *http.Request) {
+	var payload CrawlRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, errors.NewBadRequest("invalid json body"))
+		return
+	}
+	template, err := payload.ScrapeOptions.ToRequest(payload.URL)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := fetch.ValidateRequest(template, fetch.FetcherCapabilities(h.fetcher)); err != nil {
+		writeError(w, err)
+		return
+	}
+	limit := payload.Limit
+	if limit <= 0 || limit > h.maxCrawlURLs {
+		limit = h.maxCrawlURLs
+	}
+	followBehavior := crawler.FollowSameDomain
+	if payload.AllowExternalLinks {
+		followBehavior = crawler.FollowAny
+	}
+	includePatterns, err := pathPatterns("includePaths", payload.IncludePaths)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	excludePatterns, err := pathPatterns("excludePaths", payload.ExcludePaths)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := crawler.New(crawler.Options{
+		MaxURLs:         limit,
+		MaxDepth:        payload.MaxDepth,
+		Workers:         h.crawlWorkers,
+		DefaultFetcher:  &templateFetcher{fetcher: h.fetcher, template: template},
+		FollowBehavior:  followBehavior,
+		IncludePatterns: includePatterns,
+		ExcludePatterns: excludePatterns,
+		Logger:          h.logger,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id := newJobID()
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &crawlJob{
+		status:    StatusScraping,
+		expiresAt: time.Now().Add(h.jobTTL),
+		cancel:    cancel,
+	}
+	h.mutex.Lock()
+	h.pruneJobs()
+	h.jobs[id] = job
+	h.mutex.Unlock()
+
+	go func() {
+		defer cancel()
+		err := c.Crawl(ctx, []string{payload.URL}, func(ctx context.Context, result *crawler.Result) error {
+			job.mutex.Lock()
+			defer job.mutex.Unlock()
+			job.total++
+			if result.Response == nil {
+				return nil
+			}
+			job.data = append(job.data, NewDocument(result.Response, &payload.ScrapeOptions))
+			return nil
+		})
+		job.mutex.Lock()
+		defer job.mutex.Unlock()
+		switch {
+		case job.status == StatusCancelled:
+		case err != nil:
+			job.status, job.err = StatusFailed, err.Error()
+		default:
+			job.status = StatusCompleted
+		}
+	}()
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	writeJSON(w, http.StatusOK, &CrawlResponse{
+		Success: true,
+		ID:      id,
+		URL:     scheme + "://" + r.Host + "/v1/crawl/" + id,
+	})
+}
+
+// pathPatterns returns the crawler rules of the includePaths or excludePaths
+// of a crawl request, which are regular expressions matched against the
+// paths of URLs.
+func pathPatterns(field string, patterns []string) ([]*crawler.MatchRule, error) {
+	var rules []*crawler.MatchRule
+	for _, pattern := range patterns {
+		rule := &crawler.MatchRule{Pattern: pattern, Type: crawler.MatchRegex}
+		if err := rule.Compile(); err != nil {
+			return nil, errors.NewBadRequest("invalid %s pattern: %q", field, pattern)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (h *Handler) crawlStatus(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.job(r.PathValue("id"))
+	if !ok {
+		writeError(w, errors.NewNotFound("crawl job not found"))
+		return
+	}
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	data := job.data
+	if data == nil {
+		data = []*Document{}
+	}
+	writeJSON(w, http.StatusOK, &CrawlStatus{
+		Status:      job.status,
+		Total:       job.total,
+		Completed:   len(job.data),
+		CreditsUsed: len(job.data),
+		ExpiresAt:   job.expiresAt,
+		Data:        data,
+		Error:       job.err,
+	})
+}
+
+func (h *Handler) cancelCrawl(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.job(r.PathValue("id"))
+	if !ok {
+		writeError(w, errors.NewNotFound("crawl job not found"))
+		return
+	}
+	job.mutex.Lock()
+	if job.status == StatusScraping {
+		job.status = StatusCancelled
+	}
+	job.mutex.Unlock()
+	job.cancel()
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusCancelled})
+}
+
+func (h *Handler) job(id string) (*crawlJob, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.pruneJobs()
+	job, ok := h.jobs[id]
+	return job, ok
+}
+
+// pruneJobs removes expired jobs. The caller must hold h.mutex.
+func (h *Handler) pruneJobs() {
+	now := time.Now()
+	for id, job := range h.jobs {
+		if now.After(job.expiresAt) {
+			job.cancel()
+			delete(h.jobs, id)
+		}
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// templateFetcher applies the scrape options of a crawl to each request made
+// by the crawler.
+type templateFetcher struct {
+	fetcher  fetch.Fetcher
+	template *fetch.Request
+}
+
+func (f *templateFetcher) Fetch(ctx context.Context, request *fetch.Request) (*fetch.Response, error) {
+	merged := *f.template
+	merged.URL = request.URL
+	return f.fetcher.Fetch(ctx, &merged)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes an error in the Firecrawl format with a status code
+// matching the error type.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var (
+		badRequest *errors.BadRequest
+		notFound   *errors.NotFound
+	)
+	switch {
+	case errors.As(err, &badRequest):
+		status = http.StatusBadRequest
+	case errors.As(err, &notFound):
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, map[string]any{"success": false, "error": err.Error()})
+}
@@ -0,0 +1,98 @@
+package fetch
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Header is an HTTP header with the exact casing of its name.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// HeaderProfile is a list of headers sent in order and with the exact casing
+// given, as some bot detection systems check both. Headers of a request that
+// are in the profile replace the profile value in place, and other request
+// headers are sent after the profile headers. A "Host" entry positions the
+// Host header, which is otherwise sent first; its value is ignored.
+//
+// Only gzip and deflate are decoded, so profiles should not advertise other
+// encodings in Accept-Encoding.
+type HeaderProfile []Header
+
+// FirefoxHeaderProfile mimics the headers sent by Firefox on navigation.
+var FirefoxHeaderProfile = HeaderProfile{
+	{"Host", ""},
+	{"User-Agent", FakeUserAgent},
+	{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"},
+	{"Accept-Language", "en-US,en;q=0.5"},
+	{"Accept-Encoding", "gzip, deflate"},
+	{"Connection", "keep-alive"},
+	{"Upgrade-Insecure-Requests", "1"},
+	{"Sec-Fetch-Dest", "document"},
+	{"Sec-Fetch-Mode", "navigate"},
+	{"Sec-Fetch-Site", "none"},
+	{"Sec-Fetch-User", "?1"},
+	{"Priority", "u=0, i"},
+}
+
+// ChromeHeaderProfile mimics the headers sent by Chrome on navigation.
+var ChromeHeaderProfile = HeaderProfile{
+	{"Host", ""},
+	{"Connection", "keep-alive"},
+	{"sec-ch-ua", `"Chromium";v="131", "Not_A Brand";v="24", "Google Chrome";v="131"`},
+	{"sec-ch-ua-mobile", "?0"},
+	{"sec-ch-ua-platform", `"macOS"`},
+	{"Upgrade-Insecure-Requests", "1"},
+	{"User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"},
+	{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"},
+	{"Sec-Fetch-Site", "none"},
+	{"Sec-Fetch-Mode", "navigate"},
+	{"Sec-Fetch-User", "?1"},
+	{"Sec-Fetch-Dest", "document"},
+	{"Accept-Encoding", "gzip, deflate"},
+	{"Accept-Language", "en-US,en;q=0.9"},
+}
+
+// apply returns the headers to send for a request in order: the profile
+// headers, overridden by the request headers, followed by the remaining
+// request headers sorted by name.
+func (p HeaderProfile) apply(host string, header http.Header) []Header {
+	var headers []Header
+	used := map[string]bool{}
+	hasHost := false
+	for _, h := range p {
+		key := http.CanonicalHeaderKey(h.Name)
+		if key == "Host" {
+			headers = append(headers, Header{h.Name, host})
+			hasHost = true
+			continue
+		}
+		used[key] = true
+		if values, ok := header[key]; ok {
+			for _, value := range values {
+				headers = append(headers, Header{h.Name, value})
+			}
+		} else {
+			headers = append(headers, h)
+		}
+	}
+	if !hasHost {
+		headers = append([]Header{{"Host", host}}, headers...)
+	}
+	var names []string
+	for name := range header {
+		if !used[http.CanonicalHeaderKey(name)] && !strings.EqualFold(name, "Host") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			headers = append(headers, Header{name, value})
+		}
+	}
+	return headers
+}
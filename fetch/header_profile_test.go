@@ -0,0 +1,69 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPFetcher_HeaderProfile(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// Serve a gzipped page and capture the raw request head
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	gz.Write([]byte(`<html><head><title>Ordered</title></head></html>`))
+	gz.Close()
+	heads := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+		heads <- lines
+		fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Encoding: gzip\r\nContent-Length: %d\r\n\r\n", body.Len())
+		conn.Write(body.Bytes())
+	}()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{
+		HeaderProfile: HeaderProfile{
+			{"user-agent", "Test/1.0"},
+			{"Host", ""},
+			{"accept", "text/html"},
+			{"Accept-Encoding", "gzip"},
+		},
+	})
+	response, err := fetcher.Fetch(context.Background(), &Request{
+		URL:     "http://" + listener.Addr().String() + "/page?q=1",
+		Headers: map[string]string{"Accept": "*/*", "X-Extra": "yes"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Ordered", response.Metadata.Title)
+	require.Equal(t, []string{
+		"GET /page?q=1 HTTP/1.1",
+		"user-agent: Test/1.0",
+		"Host: " + listener.Addr().String(),
+		"accept: */*",
+		"Accept-Encoding: gzip",
+		"X-Extra: yes",
+	}, <-heads)
+}
@@ -0,0 +1,48 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+)
+
+// Warmer is implemented by fetchers that prepare their resources ahead of
+// the first fetch, such as the BrowserFetcher launching its browser.
+type Warmer interface {
+	Warmup(ctx context.Context) error
+}
+
+// HealthChecker is implemented by fetchers that can check they are able to
+// fetch pages, e.g. that their proxy or remote service is reachable.
+type HealthChecker interface {
+	Healthy(ctx context.Context) error
+}
+
+// WarmupFetcher warms up a fetcher, if it implements Warmer.
+func WarmupFetcher(ctx context.Context, fetcher Fetcher) error {
+	if warmer, ok := fetcher.(Warmer); ok {
+		return warmer.Warmup(ctx)
+	}
+	return nil
+}
+
+// CheckFetcherHealth checks the health of a fetcher, if it implements
+// HealthChecker.
+func CheckFetcherHealth(ctx context.Context, fetcher Fetcher) error {
+	if checker, ok := fetcher.(HealthChecker); ok {
+		return checker.Healthy(ctx)
+	}
+	return nil
+}
+
+// PrepareFetcher warms up a fetcher and checks its health, so that a
+// misconfigured fetcher fails on startup with a clear error rather than on
+// the first page it fetches.
+func PrepareFetcher(ctx context.Context, fetcher Fetcher) error {
+	if err := WarmupFetcher(ctx, fetcher); err != nil {
+		return fmt.Errorf("failed to warm up fetcher: %w", err)
+	}
+	if err := CheckFetcherHealth(ctx, fetcher); err != nil {
+		return fmt.Errorf("fetcher is unhealthy: %w", err)
+	}
+	return nil
+}
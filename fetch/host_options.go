@@ -0,0 +1,100 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPVersion is an HTTP protocol version that requests can be forced to use.
+type HTTPVersion string
+
+const (
+	HTTP1 HTTPVersion = "1.1"
+	HTTP2 HTTPVersion = "2"
+)
+
+// HostOptions overrides the options of an HTTPFetcher for the requests to one
+// host, e.g. to special case a slow or picky host without affecting a crawl.
+// Zero values use the fetcher options.
+type HostOptions struct {
+	// Timeout of requests to the host.
+	Timeout time.Duration
+
+	// MaxConnections limits the number of concurrent requests to the host.
+	MaxConnections int
+
+	// HTTPVersion forces the HTTP version of requests to the host. HTTP/2 is
+	// used over cleartext connections without upgrade, so the host must
+	// support it. Header profiles always use HTTP/1.1, and DialTLS does not
+	// support HTTP/2. Ignored if Client is set.
+	HTTPVersion HTTPVersion
+
+	// Proxy of requests to the host. The proxy of a Request takes precedence.
+	// Ignored if Client is set.
+	Proxy string
+}
+
+// hostOverride holds the client, timeout and connection limit of a host with
+// options.
+type hostOverride struct {
+	client  *http.Client
+	timeout time.Duration
+	slots   chan struct{}
+}
+
+// newHostOverride returns the override of a host. The client is shared with
+// the fetcher unless the host options require a different one.
+func newHostOverride(options HTTPFetcherOptions, client *http.Client, host HostOptions) *hostOverride {
+	override := &hostOverride{client: client, timeout: host.Timeout}
+	if host.MaxConnections > 0 {
+		override.slots = make(chan struct{}, host.MaxConnections)
+	}
+	if options.Client != nil || (host.HTTPVersion == "" && host.Proxy == "") {
+		return override
+	}
+	if host.Proxy != "" {
+		options.Proxy = host.Proxy
+	}
+	transport := newTransport(options)
+	if t, ok := transport.(*compressionTransport); ok && host.HTTPVersion != "" {
+		t.Protocols = new(http.Protocols)
+		switch host.HTTPVersion {
+		case HTTP2:
+			t.Protocols.SetHTTP2(true)
+			t.Protocols.SetUnencryptedHTTP2(true)
+		default:
+			t.Protocols.SetHTTP1(true)
+		}
+	}
+	override.client = &http.Client{Transport: transport}
+	return override
+}
+
+// acquire waits for a connection slot to the host. The returned function
+// releases the slot.
+func (o *hostOverride) acquire(ctx context.Context) (func(), error) {
+	if o.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case o.slots <- struct{}{}:
+		return func() { <-o.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// hostOverride returns the override for the host of the URL, matching the
+// host with its port first and then the host name.
+func (f *HTTPFetcher) hostOverride(u *url.URL) *hostOverride {
+	if len(f.hosts) == 0 {
+		return nil
+	}
+	if override, ok := f.hosts[strings.ToLower(u.Host)]; ok {
+		return override
+	}
+	return f.hosts[strings.ToLower(u.Hostname())]
+}
@@ -0,0 +1,520 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPFetcher_FetchManifest(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Home</title><link rel="manifest" href="/manifest.json"></head></html>`))
+	})
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/manifest+json")
+		w.Write([]byte(`{"name": "Home App", "theme_color": "#000000", "icons": [{"src": "/icon.png", "sizes": "192x192"}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{FetchManifest: true})
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+	require.NoError(t, err)
+	require.Equal(t, "Home", response.Metadata.Title)
+	require.NotNil(t, response.Metadata.Manifest)
+	require.Equal(t, "Home App", response.Metadata.Manifest.Name)
+	require.Equal(t, "#000000", response.Metadata.Manifest.ThemeColor)
+	require.Equal(t, server.URL+"/icon.png", response.Metadata.Manifest.Icons[0].Src)
+
+	// Manifests are not fetched unless enabled
+	response, err = NewHTTPFetcher(HTTPFetcherOptions{}).Fetch(context.Background(), &Request{URL: server.URL})
+	require.NoError(t, err)
+	require.Equal(t, "/manifest.json", response.Metadata.ManifestURL)
+	require.Nil(t, response.Metadata.Manifest)
+}
+
+func TestHTTPFetcher_InlineIframes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><h1>Page</h1>
+			<iframe src="/frame"></iframe>
+			<iframe src="/missing"></iframe>
+			<iframe src="https://other.example.com/frame"></iframe>
+			<noscript><p>Enable JavaScript</p></noscript>
+		</body></html>`))
+	})
+	mux.HandleFunc("/frame", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><p>Framed content</p></body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{})
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL, InlineIframes: true, IncludeNoscript: true})
+	require.NoError(t, err)
+	require.Contains(t, response.HTML, `<div data-iframe-src="`+server.URL+`/frame"><p>Framed content</p></div>`)
+	require.Contains(t, response.HTML, `<iframe src="/missing"></iframe>`)
+	require.Contains(t, response.HTML, `<iframe src="https://other.example.com/frame"></iframe>`)
+	require.Contains(t, response.HTML, `<p>Enable JavaScript</p>`)
+	require.NotContains(t, response.HTML, `<noscript>`)
+
+	// Iframes are not fetched unless requested
+	response, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+	require.NoError(t, err)
+	require.Contains(t, response.HTML, `<iframe src="/frame"></iframe>`)
+}
+
+func TestHTTPFetcher_FinalURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>New</title></head></html>`))
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/old", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{})
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/old"})
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/old", response.URL)
+	require.Equal(t, server.URL+"/new", response.FinalURL)
+	require.True(t, response.PermanentRedirect)
+
+	// Redirect chains are permanent only if all their redirects are
+	response, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/temporary"})
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/new", response.FinalURL)
+	require.False(t, response.PermanentRedirect)
+
+	response, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/new"})
+	require.NoError(t, err)
+	require.Empty(t, response.FinalURL)
+}
+
+func TestHTTPFetcher_ConditionalRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{})
+	require.True(t, fetcher.Capabilities().ConditionalRequests)
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+	require.NoError(t, err)
+	require.False(t, response.NotModified())
+
+	headers := ConditionalHeaders(response)
+	require.Equal(t, map[string]string{
+		"If-None-Match":     `"v1"`,
+		"If-Modified-Since": "Mon, 02 Jan 2006 15:04:05 GMT",
+	}, headers)
+	response, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL, Headers: headers})
+	require.NoError(t, err)
+	require.True(t, response.NotModified())
+	require.Empty(t, response.HTML)
+	require.Equal(t, `"v1"`, response.Headers["Etag"])
+
+	require.Nil(t, ConditionalHeaders(&Response{Headers: map[string]string{"Content-Type": "text/html"}}))
+}
+
+func TestHTTPFetcher_NotModifiedCompressed(t *testing.T) {
+	// Servers may keep the Content-Encoding of the page on 304 responses,
+	// which have no body to decompress
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Encoding", "gzip")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`<html><head><title>Page</title></head></html>`))
+		gz.Close()
+	}))
+	defer server.Close()
+
+	for _, options := range []HTTPFetcherOptions{{}, {HeaderProfile: ChromeHeaderProfile}} {
+		fetcher := NewHTTPFetcher(options)
+		response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+		require.NoError(t, err)
+		require.Equal(t, "Page", response.Metadata.Title)
+
+		response, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL, Headers: ConditionalHeaders(response)})
+		require.NoError(t, err)
+		require.True(t, response.NotModified())
+	}
+}
+
+func TestHTTPFetcher_Timeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if r.URL.Path == "/slow-body" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{
+		Timeouts: Timeouts{ResponseHeader: 100 * time.Millisecond},
+	})
+	_, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/slow-headers"})
+	require.ErrorContains(t, err, "timeout awaiting response headers")
+	var requestErr *errors.RequestError
+	require.True(t, errors.As(err, &requestErr), "got %v", err)
+	require.Equal(t, errors.TimeoutHeaders, requestErr.TimeoutPhase())
+
+	// A slow body is bounded by the total timeout only
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/slow-body"})
+	require.NoError(t, err)
+
+	// The timeout of the request takes precedence
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/slow-body", Timeout: 100})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.True(t, errors.As(err, &requestErr), "got %v", err)
+	require.Equal(t, errors.TimeoutBody, requestErr.TimeoutPhase())
+	require.Equal(t, http.StatusOK, requestErr.StatusCode())
+
+	// Timeouts are clipped against the context deadline
+	fetcher = NewHTTPFetcher(HTTPFetcherOptions{Timeouts: Timeouts{Total: time.Minute}})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = fetcher.Fetch(ctx, &Request{URL: server.URL + "/slow-body"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHTTPFetcher_PartialBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Large</title></head><body><p>First part</p>`))
+		w.(http.Flusher).Flush()
+		if r.URL.Path == "/slow" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.Write([]byte(`<p>` + strings.Repeat("x", 2048) + `</p></body></html>`))
+	}))
+	defer server.Close()
+
+	for _, headerProfile := range []HeaderProfile{nil, ChromeHeaderProfile} {
+		fetcher := NewHTTPFetcher(HTTPFetcherOptions{PartialBodies: true, HeaderProfile: headerProfile})
+		response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/slow", Timeout: 100})
+		require.NoError(t, err)
+		require.True(t, response.Truncated)
+		require.Contains(t, response.HTML, "First part")
+		require.Equal(t, "Large", response.Metadata.Title)
+
+		// Bodies exceeding the size limit are truncated to it
+		fetcher = NewHTTPFetcher(HTTPFetcherOptions{PartialBodies: true, HeaderProfile: headerProfile, MaxBodySize: 1024})
+		response, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+		require.NoError(t, err)
+		require.True(t, response.Truncated)
+		require.Len(t, response.HTML, 1024)
+	}
+
+	// Complete bodies are not flagged
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{PartialBodies: true})
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+	require.NoError(t, err)
+	require.False(t, response.Truncated)
+}
+
+func TestHTTPFetcher_RequestError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/error", http.StatusFound)
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": "overloaded"}` + strings.Repeat(" ", 2*errors.MaxBodySnippetSize)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	_, err := NewHTTPFetcher(HTTPFetcherOptions{}).Fetch(context.Background(), &Request{URL: server.URL + "/old"})
+	var requestErr *errors.RequestError
+	require.True(t, errors.As(err, &requestErr), "got %v", err)
+	require.Equal(t, "unexpected content type: application/json", requestErr.Error())
+	require.Equal(t, http.StatusServiceUnavailable, requestErr.StatusCode())
+	require.Equal(t, server.URL+"/old", requestErr.RawURL())
+	require.Equal(t, server.URL+"/error", requestErr.FinalURL())
+	require.Equal(t, "120", requestErr.Headers().Get("Retry-After"))
+	require.Equal(t, `{"error": "overloaded"}`, requestErr.BodySnippet())
+}
+
+func TestClient_RequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("upstream unavailable"))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(ClientOptions{BaseURL: server.URL}).Fetch(context.Background(), &Request{URL: "https://example.com"})
+	var requestErr *errors.RequestError
+	require.True(t, errors.As(err, &requestErr), "got %v", err)
+	require.Equal(t, "request failed with status 502: upstream unavailable", requestErr.Error())
+	require.Equal(t, http.StatusBadGateway, requestErr.StatusCode())
+	require.Equal(t, "upstream unavailable", requestErr.BodySnippet())
+}
+
+func TestBodySnippet(t *testing.T) {
+	body := []byte(strings.Repeat("a", errors.MaxBodySnippetSize-1) + "é")
+	require.Equal(t, strings.Repeat("a", errors.MaxBodySnippetSize-1), errors.BodySnippet(body))
+}
+
+func TestHTTPFetcher_BodySize(t *testing.T) {
+	page := "<html><body><p>" + strings.Repeat("x", 4096) + "</p></body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	// Pooled buffers are reused across fetches without leaking content
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{})
+	for i := 0; i < 3; i++ {
+		response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+		require.NoError(t, err)
+		require.Equal(t, page, response.HTML)
+	}
+
+	_, err := NewHTTPFetcher(HTTPFetcherOptions{MaxBodySize: 1024}).Fetch(context.Background(), &Request{URL: server.URL})
+	require.ErrorContains(t, err, "response size exceeds limit of 1024 bytes")
+}
+
+func TestHTTPFetcher_TransferSize(t *testing.T) {
+	page := "<html><body><p>" + strings.Repeat("x", 4096) + "</p></body></html>"
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(page))
+	gz.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(compressed.Bytes())
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	// Compressed pages are requested and decompressed by both transports
+	for _, options := range []HTTPFetcherOptions{{}, {HeaderProfile: ChromeHeaderProfile}} {
+		response, err := NewHTTPFetcher(options).Fetch(context.Background(), &Request{URL: server.URL})
+		require.NoError(t, err)
+		require.Equal(t, page, response.HTML)
+		require.Equal(t, int64(len(page)), response.BodySize)
+		require.Equal(t, int64(compressed.Len()), response.TransferSize)
+	}
+
+	response, err := NewHTTPFetcher(HTTPFetcherOptions{}).Fetch(context.Background(), &Request{
+		URL:     server.URL,
+		Headers: map[string]string{"Accept-Encoding": "identity"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(len(page)), response.BodySize)
+	require.Equal(t, int64(len(page)), response.TransferSize)
+}
+
+func BenchmarkHTTPFetcher_Fetch(b *testing.B) {
+	page := benchmarkPage()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{})
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessRequest(b *testing.B) {
+	page := benchmarkPage()
+	for _, formats := range [][]string{{"html"}, {"markdown"}, {"links"}} {
+		b.Run(formats[0], func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := ProcessRequest(&Request{URL: "https://example.com", Formats: formats}, page); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// benchmarkPage returns a page of about 100 KB.
+func benchmarkPage() string {
+	var b strings.Builder
+	b.WriteString(`<html><head><title>Benchmark</title><meta name="description" content="A page"></head><body><main>`)
+	for i := 0; i < 200; i++ {
+		b.WriteString(`<section><h2>Section</h2><p>Some <b>text</b> with a <a href="/page">link</a>. `)
+		b.WriteString(strings.Repeat("Lorem ipsum dolor sit amet. ", 12))
+		b.WriteString(`</p></section>`)
+	}
+	b.WriteString(`</main></body></html>`)
+	return b.String()
+}
+
+func TestHTTPFetcher_DialTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Secure</title></head></html>`))
+	}))
+	defer server.Close()
+	rootCAs := server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	var dialed []string
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{
+		DialTLS: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			tlsConn := tls.Client(conn, &tls.Config{RootCAs: rootCAs, ServerName: "example.com"})
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		},
+	})
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+	require.NoError(t, err)
+	require.Equal(t, "Secure", response.Metadata.Title)
+	require.Equal(t, []string{strings.TrimPrefix(server.URL, "https://")}, dialed)
+}
+
+func TestHTTPFetcher_Hosts(t *testing.T) {
+	var active, maxActive atomic.Int32
+	var protos sync.Map
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			current := maxActive.Load()
+			if n <= current || maxActive.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		protos.Store(r.Host, r.Proto)
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+	})
+	limited := httptest.NewServer(handler)
+	defer limited.Close()
+	unlimited := httptest.NewServer(handler)
+	defer unlimited.Close()
+	h2c := httptest.NewUnstartedServer(handler)
+	h2c.Config.Protocols = new(http.Protocols)
+	h2c.Config.Protocols.SetHTTP1(true)
+	h2c.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2c.Start()
+	defer h2c.Close()
+
+	limitedHost := strings.TrimPrefix(limited.URL, "http://")
+	h2cHost := strings.TrimPrefix(h2c.URL, "http://")
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{
+		Hosts: map[string]HostOptions{
+			limitedHost: {Timeout: 50 * time.Millisecond, MaxConnections: 1},
+			h2cHost:     {HTTPVersion: HTTP2},
+		},
+	})
+
+	// Timeouts only apply to the overridden host
+	_, err := fetcher.Fetch(context.Background(), &Request{URL: limited.URL + "/slow"})
+	require.Error(t, err)
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: unlimited.URL + "/slow"})
+	require.NoError(t, err)
+
+	// Concurrent requests are limited
+	maxActive.Store(0)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := fetcher.Fetch(context.Background(), &Request{URL: limited.URL})
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), maxActive.Load())
+
+	// The HTTP version is forced
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: h2c.URL})
+	require.NoError(t, err)
+	proto, _ := protos.Load(h2cHost)
+	require.Equal(t, "HTTP/2.0", proto)
+	proto, _ = protos.Load(strings.TrimPrefix(unlimited.URL, "http://"))
+	require.Equal(t, "HTTP/1.1", proto)
+}
+
+func TestHTTPFetcher_Healthy(t *testing.T) {
+	proxy := httptest.NewServer(http.NotFoundHandler())
+	defer proxy.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := listener.Addr().String()
+	listener.Close()
+
+	require.NoError(t, NewHTTPFetcher(HTTPFetcherOptions{}).Healthy(context.Background()))
+	require.NoError(t, NewHTTPFetcher(HTTPFetcherOptions{Proxy: proxy.URL}).Healthy(context.Background()))
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{
+		Proxy: proxy.URL,
+		Hosts: map[string]HostOptions{"example.com": {Proxy: "http://user:secret@" + closed}},
+	})
+	err = fetcher.Healthy(context.Background())
+	require.ErrorContains(t, err, "proxy "+closed+" is unreachable")
+	require.NotContains(t, err.Error(), "secret")
+
+	err = NewHTTPFetcher(HTTPFetcherOptions{Proxy: "ftp://proxy"}).Healthy(context.Background())
+	var badRequest *errors.BadRequest
+	require.True(t, errors.As(err, &badRequest), "got %v", err)
+}
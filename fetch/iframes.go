@@ -0,0 +1,91 @@
+package fetch
+
+import (
+	"context"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// MaxInlineIframes limits the number of iframes of a page inlined by
+// Request.InlineIframes.
+const MaxInlineIframes = 10
+
+// InlineIframes fetches the same-origin iframes of a page and replaces them
+// with the content of their body, wrapped in a div with a data-iframe-src
+// attribute. Iframes that fail to be fetched are left as is, as are those of
+// other origins and nested iframes. Bodies are limited to maxSize bytes.
+func InlineIframes(ctx context.Context, client *http.Client, pageURL, content string, headers map[string]string, maxSize int64) (string, error) {
+	if !strings.Contains(content, "<iframe") {
+		return content, nil
+	}
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	doc, err := web.NewDocument(content)
+	if err != nil {
+		return "", err
+	}
+	root := doc.GoqueryDocument()
+	inlined := 0
+	root.Find("iframe[src]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if inlined >= MaxInlineIframes {
+			return false
+		}
+		src, ok := resolveURL(page, strings.TrimSpace(s.AttrOr("src", "")))
+		if !ok {
+			return true
+		}
+		frameURL, _ := url.Parse(src)
+		if frameURL.Scheme != page.Scheme || !strings.EqualFold(frameURL.Host, page.Host) {
+			return true
+		}
+		body, err := fetchIframe(ctx, client, src, headers, maxSize)
+		if err != nil {
+			return true
+		}
+		frame, err := web.NewDocument(body)
+		if err != nil {
+			return true
+		}
+		frameBody, err := frame.GoqueryDocument().Find("body").Html()
+		if err != nil {
+			return true
+		}
+		inlined++
+		s.ReplaceWithHtml(`<div data-iframe-src="` + html.EscapeString(src) + `">` + frameBody + `</div>`)
+		return true
+	})
+	if inlined == 0 {
+		return content, nil
+	}
+	return root.Html()
+}
+
+// fetchIframe returns the HTML of an iframe.
+func fetchIframe(ctx context.Context, client *http.Client, rawURL string, headers map[string]string, maxSize int64) (string, error) {
+	resp, err := doRequest(ctx, client, http.MethodGet, rawURL, headers)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return "", errors.NewRequestErrorf("unexpected iframe response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type")).
+			WithRawURL(rawURL)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(body)) > maxSize {
+		return "", errors.NewRequestErrorf("iframe size exceeds limit of %d bytes", maxSize).WithRawURL(rawURL)
+	}
+	return string(body), nil
+}
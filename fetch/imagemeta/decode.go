@@ -0,0 +1,228 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+)
+
+// ErrUnknownFormat is returned when the image format is not recognized.
+var ErrUnknownFormat = errors.New("unknown image format")
+
+// ErrTruncated is returned when more data is needed to read the image header.
+var ErrTruncated = errors.New("truncated image header")
+
+// Decode reads the image format, dimensions and EXIF data from the leading
+// bytes of an image file. The full file is not required: the header is
+// usually within the first few kilobytes, though JPEG files may carry large
+// metadata segments before the frame header.
+func Decode(data []byte) (*Info, error) {
+	info := &Info{}
+	switch {
+	case bytes.HasPrefix(data, []byte("RIFF")) && len(data) >= 12 && string(data[8:12]) == "WEBP":
+		info.Format = "webp"
+		if err := decodeWebP(data, info); err != nil {
+			return nil, err
+		}
+	case bytes.HasPrefix(data, []byte("BM")):
+		info.Format = "bmp"
+		if len(data) < 26 {
+			return nil, ErrTruncated
+		}
+		info.Width = int(int32(binary.LittleEndian.Uint32(data[18:22])))
+		height := int(int32(binary.LittleEndian.Uint32(data[22:26])))
+		if height < 0 {
+			height = -height
+		}
+		info.Height = height
+	case isSVG(data):
+		info.Format = "svg"
+	default:
+		config, format, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			if errors.Is(err, image.ErrFormat) {
+				return nil, ErrUnknownFormat
+			}
+			return nil, ErrTruncated
+		}
+		info.Format = format
+		info.Width = config.Width
+		info.Height = config.Height
+		if format == "jpeg" {
+			info.EXIF = jpegEXIF(data)
+		}
+	}
+	return info, nil
+}
+
+// isSVG returns true if the root element of the document is an svg element,
+// skipping a leading byte order mark, XML declaration, comments and doctype.
+func isSVG(data []byte) bool {
+	head := strings.TrimPrefix(string(data[:min(len(data), 512)]), "\ufeff")
+	for {
+		head = strings.TrimLeft(head, " \t\r\n")
+		var end string
+		switch {
+		case hasPrefixFold(head, "<svg"):
+			return len(head) == 4 || strings.ContainsRune(" \t\r\n/>", rune(head[4]))
+		case strings.HasPrefix(head, "<?"):
+			end = "?>"
+		case strings.HasPrefix(head, "<!--"):
+			end = "-->"
+		case hasPrefixFold(head, "<!doctype"):
+			end = ">"
+			// Skip the internal subset, which may contain markup
+			if i := strings.IndexAny(head, "[>"); i >= 0 && head[i] == '[' {
+				end = "]>"
+			}
+		default:
+			return false
+		}
+		i := strings.Index(head, end)
+		if i < 0 {
+			return false
+		}
+		head = head[i+len(end):]
+	}
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func decodeWebP(data []byte, info *Info) error {
+	if len(data) < 30 {
+		return ErrTruncated
+	}
+	switch string(data[12:16]) {
+	case "VP8 ":
+		// Lossy: 14 bit dimensions following the frame start code
+		info.Width = int(binary.LittleEndian.Uint16(data[26:28]) & 0x3fff)
+		info.Height = int(binary.LittleEndian.Uint16(data[28:30]) & 0x3fff)
+	case "VP8L":
+		// Lossless: 14 bit dimensions packed after the signature byte
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		info.Width = int(bits&0x3fff) + 1
+		info.Height = int((bits>>14)&0x3fff) + 1
+	case "VP8X":
+		// Extended: 24 bit canvas dimensions
+		info.Width = int(uint32(data[24])|uint32(data[25])<<8|uint32(data[26])<<16) + 1
+		info.Height = int(uint32(data[27])|uint32(data[28])<<8|uint32(data[29])<<16) + 1
+	default:
+		return ErrUnknownFormat
+	}
+	return nil
+}
+
+// jpegEXIF finds the APP1 Exif segment in a JPEG file and parses it.
+func jpegEXIF(data []byte) *EXIF {
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		// The length includes its own two bytes
+		if marker == 0xDA || length < 2 || pos+2+length > len(data) {
+			return nil
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return parseEXIF(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return nil
+}
+
+// EXIF tags read from the image and Exif IFDs.
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagOrientation      = 0x0112
+	tagSoftware         = 0x0131
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagDateTimeOriginal = 0x9003
+)
+
+// parseEXIF parses the TIFF structure of an Exif segment.
+func parseEXIF(tiff []byte) *EXIF {
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+	exif := &EXIF{}
+	var readIFD func(offset uint32, depth int)
+	readIFD = func(offset uint32, depth int) {
+		if depth > 2 || int(offset)+2 > len(tiff) {
+			return
+		}
+		count := int(order.Uint16(tiff[offset:]))
+		for i := 0; i < count; i++ {
+			entry := int(offset) + 2 + i*12
+			if entry+12 > len(tiff) {
+				return
+			}
+			tag := order.Uint16(tiff[entry:])
+			typ := order.Uint16(tiff[entry+2:])
+			n := order.Uint32(tiff[entry+4:])
+			value := tiff[entry+8 : entry+12]
+			switch tag {
+			case tagMake:
+				exif.Make = exifString(tiff, order, typ, n, value)
+			case tagModel:
+				exif.Model = exifString(tiff, order, typ, n, value)
+			case tagSoftware:
+				exif.Software = exifString(tiff, order, typ, n, value)
+			case tagDateTime:
+				exif.DateTime = exifString(tiff, order, typ, n, value)
+			case tagDateTimeOriginal:
+				exif.DateTimeOriginal = exifString(tiff, order, typ, n, value)
+			case tagOrientation:
+				if typ == 3 {
+					exif.Orientation = int(order.Uint16(value))
+				}
+			case tagExifIFD:
+				readIFD(order.Uint32(value), depth+1)
+			}
+		}
+	}
+	readIFD(order.Uint32(tiff[4:]), 0)
+	if *exif == (EXIF{}) {
+		return nil
+	}
+	return exif
+}
+
+// exifString reads an ASCII value, stored inline when 4 bytes or shorter.
+func exifString(tiff []byte, order binary.ByteOrder, typ uint16, n uint32, value []byte) string {
+	if typ != 2 {
+		return ""
+	}
+	var raw []byte
+	if n <= 4 {
+		raw = value[:n]
+	} else {
+		offset := order.Uint32(value)
+		if uint64(offset)+uint64(n) > uint64(len(tiff)) {
+			return ""
+		}
+		raw = tiff[offset : offset+n]
+	}
+	return strings.TrimSpace(strings.TrimRight(string(raw), "\x00"))
+}
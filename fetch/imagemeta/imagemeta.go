@@ -0,0 +1,170 @@
+// Package imagemeta reads image dimensions, format, size and basic EXIF data
+// for image URLs. Only the start of each file is downloaded when the server
+// supports range requests, which makes it cheap to enrich the images found
+// on crawled pages.
+package imagemeta
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+const (
+	DefaultRangeSize   = 64 * 1024        // 64 KB
+	DefaultMaxBodySize = 10 * 1024 * 1024 // 10 MB
+	DefaultTimeout     = 30 * time.Second
+	DefaultConcurrency = 4
+)
+
+// EXIF contains basic camera and orientation information.
+type EXIF struct {
+	Make             string `json:"make,omitempty"`
+	Model            string `json:"model,omitempty"`
+	Software         string `json:"software,omitempty"`
+	DateTime         string `json:"date_time,omitempty"`
+	DateTimeOriginal string `json:"date_time_original,omitempty"`
+	Orientation      int    `json:"orientation,omitempty"`
+}
+
+// Info describes an image.
+type Info struct {
+	URL         string `json:"url,omitempty"`
+	Format      string `json:"format,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	Size        int64  `json:"size,omitempty"` // Total size in bytes, if known
+	EXIF        *EXIF  `json:"exif,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Options defines the options for the image metadata fetcher.
+type Options struct {
+	Client      *http.Client
+	Headers     map[string]string
+	RangeSize   int64 // Bytes requested up front
+	MaxBodySize int64 // Maximum bytes read when the header is not in range
+	Concurrency int   // Parallel requests made by FetchAll
+}
+
+// Fetcher reads image metadata from URLs.
+type Fetcher struct {
+	client      *http.Client
+	headers     map[string]string
+	rangeSize   int64
+	maxBodySize int64
+	concurrency int
+}
+
+// New creates a new image metadata fetcher.
+func New(options Options) *Fetcher {
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: DefaultTimeout}
+	}
+	if options.RangeSize == 0 {
+		options.RangeSize = DefaultRangeSize
+	}
+	if options.MaxBodySize == 0 {
+		options.MaxBodySize = DefaultMaxBodySize
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+	return &Fetcher{
+		client:      options.Client,
+		headers:     options.Headers,
+		rangeSize:   options.RangeSize,
+		maxBodySize: options.MaxBodySize,
+		concurrency: options.Concurrency,
+	}
+}
+
+// Fetch reads the metadata of the image at the given URL. A ranged request
+// for the first bytes of the file is tried first; if the header lies beyond
+// that range, more of the file is read up to the maximum body size.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Info, error) {
+	data, resource, err := f.get(ctx, rawURL, f.rangeSize)
+	if err != nil {
+		return nil, err
+	}
+	info, err := Decode(data)
+	if errors.Is(err, ErrTruncated) && int64(len(data)) >= f.rangeSize {
+		data, resource, err = f.get(ctx, rawURL, f.maxBodySize)
+		if err != nil {
+			return nil, err
+		}
+		info, err = Decode(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.URL = rawURL
+	info.ContentType = resource.ContentType
+	info.Size = resource.Size
+	return info, nil
+}
+
+// FetchAll reads the metadata of each image concurrently. The results are in
+// the same order as the URLs, with failures reported in Info.Error.
+func (f *Fetcher) FetchAll(ctx context.Context, urls []string) []*Info {
+	results := make([]*Info, len(urls))
+	sem := make(chan struct{}, f.concurrency)
+	var wg sync.WaitGroup
+	for i, rawURL := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			info, err := f.Fetch(ctx, rawURL)
+			if err != nil {
+				info = &Info{URL: rawURL, Error: err.Error()}
+			}
+			results[i] = info
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// FetchImages reads the metadata of images found on a page, as returned by
+// web.Document.Images. Relative image URLs are resolved against the page URL.
+func (f *Fetcher) FetchImages(ctx context.Context, pageURL string, images []*web.Link) []*Info {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	var urls []string
+	seen := map[string]bool{}
+	for _, image := range images {
+		u, err := url.Parse(strings.TrimSpace(image.URL))
+		if err != nil {
+			continue
+		}
+		u = base.ResolveReference(u)
+		if u.Scheme != "http" && u.Scheme != "https" {
+			continue
+		}
+		resolved := u.String()
+		if seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+		urls = append(urls, resolved)
+	}
+	return f.FetchAll(ctx, urls)
+}
+
+// get requests the first n bytes of the URL. Servers that ignore the Range
+// header send the whole file, but only n bytes are read.
+func (f *Fetcher) get(ctx context.Context, rawURL string, n int64) ([]byte, *fetch.ResourceInfo, error) {
+	return fetch.GetRange(ctx, f.client, rawURL, 0, n, f.headers)
+}
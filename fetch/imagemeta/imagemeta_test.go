@@ -0,0 +1,165 @@
+package imagemeta
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+// exifJPEG encodes a JPEG with an APP1 Exif segment inserted after SOI.
+func exifJPEG(t *testing.T, width, height int, padding int) []byte {
+	var img bytes.Buffer
+	require.NoError(t, jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, width, height)), nil))
+
+	var tiff bytes.Buffer
+	tiff.WriteString("II")
+	binary.Write(&tiff, binary.LittleEndian, uint16(42))
+	binary.Write(&tiff, binary.LittleEndian, uint32(8))
+	binary.Write(&tiff, binary.LittleEndian, uint16(2))
+	// Make: "Acme Cam" stored after the IFD
+	binary.Write(&tiff, binary.LittleEndian, []uint16{0x010F, 2})
+	binary.Write(&tiff, binary.LittleEndian, []uint32{9, 8 + 2 + 2*12 + 4})
+	// Orientation: 6
+	binary.Write(&tiff, binary.LittleEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.LittleEndian, uint32(1))
+	binary.Write(&tiff, binary.LittleEndian, []uint16{6, 0})
+	binary.Write(&tiff, binary.LittleEndian, uint32(0))
+	tiff.WriteString("Acme Cam\x00")
+	tiff.Write(make([]byte, padding))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(img.Bytes()[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(img.Bytes()[2:])
+	return out.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	info, err := Decode(encodePNG(t, 40, 30))
+	require.NoError(t, err)
+	require.Equal(t, "png", info.Format)
+	require.Equal(t, 40, info.Width)
+	require.Equal(t, 30, info.Height)
+
+	info, err = Decode(exifJPEG(t, 16, 8, 0))
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", info.Format)
+	require.Equal(t, 16, info.Width)
+	require.Equal(t, 8, info.Height)
+	require.Equal(t, &EXIF{Make: "Acme Cam", Orientation: 6}, info.EXIF)
+
+	// VP8X extended WebP header with a 300x200 canvas
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00\x2b\x01\x00\xc7\x00\x00")
+	info, err = Decode(webp)
+	require.NoError(t, err)
+	require.Equal(t, "webp", info.Format)
+	require.Equal(t, 300, info.Width)
+	require.Equal(t, 200, info.Height)
+
+	_, err = Decode([]byte("not an image"))
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestDecode_SVG(t *testing.T) {
+	documents := []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"></svg>`,
+		"\ufeff\n  <SVG></SVG>",
+		`<?xml version="1.0" encoding="UTF-8"?>
+<!-- Generator: Illustrator -->
+<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd" [
+	<!ENTITY ns "http://www.w3.org/2000/svg">
+]>
+<svg xmlns="&ns;"/>`,
+	}
+	for _, document := range documents {
+		info, err := Decode([]byte(document))
+		require.NoError(t, err, document)
+		require.Equal(t, "svg", info.Format, document)
+	}
+
+	// Documents with a non-svg root element are not svg images
+	documents = []string{
+		`<!DOCTYPE html><html><body><svg width="10" height="10"></svg></body></html>`,
+		`<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"><svg/></html>`,
+		`<svgfont/>`,
+		`not an image <svg></svg>`,
+	}
+	for _, document := range documents {
+		_, err := Decode([]byte(document))
+		require.ErrorIs(t, err, ErrUnknownFormat, document)
+	}
+}
+
+func TestJPEGEXIF_MalformedSegment(t *testing.T) {
+	// A zero-length APP5 segment, shorter than its own length field, is
+	// placed before the Exif segment
+	data := exifJPEG(t, 16, 8, 0)
+	malformed := append([]byte{}, data[:2]...)
+	malformed = append(malformed, 0xFF, 0xE5, 0x00, 0x00)
+	malformed = append(malformed, data[2:]...)
+	require.NotPanics(t, func() {
+		require.Nil(t, jpegEXIF(malformed))
+		Decode(malformed)
+	})
+	require.NotNil(t, jpegEXIF(data))
+}
+
+func TestFetcher_RangeRequests(t *testing.T) {
+	images := map[string][]byte{
+		"/small.png": encodePNG(t, 640, 480),
+		"/large.jpg": exifJPEG(t, 20, 10, 4096),
+	}
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		data := images[r.URL.Path]
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+	}))
+	defer server.Close()
+
+	fetcher := New(Options{RangeSize: 1024})
+	info, err := fetcher.Fetch(context.Background(), server.URL+"/small.png")
+	require.NoError(t, err)
+	require.Equal(t, 640, info.Width)
+	require.Equal(t, int64(len(images["/small.png"])), info.Size)
+	require.Equal(t, "image/png", info.ContentType)
+	require.Equal(t, int64(1), atomic.LoadInt64(&requests))
+
+	// The Exif segment pushes the frame header past the first range
+	info, err = fetcher.Fetch(context.Background(), server.URL+"/large.jpg")
+	require.NoError(t, err)
+	require.Equal(t, 20, info.Width)
+	require.Equal(t, int64(len(images["/large.jpg"])), info.Size)
+	require.Equal(t, "Acme Cam", info.EXIF.Make)
+
+	results := fetcher.FetchImages(context.Background(), server.URL, []*web.Link{
+		{URL: "/small.png"},
+		{URL: "/small.png"},
+		{URL: "/missing.gif"},
+	})
+	require.Len(t, results, 2)
+	require.Equal(t, "png", results[0].Format)
+	require.True(t, strings.HasSuffix(results[1].URL, "/missing.gif"))
+	require.NotEmpty(t, results[1].Error)
+}
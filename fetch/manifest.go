@@ -0,0 +1,56 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// DefaultMaxManifestSize limits the size of fetched web app manifests.
+const DefaultMaxManifestSize = 1024 * 1024 // 1 MB
+
+// FetchManifest fetches and parses the web app manifest at the given URL.
+func FetchManifest(ctx context.Context, client *http.Client, manifestURL string) (*web.Manifest, error) {
+	if client == nil {
+		client = DefaultHTTPClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/manifest+json, application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, errors.MaxBodySnippetSize))
+		return nil, errors.NewRequestErrorf("unexpected status code fetching manifest: %d", resp.StatusCode).
+			WithResponse(resp, snippet).
+			WithRawURL(manifestURL)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxManifestSize))
+	if err != nil {
+		return nil, err
+	}
+	return web.ParseManifest(body, resp.Request.URL.String())
+}
+
+// resolveURL resolves a link found on a page against the page URL, accepting
+// only HTTP(S) results.
+func resolveURL(pageURL *url.URL, href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || href == "" {
+		return "", false
+	}
+	u = pageURL.ResolveReference(u)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	return u.String(), true
+}
@@ -0,0 +1,71 @@
+package fetch
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxCapturedBodySize is the default size limit of captured bodies.
+const DefaultMaxCapturedBodySize = 1024 * 1024 // 1 MB
+
+// NetworkCapture configures the capture of the requests made by a page while
+// a browser fetcher renders it. The data behind many single page apps lives
+// in their API calls rather than in the DOM.
+type NetworkCapture struct {
+	// Types of the requests to capture, as reported by the browser. Defaults
+	// to xhr and fetch.
+	Types []string `json:"types,omitempty"`
+
+	// URLContains restricts the capture to requests whose URL contains one
+	// of the values, e.g. "/api/".
+	URLContains []string `json:"url_contains,omitempty"`
+
+	// MaxBodySize is the size limit of captured bodies in bytes. Larger
+	// bodies are truncated. Defaults to DefaultMaxCapturedBodySize.
+	MaxBodySize int `json:"max_body_size,omitempty"`
+}
+
+// Captures returns true if a request of the given type and URL should be
+// captured.
+func (c *NetworkCapture) Captures(resourceType, rawURL string) bool {
+	if c == nil {
+		return false
+	}
+	resourceType = strings.ToLower(resourceType)
+	if len(c.Types) == 0 {
+		if resourceType != "xhr" && resourceType != "fetch" {
+			return false
+		}
+	} else if !slices.ContainsFunc(c.Types, func(t string) bool { return strings.EqualFold(t, resourceType) }) {
+		return false
+	}
+	if len(c.URLContains) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(c.URLContains, func(value string) bool { return strings.Contains(rawURL, value) })
+}
+
+// CapturedRequest is a request made by a page while it was rendered.
+type CapturedRequest struct {
+	URL          string            `json:"url"`
+	Method       string            `json:"method"`
+	ResourceType string            `json:"resource_type,omitempty"`
+	RequestBody  string            `json:"request_body,omitempty"`
+	StatusCode   int               `json:"status_code,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"` // response headers
+	ContentType  string            `json:"content_type,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	Truncated    bool              `json:"truncated,omitempty"` // body exceeded the size limit
+}
+
+// IsJSON returns true if the response has a JSON content type.
+func (r *CapturedRequest) IsJSON() bool {
+	mediaType := MediaType(r.ContentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// DecodeJSON decodes the JSON response body into v.
+func (r *CapturedRequest) DecodeJSON(v any) error {
+	return json.Unmarshal([]byte(r.Body), v)
+}
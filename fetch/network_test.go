@@ -0,0 +1,31 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkCapture_Captures(t *testing.T) {
+	capture := &NetworkCapture{}
+	require.True(t, capture.Captures("XHR", "https://example.com/api/items"))
+	require.True(t, capture.Captures("fetch", "https://example.com/data.json"))
+	require.False(t, capture.Captures("image", "https://example.com/logo.png"))
+
+	capture = &NetworkCapture{Types: []string{"script", "fetch"}, URLContains: []string{"/api/", "graphql"}}
+	require.True(t, capture.Captures("fetch", "https://example.com/graphql"))
+	require.False(t, capture.Captures("fetch", "https://example.com/data.json"))
+	require.False(t, capture.Captures("xhr", "https://example.com/api/items"))
+
+	var none *NetworkCapture
+	require.False(t, none.Captures("xhr", "https://example.com/api/items"))
+}
+
+func TestCapturedRequest_JSON(t *testing.T) {
+	captured := &CapturedRequest{ContentType: "application/vnd.api+json; charset=utf-8", Body: `{"items": [1, 2]}`}
+	require.True(t, captured.IsJSON())
+	var body struct{ Items []int }
+	require.NoError(t, captured.DecodeJSON(&body))
+	require.Equal(t, []int{1, 2}, body.Items)
+	require.False(t, (&CapturedRequest{ContentType: "text/html"}).IsJSON())
+}
@@ -0,0 +1,85 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// DefaultMaxOEmbedSize limits the size of fetched oEmbed responses.
+const DefaultMaxOEmbedSize = 1024 * 1024 // 1 MB
+
+// OEmbedOptions defines the options for fetching oEmbed data.
+type OEmbedOptions struct {
+	Client    *http.Client
+	Format    string // "json" (default) or "xml"
+	MaxWidth  int    // Optional maximum embed width
+	MaxHeight int    // Optional maximum embed height
+}
+
+// FetchOEmbed fetches the embed data from an oEmbed endpoint URL, such as one
+// returned by web.Document.OEmbedLinks.
+func FetchOEmbed(ctx context.Context, endpoint string, options OEmbedOptions) (*web.OEmbed, error) {
+	client := options.Client
+	if client == nil {
+		client = DefaultHTTPClient
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	if options.MaxWidth > 0 {
+		query.Set("maxwidth", strconv.Itoa(options.MaxWidth))
+	}
+	if options.MaxHeight > 0 {
+		query.Set("maxheight", strconv.Itoa(options.MaxHeight))
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, errors.MaxBodySnippetSize))
+		return nil, errors.NewRequestErrorf("oembed request failed with status %d", resp.StatusCode).
+			WithResponse(resp, snippet).
+			WithRawURL(endpoint)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxOEmbedSize))
+	if err != nil {
+		return nil, err
+	}
+	return web.ParseOEmbed(body, options.Format)
+}
+
+// DiscoverOEmbed finds the oEmbed endpoint advertised by a page and fetches
+// its embed data. Relative endpoint URLs are resolved against the page URL.
+func DiscoverOEmbed(ctx context.Context, doc *web.Document, pageURL string, options OEmbedOptions) (*web.OEmbed, error) {
+	links := doc.OEmbedLinks()
+	if len(links) == 0 {
+		return nil, fmt.Errorf("no oembed endpoint found")
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, ok := resolveURL(base, links[0].URL)
+	if !ok {
+		return nil, fmt.Errorf("invalid oembed endpoint: %s", links[0].URL)
+	}
+	options.Format = links[0].Format
+	return FetchOEmbed(ctx, endpoint, options)
+}
@@ -0,0 +1,36 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverOEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "https://example.com/watch", r.URL.Query().Get("url"))
+		require.Equal(t, "320", r.URL.Query().Get("maxwidth"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type": "video", "title": "Watch", "provider_name": "Example"}`))
+	}))
+	defer server.Close()
+
+	doc, err := web.NewDocument(`<html><head>
+		<link rel="alternate" type="application/json+oembed" href="/oembed?url=https%3A%2F%2Fexample.com%2Fwatch">
+	</head></html>`)
+	require.NoError(t, err)
+
+	oembed, err := DiscoverOEmbed(context.Background(), doc, server.URL+"/watch", OEmbedOptions{MaxWidth: 320})
+	require.NoError(t, err)
+	require.Equal(t, "Watch", oembed.Title)
+	require.Equal(t, "Example", oembed.ProviderName)
+
+	empty, err := web.NewDocument(`<html></html>`)
+	require.NoError(t, err)
+	_, err = DiscoverOEmbed(context.Background(), empty, server.URL, OEmbedOptions{})
+	require.Error(t, err)
+}
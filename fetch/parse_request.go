@@ -3,6 +3,7 @@ package fetch
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -15,13 +16,20 @@ func ParsePostRequest(r *http.Request) (*Request, error) {
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
 		return nil, errors.NewBadRequest("invalid json body")
 	}
-	if requestBody.URL == "" {
-		return nil, errors.NewBadRequest("url is required")
+	if err := ValidateRequest(&requestBody, nil); err != nil {
+		return nil, err
 	}
 	return &requestBody, nil
 }
 
-// ParseGetRequest parses a fetch.Request from a GET request and its query parameters.
+// ParseGetRequest parses a fetch.Request from a GET request. The path is the
+// URL to fetch and query parameters hold the options, named as the JSON fields
+// of Request:
+//
+//   - Lists are comma separated or repeated: formats=html,links&formats=markdown
+//   - Booleans may be given without a value: mobile is the same as mobile=true
+//   - Headers are repeated header parameters: header=Accept-Language:fr
+//   - Structured options are JSON: actions=[{"type":"wait","duration":500}]
 func ParseGetRequest(r *http.Request) (*Request, error) {
 	path := strings.TrimPrefix(r.URL.Path, "/")
 	if path == "" {
@@ -35,37 +43,116 @@ func ParseGetRequest(r *http.Request) (*Request, error) {
 		targetURL = "https://" + path
 	}
 
-	query := r.URL.Query()
-
-	var timeout int
-	if timeoutStr := query.Get("timeout"); timeoutStr != "" {
-		if t, err := strconv.Atoi(timeoutStr); err == nil && t > 0 {
-			timeout = t
+	q := &queryParams{values: r.URL.Query()}
+	req := &Request{
+		URL:             targetURL,
+		Version:         q.get("version"),
+		OnlyMainContent: q.bool("only_main_content"),
+		IncludeTags:     q.list("include_tags"),
+		ExcludeTags:     q.list("exclude_tags"),
+		MaxAge:          q.int("max_age"),
+		Timeout:         q.int("timeout"),
+		WaitFor:         q.int("wait_for"),
+		Fetcher:         q.get("fetcher"),
+		Mobile:          q.bool("mobile"),
+		Prettify:        q.bool("prettify"),
+		Formats:         q.list("formats"),
+		Proxy:           q.get("proxy"),
+		ExcludeProfile:  q.get("exclude_profile"),
+		KeepTags:        q.list("keep_tags"),
+		IncludeNoscript: q.bool("include_noscript"),
+		InlineIframes:   q.bool("inline_iframes"),
+	}
+	for _, header := range q.values["header"] {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, errors.NewBadRequest("invalid header: expected name:value")
 		}
+		if req.Headers == nil {
+			req.Headers = map[string]string{}
+		}
+		req.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
 	}
-
-	var waitFor int
-	if waitForStr := query.Get("wait_for"); waitForStr != "" {
-		if w, err := strconv.Atoi(waitForStr); err == nil && w > 0 {
-			waitFor = w
+	fields := []struct {
+		name  string
+		value any
+	}{
+		{"actions", &req.Actions},
+		{"storage_state", &req.StorageState},
+		{"emulation", &req.Emulation},
+		{"block", &req.Block},
+		{"capture_network", &req.CaptureNetwork},
+		{"extract", &req.Extract},
+		{"extensions", &req.Extensions},
+	}
+	for _, field := range fields {
+		if value := q.get(field.name); value != "" {
+			if err := json.Unmarshal([]byte(value), field.value); err != nil {
+				return nil, errors.NewBadRequest("invalid %s: %s", field.name, err)
+			}
 		}
 	}
+	if q.err != nil {
+		return nil, q.err
+	}
+	if err := ValidateRequest(req, nil); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// queryParams reads typed values from query parameters, keeping the first
+// error.
+type queryParams struct {
+	values url.Values
+	err    error
+}
+
+func (q *queryParams) get(name string) string {
+	return q.values.Get(name)
+}
+
+func (q *queryParams) fail(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
 
-	var onlyMainContent bool
-	if value := query.Get("only_main_content"); value != "" {
-		onlyMainContent = value == "true"
+func (q *queryParams) list(name string) []string {
+	var values []string
+	for _, value := range q.values[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
 	}
+	return values
+}
 
-	var excludeTags []string
-	if value := query.Get("exclude_tags"); value != "" {
-		excludeTags = strings.Split(value, ",")
+func (q *queryParams) int(name string) int {
+	value := q.get(name)
+	if value == "" {
+		return 0
 	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		q.fail(errors.NewBadRequest("invalid %s: expected a non-negative integer", name))
+	}
+	return n
+}
 
-	return &Request{
-		URL:             targetURL,
-		Timeout:         timeout,
-		WaitFor:         waitFor,
-		OnlyMainContent: onlyMainContent,
-		ExcludeTags:     excludeTags,
-	}, nil
+func (q *queryParams) bool(name string) bool {
+	values, ok := q.values[name]
+	if !ok {
+		return false
+	}
+	if values[0] == "" {
+		return true
+	}
+	b, err := strconv.ParseBool(values[0])
+	if err != nil {
+		q.fail(errors.NewBadRequest("invalid %s: expected true or false", name))
+	}
+	return b
 }
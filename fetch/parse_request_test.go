@@ -0,0 +1,70 @@
+package fetch
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGetRequest(t *testing.T) {
+	query := url.Values{
+		"formats":           {"html,links", "markdown"},
+		"only_main_content": {""},
+		"include_tags":      {"article"},
+		"exclude_tags":      {"nav,footer"},
+		"max_age":           {"60000"},
+		"timeout":           {"5000"},
+		"wait_for":          {"100"},
+		"fetcher":           {"chrome"},
+		"mobile":            {"true"},
+		"prettify":          {"false"},
+		"header":            {"Accept-Language: fr", "X-Test:1"},
+		"proxy":             {"socks5://proxy:1080"},
+		"actions":           {`[{"type":"wait","duration":500}]`},
+		"emulation":         {`{"locale":"fr-FR"}`},
+		"block":             {`{"types":["image"]}`},
+	}
+	r := httptest.NewRequest("GET", "/example.com/page?"+query.Encode(), nil)
+	req, err := ParseGetRequest(r)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/page", req.URL)
+	require.Equal(t, []string{"html", "links", "markdown"}, req.Formats)
+	require.True(t, req.OnlyMainContent)
+	require.Equal(t, []string{"article"}, req.IncludeTags)
+	require.Equal(t, []string{"nav", "footer"}, req.ExcludeTags)
+	require.Equal(t, 60000, req.MaxAge)
+	require.Equal(t, 5000, req.Timeout)
+	require.Equal(t, 100, req.WaitFor)
+	require.Equal(t, "chrome", req.Fetcher)
+	require.True(t, req.Mobile)
+	require.False(t, req.Prettify)
+	require.Equal(t, map[string]string{"Accept-Language": "fr", "X-Test": "1"}, req.Headers)
+	require.Equal(t, "socks5://proxy:1080", req.Proxy)
+	require.Len(t, req.Actions, 1)
+	require.Equal(t, &WaitAction{BaseAction: BaseAction{Type: "wait"}, Duration: 500}, req.Actions[0].Action)
+	require.Equal(t, "fr-FR", req.Emulation.Locale)
+	require.Equal(t, []string{"image"}, req.Block.Types)
+
+	r = httptest.NewRequest("GET", "/https://example.com", nil)
+	req, err = ParseGetRequest(r)
+	require.NoError(t, err)
+	require.Equal(t, &Request{URL: "https://example.com"}, req)
+}
+
+func TestParseGetRequest_Invalid(t *testing.T) {
+	for query, message := range map[string]string{
+		"timeout=soon":   "invalid timeout",
+		"wait_for=-1":    "invalid wait_for",
+		"mobile=maybe":   "invalid mobile",
+		"header=nocolon": "invalid header",
+		"actions=[":      "invalid actions",
+	} {
+		r := httptest.NewRequest("GET", "/example.com?"+query, nil)
+		_, err := ParseGetRequest(r)
+		require.True(t, errors.IsBadRequest(err), query)
+		require.ErrorContains(t, err, message, query)
+	}
+}
@@ -0,0 +1,303 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+var objectPattern = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// object is an indirect object with an optional stream.
+type object struct {
+	value  any
+	stream []byte
+}
+
+// file is a loosely parsed PDF. Objects are located by scanning rather than
+// through the cross-reference table, which tolerates damaged files.
+type file struct {
+	objects   map[int]*object
+	trailer   dict
+	options   Options
+	remaining int64 // bytes left of the decoded size budget
+	err       error // first error decoding a stream
+}
+
+func parseFile(data []byte, options Options) *file {
+	f := &file{
+		objects:   map[int]*object{},
+		trailer:   dict{},
+		options:   options,
+		remaining: options.MaxTotalSize,
+	}
+	var objectStreams []*object
+	for _, loc := range objectPattern.FindAllSubmatchIndex(data, -1) {
+		num, err := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		if err != nil {
+			continue
+		}
+		l := newLexer(data)
+		l.pos = loc[1]
+		value, _ := l.value()
+		obj := &object{value: value}
+		if d, ok := value.(dict); ok {
+			obj.stream = readStream(l, d)
+			if d["Type"] == name("ObjStm") {
+				objectStreams = append(objectStreams, obj)
+			}
+			if d["Type"] == name("XRef") {
+				f.mergeTrailer(d)
+			}
+		}
+		f.objects[num] = obj
+	}
+	for _, obj := range objectStreams {
+		f.expandObjectStream(obj)
+	}
+	trailerKeyword := []byte("trailer")
+	for offset := 0; ; {
+		idx := bytes.Index(data[offset:], trailerKeyword)
+		if idx < 0 {
+			break
+		}
+		offset += idx + len(trailerKeyword)
+		l := newLexer(data)
+		l.pos = offset
+		if d, ok := l.value(); ok {
+			if d, ok := d.(dict); ok {
+				f.mergeTrailer(d)
+			}
+		}
+	}
+	return f
+}
+
+func (f *file) mergeTrailer(d dict) {
+	for _, key := range []string{"Root", "Info"} {
+		if v, ok := d[key]; ok {
+			f.trailer[key] = v
+		}
+	}
+}
+
+// readStream reads the stream following a dictionary, if any.
+func readStream(l *lexer, d dict) []byte {
+	save := l.pos
+	tok := l.next()
+	if tok.kind != tokKeyword || tok.text != "stream" {
+		l.pos = save
+		return nil
+	}
+	start := l.pos
+	if start < len(l.data) && l.data[start] == '\r' {
+		start++
+	}
+	if start < len(l.data) && l.data[start] == '\n' {
+		start++
+	}
+	if length, ok := d["Length"].(float64); ok {
+		end := start + int(length)
+		if end <= len(l.data) && bytes.HasPrefix(bytes.TrimLeft(l.data[end:], "\r\n \t"), []byte("endstream")) {
+			l.pos = end
+			return l.data[start:end]
+		}
+	}
+	end := bytes.Index(l.data[start:], []byte("endstream"))
+	if end < 0 {
+		return l.data[start:]
+	}
+	l.pos = start + end
+	return bytes.TrimRight(l.data[start:start+end], "\r\n")
+}
+
+// expandObjectStream registers the objects packed in a compressed object
+// stream. Objects defined directly in the file take precedence.
+func (f *file) expandObjectStream(obj *object) {
+	d := obj.value.(dict)
+	data := f.decode(d, obj.stream)
+	n, _ := f.resolve(d["N"]).(float64)
+	first, _ := f.resolve(d["First"]).(float64)
+	// Hostile streams may declare counts and offsets outside the stream
+	if data == nil || n < 0 || first < 0 || first > float64(len(data)) {
+		return
+	}
+	header := newLexer(data[:int(first)])
+	for i := 0; i < int(n); i++ {
+		numTok, offTok := header.next(), header.next()
+		if numTok.kind != tokNumber || offTok.kind != tokNumber {
+			return
+		}
+		num := int(numTok.num)
+		if _, exists := f.objects[num]; exists {
+			continue
+		}
+		if offTok.num < 0 || first+offTok.num > float64(len(data)) {
+			continue
+		}
+		l := newLexer(data)
+		l.pos = int(first) + int(offTok.num)
+		value, _ := l.value()
+		f.objects[num] = &object{value: value}
+	}
+}
+
+// resolve follows indirect references until a direct value is reached.
+func (f *file) resolve(v any) any {
+	for i := 0; i < 32; i++ {
+		r, ok := v.(ref)
+		if !ok {
+			return v
+		}
+		obj, ok := f.objects[r.num]
+		if !ok {
+			return nil
+		}
+		v = obj.value
+	}
+	return nil
+}
+
+func (f *file) dict(v any) dict {
+	d, _ := f.resolve(v).(dict)
+	return d
+}
+
+// streamData returns the decoded stream of the referenced object.
+func (f *file) streamData(v any) []byte {
+	r, ok := v.(ref)
+	if !ok {
+		return nil
+	}
+	obj, ok := f.objects[r.num]
+	if !ok || obj.stream == nil {
+		return nil
+	}
+	d, _ := obj.value.(dict)
+	return f.decode(d, obj.stream)
+}
+
+// decode applies the stream filters. Returns nil if any filter is
+// unsupported, which is the case for image encodings. Once a stream fails to
+// be decoded or exceeds the size limits, the error is kept and no more
+// streams are decoded.
+func (f *file) decode(d dict, data []byte) []byte {
+	if f.err != nil {
+		return nil
+	}
+	data, err := f.applyFilters(d, data)
+	if err == nil {
+		f.remaining -= int64(len(data))
+		if int64(len(data)) > f.options.MaxStreamSize || f.remaining < 0 {
+			err = ErrTooLarge
+		}
+	}
+	if err != nil {
+		f.err = err
+		return nil
+	}
+	return data
+}
+
+func (f *file) applyFilters(d dict, data []byte) ([]byte, error) {
+	var filters []any
+	switch v := f.resolve(d["Filter"]).(type) {
+	case name:
+		filters = []any{v}
+	case array:
+		filters = v
+	}
+	for _, filter := range filters {
+		switch f.resolve(filter) {
+		case name("FlateDecode"), name("Fl"):
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, nil
+			}
+			limit := min(f.options.MaxStreamSize, f.remaining)
+			out, err := io.ReadAll(io.LimitReader(r, limit+1))
+			switch {
+			case int64(len(out)) > limit:
+				return nil, ErrTooLarge
+			case errors.Is(err, io.ErrUnexpectedEOF):
+				// Keep whatever was inflated before a truncated tail, as
+				// files cut short by size limits are common
+			case err != nil:
+				return nil, fmt.Errorf("failed to decompress stream: %w", err)
+			}
+			data = out
+		case name("ASCIIHexDecode"), name("AHx"):
+			data = newLexer(append(bytes.TrimSuffix(bytes.TrimSpace(data), []byte(">")), '>')).hexString()
+		case name("ASCII85Decode"), name("A85"):
+			data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+			if i := bytes.Index(data, []byte("~>")); i >= 0 {
+				data = data[:i]
+			}
+			out := make([]byte, 4*len(data))
+			n, _, err := ascii85.Decode(out, data, true)
+			if err != nil {
+				return nil, nil
+			}
+			data = out[:n]
+		default:
+			return nil, nil
+		}
+	}
+	return data, nil
+}
+
+// pages returns the page dictionaries in document order, following the page
+// tree from the catalog.
+func (f *file) pages() []dict {
+	root := f.dict(f.trailer["Root"])
+	if root == nil {
+		for _, obj := range f.objects {
+			if d, ok := obj.value.(dict); ok && d["Type"] == name("Catalog") {
+				root = d
+				break
+			}
+		}
+	}
+	var pages []dict
+	seen := map[int]bool{}
+	var walk func(node any, inherited dict)
+	walk = func(node any, inherited dict) {
+		if r, ok := node.(ref); ok {
+			if seen[r.num] {
+				return
+			}
+			seen[r.num] = true
+		}
+		d := f.dict(node)
+		if d == nil {
+			return
+		}
+		if res, ok := d["Resources"]; ok {
+			inherited = dict{"Resources": res}
+		}
+		kids, isTree := f.resolve(d["Kids"]).(array)
+		if !isTree {
+			page := dict{}
+			for k, v := range d {
+				page[k] = v
+			}
+			if _, ok := page["Resources"]; !ok && inherited != nil {
+				page["Resources"] = inherited["Resources"]
+			}
+			pages = append(pages, page)
+			return
+		}
+		for _, kid := range kids {
+			walk(kid, inherited)
+		}
+	}
+	if root != nil {
+		walk(root["Pages"], nil)
+	}
+	return pages
+}
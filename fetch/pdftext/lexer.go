@@ -0,0 +1,325 @@
+package pdftext
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// PDF object types produced by the lexer.
+type (
+	name    string
+	keyword string
+	array   []any
+	dict    map[string]any
+	ref     struct{ num, gen int }
+)
+
+// pdfString holds the raw bytes of a literal or hexadecimal string.
+type pdfString []byte
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokName
+	tokKeyword
+	tokArrayStart
+	tokArrayEnd
+	tokDictStart
+	tokDictEnd
+)
+
+type token struct {
+	kind tokenKind
+	num  float64
+	text string
+	data []byte
+}
+
+// lexer tokenizes PDF object syntax and content streams.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func newLexer(data []byte) *lexer {
+	return &lexer{data: data}
+}
+
+func isWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isWhitespace(c) {
+			l.pos++
+		} else if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+func (l *lexer) regular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+func (l *lexer) next() token {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return token{kind: tokEOF}
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: tokString, data: l.literalString()}
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return token{kind: tokDictStart}
+		}
+		l.pos++
+		return token{kind: tokString, data: l.hexString()}
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return token{kind: tokDictEnd}
+		}
+		l.pos++
+		return l.next()
+	case c == '[':
+		l.pos++
+		return token{kind: tokArrayStart}
+	case c == ']':
+		l.pos++
+		return token{kind: tokArrayEnd}
+	case c == '{' || c == '}' || c == ')':
+		l.pos++
+		return l.next()
+	case c == '/':
+		l.pos++
+		return token{kind: tokName, text: decodeName(l.regular())}
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		word := l.regular()
+		if n, err := strconv.ParseFloat(word, 64); err == nil {
+			return token{kind: tokNumber, num: n, text: word}
+		}
+		return token{kind: tokKeyword, text: word}
+	default:
+		return token{kind: tokKeyword, text: l.regular()}
+	}
+}
+
+func (l *lexer) literalString() []byte {
+	var buf bytes.Buffer
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf.Bytes()
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return buf.Bytes()
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data); i++ {
+						d := l.data[l.pos]
+						if d < '0' || d > '7' {
+							break
+						}
+						v = v*8 + int(d-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return buf.Bytes()
+}
+
+func (l *lexer) hexString() []byte {
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if v, ok := hexValue(l.data[l.pos]); ok {
+			digits = append(digits, v)
+		}
+		l.pos++
+	}
+	l.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, 0)
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		out[i] = digits[2*i]<<4 | digits[2*i+1]
+	}
+	return out
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func decodeName(s string) string {
+	if !bytes.Contains([]byte(s), []byte("#")) {
+		return s
+	}
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && i+2 < len(s) {
+			hi, ok1 := hexValue(s[i+1])
+			lo, ok2 := hexValue(s[i+2])
+			if ok1 && ok2 {
+				out = append(out, hi<<4|lo)
+				i += 2
+				continue
+			}
+		}
+		out = append(out, s[i])
+	}
+	return string(out)
+}
+
+// value reads one complete object, returning nil at the end of input. Bare
+// operators are returned as keywords.
+func (l *lexer) value() (any, bool) {
+	return l.valueFrom(l.next())
+}
+
+func (l *lexer) valueFrom(tok token) (any, bool) {
+	switch tok.kind {
+	case tokEOF:
+		return nil, false
+	case tokNumber:
+		if num, gen, ok := l.refAhead(tok); ok {
+			return ref{num: num, gen: gen}, true
+		}
+		return tok.num, true
+	case tokString:
+		return pdfString(tok.data), true
+	case tokName:
+		return name(tok.text), true
+	case tokArrayStart:
+		var arr array
+		for {
+			t := l.next()
+			if t.kind == tokArrayEnd || t.kind == tokEOF {
+				return arr, true
+			}
+			v, _ := l.valueFrom(t)
+			arr = append(arr, v)
+		}
+	case tokDictStart:
+		d := dict{}
+		for {
+			t := l.next()
+			if t.kind == tokDictEnd || t.kind == tokEOF {
+				return d, true
+			}
+			if t.kind != tokName {
+				continue
+			}
+			v, ok := l.value()
+			if !ok {
+				return d, true
+			}
+			d[t.text] = v
+		}
+	case tokArrayEnd, tokDictEnd:
+		return l.value()
+	}
+	switch tok.text {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "null":
+		return nil, true
+	}
+	return keyword(tok.text), true
+}
+
+// refAhead checks whether an integer is the start of an indirect reference
+// ("12 0 R"), consuming the reference if so.
+func (l *lexer) refAhead(tok token) (int, int, bool) {
+	num, err := strconv.Atoi(tok.text)
+	if err != nil {
+		return 0, 0, false
+	}
+	save := l.pos
+	gen := l.next()
+	if gen.kind == tokNumber {
+		if g, err := strconv.Atoi(gen.text); err == nil {
+			if r := l.next(); r.kind == tokKeyword && r.text == "R" {
+				return num, g, true
+			}
+		}
+	}
+	l.pos = save
+	return 0, 0, false
+}
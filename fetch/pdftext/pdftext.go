@@ -0,0 +1,249 @@
+// Package pdftext extracts plain text and document metadata from PDF files.
+//
+// The extractor is dependency free and intentionally simple: it recovers the
+// text drawn by page content streams, decoding fonts through their ToUnicode
+// maps when present, but does not attempt layout analysis. It works well for
+// the text-based PDFs common on documentation and government sites. Scanned
+// documents contain no text and yield an empty result.
+//
+// Register a Handler with fetch.HTTPFetcher to fetch PDFs like HTML pages:
+//
+//	fetcher := fetch.NewHTTPFetcher(fetch.HTTPFetcherOptions{
+//		ContentHandlers: map[string]fetch.ContentHandler{
+//			"application/pdf": pdftext.NewHandler(),
+//		},
+//	})
+package pdftext
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/deepnoodle-ai/web/fetch"
+)
+
+// ErrNotPDF is returned when the input does not look like a PDF file.
+var ErrNotPDF = errors.New("not a pdf file")
+
+// ErrTooLarge is returned when the streams of a PDF decompress to more than
+// the limits of the extraction. See Options.
+var ErrTooLarge = errors.New("pdf streams exceed the size limit")
+
+// Default limits of the extraction.
+const (
+	DefaultMaxStreamSize = 32 * 1024 * 1024  // 32 MB
+	DefaultMaxTotalSize  = 128 * 1024 * 1024 // 128 MB
+)
+
+// Options limit the extraction, since compressed streams of a small file may
+// decompress to gigabytes.
+type Options struct {
+	// MaxStreamSize is the maximum size of a decoded stream. Defaults to
+	// DefaultMaxStreamSize.
+	MaxStreamSize int64
+
+	// MaxTotalSize is the maximum size of all the decoded streams of a file.
+	// Defaults to DefaultMaxTotalSize.
+	MaxTotalSize int64
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxStreamSize <= 0 {
+		o.MaxStreamSize = DefaultMaxStreamSize
+	}
+	if o.MaxTotalSize <= 0 {
+		o.MaxTotalSize = DefaultMaxTotalSize
+	}
+	return o
+}
+
+// Metadata contains the document information dictionary of a PDF.
+type Metadata struct {
+	Title        string    `json:"title,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	Subject      string    `json:"subject,omitempty"`
+	Keywords     string    `json:"keywords,omitempty"`
+	Creator      string    `json:"creator,omitempty"`
+	Producer     string    `json:"producer,omitempty"`
+	CreationDate time.Time `json:"creation_date,omitzero"`
+	ModDate      time.Time `json:"mod_date,omitzero"`
+	PageCount    int       `json:"page_count"`
+}
+
+// Document is the text and metadata extracted from a PDF.
+type Document struct {
+	Text     string   `json:"text"`
+	Pages    []string `json:"pages"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// Extract the text and metadata from the given PDF file contents, with the
+// default Options.
+func Extract(data []byte) (*Document, error) {
+	return ExtractWithOptions(data, Options{})
+}
+
+// ExtractWithOptions extracts the text and metadata from the given PDF file
+// contents. Fails with ErrTooLarge when its streams exceed the limits of the
+// options, and with the error of streams that fail to be decompressed.
+func ExtractWithOptions(data []byte, options Options) (*Document, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, ErrNotPDF
+	}
+	f := parseFile(data, options.withDefaults())
+	if f.err != nil {
+		return nil, f.err
+	}
+	doc := &Document{Metadata: f.metadata()}
+	for _, page := range f.pages() {
+		doc.Pages = append(doc.Pages, f.pageText(page))
+		if f.err != nil {
+			return nil, f.err
+		}
+	}
+	doc.Metadata.PageCount = len(doc.Pages)
+	var nonEmpty []string
+	for _, text := range doc.Pages {
+		if text != "" {
+			nonEmpty = append(nonEmpty, text)
+		}
+	}
+	doc.Text = strings.Join(nonEmpty, "\n\n")
+	return doc, nil
+}
+
+func (f *file) pageText(page dict) string {
+	fonts := map[string]*cmap{}
+	resources := f.dict(page["Resources"])
+	for fontName, fontRef := range f.dict(resources["Font"]) {
+		font := f.dict(fontRef)
+		if data := f.streamData(font["ToUnicode"]); data != nil {
+			fonts[fontName] = parseCMap(data)
+		}
+	}
+	var content bytes.Buffer
+	switch contents := page["Contents"].(type) {
+	case ref:
+		if arr, ok := f.resolve(contents).(array); ok {
+			for _, item := range arr {
+				content.Write(f.streamData(item))
+				content.WriteByte('\n')
+			}
+		} else {
+			content.Write(f.streamData(contents))
+		}
+	case array:
+		for _, item := range contents {
+			content.Write(f.streamData(item))
+			content.WriteByte('\n')
+		}
+	}
+	return pageText(content.Bytes(), fonts)
+}
+
+func (f *file) metadata() Metadata {
+	info := f.dict(f.trailer["Info"])
+	text := func(key string) string {
+		s, _ := f.resolve(info[key]).(pdfString)
+		return strings.TrimSpace(decodeTextString(s))
+	}
+	return Metadata{
+		Title:        text("Title"),
+		Author:       text("Author"),
+		Subject:      text("Subject"),
+		Keywords:     text("Keywords"),
+		Creator:      text("Creator"),
+		Producer:     text("Producer"),
+		CreationDate: parseDate(text("CreationDate")),
+		ModDate:      parseDate(text("ModDate")),
+	}
+}
+
+var datePattern = regexp.MustCompile(`^D?:?(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?([Zz+\-])?(\d{2})?'?(\d{2})?'?`)
+
+// parseDate parses a PDF date string such as "D:20240131120000+01'00'".
+func parseDate(s string) time.Time {
+	m := datePattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}
+	}
+	layout := "2006"
+	value := m[1]
+	for i, part := range []string{"01", "02", "15", "04", "05"} {
+		if m[i+2] == "" {
+			break
+		}
+		layout += part
+		value += m[i+2]
+	}
+	loc := time.UTC
+	if (m[7] == "+" || m[7] == "-") && m[8] != "" {
+		offset, _ := time.Parse("1504", m[8]+strings.Repeat("0", 2-len(m[9]))+m[9])
+		seconds := offset.Hour()*3600 + offset.Minute()*60
+		if m[7] == "-" {
+			seconds = -seconds
+		}
+		loc = time.FixedZone("", seconds)
+	}
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// Handler is a fetch.ContentHandler for application/pdf responses. The
+// response carries the extracted text, with the PDF document information
+// mapped onto the page metadata.
+type Handler struct {
+	options Options
+}
+
+// NewHandler creates a new PDF content handler with the default Options.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// NewHandlerWithOptions creates a new PDF content handler with the given
+// extraction limits.
+func NewHandlerWithOptions(options Options) *Handler {
+	return &Handler{options: options}
+}
+
+// HandleContent implements the fetch.ContentHandler interface.
+func (h *Handler) HandleContent(ctx context.Context, request *fetch.Request, body []byte) (*fetch.Response, error) {
+	doc, err := ExtractWithOptions(body, h.options)
+	if err != nil {
+		return nil, err
+	}
+	// The fetcher fills in the URL, status code and headers of the response
+	response := &fetch.Response{
+		Text: doc.Text,
+		Metadata: fetch.Metadata{
+			Title:       doc.Metadata.Title,
+			Author:      doc.Metadata.Author,
+			Description: doc.Metadata.Subject,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+	for _, keyword := range strings.FieldsFunc(doc.Metadata.Keywords, func(r rune) bool {
+		return r == ',' || r == ';'
+	}) {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			response.Metadata.Keywords = append(response.Metadata.Keywords, keyword)
+		}
+	}
+	if !doc.Metadata.CreationDate.IsZero() {
+		response.Metadata.PublishedTime = doc.Metadata.CreationDate.Format(time.RFC3339)
+	}
+	for _, format := range request.Formats {
+		if format == "markdown" {
+			response.Markdown = doc.Text
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,208 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepnoodle-ai/web/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+// buildPDF assembles a minimal PDF from object bodies, numbered from 1.
+func buildPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, body := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R /Info 2 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func stream(dict string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func deflate(data string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(data))
+	w.Close()
+	return buf.Bytes()
+}
+
+func testPDF() []byte {
+	cmap := `/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar
+<0001> <0048>
+<0002> <0069>
+endbfchar
+1 beginbfrange
+<0010> <0012> <0061>
+endbfrange
+endcmap`
+	return buildPDF(
+		"<< /Type /Catalog /Pages 3 0 R >>",
+		"<< /Title (Annual Report) /Author <FEFF004A006F> /Subject (Budget) /Keywords (finance, budget) /CreationDate (D:20240131120000+01'00') >>",
+		"<< /Type /Pages /Kids [4 0 R 5 0 R] /Count 2 /Resources << /Font << /F1 6 0 R /F2 7 0 R >> >> >>",
+		"<< /Type /Page /Parent 3 0 R /Contents 8 0 R >>",
+		"<< /Type /Page /Parent 3 0 R /Contents [9 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /ToUnicode 10 0 R >>",
+		stream("", []byte("BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\) world) Tj 0 -14 Td [(Second) -300 (line)] TJ ET")),
+		stream("/Filter /FlateDecode", deflate("BT /F2 12 Tf <00010002> Tj T* <001000110012> Tj ET")),
+		stream("", []byte(cmap)),
+	)
+}
+
+func TestExtract(t *testing.T) {
+	doc, err := Extract(testPDF())
+	require.NoError(t, err)
+	require.Equal(t, []string{"Hello (PDF) world\nSecond line", "Hi\nabc"}, doc.Pages)
+	require.Equal(t, "Hello (PDF) world\nSecond line\n\nHi\nabc", doc.Text)
+	require.Equal(t, "Annual Report", doc.Metadata.Title)
+	require.Equal(t, "Jo", doc.Metadata.Author)
+	require.Equal(t, "Budget", doc.Metadata.Subject)
+	require.Equal(t, 2, doc.Metadata.PageCount)
+	require.True(t, doc.Metadata.CreationDate.Equal(time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)))
+}
+
+func TestExtract_NotPDF(t *testing.T) {
+	_, err := Extract([]byte("<html></html>"))
+	require.ErrorIs(t, err, ErrNotPDF)
+}
+
+func TestExtract_Limits(t *testing.T) {
+	content := "BT /F1 12 Tf (" + strings.Repeat("x", 2000) + ") Tj ET"
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 3 0 R >>",
+		"<< >>",
+		"<< /Type /Pages /Kids [4 0 R 5 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 3 0 R /Contents 6 0 R >>",
+		"<< /Type /Page /Parent 3 0 R /Contents 6 0 R >>",
+		stream("/Filter /FlateDecode", deflate(content)),
+	)
+	doc, err := Extract(data)
+	require.NoError(t, err)
+	require.Len(t, doc.Pages, 2)
+
+	// Streams inflating beyond the limits fail the extraction
+	_, err = ExtractWithOptions(data, Options{MaxStreamSize: 1024})
+	require.ErrorIs(t, err, ErrTooLarge)
+	_, err = ExtractWithOptions(data, Options{MaxTotalSize: 3000})
+	require.ErrorIs(t, err, ErrTooLarge)
+	_, err = NewHandlerWithOptions(Options{MaxStreamSize: 1024}).HandleContent(context.Background(), &fetch.Request{}, data)
+	require.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestExtract_CorruptStream(t *testing.T) {
+	page := func(compressed []byte) []byte {
+		return buildPDF(
+			"<< /Type /Catalog /Pages 3 0 R >>",
+			"<< >>",
+			"<< /Type /Pages /Kids [4 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 3 0 R /Contents 5 0 R >>",
+			stream("/Filter /FlateDecode", compressed),
+		)
+	}
+	var content strings.Builder
+	content.WriteString("BT /F1 12 Tf ")
+	for i := range 500 {
+		fmt.Fprintf(&content, "(Hello %d) Tj T* ", i*7919%1000)
+	}
+	content.WriteString("ET")
+	compressed := deflate(content.String())
+
+	// Truncated streams keep the text inflated so far
+	doc, err := Extract(page(compressed[:len(compressed)/2]))
+	require.NoError(t, err)
+	require.Contains(t, doc.Text, "Hello")
+
+	// A stream failing its checksum fails the extraction
+	corrupt := append([]byte{}, compressed...)
+	corrupt[len(corrupt)-1] ^= 0xFF
+	_, err = Extract(page(corrupt))
+	require.ErrorContains(t, err, "failed to decompress stream")
+}
+
+func TestExtract_MalformedObjectStream(t *testing.T) {
+	for _, objStm := range []string{
+		stream("/Type /ObjStm /N 1 /First -1", []byte("7 0 << /A 1 >>")),
+		stream("/Type /ObjStm /N -1 /First 4", []byte("7 0 << /A 1 >>")),
+		stream("/Type /ObjStm /N 1 /First 1e300", []byte("7 0 << /A 1 >>")),
+		stream("/Type /ObjStm /N 1 /First 5", []byte("7 -5 << /A 1 >>")),
+		stream("/Type /ObjStm /N 1 /First 6", []byte("7 999 << /A 1 >>")),
+	} {
+		data := buildPDF(
+			"<< /Type /Catalog /Pages 3 0 R >>",
+			"<< >>",
+			"<< /Type /Pages /Kids [4 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 3 0 R /Contents 5 0 R >>",
+			stream("", []byte("BT /F1 12 Tf (Hello) Tj ET")),
+			objStm,
+		)
+		doc, err := Extract(data)
+		require.NoError(t, err, objStm)
+		require.Equal(t, "Hello", doc.Text, objStm)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	require.Equal(t, time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), parseDate("D:20230501"))
+	require.Equal(t, time.Date(2023, 5, 1, 8, 30, 0, 0, time.UTC), parseDate("D:202305010830Z"))
+	require.True(t, parseDate("garbage").IsZero())
+}
+
+func TestHandler_HTTPFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(testPDF())
+	}))
+	defer server.Close()
+
+	fetcher := fetch.NewHTTPFetcher(fetch.HTTPFetcherOptions{
+		ContentHandlers: map[string]fetch.ContentHandler{
+			"application/pdf": NewHandler(),
+		},
+	})
+	response, err := fetcher.Fetch(context.Background(), &fetch.Request{URL: server.URL})
+	require.NoError(t, err)
+	require.Equal(t, 200, response.StatusCode)
+	require.Contains(t, response.Text, "Hello (PDF) world")
+	require.Equal(t, "Annual Report", response.Metadata.Title)
+	require.Equal(t, []string{"finance", "budget"}, response.Metadata.Keywords)
+	require.Equal(t, "application/pdf", response.Headers["Content-Type"])
+
+	// The status and final URL are those of the HTTP response
+	mux := http.NewServeMux()
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/files/report.pdf", http.StatusFound)
+	})
+	mux.HandleFunc("/files/report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.WriteHeader(http.StatusNonAuthoritativeInfo)
+		w.Write(testPDF())
+	})
+	redirecting := httptest.NewServer(mux)
+	defer redirecting.Close()
+	response, err = fetcher.Fetch(context.Background(), &fetch.Request{URL: redirecting.URL + "/report"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNonAuthoritativeInfo, response.StatusCode)
+	require.Equal(t, redirecting.URL+"/report", response.URL)
+	require.Equal(t, redirecting.URL+"/files/report.pdf", response.FinalURL)
+	require.Contains(t, response.Text, "Hello (PDF) world")
+
+	// Without a registered handler the content type is rejected
+	_, err = fetch.NewHTTPFetcher(fetch.HTTPFetcherOptions{}).
+		Fetch(context.Background(), &fetch.Request{URL: server.URL})
+	require.ErrorContains(t, err, "unexpected content type")
+}
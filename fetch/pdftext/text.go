@@ -0,0 +1,289 @@
+package pdftext
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+)
+
+// cmap maps character codes to Unicode text, as defined by a font's
+// ToUnicode stream.
+type cmap struct {
+	codeLen  int
+	mappings map[uint32]string
+}
+
+func parseCMap(data []byte) *cmap {
+	m := &cmap{codeLen: 1, mappings: map[uint32]string{}}
+	l := newLexer(data)
+	var operands []any
+	for {
+		v, ok := l.value()
+		if !ok {
+			break
+		}
+		op, isOp := v.(keyword)
+		if !isOp {
+			operands = append(operands, v)
+			continue
+		}
+		switch op {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if lo, ok := operands[0].(pdfString); ok && len(lo) > 0 {
+					m.codeLen = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					m.mappings[codeValue(src)] = decodeUTF16(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 {
+					continue
+				}
+				start, end := codeValue(lo), codeValue(hi)
+				if end < start || end-start > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					base := []rune(decodeUTF16(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for code := start; code <= end; code++ {
+						r := append([]rune{}, base...)
+						r[len(r)-1] += rune(code - start)
+						m.mappings[code] = string(r)
+					}
+				case array:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && start+uint32(j) <= end {
+							m.mappings[start+uint32(j)] = decodeUTF16(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return m
+}
+
+func codeValue(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func (m *cmap) decode(b []byte) string {
+	var sb strings.Builder
+	for i := 0; i+m.codeLen <= len(b); i += m.codeLen {
+		code := codeValue(b[i : i+m.codeLen])
+		if s, ok := m.mappings[code]; ok {
+			sb.WriteString(s)
+		} else if m.codeLen == 1 {
+			sb.WriteRune(rune(code))
+		}
+	}
+	return sb.String()
+}
+
+func decodeUTF16(b []byte) string {
+	codes := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		codes = append(codes, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(codes))
+}
+
+// decodeTextString decodes a PDF text string such as a document info entry,
+// which is either UTF-16BE with a byte order mark or PDFDocEncoding. The
+// latter is treated as Latin-1, which it matches for printable characters.
+func decodeTextString(b []byte) string {
+	if bytes.HasPrefix(b, []byte{0xFE, 0xFF}) {
+		return decodeUTF16(b[2:])
+	}
+	if bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}) {
+		return string(b[3:])
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// textWriter accumulates extracted text, inserting line breaks and spaces as
+// the content stream moves the text position.
+type textWriter struct {
+	sb strings.Builder
+}
+
+func (w *textWriter) last() byte {
+	s := w.sb.String()
+	if s == "" {
+		return '\n'
+	}
+	return s[len(s)-1]
+}
+
+func (w *textWriter) write(s string) {
+	w.sb.WriteString(s)
+}
+
+func (w *textWriter) space() {
+	if c := w.last(); c != ' ' && c != '\n' {
+		w.sb.WriteByte(' ')
+	}
+}
+
+func (w *textWriter) newline() {
+	if w.last() != '\n' {
+		w.sb.WriteByte('\n')
+	}
+}
+
+// pageText interprets a page content stream, returning the text shown by its
+// text operators in stream order.
+func pageText(content []byte, fonts map[string]*cmap) string {
+	var w textWriter
+	var current *cmap
+	var lastY float64
+	var haveY bool
+	show := func(v any) {
+		s, ok := v.(pdfString)
+		if !ok {
+			return
+		}
+		if current != nil {
+			w.write(current.decode(s))
+		} else {
+			w.write(decodeTextString(s))
+		}
+	}
+	l := newLexer(content)
+	var operands []any
+	for {
+		v, ok := l.value()
+		if !ok {
+			break
+		}
+		op, isOp := v.(keyword)
+		if !isOp {
+			operands = append(operands, v)
+			continue
+		}
+		switch op {
+		case "Tf":
+			if len(operands) > 0 {
+				if n, ok := operands[0].(name); ok {
+					current = fonts[string(n)]
+				}
+			}
+		case "Tj":
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "'":
+			w.newline()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "\"":
+			w.newline()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				items, _ := operands[len(operands)-1].(array)
+				for _, item := range items {
+					// Large negative adjustments are used in place of spaces
+					if n, ok := item.(float64); ok && n < -200 {
+						w.space()
+						continue
+					}
+					show(item)
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty, _ := operands[1].(float64); ty != 0 {
+					w.newline()
+				} else {
+					w.space()
+				}
+			}
+		case "T*":
+			w.newline()
+		case "Tm":
+			if len(operands) >= 6 {
+				y, _ := operands[5].(float64)
+				if haveY && y != lastY {
+					w.newline()
+				} else {
+					w.space()
+				}
+				lastY, haveY = y, true
+			}
+		case "ET":
+			w.space()
+		case "ID":
+			skipInlineImage(l)
+		}
+		operands = operands[:0]
+	}
+	return cleanText(w.sb.String())
+}
+
+// skipInlineImage advances past inline image data, which ends at "EI".
+func skipInlineImage(l *lexer) {
+	idx := bytes.Index(l.data[l.pos:], []byte("EI"))
+	for idx >= 0 {
+		end := l.pos + idx
+		before := end == 0 || isWhitespace(l.data[end-1])
+		after := end+2 >= len(l.data) || isWhitespace(l.data[end+2])
+		if before && after {
+			l.pos = end + 2
+			return
+		}
+		next := bytes.Index(l.data[end+2:], []byte("EI"))
+		if next < 0 {
+			break
+		}
+		idx = end + 2 + next - l.pos
+	}
+	l.pos = len(l.data)
+}
+
+// cleanText trims trailing spaces from lines and collapses runs of blank
+// lines.
+func cleanText(s string) string {
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
@@ -0,0 +1,124 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// ResourceInfo describes a resource from the headers of a response, so that
+// it can be probed without downloading it.
+type ResourceInfo struct {
+	URL          string    `json:"url"`
+	StatusCode   int       `json:"status_code"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Size         int64     `json:"size,omitempty"` // Size of the whole resource in bytes, if known
+	LastModified time.Time `json:"last_modified,omitzero"`
+	ETag         string    `json:"etag,omitempty"`
+	AcceptRanges bool      `json:"accept_ranges,omitempty"`
+}
+
+// MediaType returns the media type of the resource, without parameters.
+func (i *ResourceInfo) MediaType() string {
+	return MediaType(i.ContentType)
+}
+
+// Prober is implemented by fetchers that can probe resources without
+// downloading them, such as the HTTPFetcher.
+type Prober interface {
+	Head(ctx context.Context, rawURL string) (*ResourceInfo, error)
+}
+
+// Head requests the headers of the resource at the given URL with a HEAD
+// request. Responses of any status are returned, since some servers don't
+// support HEAD requests.
+func Head(ctx context.Context, client *http.Client, rawURL string, headers map[string]string) (*ResourceInfo, error) {
+	resp, err := doRequest(ctx, client, http.MethodHead, rawURL, headers)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return newResourceInfo(rawURL, resp), nil
+}
+
+// GetRange requests length bytes of the resource at the given URL, starting at
+// offset, with a ranged GET request. Servers that ignore the Range header send
+// the whole resource, of which only the requested bytes are read. Fewer bytes
+// are returned if the resource ends before the end of the range.
+func GetRange(ctx context.Context, client *http.Client, rawURL string, offset, length int64, headers map[string]string) ([]byte, *ResourceInfo, error) {
+	if offset < 0 || length <= 0 {
+		return nil, nil, fmt.Errorf("invalid range: offset %d, length %d", offset, length)
+	}
+	rangeHeaders := make(map[string]string, len(headers)+1)
+	for key, value := range headers {
+		rangeHeaders[key] = value
+	}
+	rangeHeaders["Range"] = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	resp, err := doRequest(ctx, client, http.MethodGet, rawURL, rangeHeaders)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+	case http.StatusPartialContent:
+	default:
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, errors.MaxBodySnippetSize))
+		return nil, nil, errors.NewRequestErrorf("unexpected status code: %d", resp.StatusCode).
+			WithResponse(resp, snippet).
+			WithRawURL(rawURL)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, length))
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, newResourceInfo(rawURL, resp), nil
+}
+
+func doRequest(ctx context.Context, client *http.Client, method, rawURL string, headers map[string]string) (*http.Response, error) {
+	if client == nil {
+		client = DefaultHTTPClient
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return client.Do(req)
+}
+
+func newResourceInfo(rawURL string, resp *http.Response) *ResourceInfo {
+	info := &ResourceInfo{
+		URL:          rawURL,
+		StatusCode:   resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ETag:         resp.Header.Get("ETag"),
+		AcceptRanges: strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes"),
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lastModified
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		// The size is given by the Content-Range header, e.g. "bytes 0-99/1234"
+		info.AcceptRanges = true
+		if _, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/"); ok {
+			if size, err := strconv.ParseInt(total, 10, 64); err == nil {
+				info.Size = size
+			}
+		}
+	} else if resp.ContentLength > 0 {
+		info.Size = resp.ContentLength
+	}
+	return info
+}
@@ -0,0 +1,65 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadAndGetRange(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/file.bin", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file.bin", modified, bytes.NewReader(content))
+	})
+	mux.HandleFunc("/no-ranges", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(content)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	ctx := context.Background()
+
+	info, err := Head(ctx, nil, server.URL+"/file.bin", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, info.StatusCode)
+	require.Equal(t, "application/octet-stream", info.MediaType())
+	require.Equal(t, int64(len(content)), info.Size)
+	require.Equal(t, modified, info.LastModified.UTC())
+	require.Equal(t, `"v1"`, info.ETag)
+	require.True(t, info.AcceptRanges)
+
+	data, info, err := GetRange(ctx, nil, server.URL+"/file.bin", 5, 4, nil)
+	require.NoError(t, err)
+	require.Equal(t, "5678", string(data))
+	require.Equal(t, http.StatusPartialContent, info.StatusCode)
+	require.Equal(t, int64(len(content)), info.Size)
+
+	// The range is read from full responses
+	data, info, err = GetRange(ctx, nil, server.URL+"/no-ranges", 5, 4, nil)
+	require.NoError(t, err)
+	require.Equal(t, "5678", string(data))
+	require.Equal(t, http.StatusOK, info.StatusCode)
+	require.False(t, info.AcceptRanges)
+
+	// Ranges past the end are rejected by the server
+	_, _, err = GetRange(ctx, nil, server.URL+"/file.bin", 100, 4, nil)
+	require.ErrorContains(t, err, "unexpected status code: 416")
+
+	// The fetcher applies its headers
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{Headers: map[string]string{"User-Agent": "test"}})
+	info, err = fetcher.Head(ctx, server.URL+"/no-ranges")
+	require.NoError(t, err)
+	require.Equal(t, "text/plain", info.ContentType)
+	data, _, err = fetcher.GetRange(ctx, server.URL+"/file.bin", 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, "012", string(data))
+}
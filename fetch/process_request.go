@@ -15,30 +15,18 @@ func ProcessRequest(request *Request, html string) (*Response, error) {
 	html = strings.TrimSpace(html)
 	if html == "" {
 		return &Response{
+			Version:    APIVersion,
 			URL:        request.URL,
 			StatusCode: 200,
 		}, nil
 	}
 
-	// Parse the HTML
-	doc, err := web.NewDocument(html)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse html: %w", err)
-	}
-	metadata := doc.Metadata()
-
-	// Render transformed HTML with options
-	renderedHTML, err := doc.Render(web.RenderOptions{
-		Prettify:    request.Prettify,
-		ExcludeTags: request.ExcludeTags,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to render html: %w", err)
-	}
-
 	// By default, return the HTML but not markdown
 	includeHTML := true
 	includeMarkdown := false
+	includeLinks := false
+	includeMetadata := false
+	minify := false
 
 	// Specified formats were requested
 	if len(request.Formats) > 0 {
@@ -49,38 +37,94 @@ func ProcessRequest(request *Request, html string) (*Response, error) {
 				includeMarkdown = true
 			case "html":
 				includeHTML = true
+			case "min_html":
+				includeHTML = true
+				minify = true
+			case "links":
+				includeLinks = true
+			case "metadata":
+				includeMetadata = true
 			}
 		}
 	}
 
+	// Links and metadata alone are extracted without building a DOM
+	if len(request.Formats) > 0 && !includeHTML && !includeMarkdown {
+		scanned, err := web.ScanHTML(strings.NewReader(html), web.ScanOptions{
+			MetadataOnly: includeMetadata && !includeLinks,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse html: %w", err)
+		}
+		links := scanned.Links
+		if len(links) == 0 {
+			links = nil
+		}
+		return &Response{
+			Version:    APIVersion,
+			URL:        request.URL,
+			StatusCode: 200,
+			Headers:    map[string]string{},
+			Metadata:   scanned.Metadata,
+			Links:      links,
+			Timestamp:  time.Now().UTC(),
+		}, nil
+	}
+
+	// Parse the HTML
+	doc, err := web.NewDocument(html)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+	metadata := doc.Metadata()
+
+	// Render transformed HTML with options. The parsed tree is reused for
+	// the markdown conversion and by Response.Document.
+	rendered, err := doc.RenderDocument(web.RenderOptions{
+		Prettify:        request.Prettify,
+		ExcludeTags:     request.ExcludeTags,
+		ExcludeProfile:  request.ExcludeProfile,
+		KeepTags:        request.KeepTags,
+		IncludeNoscript: request.IncludeNoscript,
+		Minify:          minify,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render html: %w", err)
+	}
+	renderedHTML := rendered.Raw()
+
 	// Generate markdown if requested
 	var markdownContent string
 	if includeMarkdown {
-		markdownContent, err = web.Markdown(renderedHTML)
+		markdownContent, err = rendered.Markdown()
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate markdown: %w", err)
 		}
 	}
 
 	// Decide whether to include the HTML
+	var document *web.Document
 	if !includeHTML {
 		renderedHTML = ""
+	} else if !request.Prettify {
+		document = rendered
 	}
 
-	// Massage link types
-	var links []*Link
-	for _, link := range doc.Links() {
-		links = append(links, &Link{URL: link.URL, Text: link.Text})
+	links := doc.Links()
+	if len(links) == 0 {
+		links = nil
 	}
 
 	return &Response{
+		Version:    APIVersion,
 		URL:        request.URL,
 		StatusCode: 200,
 		Headers:    map[string]string{},
 		HTML:       renderedHTML,
 		Markdown:   markdownContent,
-		Metadata:   Metadata(metadata),
+		Metadata:   metadata,
 		Links:      links,
 		Timestamp:  time.Now().UTC(),
+		document:   document,
 	}, nil
 }
@@ -0,0 +1,157 @@
+package fetch
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"golang.org/x/net/proxy"
+)
+
+// proxyKey is the context key of the proxy of a request.
+type proxyKey struct{}
+
+// withProxy returns a copy of the context that routes requests through the
+// given proxy, overriding the proxy of the transport.
+func withProxy(ctx context.Context, proxyURL *url.URL) context.Context {
+	return context.WithValue(ctx, proxyKey{}, proxyURL)
+}
+
+// parseProxyURL parses and validates a proxy URL.
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		// The URL may contain credentials, so it is not included
+		return nil, errors.NewBadRequest("invalid proxy url")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	}
+	return nil, errors.NewBadRequest("unsupported proxy scheme: %q", u.Scheme)
+}
+
+// proxyFunc returns a function that selects the proxy of a request: the
+// proxy of the request context, the given default proxy or the proxy of the
+// environment, in that order.
+func proxyFunc(defaultProxy string) func(*http.Request) (*url.URL, error) {
+	var fixed *url.URL
+	var fixedErr error
+	if defaultProxy != "" {
+		fixed, fixedErr = parseProxyURL(defaultProxy)
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if proxyURL, ok := req.Context().Value(proxyKey{}).(*url.URL); ok {
+			return proxyURL, nil
+		}
+		if fixedErr != nil {
+			return nil, fixedErr
+		}
+		if fixed != nil {
+			return fixed, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+}
+
+// proxyPorts are the default ports of proxies by scheme.
+var proxyPorts = map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}
+
+// checkProxy checks that a proxy URL is valid and that the proxy accepts
+// connections.
+func checkProxy(ctx context.Context, dialer *net.Dialer, rawURL string) error {
+	proxyURL, err := parseProxyURL(rawURL)
+	if err != nil {
+		return err
+	}
+	addr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		addr = net.JoinHostPort(proxyURL.Hostname(), proxyPorts[proxyURL.Scheme])
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		// The URL may contain credentials, so only the address is included
+		return errors.NewRequestErrorf("proxy %s is unreachable: %w", addr, err)
+	}
+	return conn.Close()
+}
+
+// dialProxy connects to the address through the proxy.
+func dialProxy(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string) (net.Conn, error) {
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(proxyURL, dialer)
+		if err != nil {
+			return nil, err
+		}
+		return d.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+	case "http", "https":
+		return dialConnect(ctx, dialer, proxyURL, addr)
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme: %q", proxyURL.Scheme)
+}
+
+// dialConnect opens a tunnel to the address through an HTTP proxy using the
+// CONNECT method.
+func dialConnect(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	fail := func(err error) (net.Conn, error) {
+		stop()
+		conn.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fail(err)
+		}
+		conn = tlsConn
+	}
+
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n", addr, addr)
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		fmt.Fprintf(w, "Proxy-Authorization: Basic %s\r\n", credentials)
+	}
+	w.WriteString("\r\n")
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	// The response to a CONNECT request has no body, so nothing past the
+	// headers is buffered by the reader
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return fail(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fail(fmt.Errorf("proxy refused connection: %s", resp.Status))
+	}
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	return conn, nil
+}
@@ -0,0 +1,243 @@
+package fetch
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPFetcher_Proxy(t *testing.T) {
+	// Forward proxies that answer requests themselves
+	newProxy := func(title string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, pass, ok := proxyAuth(r); !ok || user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusProxyAuthRequired)
+				return
+			}
+			require.Equal(t, "http://example.com/page", r.URL.String())
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><head><title>" + title + "</title></head></html>"))
+		}))
+	}
+	proxy := newProxy("Default")
+	defer proxy.Close()
+	override := newProxy("Override")
+	defer override.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{Proxy: withUser(proxy.URL)})
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: "http://example.com/page"})
+	require.NoError(t, err)
+	require.Equal(t, "Default", response.Metadata.Title)
+
+	response, err = fetcher.Fetch(context.Background(), &Request{
+		URL:   "http://example.com/page",
+		Proxy: withUser(override.URL),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Override", response.Metadata.Title)
+}
+
+func TestHTTPFetcher_ProxyErrors(t *testing.T) {
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{})
+	_, err := fetcher.Fetch(context.Background(), &Request{URL: "http://example.com", Proxy: "ftp://proxy:21"})
+	require.True(t, errors.IsBadRequest(err))
+
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: "http://example.com", Proxy: "not a url"})
+	require.True(t, errors.IsBadRequest(err))
+
+	fetcher = NewHTTPFetcher(HTTPFetcherOptions{Client: &http.Client{}})
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: "http://example.com", Proxy: "http://proxy:8080"})
+	require.True(t, errors.IsBadRequest(err))
+
+	fetcher = NewHTTPFetcher(HTTPFetcherOptions{Proxy: "ftp://proxy:21"})
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: "http://example.com"})
+	require.ErrorContains(t, err, "unsupported proxy scheme")
+}
+
+func TestHTTPFetcher_SOCKS5Proxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Tunneled</title></head></html>`))
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go serveSOCKS5(listener, "user", "pass")
+
+	for _, options := range []HTTPFetcherOptions{
+		{Proxy: "socks5://user:pass@" + listener.Addr().String()},
+		{Proxy: "socks5h://user:pass@" + listener.Addr().String(), HeaderProfile: FirefoxHeaderProfile},
+	} {
+		fetcher := NewHTTPFetcher(options)
+		response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+		require.NoError(t, err)
+		require.Equal(t, "Tunneled", response.Metadata.Title)
+	}
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{Proxy: "socks5://user:wrong@" + listener.Addr().String()})
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+	require.Error(t, err)
+}
+
+func TestHTTPFetcher_HeaderProfileConnectProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Tunneled</title></head></html>`))
+	}))
+	defer server.Close()
+
+	var tunneled string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := proxyAuth(r); r.Method != http.MethodConnect || !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		tunneled = r.Host
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		pipe(conn, target)
+	}))
+	defer proxy.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{
+		HeaderProfile: FirefoxHeaderProfile,
+		Proxy:         withUser(proxy.URL),
+	})
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+	require.NoError(t, err)
+	require.Equal(t, "Tunneled", response.Metadata.Title)
+	require.Equal(t, server.Listener.Addr().String(), tunneled)
+
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL, Proxy: proxy.URL})
+	require.ErrorContains(t, err, "407")
+}
+
+func withUser(proxyURL string) string {
+	return "http://user:pass@" + proxyURL[len("http://"):]
+}
+
+func proxyAuth(r *http.Request) (string, string, bool) {
+	return (&http.Request{Header: http.Header{"Authorization": r.Header["Proxy-Authorization"]}}).BasicAuth()
+}
+
+// pipe copies data between two connections until either is closed.
+func pipe(a, b net.Conn) {
+	defer a.Close()
+	defer b.Close()
+	done := make(chan struct{}, 2)
+	go func() { io.Copy(a, b); done <- struct{}{} }()
+	go func() { io.Copy(b, a); done <- struct{}{} }()
+	<-done
+}
+
+// serveSOCKS5 serves a minimal SOCKS5 proxy requiring username and password
+// authentication, supporting CONNECT to IPv4 and domain addresses.
+func serveSOCKS5(listener net.Listener, user, pass string) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			target, err := socks5Handshake(conn, user, pass)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			pipe(conn, target)
+		}()
+	}
+}
+
+func socks5Handshake(conn net.Conn, user, pass string) (net.Conn, error) {
+	read := func(n int) ([]byte, error) {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(conn, buf)
+		return buf, err
+	}
+	header, err := read(2)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := read(int(header[1])); err != nil {
+		return nil, err
+	}
+	conn.Write([]byte{5, 2}) // username/password
+	header, err = read(2)
+	if err != nil {
+		return nil, err
+	}
+	gotUser, err := read(int(header[1]))
+	if err != nil {
+		return nil, err
+	}
+	length, err := read(1)
+	if err != nil {
+		return nil, err
+	}
+	gotPass, err := read(int(length[0]))
+	if err != nil {
+		return nil, err
+	}
+	if string(gotUser) != user || string(gotPass) != pass {
+		conn.Write([]byte{1, 1})
+		return nil, io.EOF
+	}
+	conn.Write([]byte{1, 0})
+
+	request, err := read(4)
+	if err != nil {
+		return nil, err
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip, err := read(4)
+		if err != nil {
+			return nil, err
+		}
+		host = net.IP(ip).String()
+	case 3:
+		length, err := read(1)
+		if err != nil {
+			return nil, err
+		}
+		name, err := read(int(length[0]))
+		if err != nil {
+			return nil, err
+		}
+		host = string(name)
+	default:
+		return nil, io.EOF
+	}
+	port, err := read(2)
+	if err != nil {
+		return nil, err
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return nil, err
+	}
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	return target, nil
+}
@@ -0,0 +1,68 @@
+package fetch
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/deepnoodle-ai/web"
+)
+
+// RenderRule customizes the rendering of the pages of a domain and its
+// subdomains, e.g. to drop the cookie banner or the related posts widget of a
+// site without writing a parser.
+type RenderRule struct {
+	// Domain matched by the rule, e.g. "example.com" for example.com and its
+	// subdomains.
+	Domain string `json:"domain"`
+
+	// Profile is the exclude profile used when the request has none.
+	Profile string `json:"profile,omitempty"`
+
+	// Keep lists selectors that are not excluded, added to Request.KeepTags.
+	Keep []string `json:"keep,omitempty"`
+
+	// Drop lists selectors that are excluded, added to Request.ExcludeTags.
+	Drop []string `json:"drop,omitempty"`
+}
+
+// RenderRules are the render rules of a crawler or a server.
+type RenderRules []*RenderRule
+
+// Validate checks that the rules have a domain and known profiles.
+func (rules RenderRules) Validate() error {
+	for i, rule := range rules {
+		if strings.TrimSpace(rule.Domain) == "" {
+			return fmt.Errorf("render rule %d: domain is required", i)
+		}
+		if _, ok := web.ExcludeProfiles[rule.Profile]; rule.Profile != "" && !ok {
+			return fmt.Errorf("render rule %d: unknown exclude profile %q", i, rule.Profile)
+		}
+	}
+	return nil
+}
+
+// Apply adds the selectors of the rules matching the host of the request URL
+// to the request. Rules are applied in order, so that the profile of the
+// first matching rule is used. The request is modified in place.
+func (rules RenderRules) Apply(req *Request) {
+	if len(rules) == 0 {
+		return
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, rule := range rules {
+		domain := strings.ToLower(rule.Domain)
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+		if req.ExcludeProfile == "" {
+			req.ExcludeProfile = rule.Profile
+		}
+		req.KeepTags = append(req.KeepTags, rule.Keep...)
+		req.ExcludeTags = append(req.ExcludeTags, rule.Drop...)
+	}
+}
@@ -0,0 +1,35 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderRules(t *testing.T) {
+	rules := RenderRules{
+		{Domain: "Example.com", Profile: "article", Drop: []string{".cookie-banner"}},
+		{Domain: "blog.example.com", Profile: "aggressive", Keep: []string{"aside"}, Drop: []string{".related-posts"}},
+		{Domain: "other.com", Drop: []string{".ads"}},
+	}
+	require.NoError(t, rules.Validate())
+
+	req := &Request{URL: "https://blog.example.com/post", ExcludeTags: []string{"nav"}}
+	rules.Apply(req)
+	require.Equal(t, "article", req.ExcludeProfile)
+	require.Equal(t, []string{"aside"}, req.KeepTags)
+	require.Equal(t, []string{"nav", ".cookie-banner", ".related-posts"}, req.ExcludeTags)
+
+	// The profile of the request takes precedence
+	req = &Request{URL: "https://example.com", ExcludeProfile: "conservative"}
+	rules.Apply(req)
+	require.Equal(t, "conservative", req.ExcludeProfile)
+	require.Equal(t, []string{".cookie-banner"}, req.ExcludeTags)
+
+	req = &Request{URL: "https://notexample.com"}
+	rules.Apply(req)
+	require.Empty(t, req.ExcludeTags)
+
+	require.Error(t, RenderRules{{Domain: ""}}.Validate())
+	require.Error(t, RenderRules{{Domain: "example.com", Profile: "minimal"}}.Validate())
+}
@@ -0,0 +1,180 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// Backoff strategies of RetryOptions.
+const (
+	BackoffExponential = "exponential"
+	BackoffLinear      = "linear"
+	BackoffConstant    = "constant"
+)
+
+// Defaults of RetryOptions.
+const (
+	DefaultRetryDelay    = 500 * time.Millisecond
+	DefaultMaxRetryDelay = 30 * time.Second
+)
+
+// DefaultRetryStatusCodes are the response status codes retried by default.
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryOptions defines how requests failing with transient errors, i.e.
+// timeouts, connection resets and retryable status codes, are retried. The delay before
+// each retry follows the backoff strategy, unless the response has a
+// Retry-After header. All attempts are bounded by the total timeout of the
+// request. The last response is returned when attempts are exhausted.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of attempts of a request, including
+	// the first. Requests are not retried if less than 2.
+	MaxAttempts int
+
+	// Backoff is the strategy of the delays between attempts: exponential
+	// (the default) doubles the delay after each attempt, linear increases
+	// it by Delay and constant keeps it at Delay.
+	Backoff string
+
+	// Delay is the delay before the first retry. Defaults to
+	// DefaultRetryDelay.
+	Delay time.Duration
+
+	// MaxDelay caps the delay between attempts. Requests are not retried
+	// when their Retry-After header asks for a longer delay. Defaults to
+	// DefaultMaxRetryDelay.
+	MaxDelay time.Duration
+
+	// Jitter randomizes delays by up to this fraction (0-1) of their value,
+	// so that clients don't retry in lockstep.
+	Jitter float64
+
+	// StatusCodes are the response status codes retried. Defaults to
+	// DefaultRetryStatusCodes.
+	StatusCodes []int
+}
+
+// withDefaults returns the options with the defaults of unset values.
+func (o RetryOptions) withDefaults() RetryOptions {
+	if o.Backoff == "" {
+		o.Backoff = BackoffExponential
+	}
+	if o.Delay <= 0 {
+		o.Delay = DefaultRetryDelay
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = DefaultMaxRetryDelay
+	}
+	if o.StatusCodes == nil {
+		o.StatusCodes = DefaultRetryStatusCodes
+	}
+	o.Jitter = min(max(o.Jitter, 0), 1)
+	return o
+}
+
+// delay returns the backoff delay after the given attempt, starting at 1.
+func (o RetryOptions) delay(attempt int) time.Duration {
+	var delay time.Duration
+	switch o.Backoff {
+	case BackoffConstant:
+		delay = o.Delay
+	case BackoffLinear:
+		delay = o.Delay * time.Duration(attempt)
+	default:
+		delay = o.Delay << min(attempt-1, 30)
+	}
+	if delay <= 0 {
+		delay = o.MaxDelay
+	}
+	if o.Jitter > 0 {
+		delay += time.Duration(rand.Float64() * o.Jitter * float64(delay))
+	}
+	return min(delay, o.MaxDelay)
+}
+
+// transientError returns true if a request error may not recur: a timeout,
+// a connection reset or closed by the server, or a temporary DNS failure.
+// Errors such as invalid certificates, unknown hosts and invalid URLs are
+// not transient.
+func transientError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// doWithRetries sends a request, retrying it as defined by the options.
+func doWithRetries(ctx context.Context, client *http.Client, req *http.Request, options RetryOptions) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req.Clone(ctx))
+		if attempt >= options.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+		if err != nil && !transientError(err) {
+			return resp, err
+		}
+		delay := options.delay(attempt)
+		if err == nil {
+			if !slices.Contains(options.StatusCodes, resp.StatusCode) {
+				return resp, nil
+			}
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				if retryAfter > options.MaxDelay {
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, errors.MaxBodySnippetSize))
+			resp.Body.Close()
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err == nil {
+				err = ctx.Err()
+			}
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either a number
+// of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,156 @@
+package fetch
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPFetcher_Retry(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if attempts.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/limited":
+			attempts.Add(1)
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case "/missing":
+			attempts.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{Retry: RetryOptions{MaxAttempts: 3, Delay: time.Millisecond}})
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/flaky"})
+	require.NoError(t, err)
+	require.Equal(t, 200, response.StatusCode)
+	require.Equal(t, int32(3), attempts.Load())
+
+	// Retry-After delays longer than MaxDelay are not waited for
+	attempts.Store(0)
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/limited"})
+	require.Error(t, err)
+	require.Equal(t, int32(1), attempts.Load())
+
+	// Other status codes are not retried
+	attempts.Store(0)
+	response, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/missing"})
+	require.NoError(t, err)
+	require.Equal(t, 404, response.StatusCode)
+	require.Equal(t, int32(1), attempts.Load())
+
+	// Retries are disabled by default
+	attempts.Store(0)
+	_, err = NewHTTPFetcher(HTTPFetcherOptions{}).Fetch(context.Background(), &Request{URL: server.URL + "/flaky"})
+	require.Error(t, err)
+	require.Equal(t, int32(1), attempts.Load())
+}
+
+func TestRetryOptions_Delay(t *testing.T) {
+	options := RetryOptions{Delay: time.Second, MaxDelay: 5 * time.Second}.withDefaults()
+	require.Equal(t, time.Second, options.delay(1))
+	require.Equal(t, 2*time.Second, options.delay(2))
+	require.Equal(t, 4*time.Second, options.delay(3))
+	require.Equal(t, 5*time.Second, options.delay(4))
+	require.Equal(t, 5*time.Second, options.delay(100))
+
+	options.Backoff = BackoffLinear
+	require.Equal(t, 3*time.Second, options.delay(3))
+	options.Backoff = BackoffConstant
+	require.Equal(t, time.Second, options.delay(3))
+
+	options.Jitter = 0.5
+	for range 10 {
+		require.GreaterOrEqual(t, options.delay(1), time.Second)
+		require.LessOrEqual(t, options.delay(1), 1500*time.Millisecond)
+	}
+
+	// Jittered delays are still capped
+	options.Backoff = BackoffExponential
+	for range 10 {
+		require.Equal(t, 5*time.Second, options.delay(100))
+	}
+}
+
+func TestHTTPFetcher_RetryNetworkErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			// Close the connection without a response
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{Retry: RetryOptions{MaxAttempts: 3, Delay: time.Millisecond}})
+	response, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL})
+	require.NoError(t, err)
+	require.Equal(t, 200, response.StatusCode)
+	require.Equal(t, int32(3), attempts.Load())
+
+	// Certificate errors are not retried
+	var connections atomic.Int32
+	tlsServer := httptest.NewUnstartedServer(http.NotFoundHandler())
+	tlsServer.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	tlsServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: tlsServer.URL})
+	require.Error(t, err)
+	require.Equal(t, int32(1), connections.Load())
+}
+
+func TestTransientError(t *testing.T) {
+	require.True(t, transientError(&url.Error{Op: "Get", URL: "http://example.com", Err: io.EOF}))
+	require.True(t, transientError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}))
+	require.True(t, transientError(&net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}))
+	require.True(t, transientError(context.DeadlineExceeded))
+
+	require.False(t, transientError(&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}))
+	require.False(t, transientError(&url.Error{Op: "Get", URL: "http://example.com", Err: x509.UnknownAuthorityError{}}))
+	_, err := url.Parse("http://[::1")
+	require.False(t, transientError(err))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	delay, ok := parseRetryAfter("120")
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, delay)
+
+	delay, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	require.True(t, ok)
+	require.InDelta(t, time.Hour.Seconds(), delay.Seconds(), 2)
+
+	_, ok = parseRetryAfter("soon")
+	require.False(t, ok)
+}
@@ -0,0 +1,383 @@
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+//go:generate go run ../cmd/fetch-schema -dir schema
+
+// APIVersion is the version of the Request and Response JSON format. The
+// major version changes when fields are removed or change meaning.
+const APIVersion = "1.0"
+
+// actionSchemaTypes lists the concrete action types by their "type" value.
+// Keep in sync with Action.UnmarshalJSON.
+var actionSchemaTypes = map[string]reflect.Type{
+	"screenshot":       reflect.TypeOf(ScreenshotAction{}),
+	"pdf":              reflect.TypeOf(PDFAction{}),
+	"wait":             reflect.TypeOf(WaitAction{}),
+	"scroll_to_bottom": reflect.TypeOf(ScrollToBottomAction{}),
+	"capture_html":     reflect.TypeOf(CaptureHTMLAction{}),
+}
+
+// RequestSchema returns a JSON Schema (draft 2020-12) describing the Request
+// JSON format, generated from the Go types.
+func RequestSchema() map[string]any {
+	return generateSchema(reflect.TypeOf(Request{}))
+}
+
+// ResponseSchema returns a JSON Schema (draft 2020-12) describing the
+// Response JSON format, generated from the Go types.
+func ResponseSchema() map[string]any {
+	return generateSchema(reflect.TypeOf(Response{}))
+}
+
+// ValidateRequestJSON validates a JSON encoded request against the request
+// schema and checks that its version, if given, is supported. Returns an
+// *errors.BadRequest naming the offending field.
+func ValidateRequestJSON(data []byte) error {
+	return validateJSON(compiledSchemas().request, data)
+}
+
+// ValidateResponseJSON validates a JSON encoded response against the response
+// schema and checks that its version, if given, is supported.
+func ValidateResponseJSON(data []byte) error {
+	return validateJSON(compiledSchemas().response, data)
+}
+
+// compiledSchemas holds the schemas decoded into generic JSON values, which
+// is the form the validator works with.
+var compiledSchemas = sync.OnceValue(func() struct{ request, response map[string]any } {
+	normalize := func(schema map[string]any) map[string]any {
+		data, err := json.Marshal(schema)
+		if err != nil {
+			panic(err)
+		}
+		var value map[string]any
+		if err := json.Unmarshal(data, &value); err != nil {
+			panic(err)
+		}
+		return value
+	}
+	return struct{ request, response map[string]any }{
+		request:  normalize(RequestSchema()),
+		response: normalize(ResponseSchema()),
+	}
+})
+
+func validateJSON(schema map[string]any, data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.NewBadRequest("invalid json: %s", err)
+	}
+	v := &schemaValidator{defs: schema["$defs"].(map[string]any)}
+	if err := v.validate(schema, value, ""); err != nil {
+		return err
+	}
+	if object, ok := value.(map[string]any); ok {
+		if version, ok := object["version"].(string); ok && version != "" {
+			if major(version) != major(APIVersion) {
+				return errors.NewInvalidField("version", "unsupported version %q (supported: %s)", version, APIVersion)
+			}
+		}
+	}
+	return nil
+}
+
+func major(version string) string {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	return major
+}
+
+func generateSchema(t reflect.Type) map[string]any {
+	g := &schemaGenerator{defs: map[string]any{}}
+	schema := g.schema(t)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = t.Name()
+	schema["x-version"] = APIVersion
+	schema["$defs"] = g.defs
+	return schema
+}
+
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	actionType = reflect.TypeOf(Action{})
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case actionType:
+		return g.actionSchema()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.ref(t, func() map[string]any { return g.structSchema(t) })
+	}
+	return map[string]any{}
+}
+
+// ref registers a named definition, generating it on first use, and returns
+// a reference to it. Registering before generating allows recursive types.
+func (g *schemaGenerator) ref(t reflect.Type, generate func() map[string]any) map[string]any {
+	name := t.Name()
+	if _, ok := g.defs[name]; !ok {
+		g.defs[name] = map[string]any{}
+		g.defs[name] = generate()
+	}
+	return map[string]any{"$ref": "#/$defs/" + name}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	g.addFields(t, properties, &required)
+	sort.Strings(required)
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+		optional := strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero")
+		if !optional && field.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func (g *schemaGenerator) actionSchema() map[string]any {
+	return g.ref(actionType, func() map[string]any {
+		names := make([]string, 0, len(actionSchemaTypes))
+		for name := range actionSchemaTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var variants []any
+		for _, name := range names {
+			t := actionSchemaTypes[name]
+			variant := g.ref(t, func() map[string]any {
+				schema := g.structSchema(t)
+				schema["properties"].(map[string]any)["type"] = map[string]any{"const": name}
+				return schema
+			})
+			variants = append(variants, variant)
+		}
+		return map[string]any{
+			"oneOf":         variants,
+			"discriminator": map[string]any{"propertyName": "type"},
+		}
+	})
+}
+
+// schemaValidator validates generic JSON values against the subset of JSON
+// Schema produced by the generator.
+type schemaValidator struct {
+	defs map[string]any
+}
+
+func (v *schemaValidator) validate(schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			return fmt.Errorf("unknown schema reference %q", ref)
+		}
+		return v.validate(def, value, path)
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		return badField(path, "must be %v", c)
+	}
+	if variants, ok := schema["oneOf"].([]any); ok {
+		return v.validateOneOf(schema, variants, value, path)
+	}
+	switch schema["type"] {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return badField(path, "must be a string")
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return badField(path, "must be an RFC 3339 date-time")
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return badField(path, "must be a boolean")
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return badField(path, "must be an integer")
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return badField(path, "must be a number")
+		}
+	case "array":
+		items, ok := value.([]any)
+		if value == nil {
+			return nil
+		}
+		if !ok {
+			return badField(path, "must be an array")
+		}
+		itemSchema, _ := schema["items"].(map[string]any)
+		for i, item := range items {
+			if err := v.validate(itemSchema, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		if value == nil {
+			return nil
+		}
+		object, ok := value.(map[string]any)
+		if !ok {
+			return badField(path, "must be an object")
+		}
+		return v.validateObject(schema, object, path)
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateObject(schema map[string]any, object map[string]any, path string) error {
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := object[name.(string)]; !ok {
+			return badField(joinPath(path, name.(string)), "is required")
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		propertySchema, ok := properties[key].(map[string]any)
+		if !ok {
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return badField(joinPath(path, key), "is not a known field")
+				}
+				continue
+			case map[string]any:
+				propertySchema = additional
+			default:
+				continue
+			}
+		}
+		if err := v.validate(propertySchema, object[key], joinPath(path, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateOneOf(schema map[string]any, variants []any, value any, path string) error {
+	// With a discriminator, validate against the matching variant only so the
+	// error describes the actual problem.
+	if discriminator, ok := schema["discriminator"].(map[string]any); ok {
+		property, _ := discriminator["propertyName"].(string)
+		object, ok := value.(map[string]any)
+		if !ok {
+			return badField(path, "must be an object")
+		}
+		kind, ok := object[property].(string)
+		if !ok {
+			return badField(joinPath(path, property), "is required")
+		}
+		for _, variant := range variants {
+			resolved := v.resolve(variant.(map[string]any))
+			properties, _ := resolved["properties"].(map[string]any)
+			if p, ok := properties[property].(map[string]any); ok && p["const"] == kind {
+				return v.validate(resolved, value, path)
+			}
+		}
+		return badField(joinPath(path, property), "unknown value %q", kind)
+	}
+	for _, variant := range variants {
+		if v.validate(variant.(map[string]any), value, path) == nil {
+			return nil
+		}
+	}
+	return badField(path, "does not match any allowed schema")
+}
+
+func (v *schemaValidator) resolve(schema map[string]any) map[string]any {
+	if ref, ok := schema["$ref"].(string); ok {
+		if def, ok := v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any); ok {
+			return def
+		}
+	}
+	return schema
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func badField(path, message string, args ...any) error {
+	if path == "" {
+		path = "body"
+	}
+	return errors.NewInvalidField(path, message, args...)
+}
@@ -0,0 +1,72 @@
+package fetch
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaFilesUpToDate(t *testing.T) {
+	for name, schema := range map[string]map[string]any{
+		"schema/request.schema.json":  RequestSchema(),
+		"schema/response.schema.json": ResponseSchema(),
+	} {
+		data, err := json.MarshalIndent(schema, "", "  ")
+		require.NoError(t, err)
+		existing, err := os.ReadFile(name)
+		require.NoError(t, err)
+		require.Equal(t, string(existing), string(data)+"\n", "%s is stale, run go generate", name)
+	}
+}
+
+func TestValidateRequestJSON(t *testing.T) {
+	require.NoError(t, ValidateRequestJSON([]byte(`{
+		"version": "1.2",
+		"url": "https://example.com",
+		"formats": ["markdown"],
+		"headers": {"Accept-Language": "en"},
+		"actions": [{"type": "wait", "duration": 500}, {"type": "scroll_to_bottom", "max_iterations": 5}, {"type": "screenshot", "full_page": true}, {"type": "screenshot", "selector": "#pricing"}, {"type": "screenshot", "clip": {"x": 0, "y": 100, "width": 800, "height": 600}}],
+		"extensions": {"anything": [1, 2]}
+	}`)))
+
+	tests := []struct {
+		name    string
+		payload string
+		message string
+	}{
+		{"invalid json", `{`, "invalid json: unexpected end of JSON input"},
+		{"missing url", `{}`, "url: is required"},
+		{"wrong type", `{"url": "https://example.com", "timeout": "10s"}`, "timeout: must be an integer"},
+		{"unknown field", `{"url": "https://example.com", "format": ["html"]}`, "format: is not a known field"},
+		{"bad header", `{"url": "https://example.com", "headers": {"X-Count": 1}}`, "headers.X-Count: must be a string"},
+		{"unknown action", `{"url": "https://example.com", "actions": [{"type": "click"}]}`, `actions[0].type: unknown value "click"`},
+		{"bad action field", `{"url": "https://example.com", "actions": [{"type": "wait", "duration": 1.5}]}`, "actions[0].duration: must be an integer"},
+		{"bad clip", `{"url": "https://example.com", "actions": [{"type": "screenshot", "clip": {"x": 0, "y": 0, "width": 10}}]}`, "actions[0].clip.height: is required"},
+		{"unsupported version", `{"version": "2.0", "url": "https://example.com"}`, `version: unsupported version "2.0" (supported: 1.0)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRequestJSON([]byte(tt.payload))
+			var badRequest *errors.BadRequest
+			require.True(t, errors.As(err, &badRequest), "got %v", err)
+			require.Equal(t, tt.message, badRequest.Message)
+		})
+	}
+}
+
+func TestValidateResponseJSON(t *testing.T) {
+	response, err := ProcessRequest(&Request{URL: "https://example.com"},
+		`<html><head><title>Hi</title></head><body><a href="/a" rel="nofollow">A</a></body></html>`)
+	require.NoError(t, err)
+	require.Equal(t, APIVersion, response.Version)
+
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	require.NoError(t, ValidateResponseJSON(data))
+
+	err = ValidateResponseJSON([]byte(`{"url": "https://example.com", "headers": {}}`))
+	require.EqualError(t, err, "status_code: is required")
+}
@@ -0,0 +1,134 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ActionOutput is the output of an action that captures the page. Screenshots
+// and PDFs are base64 encoded.
+type ActionOutput struct {
+	Screenshot string `json:"screenshot,omitempty"`
+	PDF        string `json:"pdf,omitempty"`
+	HTML       string `json:"html,omitempty"`
+}
+
+// Statuses of ActionResult.
+const (
+	ActionSucceeded = "succeeded"
+	ActionSkipped   = "skipped" // the condition of the action didn't hold
+	ActionFailed    = "failed"
+)
+
+// ActionResult reports the outcome of an action, so that clients can tell
+// which step of a scripted fetch failed.
+type ActionResult struct {
+	Index    int    `json:"index"` // index of the action in the request
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	Status   string `json:"status"`             // succeeded, skipped or failed
+	Duration int    `json:"duration,omitempty"` // milliseconds
+	Artifact string `json:"artifact,omitempty"` // response field holding the output, e.g. "screenshot" or "outputs.feed"
+	Error    string `json:"error,omitempty"`
+}
+
+// ActionPage is a rendered page that actions run on. Browser fetchers
+// implement it to run the actions of a request with RunActions.
+type ActionPage interface {
+	// Exists returns true if an element of the page matches the selector.
+	Exists(ctx context.Context, selector string) (bool, error)
+
+	// Run runs an action on the page. Actions that capture the page return
+	// their output, other actions return nil.
+	Run(ctx context.Context, action TypedAction) (*ActionOutput, error)
+}
+
+// RunActions runs actions on a page in order, skipping those whose condition
+// doesn't hold. The outputs of named actions are added to Response.Outputs,
+// while those of unnamed actions set the Screenshot, PDF and HTML of the
+// response. The outcome of each action is added to Response.ActionResults.
+// Running stops at the first action that fails.
+func RunActions(ctx context.Context, page ActionPage, actions []Action, response *Response) error {
+	for i, action := range actions {
+		if action.Action == nil {
+			return fmt.Errorf("actions[%d]: missing action", i)
+		}
+		var base BaseAction
+		if b, ok := action.Action.(interface{ base() *BaseAction }); ok {
+			base = *b.base()
+		}
+		result := ActionResult{Index: i, Type: action.Action.GetType(), Name: base.Name}
+		err := runAction(ctx, page, action.Action, base, response, &result)
+		response.ActionResults = append(response.ActionResults, result)
+		if err != nil {
+			return fmt.Errorf("actions[%d] (%s): %w", i, result.Type, err)
+		}
+	}
+	return nil
+}
+
+// runAction runs one action and records its outcome in the result.
+func runAction(ctx context.Context, page ActionPage, action TypedAction, base BaseAction, response *Response, result *ActionResult) error {
+	started := time.Now()
+	defer func() { result.Duration = int(time.Since(started).Milliseconds()) }()
+	ok, err := conditionHolds(ctx, page, base.If)
+	if err != nil {
+		err = fmt.Errorf("failed to check condition: %w", err)
+		result.Status, result.Error = ActionFailed, err.Error()
+		return err
+	}
+	if !ok {
+		result.Status = ActionSkipped
+		return nil
+	}
+	output, err := page.Run(ctx, action)
+	if err != nil {
+		result.Status, result.Error = ActionFailed, err.Error()
+		return err
+	}
+	result.Status = ActionSucceeded
+	if output == nil {
+		return nil
+	}
+	if base.Name != "" {
+		if response.Outputs == nil {
+			response.Outputs = map[string]*ActionOutput{}
+		}
+		response.Outputs[base.Name] = output
+		result.Artifact = "outputs." + base.Name
+		return nil
+	}
+	if output.Screenshot != "" {
+		response.Screenshot = output.Screenshot
+		result.Artifact = "screenshot"
+	}
+	if output.PDF != "" {
+		response.PDF = output.PDF
+		result.Artifact = "pdf"
+	}
+	if output.HTML != "" {
+		response.HTML = output.HTML
+		result.Artifact = "html"
+	}
+	return nil
+}
+
+func conditionHolds(ctx context.Context, page ActionPage, condition *ActionCondition) (bool, error) {
+	if condition == nil {
+		return true, nil
+	}
+	if condition.SelectorExists != "" {
+		exists, err := page.Exists(ctx, condition.SelectorExists)
+		if err != nil || !exists {
+			return false, err
+		}
+	}
+	if condition.SelectorMissing != "" {
+		exists, err := page.Exists(ctx, condition.SelectorMissing)
+		if err != nil || exists {
+			return false, err
+		}
+	}
+	return true, nil
+}
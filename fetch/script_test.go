@@ -0,0 +1,92 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// scriptPage is an ActionPage with a fixed set of elements that records the
+// actions run.
+type scriptPage struct {
+	elements map[string]bool
+	ran      []string
+}
+
+func (p *scriptPage) Exists(ctx context.Context, selector string) (bool, error) {
+	return p.elements[selector], nil
+}
+
+func (p *scriptPage) Run(ctx context.Context, action TypedAction) (*ActionOutput, error) {
+	p.ran = append(p.ran, action.GetType())
+	switch action := action.(type) {
+	case *ScreenshotAction:
+		return &ActionOutput{Screenshot: fmt.Sprintf("shot%d", len(p.ran))}, nil
+	case *CaptureHTMLAction:
+		return &ActionOutput{HTML: fmt.Sprintf("<p>%d</p>", len(p.ran))}, nil
+	case *WaitAction:
+		if action.Selector != "" && !p.elements[action.Selector] {
+			return nil, fmt.Errorf("timed out waiting for %s", action.Selector)
+		}
+	}
+	return nil, nil
+}
+
+func TestRunActions(t *testing.T) {
+	var actions []Action
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"type": "wait", "duration": 100},
+		{"type": "screenshot", "name": "before"},
+		{"type": "wait", "selector": ".cookie-banner", "if": {"selector_exists": ".cookie-banner"}},
+		{"type": "scroll_to_bottom", "if": {"selector_missing": ".end-of-feed"}},
+		{"type": "capture_html", "name": "feed"},
+		{"type": "screenshot"}
+	]`), &actions))
+
+	page := &scriptPage{elements: map[string]bool{".end-of-feed": false}}
+	response := &Response{}
+	require.NoError(t, RunActions(context.Background(), page, actions, response))
+	require.Equal(t, []string{"wait", "screenshot", "scroll_to_bottom", "capture_html", "screenshot"}, page.ran)
+	require.Equal(t, map[string]*ActionOutput{
+		"before": {Screenshot: "shot2"},
+		"feed":   {HTML: "<p>4</p>"},
+	}, response.Outputs)
+	require.Equal(t, "shot5", response.Screenshot)
+	require.Empty(t, response.HTML)
+	for i := range response.ActionResults {
+		response.ActionResults[i].Duration = 0
+	}
+	require.Equal(t, []ActionResult{
+		{Index: 0, Type: "wait", Status: ActionSucceeded},
+		{Index: 1, Type: "screenshot", Name: "before", Status: ActionSucceeded, Artifact: "outputs.before"},
+		{Index: 2, Type: "wait", Status: ActionSkipped},
+		{Index: 3, Type: "scroll_to_bottom", Status: ActionSucceeded},
+		{Index: 4, Type: "capture_html", Name: "feed", Status: ActionSucceeded, Artifact: "outputs.feed"},
+		{Index: 5, Type: "screenshot", Status: ActionSucceeded, Artifact: "screenshot"},
+	}, response.ActionResults)
+
+	// Running stops at the first failure
+	page = &scriptPage{}
+	response = &Response{}
+	err := RunActions(context.Background(), page, []Action{
+		NewWaitAction(WaitActionOptions{Selector: "#results"}),
+		NewScreenshotAction(ScreenshotActionOptions{}).Named("results"),
+	}, response)
+	require.EqualError(t, err, "actions[0] (wait): timed out waiting for #results")
+	require.Equal(t, []string{"wait"}, page.ran)
+	require.Len(t, response.ActionResults, 1)
+	require.Equal(t, ActionFailed, response.ActionResults[0].Status)
+	require.Equal(t, "timed out waiting for #results", response.ActionResults[0].Error)
+}
+
+func TestAction_NamedWhen(t *testing.T) {
+	action := NewScreenshotAction(ScreenshotActionOptions{Selector: "#pricing"}).
+		Named("pricing").
+		When(ActionCondition{SelectorExists: "#pricing"})
+	data, err := json.Marshal(&action)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "screenshot", "name": "pricing", "if": {"selector_exists": "#pricing"}, "selector": "#pricing"}`, string(data))
+}
@@ -0,0 +1,115 @@
+package fetch
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/deepnoodle-ai/web"
+)
+
+// Security headers reported by SecurityAudit.
+const (
+	HeaderContentSecurityPolicy   = "Content-Security-Policy"
+	HeaderStrictTransportSecurity = "Strict-Transport-Security"
+	HeaderXFrameOptions           = "X-Frame-Options"
+	HeaderXContentTypeOptions     = "X-Content-Type-Options"
+	HeaderReferrerPolicy          = "Referrer-Policy"
+	HeaderPermissionsPolicy       = "Permissions-Policy"
+)
+
+// SecurityHeaders lists the security headers reported by SecurityAudit.
+var SecurityHeaders = []string{
+	HeaderContentSecurityPolicy,
+	HeaderStrictTransportSecurity,
+	HeaderXFrameOptions,
+	HeaderXContentTypeOptions,
+	HeaderReferrerPolicy,
+	HeaderPermissionsPolicy,
+}
+
+// SecurityAudit reports the security headers of a page and its mixed
+// content, i.e. the subresources it loads over http while served over https.
+type SecurityAudit struct {
+	URL string `json:"url"`
+
+	// Headers are the security headers of the response, by canonical name.
+	Headers map[string]string `json:"headers"`
+
+	// Missing lists the security headers absent from the response. HSTS is
+	// only expected from https pages.
+	Missing []string `json:"missing,omitempty"`
+
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header in
+	// seconds, or -1 if the header is absent or invalid.
+	HSTSMaxAge int64 `json:"hsts_max_age"`
+
+	// MixedContent are the http subresources of an https page.
+	MixedContent []*web.MixedContent `json:"mixed_content,omitempty"`
+}
+
+// Secure returns true if the page has all the security headers and no mixed
+// content.
+func (a *SecurityAudit) Secure() bool {
+	return len(a.Missing) == 0 && len(a.MixedContent) == 0
+}
+
+// SecurityAudit audits the security headers and mixed content of the page.
+// The frame-ancestors directive of the Content-Security-Policy header stands
+// in for X-Frame-Options, which it supersedes.
+func (r *Response) SecurityAudit() *SecurityAudit {
+	pageURL := r.URL
+	if r.FinalURL != "" {
+		pageURL = r.FinalURL
+	}
+	audit := &SecurityAudit{URL: pageURL, Headers: map[string]string{}, HSTSMaxAge: -1}
+	for name, value := range r.Headers {
+		for _, header := range SecurityHeaders {
+			if strings.EqualFold(name, header) {
+				audit.Headers[header] = value
+			}
+		}
+	}
+	https := strings.HasPrefix(strings.ToLower(pageURL), "https://")
+	for _, header := range SecurityHeaders {
+		if _, ok := audit.Headers[header]; ok {
+			continue
+		}
+		switch header {
+		case HeaderStrictTransportSecurity:
+			if !https {
+				continue
+			}
+		case HeaderXFrameOptions:
+			if strings.Contains(strings.ToLower(audit.Headers[HeaderContentSecurityPolicy]), "frame-ancestors") {
+				continue
+			}
+		}
+		audit.Missing = append(audit.Missing, header)
+	}
+	if hsts, ok := audit.Headers[HeaderStrictTransportSecurity]; ok {
+		audit.HSTSMaxAge = hstsMaxAge(hsts)
+	}
+	if r.HTML != "" {
+		if doc, err := r.Document(); err == nil {
+			audit.MixedContent = doc.MixedContent(pageURL)
+		}
+	}
+	return audit
+}
+
+// hstsMaxAge returns the max-age directive of a Strict-Transport-Security
+// header, or -1 if it is missing or invalid.
+func hstsMaxAge(value string) int64 {
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "max-age") {
+			continue
+		}
+		maxAge, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(arg), `"`), 10, 64)
+		if err != nil || maxAge < 0 {
+			return -1
+		}
+		return maxAge
+	}
+	return -1
+}
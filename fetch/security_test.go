@@ -0,0 +1,37 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponse_SecurityAudit(t *testing.T) {
+	response := &Response{
+		URL: "https://example.com",
+		Headers: map[string]string{
+			"strict-transport-security": "max-age=31536000; includeSubDomains",
+			"Content-Security-Policy":   "default-src 'self'; frame-ancestors 'none'",
+			"X-Content-Type-Options":    "nosniff",
+		},
+		HTML: `<html><body><img src="http://example.com/logo.png"></body></html>`,
+	}
+	audit := response.SecurityAudit()
+	require.Equal(t, map[string]string{
+		HeaderStrictTransportSecurity: "max-age=31536000; includeSubDomains",
+		HeaderContentSecurityPolicy:   "default-src 'self'; frame-ancestors 'none'",
+		HeaderXContentTypeOptions:     "nosniff",
+	}, audit.Headers)
+	// frame-ancestors supersedes X-Frame-Options
+	require.Equal(t, []string{HeaderReferrerPolicy, HeaderPermissionsPolicy}, audit.Missing)
+	require.Equal(t, int64(31536000), audit.HSTSMaxAge)
+	require.Len(t, audit.MixedContent, 1)
+	require.False(t, audit.Secure())
+
+	// HSTS is not expected from http pages
+	audit = (&Response{URL: "http://example.com", HTML: "<html></html>"}).SecurityAudit()
+	require.NotContains(t, audit.Missing, HeaderStrictTransportSecurity)
+	require.Contains(t, audit.Missing, HeaderXFrameOptions)
+	require.Equal(t, int64(-1), audit.HSTSMaxAge)
+	require.Empty(t, audit.MixedContent)
+}
@@ -0,0 +1,87 @@
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// StorageStates holds the browser storage state (cookies and local storage)
+// of each host, so that the state returned by one fetch can be sent with the
+// next fetches to the same host. The states are opaque to this package and
+// follow the format of the browser fetcher, e.g. Playwright storage state.
+// StorageStates is safe for concurrent use.
+type StorageStates struct {
+	mutex  sync.RWMutex
+	states map[string]map[string]any
+}
+
+// NewStorageStates returns empty storage states.
+func NewStorageStates() *StorageStates {
+	return &StorageStates{states: map[string]map[string]any{}}
+}
+
+// LoadStorageStates reads storage states saved with Save. A missing file
+// results in empty storage states.
+func LoadStorageStates(path string) (*StorageStates, error) {
+	s := NewStorageStates()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.states); err != nil {
+		return nil, fmt.Errorf("invalid storage states file: %w", err)
+	}
+	if s.states == nil {
+		s.states = map[string]map[string]any{}
+	}
+	return s, nil
+}
+
+// Get returns the storage state of the host, or nil. The returned state must
+// not be modified.
+func (s *StorageStates) Get(host string) map[string]any {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.states[host]
+}
+
+// Set replaces the storage state of the host. Empty states are ignored, so
+// that fetchers that don't return a state don't clear it.
+func (s *StorageStates) Set(host string, state map[string]any) {
+	if len(state) == 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.states[host] = state
+}
+
+// Save writes the storage states to a JSON file. The file is replaced
+// atomically and is only readable by the owner, as it holds credentials.
+func (s *StorageStates) Save(path string) error {
+	s.mutex.RLock()
+	data, err := json.Marshal(s.states)
+	s.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
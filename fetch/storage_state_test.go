@@ -0,0 +1,34 @@
+package fetch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageStates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	states, err := LoadStorageStates(path)
+	require.NoError(t, err)
+	require.Nil(t, states.Get("example.com"))
+
+	cookies := map[string]any{"cookies": []any{map[string]any{"name": "session", "value": "1"}}}
+	states.Set("example.com", cookies)
+	states.Set("example.com", nil)
+	require.Equal(t, cookies, states.Get("example.com"))
+	require.NoError(t, states.Save(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := LoadStorageStates(path)
+	require.NoError(t, err)
+	require.Equal(t, cookies, loaded.Get("example.com"))
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	_, err = LoadStorageStates(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package fetch
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// Default timeouts of the phases of HTTP requests.
+const (
+	DefaultDialTimeout         = 30 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// Timeouts defines the timeouts of the phases of HTTP requests, so that a
+// host that is slow to respond but then works isn't treated like a host that
+// doesn't accept connections. Zero values use the defaults. All timeouts are
+// clipped against the deadline of the request context.
+type Timeouts struct {
+	// Dial is the timeout of establishing a connection, including through a
+	// proxy. Defaults to DefaultDialTimeout.
+	Dial time.Duration
+
+	// TLSHandshake is the timeout of the TLS handshake. Defaults to
+	// DefaultTLSHandshakeTimeout. Not applied to DialTLS.
+	TLSHandshake time.Duration
+
+	// ResponseHeader is the time to wait for the response headers after
+	// writing the request. Unlimited by default, other than by Total.
+	ResponseHeader time.Duration
+
+	// Idle is how long idle connections are kept for reuse. Defaults to
+	// DefaultIdleConnTimeout.
+	Idle time.Duration
+
+	// Total bounds the whole request, including reading the body. Defaults
+	// to DefaultTimeout. The timeout of a Request takes precedence.
+	Total time.Duration
+}
+
+// isTimeout returns true if an error is a timeout, or follows the expiry of
+// the deadline of the request context, which may surface as an error of the
+// closed connection.
+func isTimeout(ctx context.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// withDefaults returns the timeouts with the defaults of unset values.
+func (t Timeouts) withDefaults() Timeouts {
+	if t.Dial == 0 {
+		t.Dial = DefaultDialTimeout
+	}
+	if t.TLSHandshake == 0 {
+		t.TLSHandshake = DefaultTLSHandshakeTimeout
+	}
+	if t.Idle == 0 {
+		t.Idle = DefaultIdleConnTimeout
+	}
+	if t.Total == 0 {
+		t.Total = DefaultTimeout
+	}
+	return t
+}
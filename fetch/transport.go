@@ -0,0 +1,302 @@
+package fetch
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TLSDialer establishes TLS connections to the given address. It may be used
+// to replace crypto/tls, e.g. with uTLS so that the ClientHello matches the
+// fingerprint of a real browser:
+//
+//	func(ctx context.Context, network, addr string) (net.Conn, error) {
+//		conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+//		if err != nil {
+//			return nil, err
+//		}
+//		host, _, _ := net.SplitHostPort(addr)
+//		config := &utls.Config{ServerName: host, NextProtos: []string{"http/1.1"}}
+//		tlsConn := utls.UClient(conn, config, utls.HelloChrome_Auto)
+//		if err := tlsConn.HandshakeContext(ctx); err != nil {
+//			conn.Close()
+//			return nil, err
+//		}
+//		return tlsConn, nil
+//	}
+//
+// The connections must negotiate HTTP/1.1, as HTTP/2 is only supported over
+// crypto/tls connections.
+type TLSDialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// newTransport returns the HTTP transport used by an HTTPFetcher that was not
+// given an HTTP client.
+func newTransport(options HTTPFetcherOptions) http.RoundTripper {
+	timeouts := options.Timeouts.withDefaults()
+	dialer := &net.Dialer{Timeout: timeouts.Dial, KeepAlive: 30 * time.Second}
+	if options.HeaderProfile != nil {
+		return &profileTransport{
+			profile:  options.HeaderProfile,
+			dialer:   dialer,
+			dialTLS:  options.DialTLS,
+			proxy:    proxyFunc(options.Proxy),
+			timeouts: timeouts,
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true
+	transport.Proxy = proxyFunc(options.Proxy)
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = timeouts.TLSHandshake
+	transport.ResponseHeaderTimeout = timeouts.ResponseHeader
+	transport.IdleConnTimeout = timeouts.Idle
+	if options.DialTLS != nil {
+		transport.DialTLSContext = options.DialTLS
+		transport.ForceAttemptHTTP2 = false
+	}
+	return &compressionTransport{Transport: transport}
+}
+
+// compressionTransport requests gzip compressed responses and decompresses
+// them, as http.Transport does unless DisableCompression is set, so that the
+// bytes transferred are counted. See Response.TransferSize.
+type compressionTransport struct {
+	*http.Transport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" && req.Method != http.MethodHead {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	decodeBody(resp)
+	return resp, nil
+}
+
+// profileTransport is an HTTP/1.1 transport that writes request headers in
+// the order and casing of a HeaderProfile, which net/http does not allow.
+// Connections are not reused.
+type profileTransport struct {
+	profile  HeaderProfile
+	dialer   *net.Dialer
+	dialTLS  TLSDialer
+	proxy    func(*http.Request) (*url.URL, error)
+	timeouts Timeouts
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *profileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body.Close()
+		return nil, fmt.Errorf("header profiles do not support request bodies")
+	}
+	ctx := req.Context()
+	conn, err := t.dial(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if trace := httptrace.ContextClientTrace(ctx); trace != nil && trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{Conn: conn})
+	}
+	// Close the connection if the context is done before the body is read
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	fail := func(err error) (*http.Response, error) {
+		stop()
+		conn.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "%s %s HTTP/1.1\r\n", req.Method, req.URL.RequestURI())
+	for _, h := range t.profile.apply(host, req.Header) {
+		fmt.Fprintf(w, "%s: %s\r\n", h.Name, headerValueReplacer.Replace(h.Value))
+	}
+	w.WriteString("\r\n")
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+
+	if t.timeouts.ResponseHeader > 0 {
+		conn.SetReadDeadline(time.Now().Add(t.timeouts.ResponseHeader))
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return fail(err)
+	}
+	conn.SetReadDeadline(time.Time{})
+	body := &connBody{ReadCloser: resp.Body, conn: conn, stop: stop}
+	resp.Body = body
+	decodeBody(resp)
+	return resp, nil
+}
+
+func (t *profileTransport) dial(ctx context.Context, req *http.Request) (net.Conn, error) {
+	u := req.URL
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		default:
+			return nil, fmt.Errorf("unsupported url scheme: %q", u.Scheme)
+		}
+	}
+	addr := net.JoinHostPort(host, port)
+	proxyURL, err := t.proxy(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Connections through a proxy use crypto/tls, as DialTLS dials the
+	// address itself
+	var conn net.Conn
+	switch {
+	case proxyURL != nil:
+		conn, err = dialProxy(ctx, t.dialer, proxyURL, addr)
+	case u.Scheme == "https" && t.dialTLS != nil:
+		return t.dialTLS(ctx, "tcp", addr)
+	default:
+		conn, err = t.dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil || u.Scheme == "http" {
+		return conn, err
+	}
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: host, NextProtos: []string{"http/1.1"}})
+	handshakeCtx, cancel := context.WithTimeout(ctx, t.timeouts.TLSHandshake)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(handshakeCtx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// headerValueReplacer prevents header values from injecting headers.
+var headerValueReplacer = strings.NewReplacer("\r", " ", "\n", " ")
+
+// connBody closes the connection of a response when its body is closed.
+type connBody struct {
+	io.ReadCloser
+	conn net.Conn
+	stop func() bool
+}
+
+func (b *connBody) Close() error {
+	b.stop()
+	err := b.ReadCloser.Close()
+	b.conn.Close()
+	return err
+}
+
+// decodeBody decompresses the body of a gzip or deflate encoded response.
+// Other responses, and responses without a body such as 304 responses, are
+// left as is. Invalid bodies fail when read.
+func decodeBody(resp *http.Response) {
+	if resp.Uncompressed || !hasBody(resp) {
+		return
+	}
+	raw := &countingReader{Reader: resp.Body}
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "gzip":
+		resp.Body = &decodedBody{Reader: &gzipReader{body: raw}, closer: resp.Body, raw: raw}
+	case "deflate":
+		resp.Body = &decodedBody{Reader: flate.NewReader(raw), closer: resp.Body, raw: raw}
+	default:
+		return
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// hasBody returns false for responses that have no body, as given by their
+// request method, status code or Content-Length.
+func hasBody(resp *http.Response) bool {
+	switch {
+	case resp.Body == nil || resp.Body == http.NoBody || resp.ContentLength == 0:
+		return false
+	case resp.Request != nil && resp.Request.Method == http.MethodHead:
+		return false
+	case resp.StatusCode < 200 || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// gzipReader decompresses a gzip body, reading its header on the first read
+// rather than when the response is received, as net/http does.
+type gzipReader struct {
+	body   io.Reader
+	reader *gzip.Reader
+	err    error
+}
+
+func (r *gzipReader) Read(p []byte) (int, error) {
+	if r.reader == nil && r.err == nil {
+		r.reader, r.err = gzip.NewReader(r.body)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.reader.Read(p)
+}
+
+// transferSize returns the number of bytes of the body of a response read
+// so far as transferred, i.e. before decompression, given the number of
+// bytes read from the body. They are equal unless the body was
+// decompressed by the transports of the package.
+func transferSize(resp *http.Response, read int64) int64 {
+	if body, ok := resp.Body.(*decodedBody); ok {
+		return body.raw.n
+	}
+	return read
+}
+
+// countingReader counts the bytes read from a reader.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// decodedBody is a decompressed response body.
+type decodedBody struct {
+	io.Reader
+	closer io.Closer
+	raw    *countingReader // the compressed body
+}
+
+func (b *decodedBody) Close() error {
+	return b.closer.Close()
+}
@@ -0,0 +1,166 @@
+package fetch
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/deepnoodle-ai/web"
+	"github.com/deepnoodle-ai/web/errors"
+)
+
+// Bounds of request durations applied by ValidateRequest, in milliseconds.
+const (
+	MaxRequestTimeout = 5 * 60 * 1000 // 5 minutes
+	MaxRequestWaitFor = 60 * 1000     // 1 minute
+)
+
+// Formats lists the supported values of Request.Formats.
+var Formats = []string{"html", "min_html", "markdown", "links", "metadata"}
+
+// Capabilities declares the features a fetcher supports, so that requests
+// needing other features are rejected rather than silently ignored. See
+// CapabilityReporter.
+type Capabilities struct {
+	// Formats are the supported formats. All formats are supported if empty.
+	Formats []string `json:"formats,omitempty"`
+
+	// Actions are the supported action types. Screenshots and PDFs are taken
+	// by the "screenshot" and "pdf" actions.
+	Actions []string `json:"actions,omitempty"`
+
+	// Mobile is true if the fetcher can emulate a mobile device.
+	Mobile bool `json:"mobile,omitempty"`
+
+	// StorageState is true if the fetcher restores and returns the storage
+	// state of the browser, i.e. its cookies and local storage.
+	StorageState bool `json:"storage_state,omitempty"`
+
+	// NetworkCapture is true if the fetcher captures the requests made by
+	// pages. See Request.CaptureNetwork.
+	NetworkCapture bool `json:"network_capture,omitempty"`
+
+	// ConditionalRequests is true if the fetcher sends the If-None-Match
+	// and If-Modified-Since headers of requests as is, and returns 304 Not
+	// Modified responses. See ConditionalHeaders.
+	ConditionalRequests bool `json:"conditional_requests,omitempty"`
+}
+
+// Supports returns true if the fetcher supports the given action type.
+func (c *Capabilities) Supports(actionType string) bool {
+	return slices.Contains(c.Actions, actionType)
+}
+
+// ValidateRequest validates a request and applies defaults: the URL is
+// normalized, with https assumed when the scheme is missing, and the timeout
+// and wait are clipped to MaxRequestTimeout and MaxRequestWaitFor. When
+// capabilities are given, the formats and actions of the request must be
+// supported. Returns an *errors.BadRequest naming the invalid field.
+func ValidateRequest(req *Request, capabilities *Capabilities) error {
+	if req == nil {
+		return errors.NewBadRequest("request is required")
+	}
+	normalized, err := normalizeRequestURL(req.URL)
+	if err != nil {
+		return err
+	}
+	req.URL = normalized
+	if req.Version != "" && major(req.Version) != major(APIVersion) {
+		return errors.NewInvalidField("version", "unsupported version %q (supported: %s)", req.Version, APIVersion)
+	}
+
+	// Durations
+	for _, field := range []struct {
+		name  string
+		value *int
+		max   int
+	}{
+		{"timeout", &req.Timeout, MaxRequestTimeout},
+		{"wait_for", &req.WaitFor, MaxRequestWaitFor},
+		{"max_age", &req.MaxAge, 0},
+	} {
+		if *field.value < 0 {
+			return errors.NewInvalidField(field.name, "must not be negative")
+		}
+		if field.max > 0 && *field.value > field.max {
+			*field.value = field.max
+		}
+	}
+	if req.Timeout > 0 && req.WaitFor >= req.Timeout {
+		return errors.NewInvalidField("wait_for", "must be less than the timeout")
+	}
+
+	for i, format := range req.Formats {
+		field := fmt.Sprintf("formats[%d]", i)
+		if !slices.Contains(Formats, format) {
+			return errors.NewInvalidField(field, "unknown format %q", format)
+		}
+		if capabilities != nil && len(capabilities.Formats) > 0 && !slices.Contains(capabilities.Formats, format) {
+			return errors.NewInvalidField(field, "format %q is not supported by the fetcher", format)
+		}
+	}
+	for i, action := range req.Actions {
+		field := fmt.Sprintf("actions[%d]", i)
+		if action.Action == nil {
+			return errors.NewInvalidField(field, "missing action")
+		}
+		actionType := action.Action.GetType()
+		if _, ok := actionSchemaTypes[actionType]; !ok {
+			return errors.NewInvalidField(field+".type", "unknown action type %q", actionType)
+		}
+		if capabilities != nil && !capabilities.Supports(actionType) {
+			return errors.NewInvalidField(field+".type", "action %q is not supported by the fetcher", actionType)
+		}
+	}
+	if capabilities != nil && req.Mobile && !capabilities.Mobile {
+		return errors.NewInvalidField("mobile", "mobile emulation is not supported by the fetcher")
+	}
+	if capabilities != nil && req.StorageState != nil && !capabilities.StorageState {
+		return errors.NewInvalidField("storage_state", "storage state is not supported by the fetcher")
+	}
+	if capabilities != nil && req.CaptureNetwork != nil && !capabilities.NetworkCapture {
+		return errors.NewInvalidField("capture_network", "network capture is not supported by the fetcher")
+	}
+	if req.ExcludeProfile != "" {
+		if _, ok := web.ExcludeProfiles[req.ExcludeProfile]; !ok {
+			return errors.NewInvalidField("exclude_profile", "unknown profile %q (supported: %s)",
+				req.ExcludeProfile, strings.Join(web.ExcludeProfileNames(), ", "))
+		}
+	}
+	for name := range req.Headers {
+		if strings.TrimSpace(name) == "" {
+			return errors.NewInvalidField("headers", "header names must not be empty")
+		}
+	}
+	if req.Proxy != "" {
+		if _, err := parseProxyURL(req.Proxy); err != nil {
+			return errors.NewInvalidField("proxy", "%s", err)
+		}
+	}
+	return nil
+}
+
+// normalizeRequestURL checks that a URL is an absolute http or https URL,
+// adding the https scheme if missing.
+func normalizeRequestURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", errors.NewInvalidField("url", "is required")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.NewInvalidField("url", "invalid url")
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.NewInvalidField("url", "unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", errors.NewInvalidField("url", "missing host")
+	}
+	return u.String(), nil
+}
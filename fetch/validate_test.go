@@ -0,0 +1,105 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/deepnoodle-ai/web/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRequest(t *testing.T) {
+	req := &Request{
+		URL:     "Example.com/page",
+		Timeout: 10 * 60 * 1000,
+		WaitFor: 2 * 60 * 1000,
+		Formats: []string{"html", "links"},
+	}
+	require.NoError(t, ValidateRequest(req, nil))
+	require.Equal(t, "https://Example.com/page", req.URL)
+	require.Equal(t, MaxRequestTimeout, req.Timeout)
+	require.Equal(t, MaxRequestWaitFor, req.WaitFor)
+
+	req = &Request{URL: "HTTP://example.com"}
+	require.NoError(t, ValidateRequest(req, nil))
+	require.Equal(t, "http://example.com", req.URL)
+}
+
+func TestValidateRequest_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *Request
+		field   string
+	}{
+		{"missing url", &Request{}, "url"},
+		{"bad scheme", &Request{URL: "ftp://example.com"}, "url"},
+		{"missing host", &Request{URL: "https://"}, "url"},
+		{"negative timeout", &Request{URL: "example.com", Timeout: -1}, "timeout"},
+		{"wait exceeds timeout", &Request{URL: "example.com", Timeout: 1000, WaitFor: 1000}, "wait_for"},
+		{"negative max age", &Request{URL: "example.com", MaxAge: -1}, "max_age"},
+		{"unknown format", &Request{URL: "example.com", Formats: []string{"html", "pdf"}}, "formats[1]"},
+		{"missing action", &Request{URL: "example.com", Actions: []Action{{}}}, "actions[0]"},
+		{"empty header", &Request{URL: "example.com", Headers: map[string]string{" ": "x"}}, "headers"},
+		{"bad proxy", &Request{URL: "example.com", Proxy: "gopher://proxy"}, "proxy"},
+		{"bad version", &Request{URL: "example.com", Version: "99.0"}, "version"},
+		{"unknown exclude profile", &Request{URL: "example.com", ExcludeProfile: "minimal"}, "exclude_profile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRequest(tt.request, nil)
+			require.Error(t, err)
+			require.True(t, errors.IsBadRequest(err))
+			require.Equal(t, tt.field, err.(*errors.BadRequest).Field)
+		})
+	}
+}
+
+func TestValidateRequest_Capabilities(t *testing.T) {
+	capabilities := &Capabilities{
+		Formats: []string{"html", "links"},
+		Actions: []string{"wait"},
+	}
+	req := &Request{
+		URL:     "https://example.com",
+		Formats: []string{"html"},
+		Actions: []Action{NewWaitAction(WaitActionOptions{Duration: 100})},
+	}
+	require.NoError(t, ValidateRequest(req, capabilities))
+
+	req.Formats = []string{"markdown"}
+	err := ValidateRequest(req, capabilities)
+	require.EqualError(t, err, `formats[0]: format "markdown" is not supported by the fetcher`)
+
+	req.Formats = nil
+	req.Actions = append(req.Actions, NewScreenshotAction(ScreenshotActionOptions{}))
+	err = ValidateRequest(req, capabilities)
+	require.EqualError(t, err, `actions[1].type: action "screenshot" is not supported by the fetcher`)
+
+	req.Actions = nil
+	req.Mobile = true
+	err = ValidateRequest(req, capabilities)
+	require.EqualError(t, err, "mobile: mobile emulation is not supported by the fetcher")
+
+	req.Mobile = false
+	req.StorageState = map[string]any{}
+	err = ValidateRequest(req, capabilities)
+	require.EqualError(t, err, "storage_state: storage state is not supported by the fetcher")
+
+	req.StorageState = nil
+	req.CaptureNetwork = &NetworkCapture{}
+	err = ValidateRequest(req, capabilities)
+	require.EqualError(t, err, "capture_network: network capture is not supported by the fetcher")
+}
+
+func TestFetcherCapabilities(t *testing.T) {
+	require.Nil(t, FetcherCapabilities(NewMockFetcher()))
+
+	httpFetcher := NewHTTPFetcher(HTTPFetcherOptions{})
+	capabilities := FetcherCapabilities(httpFetcher)
+	require.Equal(t, Formats, capabilities.Formats)
+	require.False(t, capabilities.Supports("screenshot"))
+	require.False(t, capabilities.Mobile)
+
+	// Wrapping fetchers report the capabilities of the wrapped fetcher
+	wrapped := NewExtractFetcher(NewArtifactFetcher(httpFetcher, nil), nil)
+	require.Equal(t, capabilities, FetcherCapabilities(wrapped))
+}
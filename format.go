@@ -6,6 +6,7 @@ import (
 )
 
 // FormatHTML parses the input HTML string, formats it and returns the result.
+// Use StableHTML to compare renderings, e.g. between crawls.
 func FormatHTML(html string) string {
 	return gohtml.Format(html)
 }
@@ -0,0 +1,125 @@
+package web
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Salary is a pay range from a JobPosting's baseSalary.
+type Salary struct {
+	Currency string  `json:"currency,omitempty"`
+	Min      float64 `json:"min,omitempty"`
+	Max      float64 `json:"max,omitempty"`
+	Unit     string  `json:"unit,omitempty"` // e.g. "HOUR", "YEAR"
+}
+
+// JobPosting contains schema.org JobPosting data extracted from a page.
+type JobPosting struct {
+	Title           string    `json:"title,omitempty"`
+	Description     string    `json:"description,omitempty"`
+	URL             string    `json:"url,omitempty"`
+	Identifier      string    `json:"identifier,omitempty"`
+	DatePosted      time.Time `json:"date_posted,omitzero"`
+	ValidThrough    time.Time `json:"valid_through,omitzero"`
+	EmploymentTypes []string  `json:"employment_types,omitempty"` // e.g. "FULL_TIME"
+	Organization    string    `json:"organization,omitempty"`
+	OrganizationURL string    `json:"organization_url,omitempty"`
+	Locations       []string  `json:"locations,omitempty"`
+	Remote          bool      `json:"remote,omitempty"`
+	Salary          *Salary   `json:"salary,omitempty"`
+	DirectApply     bool      `json:"direct_apply,omitempty"`
+}
+
+// ExtractJobPostings returns the job postings described by the document's
+// structured data. Descriptions, which are usually HTML, are converted to
+// plain text.
+func ExtractJobPostings(doc *Document) []*JobPosting {
+	var jobs []*JobPosting
+	for _, item := range FindStructuredItems(doc.StructuredItems(), "JobPosting") {
+		job := &JobPosting{
+			Title:           schemaString(item["title"]),
+			Description:     schemaHTMLText(item["description"]),
+			URL:             schemaString(item["url"]),
+			Identifier:      schemaIdentifier(item["identifier"]),
+			DatePosted:      ParseTime(schemaString(item["datePosted"])),
+			ValidThrough:    ParseTime(schemaString(item["validThrough"])),
+			EmploymentTypes: schemaStrings(item["employmentType"]),
+			Organization:    schemaString(item["hiringOrganization"]),
+			Remote:          strings.EqualFold(schemaString(item["jobLocationType"]), "TELECOMMUTE"),
+			Salary:          schemaSalary(item["baseSalary"]),
+			DirectApply:     schemaString(item["directApply"]) == "true",
+		}
+		if job.Title == "" {
+			job.Title = schemaString(item["name"])
+		}
+		if org, ok := item["hiringOrganization"].(map[string]any); ok {
+			job.OrganizationURL = schemaString(org["sameAs"])
+			fillString(&job.OrganizationURL, schemaString(org["url"]))
+		}
+		for _, venue := range schemaVenues(item["jobLocation"]) {
+			if venue.Address != "" {
+				job.Locations = append(job.Locations, venue.Address)
+			} else if venue.Name != "" {
+				job.Locations = append(job.Locations, venue.Name)
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// schemaIdentifier returns the value of an identifier given as text or as a
+// PropertyValue.
+func schemaIdentifier(value any) string {
+	if v, ok := value.(map[string]any); ok {
+		if s := schemaString(v["value"]); s != "" {
+			return s
+		}
+	}
+	return schemaString(value)
+}
+
+// schemaSalary parses a MonetaryAmount whose value is a number or a
+// QuantitativeValue with a range.
+func schemaSalary(value any) *Salary {
+	amount, ok := value.(map[string]any)
+	if !ok {
+		if n := schemaNumber(value); n != 0 {
+			return &Salary{Min: n, Max: n}
+		}
+		return nil
+	}
+	salary := &Salary{Currency: strings.ToUpper(schemaString(amount["currency"]))}
+	switch v := amount["value"].(type) {
+	case map[string]any:
+		salary.Min = schemaNumber(v["minValue"])
+		salary.Max = schemaNumber(v["maxValue"])
+		if n := schemaNumber(v["value"]); n != 0 {
+			salary.Min, salary.Max = n, n
+		}
+		salary.Unit = strings.ToUpper(schemaString(v["unitText"]))
+	default:
+		n := schemaNumber(v)
+		salary.Min, salary.Max = n, n
+	}
+	if salary.Min == 0 && salary.Max == 0 {
+		return nil
+	}
+	return salary
+}
+
+// schemaHTMLText returns the text of a structured data value that may contain
+// HTML markup, such as a job description or FAQ answer.
+func schemaHTMLText(value any) string {
+	s := schemaString(value)
+	if !strings.Contains(s, "<") {
+		return s
+	}
+	root, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return s
+	}
+	return blockText(root)
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractJobPostings(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<script type="application/ld+json">
+			{
+				"@context": "https://schema.org/",
+				"@type": "JobPosting",
+				"title": "Backend Engineer",
+				"description": "&lt;p&gt;Build &lt;b&gt;APIs&lt;/b&gt;.&lt;/p&gt;&lt;ul&gt;&lt;li&gt;Go&lt;/li&gt;&lt;li&gt;SQL&lt;/li&gt;&lt;/ul&gt;",
+				"identifier": {"@type": "PropertyValue", "name": "Acme", "value": "1234"},
+				"datePosted": "2024-01-18",
+				"validThrough": "2024-03-18T00:00",
+				"employmentType": ["FULL_TIME", "CONTRACTOR"],
+				"hiringOrganization": {"@type": "Organization", "name": "Acme", "sameAs": "https://acme.example"},
+				"jobLocation": {
+					"@type": "Place",
+					"address": {"@type": "PostalAddress", "addressLocality": "Austin", "addressRegion": "TX", "addressCountry": "US"}
+				},
+				"baseSalary": {
+					"@type": "MonetaryAmount",
+					"currency": "usd",
+					"value": {"@type": "QuantitativeValue", "minValue": 120000, "maxValue": 150000, "unitText": "YEAR"}
+				},
+				"directApply": true
+			}
+			</script>
+		</head><body></body></html>
+	`)
+	require.NoError(t, err)
+
+	jobs := ExtractJobPostings(doc)
+	require.Len(t, jobs, 1)
+	job := jobs[0]
+	require.Equal(t, "Backend Engineer", job.Title)
+	require.Equal(t, "Build APIs.\n\nGo\n\nSQL", job.Description)
+	require.Equal(t, "1234", job.Identifier)
+	require.Equal(t, time.Date(2024, 1, 18, 0, 0, 0, 0, time.UTC), job.DatePosted)
+	require.Equal(t, time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC), job.ValidThrough)
+	require.Equal(t, []string{"FULL_TIME", "CONTRACTOR"}, job.EmploymentTypes)
+	require.Equal(t, "Acme", job.Organization)
+	require.Equal(t, "https://acme.example", job.OrganizationURL)
+	require.Equal(t, []string{"Austin, TX, US"}, job.Locations)
+	require.False(t, job.Remote)
+	require.Equal(t, &Salary{Currency: "USD", Min: 120000, Max: 150000, Unit: "YEAR"}, job.Salary)
+	require.True(t, job.DirectApply)
+}
+
+func TestExtractFAQ(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<script type="application/ld+json">
+			{
+				"@context": "https://schema.org",
+				"@type": "FAQPage",
+				"mainEntity": [{
+					"@type": "Question",
+					"name": "How long does shipping take?",
+					"acceptedAnswer": {"@type": "Answer", "text": "Usually <b>3-5</b> days."}
+				}, {
+					"@type": "Question",
+					"name": "Unanswered?"
+				}]
+			}
+			</script>
+		</head><body></body></html>
+	`)
+	require.NoError(t, err)
+
+	require.Equal(t, []*FAQItem{
+		{Question: "How long does shipping take?", Answer: "Usually 3-5 days."},
+	}, ExtractFAQ(doc))
+}
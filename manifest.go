@@ -0,0 +1,86 @@
+package web
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// ManifestIcon is an icon declared in a web app manifest.
+type ManifestIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// Manifest is a web app manifest, as linked by <link rel="manifest">.
+type Manifest struct {
+	URL             string          `json:"url,omitempty"`
+	Name            string          `json:"name,omitempty"`
+	ShortName       string          `json:"short_name,omitempty"`
+	Description     string          `json:"description,omitempty"`
+	StartURL        string          `json:"start_url,omitempty"`
+	Scope           string          `json:"scope,omitempty"`
+	Display         string          `json:"display,omitempty"`
+	ThemeColor      string          `json:"theme_color,omitempty"`
+	BackgroundColor string          `json:"background_color,omitempty"`
+	Icons           []*ManifestIcon `json:"icons,omitempty"`
+}
+
+// ParseManifest parses a web app manifest. The manifest URL is used to
+// resolve relative icon and start URLs and may be empty.
+func ParseManifest(data []byte, manifestURL string) (*Manifest, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	manifest.URL = manifestURL
+	manifest.Name = NormalizeText(manifest.Name)
+	manifest.ShortName = NormalizeText(manifest.ShortName)
+	manifest.Description = NormalizeText(manifest.Description)
+	if base, err := url.Parse(manifestURL); err == nil && manifestURL != "" {
+		manifest.StartURL, _ = resolveIconURL(base, manifest.StartURL)
+		for _, icon := range manifest.Icons {
+			icon.Src, _ = resolveIconURL(base, icon.Src)
+		}
+	}
+	return &manifest, nil
+}
+
+// ResolveIcons returns the manifest icons as icon candidates, best first.
+// ResolveFaviconWithManifest ranks them along with the icons linked by a
+// document.
+func (m *Manifest) ResolveIcons() []*Icon {
+	var icons []*Icon
+	for _, mi := range m.Icons {
+		if mi.Src == "" {
+			continue
+		}
+		icon := &Icon{
+			URL:    mi.Src,
+			Rel:    "manifest",
+			Type:   mi.Type,
+			Source: IconSourceManifest,
+		}
+		icon.SetSizes(mi.Sizes)
+		if icon.Type == "image/svg+xml" {
+			icon.Any = true
+		}
+		if strings.Contains(mi.Purpose, "monochrome") && !strings.Contains(mi.Purpose, "any") {
+			icon.Rel = "mask-icon"
+		}
+		icons = append(icons, icon)
+	}
+	return RankIcons(icons)
+}
+
+// ManifestURL returns the web app manifest link of the document.
+func (d *Document) ManifestURL() string {
+	return memoize(d, "manifestURL", func() string {
+		if s := d.doc.Find("link[rel='manifest']").First(); len(s.Nodes) > 0 {
+			return strings.TrimSpace(s.AttrOr("href", ""))
+		}
+		return ""
+	})
+}
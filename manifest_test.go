@@ -0,0 +1,45 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	manifest, err := ParseManifest([]byte(`{
+		"name": "Example App",
+		"short_name": "Example",
+		"start_url": "/?source=pwa",
+		"display": "standalone",
+		"theme_color": "#336699",
+		"background_color": "#ffffff",
+		"icons": [
+			{"src": "icons/192.png", "sizes": "192x192", "type": "image/png"},
+			{"src": "icons/512.png", "sizes": "512x512", "type": "image/png"},
+			{"src": "icons/mono.png", "sizes": "1024x1024", "purpose": "monochrome"}
+		]
+	}`), "https://example.com/app/manifest.json")
+	require.NoError(t, err)
+	require.Equal(t, "Example App", manifest.Name)
+	require.Equal(t, "Example", manifest.ShortName)
+	require.Equal(t, "#336699", manifest.ThemeColor)
+	require.Equal(t, "https://example.com/?source=pwa", manifest.StartURL)
+	require.Equal(t, "https://example.com/app/icons/192.png", manifest.Icons[0].Src)
+
+	icons := manifest.ResolveIcons()
+	require.Len(t, icons, 3)
+	require.Equal(t, "https://example.com/app/icons/512.png", icons[0].URL)
+	require.Equal(t, IconSourceManifest, icons[0].Source)
+	require.Equal(t, "https://example.com/app/icons/mono.png", icons[2].URL)
+
+	_, err = ParseManifest([]byte(`not json`), "")
+	require.Error(t, err)
+}
+
+func TestDocument_ManifestURL(t *testing.T) {
+	doc, err := NewDocument(`<html><head><link rel="manifest" href="/site.webmanifest"></head></html>`)
+	require.NoError(t, err)
+	require.Equal(t, "/site.webmanifest", doc.ManifestURL())
+	require.Equal(t, "/site.webmanifest", doc.Metadata().ManifestURL)
+}
@@ -11,7 +11,16 @@ type Metadata struct {
 	Robots        string   `json:"robots,omitempty"`
 	Image         string   `json:"image,omitempty"`
 	Icon          string   `json:"icon,omitempty"`
+	ManifestURL   string   `json:"manifest_url,omitempty"`
+	BaseURL       string   `json:"base_url,omitempty"` // href of the base element
 	PublishedTime string   `json:"published_time,omitempty"`
 	Keywords      []string `json:"keywords,omitempty"`
 	Tags          []*Meta  `json:"tags,omitempty"`
+
+	// Manifest is populated by fetchers configured to fetch the web app
+	// manifest linked from the page.
+	Manifest *Manifest `json:"manifest,omitempty"`
+
+	// StructuredData holds the typed schema.org entities of the page.
+	StructuredData *StructuredData `json:"structured_data,omitempty"`
 }
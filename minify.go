@@ -0,0 +1,114 @@
+package web
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// preservedWhitespace are the elements whose text is rendered as is.
+var preservedWhitespace = map[string]bool{
+	"pre": true, "textarea": true, "script": true, "style": true,
+	"code": true, "listing": true, "plaintext": true, "xmp": true,
+}
+
+// meaningfulEmptyAttributes are the attributes kept by minification even when
+// empty: boolean attributes, and those whose empty value differs from their
+// absence.
+var meaningfulEmptyAttributes = map[string]bool{
+	"allowfullscreen": true, "async": true, "autofocus": true, "autoplay": true,
+	"checked": true, "controls": true, "default": true, "defer": true,
+	"disabled": true, "formnovalidate": true, "hidden": true, "inert": true,
+	"ismap": true, "itemscope": true, "loop": true, "multiple": true,
+	"muted": true, "nomodule": true, "novalidate": true, "open": true,
+	"playsinline": true, "readonly": true, "required": true, "reversed": true,
+	"selected": true, "alt": true, "value": true, "content": true,
+}
+
+// MinifyHTML parses the input HTML string, minifies it and returns the result.
+// See RenderOptions.Minify.
+func MinifyHTML(input string) (string, error) {
+	doc, err := NewDocument(input)
+	if err != nil {
+		return "", err
+	}
+	return doc.Render(RenderOptions{Minify: true})
+}
+
+// minifyNode strips the comments of a tree, collapses the whitespace of its
+// text and drops its empty attributes. Whitespace between blocks is removed,
+// while whitespace within inline content collapses to a single space.
+func minifyNode(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.CommentNode:
+			n.RemoveChild(c)
+		case html.TextNode:
+			if preservedWhitespace[n.Data] {
+				break
+			}
+			c.Data = collapseWhitespace(c.Data)
+			if c.Data == " " && !inlineWhitespace(c) {
+				n.RemoveChild(c)
+			}
+		case html.ElementNode:
+			attrs := c.Attr[:0]
+			for _, attr := range c.Attr {
+				if attr.Val != "" || meaningfulEmptyAttributes[attr.Key] {
+					attrs = append(attrs, attr)
+				}
+			}
+			c.Attr = attrs
+			if !preservedWhitespace[c.Data] {
+				minifyNode(c)
+			}
+		}
+		c = next
+	}
+}
+
+// collapseWhitespace replaces runs of whitespace with a single space.
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+		default:
+			b.WriteRune(r)
+			space = false
+		}
+	}
+	return b.String()
+}
+
+// inlineWhitespace returns true if a whitespace text node separates inline
+// content, where removing it would join words.
+func inlineWhitespace(n *html.Node) bool {
+	switch n.Parent.Data {
+	case "html", "head", "body", "table", "thead", "tbody", "tfoot", "tr", "ul", "ol", "dl", "select":
+		return false
+	}
+	if n.PrevSibling == nil || n.NextSibling == nil {
+		return !blockElements[n.Parent.Data]
+	}
+	return !isBlock(n.PrevSibling) && !isBlock(n.NextSibling)
+}
+
+func isBlock(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch n.Data {
+	case "head", "body", "br", "hr", "script", "style", "link", "meta", "title":
+		// Whitespace around these elements isn't rendered
+		return true
+	}
+	return blockElements[n.Data]
+}
@@ -70,6 +70,31 @@ func NormalizeURL(value string) (*url.URL, error) {
 	return u, nil
 }
 
+// DirectoryIndexFiles are the names of the files commonly served for
+// directory paths, e.g. /docs/index.html for /docs/.
+var DirectoryIndexFiles = []string{
+	"index.html",
+	"index.htm",
+	"index.php",
+	"default.html",
+	"default.htm",
+	"default.aspx",
+}
+
+// TrimDirectoryIndex removes a directory index file name from the end of a
+// URL path, so that /path/index.html becomes /path/. The names are matched
+// case-insensitively.
+func TrimDirectoryIndex(path string, indexFiles []string) string {
+	slash := strings.LastIndex(path, "/")
+	dir, file := path[:slash+1], path[slash+1:]
+	for _, indexFile := range indexFiles {
+		if strings.EqualFold(file, indexFile) {
+			return dir
+		}
+	}
+	return path
+}
+
 // SortURLs sorts a slice of URLs by their string representation.
 func SortURLs(urls []*url.URL) {
 	sort.Slice(urls, func(i, j int) bool {
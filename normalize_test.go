@@ -171,6 +171,24 @@ func TestNormalizeURL(t *testing.T) {
 	}
 }
 
+func TestTrimDirectoryIndex(t *testing.T) {
+	tests := map[string]string{
+		"":                     "",
+		"/":                    "/",
+		"/index.html":          "/",
+		"/docs":                "/docs",
+		"/docs/":               "/docs/",
+		"/docs/INDEX.HTM":      "/docs/",
+		"/docs/default.aspx":   "/docs/",
+		"/docs/index.html.bak": "/docs/index.html.bak",
+		"/index.html/page":     "/index.html/page",
+	}
+	for path, expected := range tests {
+		require.Equal(t, expected, TrimDirectoryIndex(path, DirectoryIndexFiles), path)
+	}
+	require.Equal(t, "/docs/index.php", TrimDirectoryIndex("/docs/index.php", []string{"index.html"}))
+}
+
 func TestAreSameHost(t *testing.T) {
 	tests := []struct {
 		name     string
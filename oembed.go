@@ -0,0 +1,109 @@
+package web
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// OEmbedLink is an oEmbed endpoint discovered on a page.
+type OEmbedLink struct {
+	URL    string `json:"url"`
+	Format string `json:"format"` // "json" or "xml"
+	Title  string `json:"title,omitempty"`
+}
+
+// OEmbed is the embed data returned by an oEmbed provider.
+type OEmbed struct {
+	Type            string `json:"type" xml:"type"`
+	Version         string `json:"version,omitempty" xml:"version"`
+	Title           string `json:"title,omitempty" xml:"title"`
+	AuthorName      string `json:"author_name,omitempty" xml:"author_name"`
+	AuthorURL       string `json:"author_url,omitempty" xml:"author_url"`
+	ProviderName    string `json:"provider_name,omitempty" xml:"provider_name"`
+	ProviderURL     string `json:"provider_url,omitempty" xml:"provider_url"`
+	CacheAge        int    `json:"cache_age,omitempty" xml:"cache_age"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty" xml:"thumbnail_url"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty" xml:"thumbnail_width"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty" xml:"thumbnail_height"`
+	URL             string `json:"url,omitempty" xml:"url"`
+	HTML            string `json:"html,omitempty" xml:"html"`
+	Width           int    `json:"width,omitempty" xml:"width"`
+	Height          int    `json:"height,omitempty" xml:"height"`
+}
+
+// OEmbedLinks returns the oEmbed endpoints advertised by the document, with
+// JSON endpoints first.
+func (d *Document) OEmbedLinks() []*OEmbedLink {
+	return memoize(d, "oEmbedLinks", func() []*OEmbedLink {
+		var jsonLinks, xmlLinks []*OEmbedLink
+		d.doc.Find("link[rel='alternate'][href]").Each(func(i int, s *goquery.Selection) {
+			link := &OEmbedLink{
+				URL:   strings.TrimSpace(s.AttrOr("href", "")),
+				Title: NormalizeText(s.AttrOr("title", "")),
+			}
+			switch strings.ToLower(strings.TrimSpace(s.AttrOr("type", ""))) {
+			case "application/json+oembed":
+				link.Format = "json"
+				jsonLinks = append(jsonLinks, link)
+			case "text/xml+oembed", "application/xml+oembed":
+				link.Format = "xml"
+				xmlLinks = append(xmlLinks, link)
+			}
+		})
+		return append(jsonLinks, xmlLinks...)
+	})
+}
+
+// ParseOEmbed parses an oEmbed response in the given format, "json" or "xml".
+// Numeric fields sent as strings, which some providers do, are accepted.
+func ParseOEmbed(data []byte, format string) (*OEmbed, error) {
+	switch format {
+	case "xml":
+		var oembed OEmbed
+		if err := xml.Unmarshal(data, &oembed); err != nil {
+			return nil, err
+		}
+		return &oembed, nil
+	case "json", "":
+		var raw map[string]any
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		str := func(key string) string {
+			switch v := raw[key].(type) {
+			case string:
+				return v
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			}
+			return ""
+		}
+		num := func(key string) int {
+			n, _ := strconv.ParseFloat(str(key), 64)
+			return int(n)
+		}
+		return &OEmbed{
+			Type:            str("type"),
+			Version:         str("version"),
+			Title:           str("title"),
+			AuthorName:      str("author_name"),
+			AuthorURL:       str("author_url"),
+			ProviderName:    str("provider_name"),
+			ProviderURL:     str("provider_url"),
+			CacheAge:        num("cache_age"),
+			ThumbnailURL:    str("thumbnail_url"),
+			ThumbnailWidth:  num("thumbnail_width"),
+			ThumbnailHeight: num("thumbnail_height"),
+			URL:             str("url"),
+			HTML:            str("html"),
+			Width:           num("width"),
+			Height:          num("height"),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported oembed format: %s", format)
+}
@@ -0,0 +1,52 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_OEmbedLinks(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<link rel="alternate" type="text/xml+oembed" href="https://example.com/oembed?format=xml">
+			<link rel="alternate" type="application/json+oembed" href="https://example.com/oembed?format=json" title="A Video">
+			<link rel="alternate" type="application/rss+xml" href="/feed">
+		</head></html>
+	`)
+	require.NoError(t, err)
+	require.Equal(t, []*OEmbedLink{
+		{URL: "https://example.com/oembed?format=json", Format: "json", Title: "A Video"},
+		{URL: "https://example.com/oembed?format=xml", Format: "xml"},
+	}, doc.OEmbedLinks())
+}
+
+func TestParseOEmbed(t *testing.T) {
+	oembed, err := ParseOEmbed([]byte(`{
+		"type": "video",
+		"version": "1.0",
+		"title": "A Video",
+		"author_name": "Jane",
+		"thumbnail_url": "https://example.com/thumb.jpg",
+		"thumbnail_width": "480",
+		"width": 640,
+		"height": 360,
+		"html": "<iframe src=\"https://example.com/embed\"></iframe>"
+	}`), "json")
+	require.NoError(t, err)
+	require.Equal(t, "video", oembed.Type)
+	require.Equal(t, "Jane", oembed.AuthorName)
+	require.Equal(t, 480, oembed.ThumbnailWidth)
+	require.Equal(t, 640, oembed.Width)
+	require.Contains(t, oembed.HTML, "iframe")
+
+	oembed, err = ParseOEmbed([]byte(`<?xml version="1.0"?>
+		<oembed><type>photo</type><title>A Photo</title><url>https://example.com/p.jpg</url><width>100</width></oembed>`), "xml")
+	require.NoError(t, err)
+	require.Equal(t, "photo", oembed.Type)
+	require.Equal(t, "https://example.com/p.jpg", oembed.URL)
+	require.Equal(t, 100, oembed.Width)
+
+	_, err = ParseOEmbed([]byte(`{}`), "yaml")
+	require.Error(t, err)
+}
@@ -0,0 +1,224 @@
+package web
+
+import (
+	"strconv"
+	"strings"
+)
+
+// OpenGraphMedia is an image, video or audio of an Open Graph object.
+type OpenGraphMedia struct {
+	URL       string `json:"url"`
+	SecureURL string `json:"secure_url,omitempty"`
+	Type      string `json:"type,omitempty"` // MIME type
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	Alt       string `json:"alt,omitempty"`
+}
+
+// OpenGraph contains the Open Graph properties of a page, as specified by
+// ogp.me.
+type OpenGraph struct {
+	Type             string            `json:"type,omitempty"` // e.g. "article" or "video.movie"
+	Title            string            `json:"title,omitempty"`
+	Description      string            `json:"description,omitempty"`
+	URL              string            `json:"url,omitempty"`
+	SiteName         string            `json:"site_name,omitempty"`
+	Locale           string            `json:"locale,omitempty"`
+	LocaleAlternates []string          `json:"locale_alternates,omitempty"`
+	Determiner       string            `json:"determiner,omitempty"`
+	Images           []*OpenGraphMedia `json:"images,omitempty"`
+	Videos           []*OpenGraphMedia `json:"videos,omitempty"`
+	Audios           []*OpenGraphMedia `json:"audios,omitempty"`
+
+	// Properties holds the values of all the og:* properties, and of the
+	// properties of the namespace of the type, e.g. article:author for
+	// articles, in document order.
+	Properties map[string][]string `json:"properties,omitempty"`
+}
+
+// Property returns the first value of a property, e.g. "article:section".
+func (o *OpenGraph) Property(name string) string {
+	if values := o.Properties[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// OpenGraph returns the Open Graph properties of the document, or nil if it
+// has none. Properties are read from the property attribute of meta tags, or
+// their name attribute. Structured properties such as og:image:width apply to
+// the last media of their kind declared before them.
+func (d *Document) OpenGraph() *OpenGraph {
+	return memoize(d, "openGraph", func() *OpenGraph {
+		type property struct{ name, value string }
+		var properties []property
+		for _, meta := range d.Meta() {
+			name := strings.ToLower(strings.TrimSpace(meta.Property))
+			if name == "" {
+				name = strings.ToLower(strings.TrimSpace(meta.Name))
+			}
+			if name == "" || !strings.Contains(name, ":") {
+				continue
+			}
+			properties = append(properties, property{name, strings.TrimSpace(meta.Content)})
+		}
+
+		og := &OpenGraph{Properties: map[string][]string{}}
+		for _, p := range properties {
+			if p.name == "og:type" {
+				og.Type = p.value
+				break
+			}
+		}
+		namespace, _, _ := strings.Cut(og.Type, ".")
+		current := map[string]*OpenGraphMedia{} // last media of each kind
+		for _, p := range properties {
+			prefix, _, _ := strings.Cut(p.name, ":")
+			if prefix != "og" && (namespace == "" || prefix != namespace) {
+				continue
+			}
+			og.Properties[p.name] = append(og.Properties[p.name], p.value)
+			switch p.name {
+			case "og:title":
+				fillString(&og.Title, NormalizeText(p.value))
+			case "og:description":
+				fillString(&og.Description, NormalizeText(p.value))
+			case "og:url":
+				fillString(&og.URL, p.value)
+			case "og:site_name":
+				fillString(&og.SiteName, NormalizeText(p.value))
+			case "og:locale":
+				fillString(&og.Locale, p.value)
+			case "og:locale:alternate":
+				og.LocaleAlternates = append(og.LocaleAlternates, p.value)
+			case "og:determiner":
+				fillString(&og.Determiner, p.value)
+			case "og:image", "og:video", "og:audio":
+				current[p.name] = &OpenGraphMedia{URL: p.value}
+				og.addMedia(p.name, current[p.name])
+			default:
+				kind, field, ok := openGraphMediaProperty(p.name)
+				if !ok {
+					continue
+				}
+				// og:image:url is an alias of og:image, so it only declares
+				// a new image if it differs from the current one
+				media := current[kind]
+				if media == nil || (field == "url" && media.URL != "" && media.URL != p.value) {
+					media = &OpenGraphMedia{}
+					current[kind] = media
+					og.addMedia(kind, media)
+				}
+				media.set(field, p.value)
+			}
+		}
+		if len(og.Properties) == 0 {
+			return nil
+		}
+		return og
+	})
+}
+
+// openGraphMediaProperty splits a structured media property, e.g.
+// og:image:width into og:image and width.
+func openGraphMediaProperty(name string) (kind, field string, ok bool) {
+	for _, kind := range []string{"og:image:", "og:video:", "og:audio:"} {
+		if field, ok := strings.CutPrefix(name, kind); ok {
+			return strings.TrimSuffix(kind, ":"), field, true
+		}
+	}
+	return "", "", false
+}
+
+// addMedia appends a media of the given kind, e.g. og:image.
+func (o *OpenGraph) addMedia(kind string, media *OpenGraphMedia) {
+	switch kind {
+	case "og:image":
+		o.Images = append(o.Images, media)
+	case "og:video":
+		o.Videos = append(o.Videos, media)
+	case "og:audio":
+		o.Audios = append(o.Audios, media)
+	}
+}
+
+// set sets a structured property of the media.
+func (m *OpenGraphMedia) set(field, value string) {
+	switch field {
+	case "url":
+		m.URL = value
+	case "secure_url":
+		m.SecureURL = value
+	case "type":
+		m.Type = value
+	case "width":
+		m.Width, _ = strconv.Atoi(value)
+	case "height":
+		m.Height, _ = strconv.Atoi(value)
+	case "alt":
+		m.Alt = NormalizeText(value)
+	}
+}
+
+// TwitterCard contains the Twitter (X) Card properties of a page.
+type TwitterCard struct {
+	Card         string `json:"card,omitempty"` // e.g. "summary_large_image"
+	Site         string `json:"site,omitempty"`
+	SiteID       string `json:"site_id,omitempty"`
+	Creator      string `json:"creator,omitempty"`
+	CreatorID    string `json:"creator_id,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Image        string `json:"image,omitempty"`
+	ImageAlt     string `json:"image_alt,omitempty"`
+	Player       string `json:"player,omitempty"`
+	PlayerWidth  int    `json:"player_width,omitempty"`
+	PlayerHeight int    `json:"player_height,omitempty"`
+	PlayerStream string `json:"player_stream,omitempty"`
+
+	// Properties holds the values of all the twitter:* properties, e.g. the
+	// twitter:app:* properties of app cards.
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// TwitterCard returns the Twitter Card properties of the document, or nil if
+// it has none. Properties are read from the name attribute of meta tags, or
+// their property attribute. The first value of each property is kept.
+func (d *Document) TwitterCard() *TwitterCard {
+	return memoize(d, "twitterCard", func() *TwitterCard {
+		properties := map[string]string{}
+		for _, meta := range d.Meta() {
+			name := strings.ToLower(strings.TrimSpace(meta.Name))
+			if !strings.HasPrefix(name, "twitter:") {
+				name = strings.ToLower(strings.TrimSpace(meta.Property))
+			}
+			if !strings.HasPrefix(name, "twitter:") {
+				continue
+			}
+			if _, exists := properties[name]; !exists {
+				properties[name] = strings.TrimSpace(meta.Content)
+			}
+		}
+		if len(properties) == 0 {
+			return nil
+		}
+		card := &TwitterCard{
+			Card:         properties["twitter:card"],
+			Site:         properties["twitter:site"],
+			SiteID:       properties["twitter:site:id"],
+			Creator:      properties["twitter:creator"],
+			CreatorID:    properties["twitter:creator:id"],
+			Title:        NormalizeText(properties["twitter:title"]),
+			Description:  NormalizeText(properties["twitter:description"]),
+			Image:        properties["twitter:image"],
+			ImageAlt:     NormalizeText(properties["twitter:image:alt"]),
+			Player:       properties["twitter:player"],
+			PlayerStream: properties["twitter:player:stream"],
+			Properties:   properties,
+		}
+		fillString(&card.Image, properties["twitter:image:src"])
+		card.PlayerWidth, _ = strconv.Atoi(properties["twitter:player:width"])
+		card.PlayerHeight, _ = strconv.Atoi(properties["twitter:player:height"])
+		return card
+	})
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_OpenGraph(t *testing.T) {
+	doc, err := NewDocument(`<html><head>
+		<meta property="og:type" content="article">
+		<meta property="og:title" content="Rates rise">
+		<meta property="og:site_name" content="Daily">
+		<meta property="og:url" content="https://daily.example.com/rates">
+		<meta property="og:locale" content="en_US">
+		<meta property="og:locale:alternate" content="fr_FR">
+		<meta property="og:image" content="https://daily.example.com/a.jpg">
+		<meta property="og:image:url" content="https://daily.example.com/a.jpg">
+		<meta property="og:image:width" content="1200">
+		<meta property="og:image:height" content="630">
+		<meta property="og:image:alt" content="A chart">
+		<meta property="og:image" content="https://daily.example.com/b.jpg">
+		<meta property="og:video:url" content="https://daily.example.com/v.mp4">
+		<meta property="og:video:type" content="video/mp4">
+		<meta name="og:description" content="Central banks act.">
+		<meta property="article:section" content="Economy">
+		<meta property="article:tag" content="rates">
+		<meta property="article:tag" content="banks">
+		<meta property="product:price:amount" content="1">
+	</head></html>`)
+	require.NoError(t, err)
+
+	og := doc.OpenGraph()
+	require.NotNil(t, og)
+	require.Equal(t, "article", og.Type)
+	require.Equal(t, "Rates rise", og.Title)
+	require.Equal(t, "Central banks act.", og.Description)
+	require.Equal(t, "Daily", og.SiteName)
+	require.Equal(t, "https://daily.example.com/rates", og.URL)
+	require.Equal(t, "en_US", og.Locale)
+	require.Equal(t, []string{"fr_FR"}, og.LocaleAlternates)
+	require.Equal(t, []*OpenGraphMedia{
+		{URL: "https://daily.example.com/a.jpg", Width: 1200, Height: 630, Alt: "A chart"},
+		{URL: "https://daily.example.com/b.jpg"},
+	}, og.Images)
+	require.Equal(t, []*OpenGraphMedia{{URL: "https://daily.example.com/v.mp4", Type: "video/mp4"}}, og.Videos)
+	require.Equal(t, "Economy", og.Property("article:section"))
+	require.Equal(t, []string{"rates", "banks"}, og.Properties["article:tag"])
+	// Properties of other namespaces are ignored
+	require.NotContains(t, og.Properties, "product:price:amount")
+
+	doc, err = NewDocument(`<html><head><title>Plain</title></head></html>`)
+	require.NoError(t, err)
+	require.Nil(t, doc.OpenGraph())
+	require.Nil(t, doc.TwitterCard())
+}
+
+func TestDocument_TwitterCard(t *testing.T) {
+	doc, err := NewDocument(`<html><head>
+		<meta name="twitter:card" content="player">
+		<meta name="twitter:site" content="@daily">
+		<meta name="twitter:creator" content="@jo">
+		<meta property="twitter:title" content="Watch">
+		<meta name="twitter:image:src" content="https://daily.example.com/a.jpg">
+		<meta name="twitter:player" content="https://daily.example.com/embed">
+		<meta name="twitter:player:width" content="480">
+		<meta name="twitter:player:height" content="270">
+		<meta name="twitter:app:id:iphone" content="123">
+	</head></html>`)
+	require.NoError(t, err)
+
+	card := doc.TwitterCard()
+	require.NotNil(t, card)
+	require.Equal(t, "player", card.Card)
+	require.Equal(t, "@daily", card.Site)
+	require.Equal(t, "@jo", card.Creator)
+	require.Equal(t, "Watch", card.Title)
+	require.Equal(t, "https://daily.example.com/a.jpg", card.Image)
+	require.Equal(t, "https://daily.example.com/embed", card.Player)
+	require.Equal(t, 480, card.PlayerWidth)
+	require.Equal(t, 270, card.PlayerHeight)
+	require.Equal(t, "123", card.Properties["twitter:app:id:iphone"])
+}
@@ -0,0 +1,59 @@
+package web
+
+// OrganizationTypes are the schema.org types treated as organizations.
+var OrganizationTypes = []string{
+	"Organization",
+	"Corporation",
+	"EducationalOrganization",
+	"GovernmentOrganization",
+	"LocalBusiness",
+	"NGO",
+	"NewsMediaOrganization",
+	"OnlineBusiness",
+	"OnlineStore",
+	"Store",
+}
+
+// Organization contains schema.org Organization data extracted from a page.
+type Organization struct {
+	Type        string   `json:"type,omitempty"` // e.g. "NewsMediaOrganization"
+	Name        string   `json:"name,omitempty"`
+	LegalName   string   `json:"legal_name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Logo        string   `json:"logo,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Telephone   string   `json:"telephone,omitempty"`
+	Address     string   `json:"address,omitempty"`
+	SameAs      []string `json:"same_as,omitempty"` // e.g. social profiles
+}
+
+// ExtractOrganizations returns the organizations described by the top-level
+// structured data items of the document. Organizations nested in other
+// items, such as the publisher of an article, are not included.
+func ExtractOrganizations(doc *Document) []*Organization {
+	var organizations []*Organization
+	for _, item := range doc.StructuredItems() {
+		if !hasSchemaType(item, OrganizationTypes...) {
+			continue
+		}
+		organization := &Organization{
+			Type:        SchemaTypes(item)[0],
+			Name:        schemaString(item["name"]),
+			LegalName:   schemaString(item["legalName"]),
+			Description: schemaString(item["description"]),
+			URL:         schemaString(item["url"]),
+			Email:       schemaString(item["email"]),
+			Telephone:   schemaString(item["telephone"]),
+			Address:     schemaAddress(item["address"]),
+			SameAs:      schemaURLs(item["sameAs"]),
+		}
+		if logos := schemaURLs(item["logo"]); len(logos) > 0 {
+			organization.Logo = logos[0]
+		}
+		if organization.Name != "" || organization.URL != "" {
+			organizations = append(organizations, organization)
+		}
+	}
+	return organizations
+}
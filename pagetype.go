@@ -0,0 +1,143 @@
+package web
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PageType is the kind of a page, as determined by ClassifyPage.
+type PageType string
+
+const (
+	PageUnknown PageType = ""
+	PageArticle PageType = "article"
+	PageProduct PageType = "product"
+	PageListing PageType = "listing" // e.g. categories, archives and tags
+	PageSearch  PageType = "search"  // search results
+	PageLogin   PageType = "login"
+	PageError   PageType = "error" // e.g. not found pages served as 200
+)
+
+// PageTypes are the page types returned by ClassifyPage, other than
+// PageUnknown.
+var PageTypes = []PageType{PageArticle, PageProduct, PageListing, PageSearch, PageLogin, PageError}
+
+// errorPagePhrases are phrases of the title or heading of error pages.
+var errorPagePhrases = []string{
+	"page not found",
+	"404 not found",
+	"error 404",
+	"404 error",
+	"not found (404)",
+	"page does not exist",
+	"page doesn't exist",
+	"page cannot be found",
+	"page can't be found",
+	"page could not be found",
+	"internal server error",
+	"service unavailable",
+	"access denied",
+}
+
+// URL patterns of page types, matched against the path of the page URL.
+var (
+	loginPathPattern   = regexp.MustCompile(`(?i)/(log-?in|sign-?in|auth|account/login|session/new)(/|\.\w+)?$`)
+	searchPathPattern  = regexp.MustCompile(`(?i)/(search|results)(/|\.\w+)?$`)
+	productPathPattern = regexp.MustCompile(`(?i)/(products?|p|dp|item|items|shop/[^/]+)/[^/]+`)
+	listingPathPattern = regexp.MustCompile(`(?i)/(category|categories|collections?|tags?|topics?|archives?|page/\d+)(/|$)`)
+	articlePathPattern = regexp.MustCompile(`(?i)/((19|20)\d\d/\d\d(/\d\d)?/[^/]+|(blog|news|articles?|posts?|stories)/[^/]+)`)
+)
+
+// searchQueryParams are the query parameters of search URLs.
+var searchQueryParams = []string{"q", "query", "search", "s", "keyword", "keywords"}
+
+// ClassifyPage returns the type of the page, or PageUnknown. The URL of the
+// page is taken from its canonical URL or og:url; use ClassifyPageURL when
+// the URL of the page is known.
+func ClassifyPage(doc *Document) PageType {
+	pageURL := doc.CanonicalURL()
+	if pageURL == "" {
+		pageURL = doc.metaContent("og:url")
+	}
+	return ClassifyPageURL(doc, pageURL)
+}
+
+// ClassifyPageURL returns the type of the page at the given URL, or
+// PageUnknown. Error pages are detected from their title and heading, login
+// pages from their password field, then types are determined from the
+// structured data of the page, its Open Graph type, its URL and finally its
+// DOM, e.g. repeated article elements for listings.
+func ClassifyPageURL(doc *Document, pageURL string) PageType {
+	var path string
+	var query url.Values
+	if u, err := url.Parse(pageURL); err == nil {
+		path, query = u.Path, u.Query()
+	}
+
+	if isErrorPage(doc) {
+		return PageError
+	}
+	passwords := len(doc.doc.Find(`input[type="password" i]`).Nodes)
+	if passwords == 1 || (passwords > 0 && loginPathPattern.MatchString(path)) {
+		return PageLogin
+	}
+
+	items := doc.StructuredItems()
+	if len(FindStructuredItems(items, "SearchResultsPage")) > 0 || isSearchURL(path, query) {
+		return PageSearch
+	}
+	if len(FindStructuredItems(items, "Product", "ProductGroup")) > 0 {
+		return PageProduct
+	}
+	if len(FindStructuredItems(items, ArticleTypes...)) > 0 {
+		return PageArticle
+	}
+	if len(FindStructuredItems(items, "CollectionPage", "ItemList", "OfferCatalog")) > 0 {
+		return PageListing
+	}
+	switch ogType := strings.ToLower(doc.metaContent("og:type")); {
+	case ogType == "product" || strings.HasPrefix(ogType, "product."):
+		return PageProduct
+	case ogType == "article":
+		return PageArticle
+	}
+
+	articles := len(doc.doc.Find("article").Nodes)
+	switch {
+	case articles >= 3 || listingPathPattern.MatchString(path):
+		return PageListing
+	case productPathPattern.MatchString(path) && doc.metaContent("product:price:amount", "og:price:amount") != "":
+		return PageProduct
+	case articles == 1 || articlePathPattern.MatchString(path):
+		return PageArticle
+	}
+	return PageUnknown
+}
+
+// isErrorPage returns true if the title or main heading of the page says it
+// is an error page.
+func isErrorPage(doc *Document) bool {
+	for _, text := range []string{doc.Title(), doc.H1()} {
+		text = strings.ToLower(text)
+		for _, phrase := range errorPagePhrases {
+			if strings.Contains(text, phrase) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSearchURL returns true if the URL is that of search results.
+func isSearchURL(path string, query url.Values) bool {
+	if searchPathPattern.MatchString(path) {
+		return true
+	}
+	for _, param := range searchQueryParams {
+		if query.Get(param) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyPageURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		pageURL string
+		want    PageType
+	}{
+		{
+			name: "error title",
+			html: `<html><head><title>Page Not Found | Shop</title></head><body><article>x</article></body></html>`,
+			want: PageError,
+		},
+		{
+			name: "login form",
+			html: `<form><input name="user"><input type="password" name="pass"></form>`,
+			want: PageLogin,
+		},
+		{
+			name: "signup form is not a login page",
+			html: `<form><input type="password"><input type="password"></form>`,
+			want: PageUnknown,
+		},
+		{
+			name:    "search query",
+			html:    `<p>Results</p>`,
+			pageURL: "https://example.com/find?q=shoes",
+			want:    PageSearch,
+		},
+		{
+			name: "structured product",
+			html: `<script type="application/ld+json">{"@type": "Product", "name": "Widget"}</script>`,
+			want: PageProduct,
+		},
+		{
+			name: "structured article",
+			html: `<script type="application/ld+json">{"@type": "NewsArticle", "headline": "Hi"}</script>`,
+			want: PageArticle,
+		},
+		{
+			name: "structured listing",
+			html: `<script type="application/ld+json">{"@type": "CollectionPage"}</script>`,
+			want: PageListing,
+		},
+		{
+			name: "open graph product",
+			html: `<meta property="og:type" content="product.item">`,
+			want: PageProduct,
+		},
+		{
+			name: "repeated articles",
+			html: `<article>a</article><article>b</article><article>c</article>`,
+			want: PageListing,
+		},
+		{
+			name:    "category URL",
+			html:    `<p>Shoes</p>`,
+			pageURL: "https://example.com/category/shoes",
+			want:    PageListing,
+		},
+		{
+			name:    "product URL with price",
+			html:    `<meta property="product:price:amount" content="10">`,
+			pageURL: "https://example.com/products/widget",
+			want:    PageProduct,
+		},
+		{
+			name:    "dated URL",
+			html:    `<p>Post</p>`,
+			pageURL: "https://example.com/2024/05/01/launch",
+			want:    PageArticle,
+		},
+		{
+			name:    "unknown",
+			html:    `<p>About us</p>`,
+			pageURL: "https://example.com/about",
+			want:    PageUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewDocument(tt.html)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, ClassifyPageURL(doc, tt.pageURL))
+		})
+	}
+}
+
+func TestClassifyPage(t *testing.T) {
+	doc, err := NewDocument(`<html><head><link rel="canonical" href="https://example.com/search?q=shoes"></head></html>`)
+	require.NoError(t, err)
+	require.Equal(t, PageSearch, ClassifyPage(doc))
+}
@@ -0,0 +1,197 @@
+package web
+
+import (
+	"strings"
+)
+
+// Product contains schema.org Product data extracted from a page.
+type Product struct {
+	Name         string   `json:"name,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Brand        string   `json:"brand,omitempty"`
+	SKU          string   `json:"sku,omitempty"`
+	GTIN         string   `json:"gtin,omitempty"`
+	URL          string   `json:"url,omitempty"`
+	Images       []string `json:"images,omitempty"`
+	Price        float64  `json:"price,omitempty"`
+	HighPrice    float64  `json:"high_price,omitempty"` // Set for price ranges
+	Currency     string   `json:"currency,omitempty"`
+	Availability string   `json:"availability,omitempty"` // e.g. "InStock"
+	Condition    string   `json:"condition,omitempty"`    // e.g. "NewCondition"
+	Rating       float64  `json:"rating,omitempty"`
+	ReviewCount  int      `json:"review_count,omitempty"`
+}
+
+// ExtractProduct returns the product described by the document, or nil if
+// the page does not describe a product. JSON-LD is preferred, then microdata,
+// with Open Graph product tags and the page heading filling any gaps.
+func ExtractProduct(doc *Document) *Product {
+	jsonld := FindStructuredItems(doc.JSONLD(), "Product", "ProductGroup")
+	microdata := FindStructuredItems(doc.Microdata(), "Product", "ProductGroup")
+	var product *Product
+	for _, item := range append(jsonld, microdata...) {
+		candidate := productFromItem(item)
+		if product == nil {
+			product = candidate
+		} else if candidate.Name == "" || strings.EqualFold(candidate.Name, product.Name) {
+			// The same product may be described in more than one syntax,
+			// but other products on the page must not be mixed in
+			product.merge(candidate)
+		}
+	}
+	fallback := productFromMeta(doc)
+	if product == nil {
+		if fallback.Price == 0 && fallback.Availability == "" {
+			return nil
+		}
+		product = fallback
+		product.Name = doc.Title()
+		if h1 := doc.H1(); h1 != "" {
+			product.Name = h1
+		}
+		product.Description = doc.Description()
+		return product
+	}
+	product.merge(fallback)
+	return product
+}
+
+func productFromItem(item map[string]any) *Product {
+	product := &Product{
+		Name:        schemaString(item["name"]),
+		Description: schemaString(item["description"]),
+		Brand:       schemaString(item["brand"]),
+		SKU:         schemaString(item["sku"]),
+		URL:         schemaString(item["url"]),
+		Images:      schemaURLs(item["image"]),
+	}
+	for _, key := range []string{"gtin", "gtin13", "gtin12", "gtin14", "gtin8"} {
+		if product.GTIN == "" {
+			product.GTIN = schemaString(item[key])
+		}
+	}
+	if product.Brand == "" {
+		product.Brand = schemaString(item["manufacturer"])
+	}
+	if offers := firstOffer(item["offers"]); offers != nil {
+		product.Price = schemaNumber(offers["price"])
+		if product.Price == 0 {
+			product.Price = schemaNumber(offers["lowPrice"])
+		}
+		product.HighPrice = schemaNumber(offers["highPrice"])
+		product.Currency = strings.ToUpper(schemaString(offers["priceCurrency"]))
+		product.Availability = schemaEnum(offers["availability"])
+		product.Condition = schemaEnum(offers["itemCondition"])
+		if spec, ok := offers["priceSpecification"].(map[string]any); ok {
+			if product.Price == 0 {
+				product.Price = schemaNumber(spec["price"])
+			}
+			if product.Currency == "" {
+				product.Currency = strings.ToUpper(schemaString(spec["priceCurrency"]))
+			}
+		}
+	}
+	product.Rating, product.ReviewCount = aggregateRating(item["aggregateRating"])
+	return product
+}
+
+// firstOffer returns the first offer of an offers value, which may be a
+// single Offer, an AggregateOffer or a list of offers.
+func firstOffer(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		if v["price"] == nil && v["lowPrice"] == nil && v["priceSpecification"] == nil {
+			if nested := firstOffer(v["offers"]); nested != nil {
+				return nested
+			}
+		}
+		return v
+	case []any:
+		for _, item := range v {
+			if offer := firstOffer(item); offer != nil {
+				return offer
+			}
+		}
+	}
+	return nil
+}
+
+// productFromMeta reads the Open Graph and Facebook product meta tags.
+func productFromMeta(doc *Document) *Product {
+	return &Product{
+		Brand:        doc.metaContent("product:brand", "og:brand"),
+		Price:        parseNumber(doc.metaContent("product:price:amount", "og:price:amount")),
+		Currency:     strings.ToUpper(doc.metaContent("product:price:currency", "og:price:currency")),
+		Availability: normalizeAvailability(doc.metaContent("product:availability", "og:availability")),
+		Condition:    doc.metaContent("product:condition"),
+		SKU:          doc.metaContent("product:retailer_item_id"),
+		Images:       nonEmpty(doc.Image()),
+	}
+}
+
+// normalizeAvailability maps Open Graph availability values such as
+// "in stock" to their schema.org equivalents.
+func normalizeAvailability(value string) string {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(value), "_", " ")) {
+	case "":
+		return ""
+	case "in stock", "instock":
+		return "InStock"
+	case "out of stock", "oos", "outofstock":
+		return "OutOfStock"
+	case "preorder", "pre-order":
+		return "PreOrder"
+	case "available for order", "backorder":
+		return "BackOrder"
+	case "discontinued":
+		return "Discontinued"
+	}
+	return schemaEnum(value)
+}
+
+// merge fills empty fields of the product from another product.
+func (p *Product) merge(other *Product) {
+	if other == nil {
+		return
+	}
+	fillString(&p.Name, other.Name)
+	fillString(&p.Description, other.Description)
+	fillString(&p.Brand, other.Brand)
+	fillString(&p.SKU, other.SKU)
+	fillString(&p.GTIN, other.GTIN)
+	fillString(&p.URL, other.URL)
+	fillString(&p.Currency, other.Currency)
+	fillString(&p.Availability, other.Availability)
+	fillString(&p.Condition, other.Condition)
+	if len(p.Images) == 0 {
+		p.Images = other.Images
+	}
+	if p.Price == 0 {
+		p.Price = other.Price
+	}
+	if p.HighPrice == 0 {
+		p.HighPrice = other.HighPrice
+	}
+	if p.Rating == 0 {
+		p.Rating = other.Rating
+	}
+	if p.ReviewCount == 0 {
+		p.ReviewCount = other.ReviewCount
+	}
+}
+
+func fillString(dst *string, value string) {
+	if *dst == "" {
+		*dst = value
+	}
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractProduct_JSONLD(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<script type="application/ld+json">
+			{
+				"@context": "https://schema.org",
+				"@graph": [
+					{"@type": "WebPage", "name": "Shop"},
+					{
+						"@type": "Product",
+						"name": "Trail Shoe",
+						"image": [{"@type": "ImageObject", "url": "https://example.com/shoe.jpg"}, "https://example.com/shoe-2.jpg"],
+						"brand": {"@type": "Brand", "name": "Acme"},
+						"sku": "TS-1",
+						"gtin13": "0123456789012",
+						"offers": {
+							"@type": "Offer",
+							"price": "1,299.50",
+							"priceCurrency": "usd",
+							"availability": "https://schema.org/InStock"
+						},
+						"aggregateRating": {"@type": "AggregateRating", "ratingValue": 4.6, "reviewCount": "87"}
+					}
+				]
+			}
+			</script>
+		</head></html>
+	`)
+	require.NoError(t, err)
+
+	product := ExtractProduct(doc)
+	require.NotNil(t, product)
+	require.Equal(t, &Product{
+		Name:         "Trail Shoe",
+		Brand:        "Acme",
+		SKU:          "TS-1",
+		GTIN:         "0123456789012",
+		Images:       []string{"https://example.com/shoe.jpg", "https://example.com/shoe-2.jpg"},
+		Price:        1299.5,
+		Currency:     "USD",
+		Availability: "InStock",
+		Rating:       4.6,
+		ReviewCount:  87,
+	}, product)
+}
+
+func TestExtractProduct_Microdata(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><body>
+			<div itemscope itemtype="https://schema.org/Product">
+				<h1 itemprop="name">Desk Lamp</h1>
+				<img itemprop="image" src="/lamp.jpg">
+				<div itemprop="offers" itemscope itemtype="https://schema.org/AggregateOffer">
+					<meta itemprop="priceCurrency" content="EUR">
+					<span itemprop="lowPrice">19.99</span> - <span itemprop="highPrice">29.99</span>
+					<link itemprop="availability" href="https://schema.org/OutOfStock">
+				</div>
+			</div>
+		</body></html>
+	`)
+	require.NoError(t, err)
+
+	product := ExtractProduct(doc)
+	require.NotNil(t, product)
+	require.Equal(t, "Desk Lamp", product.Name)
+	require.Equal(t, []string{"/lamp.jpg"}, product.Images)
+	require.Equal(t, 19.99, product.Price)
+	require.Equal(t, 29.99, product.HighPrice)
+	require.Equal(t, "EUR", product.Currency)
+	require.Equal(t, "OutOfStock", product.Availability)
+}
+
+func TestExtractProduct_MetaFallback(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<title>Mug | Shop</title>
+			<meta property="og:image" content="https://example.com/mug.jpg">
+			<meta property="product:price:amount" content="12.00">
+			<meta property="product:price:currency" content="GBP">
+			<meta property="product:availability" content="in stock">
+		</head><body><h1>Mug</h1></body></html>
+	`)
+	require.NoError(t, err)
+
+	product := ExtractProduct(doc)
+	require.NotNil(t, product)
+	require.Equal(t, "Mug", product.Name)
+	require.Equal(t, 12.0, product.Price)
+	require.Equal(t, "GBP", product.Currency)
+	require.Equal(t, "InStock", product.Availability)
+	require.Equal(t, []string{"https://example.com/mug.jpg"}, product.Images)
+
+	doc, err = NewDocument(`<html><head><title>About</title></head></html>`)
+	require.NoError(t, err)
+	require.Nil(t, ExtractProduct(doc))
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recipe contains schema.org Recipe data extracted from a page.
+type Recipe struct {
+	Name          string            `json:"name,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	Authors       []string          `json:"authors,omitempty"`
+	Images        []string          `json:"images,omitempty"`
+	DatePublished time.Time         `json:"date_published,omitzero"`
+	Category      string            `json:"category,omitempty"`
+	Cuisine       string            `json:"cuisine,omitempty"`
+	Yield         string            `json:"yield,omitempty"`
+	PrepTime      time.Duration     `json:"prep_time,omitempty"`
+	CookTime      time.Duration     `json:"cook_time,omitempty"`
+	TotalTime     time.Duration     `json:"total_time,omitempty"`
+	Ingredients   []string          `json:"ingredients,omitempty"`
+	Instructions  []string          `json:"instructions,omitempty"`
+	Nutrition     map[string]string `json:"nutrition,omitempty"` // e.g. "calories": "240 kcal"
+	Keywords      []string          `json:"keywords,omitempty"`
+	Rating        float64           `json:"rating,omitempty"`
+	ReviewCount   int               `json:"review_count,omitempty"`
+}
+
+// ExtractRecipe returns the recipe described by the document's structured
+// data, or nil if there is none.
+func ExtractRecipe(doc *Document) *Recipe {
+	items := FindStructuredItems(doc.StructuredItems(), "Recipe")
+	if len(items) == 0 {
+		return nil
+	}
+	item := items[0]
+	recipe := &Recipe{
+		Name:          schemaString(item["name"]),
+		Description:   schemaString(item["description"]),
+		Authors:       schemaStrings(item["author"]),
+		Images:        schemaURLs(item["image"]),
+		DatePublished: ParseTime(schemaString(item["datePublished"])),
+		Category:      strings.Join(schemaStrings(item["recipeCategory"]), ", "),
+		Cuisine:       strings.Join(schemaStrings(item["recipeCuisine"]), ", "),
+		PrepTime:      ParseISODuration(schemaString(item["prepTime"])),
+		CookTime:      ParseISODuration(schemaString(item["cookTime"])),
+		TotalTime:     ParseISODuration(schemaString(item["totalTime"])),
+		Ingredients:   schemaStrings(item["recipeIngredient"]),
+		Instructions:  howToSteps(item["recipeInstructions"]),
+		Keywords:      schemaKeywords(item["keywords"]),
+	}
+	if len(recipe.Ingredients) == 0 {
+		recipe.Ingredients = schemaStrings(item["ingredients"])
+	}
+	if yields := schemaStrings(item["recipeYield"]); len(yields) > 0 {
+		// Yields are often given both as a count and as a description
+		recipe.Yield = yields[len(yields)-1]
+	}
+	if recipe.TotalTime == 0 {
+		recipe.TotalTime = recipe.PrepTime + recipe.CookTime
+	}
+	if nutrition, ok := item["nutrition"].(map[string]any); ok {
+		recipe.Nutrition = map[string]string{}
+		for key, value := range nutrition {
+			if strings.HasPrefix(key, "@") {
+				continue
+			}
+			if s := schemaString(value); s != "" {
+				recipe.Nutrition[key] = s
+			}
+		}
+	}
+	recipe.Rating, recipe.ReviewCount = aggregateRating(item["aggregateRating"])
+	fillString(&recipe.Name, doc.Title())
+	return recipe
+}
+
+// howToSteps flattens recipe instructions, which may be a single string, a
+// list of strings, HowToStep objects or HowToSection objects containing steps.
+func howToSteps(value any) []string {
+	var steps []string
+	switch v := value.(type) {
+	case string:
+		for _, line := range strings.Split(v, "\n") {
+			if line = NormalizeText(line); line != "" {
+				steps = append(steps, line)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			steps = append(steps, howToSteps(item)...)
+		}
+	case map[string]any:
+		if elements, ok := v["itemListElement"]; ok {
+			return howToSteps(elements)
+		}
+		for _, key := range []string{"text", "name"} {
+			if s := schemaString(v[key]); s != "" {
+				return []string{s}
+			}
+		}
+	}
+	return steps
+}
+
+// aggregateRating returns the rating value and count of an AggregateRating.
+func aggregateRating(value any) (float64, int) {
+	rating, ok := value.(map[string]any)
+	if !ok {
+		return 0, 0
+	}
+	count := int(schemaNumber(rating["reviewCount"]))
+	if count == 0 {
+		count = int(schemaNumber(rating["ratingCount"]))
+	}
+	return schemaNumber(rating["ratingValue"]), count
+}
+
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)Y)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)W)?(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseISODuration parses an ISO 8601 duration such as "PT1H30M", as used by
+// schema.org. Years and months are approximated as 365 and 30 days. Returns
+// zero if the value is not a valid duration.
+func ParseISODuration(value string) time.Duration {
+	m := isoDurationPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(value)))
+	if m == nil {
+		return 0
+	}
+	units := []time.Duration{
+		365 * 24 * time.Hour,
+		30 * 24 * time.Hour,
+		7 * 24 * time.Hour,
+		24 * time.Hour,
+		time.Hour,
+		time.Minute,
+		time.Second,
+	}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		n, _ := strconv.ParseFloat(m[i+1], 64)
+		d += time.Duration(n * float64(unit))
+	}
+	return d
+}
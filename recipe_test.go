@@ -0,0 +1,74 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractRecipe(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<script type="application/ld+json">
+			{
+				"@context": "https://schema.org",
+				"@type": "Recipe",
+				"name": "Pancakes",
+				"author": {"@type": "Person", "name": "Kim"},
+				"recipeYield": ["4", "4 servings"],
+				"prepTime": "PT10M",
+				"cookTime": "PT20M",
+				"recipeIngredient": ["2 eggs", "1 cup flour"],
+				"recipeInstructions": [
+					{"@type": "HowToSection", "name": "Batter", "itemListElement": [
+						{"@type": "HowToStep", "text": "Whisk the eggs."},
+						{"@type": "HowToStep", "text": "Add the flour."}
+					]},
+					{"@type": "HowToStep", "text": "Fry."}
+				],
+				"nutrition": {"@type": "NutritionInformation", "calories": "240 kcal"},
+				"aggregateRating": {"@type": "AggregateRating", "ratingValue": "4.5", "ratingCount": "12"}
+			}
+			</script>
+		</head><body></body></html>
+	`)
+	require.NoError(t, err)
+
+	recipe := ExtractRecipe(doc)
+	require.NotNil(t, recipe)
+	require.Equal(t, "Pancakes", recipe.Name)
+	require.Equal(t, []string{"Kim"}, recipe.Authors)
+	require.Equal(t, "4 servings", recipe.Yield)
+	require.Equal(t, 10*time.Minute, recipe.PrepTime)
+	require.Equal(t, 30*time.Minute, recipe.TotalTime)
+	require.Equal(t, []string{"2 eggs", "1 cup flour"}, recipe.Ingredients)
+	require.Equal(t, []string{"Whisk the eggs.", "Add the flour.", "Fry."}, recipe.Instructions)
+	require.Equal(t, map[string]string{"calories": "240 kcal"}, recipe.Nutrition)
+	require.Equal(t, 4.5, recipe.Rating)
+	require.Equal(t, 12, recipe.ReviewCount)
+
+	doc, err = NewDocument(`<html><body><p>No recipe</p></body></html>`)
+	require.NoError(t, err)
+	require.Nil(t, ExtractRecipe(doc))
+}
+
+func TestParseISODuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"PT45M", 45 * time.Minute},
+		{"PT1H30M", 90 * time.Minute},
+		{"P1DT2H", 26 * time.Hour},
+		{"pt0.5h", 30 * time.Minute},
+		{"PT", 0},
+		{"45 minutes", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			require.Equal(t, tt.want, ParseISODuration(tt.value))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+)
+
+// RobotsDirectives are the indexing directives of a page, given by its robots
+// meta tag or X-Robots-Tag header.
+type RobotsDirectives struct {
+	NoIndex  bool // the page should not be indexed
+	NoFollow bool // the links of the page should not be followed
+}
+
+// robotsValueDirectives are the directives that take a value after a colon,
+// which are not to be confused with user agent prefixes.
+var robotsValueDirectives = map[string]bool{
+	"unavailable_after": true,
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+}
+
+// ParseRobotsDirectives parses the values of robots meta tags and X-Robots-Tag
+// headers, e.g. "noindex, nofollow". The "none" directive implies both
+// noindex and nofollow. Values scoped to a user agent, e.g.
+// "googlebot: noindex", are ignored.
+func ParseRobotsDirectives(values ...string) RobotsDirectives {
+	var directives RobotsDirectives
+	for _, value := range values {
+		if agent, _, ok := strings.Cut(value, ":"); ok {
+			agent = strings.ToLower(strings.TrimSpace(agent))
+			if !robotsValueDirectives[agent] && !strings.Contains(agent, ",") {
+				continue
+			}
+		}
+		for _, directive := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "noindex":
+				directives.NoIndex = true
+			case "nofollow":
+				directives.NoFollow = true
+			case "none":
+				directives.NoIndex = true
+				directives.NoFollow = true
+			}
+		}
+	}
+	return directives
+}
+
+// Reasons reported by IndexabilityVerdict.
+const (
+	ReasonInvalidURL     = "invalid url"
+	ReasonRobotsTxt      = "disallowed by robots.txt"
+	ReasonNoIndexMeta    = "noindex robots meta tag"
+	ReasonNoIndexHeader  = "noindex X-Robots-Tag header"
+	ReasonNoFollowMeta   = "nofollow robots meta tag"
+	ReasonNoFollowHeader = "nofollow X-Robots-Tag header"
+)
+
+// Indexability is the verdict of IndexabilityVerdict.
+type Indexability struct {
+	// Crawlable is true if robots.txt allows fetching the page.
+	Crawlable bool `json:"crawlable"`
+
+	// Indexable is true if the page is crawlable and not noindex.
+	Indexable bool `json:"indexable"`
+
+	// Follow is true if the page is crawlable and its links may be
+	// followed.
+	Follow bool `json:"follow"`
+
+	// Reasons explain why the page is not crawlable, indexable or followed.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// IndexabilityVerdict combines the robots.txt rules of a site and the robots
+// meta tag and X-Robots-Tag header of one of its pages into whether the page
+// may be crawled and indexed, and its links followed. The robots.txt rules
+// for all user agents apply, and a nil robotsTxt allows all pages. Pages
+// disallowed by robots.txt are neither indexable nor followed, as their
+// directives can't be fetched.
+func IndexabilityVerdict(pageURL string, robotsTxt *RobotsTxt, metaRobots, xRobotsTag string) Indexability {
+	verdict := Indexability{Crawlable: true, Indexable: true, Follow: true}
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return Indexability{Reasons: []string{ReasonInvalidURL}}
+	}
+	if robotsTxt != nil && !robotsTxt.Allowed("", u.RequestURI()) {
+		return Indexability{Reasons: []string{ReasonRobotsTxt}}
+	}
+	meta := ParseRobotsDirectives(metaRobots)
+	header := ParseRobotsDirectives(xRobotsTag)
+	if meta.NoIndex {
+		verdict.Indexable = false
+		verdict.Reasons = append(verdict.Reasons, ReasonNoIndexMeta)
+	}
+	if header.NoIndex {
+		verdict.Indexable = false
+		verdict.Reasons = append(verdict.Reasons, ReasonNoIndexHeader)
+	}
+	if meta.NoFollow {
+		verdict.Follow = false
+		verdict.Reasons = append(verdict.Reasons, ReasonNoFollowMeta)
+	}
+	if header.NoFollow {
+		verdict.Follow = false
+		verdict.Reasons = append(verdict.Reasons, ReasonNoFollowHeader)
+	}
+	return verdict
+}
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRobotsDirectives(t *testing.T) {
+	tests := []struct {
+		values   []string
+		expected RobotsDirectives
+	}{
+		{nil, RobotsDirectives{}},
+		{[]string{"index, follow"}, RobotsDirectives{}},
+		{[]string{"noindex"}, RobotsDirectives{NoIndex: true}},
+		{[]string{"NoFollow"}, RobotsDirectives{NoFollow: true}},
+		{[]string{"none"}, RobotsDirectives{NoIndex: true, NoFollow: true}},
+		{[]string{"noindex", "nofollow"}, RobotsDirectives{NoIndex: true, NoFollow: true}},
+		{[]string{"noarchive, unavailable_after: 25 Jun 2010 15:00:00 PST, nofollow"}, RobotsDirectives{NoFollow: true}},
+		{[]string{"max-snippet: 20, noindex"}, RobotsDirectives{NoIndex: true}},
+		{[]string{"googlebot: noindex"}, RobotsDirectives{}},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, ParseRobotsDirectives(tt.values...), tt.values)
+	}
+}
+
+func TestIndexabilityVerdict(t *testing.T) {
+	robotsTxt := ParseRobotsTxt([]byte("User-agent: *\nDisallow: /private\n"))
+	tests := []struct {
+		name       string
+		pageURL    string
+		robotsTxt  *RobotsTxt
+		metaRobots string
+		xRobotsTag string
+		expected   Indexability
+	}{
+		{"indexable", "https://example.com/", robotsTxt, "", "",
+			Indexability{Crawlable: true, Indexable: true, Follow: true}},
+		{"no robots.txt", "https://example.com/private", nil, "index, follow", "",
+			Indexability{Crawlable: true, Indexable: true, Follow: true}},
+		{"disallowed", "https://example.com/private?page=2", robotsTxt, "", "",
+			Indexability{Reasons: []string{ReasonRobotsTxt}}},
+		{"noindex meta", "https://example.com/a", robotsTxt, "noindex", "",
+			Indexability{Crawlable: true, Follow: true, Reasons: []string{ReasonNoIndexMeta}}},
+		{"none header", "https://example.com/a", robotsTxt, "", "none",
+			Indexability{Crawlable: true, Reasons: []string{ReasonNoIndexHeader, ReasonNoFollowHeader}}},
+		{"scoped header", "https://example.com/a", robotsTxt, "nofollow", "otherbot: noindex",
+			Indexability{Crawlable: true, Indexable: true, Reasons: []string{ReasonNoFollowMeta}}},
+		{"invalid url", "/relative", robotsTxt, "", "",
+			Indexability{Reasons: []string{ReasonInvalidURL}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, IndexabilityVerdict(tt.pageURL, tt.robotsTxt, tt.metaRobots, tt.xRobotsTag))
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package web
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RobotsTxt is a parsed robots.txt file, as specified by RFC 9309, with the
+// common Crawl-delay and Sitemap extensions.
+type RobotsTxt struct {
+	// Sitemaps are the URLs of the sitemaps listed in the file.
+	Sitemaps []string
+
+	groups []*robotsGroup
+}
+
+// robotsGroup is a group of rules for one or more user agents.
+type robotsGroup struct {
+	agents     []string // lowercase product tokens, or "*"
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// ParseRobotsTxt parses a robots.txt file. Invalid lines are ignored, so
+// parsing always succeeds.
+func ParseRobotsTxt(data []byte) *RobotsTxt {
+	robots := &RobotsTxt{}
+	var group *robotsGroup
+	groupStarted := false // rules were given for the current group
+	// The file is split in memory rather than scanned, so that lines of any
+	// length are parsed, such as long comments. Lines end with CR, LF or
+	// CRLF.
+	for len(data) > 0 {
+		end := bytes.IndexAny(data, "\r\n")
+		if end < 0 {
+			end = len(data)
+		}
+		text := string(data[:end])
+		data = data[min(end+1, len(data)):]
+		line, _, _ := strings.Cut(text, "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if group == nil || groupStarted {
+				group = &robotsGroup{}
+				robots.groups = append(robots.groups, group)
+				groupStarted = false
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if group == nil {
+				continue
+			}
+			groupStarted = true
+			if value != "" {
+				group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if group == nil {
+				continue
+			}
+			groupStarted = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				group.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		case "sitemap":
+			if value != "" {
+				robots.Sitemaps = append(robots.Sitemaps, value)
+			}
+		}
+	}
+	return robots
+}
+
+// matchingGroups returns the groups that apply to a user agent: those that
+// name its product token, or else those for all user agents.
+func (r *RobotsTxt) matchingGroups(userAgent string) []*robotsGroup {
+	token, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(userAgent)), "/")
+	var specific, wildcard []*robotsGroup
+	for _, group := range r.groups {
+		for _, agent := range group.agents {
+			if agent == "*" {
+				wildcard = append(wildcard, group)
+				break
+			}
+			if token != "" && token != "*" && agent == token {
+				specific = append(specific, group)
+				break
+			}
+		}
+	}
+	if len(specific) > 0 {
+		return specific
+	}
+	return wildcard
+}
+
+// Allowed returns true if a user agent may fetch the URL with the given path
+// and query. The longest matching rule applies, and allow rules win ties.
+// The user agent is a product token like "MyBot"; only the rules for all
+// user agents apply if it is empty.
+func (r *RobotsTxt) Allowed(userAgent, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	allowed, matched := true, -1
+	for _, group := range r.matchingGroups(userAgent) {
+		for _, rule := range group.rules {
+			if len(rule.pattern) < matched || !robotsMatch(rule.pattern, path) {
+				continue
+			}
+			if len(rule.pattern) > matched || rule.allow {
+				allowed = rule.allow
+			}
+			matched = len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay returns the delay a user agent should wait between requests, or
+// zero if the file doesn't set one.
+func (r *RobotsTxt) CrawlDelay(userAgent string) time.Duration {
+	for _, group := range r.matchingGroups(userAgent) {
+		if group.crawlDelay > 0 {
+			return group.crawlDelay
+		}
+	}
+	return 0
+}
+
+// robotsMatch returns true if the path matches a rule pattern, in which "*"
+// matches any sequence of characters and a final "$" the end of the path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	return true
+}
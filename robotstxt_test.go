@@ -0,0 +1,76 @@
+package web
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRobotsTxt(t *testing.T) {
+	robots := ParseRobotsTxt([]byte(`# Example
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow: /search?q=
+
+User-agent: MyBot
+User-agent: OtherBot
+Disallow: /admin   # comment
+Disallow:
+Crawl-delay: 2.5
+
+Sitemap: https://example.com/sitemap.xml
+`))
+	require.Equal(t, []string{"https://example.com/sitemap.xml"}, robots.Sitemaps)
+
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"", "/", true},
+		{"", "/private/page", false},
+		{"", "/private/public/page", true},
+		{"", "/docs/file.pdf", false},
+		{"", "/docs/file.pdf?download=1", true},
+		{"", "/search?q=go", false},
+		{"", "/robots.txt", true},
+		{"MyBot/1.0", "/private/page", true},
+		{"mybot", "/admin/users", false},
+		{"otherbot", "/admin", false},
+		{"UnknownBot", "/admin", true},
+		{"UnknownBot", "/private", false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.allowed, robots.Allowed(tt.agent, tt.path), "%s %s", tt.agent, tt.path)
+	}
+	require.Equal(t, 2500*time.Millisecond, robots.CrawlDelay("MyBot"))
+	require.Zero(t, robots.CrawlDelay("UnknownBot"))
+}
+
+func TestRobotsMatch(t *testing.T) {
+	require.True(t, robotsMatch("/fish", "/fish.html"))
+	require.True(t, robotsMatch("/fish*", "/fish/salmon"))
+	require.True(t, robotsMatch("/*.php", "/folder/index.php?x=1"))
+	require.False(t, robotsMatch("/*.php$", "/index.php?x=1"))
+	require.True(t, robotsMatch("/*.php$", "/index.php"))
+	require.True(t, robotsMatch("/fish$", "/fish"))
+	require.False(t, robotsMatch("/fish$", "/fishes"))
+	require.False(t, robotsMatch("/fish", "/Fish"))
+	require.True(t, robotsMatch("/a*b*c", "/axxbyyc"))
+	require.False(t, robotsMatch("/a*b*c", "/axxcyyb"))
+}
+
+func TestParseRobotsTxt_LongLines(t *testing.T) {
+	// Rules after lines longer than any read buffer still apply, with any
+	// line ending
+	data := "User-agent: *\r\n# " + strings.Repeat("x", 200*1024) + "\r\nDisallow: /private\rDisallow: /admin\nSitemap: https://example.com/sitemap.xml"
+	robots := ParseRobotsTxt([]byte(data))
+	require.False(t, robots.Allowed("", "/private/page"))
+	require.False(t, robots.Allowed("", "/admin"))
+	require.True(t, robots.Allowed("", "/public"))
+	require.Equal(t, []string{"https://example.com/sitemap.xml"}, robots.Sitemaps)
+}
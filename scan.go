@@ -0,0 +1,347 @@
+package web
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// ScanOptions configures ScanHTML.
+type ScanOptions struct {
+	// MetadataOnly stops scanning at the end of the head. Links, the heading
+	// and meta tags in the body are not extracted.
+	MetadataOnly bool
+}
+
+// ScanResult holds the metadata and links extracted by ScanHTML.
+type ScanResult struct {
+	Metadata Metadata
+	Links    []*Link
+}
+
+// ScanHTML extracts the metadata and links of a page using a streaming
+// tokenizer, without building a DOM. This is considerably faster than
+// NewDocument for callers that only need links and basic metadata.
+//
+// The results match those of Document.Metadata and Document.Links, except
+// that Link.InMainContent is true for links inside a main, article or
+// articleBody element rather than in the element with the most text.
+func ScanHTML(r io.Reader, opts ScanOptions) (*ScanResult, error) {
+	s := &scanner{links: []*Link{}, metas: []*Meta{}}
+	z := html.NewTokenizer(r)
+	for {
+		tokenType := z.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			return s.result(), nil
+		case html.TextToken:
+			s.text(string(z.Text()))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if opts.MetadataOnly && token.Data == "body" {
+				return s.result(), nil
+			}
+			s.startTag(token, tokenType == html.SelfClosingTagToken)
+		case html.EndTagToken:
+			token := z.Token()
+			if opts.MetadataOnly && token.Data == "head" {
+				return s.result(), nil
+			}
+			s.endTag(token.Data)
+		}
+	}
+}
+
+// ExtractMetadataFast extracts the metadata of a page from its head only,
+// using a streaming tokenizer, for pipelines that need the title, description
+// or canonical URL of many pages at high throughput. Metadata found in the
+// body, such as the heading, is not extracted. See ScanHTML.
+func ExtractMetadataFast(html []byte) (Metadata, error) {
+	result, err := ScanHTML(bytes.NewReader(html), ScanOptions{MetadataOnly: true})
+	if err != nil {
+		return Metadata{}, err
+	}
+	return result.Metadata, nil
+}
+
+// scanElement is an open element that affects the context of links.
+type scanElement struct {
+	tag      string
+	landmark string
+	main     bool
+	nested   int // open descendants with the same tag
+}
+
+type scanner struct {
+	metadata Metadata
+	metas    []*Meta
+	links    []*Link
+	elements []*scanElement
+
+	title, heading strings.Builder
+	titleSeen      bool
+	baseSeen       bool
+	inTitle        bool
+	inHeading      int
+	link           *Link
+	linkText       strings.Builder
+	linkRels       map[string]string
+}
+
+// lookup is the result of looking up an optional value.
+type lookup struct {
+	value string
+	ok    bool
+}
+
+func (s *scanner) text(value string) {
+	if s.inTitle {
+		s.title.WriteString(value)
+	}
+	if s.inHeading > 0 {
+		s.heading.WriteString(value)
+	}
+	if s.link != nil {
+		s.linkText.WriteString(value)
+	}
+}
+
+func (s *scanner) startTag(token html.Token, selfClosing bool) {
+	switch token.Data {
+	case "html":
+		if lang, ok := tokenAttr(token, "lang"); ok && s.metadata.Language == "" {
+			s.metadata.Language = strings.ToLower(strings.TrimSpace(lang))
+		}
+	case "title":
+		if !s.titleSeen {
+			s.titleSeen, s.inTitle = true, !selfClosing
+		}
+	case "meta":
+		meta := &Meta{Tag: "meta"}
+		meta.Name, _ = tokenAttr(token, "name")
+		meta.Property, _ = tokenAttr(token, "property")
+		meta.Content, _ = tokenAttr(token, "content")
+		meta.Charset, _ = tokenAttr(token, "charset")
+		s.metas = append(s.metas, meta)
+	case "link":
+		rel, _ := tokenAttr(token, "rel")
+		if _, exists := s.linkRels[rel]; !exists {
+			if s.linkRels == nil {
+				s.linkRels = map[string]string{}
+			}
+			href, _ := tokenAttr(token, "href")
+			s.linkRels[rel] = strings.TrimSpace(href)
+		}
+	case "base":
+		if href, ok := tokenAttr(token, "href"); ok && !s.baseSeen {
+			s.baseSeen = true
+			s.metadata.BaseURL = strings.TrimSpace(href)
+		}
+	case "h1":
+		if !selfClosing {
+			if s.inHeading == 0 {
+				s.heading.Reset()
+			}
+			s.inHeading++
+		}
+	case "a":
+		s.endLink()
+		href, _ := tokenAttr(token, "href")
+		if href == "" || selfClosing {
+			return
+		}
+		rel, _ := tokenAttr(token, "rel")
+		title, _ := tokenAttr(token, "title")
+		s.link = &Link{
+			URL:   href,
+			Rel:   strings.ToLower(strings.Join(strings.Fields(rel), " ")),
+			Title: strings.TrimSpace(title),
+		}
+		s.link.Container, s.link.InMainContent = s.linkContext()
+		s.linkText.Reset()
+	}
+	if selfClosing {
+		return
+	}
+	// Track elements that determine the context of links
+	for i := len(s.elements) - 1; i >= 0; i-- {
+		if s.elements[i].tag == token.Data {
+			s.elements[i].nested++
+			return
+		}
+	}
+	element := &scanElement{tag: token.Data}
+	switch token.Data {
+	case "nav", "header", "footer", "aside", "main", "article":
+		element.landmark = token.Data
+	}
+	if role, ok := tokenAttr(token, "role"); ok {
+		if landmark, ok := landmarkRoles[strings.ToLower(strings.TrimSpace(role))]; ok {
+			element.landmark = landmark
+		}
+	}
+	if itemprop, _ := tokenAttr(token, "itemprop"); itemprop == "articleBody" {
+		element.main = true
+	}
+	switch element.landmark {
+	case "main", "article":
+		element.main = true
+	}
+	if element.landmark != "" || element.main {
+		s.elements = append(s.elements, element)
+	}
+}
+
+func (s *scanner) endTag(tag string) {
+	switch tag {
+	case "title":
+		s.inTitle = false
+	case "h1":
+		if s.inHeading > 0 {
+			s.inHeading--
+			if s.inHeading == 0 {
+				s.metadata.Heading = NormalizeText(s.heading.String())
+			}
+		}
+	case "a":
+		s.endLink()
+	}
+	for i := len(s.elements) - 1; i >= 0; i-- {
+		if s.elements[i].tag != tag {
+			continue
+		}
+		if s.elements[i].nested > 0 {
+			s.elements[i].nested--
+		} else {
+			s.elements = s.elements[:i]
+		}
+		return
+	}
+}
+
+func (s *scanner) endLink() {
+	if s.link == nil {
+		return
+	}
+	s.link.Text = s.linkText.String()
+	s.links = append(s.links, s.link)
+	s.link = nil
+}
+
+// linkContext returns the nearest landmark of the open elements and whether
+// they place a link in the main content, as linkContext does for a DOM.
+func (s *scanner) linkContext() (string, bool) {
+	var container string
+	for i := len(s.elements) - 1; i >= 0; i-- {
+		element := s.elements[i]
+		if container == "" {
+			container = element.landmark
+		}
+		if element.main {
+			return container, true
+		}
+		switch element.landmark {
+		case "nav", "header", "footer", "aside":
+			return container, false
+		}
+	}
+	return container, false
+}
+
+func (s *scanner) result() *ScanResult {
+	s.endLink()
+	metadata := s.metadata
+	if s.titleSeen {
+		metadata.Title = NormalizeText(s.title.String())
+	} else {
+		metadata.Title = NormalizeText(either(s.meta("property", "og:title"), s.meta("name", "title")))
+	}
+	metadata.Description = NormalizeText(either(s.meta("name", "description"), s.meta("property", "og:description")))
+	metadata.Author = strings.TrimSpace(either(s.meta("name", "author"), s.meta("property", "og:author")))
+	metadata.Robots = strings.TrimSpace(either(s.meta("name", "robots")))
+	metadata.Image = strings.TrimSpace(either(s.meta("property", "og:image"), s.meta("property", "og:image:url")))
+	metadata.CanonicalURL = either(s.linkHref("canonical"))
+	metadata.Icon = either(s.linkHref("icon"), s.linkHref("shortcut icon"))
+	metadata.ManifestURL = either(s.linkHref("manifest"))
+	metadata.Keywords = []string{}
+	if keywords := s.meta("name", "keywords"); keywords.value != "" {
+		metadata.Keywords = parseKeywords(keywords.value)
+	} else if keywords := s.meta("property", "og:keywords"); keywords.ok {
+		metadata.Keywords = parseKeywords(keywords.value)
+	}
+	if value := s.publishedTime(); !value.IsZero() {
+		metadata.PublishedTime = value.Format(time.RFC3339)
+	}
+	metadata.Tags = s.metas
+	return &ScanResult{Metadata: metadata, Links: s.links}
+}
+
+// meta looks up the content of the first meta tag with the given name or
+// property.
+func (s *scanner) meta(key, value string) lookup {
+	for _, meta := range s.metas {
+		if (key == "name" && meta.Name == value) || (key == "property" && meta.Property == value) {
+			return lookup{meta.Content, true}
+		}
+	}
+	return lookup{}
+}
+
+// linkHref looks up the href of the first link element with the given rel.
+func (s *scanner) linkHref(rel string) lookup {
+	href, ok := s.linkRels[rel]
+	return lookup{href, ok}
+}
+
+// publishedTime returns the last published time meta tag, checking the
+// same tags in the same order as Document.PublishedTime.
+func (s *scanner) publishedTime() time.Time {
+	var timeStr string
+	for _, meta := range s.metas {
+		if meta.Name == "article:published_time" {
+			timeStr = strings.TrimSpace(meta.Content)
+		}
+	}
+	if timeStr == "" {
+		for _, meta := range s.metas {
+			if meta.Property == "article:published_time" {
+				timeStr = strings.TrimSpace(meta.Content)
+			}
+		}
+	}
+	if timeStr == "" {
+		for _, meta := range s.metas {
+			if meta.Property == "og:published_time" {
+				timeStr = strings.TrimSpace(meta.Content)
+			}
+		}
+	}
+	value, _ := time.Parse(time.RFC3339, timeStr)
+	return value
+}
+
+func tokenAttr(token html.Token, key string) (string, bool) {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// either returns the value of the first lookup that found a value, like the
+// fallbacks of the Document accessors.
+func either(lookups ...lookup) string {
+	for _, l := range lookups {
+		if l.ok {
+			return l.value
+		}
+	}
+	return ""
+}
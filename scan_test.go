@@ -0,0 +1,113 @@
+package web
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const scanTestHTML = `<!DOCTYPE html>
+<html lang="EN">
+<head>
+	<title> The Page &amp; More </title>
+	<meta charset="utf-8">
+	<meta name="description" content="A page  description">
+	<meta name="keywords" content="go, html, parsing">
+	<meta property="og:image" content=" https://example.com/image.png ">
+	<meta name="author" content="Jane">
+	<meta property="article:published_time" content="2024-05-01T10:00:00Z">
+	<link rel="canonical" href="https://example.com/page">
+	<link rel="shortcut icon" href="/favicon.ico">
+	<link rel="manifest" href="/manifest.json">
+	<base href="/docs/">
+</head>
+<body>
+	<header><a href="/">Home</a></header>
+	<div role="navigation"><div><a href="/docs">Docs</a></div></div>
+	<main>
+		<h1>The <em>Heading</em></h1>
+		<p>See the <a href="/guide" title=" The guide " rel="NoFollow  ugc">guide <b>here</b></a>.</p>
+		<aside><a href="/related">Related</a></aside>
+	</main>
+	<footer><a href="/privacy">Privacy</a></footer>
+	<a href="/loose">Loose</a>
+	<a name="anchor">No href</a>
+</body>
+</html>`
+
+func TestScanHTML(t *testing.T) {
+	doc, err := NewDocument(scanTestHTML)
+	require.NoError(t, err)
+
+	result, err := ScanHTML(strings.NewReader(scanTestHTML), ScanOptions{})
+	require.NoError(t, err)
+	require.Equal(t, doc.Metadata(), result.Metadata)
+	require.Equal(t, doc.Links(), result.Links)
+	require.Equal(t, "The Page & More", result.Metadata.Title)
+	require.Equal(t, "The Heading", result.Metadata.Heading)
+	require.Equal(t, "/docs/", result.Metadata.BaseURL)
+}
+
+func TestScanHTML_MetadataOnly(t *testing.T) {
+	result, err := ScanHTML(strings.NewReader(scanTestHTML), ScanOptions{MetadataOnly: true})
+	require.NoError(t, err)
+	require.Equal(t, "The Page & More", result.Metadata.Title)
+	require.Equal(t, "https://example.com/page", result.Metadata.CanonicalURL)
+	require.Equal(t, "/favicon.ico", result.Metadata.Icon)
+	require.Empty(t, result.Metadata.Heading)
+	require.Empty(t, result.Links)
+}
+
+func TestExtractMetadataFast(t *testing.T) {
+	metadata, err := ExtractMetadataFast([]byte(scanTestHTML))
+	require.NoError(t, err)
+	result, err := ScanHTML(strings.NewReader(scanTestHTML), ScanOptions{MetadataOnly: true})
+	require.NoError(t, err)
+	require.Equal(t, result.Metadata, metadata)
+	require.Equal(t, "The Page & More", metadata.Title)
+	require.Equal(t, "https://example.com/page", metadata.CanonicalURL)
+}
+
+func TestScanHTML_Fallbacks(t *testing.T) {
+	html := `<html><head>
+		<meta property="og:title" content="OG Title">
+		<meta property="og:description" content="OG description">
+		<meta property="og:keywords" content="a b">
+	</head><body><h1>First</h1><h1>Last</h1></body></html>`
+	doc, err := NewDocument(html)
+	require.NoError(t, err)
+
+	result, err := ScanHTML(strings.NewReader(html), ScanOptions{})
+	require.NoError(t, err)
+	require.Equal(t, doc.Metadata(), result.Metadata)
+	require.Equal(t, "OG Title", result.Metadata.Title)
+}
+
+func BenchmarkScanHTML(b *testing.B) {
+	html := strings.Repeat(scanTestHTML, 20)
+	b.Run("Document", func(b *testing.B) {
+		for b.Loop() {
+			doc, err := NewDocument(html)
+			if err != nil {
+				b.Fatal(err)
+			}
+			doc.Metadata()
+			doc.Links()
+		}
+	})
+	b.Run("Scan", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := ScanHTML(strings.NewReader(html), ScanOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ScanMetadataOnly", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := ScanHTML(strings.NewReader(html), ScanOptions{MetadataOnly: true}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
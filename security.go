@@ -0,0 +1,104 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// MixedContent is an http:// subresource of an https page. Browsers block
+// active mixed content and flag pages with passive mixed content as insecure.
+type MixedContent struct {
+	URL     string `json:"url"`
+	Element string `json:"element"`          // tag name of the referencing element
+	Active  bool   `json:"active,omitempty"` // scripts, stylesheets, frames and plugins
+}
+
+// subresourceAttributes are the attributes of elements that load
+// subresources, by tag.
+var subresourceAttributes = map[string][]string{
+	"script": {"src"}, "link": {"href"}, "iframe": {"src"}, "frame": {"src"},
+	"object": {"data"}, "embed": {"src"}, "img": {"src", "srcset"},
+	"source": {"src", "srcset"}, "audio": {"src"}, "video": {"src", "poster"},
+	"track": {"src"}, "input": {"src"},
+}
+
+// activeElements are the elements whose subresources are active content.
+var activeElements = map[string]bool{
+	"script": true, "iframe": true, "frame": true, "object": true,
+	"embed": true,
+}
+
+// subresourceLinkRels are the link relations that load subresources.
+var subresourceLinkRels = map[string]bool{
+	"stylesheet": true, "icon": true, "preload": true, "modulepreload": true,
+	"prefetch": true, "manifest": true, "apple-touch-icon": true,
+}
+
+// MixedContent returns the subresources of the document loaded over http
+// while the page itself is served over https, in document order. Relative
+// URLs are resolved against the page URL and the base element of the
+// document. Returns nil for http pages.
+func (d *Document) MixedContent(pageURL string) []*MixedContent {
+	page, err := url.Parse(pageURL)
+	if err != nil || !strings.EqualFold(page.Scheme, "https") {
+		return nil
+	}
+	base, err := url.Parse(ResolveBase(pageURL, d.BaseURL()))
+	if err != nil {
+		return nil
+	}
+	var mixed []*MixedContent
+	seen := map[string]bool{}
+	d.doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		tag := goquery.NodeName(s)
+		attrs, ok := subresourceAttributes[tag]
+		if !ok || (tag == "link" && !loadsSubresource(s)) ||
+			(tag == "input" && !strings.EqualFold(s.AttrOr("type", ""), "image")) {
+			return
+		}
+		active := activeElements[tag] || (tag == "link" && strings.Contains(strings.ToLower(s.AttrOr("rel", "")), "stylesheet"))
+		for _, attr := range attrs {
+			value := s.AttrOr(attr, "")
+			refs := []string{value}
+			if attr == "srcset" {
+				refs = srcsetURLs(value)
+			}
+			for _, ref := range refs {
+				ref = strings.TrimSpace(ref)
+				if ref == "" {
+					continue
+				}
+				u, err := base.Parse(ref)
+				if err != nil || !strings.EqualFold(u.Scheme, "http") || seen[u.String()] {
+					continue
+				}
+				seen[u.String()] = true
+				mixed = append(mixed, &MixedContent{URL: u.String(), Element: tag, Active: active})
+			}
+		}
+	})
+	return mixed
+}
+
+// loadsSubresource returns true if a link element loads a subresource.
+func loadsSubresource(s *goquery.Selection) bool {
+	for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+		if subresourceLinkRels[rel] {
+			return true
+		}
+	}
+	return false
+}
+
+// srcsetURLs returns the URLs of the candidates of a srcset attribute.
+func srcsetURLs(srcset string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
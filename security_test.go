@@ -0,0 +1,38 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_MixedContent(t *testing.T) {
+	doc, err := NewDocument(`<html><head>
+		<link rel="stylesheet" href="http://cdn.example.com/style.css">
+		<link rel="canonical" href="http://example.com/page">
+		<script src="//cdn.example.com/app.js"></script>
+		<script src="http://cdn.example.com/legacy.js"></script>
+	</head><body>
+		<img src="http://images.example.com/a.png" srcset="/b.png 2x, http://images.example.com/c.png 3x">
+		<img src="http://images.example.com/a.png">
+		<a href="http://other.com/">Link</a>
+		<iframe src="http://embed.example.com/"></iframe>
+	</body></html>`)
+	require.NoError(t, err)
+
+	require.Equal(t, []*MixedContent{
+		{URL: "http://cdn.example.com/style.css", Element: "link", Active: true},
+		{URL: "http://cdn.example.com/legacy.js", Element: "script", Active: true},
+		{URL: "http://images.example.com/a.png", Element: "img"},
+		{URL: "http://images.example.com/c.png", Element: "img"},
+		{URL: "http://embed.example.com/", Element: "iframe", Active: true},
+	}, doc.MixedContent("https://example.com/page"))
+
+	// Only https pages have mixed content
+	require.Nil(t, doc.MixedContent("http://example.com/page"))
+
+	// An http base element makes relative URLs insecure
+	doc, err = NewDocument(`<html><head><base href="http://example.com/"></head><body><img src="/logo.png"></body></html>`)
+	require.NoError(t, err)
+	require.Equal(t, []*MixedContent{{URL: "http://example.com/logo.png", Element: "img"}}, doc.MixedContent("https://example.com/"))
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SitemapURL is an entry of a sitemap: a page of a urlset, or a sitemap of a
+// sitemap index.
+type SitemapURL struct {
+	Loc        string    `json:"loc"`
+	LastMod    time.Time `json:"lastmod,omitzero"`
+	ChangeFreq string    `json:"changefreq,omitempty"`
+	Priority   float64   `json:"priority,omitempty"`
+}
+
+// Sitemap is a parsed sitemap, as specified by sitemaps.org.
+type Sitemap struct {
+	// URLs are the pages listed by a urlset or a text sitemap.
+	URLs []SitemapURL `json:"urls,omitempty"`
+
+	// Sitemaps are the sitemaps listed by a sitemap index.
+	Sitemaps []SitemapURL `json:"sitemaps,omitempty"`
+}
+
+// sitemapLastModLayouts are the W3C datetime formats of lastmod values.
+var sitemapLastModLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseSitemap parses an XML sitemap, either a urlset or a sitemap index, or
+// a text sitemap listing one URL per line. Gzipped sitemaps are decompressed.
+// Invalid lastmod and priority values are ignored.
+func ParseSitemap(data []byte) (*Sitemap, error) {
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzipped sitemap: %w", err)
+		}
+		if data, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("invalid gzipped sitemap: %w", err)
+		}
+	}
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		return parseTextSitemap(trimmed), nil
+	}
+
+	type entry struct {
+		Loc        string `xml:"loc"`
+		LastMod    string `xml:"lastmod"`
+		ChangeFreq string `xml:"changefreq"`
+		Priority   string `xml:"priority"`
+	}
+	var document struct {
+		XMLName  xml.Name
+		URLs     []entry `xml:"url"`
+		Sitemaps []entry `xml:"sitemap"`
+	}
+	if err := xml.Unmarshal(trimmed, &document); err != nil {
+		return nil, fmt.Errorf("invalid sitemap: %w", err)
+	}
+	switch document.XMLName.Local {
+	case "urlset", "sitemapindex":
+	default:
+		return nil, fmt.Errorf("invalid sitemap: unexpected root element %q", document.XMLName.Local)
+	}
+	convert := func(entries []entry) []SitemapURL {
+		var urls []SitemapURL
+		for _, e := range entries {
+			loc := strings.TrimSpace(e.Loc)
+			if loc == "" {
+				continue
+			}
+			u := SitemapURL{
+				Loc:        loc,
+				LastMod:    parseSitemapLastMod(e.LastMod),
+				ChangeFreq: strings.ToLower(strings.TrimSpace(e.ChangeFreq)),
+			}
+			if priority, err := strconv.ParseFloat(strings.TrimSpace(e.Priority), 64); err == nil {
+				u.Priority = priority
+			}
+			urls = append(urls, u)
+		}
+		return urls
+	}
+	return &Sitemap{URLs: convert(document.URLs), Sitemaps: convert(document.Sitemaps)}, nil
+}
+
+// parseTextSitemap parses a text sitemap, ignoring lines that are not http
+// or https URLs.
+func parseTextSitemap(data []byte) *Sitemap {
+	sitemap := &Sitemap{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			sitemap.URLs = append(sitemap.URLs, SitemapURL{Loc: line})
+		}
+	}
+	return sitemap
+}
+
+// parseSitemapLastMod parses a lastmod value, returning the zero time if it
+// is invalid.
+func parseSitemapLastMod(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range sitemapLastModLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSitemap(t *testing.T) {
+	sitemap, err := ParseSitemap([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc> https://example.com/ </loc>
+    <lastmod>2024-05-01</lastmod>
+    <changefreq>Daily</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url>
+    <loc>https://example.com/about</loc>
+    <lastmod>2024-05-02T10:30:00+02:00</lastmod>
+    <priority>high</priority>
+  </url>
+  <url><lastmod>2024-05-03</lastmod></url>
+</urlset>`))
+	require.NoError(t, err)
+	require.Equal(t, []SitemapURL{
+		{Loc: "https://example.com/", LastMod: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), ChangeFreq: "daily", Priority: 0.8},
+		{Loc: "https://example.com/about", LastMod: time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)},
+	}, utcSitemapURLs(sitemap.URLs))
+	require.Empty(t, sitemap.Sitemaps)
+
+	// Sitemap index, gzipped
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-posts.xml</loc><lastmod>2024-05-01T12:00Z</lastmod></sitemap>
+</sitemapindex>`))
+	writer.Close()
+	sitemap, err = ParseSitemap(buf.Bytes())
+	require.NoError(t, err)
+	require.Empty(t, sitemap.URLs)
+	require.Equal(t, []SitemapURL{
+		{Loc: "https://example.com/sitemap-posts.xml", LastMod: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	}, utcSitemapURLs(sitemap.Sitemaps))
+
+	// Text sitemap
+	sitemap, err = ParseSitemap([]byte("https://example.com/a\n\n# comment\nhttp://example.com/b\n"))
+	require.NoError(t, err)
+	require.Equal(t, []SitemapURL{{Loc: "https://example.com/a"}, {Loc: "http://example.com/b"}}, sitemap.URLs)
+
+	// Invalid sitemaps
+	_, err = ParseSitemap([]byte(`<html><body>Not found</body></html>`))
+	require.ErrorContains(t, err, "unexpected root element")
+	_, err = ParseSitemap([]byte(`<urlset><url>`))
+	require.Error(t, err)
+}
+
+func utcSitemapURLs(urls []SitemapURL) []SitemapURL {
+	for i := range urls {
+		urls[i].LastMod = urls[i].LastMod.UTC()
+	}
+	return urls
+}
@@ -0,0 +1,171 @@
+package web
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// layoutElements are the elements placed on their own lines by the stable
+// renderer, in addition to blockElements.
+var layoutElements = map[string]bool{
+	"html": true, "head": true, "body": true, "title": true, "meta": true,
+	"link": true, "base": true, "script": true, "style": true,
+	"noscript": true, "template": true, "form": true, "fieldset": true,
+	"legend": true, "hr": true, "thead": true, "tbody": true, "tfoot": true,
+	"caption": true, "colgroup": true, "td": true, "th": true,
+	"details": true, "summary": true, "dialog": true, "menu": true,
+	"hgroup": true, "search": true, "textarea": true,
+}
+
+// opaqueElements are rendered as is on their own line, as their content is
+// whitespace sensitive or not HTML.
+var opaqueElements = map[string]bool{
+	"pre": true, "textarea": true, "script": true, "style": true,
+	"title": true, "meta": true, "link": true, "base": true, "hr": true,
+}
+
+// StableHTML parses the input HTML string and renders it deterministically.
+// See RenderOptions.Stable.
+func StableHTML(input string) (string, error) {
+	doc, err := NewDocument(input)
+	if err != nil {
+		return "", err
+	}
+	return doc.Render(RenderOptions{Stable: true})
+}
+
+// renderStable minifies a tree, sorts its attributes and class names, and
+// renders it with block elements on their own indented lines. Unlike
+// FormatHTML, the output depends only on the tree, and inline content is
+// never reflowed.
+func renderStable(root *html.Node) (string, error) {
+	minifyNode(root)
+	sortAttributes(root)
+	var buf bytes.Buffer
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.DoctypeNode {
+			if err := html.Render(&buf, c); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := renderStableChildren(&buf, root, 0); err != nil {
+		return "", err
+	}
+	return strings.TrimLeft(buf.String(), "\n") + "\n", nil
+}
+
+// renderStableChildren renders the children of a node, each layout element
+// on its own line and consecutive inline content on a shared line.
+func renderStableChildren(buf *bytes.Buffer, n *html.Node, depth int) error {
+	var inline bytes.Buffer
+	flush := func() {
+		if text := strings.TrimSpace(inline.String()); text != "" {
+			newLine(buf, depth)
+			buf.WriteString(text)
+		}
+		inline.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.DoctypeNode:
+		case c.Type != html.ElementNode || !isLayoutElement(c):
+			if err := html.Render(&inline, c); err != nil {
+				return err
+			}
+		default:
+			flush()
+			if err := renderStableElement(buf, c, depth); err != nil {
+				return err
+			}
+		}
+	}
+	flush()
+	return nil
+}
+
+// renderStableElement renders a layout element from a new line. Elements
+// without layout children are rendered on a single line.
+func renderStableElement(buf *bytes.Buffer, n *html.Node, depth int) error {
+	newLine(buf, depth)
+	if opaqueElements[n.Data] {
+		return html.Render(buf, n)
+	}
+	buf.WriteByte('<')
+	buf.WriteString(n.Data)
+	for _, attr := range n.Attr {
+		buf.WriteByte(' ')
+		if attr.Namespace != "" {
+			buf.WriteString(attr.Namespace)
+			buf.WriteByte(':')
+		}
+		buf.WriteString(attr.Key)
+		buf.WriteString(`="`)
+		buf.WriteString(html.EscapeString(attr.Val))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+
+	hasLayout := false
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && isLayoutElement(c) {
+			hasLayout = true
+			break
+		}
+	}
+	if hasLayout {
+		if err := renderStableChildren(buf, n, depth+1); err != nil {
+			return err
+		}
+		newLine(buf, depth)
+	} else {
+		var inline bytes.Buffer
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := html.Render(&inline, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(strings.TrimSpace(inline.String()))
+	}
+	buf.WriteString("</")
+	buf.WriteString(n.Data)
+	buf.WriteByte('>')
+	return nil
+}
+
+func isLayoutElement(n *html.Node) bool {
+	return n.Namespace == "" && (blockElements[n.Data] || layoutElements[n.Data])
+}
+
+func newLine(buf *bytes.Buffer, depth int) {
+	buf.WriteByte('\n')
+	for range depth {
+		buf.WriteString("  ")
+	}
+}
+
+// sortAttributes sorts the attributes of the elements of a tree by name, and
+// the names of their classes, which are unordered.
+func sortAttributes(n *html.Node) {
+	if n.Type == html.ElementNode {
+		for i, attr := range n.Attr {
+			if attr.Key == "class" && attr.Namespace == "" {
+				classes := strings.Fields(attr.Val)
+				slices.Sort(classes)
+				n.Attr[i].Val = strings.Join(slices.Compact(classes), " ")
+			}
+		}
+		slices.SortStableFunc(n.Attr, func(a, b html.Attribute) int {
+			if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Key, b.Key)
+		})
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sortAttributes(c)
+	}
+}
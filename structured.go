@@ -0,0 +1,450 @@
+package web
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// JSONLD returns the objects declared in the JSON-LD script blocks of the
+// document. Top-level arrays and @graph containers are flattened. Blocks that
+// are not valid JSON are skipped.
+func (d *Document) JSONLD() []map[string]any {
+	return memoize(d, "jSONLD", func() []map[string]any {
+		var items []map[string]any
+		d.doc.Find(`script[type="application/ld+json"]`).Each(func(i int, s *goquery.Selection) {
+			text := strings.TrimSpace(s.Text())
+			text = strings.TrimPrefix(text, "<!--")
+			text = strings.TrimSuffix(text, "-->")
+			text = strings.TrimPrefix(strings.TrimSpace(text), "//<![CDATA[")
+			text = strings.TrimSuffix(strings.TrimSpace(text), "//]]>")
+			var value any
+			if err := json.Unmarshal([]byte(text), &value); err != nil {
+				return
+			}
+			items = append(items, flattenJSONLD(value)...)
+		})
+		return items
+	})
+}
+
+func flattenJSONLD(value any) []map[string]any {
+	switch v := value.(type) {
+	case []any:
+		var items []map[string]any
+		for _, item := range v {
+			items = append(items, flattenJSONLD(item)...)
+		}
+		return items
+	case map[string]any:
+		if graph, ok := v["@graph"]; ok {
+			return flattenJSONLD(graph)
+		}
+		return []map[string]any{v}
+	}
+	return nil
+}
+
+// Microdata returns the top-level microdata items of the document, converted
+// to the same shape as JSON-LD objects: the item type is stored in "@type"
+// and properties map to strings or nested items. Properties that occur more
+// than once are collected into a slice.
+func (d *Document) Microdata() []map[string]any {
+	return memoize(d, "microdata", func() []map[string]any {
+		var items []map[string]any
+		d.doc.Find("[itemscope]").Each(func(i int, s *goquery.Selection) {
+			if _, isProp := s.Attr("itemprop"); isProp {
+				return
+			}
+			items = append(items, microdataItem(s))
+		})
+		return items
+	})
+}
+
+func microdataItem(s *goquery.Selection) map[string]any {
+	item := map[string]any{}
+	if itemType := strings.TrimSpace(s.AttrOr("itemtype", "")); itemType != "" {
+		item["@type"] = itemType
+	}
+	if id := strings.TrimSpace(s.AttrOr("itemid", "")); id != "" {
+		item["@id"] = id
+	}
+	var visit func(sel *goquery.Selection)
+	visit = func(sel *goquery.Selection) {
+		sel.Children().Each(func(i int, child *goquery.Selection) {
+			props := strings.Fields(child.AttrOr("itemprop", ""))
+			_, scoped := child.Attr("itemscope")
+			if len(props) > 0 {
+				var value any
+				if scoped {
+					value = microdataItem(child)
+				} else {
+					value = microdataValue(child)
+				}
+				for _, prop := range props {
+					switch existing := item[prop].(type) {
+					case nil:
+						item[prop] = value
+					case []any:
+						item[prop] = append(existing, value)
+					default:
+						item[prop] = []any{existing, value}
+					}
+				}
+			}
+			if !scoped {
+				visit(child)
+			}
+		})
+	}
+	visit(s)
+	return item
+}
+
+func microdataValue(s *goquery.Selection) string {
+	if content, ok := s.Attr("content"); ok {
+		return strings.TrimSpace(content)
+	}
+	switch goquery.NodeName(s) {
+	case "a", "link", "area":
+		return strings.TrimSpace(s.AttrOr("href", ""))
+	case "img", "audio", "video", "source", "embed", "iframe", "track":
+		return strings.TrimSpace(s.AttrOr("src", ""))
+	case "object":
+		return strings.TrimSpace(s.AttrOr("data", ""))
+	case "time":
+		if dt, ok := s.Attr("datetime"); ok {
+			return strings.TrimSpace(dt)
+		}
+	case "data", "meter":
+		if v, ok := s.Attr("value"); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return NormalizeText(strings.Join(strings.Fields(s.Text()), " "))
+}
+
+// RDFa returns the top-level RDFa items of the document, i.e. the elements
+// with a typeof attribute that are not properties of another item, converted
+// to the same shape as JSON-LD objects. Types are prefixed with the vocab in
+// scope, and property names are stored without their prefix, so that
+// "schema:name" maps to "name".
+func (d *Document) RDFa() []map[string]any {
+	return memoize(d, "rdfa", func() []map[string]any {
+		var items []map[string]any
+		d.doc.Find("[typeof]").Each(func(i int, s *goquery.Selection) {
+			if _, isProp := s.Attr("property"); isProp {
+				return
+			}
+			items = append(items, rdfaItem(s))
+		})
+		return items
+	})
+}
+
+func rdfaItem(s *goquery.Selection) map[string]any {
+	item := map[string]any{}
+	vocab := s.Closest("[vocab]").AttrOr("vocab", "")
+	var types []any
+	for _, t := range strings.Fields(s.AttrOr("typeof", "")) {
+		if vocab != "" && !strings.ContainsAny(t, ":/") {
+			t = vocab + t
+		}
+		types = append(types, t)
+	}
+	switch len(types) {
+	case 0:
+	case 1:
+		item["@type"] = types[0]
+	default:
+		item["@type"] = types
+	}
+	for _, attr := range []string{"resource", "about"} {
+		if id := strings.TrimSpace(s.AttrOr(attr, "")); id != "" {
+			item["@id"] = id
+			break
+		}
+	}
+	var visit func(sel *goquery.Selection)
+	visit = func(sel *goquery.Selection) {
+		sel.Children().Each(func(i int, child *goquery.Selection) {
+			props := strings.Fields(child.AttrOr("property", ""))
+			_, typed := child.Attr("typeof")
+			if len(props) > 0 {
+				var value any
+				if typed {
+					value = rdfaItem(child)
+				} else if resource, ok := child.Attr("resource"); ok {
+					value = strings.TrimSpace(resource)
+				} else {
+					value = microdataValue(child)
+				}
+				for _, prop := range props {
+					if idx := strings.LastIndexAny(prop, "/:#"); idx >= 0 {
+						prop = prop[idx+1:]
+					}
+					switch existing := item[prop].(type) {
+					case nil:
+						item[prop] = value
+					case []any:
+						item[prop] = append(existing, value)
+					default:
+						item[prop] = []any{existing, value}
+					}
+				}
+			}
+			if !typed {
+				visit(child)
+			}
+		})
+	}
+	visit(s)
+	return item
+}
+
+// StructuredItems returns all JSON-LD, microdata and RDFa items of the
+// document.
+func (d *Document) StructuredItems() []map[string]any {
+	return memoize(d, "structuredItems", func() []map[string]any {
+		jsonld, microdata, rdfa := d.JSONLD(), d.Microdata(), d.RDFa()
+		if len(jsonld)+len(microdata)+len(rdfa) == 0 {
+			return nil
+		}
+		items := make([]map[string]any, 0, len(jsonld)+len(microdata)+len(rdfa))
+		return append(append(append(items, jsonld...), microdata...), rdfa...)
+	})
+}
+
+// StructuredData holds the schema.org entities of a page decoded into typed
+// values.
+type StructuredData struct {
+	Articles      []*Article        `json:"articles,omitempty"`
+	Products      []*Product        `json:"products,omitempty"`
+	Organizations []*Organization   `json:"organizations,omitempty"`
+	Breadcrumbs   []*BreadcrumbList `json:"breadcrumbs,omitempty"`
+}
+
+// IsEmpty returns true if no entities were found.
+func (s *StructuredData) IsEmpty() bool {
+	return s == nil || len(s.Articles)+len(s.Products)+len(s.Organizations)+len(s.Breadcrumbs) == 0
+}
+
+// StructuredData returns the articles, products, organizations and
+// breadcrumbs described by the JSON-LD, microdata and RDFa of the document,
+// or nil if there are none. Unlike ExtractArticle and ExtractProduct, values
+// are only read from structured data, without meta tag fallbacks.
+func (d *Document) StructuredData() *StructuredData {
+	return memoize(d, "structuredData", func() *StructuredData {
+		items := d.StructuredItems()
+		if len(items) == 0 {
+			return nil
+		}
+		data := &StructuredData{
+			Organizations: ExtractOrganizations(d),
+			Breadcrumbs:   ExtractBreadcrumbs(d),
+		}
+		for _, item := range FindStructuredItems(items, ArticleTypes...) {
+			data.Articles = append(data.Articles, articleFromItem(item))
+		}
+		for _, item := range FindStructuredItems(items, "Product", "ProductGroup") {
+			data.Products = append(data.Products, productFromItem(item))
+		}
+		if data.IsEmpty() {
+			return nil
+		}
+		return data
+	})
+}
+
+// FindStructuredItems searches the items, including nested objects, for items
+// of any of the given schema.org types. Types match regardless of vocabulary
+// prefix, so "Product" matches "https://schema.org/Product".
+func FindStructuredItems(items []map[string]any, types ...string) []map[string]any {
+	var found []map[string]any
+	var visit func(value any, depth int)
+	visit = func(value any, depth int) {
+		if depth > 10 {
+			return
+		}
+		switch v := value.(type) {
+		case []any:
+			for _, item := range v {
+				visit(item, depth+1)
+			}
+		case map[string]any:
+			if hasSchemaType(v, types...) {
+				found = append(found, v)
+				return
+			}
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				if key != "@context" {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				visit(v[key], depth+1)
+			}
+		}
+	}
+	for _, item := range items {
+		visit(item, 0)
+	}
+	return found
+}
+
+// SchemaTypes returns the types of a structured data item without their
+// vocabulary prefix.
+func SchemaTypes(item map[string]any) []string {
+	var types []string
+	for _, t := range schemaStrings(item["@type"]) {
+		if idx := strings.LastIndexAny(t, "/:#"); idx >= 0 {
+			t = t[idx+1:]
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+func hasSchemaType(item map[string]any, types ...string) bool {
+	for _, t := range SchemaTypes(item) {
+		for _, want := range types {
+			if strings.EqualFold(t, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaString returns the text of a structured data value. Objects yield
+// their name, @value or url, and lists yield their first usable entry.
+func schemaString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return NormalizeText(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]any:
+		for _, key := range []string{"name", "@value", "text", "url", "@id"} {
+			if s := schemaString(v[key]); s != "" {
+				return s
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if s := schemaString(item); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// schemaStrings returns the text of each entry of a structured data value.
+func schemaStrings(value any) []string {
+	var values []string
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			if s := schemaString(item); s != "" {
+				values = append(values, s)
+			}
+		}
+	default:
+		if s := schemaString(v); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// schemaURLs returns the URLs of a structured data value, such as an image
+// property given as a string, ImageObject or list of either.
+func schemaURLs(value any) []string {
+	var urls []string
+	switch v := value.(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			urls = append(urls, v)
+		}
+	case map[string]any:
+		for _, key := range []string{"url", "contentUrl", "@id"} {
+			if s, ok := v[key].(string); ok && s != "" {
+				return []string{strings.TrimSpace(s)}
+			}
+		}
+	case []any:
+		for _, item := range v {
+			urls = append(urls, schemaURLs(item)...)
+		}
+	}
+	return urls
+}
+
+// schemaNumber parses a numeric structured data value, tolerating currency
+// symbols and thousands separators such as "$1,299.00".
+func schemaNumber(value any) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case []any:
+		for _, item := range v {
+			if n := schemaNumber(item); n != 0 {
+				return n
+			}
+		}
+	case map[string]any:
+		return schemaNumber(v["@value"])
+	case string:
+		return parseNumber(v)
+	}
+	return 0
+}
+
+func parseNumber(s string) float64 {
+	var b strings.Builder
+	for _, r := range s {
+		if (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			b.WriteRune(r)
+		} else if r == ',' {
+			continue
+		} else if b.Len() > 0 {
+			break
+		}
+	}
+	n, _ := strconv.ParseFloat(b.String(), 64)
+	return n
+}
+
+// schemaEnum returns an enumeration value such as availability without its
+// vocabulary prefix, e.g. "InStock" for "https://schema.org/InStock".
+func schemaEnum(value any) string {
+	s := schemaString(value)
+	if idx := strings.LastIndexAny(s, "/:#"); idx >= 0 {
+		s = s[idx+1:]
+	}
+	return s
+}
+
+// metaContent returns the content of the first meta tag with the given
+// property or name.
+func (d *Document) metaContent(keys ...string) string {
+	for _, key := range keys {
+		for _, attr := range []string{"property", "name"} {
+			if s := d.doc.Find("meta[" + attr + "='" + key + "']").First(); len(s.Nodes) > 0 {
+				if content := strings.TrimSpace(s.AttrOr("content", "")); content != "" {
+					return content
+				}
+			}
+		}
+	}
+	return ""
+}
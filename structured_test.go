@@ -0,0 +1,135 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_JSONLD(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<script type="application/ld+json">[{"@type": "Organization", "name": "Acme"}, {"@type": "WebSite"}]</script>
+			<script type="application/ld+json"><!-- {"@type": "Person", "name": "Jo"} --></script>
+			<script type="application/ld+json">{ invalid </script>
+		</head></html>
+	`)
+	require.NoError(t, err)
+
+	items := doc.JSONLD()
+	require.Len(t, items, 3)
+	require.Equal(t, []string{"Organization"}, SchemaTypes(items[0]))
+	require.Equal(t, "Jo", items[2]["name"])
+}
+
+func TestDocument_Microdata(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><body>
+			<div itemscope itemtype="http://schema.org/Person">
+				<span itemprop="name">Jane Doe</span>
+				<a itemprop="url" href="https://jane.example.com">site</a>
+				<a itemprop="sameAs" href="https://a.example.com">a</a>
+				<a itemprop="sameAs" href="https://b.example.com">b</a>
+				<div itemprop="address" itemscope itemtype="http://schema.org/PostalAddress">
+					<span itemprop="addressLocality">Springfield</span>
+				</div>
+			</div>
+		</body></html>
+	`)
+	require.NoError(t, err)
+
+	items := doc.Microdata()
+	require.Len(t, items, 1)
+	require.Equal(t, "Jane Doe", items[0]["name"])
+	require.Equal(t, "https://jane.example.com", items[0]["url"])
+	require.Equal(t, []any{"https://a.example.com", "https://b.example.com"}, items[0]["sameAs"])
+
+	addresses := FindStructuredItems(items, "PostalAddress")
+	require.Len(t, addresses, 1)
+	require.Equal(t, "Springfield", addresses[0]["addressLocality"])
+}
+
+func TestDocument_RDFa(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><body vocab="https://schema.org/">
+			<div typeof="Person" resource="#jane">
+				<span property="name">Jane Doe</span>
+				<a property="url" href="https://jane.example.com">site</a>
+				<div property="address" typeof="PostalAddress">
+					<span property="schema:addressLocality">Springfield</span>
+				</div>
+			</div>
+		</body></html>
+	`)
+	require.NoError(t, err)
+
+	items := doc.RDFa()
+	require.Len(t, items, 1)
+	require.Equal(t, "https://schema.org/Person", items[0]["@type"])
+	require.Equal(t, "#jane", items[0]["@id"])
+	require.Equal(t, "Jane Doe", items[0]["name"])
+	require.Equal(t, "https://jane.example.com", items[0]["url"])
+
+	addresses := FindStructuredItems(doc.StructuredItems(), "PostalAddress")
+	require.Len(t, addresses, 1)
+	require.Equal(t, "Springfield", addresses[0]["addressLocality"])
+}
+
+func TestDocument_StructuredData(t *testing.T) {
+	doc, err := NewDocument(`
+		<html><head>
+			<script type="application/ld+json">{"@context": "https://schema.org", "@graph": [
+				{"@type": "NewsMediaOrganization", "name": "Daily", "url": "https://daily.example.com",
+				 "logo": {"@type": "ImageObject", "url": "https://daily.example.com/logo.png"},
+				 "sameAs": ["https://x.com/daily", "https://facebook.com/daily"]},
+				{"@type": "NewsArticle", "headline": "Rates rise", "datePublished": "2024-05-01",
+				 "publisher": {"@type": "Organization", "name": "Daily"}},
+				{"@type": "BreadcrumbList", "itemListElement": [
+					{"@type": "ListItem", "position": 2, "name": "Economy", "item": "https://daily.example.com/economy"},
+					{"@type": "ListItem", "position": 1, "item": {"@id": "https://daily.example.com", "name": "Home"}}
+				]}
+			]}</script>
+		</head><body>
+			<div vocab="https://schema.org/" typeof="Product">
+				<span property="name">Widget</span>
+				<div property="offers" typeof="Offer">
+					<meta property="price" content="9.99"><meta property="priceCurrency" content="usd">
+				</div>
+			</div>
+		</body></html>
+	`)
+	require.NoError(t, err)
+
+	data := doc.StructuredData()
+	require.NotNil(t, data)
+	require.Len(t, data.Articles, 1)
+	require.Equal(t, "NewsArticle", data.Articles[0].Type)
+	require.Equal(t, "Rates rise", data.Articles[0].Headline)
+	require.Equal(t, "Daily", data.Articles[0].Publisher)
+
+	// Nested organizations, such as publishers, are not listed
+	require.Equal(t, []*Organization{{
+		Type:   "NewsMediaOrganization",
+		Name:   "Daily",
+		URL:    "https://daily.example.com",
+		Logo:   "https://daily.example.com/logo.png",
+		SameAs: []string{"https://x.com/daily", "https://facebook.com/daily"},
+	}}, data.Organizations)
+
+	require.Equal(t, []*BreadcrumbList{{Items: []*Breadcrumb{
+		{Position: 1, Name: "Home", URL: "https://daily.example.com"},
+		{Position: 2, Name: "Economy", URL: "https://daily.example.com/economy"},
+	}}}, data.Breadcrumbs)
+
+	require.Len(t, data.Products, 1)
+	require.Equal(t, "Widget", data.Products[0].Name)
+	require.Equal(t, 9.99, data.Products[0].Price)
+	require.Equal(t, "USD", data.Products[0].Currency)
+
+	require.Equal(t, data, doc.Metadata().StructuredData)
+
+	doc, err = NewDocument(`<html><head><script type="application/ld+json">{"@type": "WebSite"}</script></head></html>`)
+	require.NoError(t, err)
+	require.Nil(t, doc.StructuredData())
+	require.Nil(t, doc.Metadata().StructuredData)
+}
@@ -0,0 +1,248 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxTableSpan bounds the colspan and rowspan of table cells, as browsers do.
+const maxTableSpan = 1000
+
+// Table is the content of a table element as rows of plain text cells. Cells
+// spanning several columns or rows are repeated in each of them, so that all
+// rows have one cell per column.
+type Table struct {
+	Caption string     `json:"caption,omitempty"`
+	Headers []string   `json:"headers,omitempty"`
+	Rows    [][]string `json:"rows"`
+}
+
+// Tables returns the tables of the document, in document order. Nested tables
+// are returned separately from the tables containing them. Header cells are
+// taken from the thead rows, or from the first row when it only holds th
+// cells. Layout tables with role="presentation" and tables without cells are
+// skipped.
+func (d *Document) Tables() []*Table {
+	return memoize(d, "tables", func() []*Table {
+		var tables []*Table
+		for _, node := range d.doc.Find("table").Nodes {
+			role := strings.ToLower(nodeAttr(node, "role"))
+			if role == "presentation" || role == "none" {
+				continue
+			}
+			if table := parseTable(node); table != nil {
+				tables = append(tables, table)
+			}
+		}
+		return tables
+	})
+}
+
+// tableRow is a row of a table and whether it is a header row.
+type tableRow struct {
+	node   *html.Node
+	header bool
+}
+
+// parseTable returns the content of a table element, or nil if it has no
+// cells.
+func parseTable(node *html.Node) *Table {
+	table := &Table{}
+	var rows []tableRow
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.Data {
+		case "caption":
+			table.Caption = cellText(c)
+		case "tr":
+			rows = append(rows, tableRow{node: c})
+		case "thead", "tbody", "tfoot":
+			for r := c.FirstChild; r != nil; r = r.NextSibling {
+				if r.Type == html.ElementNode && r.Data == "tr" {
+					rows = append(rows, tableRow{node: r, header: c.Data == "thead"})
+				}
+			}
+		}
+	}
+
+	// Place the cells in a grid, skipping the positions taken by the cells
+	// spanning rows from previous rows
+	grid := make([][]string, len(rows))
+	taken := make([][]bool, len(rows))
+	width := 0
+	for i, row := range rows {
+		col := 0
+		for cell := row.node.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+				continue
+			}
+			for col < len(taken[i]) && taken[i][col] {
+				col++
+			}
+			text := cellText(cell)
+			colspan := tableSpan(cell, "colspan")
+			rowspan := min(tableSpan(cell, "rowspan"), len(rows)-i)
+			for r := i; r < i+rowspan; r++ {
+				for c := col; c < col+colspan; c++ {
+					for len(grid[r]) <= c {
+						grid[r] = append(grid[r], "")
+						taken[r] = append(taken[r], false)
+					}
+					grid[r][c] = text
+					taken[r][c] = true
+				}
+			}
+			col += colspan
+			width = max(width, col)
+		}
+	}
+	if width == 0 {
+		return nil
+	}
+	for i := range grid {
+		for len(grid[i]) < width {
+			grid[i] = append(grid[i], "")
+		}
+	}
+
+	// Header rows are those of thead, or the first row if it only has th
+	// cells
+	headerRows := 0
+	for headerRows < len(rows) && rows[headerRows].header {
+		headerRows++
+	}
+	if headerRows == 0 && len(rows) > 1 && onlyHeaderCells(rows[0].node) {
+		headerRows = 1
+	}
+	if headerRows > 0 {
+		table.Headers = make([]string, width)
+		for col := range width {
+			var parts []string
+			for _, row := range grid[:headerRows] {
+				if text := row[col]; text != "" && (len(parts) == 0 || parts[len(parts)-1] != text) {
+					parts = append(parts, text)
+				}
+			}
+			table.Headers[col] = strings.Join(parts, " / ")
+		}
+	}
+	table.Rows = grid[headerRows:]
+	return table
+}
+
+// cellText returns the plain text of a table cell.
+func cellText(node *html.Node) string {
+	r := textRenderer{blockSeparator: " ", lineBreak: " ", collapse: true}
+	return r.render(node)
+}
+
+// tableSpan returns the colspan or rowspan of a cell, between 1 and
+// maxTableSpan.
+func tableSpan(node *html.Node, name string) int {
+	span, err := strconv.Atoi(strings.TrimSpace(nodeAttr(node, name)))
+	if err != nil || span < 1 {
+		return 1
+	}
+	return min(span, maxTableSpan)
+}
+
+// nodeAttr returns the value of an attribute of a node, or an empty string.
+func nodeAttr(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// onlyHeaderCells returns true if all the cells of a row are th cells.
+func onlyHeaderCells(row *html.Node) bool {
+	cells := 0
+	for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+		if cell.Type != html.ElementNode {
+			continue
+		}
+		if cell.Data == "td" {
+			return false
+		}
+		if cell.Data == "th" {
+			cells++
+		}
+	}
+	return cells > 0
+}
+
+// Columns returns the names of the columns of the table: its headers, with
+// "column N" for columns without a header and a " (N)" suffix for repeated
+// headers.
+func (t *Table) Columns() []string {
+	width := len(t.Headers)
+	for _, row := range t.Rows {
+		width = max(width, len(row))
+	}
+	columns := make([]string, width)
+	seen := map[string]int{}
+	for i := range columns {
+		name := ""
+		if i < len(t.Headers) {
+			name = t.Headers[i]
+		}
+		if name == "" {
+			name = fmt.Sprintf("column %d", i+1)
+		}
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s (%d)", name, n)
+		}
+		columns[i] = name
+	}
+	return columns
+}
+
+// Records returns the rows of the table as maps of column names to values.
+// See Columns.
+func (t *Table) Records() []map[string]string {
+	columns := t.Columns()
+	records := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		record := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if i < len(row) {
+				record[column] = row[i]
+			} else {
+				record[column] = ""
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// WriteCSV writes the table as CSV, starting with its headers if any.
+func (t *Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if len(t.Headers) > 0 {
+		if err := writer.Write(t.Headers); err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// JSON returns the rows of the table as a JSON array of objects keyed by
+// column name. See Records.
+func (t *Table) JSON() ([]byte, error) {
+	return json.Marshal(t.Records())
+}
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_Tables(t *testing.T) {
+	doc, err := NewDocument(`<html><body>
+	<table>
+		<caption>Quarterly <b>sales</b></caption>
+		<thead>
+			<tr><th rowspan="2">Region</th><th colspan="2">Sales</th></tr>
+			<tr><th>Q1</th><th>Q2</th></tr>
+		</thead>
+		<tbody>
+			<tr><td rowspan="2"><a href="/eu">Europe</a></td><td>10</td><td>12</td></tr>
+			<tr><td>11</td><td>
+				<span>13</span>
+			</td></tr>
+			<tr><td colspan="3">Total: <em>46</em></td></tr>
+		</tbody>
+	</table>
+	<table role="presentation"><tr><td>Layout</td></tr></table>
+	<table>
+		<tr><th>Name</th><th>Details</th></tr>
+		<tr><td>Outer</td><td><table><tr><td>Inner</td><td>Cell</td></tr></table></td></tr>
+	</table>
+	<table></table>
+	</body></html>`)
+	require.NoError(t, err)
+
+	tables := doc.Tables()
+	require.Len(t, tables, 3)
+
+	require.Equal(t, &Table{
+		Caption: "Quarterly sales",
+		Headers: []string{"Region", "Sales / Q1", "Sales / Q2"},
+		Rows: [][]string{
+			{"Europe", "10", "12"},
+			{"Europe", "11", "13"},
+			{"Total: 46", "Total: 46", "Total: 46"},
+		},
+	}, tables[0])
+
+	require.Equal(t, []string{"Name", "Details"}, tables[1].Headers)
+	require.Equal(t, [][]string{{"Outer", "Inner Cell"}}, tables[1].Rows)
+	require.Equal(t, &Table{Rows: [][]string{{"Inner", "Cell"}}}, tables[2])
+}
+
+func TestTable_Serialization(t *testing.T) {
+	table := &Table{
+		Headers: []string{"Name", "", "Name"},
+		Rows: [][]string{
+			{"Ada", "1815", "Lovelace"},
+			{"Alan, M.", "1912", `"Turing"`},
+		},
+	}
+	require.Equal(t, []string{"Name", "column 2", "Name (2)"}, table.Columns())
+
+	var buf bytes.Buffer
+	require.NoError(t, table.WriteCSV(&buf))
+	require.Equal(t, "Name,,Name\nAda,1815,Lovelace\n\"Alan, M.\",1912,\"\"\"Turing\"\"\"\n", buf.String())
+
+	data, err := table.JSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"Name": "Ada", "column 2": "1815", "Name (2)": "Lovelace"},
+		{"Name": "Alan, M.", "column 2": "1912", "Name (2)": "\"Turing\""}
+	]`, string(data))
+}
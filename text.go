@@ -0,0 +1,179 @@
+package web
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// TextOptions configures the plain text returned by Document.Text.
+type TextOptions struct {
+	// BlockSeparator separates blocks such as paragraphs, headings and list
+	// items. Defaults to a blank line.
+	BlockSeparator string
+
+	// LineBreak replaces br elements. Defaults to a newline.
+	LineBreak string
+
+	// KeepWhitespace keeps the whitespace of text as found in the HTML,
+	// instead of collapsing runs of whitespace within blocks into a single
+	// space. Blocks are trimmed either way.
+	KeepWhitespace bool
+
+	// ExcludeTags are selectors of elements whose text is left out, in
+	// addition to those of ExcludeProfile.
+	ExcludeTags []string
+
+	// ExcludeProfile names the set of selectors to exclude. See
+	// ExcludeProfiles. OnlyMainContent uses the standard profile unless
+	// another one is set.
+	ExcludeProfile string
+
+	// KeepTags are selectors of the profile or of ExcludeTags that are not
+	// excluded.
+	KeepTags []string
+
+	// OnlyMainContent limits the text to the main content of the page, as
+	// returned by MainContentText.
+	OnlyMainContent bool
+}
+
+// Text returns the plain text of the document, e.g. to feed pages to
+// language models or search indexes without converting them to Markdown.
+// Scripts, styles and the head of the document are always left out. The
+// whitespace of pre elements is kept. An error is returned if the exclude
+// profile is unknown.
+func (d *Document) Text(opts TextOptions) (string, error) {
+	excludeTags, err := RenderOptions{
+		ExcludeTags:     opts.ExcludeTags,
+		ExcludeProfile:  opts.ExcludeProfile,
+		KeepTags:        opts.KeepTags,
+		OnlyMainContent: opts.OnlyMainContent,
+	}.ExcludeSelectors()
+	if err != nil {
+		return "", err
+	}
+	// Elements are only removed from a copy of the tree
+	content := d.doc.Selection
+	if opts.OnlyMainContent {
+		content = d.mainContent().Clone()
+	} else if len(excludeTags) > 0 {
+		content = d.Clone().doc.Selection
+	}
+	for _, tag := range excludeTags {
+		content.Find(tag).Remove()
+	}
+
+	r := textRenderer{
+		blockSeparator: opts.BlockSeparator,
+		lineBreak:      opts.LineBreak,
+		collapse:       !opts.KeepWhitespace,
+		keepPre:        true,
+	}
+	if r.blockSeparator == "" {
+		r.blockSeparator = "\n\n"
+	}
+	if r.lineBreak == "" {
+		r.lineBreak = "\n"
+	}
+	var parts []string
+	for _, node := range content.Nodes {
+		if text := r.render(node); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, r.blockSeparator), nil
+}
+
+// textRenderer renders the text of HTML nodes as blocks of plain text.
+type textRenderer struct {
+	blockSeparator string
+	lineBreak      string
+	collapse       bool // collapse whitespace within blocks
+	keepPre        bool // keep the whitespace of pre elements
+}
+
+// render returns the text of a node, with its blocks joined by the block
+// separator.
+func (r textRenderer) render(root *html.Node) string {
+	var blocks, lines []string
+	var current strings.Builder
+	breakLine := func() {
+		line := current.String()
+		current.Reset()
+		if r.collapse {
+			line = NormalizeText(strings.Join(strings.Fields(line), " "))
+			if line == "" {
+				return
+			}
+		}
+		lines = append(lines, line)
+	}
+	flush := func() {
+		breakLine()
+		block := strings.Join(lines, r.lineBreak)
+		lines = lines[:0]
+		if strings.TrimSpace(block) != "" {
+			blocks = append(blocks, strings.TrimSpace(block))
+		}
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			current.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "script", "style", "template", "head":
+				return
+			case "br":
+				breakLine()
+				return
+			case "pre":
+				if r.keepPre {
+					flush()
+					if text := strings.Trim(preText(n), "\n"); strings.TrimSpace(text) != "" {
+						blocks = append(blocks, text)
+					}
+					return
+				}
+			}
+		}
+		block := n.Type == html.ElementNode && blockElements[n.Data]
+		if block {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			flush()
+		} else if n.Type == html.ElementNode && (n.Data == "td" || n.Data == "th") {
+			current.WriteString(" ")
+		}
+	}
+	walk(root)
+	flush()
+	return strings.Join(blocks, r.blockSeparator)
+}
+
+// preText returns the text of a preformatted element as is, with br elements
+// rendered as newlines.
+func preText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.Data == "br":
+			sb.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
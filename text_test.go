@@ -0,0 +1,83 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const textTestHTML = `<html>
+<head><title>Ignored</title><style>p { color: red; }</style></head>
+<body>
+	<nav><a href="/">Home</a> <a href="/blog">Blog</a></nav>
+	<article>
+		<h1>The   Title</h1>
+		<p>First   paragraph with
+		a line break.<br>Second line.</p>
+		<ul><li>One</li><li>Two</li></ul>
+		<pre>func main() {
+	fmt.Println("hi")
+}</pre>
+		<aside class="ad">Buy now</aside>
+		<script>alert("x")</script>
+	</article>
+	<footer>Copyright</footer>
+</body>
+</html>`
+
+func TestDocument_Text(t *testing.T) {
+	doc, err := NewDocument(textTestHTML)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		opts     TextOptions
+		expected string
+	}{
+		{
+			name: "defaults",
+			expected: "Home Blog\n\nThe Title\n\nFirst paragraph with a line break.\nSecond line.\n\nOne\n\nTwo\n\n" +
+				"func main() {\n\tfmt.Println(\"hi\")\n}\n\nBuy now\n\nCopyright",
+		},
+		{
+			name: "main content",
+			opts: TextOptions{OnlyMainContent: true, ExcludeTags: []string{".ad"}},
+			expected: "The Title\n\nFirst paragraph with a line break.\nSecond line.\n\nOne\n\nTwo\n\n" +
+				"func main() {\n\tfmt.Println(\"hi\")\n}",
+		},
+		{
+			name: "separators",
+			opts: TextOptions{
+				BlockSeparator: "\n",
+				LineBreak:      " ",
+				ExcludeTags:    []string{"nav", "footer", "pre", "aside"},
+			},
+			expected: "The Title\nFirst paragraph with a line break. Second line.\nOne\nTwo",
+		},
+		{
+			name: "keep whitespace",
+			opts: TextOptions{
+				KeepWhitespace: true,
+				ExcludeProfile: ExcludeProfileStandard,
+				ExcludeTags:    []string{"article > *:not(h1)"},
+			},
+			expected: "The   Title",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := doc.Text(tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, text)
+		})
+	}
+
+	// The document is not modified
+	text, err := doc.Text(TextOptions{})
+	require.NoError(t, err)
+	require.Contains(t, text, "Home Blog")
+	require.Contains(t, text, "Buy now")
+
+	_, err = doc.Text(TextOptions{ExcludeProfile: "unknown"})
+	require.Error(t, err)
+}
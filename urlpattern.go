@@ -0,0 +1,180 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// URLPattern is a template of URLs with named parameters, such as
+// "https://example.com/products/{slug}". A parameter matches one path segment,
+// or part of one as in "/p/{id}.html", while a parameter ending in "..." such
+// as "/docs/{path...}" matches the rest of the path. Parameters in the host
+// match one label, as in "https://{shop}.example.com/".
+//
+// Patterns starting with "/" match the path of URLs on any host. Matching
+// ignores the scheme, query and fragment of URLs and an optional trailing
+// slash, and hosts are matched case-insensitively.
+type URLPattern struct {
+	pattern string
+	host    string // empty for path patterns
+	path    string
+	params  []string
+	regexp  *regexp.Regexp
+}
+
+// urlPatternParam matches the parameters of a pattern.
+var urlPatternParam = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(\.\.\.)?\}`)
+
+// NewURLPattern parses a URL pattern.
+func NewURLPattern(pattern string) (*URLPattern, error) {
+	p := &URLPattern{pattern: pattern}
+	rest := pattern
+	if i := strings.Index(rest, "://"); i >= 0 {
+		rest = rest[i+3:]
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		host, path, _ := strings.Cut(rest, "/")
+		if host == "" {
+			return nil, fmt.Errorf("invalid url pattern %q: missing host", pattern)
+		}
+		p.host, rest = strings.ToLower(host), "/"+path
+	}
+	p.path = strings.TrimSuffix(rest, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	seen := map[string]bool{}
+	compile := func(s string, inHost bool) error {
+		last := 0
+		for _, m := range urlPatternParam.FindAllStringSubmatchIndex(s, -1) {
+			expr.WriteString(regexp.QuoteMeta(s[last:m[0]]))
+			name, rest := s[m[2]:m[3]], m[4] >= 0
+			if seen[name] {
+				return fmt.Errorf("invalid url pattern %q: duplicate parameter %q", pattern, name)
+			}
+			seen[name] = true
+			p.params = append(p.params, name)
+			switch {
+			case inHost && rest:
+				return fmt.Errorf("invalid url pattern %q: parameter %q can't match the rest of the host", pattern, name)
+			case inHost:
+				expr.WriteString(`([^./]+)`)
+			case rest:
+				expr.WriteString(`(.+)`)
+			default:
+				expr.WriteString(`([^/]+)`)
+			}
+			last = m[1]
+		}
+		expr.WriteString(regexp.QuoteMeta(s[last:]))
+		return nil
+	}
+	if p.host != "" {
+		if err := compile(p.host, true); err != nil {
+			return nil, err
+		}
+	}
+	if err := compile(p.path, false); err != nil {
+		return nil, err
+	}
+	expr.WriteString("/?$")
+	compiled, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid url pattern %q: %w", pattern, err)
+	}
+	p.regexp = compiled
+	return p, nil
+}
+
+// MustURLPattern is like NewURLPattern but panics if the pattern is invalid.
+func MustURLPattern(pattern string) *URLPattern {
+	p, err := NewURLPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the pattern.
+func (p *URLPattern) String() string {
+	return p.pattern
+}
+
+// Params returns the names of the parameters of the pattern, in order.
+func (p *URLPattern) Params() []string {
+	return p.params
+}
+
+// Match returns the parameters of the URL if it matches the pattern.
+// Parameter values are unescaped.
+func (p *URLPattern) Match(rawURL string) (map[string]string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, false
+	}
+	value := u.EscapedPath()
+	if p.host != "" {
+		value = strings.ToLower(u.Host) + value
+	}
+	m := p.regexp.FindStringSubmatch(value)
+	if m == nil {
+		return nil, false
+	}
+	params := make(map[string]string, len(p.params))
+	for i, name := range p.params {
+		param, err := url.PathUnescape(m[i+1])
+		if err != nil {
+			return nil, false
+		}
+		params[name] = param
+	}
+	return params, true
+}
+
+// Matches returns true if the URL matches the pattern.
+func (p *URLPattern) Matches(rawURL string) bool {
+	_, ok := p.Match(rawURL)
+	return ok
+}
+
+// Generate returns the URL of the pattern with the given parameters, which
+// are escaped as needed. Path patterns generate paths, and URLs are generated
+// with the scheme of the pattern, or https.
+func (p *URLPattern) Generate(params map[string]string) (string, error) {
+	var err error
+	replace := func(s string) string {
+		return urlPatternParam.ReplaceAllStringFunc(s, func(param string) string {
+			m := urlPatternParam.FindStringSubmatch(param)
+			value, ok := params[m[1]]
+			if !ok || value == "" {
+				err = fmt.Errorf("missing url pattern parameter %q", m[1])
+				return ""
+			}
+			if m[2] == "" {
+				return url.PathEscape(value)
+			}
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			return strings.Join(segments, "/")
+		})
+	}
+	path, host := replace(p.path), replace(p.host)
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		path = "/"
+	}
+	if p.host == "" {
+		return path, nil
+	}
+	scheme := "https"
+	if i := strings.Index(p.pattern, "://"); i > 0 {
+		scheme = p.pattern[:i]
+	}
+	return scheme + "://" + host + path, nil
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLPattern_Match(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		url      string
+		expected map[string]string
+	}{
+		{"https://example.com/products/{slug}", "https://example.com/products/blue-shirt", map[string]string{"slug": "blue-shirt"}},
+		{"https://example.com/products/{slug}", "http://EXAMPLE.com/products/blue-shirt/?ref=home#top", map[string]string{"slug": "blue-shirt"}},
+		{"https://example.com/products/{slug}", "https://example.com/products/a/b", nil},
+		{"https://example.com/products/{slug}", "https://example.com/products", nil},
+		{"https://example.com/products/{slug}", "https://other.com/products/a", nil},
+		{"/p/{id}.html", "https://any.com/p/42.html", map[string]string{"id": "42"}},
+		{"/p/{id}.html", "https://any.com/p/42.htm", nil},
+		{"/docs/{path...}", "https://example.com/docs/guide/install", map[string]string{"path": "guide/install"}},
+		{"/{category}/{id}", "https://example.com/caf%C3%A9/7", map[string]string{"category": "café", "id": "7"}},
+		{"https://{shop}.example.com/", "https://acme.example.com", map[string]string{"shop": "acme"}},
+		{"https://{shop}.example.com/", "https://a.b.example.com/", nil},
+		{"https://example.com", "https://example.com/", map[string]string{}},
+	}
+	for _, tt := range tests {
+		pattern, err := NewURLPattern(tt.pattern)
+		require.NoError(t, err)
+		params, ok := pattern.Match(tt.url)
+		require.Equal(t, tt.expected != nil, ok, "%s %s", tt.pattern, tt.url)
+		if ok {
+			require.Equal(t, tt.expected, params, "%s %s", tt.pattern, tt.url)
+		}
+	}
+}
+
+func TestURLPattern_Generate(t *testing.T) {
+	pattern := MustURLPattern("https://example.com/products/{slug}")
+	require.Equal(t, []string{"slug"}, pattern.Params())
+	generated, err := pattern.Generate(map[string]string{"slug": "blue shirt"})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/products/blue%20shirt", generated)
+	require.True(t, pattern.Matches(generated))
+
+	_, err = pattern.Generate(nil)
+	require.ErrorContains(t, err, `missing url pattern parameter "slug"`)
+
+	generated, err = MustURLPattern("/docs/{path...}").Generate(map[string]string{"path": "a b/c"})
+	require.NoError(t, err)
+	require.Equal(t, "/docs/a%20b/c", generated)
+
+	generated, err = MustURLPattern("http://{shop}.example.com").Generate(map[string]string{"shop": "acme"})
+	require.NoError(t, err)
+	require.Equal(t, "http://acme.example.com/", generated)
+}
+
+func TestNewURLPattern_Invalid(t *testing.T) {
+	for _, pattern := range []string{
+		"https:///path",
+		"/{id}/{id}",
+		"https://{host...}/",
+	} {
+		_, err := NewURLPattern(pattern)
+		require.Error(t, err, pattern)
+	}
+}
@@ -1,27 +1,156 @@
 package web
 
 import (
+	"bytes"
+	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
-func ReadFileItems(filename string) ([]string, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
+// ReadItemsOptions defines the options of ReadItems.
+type ReadItemsOptions struct {
+	// URLs validates and normalizes each item as an http or https URL: the
+	// https scheme is added if missing, the scheme and host are lowercased
+	// and the fragment is removed. Invalid items are reported as ItemErrors.
+	URLs bool
+
+	// KeepDuplicates keeps repeated items, which are otherwise dropped.
+	KeepDuplicates bool
+}
+
+// ItemError is an invalid line of a file read by ReadItems.
+type ItemError struct {
+	File string
+	Line int // 1-based
+	Item string
+	Err  error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// ItemErrors are the invalid lines of the files read by ReadItems.
+type ItemErrors []*ItemError
+
+func (e ItemErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
 	}
-	var items []string
-	for _, line := range strings.Split(string(data), "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
+	return fmt.Sprintf("%s (and %d more invalid lines)", e[0].Error(), len(e)-1)
+}
+
+// ReadItems reads the items of files, one per line, in order. Blank lines
+// and lines starting with # are skipped, and a leading byte order mark is
+// removed. Patterns are file names or globs, e.g. "seeds/*.txt"; a glob that
+// matches no files is an error. When items are invalid, the valid items are
+// returned along with an ItemErrors error.
+func ReadItems(opts ReadItemsOptions, patterns ...string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			files = append(files, pattern)
 			continue
 		}
-		items = append(items, line)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", pattern)
+		}
+		files = append(files, matches...)
+	}
+	var items []string
+	var itemErrors ItemErrors
+	seen := map[string]bool{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimPrefix(data, []byte("\ufeff"))
+		for i, line := range strings.Split(string(data), "\n") {
+			item := strings.TrimSpace(line)
+			if item == "" || strings.HasPrefix(item, "#") {
+				continue
+			}
+			if opts.URLs {
+				normalized, err := normalizeItemURL(item)
+				if err != nil {
+					itemErrors = append(itemErrors, &ItemError{File: file, Line: i + 1, Item: item, Err: err})
+					continue
+				}
+				item = normalized
+			}
+			if !opts.KeepDuplicates {
+				if seen[item] {
+					continue
+				}
+				seen[item] = true
+			}
+			items = append(items, item)
+		}
+	}
+	if len(itemErrors) > 0 {
+		return items, itemErrors
 	}
 	return items, nil
 }
 
+// ReadFileItems reads the distinct items of files, one per line. See
+// ReadItems.
+func ReadFileItems(patterns ...string) ([]string, error) {
+	return ReadItems(ReadItemsOptions{}, patterns...)
+}
+
+// normalizeItemURL validates an http or https URL, adding the https scheme
+// if missing.
+func normalizeItemURL(value string) (string, error) {
+	if !strings.Contains(value, "://") {
+		value = "https://" + value
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q", value)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid host in url %q", value)
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// ResolveBase returns the URL that the relative URLs of a page resolve
+// against: the href of its base element, resolved against the page URL, or
+// the page URL if the page has no base element or its href is invalid.
+func ResolveBase(pageURL, baseHref string) string {
+	if baseHref == "" {
+		return pageURL
+	}
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL
+	}
+	base, err := page.Parse(baseHref)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return pageURL
+	}
+	base.Fragment = ""
+	return base.String()
+}
+
 func ResolveLink(domain, value string) (string, bool) {
 	// Parse the input URL
 	parsedURL, err := url.Parse(value)
@@ -0,0 +1,46 @@
+package web
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadItems(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	first := write("a.txt", "\ufeffhttps://example.com/a\r\n# comment\n\n  example.com/b  \nhttps://example.com/a\n")
+	write("b.txt", "https://EXAMPLE.com/c#top\nftp://example.com/d\nhttps://example.com/b\n")
+
+	// Plain items
+	items, err := ReadFileItems(first)
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/a", "example.com/b"}, items)
+
+	items, err = ReadItems(ReadItemsOptions{KeepDuplicates: true}, first)
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/a", "example.com/b", "https://example.com/a"}, items)
+
+	// URLs from a glob, with the invalid lines reported
+	items, err = ReadItems(ReadItemsOptions{URLs: true}, filepath.Join(dir, "*.txt"))
+	require.Equal(t, []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}, items)
+	var itemErrors ItemErrors
+	require.True(t, errors.As(err, &itemErrors))
+	require.Len(t, itemErrors, 1)
+	require.Equal(t, filepath.Join(dir, "b.txt"), itemErrors[0].File)
+	require.Equal(t, 2, itemErrors[0].Line)
+	require.Equal(t, "ftp://example.com/d", itemErrors[0].Item)
+
+	// Missing files
+	_, err = ReadFileItems(filepath.Join(dir, "missing.txt"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = ReadFileItems(filepath.Join(dir, "*.csv"))
+	require.ErrorContains(t, err, "no files match")
+}